@@ -39,6 +39,7 @@ type Client struct {
 	Remote      *RemoteConfig
 	Transport   string
 	Certificate string
+	SigningKeys string
 
 	Http            http.Client
 	websocketDialer websocket.Dialer
@@ -170,6 +171,18 @@ func NewClient(config *Config, remote string) (*Client, error) {
 			info.ServerPEMCert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
 		}
 	}
+
+	// Read the keys the remote must sign its images with (if any)
+	serverKeysPath := config.ServerKeysPath(remote)
+	if shared.PathExists(serverKeysPath) {
+		keys, err := ioutil.ReadFile(serverKeysPath)
+		if err != nil {
+			return nil, err
+		}
+
+		info.ServerSigningKeys = string(keys)
+	}
+
 	c, err := NewClientFromInfo(info)
 	if err != nil {
 		return nil, err
@@ -201,6 +214,9 @@ type ConnectInfo struct {
 	// connecting to. It can be the empty string if we do not know the
 	// server's certificate yet.
 	ServerPEMCert string
+	// ServerSigningKeys are the GPG or minisign public keys the images
+	// of a simplestreams server must be signed with.
+	ServerSigningKeys string
 }
 
 func connectViaUnix(c *Client, remote *RemoteConfig) error {
@@ -313,6 +329,15 @@ func NewClientFromInfo(info ConnectInfo) (*Client, error) {
 
 		ss := simplestreams.NewClient(c.Remote.Addr, c.Http, version.UserAgent)
 		c.simplestreams = ss
+
+		if info.ServerSigningKeys != "" {
+			err = ss.SetKeys(info.ServerSigningKeys)
+			if err != nil {
+				return nil, err
+			}
+
+			c.SigningKeys = info.ServerSigningKeys
+		}
 	}
 
 	return c, nil
@@ -461,18 +486,22 @@ func (c *Client) url(elem ...string) string {
 	// Assemble the final URL
 	uri := c.BaseURL + "/" + path
 
-	// Aliases may contain a trailing slash
-	if strings.HasPrefix(path, "1.0/images/aliases") {
-		return uri
+	// Aliases and file paths may contain a trailing slash, nothing else
+	// should
+	if !strings.HasPrefix(path, "1.0/images/aliases") && !strings.Contains(path, "?") {
+		uri = strings.TrimSuffix(uri, "/")
 	}
 
-	// File paths may contain a trailing slash
-	if strings.Contains(path, "?") {
-		return uri
+	// Target the project of the remote, if any
+	if c.Remote != nil && c.Remote.Project != "" && c.Remote.Project != "default" {
+		if strings.Contains(uri, "?") {
+			uri = fmt.Sprintf("%s&project=%s", uri, c.Remote.Project)
+		} else {
+			uri = fmt.Sprintf("%s?project=%s", uri, c.Remote.Project)
+		}
 	}
 
-	// Nothing else should contain a trailing slash
-	return strings.TrimSuffix(uri, "/")
+	return uri
 }
 
 func (c *Client) GetServerConfig() (*api.Response, error) {
@@ -594,6 +623,10 @@ func (c *Client) CopyImage(image string, dest *Client, copy_aliases bool, aliase
 		"certificate": c.Certificate,
 		"fingerprint": image}
 
+	if c.SigningKeys != "" {
+		source["signing_keys"] = c.SigningKeys
+	}
+
 	target := c.GetAlias(image)
 	if target != "" {
 		image = target
@@ -1289,6 +1322,11 @@ func (c *Client) GetAlias(alias string) string {
 // Init creates a container from either a fingerprint or an alias; you must
 // provide at least one.
 func (c *Client) Init(name string, imgremote string, image string, profiles *[]string, config map[string]string, devices map[string]map[string]string, ephem bool) (*api.Response, error) {
+	return c.InitTarget(name, imgremote, image, profiles, config, devices, ephem, "")
+}
+
+// InitTarget creates a new container on the given cluster member.
+func (c *Client) InitTarget(name string, imgremote string, image string, profiles *[]string, config map[string]string, devices map[string]map[string]string, ephem bool, target string) (*api.Response, error) {
 	if c.Remote.Public {
 		return nil, fmt.Errorf("This function isn't supported by public remotes.")
 	}
@@ -1359,6 +1397,10 @@ func (c *Client) Init(name string, imgremote string, image string, profiles *[]s
 		source["protocol"] = tmpremote.Remote.Protocol
 		source["certificate"] = tmpremote.Certificate
 		source["fingerprint"] = image
+
+		if tmpremote.SigningKeys != "" {
+			source["signing_keys"] = tmpremote.SigningKeys
+		}
 	} else {
 		fingerprint := c.GetAlias(image)
 		if fingerprint == "" {
@@ -1398,6 +1440,11 @@ func (c *Client) Init(name string, imgremote string, image string, profiles *[]s
 		body["ephemeral"] = ephem
 	}
 
+	path := "containers"
+	if target != "" {
+		path = fmt.Sprintf("containers?target=%s", target)
+	}
+
 	var resp *api.Response
 
 	if imgremote != c.Name {
@@ -1410,7 +1457,7 @@ func (c *Client) Init(name string, imgremote string, image string, profiles *[]s
 		for _, addr := range addresses {
 			body["source"].(shared.Jmap)["server"] = "https://" + addr
 
-			resp, err = c.post("containers", body, api.AsyncResponse)
+			resp, err = c.post(path, body, api.AsyncResponse)
 			if err != nil {
 				continue
 			}
@@ -1418,7 +1465,7 @@ func (c *Client) Init(name string, imgremote string, image string, profiles *[]s
 			break
 		}
 	} else {
-		resp, err = c.post("containers", body, api.AsyncResponse)
+		resp, err = c.post(path, body, api.AsyncResponse)
 	}
 
 	if err != nil {
@@ -1686,6 +1733,25 @@ func (c *Client) ServerStatus() (*api.Server, error) {
 	return &ss, nil
 }
 
+// /1.0/resources
+func (c *Client) ServerResources() (*api.Resources, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get("resources")
+	if err != nil {
+		return nil, err
+	}
+
+	resources := api.Resources{}
+	if err := resp.MetadataAsStruct(&resources); err != nil {
+		return nil, err
+	}
+
+	return &resources, nil
+}
+
 func (c *Client) ContainerInfo(name string) (*api.Container, error) {
 	if c.Remote.Public {
 		return nil, fmt.Errorf("This function isn't supported by public remotes.")
@@ -1763,7 +1829,7 @@ func (c *Client) PushFile(container string, p string, gid int, uid int, mode str
 	}
 
 	query := url.Values{"path": []string{p}}
-	uri := c.url(version.APIVersion, "containers", container, "files") + "?" + query.Encode()
+	uri := c.url(version.APIVersion, "containers", container, "files?"+query.Encode())
 
 	req, err := http.NewRequest("POST", uri, buf)
 	if err != nil {
@@ -1797,7 +1863,7 @@ func (c *Client) Mkdir(container string, p string, mode os.FileMode, uid int, gi
 	}
 
 	query := url.Values{"path": []string{p}}
-	uri := c.url(version.APIVersion, "containers", container, "files") + "?" + query.Encode()
+	uri := c.url(version.APIVersion, "containers", container, "files?"+query.Encode())
 
 	req, err := http.NewRequest("POST", uri, nil)
 	if err != nil {
@@ -1908,10 +1974,10 @@ func (c *Client) PullFile(container string, p string) (int64, int64, int, string
 		return 0, 0, 0, "", nil, nil, fmt.Errorf("This function isn't supported by public remotes.")
 	}
 
-	uri := c.url(version.APIVersion, "containers", container, "files")
 	query := url.Values{"path": []string{p}}
+	uri := c.url(version.APIVersion, "containers", container, "files?"+query.Encode())
 
-	r, err := c.getRaw(uri + "?" + query.Encode())
+	r, err := c.getRaw(uri)
 	if err != nil {
 		return 0, 0, 0, "", nil, nil, err
 	}
@@ -2335,6 +2401,188 @@ func (c *Client) SnapshotInfo(snapName string) (*api.ContainerSnapshot, error) {
 	return &result, nil
 }
 
+func (c *Client) ContainerBackupCreate(container string, name string, containerOnly bool) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := api.ContainerBackupsPost{Name: name, ContainerOnly: containerOnly}
+	return c.post(fmt.Sprintf("containers/%s/backups", container), body, api.AsyncResponse)
+}
+
+func (c *Client) ContainerBackupDelete(container string, name string) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	return c.delete(fmt.Sprintf("containers/%s/backups/%s", container, name), nil, api.AsyncResponse)
+}
+
+func (c *Client) ContainerBackupExport(container string, name string, target io.Writer) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	uri := c.url(version.APIVersion, "containers", container, "backups", name, "export")
+	raw, err := c.getRaw(uri)
+	if err != nil {
+		return err
+	}
+	defer raw.Body.Close()
+
+	_, err = io.Copy(target, raw.Body)
+	return err
+}
+
+func (c *Client) ContainerBackupImport(backupFile string, progressHandler func(int64, int64)) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	f, err := os.Open(backupFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &ioprogress.ProgressReader{
+		ReadCloser: f,
+		Tracker: &ioprogress.ProgressTracker{
+			Length:  stat.Size(),
+			Handler: progressHandler,
+		},
+	}
+
+	uri := c.url(version.APIVersion, "containers")
+	req, err := http.NewRequest("POST", uri, progress)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", version.UserAgent)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	raw, err := c.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return HoistResponse(raw, api.AsyncResponse)
+}
+
+func (c *Client) ContainerMetadata(container string) (*api.ImageMetadata, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	metadata := api.ImageMetadata{}
+
+	resp, err := c.get(fmt.Sprintf("containers/%s/metadata", container))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resp.MetadataAsStruct(&metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+func (c *Client) UpdateContainerMetadata(container string, metadata api.ImageMetadata) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.put(fmt.Sprintf("containers/%s/metadata", container), metadata, api.SyncResponse)
+	return err
+}
+
+func (c *Client) ContainerTemplateFiles(container string) ([]string, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("containers/%s/metadata/templates", container))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := resp.MetadataAsStringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name, err := url.PathUnescape(path.Base(strings.SplitN(entry, "?", 2)[0]))
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+func (c *Client) ContainerTemplateFile(container string, name string) (io.ReadCloser, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	uri := c.url(version.APIVersion, "containers", container, "metadata", "templates", url.PathEscape(name))
+	raw, err := c.getRaw(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return raw.Body, nil
+}
+
+func (c *Client) setContainerTemplateFile(method string, container string, name string, content io.Reader) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	uri := c.url(version.APIVersion, "containers", container, "metadata", "templates", url.PathEscape(name))
+	req, err := http.NewRequest(method, uri, content)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", version.UserAgent)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	raw, err := c.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = HoistResponse(raw, api.SyncResponse)
+	return err
+}
+
+func (c *Client) CreateContainerTemplateFile(container string, name string, content io.Reader) error {
+	return c.setContainerTemplateFile("POST", container, name, content)
+}
+
+func (c *Client) UpdateContainerTemplateFile(container string, name string, content io.Reader) error {
+	return c.setContainerTemplateFile("PUT", container, name, content)
+}
+
+func (c *Client) DeleteContainerTemplateFile(container string, name string) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.delete(fmt.Sprintf("containers/%s/metadata/templates/%s", container, url.PathEscape(name)), nil, api.SyncResponse)
+	return err
+}
+
 func (c *Client) GetServerConfigString() ([]string, error) {
 	var resp []string
 
@@ -2813,6 +3061,42 @@ func (c *Client) NetworkGet(name string) (api.Network, error) {
 	return network, nil
 }
 
+func (c *Client) NetworkLeases(name string) ([]api.NetworkLease, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("networks/%s/leases", name))
+	if err != nil {
+		return nil, err
+	}
+
+	leases := []api.NetworkLease{}
+	if err := resp.MetadataAsStruct(&leases); err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+func (c *Client) NetworkState(name string) (*api.NetworkState, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("networks/%s/state", name))
+	if err != nil {
+		return nil, err
+	}
+
+	state := api.NetworkState{}
+	if err := resp.MetadataAsStruct(&state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
 func (c *Client) NetworkPut(name string, network api.NetworkPut) error {
 	if c.Remote.Public {
 		return fmt.Errorf("This function isn't supported by public remotes.")
@@ -2849,6 +3133,347 @@ func (c *Client) ListNetworks() ([]api.Network, error) {
 	return networks, nil
 }
 
+// Network reservation functions
+func (c *Client) NetworkReservationCreate(network string, hwaddr string, reservation api.NetworkReservationPut) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := api.NetworkReservationsPost{NetworkReservationPut: reservation, Hwaddr: hwaddr}
+
+	_, err := c.post(fmt.Sprintf("networks/%s/reservations", network), body, api.SyncResponse)
+	return err
+}
+
+func (c *Client) NetworkReservationGet(network string, hwaddr string) (api.NetworkReservation, error) {
+	if c.Remote.Public {
+		return api.NetworkReservation{}, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("networks/%s/reservations/%s", network, hwaddr))
+	if err != nil {
+		return api.NetworkReservation{}, err
+	}
+
+	reservation := api.NetworkReservation{}
+	if err := resp.MetadataAsStruct(&reservation); err != nil {
+		return api.NetworkReservation{}, err
+	}
+
+	return reservation, nil
+}
+
+func (c *Client) NetworkReservationPut(network string, hwaddr string, reservation api.NetworkReservationPut) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.put(fmt.Sprintf("networks/%s/reservations/%s", network, hwaddr), reservation, api.SyncResponse)
+	return err
+}
+
+func (c *Client) NetworkReservationDelete(network string, hwaddr string) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.delete(fmt.Sprintf("networks/%s/reservations/%s", network, hwaddr), nil, api.SyncResponse)
+	return err
+}
+
+func (c *Client) ListNetworkReservations(network string) ([]api.NetworkReservation, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("networks/%s/reservations?recursion=1", network))
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := []api.NetworkReservation{}
+	if err := resp.MetadataAsStruct(&reservations); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// Network DNS record functions
+func (c *Client) NetworkDNSRecordCreate(network string, name string, record api.NetworkDNSRecordPut) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := api.NetworkDNSRecordsPost{NetworkDNSRecordPut: record, Name: name}
+
+	_, err := c.post(fmt.Sprintf("networks/%s/dns-records", network), body, api.SyncResponse)
+	return err
+}
+
+func (c *Client) NetworkDNSRecordGet(network string, name string) (api.NetworkDNSRecord, error) {
+	if c.Remote.Public {
+		return api.NetworkDNSRecord{}, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("networks/%s/dns-records/%s", network, name))
+	if err != nil {
+		return api.NetworkDNSRecord{}, err
+	}
+
+	record := api.NetworkDNSRecord{}
+	if err := resp.MetadataAsStruct(&record); err != nil {
+		return api.NetworkDNSRecord{}, err
+	}
+
+	return record, nil
+}
+
+func (c *Client) NetworkDNSRecordPut(network string, name string, record api.NetworkDNSRecordPut) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.put(fmt.Sprintf("networks/%s/dns-records/%s", network, name), record, api.SyncResponse)
+	return err
+}
+
+func (c *Client) NetworkDNSRecordDelete(network string, name string) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.delete(fmt.Sprintf("networks/%s/dns-records/%s", network, name), nil, api.SyncResponse)
+	return err
+}
+
+func (c *Client) ListNetworkDNSRecords(network string) ([]api.NetworkDNSRecord, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("networks/%s/dns-records?recursion=1", network))
+	if err != nil {
+		return nil, err
+	}
+
+	records := []api.NetworkDNSRecord{}
+	if err := resp.MetadataAsStruct(&records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Network forward functions
+func (c *Client) NetworkForwardCreate(network string, listenAddress string, forward api.NetworkForwardPut) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := api.NetworkForwardsPost{NetworkForwardPut: forward, ListenAddress: listenAddress}
+
+	_, err := c.post(fmt.Sprintf("networks/%s/forwards", network), body, api.SyncResponse)
+	return err
+}
+
+func (c *Client) NetworkForwardGet(network string, listenAddress string) (api.NetworkForward, error) {
+	if c.Remote.Public {
+		return api.NetworkForward{}, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("networks/%s/forwards/%s", network, listenAddress))
+	if err != nil {
+		return api.NetworkForward{}, err
+	}
+
+	forward := api.NetworkForward{}
+	if err := resp.MetadataAsStruct(&forward); err != nil {
+		return api.NetworkForward{}, err
+	}
+
+	return forward, nil
+}
+
+func (c *Client) NetworkForwardPut(network string, listenAddress string, forward api.NetworkForwardPut) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.put(fmt.Sprintf("networks/%s/forwards/%s", network, listenAddress), forward, api.SyncResponse)
+	return err
+}
+
+func (c *Client) NetworkForwardDelete(network string, listenAddress string) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.delete(fmt.Sprintf("networks/%s/forwards/%s", network, listenAddress), nil, api.SyncResponse)
+	return err
+}
+
+func (c *Client) ListNetworkForwards(network string) ([]api.NetworkForward, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("networks/%s/forwards?recursion=1", network))
+	if err != nil {
+		return nil, err
+	}
+
+	forwards := []api.NetworkForward{}
+	if err := resp.MetadataAsStruct(&forwards); err != nil {
+		return nil, err
+	}
+
+	return forwards, nil
+}
+
+// Network ACL functions
+func (c *Client) NetworkACLCreate(name string, description string) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := shared.Jmap{"name": name, "description": description}
+
+	_, err := c.post("network-acls", body, api.SyncResponse)
+	return err
+}
+
+func (c *Client) NetworkACLGet(name string) (api.NetworkACL, error) {
+	if c.Remote.Public {
+		return api.NetworkACL{}, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("network-acls/%s", name))
+	if err != nil {
+		return api.NetworkACL{}, err
+	}
+
+	acl := api.NetworkACL{}
+	if err := resp.MetadataAsStruct(&acl); err != nil {
+		return api.NetworkACL{}, err
+	}
+
+	return acl, nil
+}
+
+func (c *Client) NetworkACLPut(name string, acl api.NetworkACLPut) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.put(fmt.Sprintf("network-acls/%s", name), acl, api.SyncResponse)
+	return err
+}
+
+func (c *Client) NetworkACLRename(name string, newName string) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := shared.Jmap{"name": newName}
+
+	_, err := c.post(fmt.Sprintf("network-acls/%s", name), body, api.SyncResponse)
+	return err
+}
+
+func (c *Client) NetworkACLDelete(name string) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.delete(fmt.Sprintf("network-acls/%s", name), nil, api.SyncResponse)
+	return err
+}
+
+func (c *Client) ListNetworkACLs() ([]api.NetworkACL, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get("network-acls?recursion=1")
+	if err != nil {
+		return nil, err
+	}
+
+	acls := []api.NetworkACL{}
+	if err := resp.MetadataAsStruct(&acls); err != nil {
+		return nil, err
+	}
+
+	return acls, nil
+}
+
+// Project functions
+func (c *Client) ProjectCreate(name string, description string, config map[string]string) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := shared.Jmap{"name": name, "description": description, "config": config}
+
+	_, err := c.post("projects", body, api.SyncResponse)
+	return err
+}
+
+func (c *Client) ProjectGet(name string) (api.Project, error) {
+	if c.Remote.Public {
+		return api.Project{}, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("projects/%s", name))
+	if err != nil {
+		return api.Project{}, err
+	}
+
+	project := api.Project{}
+	if err := resp.MetadataAsStruct(&project); err != nil {
+		return api.Project{}, err
+	}
+
+	return project, nil
+}
+
+func (c *Client) ProjectPut(name string, project api.ProjectPut) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.put(fmt.Sprintf("projects/%s", name), project, api.SyncResponse)
+	return err
+}
+
+func (c *Client) ProjectDelete(name string) error {
+	if c.Remote.Public {
+		return fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	_, err := c.delete(fmt.Sprintf("projects/%s", name), nil, api.SyncResponse)
+	return err
+}
+
+func (c *Client) ListProjects() ([]api.Project, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get("projects?recursion=1")
+	if err != nil {
+		return nil, err
+	}
+
+	projects := []api.Project{}
+	if err := resp.MetadataAsStruct(&projects); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
 // Storage functions
 func (c *Client) ListStoragePools() ([]api.StoragePool, error) {
 	if c.Remote.Public {
@@ -2906,6 +3531,25 @@ func (c *Client) StoragePoolGet(name string) (api.StoragePool, error) {
 	return pools, nil
 }
 
+// /1.0/storage-pools/{name}/resources
+func (c *Client) StoragePoolResources(name string) (*api.ResourcesStoragePool, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("storage-pools/%s/resources", name))
+	if err != nil {
+		return nil, err
+	}
+
+	resources := api.ResourcesStoragePool{}
+	if err := json.Unmarshal(resp.Metadata, &resources); err != nil {
+		return nil, err
+	}
+
+	return &resources, nil
+}
+
 func (c *Client) StoragePoolPut(name string, pool api.StoragePool) error {
 	if c.Remote.Public {
 		return fmt.Errorf("This function isn't supported by public remotes.")
@@ -2992,3 +3636,113 @@ func (c *Client) StoragePoolVolumeTypeDelete(pool string, volume string, volumeT
 	_, err := c.delete(fmt.Sprintf("storage-pools/%s/volumes/%s/%s", pool, volumeType, volume), nil, api.SyncResponse)
 	return err
 }
+
+// /1.0/storage-pools/{pool}/volumes/custom
+func (c *Client) StoragePoolVolumeCopy(sourcePool string, source string, pool string, name string, volumeOnly bool) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := shared.Jmap{
+		"name": name,
+		"type": "custom",
+		"source": shared.Jmap{
+			"type":        "copy",
+			"name":        source,
+			"pool":        sourcePool,
+			"volume_only": volumeOnly,
+		},
+	}
+
+	return c.post(fmt.Sprintf("storage-pools/%s/volumes/custom", pool), body, api.AsyncResponse)
+}
+
+// /1.0/storage-pools/{pool}/volumes/custom/{name}
+func (c *Client) StoragePoolVolumeMove(pool string, name string, newPool string, newName string) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := shared.Jmap{"name": newName, "pool": newPool}
+	return c.post(fmt.Sprintf("storage-pools/%s/volumes/custom/%s", pool, name), body, api.AsyncResponse)
+}
+
+// /1.0/storage-pools/{pool}/volumes/custom/{name}
+func (c *Client) GetStoragePoolVolumeMigrationSourceWS(pool string, name string, volumeOnly bool) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := shared.Jmap{"migration": true, "volume_only": volumeOnly}
+	return c.post(fmt.Sprintf("storage-pools/%s/volumes/custom/%s", pool, name), body, api.AsyncResponse)
+}
+
+// /1.0/storage-pools/{pool}/volumes/custom
+func (c *Client) StoragePoolVolumeMigrateFrom(pool string, name string, operation string, certificate string, secrets map[string]string, volumeOnly bool) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := shared.Jmap{
+		"name": name,
+		"type": "custom",
+		"source": shared.Jmap{
+			"type":        "migration",
+			"mode":        "pull",
+			"operation":   operation,
+			"certificate": certificate,
+			"secrets":     secrets,
+			"volume_only": volumeOnly,
+		},
+	}
+
+	return c.post(fmt.Sprintf("storage-pools/%s/volumes/custom", pool), body, api.AsyncResponse)
+}
+
+// /1.0/storage-pools/{pool}/volumes/custom/{name}/snapshots
+func (c *Client) StoragePoolVolumeSnapshot(pool string, volume string, snapshotName string) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := shared.Jmap{"name": snapshotName}
+	return c.post(fmt.Sprintf("storage-pools/%s/volumes/custom/%s/snapshots", pool, volume), body, api.AsyncResponse)
+}
+
+// /1.0/storage-pools/{pool}/volumes/custom/{name}/snapshots
+func (c *Client) StoragePoolVolumeSnapshotsList(pool string, volume string) ([]api.StorageVolumeSnapshot, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	resp, err := c.get(fmt.Sprintf("storage-pools/%s/volumes/custom/%s/snapshots?recursion=1", pool, volume))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []api.StorageVolumeSnapshot{}
+	if err := json.Unmarshal(resp.Metadata, &snapshots); err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// /1.0/storage-pools/{pool}/volumes/custom/{name}/snapshots/{snapshot}
+func (c *Client) StoragePoolVolumeSnapshotRename(pool string, volume string, snapshotName string, newName string) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	body := shared.Jmap{"name": newName}
+	return c.post(fmt.Sprintf("storage-pools/%s/volumes/custom/%s/snapshots/%s", pool, volume, snapshotName), body, api.AsyncResponse)
+}
+
+// /1.0/storage-pools/{pool}/volumes/custom/{name}/snapshots/{snapshot}
+func (c *Client) StoragePoolVolumeSnapshotDelete(pool string, volume string, snapshotName string) (*api.Response, error) {
+	if c.Remote.Public {
+		return nil, fmt.Errorf("This function isn't supported by public remotes.")
+	}
+
+	return c.delete(fmt.Sprintf("storage-pools/%s/volumes/custom/%s/snapshots/%s", pool, volume, snapshotName), nil, api.AsyncResponse)
+}
@@ -28,6 +28,9 @@ type ConnectionArgs struct {
 
 	// Custom proxy
 	Proxy func(*http.Request) (*url.URL, error)
+
+	// GPG or minisign public keys the SimpleStreams index and manifests must be signed with
+	SigningKeys string
 }
 
 // ConnectLXD lets you connect to a remote LXD daemon over HTTPs.
@@ -170,5 +173,13 @@ func ConnectSimpleStreams(url string, args *ConnectionArgs) (ImageServer, error)
 	ssClient := simplestreams.NewClient(url, *httpClient, args.UserAgent)
 	server.ssClient = ssClient
 
+	// Only trust signed metadata
+	if args.SigningKeys != "" {
+		err = ssClient.SetKeys(args.SigningKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &server, nil
 }
@@ -34,6 +34,25 @@ type ContainerServer interface {
 	GetServer() (server *api.Server, ETag string, err error)
 	UpdateServer(server api.ServerPut, ETag string) (err error)
 	HasExtension(extension string) bool
+	GetServerResources() (resources *api.Resources, err error)
+
+	// Cluster functions ("clustering" API extension)
+	GetCluster() (cluster *api.Cluster, ETag string, err error)
+	UpdateCluster(cluster api.ClusterPut, ETag string) (err error)
+	GetClusterMemberNames() (names []string, err error)
+	GetClusterMembers() (members []api.ClusterMember, err error)
+	GetClusterMember(name string) (member *api.ClusterMember, ETag string, err error)
+	DeleteClusterMember(name string) (err error)
+	UseTarget(name string) (client ContainerServer)
+
+	// Project functions ("projects" API extension)
+	GetProjectNames() (names []string, err error)
+	GetProjects() (projects []api.Project, err error)
+	GetProject(name string) (project *api.Project, ETag string, err error)
+	CreateProject(project api.ProjectsPost) (err error)
+	UpdateProject(name string, project api.ProjectPut, ETag string) (err error)
+	DeleteProject(name string) (err error)
+	UseProject(name string) (client ContainerServer)
 
 	// Certificate functions
 	GetCertificateFingerprints() (fingerprints []string, err error)
@@ -67,6 +86,15 @@ type ContainerServer interface {
 	MigrateContainerSnapshot(containerName string, name string, container api.ContainerSnapshotPost) (op *Operation, err error)
 	DeleteContainerSnapshot(containerName string, name string) (op *Operation, err error)
 
+	GetContainerBackupNames(containerName string) (names []string, err error)
+	GetContainerBackups(containerName string) (backups []api.ContainerBackup, err error)
+	GetContainerBackup(containerName string, name string) (backup *api.ContainerBackup, ETag string, err error)
+	CreateContainerBackup(containerName string, backup api.ContainerBackupsPost) (op *Operation, err error)
+	RenameContainerBackup(containerName string, name string, backup api.ContainerBackupPost) (op *Operation, err error)
+	DeleteContainerBackup(containerName string, name string) (op *Operation, err error)
+	GetContainerBackupFile(containerName string, name string) (content io.ReadCloser, err error)
+	CreateContainerFromBackup(backup io.Reader) (op *Operation, err error)
+
 	GetContainerState(name string) (state *api.ContainerState, ETag string, err error)
 	UpdateContainerState(name string, state api.ContainerStatePut, ETag string) (op *Operation, err error)
 
@@ -74,6 +102,15 @@ type ContainerServer interface {
 	GetContainerLogfile(name string, filename string) (content io.ReadCloser, err error)
 	DeleteContainerLogfile(name string, filename string) (err error)
 
+	GetContainerMetadata(name string) (metadata *api.ImageMetadata, ETag string, err error)
+	SetContainerMetadata(name string, metadata api.ImageMetadata, ETag string) (err error)
+
+	GetContainerTemplateFiles(containerName string) (templates []string, err error)
+	GetContainerTemplateFile(containerName string, templateName string) (content io.ReadCloser, err error)
+	CreateContainerTemplateFile(containerName string, templateName string, content io.ReadSeeker) (err error)
+	UpdateContainerTemplateFile(containerName string, templateName string, content io.ReadSeeker) (err error)
+	DeleteContainerTemplateFile(name string, templateName string) (err error)
+
 	// Event handling functions
 	GetEvents() (listener *EventListener, err error)
 
@@ -92,11 +129,43 @@ type ContainerServer interface {
 	GetNetworkNames() (names []string, err error)
 	GetNetworks() (networks []api.Network, err error)
 	GetNetwork(name string) (network *api.Network, ETag string, err error)
+	GetNetworkLeases(name string) (leases []api.NetworkLease, err error)
+	GetNetworkState(name string) (state *api.NetworkState, err error)
 	CreateNetwork(network api.NetworksPost) (err error)
 	UpdateNetwork(name string, network api.NetworkPut, ETag string) (err error)
 	RenameNetwork(name string, network api.NetworkPost) (err error)
 	DeleteNetwork(name string) (err error)
 
+	// Network reservation functions ("network_reservations" API extension)
+	GetNetworkReservations(network string) (reservations []api.NetworkReservation, err error)
+	GetNetworkReservation(network string, hwaddr string) (reservation *api.NetworkReservation, ETag string, err error)
+	CreateNetworkReservation(network string, reservation api.NetworkReservationsPost) (err error)
+	UpdateNetworkReservation(network string, hwaddr string, reservation api.NetworkReservationPut, ETag string) (err error)
+	DeleteNetworkReservation(network string, hwaddr string) (err error)
+
+	// Network DNS record functions ("network_dns_records" API extension)
+	GetNetworkDNSRecords(network string) (records []api.NetworkDNSRecord, err error)
+	GetNetworkDNSRecord(network string, name string) (record *api.NetworkDNSRecord, ETag string, err error)
+	CreateNetworkDNSRecord(network string, record api.NetworkDNSRecordsPost) (err error)
+	UpdateNetworkDNSRecord(network string, name string, record api.NetworkDNSRecordPut, ETag string) (err error)
+	DeleteNetworkDNSRecord(network string, name string) (err error)
+
+	// Network forward functions ("network_forwards" API extension)
+	GetNetworkForwards(network string) (forwards []api.NetworkForward, err error)
+	GetNetworkForward(network string, listenAddress string) (forward *api.NetworkForward, ETag string, err error)
+	CreateNetworkForward(network string, forward api.NetworkForwardsPost) (err error)
+	UpdateNetworkForward(network string, listenAddress string, forward api.NetworkForwardPut, ETag string) (err error)
+	DeleteNetworkForward(network string, listenAddress string) (err error)
+
+	// Network ACL functions ("network_acl" API extension)
+	GetNetworkACLNames() (names []string, err error)
+	GetNetworkACLs() (acls []api.NetworkACL, err error)
+	GetNetworkACL(name string) (acl *api.NetworkACL, ETag string, err error)
+	CreateNetworkACL(acl api.NetworkACLsPost) (err error)
+	UpdateNetworkACL(name string, acl api.NetworkACLPut, ETag string) (err error)
+	RenameNetworkACL(name string, acl api.NetworkACLPost) (err error)
+	DeleteNetworkACL(name string) (err error)
+
 	// Operation functions
 	GetOperation(uuid string) (op *api.Operation, ETag string, err error)
 	DeleteOperation(uuid string) (err error)
@@ -115,6 +184,7 @@ type ContainerServer interface {
 	GetStoragePoolNames() (names []string, err error)
 	GetStoragePools() (pools []api.StoragePool, err error)
 	GetStoragePool(name string) (pool *api.StoragePool, ETag string, err error)
+	GetStoragePoolResources(name string) (resources *api.ResourcesStoragePool, err error)
 	CreateStoragePool(pool api.StoragePoolsPost) (err error)
 	UpdateStoragePool(name string, pool api.StoragePoolPut, ETag string) (err error)
 	DeleteStoragePool(name string) (err error)
@@ -127,6 +197,18 @@ type ContainerServer interface {
 	UpdateStoragePoolVolume(pool string, name string, volume api.StorageVolumePut, ETag string) (err error)
 	DeleteStoragePoolVolume(pool string, name string) (err error)
 
+	// Storage volume handling functions ("storage_api_volume_handling" API extension)
+	CopyStoragePoolVolume(pool string, volume api.StorageVolumesPost) (op *Operation, err error)
+	RenameStoragePoolVolume(pool string, name string, volume api.StorageVolumePost) (op *Operation, err error)
+	MigrateStoragePoolVolume(pool string, name string, volume api.StorageVolumePost) (op *Operation, err error)
+
+	GetStoragePoolVolumeSnapshotNames(pool string, volumeName string) (names []string, err error)
+	GetStoragePoolVolumeSnapshots(pool string, volumeName string) (snapshots []api.StorageVolumeSnapshot, err error)
+	GetStoragePoolVolumeSnapshot(pool string, volumeName string, name string) (snapshot *api.StorageVolumeSnapshot, ETag string, err error)
+	CreateStoragePoolVolumeSnapshot(pool string, volumeName string, snapshot api.StorageVolumeSnapshotsPost) (op *Operation, err error)
+	RenameStoragePoolVolumeSnapshot(pool string, volumeName string, name string, snapshot api.StorageVolumeSnapshotPost) (op *Operation, err error)
+	DeleteStoragePoolVolumeSnapshot(pool string, volumeName string, name string) (op *Operation, err error)
+
 	// Internal functions (for internal use)
 	RawQuery(method string, path string, data interface{}, queryETag string) (resp *api.Response, ETag string, err error)
 	RawWebsocket(path string) (conn *websocket.Conn, err error)
@@ -157,6 +239,10 @@ type ImageFileRequest struct {
 
 	// Progress handler (called whenever some progress is made)
 	ProgressHandler func(progress ProgressData)
+
+	// Path retriever for the local files a delta can be applied to
+	// (returns an empty string if the file isn't available)
+	DeltaSourceRetriever func(fingerprint string, file string) string
 }
 
 // The ImageFileResponse struct is used as the response for image downloads
@@ -172,6 +258,10 @@ type ImageFileResponse struct {
 
 	// Size of the rootfs file
 	RootfsSize int64
+
+	// Size of the delta the rootfs file was rebuilt from (0 if it was
+	// downloaded in full)
+	RootfsDeltaSize int64
 }
 
 // The ImageCopyArgs struct is used to pass additional options during image copy
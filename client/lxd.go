@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"sync"
@@ -24,6 +25,9 @@ type ProtocolLXD struct {
 	httpHost        string
 	httpUserAgent   string
 	httpCertificate string
+
+	clusterTarget string
+	project       string
 }
 
 // RawQuery allows directly querying the LXD API
@@ -53,21 +57,34 @@ func (r *ProtocolLXD) rawQuery(method string, url string, data interface{}, ETag
 
 	// Get a new HTTP request setup
 	if data != nil {
-		// Encode the provided data
-		buf := bytes.Buffer{}
-		err := json.NewEncoder(&buf).Encode(data)
-		if err != nil {
-			return nil, "", err
+		// Figure out the encoding and the data
+		var body io.Reader
+		contentType := "application/json"
+
+		reader, ok := data.(io.Reader)
+		if ok {
+			// Raw data was provided
+			body = reader
+			contentType = "application/octet-stream"
+		} else {
+			// Encode the provided data
+			buf := bytes.Buffer{}
+			err := json.NewEncoder(&buf).Encode(data)
+			if err != nil {
+				return nil, "", err
+			}
+
+			body = &buf
 		}
 
 		// Some data to be sent along with the request
-		req, err = http.NewRequest(method, url, &buf)
+		req, err = http.NewRequest(method, url, body)
 		if err != nil {
 			return nil, "", err
 		}
 
 		// Set the encoding accordingly
-		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", contentType)
 	} else {
 		// No data to be sent along with the request
 		req, err = http.NewRequest(method, url, nil)
@@ -120,11 +137,24 @@ func (r *ProtocolLXD) rawQuery(method string, url string, data interface{}, ETag
 
 func (r *ProtocolLXD) query(method string, path string, data interface{}, ETag string) (*api.Response, string, error) {
 	// Generate the URL
-	url := fmt.Sprintf("%s/1.0%s", r.httpHost, path)
+	url := fmt.Sprintf("%s/1.0%s", r.httpHost, r.projectPath(path))
 
 	return r.rawQuery(method, url, data, ETag)
 }
 
+// projectPath adds the project the client is using, if any, to an API path
+func (r *ProtocolLXD) projectPath(path string) string {
+	if r.project == "" || r.project == "default" {
+		return path
+	}
+
+	if strings.Contains(path, "?") {
+		return fmt.Sprintf("%s&project=%s", path, r.project)
+	}
+
+	return fmt.Sprintf("%s?project=%s", path, r.project)
+}
+
 func (r *ProtocolLXD) queryStruct(method string, path string, data interface{}, ETag string, target interface{}) (string, error) {
 	resp, etag, err := r.query(method, path, data, ETag)
 	if err != nil {
@@ -0,0 +1,128 @@
+package lxd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Cluster handling functions
+
+// GetCluster returns information about the cluster this server is part of
+func (r *ProtocolLXD) GetCluster() (*api.Cluster, string, error) {
+	if !r.HasExtension("clustering") {
+		return nil, "", fmt.Errorf("The server is missing the required \"clustering\" API extension")
+	}
+
+	cluster := api.Cluster{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", "/cluster", nil, "", &cluster)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &cluster, etag, nil
+}
+
+// UpdateCluster bootstraps a new cluster or joins an existing one
+func (r *ProtocolLXD) UpdateCluster(cluster api.ClusterPut, ETag string) error {
+	if !r.HasExtension("clustering") {
+		return fmt.Errorf("The server is missing the required \"clustering\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("PUT", "/cluster", cluster, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetClusterMemberNames returns the names of all the cluster members
+func (r *ProtocolLXD) GetClusterMemberNames() ([]string, error) {
+	if !r.HasExtension("clustering") {
+		return nil, fmt.Errorf("The server is missing the required \"clustering\" API extension")
+	}
+
+	urls := []string{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", "/cluster/members", nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it
+	names := []string{}
+	for _, url := range urls {
+		fields := strings.Split(url, "/cluster/members/")
+		names = append(names, fields[len(fields)-1])
+	}
+
+	return names, nil
+}
+
+// GetClusterMembers returns the current members of the cluster
+func (r *ProtocolLXD) GetClusterMembers() ([]api.ClusterMember, error) {
+	if !r.HasExtension("clustering") {
+		return nil, fmt.Errorf("The server is missing the required \"clustering\" API extension")
+	}
+
+	members := []api.ClusterMember{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", "/cluster/members?recursion=1", nil, "", &members)
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// GetClusterMember returns information about the given cluster member
+func (r *ProtocolLXD) GetClusterMember(name string) (*api.ClusterMember, string, error) {
+	if !r.HasExtension("clustering") {
+		return nil, "", fmt.Errorf("The server is missing the required \"clustering\" API extension")
+	}
+
+	member := api.ClusterMember{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/cluster/members/%s", name), nil, "", &member)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &member, etag, nil
+}
+
+// DeleteClusterMember removes a member from the cluster
+func (r *ProtocolLXD) DeleteClusterMember(name string) error {
+	if !r.HasExtension("clustering") {
+		return fmt.Errorf("The server is missing the required \"clustering\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/cluster/members/%s", name), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UseTarget returns a client creating new containers on the given cluster member
+func (r *ProtocolLXD) UseTarget(name string) ContainerServer {
+	return &ProtocolLXD{
+		server:          r.server,
+		http:            r.http,
+		httpHost:        r.httpHost,
+		httpUserAgent:   r.httpUserAgent,
+		httpCertificate: r.httpCertificate,
+		clusterTarget:   name,
+		project:         r.project,
+	}
+}
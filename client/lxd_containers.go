@@ -29,7 +29,7 @@ func (r *ProtocolLXD) GetContainerNames() ([]string, error) {
 	names := []string{}
 	for _, url := range urls {
 		fields := strings.Split(url, "/containers/")
-		names = append(names, fields[len(fields)-1])
+		names = append(names, strings.SplitN(fields[len(fields)-1], "?", 2)[0])
 	}
 
 	return names, nil
@@ -69,8 +69,17 @@ func (r *ProtocolLXD) CreateContainer(container api.ContainersPost) (*Operation,
 		}
 	}
 
+	path := "/containers"
+	if r.clusterTarget != "" {
+		if !r.HasExtension("clustering") {
+			return nil, fmt.Errorf("The server is missing the required \"clustering\" API extension")
+		}
+
+		path = fmt.Sprintf("/containers?target=%s", r.clusterTarget)
+	}
+
 	// Send the request
-	op, _, err := r.queryOperation("POST", "/containers", container, "")
+	op, _, err := r.queryOperation("POST", path, container, "")
 	if err != nil {
 		return nil, err
 	}
@@ -252,7 +261,7 @@ func (r *ProtocolLXD) ExecContainer(containerName string, exec api.ContainerExec
 // GetContainerFile retrieves the provided path from the container
 func (r *ProtocolLXD) GetContainerFile(containerName string, path string) (io.ReadCloser, *ContainerFileResponse, error) {
 	// Prepare the HTTP request
-	url := fmt.Sprintf("%s/1.0/containers/%s/files?path=%s", r.httpHost, containerName, path)
+	url := fmt.Sprintf("%s/1.0%s", r.httpHost, r.projectPath(fmt.Sprintf("/containers/%s/files?path=%s", containerName, path)))
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, nil, err
@@ -323,7 +332,7 @@ func (r *ProtocolLXD) CreateContainerFile(containerName string, path string, arg
 	}
 
 	// Prepare the HTTP request
-	url := fmt.Sprintf("%s/1.0/containers/%s/files?path=%s", r.httpHost, containerName, path)
+	url := fmt.Sprintf("%s/1.0%s", r.httpHost, r.projectPath(fmt.Sprintf("/containers/%s/files?path=%s", containerName, path)))
 	req, err := http.NewRequest("POST", url, args.Content)
 	if err != nil {
 		return err
@@ -390,7 +399,7 @@ func (r *ProtocolLXD) GetContainerSnapshotNames(containerName string) ([]string,
 	names := []string{}
 	for _, url := range urls {
 		fields := strings.Split(url, fmt.Sprintf("/containers/%s/snapshots/", containerName))
-		names = append(names, fields[len(fields)-1])
+		names = append(names, strings.SplitN(fields[len(fields)-1], "?", 2)[0])
 	}
 
 	return names, nil
@@ -476,6 +485,160 @@ func (r *ProtocolLXD) DeleteContainerSnapshot(containerName string, name string)
 	return op, nil
 }
 
+// GetContainerBackupNames returns a list of backup names for the container
+func (r *ProtocolLXD) GetContainerBackupNames(containerName string) ([]string, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	urls := []string{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/backups", containerName), nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it
+	names := []string{}
+	for _, url := range urls {
+		fields := strings.Split(url, fmt.Sprintf("/containers/%s/backups/", containerName))
+		names = append(names, strings.SplitN(fields[len(fields)-1], "?", 2)[0])
+	}
+
+	return names, nil
+}
+
+// GetContainerBackups returns a list of backups for the container
+func (r *ProtocolLXD) GetContainerBackups(containerName string) ([]api.ContainerBackup, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	backups := []api.ContainerBackup{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/backups?recursion=1", containerName), nil, "", &backups)
+	if err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
+// GetContainerBackup returns a Backup struct for the provided container and backup names
+func (r *ProtocolLXD) GetContainerBackup(containerName string, name string) (*api.ContainerBackup, string, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, "", fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	backup := api.ContainerBackup{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/backups/%s", containerName, name), nil, "", &backup)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &backup, etag, nil
+}
+
+// CreateContainerBackup requests that LXD creates a new backup for the container
+func (r *ProtocolLXD) CreateContainerBackup(containerName string, backup api.ContainerBackupsPost) (*Operation, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/containers/%s/backups", containerName), backup, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// RenameContainerBackup requests that LXD renames the backup
+func (r *ProtocolLXD) RenameContainerBackup(containerName string, name string, backup api.ContainerBackupPost) (*Operation, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/containers/%s/backups/%s", containerName, name), backup, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// DeleteContainerBackup requests that LXD deletes the container backup
+func (r *ProtocolLXD) DeleteContainerBackup(containerName string, name string) (*Operation, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("DELETE", fmt.Sprintf("/containers/%s/backups/%s", containerName, name), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// GetContainerBackupFile returns the content of the backup tarball
+//
+// Note that it's the caller's responsibility to close the returned ReadCloser
+func (r *ProtocolLXD) GetContainerBackupFile(containerName string, name string) (io.ReadCloser, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Prepare the HTTP request
+	url := fmt.Sprintf("%s/1.0%s", r.httpHost, r.projectPath(fmt.Sprintf("/containers/%s/backups/%s/export", containerName, name)))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the user agent
+	if r.httpUserAgent != "" {
+		req.Header.Set("User-Agent", r.httpUserAgent)
+	}
+
+	// Send the request
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Failed to fetch %s: %s", url, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// CreateContainerFromBackup is a convenience function to make it easier to
+// create a container from a backup tarball
+func (r *ProtocolLXD) CreateContainerFromBackup(backup io.Reader) (*Operation, error) {
+	if !r.HasExtension("container_backup") {
+		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", "/containers", backup, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
 // GetContainerState returns a ContainerState entry for the provided container name
 func (r *ProtocolLXD) GetContainerState(name string) (*api.ContainerState, string, error) {
 	state := api.ContainerState{}
@@ -514,7 +677,7 @@ func (r *ProtocolLXD) GetContainerLogfiles(name string) ([]string, error) {
 	logfiles := []string{}
 	for _, url := range logfiles {
 		fields := strings.Split(url, fmt.Sprintf("/containers/%s/logs/", name))
-		logfiles = append(logfiles, fields[len(fields)-1])
+		logfiles = append(logfiles, strings.SplitN(fields[len(fields)-1], "?", 2)[0])
 	}
 
 	return logfiles, nil
@@ -525,7 +688,7 @@ func (r *ProtocolLXD) GetContainerLogfiles(name string) ([]string, error) {
 // Note that it's the caller's responsibility to close the returned ReadCloser
 func (r *ProtocolLXD) GetContainerLogfile(name string, filename string) (io.ReadCloser, error) {
 	// Prepare the HTTP request
-	url := fmt.Sprintf("%s/1.0/containers/%s/logs/%s", r.httpHost, name, filename)
+	url := fmt.Sprintf("%s/1.0%s", r.httpHost, r.projectPath(fmt.Sprintf("/containers/%s/logs/%s", name, filename)))
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -560,3 +723,133 @@ func (r *ProtocolLXD) DeleteContainerLogfile(name string, filename string) error
 
 	return nil
 }
+
+// GetContainerMetadata returns the metadata.yaml of the container
+func (r *ProtocolLXD) GetContainerMetadata(name string) (*api.ImageMetadata, string, error) {
+	if !r.HasExtension("container_edit_metadata") {
+		return nil, "", fmt.Errorf("The server is missing the required \"container_edit_metadata\" API extension")
+	}
+
+	metadata := api.ImageMetadata{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/metadata", name), nil, "", &metadata)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &metadata, etag, nil
+}
+
+// SetContainerMetadata replaces the metadata.yaml of the container
+func (r *ProtocolLXD) SetContainerMetadata(name string, metadata api.ImageMetadata, ETag string) error {
+	if !r.HasExtension("container_edit_metadata") {
+		return fmt.Errorf("The server is missing the required \"container_edit_metadata\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("PUT", fmt.Sprintf("/containers/%s/metadata", name), metadata, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetContainerTemplateFiles returns the list of names of the templates of the container
+func (r *ProtocolLXD) GetContainerTemplateFiles(containerName string) ([]string, error) {
+	if !r.HasExtension("container_edit_metadata") {
+		return nil, fmt.Errorf("The server is missing the required \"container_edit_metadata\" API extension")
+	}
+
+	urls := []string{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/containers/%s/metadata/templates", containerName), nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it
+	templates := []string{}
+	for _, url := range urls {
+		fields := strings.Split(url, fmt.Sprintf("/containers/%s/metadata/templates/", containerName))
+		templates = append(templates, strings.SplitN(fields[len(fields)-1], "?", 2)[0])
+	}
+
+	return templates, nil
+}
+
+// GetContainerTemplateFile returns the content of a template of the container
+//
+// Note that it's the caller's responsibility to close the returned ReadCloser
+func (r *ProtocolLXD) GetContainerTemplateFile(containerName string, templateName string) (io.ReadCloser, error) {
+	if !r.HasExtension("container_edit_metadata") {
+		return nil, fmt.Errorf("The server is missing the required \"container_edit_metadata\" API extension")
+	}
+
+	// Prepare the HTTP request
+	url := fmt.Sprintf("%s/1.0%s", r.httpHost, r.projectPath(fmt.Sprintf("/containers/%s/metadata/templates/%s", containerName, templateName)))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the user agent
+	if r.httpUserAgent != "" {
+		req.Header.Set("User-Agent", r.httpUserAgent)
+	}
+
+	// Send the request
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Failed to fetch %s: %s", url, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// CreateContainerTemplateFile adds a new template to the container
+func (r *ProtocolLXD) CreateContainerTemplateFile(containerName string, templateName string, content io.ReadSeeker) error {
+	return r.setContainerTemplateFile("POST", containerName, templateName, content)
+}
+
+// UpdateContainerTemplateFile replaces the content of a template of the container
+func (r *ProtocolLXD) UpdateContainerTemplateFile(containerName string, templateName string, content io.ReadSeeker) error {
+	return r.setContainerTemplateFile("PUT", containerName, templateName, content)
+}
+
+func (r *ProtocolLXD) setContainerTemplateFile(method string, containerName string, templateName string, content io.ReadSeeker) error {
+	if !r.HasExtension("container_edit_metadata") {
+		return fmt.Errorf("The server is missing the required \"container_edit_metadata\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query(method, fmt.Sprintf("/containers/%s/metadata/templates/%s", containerName, templateName), content, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteContainerTemplateFile removes a template from the container
+func (r *ProtocolLXD) DeleteContainerTemplateFile(name string, templateName string) error {
+	if !r.HasExtension("container_edit_metadata") {
+		return fmt.Errorf("The server is missing the required \"container_edit_metadata\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/containers/%s/metadata/templates/%s", name, templateName), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -42,7 +42,7 @@ func (r *ProtocolLXD) GetImageFingerprints() ([]string, error) {
 	fingerprints := []string{}
 	for _, url := range urls {
 		fields := strings.Split(url, "/images/")
-		fingerprints = append(fingerprints, fields[len(fields)-1])
+		fingerprints = append(fingerprints, strings.SplitN(fields[len(fields)-1], "?", 2)[0])
 	}
 
 	return fingerprints, nil
@@ -88,7 +88,7 @@ func (r *ProtocolLXD) GetPrivateImageFile(fingerprint string, secret string, req
 	resp := ImageFileResponse{}
 
 	// Build the URL
-	url := fmt.Sprintf("%s/1.0/images/%s/export", r.httpHost, fingerprint)
+	url := fmt.Sprintf("%s/1.0%s", r.httpHost, r.projectPath(fmt.Sprintf("/images/%s/export", fingerprint)))
 	if secret != "" {
 		url = fmt.Sprintf("%s?secret=%s", url, secret)
 	}
@@ -242,7 +242,7 @@ func (r *ProtocolLXD) GetImageAliasNames() ([]string, error) {
 	names := []string{}
 	for _, url := range urls {
 		fields := strings.Split(url, "/images/aliases/")
-		names = append(names, fields[len(fields)-1])
+		names = append(names, strings.SplitN(fields[len(fields)-1], "?", 2)[0])
 	}
 
 	return names, nil
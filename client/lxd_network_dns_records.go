@@ -0,0 +1,86 @@
+package lxd
+
+import (
+	"fmt"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkDNSRecords returns the DNS records of a network
+func (r *ProtocolLXD) GetNetworkDNSRecords(network string) ([]api.NetworkDNSRecord, error) {
+	if !r.HasExtension("network_dns_records") {
+		return nil, fmt.Errorf("The server is missing the required \"network_dns_records\" API extension")
+	}
+
+	records := []api.NetworkDNSRecord{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/dns-records?recursion=1", network), nil, "", &records)
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// GetNetworkDNSRecord returns a NetworkDNSRecord entry for the provided name
+func (r *ProtocolLXD) GetNetworkDNSRecord(network string, name string) (*api.NetworkDNSRecord, string, error) {
+	if !r.HasExtension("network_dns_records") {
+		return nil, "", fmt.Errorf("The server is missing the required \"network_dns_records\" API extension")
+	}
+
+	record := api.NetworkDNSRecord{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/dns-records/%s", network, name), nil, "", &record)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &record, etag, nil
+}
+
+// CreateNetworkDNSRecord defines a new DNS record using the provided NetworkDNSRecord struct
+func (r *ProtocolLXD) CreateNetworkDNSRecord(network string, record api.NetworkDNSRecordsPost) error {
+	if !r.HasExtension("network_dns_records") {
+		return fmt.Errorf("The server is missing the required \"network_dns_records\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("POST", fmt.Sprintf("/networks/%s/dns-records", network), record, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateNetworkDNSRecord updates the DNS record to match the provided NetworkDNSRecord struct
+func (r *ProtocolLXD) UpdateNetworkDNSRecord(network string, name string, record api.NetworkDNSRecordPut, ETag string) error {
+	if !r.HasExtension("network_dns_records") {
+		return fmt.Errorf("The server is missing the required \"network_dns_records\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("PUT", fmt.Sprintf("/networks/%s/dns-records/%s", network, name), record, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteNetworkDNSRecord deletes an existing DNS record
+func (r *ProtocolLXD) DeleteNetworkDNSRecord(network string, name string) error {
+	if !r.HasExtension("network_dns_records") {
+		return fmt.Errorf("The server is missing the required \"network_dns_records\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/networks/%s/dns-records/%s", network, name), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
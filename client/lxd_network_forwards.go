@@ -0,0 +1,86 @@
+package lxd
+
+import (
+	"fmt"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkForwards returns the forwards of a network
+func (r *ProtocolLXD) GetNetworkForwards(network string) ([]api.NetworkForward, error) {
+	if !r.HasExtension("network_forwards") {
+		return nil, fmt.Errorf("The server is missing the required \"network_forwards\" API extension")
+	}
+
+	forwards := []api.NetworkForward{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/forwards?recursion=1", network), nil, "", &forwards)
+	if err != nil {
+		return nil, err
+	}
+
+	return forwards, nil
+}
+
+// GetNetworkForward returns a NetworkForward entry for the provided listen address
+func (r *ProtocolLXD) GetNetworkForward(network string, listenAddress string) (*api.NetworkForward, string, error) {
+	if !r.HasExtension("network_forwards") {
+		return nil, "", fmt.Errorf("The server is missing the required \"network_forwards\" API extension")
+	}
+
+	forward := api.NetworkForward{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/forwards/%s", network, listenAddress), nil, "", &forward)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &forward, etag, nil
+}
+
+// CreateNetworkForward defines a new forward using the provided NetworkForward struct
+func (r *ProtocolLXD) CreateNetworkForward(network string, forward api.NetworkForwardsPost) error {
+	if !r.HasExtension("network_forwards") {
+		return fmt.Errorf("The server is missing the required \"network_forwards\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("POST", fmt.Sprintf("/networks/%s/forwards", network), forward, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateNetworkForward updates the forward to match the provided NetworkForward struct
+func (r *ProtocolLXD) UpdateNetworkForward(network string, listenAddress string, forward api.NetworkForwardPut, ETag string) error {
+	if !r.HasExtension("network_forwards") {
+		return fmt.Errorf("The server is missing the required \"network_forwards\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("PUT", fmt.Sprintf("/networks/%s/forwards/%s", network, listenAddress), forward, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteNetworkForward deletes an existing forward
+func (r *ProtocolLXD) DeleteNetworkForward(network string, listenAddress string) error {
+	if !r.HasExtension("network_forwards") {
+		return fmt.Errorf("The server is missing the required \"network_forwards\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/networks/%s/forwards/%s", network, listenAddress), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package lxd
+
+import (
+	"fmt"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkReservations returns the DHCP reservations of a network
+func (r *ProtocolLXD) GetNetworkReservations(network string) ([]api.NetworkReservation, error) {
+	if !r.HasExtension("network_reservations") {
+		return nil, fmt.Errorf("The server is missing the required \"network_reservations\" API extension")
+	}
+
+	reservations := []api.NetworkReservation{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/reservations?recursion=1", network), nil, "", &reservations)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// GetNetworkReservation returns a NetworkReservation entry for the provided MAC address
+func (r *ProtocolLXD) GetNetworkReservation(network string, hwaddr string) (*api.NetworkReservation, string, error) {
+	if !r.HasExtension("network_reservations") {
+		return nil, "", fmt.Errorf("The server is missing the required \"network_reservations\" API extension")
+	}
+
+	reservation := api.NetworkReservation{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/reservations/%s", network, hwaddr), nil, "", &reservation)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &reservation, etag, nil
+}
+
+// CreateNetworkReservation defines a new DHCP reservation using the provided NetworkReservation struct
+func (r *ProtocolLXD) CreateNetworkReservation(network string, reservation api.NetworkReservationsPost) error {
+	if !r.HasExtension("network_reservations") {
+		return fmt.Errorf("The server is missing the required \"network_reservations\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("POST", fmt.Sprintf("/networks/%s/reservations", network), reservation, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateNetworkReservation updates the DHCP reservation to match the provided NetworkReservation struct
+func (r *ProtocolLXD) UpdateNetworkReservation(network string, hwaddr string, reservation api.NetworkReservationPut, ETag string) error {
+	if !r.HasExtension("network_reservations") {
+		return fmt.Errorf("The server is missing the required \"network_reservations\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("PUT", fmt.Sprintf("/networks/%s/reservations/%s", network, hwaddr), reservation, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteNetworkReservation deletes an existing DHCP reservation
+func (r *ProtocolLXD) DeleteNetworkReservation(network string, hwaddr string) error {
+	if !r.HasExtension("network_reservations") {
+		return fmt.Errorf("The server is missing the required \"network_reservations\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/networks/%s/reservations/%s", network, hwaddr), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
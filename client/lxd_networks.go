@@ -53,6 +53,40 @@ func (r *ProtocolLXD) GetNetwork(name string) (*api.Network, string, error) {
 	return &network, etag, nil
 }
 
+// GetNetworkLeases returns a list of DHCP leases for the network
+func (r *ProtocolLXD) GetNetworkLeases(name string) ([]api.NetworkLease, error) {
+	if !r.HasExtension("network_leases") {
+		return nil, fmt.Errorf("The server is missing the required \"network_leases\" API extension")
+	}
+
+	leases := []api.NetworkLease{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/leases", name), nil, "", &leases)
+	if err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// GetNetworkState returns metrics and information on the running network
+func (r *ProtocolLXD) GetNetworkState(name string) (*api.NetworkState, error) {
+	if !r.HasExtension("network_state") {
+		return nil, fmt.Errorf("The server is missing the required \"network_state\" API extension")
+	}
+
+	state := api.NetworkState{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/state", name), nil, "", &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
 // CreateNetwork defines a new network using the provided Network struct
 func (r *ProtocolLXD) CreateNetwork(network api.NetworksPost) error {
 	if !r.HasExtension("network") {
@@ -59,6 +59,23 @@ func (r *ProtocolLXD) GetStoragePool(name string) (*api.StoragePool, string, err
 	return &pool, etag, nil
 }
 
+// GetStoragePoolResources gets the resources available to a given storage pool
+func (r *ProtocolLXD) GetStoragePoolResources(name string) (*api.ResourcesStoragePool, error) {
+	if !r.HasExtension("resources") {
+		return nil, fmt.Errorf("The server is missing the required \"resources\" API extension")
+	}
+
+	res := api.ResourcesStoragePool{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/storage-pools/%s/resources", name), nil, "", &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
 // CreateStoragePool defines a new storage pool using the provided StoragePool struct
 func (r *ProtocolLXD) CreateStoragePool(pool api.StoragePoolsPost) error {
 	if !r.HasExtension("storage") {
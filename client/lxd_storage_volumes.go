@@ -91,3 +91,169 @@ func (r *ProtocolLXD) DeleteStoragePoolVolume(pool string, name string) error {
 
 	return nil
 }
+
+// CopyStoragePoolVolume creates a new custom volume from a copy or migration
+// source
+func (r *ProtocolLXD) CopyStoragePoolVolume(pool string, volume api.StorageVolumesPost) (*Operation, error) {
+	if !r.HasExtension("storage_api_volume_handling") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_api_volume_handling\" API extension")
+	}
+
+	// Sanity check
+	if volume.Source.Type == "" {
+		return nil, fmt.Errorf("Can't create a storage volume through CopyStoragePoolVolume")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/storage-pools/%s/volumes/custom", pool), volume, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// RenameStoragePoolVolume requests that LXD renames the custom volume or moves
+// it to another storage pool
+func (r *ProtocolLXD) RenameStoragePoolVolume(pool string, name string, volume api.StorageVolumePost) (*Operation, error) {
+	if !r.HasExtension("storage_api_volume_handling") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_api_volume_handling\" API extension")
+	}
+
+	// Sanity check
+	if volume.Migration {
+		return nil, fmt.Errorf("Can't ask for a migration through RenameStoragePoolVolume")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/storage-pools/%s/volumes/custom/%s", pool, name), volume, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// MigrateStoragePoolVolume requests that LXD prepares for a custom volume migration
+func (r *ProtocolLXD) MigrateStoragePoolVolume(pool string, name string, volume api.StorageVolumePost) (*Operation, error) {
+	if !r.HasExtension("storage_api_volume_handling") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_api_volume_handling\" API extension")
+	}
+
+	// Sanity check
+	if !volume.Migration {
+		return nil, fmt.Errorf("Can't ask for a rename through MigrateStoragePoolVolume")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/storage-pools/%s/volumes/custom/%s", pool, name), volume, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// GetStoragePoolVolumeSnapshotNames returns a list of snapshot names for the custom volume
+func (r *ProtocolLXD) GetStoragePoolVolumeSnapshotNames(pool string, volumeName string) ([]string, error) {
+	if !r.HasExtension("storage_api_volume_handling") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_api_volume_handling\" API extension")
+	}
+
+	urls := []string{}
+
+	// Fetch the raw value
+	path := fmt.Sprintf("/storage-pools/%s/volumes/custom/%s/snapshots", pool, volumeName)
+	_, err := r.queryStruct("GET", path, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it
+	names := []string{}
+	for _, url := range urls {
+		fields := strings.Split(url, path+"/")
+		names = append(names, fields[len(fields)-1])
+	}
+
+	return names, nil
+}
+
+// GetStoragePoolVolumeSnapshots returns a list of snapshots for the custom volume
+func (r *ProtocolLXD) GetStoragePoolVolumeSnapshots(pool string, volumeName string) ([]api.StorageVolumeSnapshot, error) {
+	if !r.HasExtension("storage_api_volume_handling") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_api_volume_handling\" API extension")
+	}
+
+	snapshots := []api.StorageVolumeSnapshot{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/storage-pools/%s/volumes/custom/%s/snapshots?recursion=1", pool, volumeName), nil, "", &snapshots)
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// GetStoragePoolVolumeSnapshot returns a snapshot of the custom volume
+func (r *ProtocolLXD) GetStoragePoolVolumeSnapshot(pool string, volumeName string, name string) (*api.StorageVolumeSnapshot, string, error) {
+	if !r.HasExtension("storage_api_volume_handling") {
+		return nil, "", fmt.Errorf("The server is missing the required \"storage_api_volume_handling\" API extension")
+	}
+
+	snapshot := api.StorageVolumeSnapshot{}
+
+	// Fetch the raw value
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/storage-pools/%s/volumes/custom/%s/snapshots/%s", pool, volumeName, name), nil, "", &snapshot)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &snapshot, etag, nil
+}
+
+// CreateStoragePoolVolumeSnapshot requests that LXD creates a new snapshot for the custom volume
+func (r *ProtocolLXD) CreateStoragePoolVolumeSnapshot(pool string, volumeName string, snapshot api.StorageVolumeSnapshotsPost) (*Operation, error) {
+	if !r.HasExtension("storage_api_volume_handling") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_api_volume_handling\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/storage-pools/%s/volumes/custom/%s/snapshots", pool, volumeName), snapshot, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// RenameStoragePoolVolumeSnapshot requests that LXD renames the snapshot of the custom volume
+func (r *ProtocolLXD) RenameStoragePoolVolumeSnapshot(pool string, volumeName string, name string, snapshot api.StorageVolumeSnapshotPost) (*Operation, error) {
+	if !r.HasExtension("storage_api_volume_handling") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_api_volume_handling\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/storage-pools/%s/volumes/custom/%s/snapshots/%s", pool, volumeName, name), snapshot, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// DeleteStoragePoolVolumeSnapshot requests that LXD deletes the snapshot of the custom volume
+func (r *ProtocolLXD) DeleteStoragePoolVolumeSnapshot(pool string, volumeName string, name string) (*Operation, error) {
+	if !r.HasExtension("storage_api_volume_handling") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_api_volume_handling\" API extension")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("DELETE", fmt.Sprintf("/storage-pools/%s/volumes/custom/%s/snapshots/%s", pool, volumeName, name), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
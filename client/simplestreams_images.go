@@ -1,9 +1,17 @@
 package lxd
 
 import (
+	"crypto/sha256"
 	"fmt"
-	"github.com/lxc/lxd/shared/api"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
 	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/simplestreams"
 )
 
 // Image handling functions
@@ -56,22 +64,31 @@ func (r *ProtocolSimpleStreams) GetImageFile(fingerprint string, req ImageFileRe
 	// Prepare the response
 	resp := ImageFileResponse{}
 
-	// Download the LXD image file
-	meta, ok := files["meta"]
-	if ok && req.MetaFile != nil {
+	download := func(path string, filename string, hash string, target io.WriteSeeker) (int64, error) {
 		// Try over http
-		url := fmt.Sprintf("http://%s/%s", strings.TrimPrefix(r.httpHost, "https://"), meta.Path)
+		url := fmt.Sprintf("http://%s/%s", strings.TrimPrefix(r.httpHost, "https://"), path)
 
-		size, err := downloadFileSha256(r.http, r.httpUserAgent, req.ProgressHandler, "metadata", url, meta.Sha256, req.MetaFile)
+		size, err := downloadFileSha256(r.http, r.httpUserAgent, req.ProgressHandler, filename, url, hash, target)
 		if err != nil {
 			// Try over https
-			url = fmt.Sprintf("%s/%s", r.httpHost, meta.Path)
-			size, err = downloadFileSha256(r.http, r.httpUserAgent, req.ProgressHandler, "metadata", url, meta.Sha256, req.MetaFile)
+			url = fmt.Sprintf("%s/%s", r.httpHost, path)
+			size, err = downloadFileSha256(r.http, r.httpUserAgent, req.ProgressHandler, filename, url, hash, target)
 			if err != nil {
-				return nil, err
+				return -1, err
 			}
 		}
 
+		return size, nil
+	}
+
+	// Download the LXD image file
+	meta, ok := files["meta"]
+	if ok && req.MetaFile != nil {
+		size, err := download(meta.Path, "metadata", meta.Sha256, req.MetaFile)
+		if err != nil {
+			return nil, err
+		}
+
 		parts := strings.Split(meta.Path, "/")
 		resp.MetaName = parts[len(parts)-1]
 		resp.MetaSize = size
@@ -80,14 +97,34 @@ func (r *ProtocolSimpleStreams) GetImageFile(fingerprint string, req ImageFileRe
 	// Download the rootfs
 	rootfs, ok := files["root"]
 	if ok && req.RootfsFile != nil {
-		// Try over http
-		url := fmt.Sprintf("http://%s/%s", strings.TrimPrefix(r.httpHost, "https://"), rootfs.Path)
+		// Look for a delta against a local file (requires xdelta3)
+		size := int64(-1)
+		deltaSize := int64(0)
+		_, err := exec.LookPath("xdelta3")
+		if err == nil && req.DeltaSourceRetriever != nil {
+			for name, file := range files {
+				if !strings.HasPrefix(name, "root.delta-") {
+					continue
+				}
 
-		size, err := downloadFileSha256(r.http, r.httpUserAgent, req.ProgressHandler, "rootfs", url, rootfs.Sha256, req.RootfsFile)
-		if err != nil {
-			// Try over https
-			url = fmt.Sprintf("%s/%s", r.httpHost, rootfs.Path)
-			size, err = downloadFileSha256(r.http, r.httpUserAgent, req.ProgressHandler, "rootfs", url, rootfs.Sha256, req.RootfsFile)
+				srcPath := req.DeltaSourceRetriever(strings.TrimPrefix(name, "root.delta-"), "rootfs")
+				if srcPath == "" {
+					continue
+				}
+
+				size, deltaSize, err = r.applyImageDelta(download, file, srcPath, rootfs.Sha256, req.RootfsFile)
+				if err == nil {
+					break
+				}
+
+				// Fallback to the next delta or the full download
+				size = -1
+				deltaSize = 0
+			}
+		}
+
+		if size < 0 {
+			size, err = download(rootfs.Path, "rootfs", rootfs.Sha256, req.RootfsFile)
 			if err != nil {
 				return nil, err
 			}
@@ -96,11 +133,73 @@ func (r *ProtocolSimpleStreams) GetImageFile(fingerprint string, req ImageFileRe
 		parts := strings.Split(rootfs.Path, "/")
 		resp.RootfsName = parts[len(parts)-1]
 		resp.RootfsSize = size
+		resp.RootfsDeltaSize = deltaSize
 	}
 
 	return &resp, nil
 }
 
+// applyImageDelta downloads a delta, applies it to the source file and copies
+// the result to the target once its hash has been verified. It returns the
+// size of the result and of the delta.
+func (r *ProtocolSimpleStreams) applyImageDelta(download func(string, string, string, io.WriteSeeker) (int64, error), delta simplestreams.SimpleStreamsFile, srcPath string, hash string, target io.WriteSeeker) (int64, int64, error) {
+	// Download the delta
+	deltaFile, err := ioutil.TempFile("", "lxd_image_")
+	if err != nil {
+		return -1, -1, err
+	}
+	defer os.Remove(deltaFile.Name())
+	defer deltaFile.Close()
+
+	deltaSize, err := download(delta.Path, "rootfs delta", delta.Sha256, deltaFile)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	// Apply it
+	patchedFile, err := ioutil.TempFile("", "lxd_image_")
+	if err != nil {
+		return -1, -1, err
+	}
+	defer os.Remove(patchedFile.Name())
+	defer patchedFile.Close()
+
+	_, err = shared.RunCommand("xdelta3", "-f", "-d", "-s", srcPath, deltaFile.Name(), patchedFile.Name())
+	if err != nil {
+		return -1, -1, err
+	}
+
+	// Verify the result
+	sha256 := sha256.New()
+	_, err = io.Copy(sha256, patchedFile)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	result := fmt.Sprintf("%x", sha256.Sum(nil))
+	if result != hash {
+		return -1, -1, fmt.Errorf("Hash mismatch after applying the delta: %s != %s", result, hash)
+	}
+
+	// Copy to the target
+	_, err = patchedFile.Seek(0, 0)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	_, err = target.Seek(0, 0)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	size, err := io.Copy(target, patchedFile)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	return size, deltaSize, nil
+}
+
 // GetPrivateImage isn't relevant for the simplestreams protocol
 func (r *ProtocolSimpleStreams) GetPrivateImage(fingerprint string, secret string) (*api.Image, string, error) {
 	return nil, "", fmt.Errorf("Private images aren't supported by the simplestreams protocol")
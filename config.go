@@ -44,6 +44,7 @@ type RemoteConfig struct {
 	Public   bool   `yaml:"public"`
 	Protocol string `yaml:"protocol,omitempty"`
 	Static   bool   `yaml:"-"`
+	Project  string `yaml:"project,omitempty"`
 }
 
 var LocalRemote = RemoteConfig{
@@ -108,6 +109,8 @@ func LoadConfig(path string) (*Config, error) {
 	c.ConfigDir = filepath.Dir(path)
 
 	for k, v := range StaticRemotes {
+		// Keep the project selected on static remotes
+		v.Project = c.Remotes[k].Project
 		c.Remotes[k] = v
 	}
 
@@ -125,6 +128,11 @@ func LoadConfig(path string) (*Config, error) {
 // SaveConfig writes the provided configuration to the config file.
 func SaveConfig(c *Config, fname string) error {
 	for k := range StaticRemotes {
+		// Static remotes are only saved to remember their project
+		if c.Remotes[k].Project != "" {
+			continue
+		}
+
 		delete(c.Remotes, k)
 	}
 
@@ -173,3 +181,7 @@ func (c *Config) ConfigPath(file string) string {
 func (c *Config) ServerCertPath(name string) string {
 	return path.Join(c.ConfigDir, "servercerts", fmt.Sprintf("%s.crt", name))
 }
+
+func (c *Config) ServerKeysPath(name string) string {
+	return path.Join(c.ConfigDir, "serverkeys", fmt.Sprintf("%s.keys", name))
+}
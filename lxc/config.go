@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bytes"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"sort"
@@ -100,6 +102,29 @@ lxc config device show [<remote>:]<container>
 lxc config device remove [<remote>:]<container> <name>
     Remove device from container.
 
+*Container metadata*
+
+lxc config metadata show [<remote>:]<container>
+    Show the container's metadata.yaml, as embedded by publish.
+
+lxc config metadata edit [<remote>:]<container>
+    Edit the container's metadata.yaml, either by launching external editor or reading STDIN.
+
+lxc config template list [<remote>:]<container>
+    List the container's template files.
+
+lxc config template show [<remote>:]<container> <template>
+    Show the content of a template file.
+
+lxc config template create [<remote>:]<container> <template>
+    Add a new template file, either empty or read from STDIN.
+
+lxc config template edit [<remote>:]<container> <template>
+    Edit a template file, either by launching external editor or reading STDIN.
+
+lxc config template delete [<remote>:]<container> <template>
+    Delete a template file.
+
 *Client trust store management*
 
 lxc config trust list [<remote>:]
@@ -495,6 +520,91 @@ func (c *configCmd) run(config *lxd.Config, args []string) error {
 			return errArgs
 		}
 
+	case "metadata":
+		if len(args) < 3 {
+			return errArgs
+		}
+
+		remote, container := config.ParseRemoteAndContainer(args[2])
+		d, err := lxd.NewClient(config, remote)
+		if err != nil {
+			return err
+		}
+
+		switch args[1] {
+		case "show":
+			metadata, err := d.ContainerMetadata(container)
+			if err != nil {
+				return err
+			}
+
+			data, err := yaml.Marshal(metadata)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s", data)
+			return nil
+		case "edit":
+			return c.doContainerMetadataEdit(d, container)
+		default:
+			return errArgs
+		}
+
+	case "template":
+		if len(args) < 3 {
+			return errArgs
+		}
+
+		remote, container := config.ParseRemoteAndContainer(args[2])
+		d, err := lxd.NewClient(config, remote)
+		if err != nil {
+			return err
+		}
+
+		if args[1] == "list" {
+			templates, err := d.ContainerTemplateFiles(container)
+			if err != nil {
+				return err
+			}
+
+			for _, template := range templates {
+				fmt.Println(template)
+			}
+			return nil
+		}
+
+		if len(args) < 4 {
+			return errArgs
+		}
+		template := args[3]
+
+		switch args[1] {
+		case "show":
+			content, err := d.ContainerTemplateFile(container, template)
+			if err != nil {
+				return err
+			}
+			defer content.Close()
+
+			_, err = io.Copy(os.Stdout, content)
+			return err
+		case "create":
+			// Read the initial content from stdin if it's not a terminal
+			var content io.Reader = &bytes.Buffer{}
+			if !termios.IsTerminal(int(syscall.Stdin)) {
+				content = os.Stdin
+			}
+
+			return d.CreateContainerTemplateFile(container, template, content)
+		case "edit":
+			return c.doContainerTemplateEdit(d, container, template)
+		case "delete":
+			return d.DeleteContainerTemplateFile(container, template)
+		default:
+			return errArgs
+		}
+
 	case "edit":
 		if len(args) < 1 {
 			return errArgs
@@ -524,6 +634,28 @@ func (c *configCmd) run(config *lxd.Config, args []string) error {
 	return errArgs
 }
 
+func (c *configCmd) metadataEditHelp() string {
+	return i18n.G(
+		`### This is a yaml representation of the container metadata.
+### Any line starting with a '# will be ignored.
+###
+### A sample configuration looks like:
+###
+### architecture: x86_64
+### creation_date: 1424284563
+### properties:
+###   description: Ubuntu 14.04 LTS Intel 64bit
+###   os: Ubuntu
+###   release: trusty
+### templates:
+###   /etc/hostname:
+###     when:
+###     - create
+###     - copy
+###     template: hostname.tpl
+###     properties: {}`)
+}
+
 func (c *configCmd) doContainerConfigEdit(client *lxd.Client, cont string) error {
 	// If stdin isn't a terminal, read text from it
 	if !termios.IsTerminal(int(syscall.Stdin)) {
@@ -587,6 +719,95 @@ func (c *configCmd) doContainerConfigEdit(client *lxd.Client, cont string) error
 	return nil
 }
 
+func (c *configCmd) doContainerMetadataEdit(client *lxd.Client, cont string) error {
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(int(syscall.Stdin)) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.ImageMetadata{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+		return client.UpdateContainerMetadata(cont, newdata)
+	}
+
+	// Extract the current value
+	metadata, err := client.ContainerMetadata(cont)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.metadataEditHelp()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.ImageMetadata{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = client.UpdateContainerMetadata(cont, newdata)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to start the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+func (c *configCmd) doContainerTemplateEdit(client *lxd.Client, cont string, template string) error {
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(int(syscall.Stdin)) {
+		return client.UpdateContainerTemplateFile(cont, template, os.Stdin)
+	}
+
+	// Extract the current value
+	reader, err := client.ContainerTemplateFile(cont, template)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", data)
+	if err != nil {
+		return err
+	}
+
+	return client.UpdateContainerTemplateFile(cont, template, bytes.NewReader(content))
+}
+
 func (c *configCmd) doDaemonConfigEdit(client *lxd.Client) error {
 	// If stdin isn't a terminal, read text from it
 	if !termios.IsTerminal(int(syscall.Stdin)) {
@@ -14,6 +14,7 @@ type Remote struct {
 	Public   bool   `yaml:"public"`
 	Protocol string `yaml:"protocol,omitempty"`
 	Static   bool   `yaml:"-"`
+	Project  string `yaml:"project,omitempty"`
 }
 
 // ParseRemote splits remote and object
@@ -54,6 +55,10 @@ func (c *Config) GetContainerServer(name string) (lxd.ContainerServer, error) {
 			return nil, err
 		}
 
+		if remote.Project != "" {
+			return d.UseProject(remote.Project), nil
+		}
+
 		return d, nil
 	}
 
@@ -67,6 +72,10 @@ func (c *Config) GetContainerServer(name string) (lxd.ContainerServer, error) {
 		return nil, err
 	}
 
+	if remote.Project != "" {
+		return d.UseProject(remote.Project), nil
+	}
+
 	return d, nil
 }
 
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lxc/lxd"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/gnuflag"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type exportCmd struct {
+	containerOnly bool
+}
+
+func (c *exportCmd) showByDefault() bool {
+	return true
+}
+
+func (c *exportCmd) usage() string {
+	return i18n.G(
+		`Usage: lxc export [<remote>:]<container> [target] [--container-only]
+
+Export container backups as tarballs.
+
+The tarball contains the container's root filesystem, its snapshots (unless
+--container-only is passed) and its configuration.
+
+*Examples*
+lxc export u1 backup0.tar.gz
+    Download a backup tarball of the u1 container.`)
+}
+
+func (c *exportCmd) flags() {
+	gnuflag.BoolVar(&c.containerOnly, "container-only", false, i18n.G("Whether or not to only backup the container (without snapshots)"))
+}
+
+func (c *exportCmd) run(config *lxd.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errArgs
+	}
+
+	remote, name := config.ParseRemoteAndContainer(args[0])
+	if name == "" || shared.IsSnapshot(name) {
+		return fmt.Errorf(i18n.G("Only containers can be exported"))
+	}
+
+	target := "backup.tar.gz"
+	if len(args) == 2 {
+		target = args[1]
+	}
+
+	d, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	// Create a temporary backup on the server
+	suffix, err := shared.RandomCryptoString()
+	if err != nil {
+		return err
+	}
+
+	backupName := fmt.Sprintf("lxc-export-%s", suffix[:8])
+	resp, err := d.ContainerBackupCreate(name, backupName, c.containerOnly)
+	if err != nil {
+		return err
+	}
+
+	err = d.WaitForSuccess(resp.Operation)
+	if err != nil {
+		return fmt.Errorf(i18n.G("Failed to create backup: %v"), err)
+	}
+
+	defer func() {
+		resp, err := d.ContainerBackupDelete(name, backupName)
+		if err == nil {
+			d.WaitForSuccess(resp.Operation)
+		}
+	}()
+
+	// Download it
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = d.ContainerBackupExport(name, backupName, f)
+	if err != nil {
+		os.Remove(target)
+		return fmt.Errorf(i18n.G("Failed to fetch backup: %v"), err)
+	}
+
+	return nil
+}
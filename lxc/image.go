@@ -314,6 +314,11 @@ func (c *imageCmd) run(config *lxd.Config, args []string) error {
 		fmt.Printf(i18n.G("Size: %.2fMB")+"\n", float64(info.Size)/1024.0/1024.0)
 		fmt.Printf(i18n.G("Architecture: %s")+"\n", info.Architecture)
 		fmt.Printf(i18n.G("Public: %s")+"\n", public)
+		if info.Signature.Type != "" {
+			fmt.Printf(i18n.G("Signature: %s (%s key %s)")+"\n", info.Signature.Status, info.Signature.Type, info.Signature.Key)
+		} else if info.Signature.Status != "" {
+			fmt.Printf(i18n.G("Signature: %s")+"\n", info.Signature.Status)
+		}
 		fmt.Printf(i18n.G("Timestamps:") + "\n")
 		const layout = "2006/01/02 15:04 UTC"
 		if shared.TimeIsSet(info.CreatedAt) {
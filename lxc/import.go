@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lxc/lxd"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type importCmd struct{}
+
+func (c *importCmd) showByDefault() bool {
+	return true
+}
+
+func (c *importCmd) usage() string {
+	return i18n.G(
+		`Usage: lxc import [<remote>:] <backup file>
+
+Import container backups.
+
+*Examples*
+lxc import backup0.tar.gz
+    Create a new container using backup0.tar.gz as the source.`)
+}
+
+func (c *importCmd) flags() {}
+
+func (c *importCmd) run(config *lxd.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errArgs
+	}
+
+	remote := config.DefaultRemote
+	backupFile := args[0]
+	if len(args) == 2 {
+		remote = config.ParseRemote(args[0])
+		backupFile = args[1]
+	}
+
+	d, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	progress := ProgressRenderer{Format: i18n.G("Importing container: %s")}
+	handler := func(percent int64, speed int64) {
+		progress.Update(fmt.Sprintf("%d%% (%s/s)", percent, shared.GetByteSizeString(speed, 2)))
+	}
+
+	resp, err := d.ContainerBackupImport(backupFile, handler)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	err = d.WaitForSuccess(resp.Operation)
+	progress.Done("")
+	return err
+}
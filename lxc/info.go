@@ -14,7 +14,8 @@ import (
 )
 
 type infoCmd struct {
-	showLog bool
+	showLog   bool
+	resources bool
 }
 
 func (c *infoCmd) showByDefault() bool {
@@ -23,19 +24,20 @@ func (c *infoCmd) showByDefault() bool {
 
 func (c *infoCmd) usage() string {
 	return i18n.G(
-		`Usage: lxc info [<remote>:][<container>] [--show-log]
+		`Usage: lxc info [<remote>:][<container>] [--show-log] [--resources]
 
 Show container or server information.
 
 lxc info [<remote>:]<container> [--show-log]
     For container information.
 
-lxc info [<remote>:]
+lxc info [<remote>:] [--resources]
     For LXD server information.`)
 }
 
 func (c *infoCmd) flags() {
 	gnuflag.BoolVar(&c.showLog, "show-log", false, i18n.G("Show the container's last 100 log lines?"))
+	gnuflag.BoolVar(&c.resources, "resources", false, i18n.G("Show the resources available to the server"))
 }
 
 func (c *infoCmd) run(config *lxd.Config, args []string) error {
@@ -53,6 +55,10 @@ func (c *infoCmd) run(config *lxd.Config, args []string) error {
 	}
 
 	if cName == "" {
+		if c.resources {
+			return c.remoteResources(d)
+		}
+
 		return c.remoteInfo(d)
 	} else {
 		return c.containerInfo(d, cName, c.showLog)
@@ -75,6 +81,66 @@ func (c *infoCmd) remoteInfo(d *lxd.Client) error {
 	return nil
 }
 
+func (c *infoCmd) remoteResources(d *lxd.Client) error {
+	resources, err := d.ServerResources()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(i18n.G("CPUs:"))
+	for _, socket := range resources.CPU.Sockets {
+		fmt.Printf("  "+i18n.G("Socket %d:")+"\n", socket.Socket)
+		if socket.Vendor != "" {
+			fmt.Printf("    "+i18n.G("Vendor: %s")+"\n", socket.Vendor)
+		}
+		if socket.Name != "" {
+			fmt.Printf("    "+i18n.G("Name: %s")+"\n", socket.Name)
+		}
+		fmt.Printf("    "+i18n.G("Cores: %d")+"\n", socket.Cores)
+		fmt.Printf("    "+i18n.G("Threads: %d")+"\n", socket.Threads)
+	}
+
+	fmt.Println(i18n.G("Memory:"))
+	fmt.Printf("  "+i18n.G("Used: %s")+"\n", shared.GetByteSizeString(int64(resources.Memory.Used), 2))
+	fmt.Printf("  "+i18n.G("Total: %s")+"\n", shared.GetByteSizeString(int64(resources.Memory.Total), 2))
+
+	if len(resources.GPU.Cards) > 0 {
+		fmt.Println(i18n.G("GPUs:"))
+		for _, card := range resources.GPU.Cards {
+			fmt.Printf("  "+i18n.G("Card %s:")+"\n", card.ID)
+			fmt.Printf("    "+i18n.G("PCI address: %s")+"\n", card.PCIAddress)
+			fmt.Printf("    "+i18n.G("Vendor ID: %s")+"\n", card.VendorID)
+			fmt.Printf("    "+i18n.G("Product ID: %s")+"\n", card.ProductID)
+		}
+	}
+
+	pools, err := d.ListStoragePools()
+	if err != nil {
+		return err
+	}
+
+	if len(pools) > 0 {
+		fmt.Println(i18n.G("Storage pools:"))
+	}
+
+	for _, pool := range pools {
+		res, err := d.StoragePoolResources(pool.Name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("  %s:\n", pool.Name)
+		fmt.Printf("    "+i18n.G("Space used: %s")+"\n", shared.GetByteSizeString(int64(res.Space.Used), 2))
+		fmt.Printf("    "+i18n.G("Space total: %s")+"\n", shared.GetByteSizeString(int64(res.Space.Total), 2))
+		if res.Inodes.Total > 0 {
+			fmt.Printf("    "+i18n.G("Inodes used: %d")+"\n", res.Inodes.Used)
+			fmt.Printf("    "+i18n.G("Inodes total: %d")+"\n", res.Inodes.Total)
+		}
+	}
+
+	return nil
+}
+
 func (c *infoCmd) containerInfo(d *lxd.Client, name string, showLog bool) error {
 	ct, err := d.ContainerInfo(name)
 	if err != nil {
@@ -189,6 +255,12 @@ func (c *infoCmd) containerInfo(d *lxd.Client, name string, showLog bool) error
 				networkInfo += fmt.Sprintf("      %s: %s\n", i18n.G("Bytes sent"), shared.GetByteSizeString(net.Counters.BytesSent, 2))
 				networkInfo += fmt.Sprintf("      %s: %d\n", i18n.G("Packets received"), net.Counters.PacketsReceived)
 				networkInfo += fmt.Sprintf("      %s: %d\n", i18n.G("Packets sent"), net.Counters.PacketsSent)
+				if net.Limits != nil && net.Limits.Ingress != "" {
+					networkInfo += fmt.Sprintf("      %s: %s\n", i18n.G("Ingress limit"), net.Limits.Ingress)
+				}
+				if net.Limits != nil && net.Limits.Egress != "" {
+					networkInfo += fmt.Sprintf("      %s: %s\n", i18n.G("Egress limit"), net.Limits.Egress)
+				}
 			}
 		}
 
@@ -217,6 +289,10 @@ func (c *infoCmd) containerInfo(d *lxd.Client, name string, showLog bool) error
 			fmt.Printf(" ("+i18n.G("taken at %s")+")", snap.CreationDate.UTC().Format(layout))
 		}
 
+		if shared.TimeIsSet(snap.ExpiresAt) {
+			fmt.Printf(" ("+i18n.G("expires at %s")+")", snap.ExpiresAt.UTC().Format(layout))
+		}
+
 		if snap.Stateful {
 			fmt.Printf(" (" + i18n.G("stateful") + ")")
 		} else {
@@ -65,6 +65,7 @@ type initCmd struct {
 	ephem       bool
 	network     string
 	storagePool string
+	target      string
 }
 
 func (c *initCmd) showByDefault() bool {
@@ -73,15 +74,18 @@ func (c *initCmd) showByDefault() bool {
 
 func (c *initCmd) usage() string {
 	return i18n.G(
-		`Usage: lxc init [<remote>:]<image> [<remote>:][<name>] [--ephemeral|-e] [--profile|-p <profile>...] [--config|-c <key=value>...] [--network|-n <network>] [--storage|-s <pool>]
+		`Usage: lxc init [<remote>:]<image> [<remote>:][<name>] [--ephemeral|-e] [--profile|-p <profile>...] [--config|-c <key=value>...] [--network|-n <network>] [--storage|-s <pool>] [--target <node>]
 
 Create containers from images.
 
 Not specifying -p will result in the default profile.
 Specifying "-p" with no argument will result in no profile.
 
+On clustered servers, --target selects the cluster member to create the container on.
+
 Examples:
-    lxc init ubuntu:16.04 u1`)
+    lxc init ubuntu:16.04 u1
+    lxc init ubuntu:16.04 u2 --target node2`)
 }
 
 func (c *initCmd) is_ephem(s string) bool {
@@ -142,6 +146,7 @@ func (c *initCmd) flags() {
 	gnuflag.StringVar(&c.network, "n", "", i18n.G("Network name"))
 	gnuflag.StringVar(&c.storagePool, "storage", "", i18n.G("Storage pool name"))
 	gnuflag.StringVar(&c.storagePool, "s", "", i18n.G("Storage pool name"))
+	gnuflag.StringVar(&c.target, "target", "", i18n.G("Cluster member to create the container on"))
 }
 
 func (c *initCmd) run(config *lxd.Config, args []string) error {
@@ -212,9 +217,9 @@ func (c *initCmd) run(config *lxd.Config, args []string) error {
 	}
 
 	if !initRequestedEmptyProfiles && len(profiles) == 0 {
-		resp, err = d.Init(name, iremote, image, nil, configMap, devicesMap, c.ephem)
+		resp, err = d.InitTarget(name, iremote, image, nil, configMap, devicesMap, c.ephem, c.target)
 	} else {
-		resp, err = d.Init(name, iremote, image, &profiles, configMap, devicesMap, c.ephem)
+		resp, err = d.InitTarget(name, iremote, image, &profiles, configMap, devicesMap, c.ephem, c.target)
 	}
 	if err != nil {
 		return err
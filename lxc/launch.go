@@ -21,15 +21,18 @@ func (c *launchCmd) showByDefault() bool {
 
 func (c *launchCmd) usage() string {
 	return i18n.G(
-		`Usage: lxc launch [<remote>:]<image> [<remote>:][<name>] [--ephemeral|-e] [--profile|-p <profile>...] [--config|-c <key=value>...] [--network|-n <network>] [--storage|-s <pool>]
+		`Usage: lxc launch [<remote>:]<image> [<remote>:][<name>] [--ephemeral|-e] [--profile|-p <profile>...] [--config|-c <key=value>...] [--network|-n <network>] [--storage|-s <pool>] [--target <node>]
 
 Create and start containers from images.
 
 Not specifying -p will result in the default profile.
 Specifying "-p" with no argument will result in no profile.
 
+On clustered servers, --target selects the cluster member to create the container on.
+
 Examples:
-    lxc launch ubuntu:16.04 u1`)
+    lxc launch ubuntu:16.04 u1
+    lxc launch ubuntu:16.04 u2 --target node2`)
 }
 
 func (c *launchCmd) flags() {
@@ -97,9 +100,9 @@ func (c *launchCmd) run(config *lxd.Config, args []string) error {
 	}
 
 	if !initRequestedEmptyProfiles && len(profiles) == 0 {
-		resp, err = d.Init(name, iremote, image, nil, configMap, devicesMap, c.init.ephem)
+		resp, err = d.InitTarget(name, iremote, image, nil, configMap, devicesMap, c.init.ephem, c.init.target)
 	} else {
-		resp, err = d.Init(name, iremote, image, &profiles, configMap, devicesMap, c.init.ephem)
+		resp, err = d.InitTarget(name, iremote, image, &profiles, configMap, devicesMap, c.init.ephem, c.init.target)
 	}
 	if err != nil {
 		return err
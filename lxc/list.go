@@ -49,7 +49,7 @@ func (c *listCmd) usage() string {
 
 List the existing containers.
 
-Default column layout: ns46tS
+Default column layout: ns46tS (ns46tSL on clustered servers)
 Fast column layout: nsacPt
 
 *Filters*
@@ -91,6 +91,8 @@ Pre-defined column shorthand chars:
 
     l - Last used date
 
+    L - Location of the container (cluster member)
+
     n - Name
 
     p - PID of the container's init process
@@ -410,7 +412,12 @@ func (c *listCmd) run(config *lxd.Config, args []string) error {
 		return err
 	}
 
+	clustered := false
 	for _, cinfo := range ctslist {
+		if cinfo.Location != "" {
+			clustered = true
+		}
+
 		if !c.shouldShow(filters, &cinfo) {
 			continue
 		}
@@ -418,6 +425,11 @@ func (c *listCmd) run(config *lxd.Config, args []string) error {
 		cts = append(cts, cinfo)
 	}
 
+	// Show where the containers live on clustered servers
+	if clustered && c.columnsRaw == "ns46tS" {
+		c.columnsRaw = "ns46tSL"
+	}
+
 	columns, err := c.parseColumns()
 	if err != nil {
 		return err
@@ -433,6 +445,7 @@ func (c *listCmd) parseColumns() ([]column, error) {
 		'a': {i18n.G("ARCHITECTURE"), c.ArchitectureColumnData, false, false},
 		'c': {i18n.G("CREATED AT"), c.CreatedColumnData, false, false},
 		'l': {i18n.G("LAST USED AT"), c.LastUsedColumnData, false, false},
+		'L': {i18n.G("LOCATION"), c.LocationColumnData, false, false},
 		'n': {i18n.G("NAME"), c.nameColumnData, false, false},
 		'p': {i18n.G("PID"), c.PIDColumnData, true, false},
 		'P': {i18n.G("PROFILES"), c.ProfilesColumnData, false, false},
@@ -614,6 +627,10 @@ func (c *listCmd) StoragePoolColumnData(cInfo api.Container, cState *api.Contain
 	return ""
 }
 
+func (c *listCmd) LocationColumnData(cInfo api.Container, cState *api.ContainerState, cSnaps []api.ContainerSnapshot) string {
+	return cInfo.Location
+}
+
 func (c *listCmd) ProfilesColumnData(cInfo api.Container, cState *api.ContainerState, cSnaps []api.ContainerSnapshot) string {
 	return strings.Join(cInfo.Profiles, "\n")
 }
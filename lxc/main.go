@@ -187,8 +187,10 @@ var commands = map[string]command{
 	"exec":    &execCmd{},
 	"file":    &fileCmd{},
 	"finger":  &fingerCmd{},
+	"export":  &exportCmd{},
 	"help":    &helpCmd{},
 	"image":   &imageCmd{},
+	"import":  &importCmd{},
 	"info":    &infoCmd{},
 	"init":    &initCmd{},
 	"launch":  &launchCmd{},
@@ -203,6 +205,7 @@ var commands = map[string]command{
 		name:        "pause",
 	},
 	"profile": &profileCmd{},
+	"project": &projectCmd{},
 	"publish": &publishCmd{},
 	"remote":  &remoteCmd{},
 	"restart": &actionCmd{
@@ -45,6 +45,78 @@ func (c *networkCmd) networkEditHelp() string {
 ### Note that only the configuration can be changed.`)
 }
 
+func (c *networkCmd) networkACLEditHelp() string {
+	return i18n.G(
+		`### This is a yaml representation of the network ACL.
+### Any line starting with a '# will be ignored.
+###
+### A network ACL consists of ordered lists of ingress and egress rules.
+### Traffic which doesn't match any rule is allowed.
+###
+### An example would look like:
+### name: web
+### description: Web servers
+### ingress:
+### - action: allow
+###   protocol: tcp
+###   destination_port: 80,443
+### - action: drop
+### egress: []
+###
+### Note that the name is shown but cannot be changed`)
+}
+
+func (c *networkCmd) networkReservationEditHelp() string {
+	return i18n.G(
+		`### This is a yaml representation of the DHCP reservation.
+### Any line starting with a '# will be ignored.
+###
+### An example would look like:
+### hwaddr: 00:16:3e:12:34:56
+### description: Printer
+### ipv4_address: 10.0.3.50
+### ipv6_address: ""
+### hostname: printer
+###
+### Note that the MAC address is shown but cannot be changed`)
+}
+
+func (c *networkCmd) networkDNSRecordEditHelp() string {
+	return i18n.G(
+		`### This is a yaml representation of the DNS record.
+### Any line starting with a '# will be ignored.
+###
+### An example would look like:
+### name: gateway
+### description: Upstream router
+### addresses:
+### - 10.0.3.254
+### - fd42::fe
+###
+### Note that the name is shown but cannot be changed`)
+}
+
+func (c *networkCmd) networkForwardEditHelp() string {
+	return i18n.G(
+		`### This is a yaml representation of the network forward.
+### Any line starting with a '# will be ignored.
+###
+### An example would look like:
+### listen_address: 192.0.2.10
+### description: Web services
+### target_address: 10.0.3.20
+### ports:
+### - protocol: tcp
+###   listen_port: 80,443
+###   target_address: 10.0.3.30
+### - protocol: tcp
+###   listen_port: "2222"
+###   target_port: "22"
+###   target_address: 10.0.3.40
+###
+### Note that the listen address is shown but cannot be changed`)
+}
+
 func (c *networkCmd) usage() string {
 	return i18n.G(
 		`Usage: lxc network <subcommand> [options]
@@ -57,6 +129,12 @@ lxc network list [<remote>:]
 lxc network show [<remote>:]<network>
     Show details of a network.
 
+lxc network info [<remote>:]<network>
+    Show runtime information about a network.
+
+lxc network list-leases [<remote>:]<network>
+    List the DHCP leases of a network.
+
 lxc network create [<remote>:]<network> [key=value...]
     Create a network.
 
@@ -87,9 +165,89 @@ lxc network detach [<remote>:]<network> <container> [device name]
 lxc network detach-profile [<remote>:]<network> <container> [device name]
     Remove a network interface connecting the network to a specified profile.
 
+lxc network acl list [<remote>:]
+    List available network ACLs.
+
+lxc network acl show [<remote>:]<acl>
+    Show details of a network ACL.
+
+lxc network acl create [<remote>:]<acl> [<description>]
+    Create a network ACL.
+
+lxc network acl edit [<remote>:]<acl>
+    Edit network ACL rules, either by launching external editor or reading STDIN.
+
+lxc network acl rename [<remote>:]<acl> <new name>
+    Rename a network ACL.
+
+lxc network acl delete [<remote>:]<acl>
+    Delete a network ACL.
+
+lxc network reservation list [<remote>:]<network>
+    List the DHCP reservations of a network.
+
+lxc network reservation show [<remote>:]<network> <MAC address>
+    Show details of a DHCP reservation.
+
+lxc network reservation create [<remote>:]<network> <MAC address> [key=value...]
+    Reserve addresses for a MAC address, the keys are ipv4_address,
+    ipv6_address, hostname and description.
+
+lxc network reservation edit [<remote>:]<network> <MAC address>
+    Edit a DHCP reservation, either by launching external editor or reading STDIN.
+
+lxc network reservation delete [<remote>:]<network> <MAC address>
+    Delete a DHCP reservation.
+
+lxc network dns-record list [<remote>:]<network>
+    List the extra DNS records of a network.
+
+lxc network dns-record show [<remote>:]<network> <name>
+    Show details of a DNS record.
+
+lxc network dns-record create [<remote>:]<network> <name> <address>...
+    Create a DNS record resolving to the given addresses.
+
+lxc network dns-record edit [<remote>:]<network> <name>
+    Edit a DNS record, either by launching external editor or reading STDIN.
+
+lxc network dns-record delete [<remote>:]<network> <name>
+    Delete a DNS record.
+
+lxc network forward list [<remote>:]<network>
+    List the forwards of a network.
+
+lxc network forward show [<remote>:]<network> <listen address>
+    Show details of a network forward.
+
+lxc network forward create [<remote>:]<network> <listen address> [key=value...]
+    Forward the traffic of a host address to the network, the keys are
+    target_address and description.
+
+lxc network forward edit [<remote>:]<network> <listen address>
+    Edit a network forward, either by launching external editor or reading STDIN.
+
+lxc network forward delete [<remote>:]<network> <listen address>
+    Delete a network forward.
+
+lxc network forward port add [<remote>:]<network> <listen address> <protocol> <listen ports> <target address> [<target ports>]
+    Forward ports of the listen address to another address or other ports.
+
+lxc network forward port remove [<remote>:]<network> <listen address> <protocol> <listen ports>
+    Remove ports from a network forward.
+
 *Examples*
 cat network.yaml | lxc network edit <network>
-    Update a network using the content of network.yaml`)
+    Update a network using the content of network.yaml
+
+lxc network set lxdbr0 security.acls web
+    Apply the "web" network ACL to the traffic routed through lxdbr0
+
+lxc network reservation create lxdbr0 00:16:3e:12:34:56 ipv4_address=10.0.3.50 hostname=printer
+    Always give 10.0.3.50 to the printer attached to lxdbr0
+
+lxc network forward port add lxdbr0 192.0.2.10 tcp 80,443 10.0.3.30
+    Publish the web server at 10.0.3.30 on the host address 192.0.2.10`)
 }
 
 func (c *networkCmd) flags() {}
@@ -103,6 +261,22 @@ func (c *networkCmd) run(config *lxd.Config, args []string) error {
 		return c.doNetworkList(config, args)
 	}
 
+	if args[0] == "acl" {
+		return c.doNetworkACL(config, args[1:])
+	}
+
+	if args[0] == "reservation" {
+		return c.doNetworkReservation(config, args[1:])
+	}
+
+	if args[0] == "dns-record" {
+		return c.doNetworkDNSRecord(config, args[1:])
+	}
+
+	if args[0] == "forward" {
+		return c.doNetworkForward(config, args[1:])
+	}
+
 	if len(args) < 2 {
 		return errArgs
 	}
@@ -130,6 +304,10 @@ func (c *networkCmd) run(config *lxd.Config, args []string) error {
 		return c.doNetworkEdit(client, network)
 	case "get":
 		return c.doNetworkGet(client, network, args[2:])
+	case "info":
+		return c.doNetworkInfo(client, network)
+	case "list-leases":
+		return c.doNetworkListLeases(client, network)
 	case "set":
 		return c.doNetworkSet(client, network, args[2:])
 	case "unset":
@@ -466,6 +644,68 @@ func (c *networkCmd) doNetworkList(config *lxd.Config, args []string) error {
 	return nil
 }
 
+func (c *networkCmd) doNetworkInfo(client *lxd.Client, name string) error {
+	if name == "" {
+		return errArgs
+	}
+
+	state, err := client.NetworkState(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(i18n.G("Name: %s")+"\n", name)
+	fmt.Printf(i18n.G("MAC address: %s")+"\n", state.Hwaddr)
+	fmt.Printf(i18n.G("MTU: %d")+"\n", state.Mtu)
+	fmt.Printf(i18n.G("State: %s")+"\n", state.State)
+
+	if len(state.Addresses) > 0 {
+		fmt.Println(i18n.G("Ips:"))
+		for _, addr := range state.Addresses {
+			fmt.Printf("  %s\t%s/%s (%s)\n", addr.Family, addr.Address, addr.Netmask, addr.Scope)
+		}
+	}
+
+	fmt.Println(i18n.G("Network usage:"))
+	fmt.Printf("  %s: %s\n", i18n.G("Bytes received"), shared.GetByteSizeString(state.Counters.BytesReceived, 2))
+	fmt.Printf("  %s: %s\n", i18n.G("Bytes sent"), shared.GetByteSizeString(state.Counters.BytesSent, 2))
+	fmt.Printf("  %s: %d\n", i18n.G("Packets received"), state.Counters.PacketsReceived)
+	fmt.Printf("  %s: %d\n", i18n.G("Packets sent"), state.Counters.PacketsSent)
+
+	return nil
+}
+
+func (c *networkCmd) doNetworkListLeases(client *lxd.Client, name string) error {
+	if name == "" {
+		return errArgs
+	}
+
+	leases, err := client.NetworkLeases(name)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, lease := range leases {
+		data = append(data, []string{lease.Hostname, lease.Hwaddr, lease.Address, strings.ToUpper(lease.Type)})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("HOSTNAME"),
+		i18n.G("MAC ADDRESS"),
+		i18n.G("IP ADDRESS"),
+		i18n.G("TYPE")})
+	sort.Sort(byName(data))
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
+
 func (c *networkCmd) doNetworkSet(client *lxd.Client, name string, args []string) error {
 	// we shifted @args so so it should read "<key> [<value>]"
 	if len(args) < 1 {
@@ -519,3 +759,747 @@ func (c *networkCmd) doNetworkShow(client *lxd.Client, name string) error {
 
 	return nil
 }
+
+func (c *networkCmd) doNetworkACL(config *lxd.Config, args []string) error {
+	if len(args) < 1 {
+		return errArgs
+	}
+
+	if args[0] == "list" {
+		return c.doNetworkACLList(config, args)
+	}
+
+	if len(args) < 2 {
+		return errArgs
+	}
+
+	remote, acl := config.ParseRemoteAndContainer(args[1])
+	client, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		description := ""
+		if len(args) > 2 {
+			description = args[2]
+		}
+
+		err := client.NetworkACLCreate(acl, description)
+		if err == nil {
+			fmt.Printf(i18n.G("Network ACL %s created")+"\n", acl)
+		}
+
+		return err
+	case "delete":
+		err := client.NetworkACLDelete(acl)
+		if err == nil {
+			fmt.Printf(i18n.G("Network ACL %s deleted")+"\n", acl)
+		}
+
+		return err
+	case "edit":
+		return c.doNetworkACLEdit(client, acl)
+	case "rename":
+		if len(args) != 3 {
+			return errArgs
+		}
+
+		err := client.NetworkACLRename(acl, args[2])
+		if err == nil {
+			fmt.Printf(i18n.G("Network ACL %s renamed to %s")+"\n", acl, args[2])
+		}
+
+		return err
+	case "show":
+		return c.doNetworkACLShow(client, acl)
+	default:
+		return errArgs
+	}
+}
+
+func (c *networkCmd) doNetworkACLEdit(client *lxd.Client, name string) error {
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(int(syscall.Stdin)) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.NetworkACLPut{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+		return client.NetworkACLPut(name, newdata)
+	}
+
+	// Extract the current value
+	acl, err := client.NetworkACLGet(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&acl)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.networkACLEditHelp()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.NetworkACLPut{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = client.NetworkACLPut(name, newdata)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+func (c *networkCmd) doNetworkACLList(config *lxd.Config, args []string) error {
+	var remote string
+	if len(args) > 1 {
+		var name string
+		remote, name = config.ParseRemoteAndContainer(args[1])
+		if name != "" {
+			return fmt.Errorf(i18n.G("Cannot provide container name to list"))
+		}
+	} else {
+		remote = config.DefaultRemote
+	}
+
+	client, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	acls, err := client.ListNetworkACLs()
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, acl := range acls {
+		strIngress := fmt.Sprintf("%d", len(acl.Ingress))
+		strEgress := fmt.Sprintf("%d", len(acl.Egress))
+		strUsedBy := fmt.Sprintf("%d", len(acl.UsedBy))
+		data = append(data, []string{acl.Name, acl.Description, strIngress, strEgress, strUsedBy})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("NAME"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("INGRESS"),
+		i18n.G("EGRESS"),
+		i18n.G("USED BY")})
+	sort.Sort(byName(data))
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
+
+func (c *networkCmd) doNetworkACLShow(client *lxd.Client, name string) error {
+	acl, err := client.NetworkACLGet(name)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(acl.UsedBy)
+
+	data, err := yaml.Marshal(&acl)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+func (c *networkCmd) doNetworkReservation(config *lxd.Config, args []string) error {
+	if len(args) < 2 {
+		return errArgs
+	}
+
+	remote, network := config.ParseRemoteAndContainer(args[1])
+	client, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "list" {
+		return c.doNetworkReservationList(client, network)
+	}
+
+	if len(args) < 3 {
+		return errArgs
+	}
+
+	hwaddr := args[2]
+
+	switch args[0] {
+	case "create":
+		reservation := api.NetworkReservationPut{}
+		for _, arg := range args[3:] {
+			fields := strings.SplitN(arg, "=", 2)
+			if len(fields) < 2 {
+				return errArgs
+			}
+
+			switch fields[0] {
+			case "ipv4_address":
+				reservation.IPv4Address = fields[1]
+			case "ipv6_address":
+				reservation.IPv6Address = fields[1]
+			case "hostname":
+				reservation.Hostname = fields[1]
+			case "description":
+				reservation.Description = fields[1]
+			default:
+				return fmt.Errorf(i18n.G("Unknown key: %s"), fields[0])
+			}
+		}
+
+		err := client.NetworkReservationCreate(network, hwaddr, reservation)
+		if err == nil {
+			fmt.Printf(i18n.G("Reservation for %s created")+"\n", hwaddr)
+		}
+
+		return err
+	case "delete":
+		err := client.NetworkReservationDelete(network, hwaddr)
+		if err == nil {
+			fmt.Printf(i18n.G("Reservation for %s deleted")+"\n", hwaddr)
+		}
+
+		return err
+	case "edit":
+		return c.doNetworkReservationEdit(client, network, hwaddr)
+	case "show":
+		reservation, err := client.NetworkReservationGet(network, hwaddr)
+		if err != nil {
+			return err
+		}
+
+		data, err := yaml.Marshal(&reservation)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s", data)
+
+		return nil
+	default:
+		return errArgs
+	}
+}
+
+func (c *networkCmd) doNetworkReservationEdit(client *lxd.Client, network string, hwaddr string) error {
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(int(syscall.Stdin)) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.NetworkReservationPut{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+		return client.NetworkReservationPut(network, hwaddr, newdata)
+	}
+
+	// Extract the current value
+	reservation, err := client.NetworkReservationGet(network, hwaddr)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&reservation)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.networkReservationEditHelp()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.NetworkReservationPut{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = client.NetworkReservationPut(network, hwaddr, newdata)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+func (c *networkCmd) doNetworkReservationList(client *lxd.Client, network string) error {
+	reservations, err := client.ListNetworkReservations(network)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, reservation := range reservations {
+		data = append(data, []string{reservation.Hwaddr, reservation.IPv4Address, reservation.IPv6Address, reservation.Hostname, reservation.Description})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("MAC ADDRESS"),
+		i18n.G("IPV4"),
+		i18n.G("IPV6"),
+		i18n.G("HOSTNAME"),
+		i18n.G("DESCRIPTION")})
+	sort.Sort(byName(data))
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
+
+func (c *networkCmd) doNetworkDNSRecord(config *lxd.Config, args []string) error {
+	if len(args) < 2 {
+		return errArgs
+	}
+
+	remote, network := config.ParseRemoteAndContainer(args[1])
+	client, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "list" {
+		return c.doNetworkDNSRecordList(client, network)
+	}
+
+	if len(args) < 3 {
+		return errArgs
+	}
+
+	name := args[2]
+
+	switch args[0] {
+	case "create":
+		if len(args) < 4 {
+			return errArgs
+		}
+
+		record := api.NetworkDNSRecordPut{Addresses: args[3:]}
+		err := client.NetworkDNSRecordCreate(network, name, record)
+		if err == nil {
+			fmt.Printf(i18n.G("DNS record %s created")+"\n", name)
+		}
+
+		return err
+	case "delete":
+		err := client.NetworkDNSRecordDelete(network, name)
+		if err == nil {
+			fmt.Printf(i18n.G("DNS record %s deleted")+"\n", name)
+		}
+
+		return err
+	case "edit":
+		return c.doNetworkDNSRecordEdit(client, network, name)
+	case "show":
+		record, err := client.NetworkDNSRecordGet(network, name)
+		if err != nil {
+			return err
+		}
+
+		data, err := yaml.Marshal(&record)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s", data)
+
+		return nil
+	default:
+		return errArgs
+	}
+}
+
+func (c *networkCmd) doNetworkDNSRecordEdit(client *lxd.Client, network string, name string) error {
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(int(syscall.Stdin)) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.NetworkDNSRecordPut{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+		return client.NetworkDNSRecordPut(network, name, newdata)
+	}
+
+	// Extract the current value
+	record, err := client.NetworkDNSRecordGet(network, name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&record)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.networkDNSRecordEditHelp()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.NetworkDNSRecordPut{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = client.NetworkDNSRecordPut(network, name, newdata)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+func (c *networkCmd) doNetworkDNSRecordList(client *lxd.Client, network string) error {
+	records, err := client.ListNetworkDNSRecords(network)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, record := range records {
+		data = append(data, []string{record.Name, strings.Join(record.Addresses, "\n"), record.Description})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("NAME"),
+		i18n.G("ADDRESSES"),
+		i18n.G("DESCRIPTION")})
+	sort.Sort(byName(data))
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
+
+func (c *networkCmd) doNetworkForward(config *lxd.Config, args []string) error {
+	if len(args) > 0 && args[0] == "port" {
+		return c.doNetworkForwardPort(config, args[1:])
+	}
+
+	if len(args) < 2 {
+		return errArgs
+	}
+
+	remote, network := config.ParseRemoteAndContainer(args[1])
+	client, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "list" {
+		return c.doNetworkForwardList(client, network)
+	}
+
+	if len(args) < 3 {
+		return errArgs
+	}
+
+	listenAddress := args[2]
+
+	switch args[0] {
+	case "create":
+		forward := api.NetworkForwardPut{}
+		for _, arg := range args[3:] {
+			fields := strings.SplitN(arg, "=", 2)
+			if len(fields) < 2 {
+				return errArgs
+			}
+
+			switch fields[0] {
+			case "target_address":
+				forward.TargetAddress = fields[1]
+			case "description":
+				forward.Description = fields[1]
+			default:
+				return fmt.Errorf(i18n.G("Unknown key: %s"), fields[0])
+			}
+		}
+
+		err := client.NetworkForwardCreate(network, listenAddress, forward)
+		if err == nil {
+			fmt.Printf(i18n.G("Network forward %s created")+"\n", listenAddress)
+		}
+
+		return err
+	case "delete":
+		err := client.NetworkForwardDelete(network, listenAddress)
+		if err == nil {
+			fmt.Printf(i18n.G("Network forward %s deleted")+"\n", listenAddress)
+		}
+
+		return err
+	case "edit":
+		return c.doNetworkForwardEdit(client, network, listenAddress)
+	case "show":
+		forward, err := client.NetworkForwardGet(network, listenAddress)
+		if err != nil {
+			return err
+		}
+
+		data, err := yaml.Marshal(&forward)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s", data)
+
+		return nil
+	default:
+		return errArgs
+	}
+}
+
+func (c *networkCmd) doNetworkForwardPort(config *lxd.Config, args []string) error {
+	if len(args) < 5 {
+		return errArgs
+	}
+
+	remote, network := config.ParseRemoteAndContainer(args[1])
+	client, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	listenAddress := args[2]
+
+	forward, err := client.NetworkForwardGet(network, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	put := forward.Writable()
+
+	switch args[0] {
+	case "add":
+		if len(args) < 6 || len(args) > 7 {
+			return errArgs
+		}
+
+		port := api.NetworkForwardPort{
+			Protocol:      args[3],
+			ListenPort:    args[4],
+			TargetAddress: args[5],
+		}
+
+		if len(args) == 7 {
+			port.TargetPort = args[6]
+		}
+
+		put.Ports = append(put.Ports, port)
+	case "remove":
+		if len(args) != 5 {
+			return errArgs
+		}
+
+		ports := []api.NetworkForwardPort{}
+		for _, port := range put.Ports {
+			if port.Protocol == args[3] && port.ListenPort == args[4] {
+				continue
+			}
+
+			ports = append(ports, port)
+		}
+
+		if len(ports) == len(put.Ports) {
+			return fmt.Errorf(i18n.G("No matching port found"))
+		}
+
+		put.Ports = ports
+	default:
+		return errArgs
+	}
+
+	return client.NetworkForwardPut(network, listenAddress, put)
+}
+
+func (c *networkCmd) doNetworkForwardEdit(client *lxd.Client, network string, listenAddress string) error {
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(int(syscall.Stdin)) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.NetworkForwardPut{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+		return client.NetworkForwardPut(network, listenAddress, newdata)
+	}
+
+	// Extract the current value
+	forward, err := client.NetworkForwardGet(network, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&forward)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.networkForwardEditHelp()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.NetworkForwardPut{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = client.NetworkForwardPut(network, listenAddress, newdata)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+func (c *networkCmd) doNetworkForwardList(client *lxd.Client, network string) error {
+	forwards, err := client.ListNetworkForwards(network)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, forward := range forwards {
+		ports := []string{}
+		for _, port := range forward.Ports {
+			target := port.TargetAddress
+			if port.TargetPort != "" {
+				target = fmt.Sprintf("%s:%s", target, port.TargetPort)
+			}
+
+			ports = append(ports, fmt.Sprintf("%s/%s -> %s", port.ListenPort, port.Protocol, target))
+		}
+
+		data = append(data, []string{forward.ListenAddress, forward.TargetAddress, strings.Join(ports, "\n"), forward.Description})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("LISTEN ADDRESS"),
+		i18n.G("DEFAULT TARGET ADDRESS"),
+		i18n.G("PORTS"),
+		i18n.G("DESCRIPTION")})
+	sort.Sort(byName(data))
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/i18n"
+	"github.com/lxc/lxd/shared/termios"
+)
+
+type projectCmd struct {
+}
+
+func (c *projectCmd) showByDefault() bool {
+	return true
+}
+
+func (c *projectCmd) projectEditHelp() string {
+	return i18n.G(
+		`### This is a yaml representation of the project.
+### Any line starting with a '# will be ignored.
+###
+### A project consists of a set of features and a description.
+###
+### An example would look like:
+### name: my-project
+### config:
+###   features.images: true
+###   features.profiles: true
+### description: My own project
+###
+### Note that the name is shown but cannot be changed`)
+}
+
+func (c *projectCmd) usage() string {
+	return i18n.G(
+		`Usage: lxc project <subcommand> [options]
+
+Manage projects.
+
+lxc project list [<remote>:]
+    List available projects.
+
+lxc project show [<remote>:]<project>
+    Show details of a project.
+
+lxc project create [<remote>:]<project> [key=value...]
+    Create a project.
+
+lxc project get [<remote>:]<project> <key>
+    Get project configuration.
+
+lxc project set [<remote>:]<project> <key> <value>
+    Set project configuration.
+
+lxc project unset [<remote>:]<project> <key>
+    Unset project configuration.
+
+lxc project delete [<remote>:]<project>
+    Delete a project.
+
+lxc project edit [<remote>:]<project>
+    Edit project, either by launching external editor or reading STDIN.
+
+lxc project switch [<remote>:]<project>
+    Switch the current project of the remote.
+
+*Examples*
+lxc project create foo features.images=false
+    Create a project named "foo" sharing the images of the default project.
+
+lxc project switch foo
+    Make all following commands against the default remote target project "foo".`)
+}
+
+func (c *projectCmd) flags() {}
+
+func (c *projectCmd) run(config *lxd.Config, args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+
+	if args[0] == "list" {
+		return c.doProjectList(config, args)
+	}
+
+	if len(args) < 2 {
+		return errArgs
+	}
+
+	remote, project := config.ParseRemoteAndContainer(args[1])
+	client, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		return c.doProjectCreate(client, project, args[2:])
+	case "delete":
+		return c.doProjectDelete(client, project)
+	case "edit":
+		return c.doProjectEdit(client, project)
+	case "get":
+		return c.doProjectGet(client, project, args[2:])
+	case "set":
+		return c.doProjectSet(client, project, args[2:])
+	case "unset":
+		return c.doProjectSet(client, project, args[2:])
+	case "show":
+		return c.doProjectShow(client, project)
+	case "switch":
+		return c.doProjectSwitch(config, client, remote, project)
+	default:
+		return errArgs
+	}
+}
+
+func (c *projectCmd) doProjectCreate(client *lxd.Client, name string, args []string) error {
+	config := map[string]string{}
+
+	for i := 0; i < len(args); i++ {
+		entry := strings.SplitN(args[i], "=", 2)
+		if len(entry) < 2 {
+			return errArgs
+		}
+
+		config[entry[0]] = entry[1]
+	}
+
+	err := client.ProjectCreate(name, "", config)
+	if err == nil {
+		fmt.Printf(i18n.G("Project %s created")+"\n", name)
+	}
+
+	return err
+}
+
+func (c *projectCmd) doProjectDelete(client *lxd.Client, name string) error {
+	err := client.ProjectDelete(name)
+	if err == nil {
+		fmt.Printf(i18n.G("Project %s deleted")+"\n", name)
+	}
+
+	return err
+}
+
+func (c *projectCmd) doProjectEdit(client *lxd.Client, name string) error {
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(int(syscall.Stdin)) {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		newdata := api.ProjectPut{}
+		err = yaml.Unmarshal(contents, &newdata)
+		if err != nil {
+			return err
+		}
+		return client.ProjectPut(name, newdata)
+	}
+
+	// Extract the current value
+	project, err := client.ProjectGet(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&project)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := shared.TextEditor("", []byte(c.projectEditHelp()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor
+		newdata := api.ProjectPut{}
+		err = yaml.Unmarshal(content, &newdata)
+		if err == nil {
+			err = client.ProjectPut(name, newdata)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = shared.TextEditor("", content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		break
+	}
+	return nil
+}
+
+func (c *projectCmd) doProjectGet(client *lxd.Client, name string, args []string) error {
+	// we shifted @args so so it should read "<key>"
+	if len(args) != 1 {
+		return errArgs
+	}
+
+	resp, err := client.ProjectGet(name)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range resp.Config {
+		if k == args[0] {
+			fmt.Printf("%s\n", v)
+		}
+	}
+	return nil
+}
+
+func (c *projectCmd) doProjectList(config *lxd.Config, args []string) error {
+	var remote string
+	if len(args) > 1 {
+		var name string
+		remote, name = config.ParseRemoteAndContainer(args[1])
+		if name != "" {
+			return fmt.Errorf(i18n.G("Cannot provide container name to list"))
+		}
+	} else {
+		remote = config.DefaultRemote
+	}
+
+	client, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	projects, err := client.ListProjects()
+	if err != nil {
+		return err
+	}
+
+	current := config.Remotes[remote].Project
+	if current == "" {
+		current = "default"
+	}
+
+	data := [][]string{}
+	for _, project := range projects {
+		images := i18n.G("NO")
+		if shared.IsTrue(project.Config["features.images"]) {
+			images = i18n.G("YES")
+		}
+
+		profiles := i18n.G("NO")
+		if shared.IsTrue(project.Config["features.profiles"]) {
+			profiles = i18n.G("YES")
+		}
+
+		name := project.Name
+		if name == current {
+			name = fmt.Sprintf("%s (%s)", name, i18n.G("current"))
+		}
+
+		strUsedBy := fmt.Sprintf("%d", len(project.UsedBy))
+		data = append(data, []string{name, images, profiles, strUsedBy})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetRowLine(true)
+	table.SetHeader([]string{
+		i18n.G("NAME"),
+		i18n.G("IMAGES"),
+		i18n.G("PROFILES"),
+		i18n.G("USED BY")})
+	sort.Sort(byName(data))
+	table.AppendBulk(data)
+	table.Render()
+
+	return nil
+}
+
+func (c *projectCmd) doProjectSet(client *lxd.Client, name string, args []string) error {
+	// we shifted @args so so it should read "<key> [<value>]"
+	if len(args) < 1 {
+		return errArgs
+	}
+
+	project, err := client.ProjectGet(name)
+	if err != nil {
+		return err
+	}
+
+	key := args[0]
+	var value string
+	if len(args) < 2 {
+		value = ""
+	} else {
+		value = args[1]
+	}
+
+	if !termios.IsTerminal(int(syscall.Stdin)) && value == "-" {
+		buf, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf(i18n.G("Can't read from stdin: %s"), err)
+		}
+		value = string(buf[:])
+	}
+
+	project.Config[key] = value
+
+	return client.ProjectPut(name, project.Writable())
+}
+
+func (c *projectCmd) doProjectShow(client *lxd.Client, name string) error {
+	project, err := client.ProjectGet(name)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(project.UsedBy)
+
+	data, err := yaml.Marshal(&project)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+func (c *projectCmd) doProjectSwitch(config *lxd.Config, client *lxd.Client, remote string, name string) error {
+	// Make sure the project exists
+	_, err := client.ProjectGet(name)
+	if err != nil {
+		return err
+	}
+
+	rc, ok := config.Remotes[remote]
+	if !ok {
+		return fmt.Errorf(i18n.G("remote %s doesn't exist"), remote)
+	}
+
+	rc.Project = name
+	config.Remotes[remote] = rc
+
+	return lxd.SaveConfig(config, configPath)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
@@ -27,6 +28,7 @@ type remoteCmd struct {
 	password   string
 	public     bool
 	protocol   string
+	keyring    string
 }
 
 func (c *remoteCmd) showByDefault() bool {
@@ -39,9 +41,12 @@ func (c *remoteCmd) usage() string {
 
 Manage the list of remote LXD servers.
 
-lxc remote add [<remote>] <IP|FQDN|URL> [--accept-certificate] [--password=PASSWORD] [--public] [--protocol=PROTOCOL]
+lxc remote add [<remote>] <IP|FQDN|URL> [--accept-certificate] [--password=PASSWORD] [--public] [--protocol=PROTOCOL] [--keyring=FILE]
     Add the remote <remote> at <url>.
 
+    The images of a simplestreams remote added with --keyring must be signed
+    by one of the GPG or minisign public keys of the file.
+
 lxc remote remove <remote>
     Remove the remote <remote>.
 
@@ -66,6 +71,7 @@ func (c *remoteCmd) flags() {
 	gnuflag.StringVar(&c.password, "password", "", i18n.G("Remote admin password"))
 	gnuflag.StringVar(&c.protocol, "protocol", "", i18n.G("Server protocol (lxd or simplestreams)"))
 	gnuflag.BoolVar(&c.public, "public", false, i18n.G("Public image server"))
+	gnuflag.StringVar(&c.keyring, "keyring", "", i18n.G("File with the public keys the images must be signed with"))
 }
 
 func (c *remoteCmd) generateClientCertificate(config *lxd.Config) error {
@@ -112,7 +118,7 @@ func (c *remoteCmd) getRemoteCertificate(address string) (*x509.Certificate, err
 	return resp.TLS.PeerCertificates[0], nil
 }
 
-func (c *remoteCmd) addServer(config *lxd.Config, server string, addr string, acceptCert bool, password string, public bool, protocol string) error {
+func (c *remoteCmd) addServer(config *lxd.Config, server string, addr string, acceptCert bool, password string, public bool, protocol string, keyring string) error {
 	var rScheme string
 	var rHost string
 	var rPort string
@@ -128,6 +134,10 @@ func (c *remoteCmd) addServer(config *lxd.Config, server string, addr string, ac
 		remoteURL = &url.URL{Host: addr}
 	}
 
+	if keyring != "" && protocol != "simplestreams" {
+		return fmt.Errorf(i18n.G("Signing keys are only supported for simplestreams remotes"))
+	}
+
 	// Fast track simplestreams
 	if protocol == "simplestreams" {
 		if remoteURL.Scheme != "https" {
@@ -135,6 +145,30 @@ func (c *remoteCmd) addServer(config *lxd.Config, server string, addr string, ac
 		}
 
 		config.Remotes[server] = lxd.RemoteConfig{Addr: addr, Public: true, Protocol: protocol}
+
+		if keyring != "" {
+			keys, err := ioutil.ReadFile(keyring)
+			if err != nil {
+				return err
+			}
+
+			err = os.MkdirAll(config.ConfigPath("serverkeys"), 0750)
+			if err != nil {
+				return err
+			}
+
+			err = ioutil.WriteFile(config.ServerKeysPath(server), keys, 0640)
+			if err != nil {
+				return err
+			}
+
+			// Make sure the keys can be used
+			_, err = lxd.NewClient(config, server)
+			if err != nil {
+				return err
+			}
+		}
+
 		return nil
 	}
 
@@ -310,6 +344,7 @@ func (c *remoteCmd) removeCertificate(config *lxd.Config, remote string) {
 	shared.LogDebugf("Trying to remove %s", certf)
 
 	os.Remove(certf)
+	os.Remove(config.ServerKeysPath(remote))
 }
 
 func (c *remoteCmd) run(config *lxd.Config, args []string) error {
@@ -333,7 +368,7 @@ func (c *remoteCmd) run(config *lxd.Config, args []string) error {
 			return fmt.Errorf(i18n.G("remote %s exists as <%s>"), remote, rc.Addr)
 		}
 
-		err := c.addServer(config, remote, fqdn, c.acceptCert, c.password, c.public, c.protocol)
+		err := c.addServer(config, remote, fqdn, c.acceptCert, c.password, c.public, c.protocol, c.keyring)
 		if err != nil {
 			delete(config.Remotes, remote)
 			c.removeCertificate(config, remote)
@@ -430,6 +465,14 @@ func (c *remoteCmd) run(config *lxd.Config, args []string) error {
 			}
 		}
 
+		// Rename the signing keys
+		if shared.PathExists(config.ServerKeysPath(args[1])) {
+			err := os.Rename(config.ServerKeysPath(args[1]), config.ServerKeysPath(args[2]))
+			if err != nil {
+				return err
+			}
+		}
+
 		config.Remotes[args[2]] = rc
 		delete(config.Remotes, args[1])
 
@@ -15,11 +15,13 @@ import (
 	"github.com/lxc/lxd"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/gnuflag"
 	"github.com/lxc/lxd/shared/i18n"
 	"github.com/lxc/lxd/shared/termios"
 )
 
 type storageCmd struct {
+	volumeOnly bool
 }
 
 func (c *storageCmd) showByDefault() bool {
@@ -125,6 +127,15 @@ lxc storage volume detach [<remote>:]<pool> <volume> <container> [device name]
 lxc storage volume detach-profile [<remote:>]<pool> <volume> <profile> [device name]
     Detach a storage volume from the specified profile.
 
+lxc storage volume copy [<remote>:]<pool>/<volume> [<remote>:]<pool>/[<volume>] [--volume-only]
+    Copy an existing volume to a new volume at the specified pool.
+
+lxc storage volume move [<remote>:]<pool>/<volume> [<remote>:]<pool>/[<volume>]
+    Move an existing volume to the specified pool, or rename it.
+
+lxc storage volume snapshot [<remote>:]<pool> <volume> [<snapshot name>]
+    Snapshot a storage volume.
+
 Unless specified through a prefix, all volume operations affect "custom" (user created) volumes.
 
 *Examples*
@@ -141,7 +152,9 @@ lxc storage volume show default container/data
     Will show the properties of the filesystem for a container called "data" in the "default" pool.`)
 }
 
-func (c *storageCmd) flags() {}
+func (c *storageCmd) flags() {
+	gnuflag.BoolVar(&c.volumeOnly, "volume-only", false, i18n.G("Copy the volume without its snapshots"))
+}
 
 func (c *storageCmd) run(config *lxd.Config, args []string) error {
 	if len(args) < 1 {
@@ -178,6 +191,11 @@ func (c *storageCmd) run(config *lxd.Config, args []string) error {
 			pool := args[2]
 			volume := args[3]
 			return c.doStoragePoolVolumeAttachProfile(client, pool, volume, args[4:])
+		case "copy", "move":
+			if len(args) != 4 {
+				return errArgs
+			}
+			return c.doStoragePoolVolumeCopy(config, args[2], args[3], args[1] == "move")
 		case "create":
 			if len(args) < 4 {
 				return errArgs
@@ -247,6 +265,15 @@ func (c *storageCmd) run(config *lxd.Config, args []string) error {
 			pool := args[2]
 			volume := args[3]
 			return c.doStoragePoolVolumeShow(client, pool, volume)
+		case "snapshot":
+			if len(args) < 4 || len(args) > 5 {
+				return errArgs
+			}
+			snapshotName := ""
+			if len(args) == 5 {
+				snapshotName = args[4]
+			}
+			return c.doStoragePoolVolumeSnapshot(config, args[2], args[3], snapshotName)
 		default:
 			return errArgs
 		}
@@ -776,6 +803,139 @@ func (c *storageCmd) doStoragePoolVolumeSet(client *lxd.Client, pool string, vol
 	return client.StoragePoolVolumeTypePut(pool, volName, volType, volumeConfig)
 }
 
+func (c *storageCmd) doStoragePoolVolumeCopy(config *lxd.Config, source string, target string, move bool) error {
+	sourceRemote, sourcePath := config.ParseRemoteAndContainer(source)
+	destRemote, destPath := config.ParseRemoteAndContainer(target)
+
+	sourceFields := strings.SplitN(sourcePath, "/", 2)
+	if len(sourceFields) != 2 || sourceFields[0] == "" || sourceFields[1] == "" {
+		return errArgs
+	}
+	sourcePool, sourceVolume := sourceFields[0], sourceFields[1]
+
+	destFields := strings.SplitN(destPath, "/", 2)
+	if destFields[0] == "" {
+		return errArgs
+	}
+	destPool, destVolume := destFields[0], sourceVolume
+	if len(destFields) == 2 && destFields[1] != "" {
+		destVolume = destFields[1]
+	}
+
+	// Moves always include the snapshots of the volume.
+	volumeOnly := c.volumeOnly && !move
+
+	sourceClient, err := lxd.NewClient(config, sourceRemote)
+	if err != nil {
+		return err
+	}
+
+	if sourceRemote == destRemote {
+		var resp *api.Response
+		if move {
+			resp, err = sourceClient.StoragePoolVolumeMove(sourcePool, sourceVolume, destPool, destVolume)
+		} else {
+			resp, err = sourceClient.StoragePoolVolumeCopy(sourcePool, sourceVolume, destPool, destVolume, volumeOnly)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = sourceClient.WaitForSuccess(resp.Operation)
+		if err != nil {
+			return err
+		}
+	} else {
+		destClient, err := lxd.NewClient(config, destRemote)
+		if err != nil {
+			return err
+		}
+
+		sourceWSResponse, err := sourceClient.GetStoragePoolVolumeMigrationSourceWS(sourcePool, sourceVolume, volumeOnly)
+		if err != nil {
+			return err
+		}
+
+		op, err := sourceWSResponse.MetadataAsOperation()
+		if err != nil {
+			return err
+		}
+
+		secrets := map[string]string{}
+		for k, v := range op.Metadata {
+			secrets[k] = v.(string)
+		}
+
+		addresses, err := sourceClient.Addresses()
+		if err != nil {
+			return err
+		}
+
+		// Try all the addresses of the source, like for containers
+		migrated := false
+		for _, addr := range addresses {
+			var migration *api.Response
+
+			sourceWSUrl := "https://" + addr + sourceWSResponse.Operation
+			migration, err = destClient.StoragePoolVolumeMigrateFrom(destPool, destVolume, sourceWSUrl, sourceClient.Certificate, secrets, volumeOnly)
+			if err != nil {
+				continue
+			}
+
+			if err = destClient.WaitForSuccess(migration.Operation); err != nil {
+				continue
+			}
+
+			if err = sourceClient.WaitForSuccess(sourceWSResponse.Operation); err != nil {
+				return err
+			}
+
+			migrated = true
+			break
+		}
+
+		if !migrated {
+			// Check for an error at the source
+			sourceOp, sourceErr := sourceClient.GetOperation(sourceWSResponse.Operation)
+			if sourceErr == nil && sourceOp.Err != "" {
+				return fmt.Errorf(i18n.G("Migration failed on source host: %s"), sourceOp.Err)
+			}
+
+			return fmt.Errorf(i18n.G("Migration failed on target host: %s"), err)
+		}
+
+		if move {
+			err = sourceClient.StoragePoolVolumeTypeDelete(sourcePool, sourceVolume, "custom")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if move {
+		fmt.Printf(i18n.G("Storage volume moved successfully!") + "\n")
+	} else {
+		fmt.Printf(i18n.G("Storage volume copied successfully!") + "\n")
+	}
+
+	return nil
+}
+
+func (c *storageCmd) doStoragePoolVolumeSnapshot(config *lxd.Config, pool string, volume string, snapshotName string) error {
+	remote, pool := config.ParseRemoteAndContainer(pool)
+	client, err := lxd.NewClient(config, remote)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.StoragePoolVolumeSnapshot(pool, volume, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	return client.WaitForSuccess(resp.Operation)
+}
+
 func (c *storageCmd) doStoragePoolVolumeShow(client *lxd.Client, pool string, volume string) error {
 	volName, volType := c.parseVolume(volume)
 	volumeStruct, err := client.StoragePoolVolumeTypeGet(pool, volName, volType)
@@ -21,10 +21,16 @@ var api10 = []Command{
 	containerCmd,
 	containerStateCmd,
 	containerFileCmd,
+	containerMetadataCmd,
+	containerMetadataTemplatesCmd,
+	containerMetadataTemplateCmd,
 	containerLogsCmd,
 	containerLogCmd,
 	containerSnapshotsCmd,
 	containerSnapshotCmd,
+	containerBackupsCmd,
+	containerBackupCmd,
+	containerBackupExportCmd,
 	containerExecCmd,
 	aliasCmd,
 	aliasesCmd,
@@ -39,6 +45,16 @@ var api10 = []Command{
 	operationWebsocket,
 	networksCmd,
 	networkCmd,
+	networkLeasesCmd,
+	networkStateCmd,
+	networkReservationsCmd,
+	networkReservationCmd,
+	networkDNSRecordsCmd,
+	networkDNSRecordCmd,
+	networkACLsCmd,
+	networkACLCmd,
+	networkForwardsCmd,
+	networkForwardCmd,
 	api10Cmd,
 	certificatesCmd,
 	certificateFingerprintCmd,
@@ -46,9 +62,21 @@ var api10 = []Command{
 	profileCmd,
 	storagePoolsCmd,
 	storagePoolCmd,
+	storagePoolResourcesCmd,
 	storagePoolVolumesCmd,
 	storagePoolVolumesTypeCmd,
+	storagePoolVolumeSnapshotsTypeCmd,
+	storagePoolVolumeSnapshotTypeCmd,
 	storagePoolVolumeTypeCmd,
+	clusterCmd,
+	clusterMembersCmd,
+	clusterMemberCmd,
+	clusterMemberContainersCmd,
+	clusterJournalCmd,
+	projectsCmd,
+	projectCmd,
+	metricsCmd,
+	resourcesCmd,
 }
 
 func api10Get(d *Daemon, r *http.Request) Response {
@@ -101,6 +129,32 @@ func api10Get(d *Daemon, r *http.Request) Response {
 			"container_only_migration",
 			"storage_zfs_clone_copy",
 			"unix_device_rename",
+			"container_backup",
+			"storage_driver_ceph",
+			"clustering",
+			"projects",
+			"certificate_permissions",
+			"proxy",
+			"metrics",
+			"snapshot_scheduling",
+			"storage_api_volume_handling",
+			"resources",
+			"network_leases",
+			"network_state",
+			"network_acl",
+			"network_firewall_nftables",
+			"network_reservations",
+			"network_dns_records",
+			"network_ovs_vlan",
+			"nic_routed_ipvlan",
+			"nic_sriov",
+			"network_forwards",
+			"network_limits",
+			"network_ipv6_delegated",
+			"image_signatures",
+			"images_streams",
+			"image_compression_zstd",
+			"container_edit_metadata",
 		},
 		APIStatus:  "stable",
 		APIVersion: version.APIVersion,
@@ -184,6 +238,10 @@ func api10Get(d *Daemon, r *http.Request) Response {
 		ServerPid:          os.Getpid(),
 		ServerVersion:      version.Version}
 
+	if d.firewall != nil {
+		env.Firewall = d.firewall.String()
+	}
+
 	drivers := readStoragePoolDriversCache()
 	for _, driver := range drivers {
 		// Initialize a core storage interface for the given driver.
@@ -303,6 +361,11 @@ func doApi10Update(d *Daemon, oldConfig map[string]string, req api.ServerPut) Re
 			return BadRequest(fmt.Errorf("Bad server config key: '%s'", key))
 		}
 
+		// Other cluster members know this server by its address
+		if key == "core.https_address" && clusterLocalName(d) != "" {
+			return BadRequest(fmt.Errorf("core.https_address can't be changed on clustered servers"))
+		}
+
 		err := confKey.Set(d, value)
 		if err != nil {
 			return SmartError(err)
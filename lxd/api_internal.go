@@ -443,6 +443,7 @@ func internalImport(d *Daemon, r *http.Request) Response {
 			Config:       snap.Config,
 			CreationDate: snap.CreationDate,
 			LastUsedDate: snap.LastUsedDate,
+			ExpiryDate:   snap.ExpiresAt,
 			Ctype:        cTypeSnapshot,
 			Devices:      snap.Devices,
 			Ephemeral:    snap.Ephemeral,
@@ -0,0 +1,542 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/osarch"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+// backupArgs holds the database record of a container backup.
+type backupArgs struct {
+	ID            int
+	ContainerID   int
+	Name          string
+	CreationDate  time.Time
+	ExpiryDate    time.Time
+	ContainerOnly bool
+}
+
+// backupInfo is the content of the index.yaml file found at the root of
+// every backup tarball.
+type backupInfo struct {
+	Name      string   `yaml:"name"`
+	Backend   string   `yaml:"backend"`
+	Pool      string   `yaml:"pool"`
+	Snapshots []string `yaml:"snapshots,omitempty"`
+}
+
+// backup represents a container backup.
+type backup struct {
+	d         *Daemon
+	container container
+
+	// Properties
+	id            int
+	name          string
+	creationDate  time.Time
+	expiryDate    time.Time
+	containerOnly bool
+}
+
+// Load a backup of the given container from the database.
+func backupLoadByName(d *Daemon, c container, name string) (*backup, error) {
+	args, err := dbContainerBackupGet(d.db, c.Id(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backup{
+		d:             d,
+		container:     c,
+		id:            args.ID,
+		name:          args.Name,
+		creationDate:  args.CreationDate,
+		expiryDate:    args.ExpiryDate,
+		containerOnly: args.ContainerOnly,
+	}, nil
+}
+
+// Create a new backup of the given container.
+func backupCreate(d *Daemon, args backupArgs, sourceContainer container) error {
+	// Create the database entry
+	err := dbContainerBackupCreate(d.db, args)
+	if err != nil {
+		if err == DbErrAlreadyDefined {
+			return fmt.Errorf("Backup \"%s\" already exists", args.Name)
+		}
+
+		return err
+	}
+
+	b, err := backupLoadByName(d, sourceContainer, args.Name)
+	if err != nil {
+		return err
+	}
+
+	// Now create the tarball
+	err = backupCreateTarball(b)
+	if err != nil {
+		dbContainerBackupRemove(d.db, b.id)
+		return err
+	}
+
+	return nil
+}
+
+// Name returns the name of the backup.
+func (b *backup) Name() string {
+	return b.name
+}
+
+// Path returns the path of the backup tarball.
+func (b *backup) Path() string {
+	return shared.VarPath("backups", b.container.Name(), b.name)
+}
+
+// Rename renames the backup.
+func (b *backup) Rename(newName string) error {
+	oldPath := b.Path()
+	newPath := shared.VarPath("backups", b.container.Name(), newName)
+
+	if shared.PathExists(newPath) {
+		return fmt.Errorf("Backup \"%s\" already exists", newName)
+	}
+
+	// Rename the tarball
+	if shared.PathExists(oldPath) {
+		err := os.Rename(oldPath, newPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Rename the database entry
+	err := dbContainerBackupRename(b.d.db, b.id, newName)
+	if err != nil {
+		return err
+	}
+
+	b.name = newName
+
+	return nil
+}
+
+// Delete removes the backup.
+func (b *backup) Delete() error {
+	// Remove the tarball
+	if shared.PathExists(b.Path()) {
+		err := os.Remove(b.Path())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove the database record
+	return dbContainerBackupRemove(b.d.db, b.id)
+}
+
+// Render returns the API representation of the backup.
+func (b *backup) Render() *api.ContainerBackup {
+	return &api.ContainerBackup{
+		Name:          b.name,
+		CreationDate:  b.creationDate,
+		ExpiryDate:    b.expiryDate,
+		ContainerOnly: b.containerOnly,
+	}
+}
+
+// Append the content of a directory to a tarball, rooting it at prefix.
+func backupTarAppend(tarPath string, path string, prefix string) error {
+	// The "S" flag prevents the transformation from applying to symlink
+	// targets.
+	transform := fmt.Sprintf("s,^\\.,%s,S", prefix)
+
+	_, err := shared.RunCommand("tar", "-rf", tarPath, "--numeric-owner", "--xattrs", "-C", path, "--transform", transform, ".")
+	return err
+}
+
+// Create the backup tarball. Its layout is as follows:
+//   - backup/index.yaml
+//   - backup/container/ (the container directory, including backup.yaml)
+//   - backup/snapshots/<name>/ (one per snapshot, unless container only)
+func backupCreateTarball(b *backup) error {
+	c := b.container
+
+	// Get the list of snapshots to include
+	snapshots := []container{}
+	if !b.containerOnly {
+		var err error
+		snapshots, err = c.Snapshots()
+		if err != nil {
+			return err
+		}
+	}
+
+	poolName, err := c.StoragePool()
+	if err != nil {
+		return err
+	}
+
+	// Prepare the index
+	_, name := projectSplitName(c.Name())
+	info := backupInfo{
+		Name:    name,
+		Backend: c.Storage().GetStorageTypeName(),
+		Pool:    poolName,
+	}
+
+	for _, snap := range snapshots {
+		info.Snapshots = append(info.Snapshots, strings.SplitN(snap.Name(), shared.SnapshotDelimiter, 2)[1])
+	}
+
+	data, err := yaml.Marshal(&info)
+	if err != nil {
+		return err
+	}
+
+	// Create a temporary directory to build the tarball in
+	tmpPath, err := ioutil.TempDir(shared.VarPath("backups"), "lxd_backup_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpPath)
+
+	err = os.Mkdir(filepath.Join(tmpPath, "backup"), 0700)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(tmpPath, "backup", "index.yaml"), data, 0644)
+	if err != nil {
+		return err
+	}
+
+	// The index always comes first so it can be read cheaply
+	tarPath := filepath.Join(tmpPath, "backup.tar")
+	_, err = shared.RunCommand("tar", "-cf", tarPath, "-C", tmpPath, "backup/index.yaml")
+	if err != nil {
+		return err
+	}
+
+	// Add the snapshots
+	for _, snap := range snapshots {
+		ourStart, err := snap.StorageStart()
+		if err != nil {
+			return err
+		}
+
+		snapName := strings.SplitN(snap.Name(), shared.SnapshotDelimiter, 2)[1]
+		err = backupTarAppend(tarPath, snap.Path(), fmt.Sprintf("backup/snapshots/%s", snapName))
+		if ourStart {
+			snap.StorageStop()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add the container
+	ourStart, err := c.StorageStart()
+	if err != nil {
+		return err
+	}
+	if ourStart {
+		defer c.StorageStop()
+	}
+
+	// Make sure backup.yaml is current
+	err = writeBackupFile(c)
+	if err != nil {
+		return err
+	}
+
+	err = backupTarAppend(tarPath, c.Path(), "backup/container")
+	if err != nil {
+		return err
+	}
+
+	// Compress it
+	compressedPath := tarPath
+	compress := daemonConfig["backups.compression_algorithm"].Get()
+	if compress != "none" {
+		compressedPath, err = compressFile(tarPath, compress)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Move it into place
+	err = os.MkdirAll(shared.VarPath("backups", c.Name()), 0700)
+	if err != nil {
+		return err
+	}
+
+	return shared.FileMove(compressedPath, b.Path())
+}
+
+// Extract a single file from a backup tarball.
+func backupReadFile(path string, name string) ([]byte, error) {
+	extractArgs, extension, err := detectCompression(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(extension, ".tar") {
+		return nil, fmt.Errorf("Unsupported backup format: %s", extension)
+	}
+
+	args := append(extractArgs, path, "-O", name)
+	output, err := exec.Command("tar", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read \"%s\" from the backup: %s", name, err)
+	}
+
+	return output, nil
+}
+
+// Extract a directory of a backup tarball into the given path.
+func backupExtract(path string, name string, target string) error {
+	extractArgs, _, err := detectCompression(path)
+	if err != nil {
+		return err
+	}
+
+	strip := fmt.Sprintf("--strip-components=%d", strings.Count(name, "/")+1)
+	args := []string{"-C", target, "--numeric-owner", "--xattrs", strip}
+	args = append(args, extractArgs...)
+	args = append(args, path, name)
+
+	_, err = shared.RunCommand("tar", args...)
+	return err
+}
+
+// Remove everything inside of the given directory.
+func backupClearPath(path string) error {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		err := os.RemoveAll(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Drop the volatile keys which shouldn't survive a restore.
+func backupFilterConfig(config map[string]string) map[string]string {
+	result := map[string]string{}
+	for k, v := range config {
+		if strings.HasPrefix(k, "volatile.") && !shared.StringInSlice(k, []string{"volatile.base_image", "volatile.last_state.idmap"}) {
+			continue
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
+
+// Create a new container (and its snapshots) from a backup tarball.
+func containerCreateFromBackup(d *Daemon, project string, path string) (container, error) {
+	// Read the index
+	data, err := backupReadFile(path, "backup/index.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	info := backupInfo{}
+	err = yaml.Unmarshal(data, &info)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the container definition
+	data, err = backupReadFile(path, "backup/container/backup.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	bf := backupFile{}
+	err = yaml.Unmarshal(data, &bf)
+	if err != nil {
+		return nil, err
+	}
+
+	if bf.Container == nil {
+		return nil, fmt.Errorf("No container definition found in the backup")
+	}
+
+	name := projectPrefix(project, bf.Container.Name)
+	_, err = dbContainerId(d.db, name)
+	if err == nil {
+		return nil, fmt.Errorf("Container \"%s\" already exists", bf.Container.Name)
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	arch, err := osarch.ArchitectureId(bf.Container.Architecture)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the container
+	c, err := containerCreateAsEmpty(d, containerArgs{
+		Architecture: arch,
+		BaseImage:    bf.Container.Config["volatile.base_image"],
+		Config:       backupFilterConfig(bf.Container.Config),
+		Ctype:        cTypeRegular,
+		Devices:      bf.Container.Devices,
+		Ephemeral:    bf.Container.Ephemeral,
+		Name:         name,
+		Profiles:     bf.Container.Profiles,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = backupRestoreContent(d, c, path, info, bf.Snapshots)
+	if err != nil {
+		c.Delete()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func backupRestoreContent(d *Daemon, c container, path string, info backupInfo, snapshots []*api.ContainerSnapshot) error {
+	ourStart, err := c.StorageStart()
+	if err != nil {
+		return err
+	}
+	if ourStart {
+		defer c.StorageStop()
+	}
+
+	isDirBackend := c.Storage().GetStorageType() == storageTypeDir
+
+	// Restore the snapshots, oldest first
+	for _, snapName := range info.Snapshots {
+		var snap *api.ContainerSnapshot
+		for _, s := range snapshots {
+			if s.Name == fmt.Sprintf("%s%s%s", info.Name, shared.SnapshotDelimiter, snapName) {
+				snap = s
+				break
+			}
+		}
+
+		if snap == nil {
+			shared.LogWarn("Snapshot missing from backup.yaml, skipping", log.Ctx{"container": c.Name(), "snapshot": snapName})
+			continue
+		}
+
+		arch, err := osarch.ArchitectureId(snap.Architecture)
+		if err != nil {
+			return err
+		}
+
+		args := containerArgs{
+			Architecture: arch,
+			BaseImage:    snap.Config["volatile.base_image"],
+			Config:       backupFilterConfig(snap.Config),
+			Ctype:        cTypeSnapshot,
+			Devices:      snap.Devices,
+			Ephemeral:    snap.Ephemeral,
+			Name:         c.Name() + shared.SnapshotDelimiter + snapName,
+			Profiles:     snap.Profiles,
+		}
+
+		source := fmt.Sprintf("backup/snapshots/%s", snapName)
+		if isDirBackend {
+			s, err := containerCreateEmptySnapshot(d, args)
+			if err != nil {
+				return err
+			}
+
+			err = backupExtract(path, source, s.Path())
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err = backupClearPath(c.Path())
+		if err != nil {
+			return err
+		}
+
+		err = backupExtract(path, source, c.Path())
+		if err != nil {
+			return err
+		}
+
+		_, err = containerCreateAsSnapshot(d, args, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Restore the container itself
+	if !isDirBackend && len(info.Snapshots) > 0 {
+		err = backupClearPath(c.Path())
+		if err != nil {
+			return err
+		}
+	}
+
+	err = backupExtract(path, "backup/container", c.Path())
+	if err != nil {
+		return err
+	}
+
+	return writeBackupFile(c)
+}
+
+func pruneExpiredContainerBackups(d *Daemon) {
+	shared.LogInfof("Pruning expired container backups")
+
+	backups, err := dbContainerBackupsGetExpired(d.db, time.Now().UTC())
+	if err != nil {
+		shared.LogError("Unable to retrieve the list of expired container backups", log.Ctx{"err": err})
+		return
+	}
+
+	for cname, names := range backups {
+		c, err := containerLoadByName(d, cname)
+		if err != nil {
+			continue
+		}
+
+		for _, name := range names {
+			b, err := backupLoadByName(d, c, name)
+			if err != nil {
+				continue
+			}
+
+			err = b.Delete()
+			if err != nil {
+				shared.LogError("Error deleting container backup", log.Ctx{"container": cname, "backup": name, "err": err})
+			}
+		}
+	}
+
+	shared.LogInfof("Done pruning expired container backups")
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
 
 	"github.com/gorilla/mux"
 
@@ -27,15 +32,7 @@ func certificatesGet(d *Daemon, r *http.Request) Response {
 			return SmartError(err)
 		}
 		for _, baseCert := range baseCerts {
-			resp := api.Certificate{}
-			resp.Fingerprint = baseCert.Fingerprint
-			resp.Certificate = baseCert.Certificate
-			if baseCert.Type == 1 {
-				resp.Type = "client"
-			} else {
-				resp.Type = "unknown"
-			}
-			certResponses = append(certResponses, resp)
+			certResponses = append(certResponses, certificateRender(baseCert))
 		}
 		return SyncResponse(true, certResponses)
 	}
@@ -51,6 +48,7 @@ func certificatesGet(d *Daemon, r *http.Request) Response {
 
 func readSavedClientCAList(d *Daemon) {
 	d.clientCerts = []x509.Certificate{}
+	d.clientCertsInfo = map[string]*dbCertInfo{}
 
 	dbCerts, err := dbCertsGet(d.db)
 	if err != nil {
@@ -71,10 +69,11 @@ func readSavedClientCAList(d *Daemon) {
 			continue
 		}
 		d.clientCerts = append(d.clientCerts, *cert)
+		d.clientCertsInfo[dbCert.Fingerprint] = dbCert
 	}
 }
 
-func saveCert(d *Daemon, host string, cert *x509.Certificate) error {
+func saveCert(d *Daemon, host string, cert *x509.Certificate, req api.CertificatePut) error {
 	baseCert := new(dbCertInfo)
 	baseCert.Fingerprint = shared.CertFingerprint(cert)
 	baseCert.Type = 1
@@ -82,10 +81,269 @@ func saveCert(d *Daemon, host string, cert *x509.Certificate) error {
 	baseCert.Certificate = string(
 		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
 	)
+	baseCert.Restricted = req.Restricted
+	baseCert.Permission = req.Permission
+	if baseCert.Permission == "" {
+		baseCert.Permission = "admin"
+	}
+	baseCert.Containers = req.Containers
+	baseCert.Projects = req.Projects
 
 	return dbCertSave(d.db, baseCert)
 }
 
+// certificateRender converts a database certificate entry into its API form.
+func certificateRender(cert *dbCertInfo) api.Certificate {
+	resp := api.Certificate{}
+
+	resp.Fingerprint = cert.Fingerprint
+	resp.Certificate = cert.Certificate
+	resp.Name = cert.Name
+	if cert.Type == 1 {
+		resp.Type = "client"
+	} else {
+		resp.Type = "unknown"
+	}
+
+	resp.Restricted = cert.Restricted
+	resp.Permission = cert.Permission
+	resp.Containers = cert.Containers
+	resp.Projects = cert.Projects
+
+	return resp
+}
+
+// certificateValidate checks and normalizes the permission and restrictions
+// of a certificate.
+func certificateValidate(req *api.CertificatePut) error {
+	if req.Permission == "" {
+		req.Permission = "admin"
+	}
+
+	if !shared.StringInSlice(req.Permission, certificatePermissions) {
+		return fmt.Errorf("Invalid certificate permission: %s", req.Permission)
+	}
+
+	if req.Containers == nil {
+		req.Containers = []string{}
+	}
+
+	if req.Projects == nil {
+		req.Projects = []string{}
+	}
+
+	if !req.Restricted && (req.Permission != "admin" || len(req.Containers) > 0 || len(req.Projects) > 0) {
+		return fmt.Errorf("Permissions and restrictions only apply to restricted certificates")
+	}
+
+	return nil
+}
+
+// certificatePermissions lists the permissions a restricted certificate can
+// be given, from the least to the most privileged.
+var certificatePermissions = []string{"readonly", "operator", "admin"}
+
+// certificateOperatorEndpoints lists the non-GET requests allowed to
+// certificates with the "operator" permission.
+var certificateOperatorEndpoints = map[string][]string{
+	"containers/{name}/state": {"PUT"},
+	"containers/{name}/exec":  {"POST"},
+	"containers/{name}/files": {"POST", "DELETE"},
+	"operations/{id}":         {"DELETE"},
+}
+
+// certificateScopedEndpoints lists the endpoints usable by certificates
+// restricted to a set of containers or projects.
+var certificateScopedEndpoints = []string{"", "containers", "images", "profiles", "operations", "events"}
+
+// certificateRequestInfo returns the restricted certificate used for the
+// request or nil if the client isn't restricted.
+func certificateRequestInfo(d *Daemon, r *http.Request) *dbCertInfo {
+	if r.RemoteAddr == "@" || r.TLS == nil {
+		return nil
+	}
+
+	// Requests forwarded by other cluster members carry the certificate
+	// of the original client
+	if clusterIsForwarded(d, r) {
+		cert, ok := d.clientCertsInfo[r.Header.Get(clusterForwardedCertificateHeader)]
+		if ok && cert.Restricted {
+			return cert
+		}
+
+		return nil
+	}
+
+	for i := range r.TLS.PeerCertificates {
+		cert, ok := d.clientCertsInfo[shared.CertFingerprint(r.TLS.PeerCertificates[i])]
+		if ok && cert.Restricted {
+			return cert
+		}
+	}
+
+	return nil
+}
+
+// certificateAccessCheck returns an error if the client certificate isn't
+// allowed to perform the request.
+func certificateAccessCheck(d *Daemon, c Command, r *http.Request) error {
+	cert := certificateRequestInfo(d, r)
+	if cert == nil {
+		return nil
+	}
+
+	// Restricted clients can't manage the trust store
+	if c.name == "certificates" || strings.HasPrefix(c.name, "certificates/") {
+		return fmt.Errorf("Restricted certificates can't manage certificates")
+	}
+
+	// Nor the server configuration or the cluster, both of which would
+	// let them lift their restrictions
+	if c.name == "" && r.Method != "GET" {
+		return fmt.Errorf("Restricted certificates can't change the server configuration")
+	}
+
+	if c.name == "cluster" || strings.HasPrefix(c.name, "cluster/") {
+		return fmt.Errorf("Restricted certificates can't manage the cluster")
+	}
+
+	switch cert.Permission {
+	case "admin":
+	case "operator":
+		if r.Method != "GET" && !shared.StringInSlice(r.Method, certificateOperatorEndpoints[c.name]) {
+			return fmt.Errorf("Operator certificates can't %s %s", r.Method, c.name)
+		}
+	default:
+		if r.Method != "GET" {
+			return fmt.Errorf("Read-only certificates can't %s %s", r.Method, c.name)
+		}
+	}
+
+	if len(cert.Containers) == 0 && len(cert.Projects) == 0 {
+		return nil
+	}
+
+	endpoint := strings.SplitN(c.name, "/", 2)[0]
+	if !shared.StringInSlice(endpoint, certificateScopedEndpoints) {
+		return fmt.Errorf("Certificate is restricted to containers or projects")
+	}
+
+	if len(cert.Projects) > 0 && !shared.StringInSlice(projectParam(r), cert.Projects) {
+		return fmt.Errorf("Certificate isn't allowed to access project '%s'", projectParam(r))
+	}
+
+	if len(cert.Containers) > 0 && endpoint == "containers" {
+		if c.name == "containers" {
+			if r.Method != "GET" {
+				return fmt.Errorf("Certificate is restricted to existing containers")
+			}
+
+			return nil
+		}
+
+		name := mux.Vars(r)["name"]
+		if !certificateAllowedContainer(cert, projectParam(r), name) {
+			return fmt.Errorf("Certificate isn't allowed to access container '%s'", name)
+		}
+	}
+
+	// Images and profiles are shared with other containers
+	if len(cert.Containers) > 0 && (endpoint == "images" || endpoint == "profiles") && r.Method != "GET" {
+		return fmt.Errorf("Certificate is restricted to containers, %s are read-only", endpoint)
+	}
+
+	// Operations of other cluster members are checked by those members
+	if len(cert.Containers) > 0 && strings.HasPrefix(c.name, "operations/{id}") {
+		id := mux.Vars(r)["id"]
+		op, err := operationGet(id)
+		if err == nil {
+			_, md, err := op.Render()
+			if err != nil || !certificateAllowedOperation(cert, md) {
+				return fmt.Errorf("Certificate isn't allowed to access operation '%s'", id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// certificateAllowedContainer checks whether a certificate restricted to some
+// containers may access the given one. Containers of other projects than the
+// default one are listed as "<project>/<name>".
+func certificateAllowedContainer(cert *dbCertInfo, project string, name string) bool {
+	if project != "" && project != "default" {
+		name = fmt.Sprintf("%s/%s", project, name)
+	}
+
+	return shared.StringInSlice(name, cert.Containers)
+}
+
+// certificateAllowedOperation checks whether a restricted certificate may see
+// an operation, which must be about one of its containers.
+func certificateAllowedOperation(cert *dbCertInfo, op *api.Operation) bool {
+	if cert == nil || len(cert.Containers) == 0 {
+		return true
+	}
+
+	prefix := fmt.Sprintf("/%s/containers/", version.APIVersion)
+	for _, resource := range op.Resources["containers"] {
+		if !strings.HasPrefix(resource, prefix) {
+			continue
+		}
+
+		// Operations refer to containers and snapshots by their
+		// stored name or by the name and project used in the request
+		fields := strings.SplitN(strings.TrimPrefix(resource, prefix), "?", 2)
+		name := strings.SplitN(fields[0], shared.SnapshotDelimiter, 2)[0]
+		project, name := projectSplitName(name)
+		if len(fields) == 2 {
+			query, err := url.ParseQuery(fields[1])
+			if err == nil && query.Get("project") != "" {
+				project = query.Get("project")
+			}
+		}
+
+		if certificateAllowedContainer(cert, project, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// certificateFilterContainers drops the containers a restricted certificate
+// can't access from a container listing.
+func certificateFilterContainers(d *Daemon, r *http.Request, result interface{}) interface{} {
+	cert := certificateRequestInfo(d, r)
+	if cert == nil || len(cert.Containers) == 0 {
+		return result
+	}
+
+	switch containers := result.(type) {
+	case []string:
+		filtered := []string{}
+		for _, url := range containers {
+			name := strings.SplitN(filepath.Base(url), "?", 2)[0]
+			if certificateAllowedContainer(cert, projectParam(r), name) {
+				filtered = append(filtered, url)
+			}
+		}
+
+		return filtered
+	case []*api.Container:
+		filtered := []*api.Container{}
+		for _, container := range containers {
+			if certificateAllowedContainer(cert, projectParam(r), container.Name) {
+				filtered = append(filtered, container)
+			}
+		}
+
+		return filtered
+	}
+
+	return result
+}
+
 func certificatesPost(d *Daemon, r *http.Request) Response {
 	// Parse the request
 	req := api.CertificatesPost{}
@@ -102,6 +360,11 @@ func certificatesPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("Unknown request type %s", req.Type))
 	}
 
+	err := certificateValidate(&req.CertificatePut)
+	if err != nil {
+		return BadRequest(err)
+	}
+
 	// Extract the certificate
 	var cert *x509.Certificate
 	var name string
@@ -139,12 +402,20 @@ func certificatesPost(d *Daemon, r *http.Request) Response {
 		}
 	}
 
-	err := saveCert(d, name, cert)
+	err = saveCert(d, name, cert, req.CertificatePut)
 	if err != nil {
 		return SmartError(err)
 	}
 
-	d.clientCerts = append(d.clientCerts, *cert)
+	readSavedClientCAList(d)
+
+	// Have the other cluster members trust the client too
+	if !clusterIsForwarded(d, r) {
+		notify := api.CertificatesPost{Certificate: base64.StdEncoding.EncodeToString(cert.Raw)}
+		notify.CertificatePut = req.CertificatePut
+		notify.Name = name
+		clusterNotify(d, "POST", "/1.0/certificates", notify)
+	}
 
 	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/certificates/%s", version.APIVersion, fingerprint))
 }
@@ -163,23 +434,12 @@ func certificateFingerprintGet(d *Daemon, r *http.Request) Response {
 }
 
 func doCertificateGet(d *Daemon, fingerprint string) (api.Certificate, error) {
-	resp := api.Certificate{}
-
 	dbCertInfo, err := dbCertGet(d.db, fingerprint)
 	if err != nil {
-		return resp, err
+		return api.Certificate{}, err
 	}
 
-	resp.Fingerprint = dbCertInfo.Fingerprint
-	resp.Certificate = dbCertInfo.Certificate
-	resp.Name = dbCertInfo.Name
-	if dbCertInfo.Type == 1 {
-		resp.Type = "client"
-	} else {
-		resp.Type = "unknown"
-	}
-
-	return resp, nil
+	return certificateRender(dbCertInfo), nil
 }
 
 func certificateFingerprintPut(d *Daemon, r *http.Request) Response {
@@ -218,9 +478,22 @@ func certificateFingerprintPatch(d *Daemon, r *http.Request) Response {
 		return PreconditionFailed(err)
 	}
 
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	rdr1 := ioutil.NopCloser(bytes.NewBuffer(body))
+	rdr2 := ioutil.NopCloser(bytes.NewBuffer(body))
+
 	req := oldEntry
 	reqRaw := shared.Jmap{}
-	if err := json.NewDecoder(r.Body).Decode(&reqRaw); err != nil {
+	if err := json.NewDecoder(rdr1).Decode(&reqRaw); err != nil {
+		return BadRequest(err)
+	}
+
+	reqNew := api.CertificatePut{}
+	if err := json.NewDecoder(rdr2).Decode(&reqNew); err != nil {
 		return BadRequest(err)
 	}
 
@@ -236,6 +509,25 @@ func certificateFingerprintPatch(d *Daemon, r *http.Request) Response {
 		req.Type = value
 	}
 
+	// Get restrictions
+	restricted, err := reqRaw.GetBool("restricted")
+	if err == nil {
+		req.Restricted = restricted
+	}
+
+	value, err = reqRaw.GetString("permission")
+	if err == nil {
+		req.Permission = value
+	}
+
+	if reqNew.Containers != nil {
+		req.Containers = reqNew.Containers
+	}
+
+	if reqNew.Projects != nil {
+		req.Projects = reqNew.Projects
+	}
+
 	return doCertificateUpdate(d, fingerprint, req.Writable())
 }
 
@@ -244,11 +536,27 @@ func doCertificateUpdate(d *Daemon, fingerprint string, req api.CertificatePut)
 		return BadRequest(fmt.Errorf("Unknown request type %s", req.Type))
 	}
 
-	err := dbCertUpdate(d.db, fingerprint, req.Name, 1)
+	err := certificateValidate(&req)
 	if err != nil {
-		return InternalError(err)
+		return BadRequest(err)
 	}
 
+	cert := dbCertInfo{
+		Type:       1,
+		Name:       req.Name,
+		Restricted: req.Restricted,
+		Permission: req.Permission,
+		Containers: req.Containers,
+		Projects:   req.Projects,
+	}
+
+	err = dbCertUpdate(d.db, fingerprint, &cert)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	readSavedClientCAList(d)
+
 	return EmptySyncResponse
 }
 
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func Test_certificateAccessCheck(t *testing.T) {
+	cert := &x509.Certificate{Raw: []byte("runner")}
+	admin := &x509.Certificate{Raw: []byte("admin")}
+
+	d := &Daemon{}
+	d.clientCertsInfo = map[string]*dbCertInfo{
+		shared.CertFingerprint(cert): {
+			Restricted: true,
+			Permission: "operator",
+			Containers: []string{"ci/build1"},
+			Projects:   []string{"ci"},
+		},
+		shared.CertFingerprint(admin): {
+			Restricted: true,
+			Permission: "admin",
+		},
+	}
+
+	operationsLock.Lock()
+	operations["op1"] = &operation{id: "op1", resources: map[string][]string{"containers": {"ci_build1"}}}
+	operations["op2"] = &operation{id: "op2", resources: map[string][]string{"containers": {"build2"}}}
+	operationsLock.Unlock()
+
+	defer func() {
+		operationsLock.Lock()
+		delete(operations, "op1")
+		delete(operations, "op2")
+		operationsLock.Unlock()
+	}()
+
+	tests := []struct {
+		cert    *x509.Certificate
+		command string
+		method  string
+		url     string
+		allowed bool
+	}{
+		{cert, "containers/{name}", "GET", "/1.0/containers/build1?project=ci", true},
+		{cert, "containers/{name}/exec", "POST", "/1.0/containers/build1/exec?project=ci", true},
+		{cert, "containers/{name}/state", "PUT", "/1.0/containers/build1/state?project=ci", true},
+		{cert, "containers/{name}", "DELETE", "/1.0/containers/build1?project=ci", false},
+		{cert, "containers/{name}/exec", "POST", "/1.0/containers/build2/exec?project=ci", false},
+		{cert, "containers/{name}/exec", "POST", "/1.0/containers/build1/exec", false},
+		{cert, "containers", "GET", "/1.0/containers?project=ci", true},
+		{cert, "containers", "POST", "/1.0/containers?project=ci", false},
+		{cert, "images", "GET", "/1.0/images?project=ci", true},
+		{cert, "images", "POST", "/1.0/images?project=ci", false},
+		{cert, "images/{fingerprint}", "DELETE", "/1.0/images/abcd?project=ci", false},
+		{cert, "profiles/{name}", "GET", "/1.0/profiles/default?project=ci", true},
+		{cert, "profiles/{name}", "PUT", "/1.0/profiles/default?project=ci", false},
+		{cert, "storage-pools/{name}", "GET", "/1.0/storage-pools/default", false},
+		{cert, "certificates", "GET", "/1.0/certificates", false},
+		{cert, "operations/{id}", "GET", "/1.0/operations/op1?project=ci", true},
+		{cert, "operations/{id}/wait", "GET", "/1.0/operations/op1/wait?project=ci", true},
+		{cert, "operations/{id}", "GET", "/1.0/operations/op2?project=ci", false},
+		{cert, "operations/{id}/wait", "GET", "/1.0/operations/op2/wait?project=ci", false},
+		{cert, "operations/{id}", "DELETE", "/1.0/operations/op2?project=ci", false},
+		{admin, "", "GET", "/1.0", true},
+		{admin, "", "PUT", "/1.0", false},
+		{admin, "", "PATCH", "/1.0", false},
+		{admin, "cluster", "PUT", "/1.0/cluster", false},
+		{admin, "cluster/members", "POST", "/1.0/cluster/members", false},
+		{admin, "cluster/members/{name}", "DELETE", "/1.0/cluster/members/node2", false},
+		{admin, "profiles/{name}", "PUT", "/1.0/profiles/default", true},
+	}
+
+	for _, test := range tests {
+		c := Command{name: test.command}
+
+		var err error
+		router := mux.NewRouter()
+		router.HandleFunc(strings.TrimSuffix("/1.0/"+test.command, "/"), func(w http.ResponseWriter, r *http.Request) {
+			err = certificateAccessCheck(d, c, r)
+		})
+
+		r := httptest.NewRequest(test.method, test.url, nil)
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{test.cert}}
+		router.ServeHTTP(httptest.NewRecorder(), r)
+
+		if test.allowed && err != nil {
+			t.Errorf("%s %s should be allowed: %v", test.method, test.url, err)
+		} else if !test.allowed && err == nil {
+			t.Errorf("%s %s should be refused", test.method, test.url)
+		}
+	}
+
+	// Unix socket clients aren't restricted
+	r := httptest.NewRequest("DELETE", "/1.0/storage-pools/default", nil)
+	r.RemoteAddr = "@"
+	err := certificateAccessCheck(d, Command{name: "storage-pools/{name}"}, r)
+	if err != nil {
+		t.Errorf("Local clients should be allowed: %v", err)
+	}
+}
+
+func Test_certificateForwardedRequest(t *testing.T) {
+	member := x509.Certificate{Raw: []byte("member")}
+	cert := &x509.Certificate{Raw: []byte("runner")}
+
+	d := &Daemon{}
+	d.clusterCerts = []x509.Certificate{member}
+	d.clientCertsInfo = map[string]*dbCertInfo{
+		shared.CertFingerprint(cert): {
+			Restricted: true,
+			Permission: "readonly",
+			Containers: []string{"build1"},
+		},
+	}
+
+	// Members forwarding requests pass the restrictions of the client along
+	r := httptest.NewRequest("GET", "/1.0/operations", nil)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{&member}}
+	r.Header.Set(clusterForwardedHeader, "1")
+	r.Header.Set(clusterForwardedCertificateHeader, shared.CertFingerprint(cert))
+	if certificateRequestInfo(d, r) == nil {
+		t.Errorf("Forwarded requests should keep the client restrictions")
+	}
+
+	// Other clients can't pretend to forward requests
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	r.Header.Set(clusterForwardedCertificateHeader, "unknown")
+	if certificateRequestInfo(d, r) == nil {
+		t.Errorf("Clients can't drop their restrictions through the forwarding headers")
+	}
+}
+
+func Test_certificateAllowedOperation(t *testing.T) {
+	cert := &dbCertInfo{Restricted: true, Containers: []string{"build1", "ci/build3"}}
+
+	tests := []struct {
+		containers []string
+		allowed    bool
+	}{
+		{[]string{"/1.0/containers/build1"}, true},
+		{[]string{"/1.0/containers/ci_build1"}, false},
+		{[]string{"/1.0/containers/build1?project=ci"}, false},
+		{[]string{"/1.0/containers/ci_build3"}, true},
+		{[]string{"/1.0/containers/build3?project=ci"}, true},
+		{[]string{"/1.0/containers/ci_build3/snap0"}, true},
+		{[]string{"/1.0/containers/build3"}, false},
+		{[]string{"/1.0/containers/build1/snap0"}, true},
+		{[]string{"/1.0/containers/build2", "/1.0/containers/build1"}, true},
+		{[]string{"/1.0/containers/build2"}, false},
+		{[]string{"/1.0/containers/ci_build2"}, false},
+		{nil, false},
+	}
+
+	for _, test := range tests {
+		op := &api.Operation{Resources: map[string][]string{"containers": test.containers}}
+		if certificateAllowedOperation(cert, op) != test.allowed {
+			t.Errorf("Wrong result for %v", test.containers)
+		}
+	}
+
+	// Only the events of allowed operations are sent
+	listener := &eventListener{cert: cert}
+	if eventAllowed(listener, "logging", shared.Jmap{"message": "hello"}) {
+		t.Errorf("Logging events shouldn't be sent to restricted clients")
+	}
+
+	op := &api.Operation{Resources: map[string][]string{"containers": {"/1.0/containers/build1"}}}
+	if !eventAllowed(listener, "operation", op) {
+		t.Errorf("Events of allowed operations should be sent")
+	}
+
+	op = &api.Operation{Resources: map[string][]string{"containers": {"/1.0/containers/build2"}}}
+	if eventAllowed(listener, "operation", op) {
+		t.Errorf("Events of other operations shouldn't be sent")
+	}
+
+	if !eventAllowed(&eventListener{}, "logging", shared.Jmap{}) {
+		t.Errorf("Unrestricted clients should get all events")
+	}
+}
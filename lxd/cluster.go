@@ -0,0 +1,1568 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+// clusterForwardedHeader marks requests sent by another cluster member.
+// Those are always handled locally and never forwarded or replicated again.
+const clusterForwardedHeader = "X-LXD-Forwarded"
+
+// clusterForwardedCertificateHeader carries the fingerprint of the original
+// client certificate of forwarded requests, so its restrictions still apply.
+const clusterForwardedCertificateHeader = "X-LXD-Forwarded-Certificate"
+
+// Endpoints whose changes get replicated to all cluster members.
+var clusterReplicatedEndpoints = []string{
+	"certificates/{fingerprint}",
+	"images/{fingerprint}",
+	"images/aliases",
+	"images/aliases/{name:.*}",
+	"network-acls",
+	"network-acls/{name}",
+	"networks",
+	"networks/{name}",
+	"networks/{name}/dns-records",
+	"networks/{name}/dns-records/{record}",
+	"networks/{name}/forwards",
+	"networks/{name}/forwards/{address}",
+	"networks/{name}/reservations",
+	"networks/{name}/reservations/{hwaddr}",
+	"profiles",
+	"profiles/{name}",
+	"projects",
+	"projects/{name}",
+	"storage-pools",
+	"storage-pools/{name}",
+}
+
+// Storage pool keys which only make sense on the node they were set on.
+var clusterNodeSpecificPoolKeys = []string{
+	"lvm.vg_name",
+	"size",
+	"source",
+	"zfs.pool_name",
+}
+
+func clusterGet(d *Daemon, r *http.Request) Response {
+	local, _, err := clusterMembers(d)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	cluster := api.Cluster{}
+	if local != nil {
+		cluster.ServerName = local.Name
+		cluster.Enabled = true
+	}
+
+	return SyncResponseETag(true, cluster, cluster)
+}
+
+func clusterPut(d *Daemon, r *http.Request) Response {
+	req := api.ClusterPut{}
+	if err := shared.ReadToJSON(r.Body, &req); err != nil {
+		return BadRequest(err)
+	}
+
+	local, _, err := clusterMembers(d)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if !req.Enabled {
+		if local != nil {
+			return BadRequest(fmt.Errorf("Clustered servers must be removed through the cluster members API"))
+		}
+
+		return EmptySyncResponse
+	}
+
+	if local != nil {
+		return BadRequest(fmt.Errorf("This server is already clustered"))
+	}
+
+	if req.ServerName == "" {
+		req.ServerName, err = os.Hostname()
+		if err != nil {
+			return InternalError(err)
+		}
+	}
+
+	if strings.Contains(req.ServerName, "/") {
+		return BadRequest(fmt.Errorf("Server names may not contain slashes"))
+	}
+
+	address, err := clusterLocalAddress()
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Bootstrap a new cluster
+	if req.ClusterAddress == "" {
+		err = dbNodeAdd(d.db, req.ServerName, address, clusterLocalCertificate(d))
+		if err != nil {
+			return SmartError(err)
+		}
+
+		readSavedClusterCertList(d)
+
+		return EmptySyncResponse
+	}
+
+	// Join an existing one
+	err = clusterJoin(d, req, address)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return EmptySyncResponse
+}
+
+var clusterCmd = Command{name: "cluster", get: clusterGet, put: clusterPut}
+
+func clusterMembersGet(d *Daemon, r *http.Request) Response {
+	recursion := d.isRecursionRequest(r)
+
+	local, peers, err := clusterMembers(d)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resultString := []string{}
+	resultList := []api.ClusterMember{}
+	if local != nil {
+		for _, node := range append([]dbNode{*local}, peers...) {
+			if !recursion {
+				url := fmt.Sprintf("/%s/cluster/members/%s", version.APIVersion, node.Name)
+				resultString = append(resultString, url)
+			} else {
+				resultList = append(resultList, clusterMemberRender(d, node, node.Name == local.Name))
+			}
+		}
+	}
+
+	if !recursion {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultList)
+}
+
+func clusterMembersPost(d *Daemon, r *http.Request) Response {
+	req := api.ClusterMembersPost{}
+	if err := shared.ReadToJSON(r.Body, &req); err != nil {
+		return BadRequest(err)
+	}
+
+	// Access check
+	if !d.isTrustedClient(r) && d.PasswordCheck(req.Password) != nil {
+		return Forbidden
+	}
+
+	local, _, err := clusterMembers(d)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if local == nil {
+		return BadRequest(fmt.Errorf("This server isn't clustered"))
+	}
+
+	if req.ServerName == "" || req.ServerAddress == "" {
+		return BadRequest(fmt.Errorf("Both a server name and address are required"))
+	}
+
+	// Members joining through us present their certificate over TLS,
+	// other members pass it along when notifying us.
+	certificate := req.ServerCertificate
+	if certificate == "" {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) < 1 {
+			return BadRequest(fmt.Errorf("No client certificate provided"))
+		}
+
+		cert := r.TLS.PeerCertificates[len(r.TLS.PeerCertificates)-1]
+		certificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+
+	err = dbNodeAdd(d.db, req.ServerName, req.ServerAddress, certificate)
+	if err == DbErrAlreadyDefined && clusterIsForwarded(d, r) {
+		// Replicated changes may be applied more than once
+		node, err := dbNodeGet(d.db, req.ServerName)
+		if err == nil && node.Address == req.ServerAddress {
+			return EmptySyncResponse
+		}
+	}
+	if err != nil {
+		return SmartError(err)
+	}
+
+	readSavedClusterCertList(d)
+
+	if !clusterIsForwarded(d, r) {
+		clusterNotify(d, "POST", "/1.0/cluster/members", api.ClusterMembersPost{
+			ServerName:        req.ServerName,
+			ServerAddress:     req.ServerAddress,
+			ServerCertificate: certificate,
+		})
+	}
+
+	// The joining server needs the full member list
+	nodes, err := dbNodesGet(d.db)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return SyncResponseLocation(true, nodes, fmt.Sprintf("/%s/cluster/members/%s", version.APIVersion, req.ServerName))
+}
+
+var clusterMembersCmd = Command{name: "cluster/members", untrustedPost: true, get: clusterMembersGet, post: clusterMembersPost}
+
+func clusterMemberGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	local, _, err := clusterMembers(d)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	node, err := dbNodeGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return SyncResponse(true, clusterMemberRender(d, *node, local != nil && node.Name == local.Name))
+}
+
+func clusterMemberDelete(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	local, _, err := clusterMembers(d)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if local == nil {
+		return NotFound
+	}
+
+	node, err := dbNodeGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Another member already validated the removal, just apply it
+	if clusterIsForwarded(d, r) {
+		if node.Name == local.Name {
+			err = dbNodesClear(d.db)
+		} else {
+			err = dbNodeRemove(d.db, node.Name)
+		}
+		if err != nil {
+			return SmartError(err)
+		}
+
+		readSavedClusterCertList(d)
+
+		return EmptySyncResponse
+	}
+
+	// Containers can't be moved automatically, so refuse to orphan them
+	containers := []string{}
+	if node.Name == local.Name {
+		containers, err = dbContainersList(d.db, cTypeRegular)
+		if err != nil {
+			return SmartError(err)
+		}
+	} else {
+		resp, err := clusterQuery(d, *node, "GET", "/1.0/containers", nil)
+		if err == nil {
+			err = resp.MetadataAsStruct(&containers)
+			if err != nil {
+				return InternalError(err)
+			}
+		} else {
+			shared.LogWarn("Unable to reach the cluster member, removing it anyway", log.Ctx{"name": node.Name, "err": err})
+		}
+	}
+
+	if len(containers) > 0 {
+		return BadRequest(fmt.Errorf("The cluster member \"%s\" still has containers", node.Name))
+	}
+
+	path := fmt.Sprintf("/1.0/cluster/members/%s", node.Name)
+	if node.Name == local.Name {
+		// Leaving drops the journal, so send the removal right away
+		clusterNotify(d, "DELETE", path, nil)
+		clusterJournalPush(d)
+
+		err = dbNodesClear(d.db)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		readSavedClusterCertList(d)
+
+		return EmptySyncResponse
+	}
+
+	err = dbNodeRemove(d.db, node.Name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	readSavedClusterCertList(d)
+
+	clusterNotify(d, "DELETE", path, nil)
+
+	// The removed member doesn't get the journal anymore, tell it directly
+	_, err = clusterQuery(d, *node, "DELETE", path, nil)
+	if err != nil {
+		shared.LogWarn("Failed to notify the removed cluster member", log.Ctx{"name": node.Name, "err": err})
+	}
+
+	return EmptySyncResponse
+}
+
+var clusterMemberCmd = Command{name: "cluster/members/{name}", get: clusterMemberGet, delete: clusterMemberDelete}
+
+func clusterMemberContainersPut(d *Daemon, r *http.Request) Response {
+	// Only members publish where their containers are
+	if !clusterIsForwarded(d, r) {
+		return Forbidden
+	}
+
+	containers := []string{}
+	if err := shared.ReadToJSON(r.Body, &containers); err != nil {
+		return BadRequest(err)
+	}
+
+	err := dbNodeContainersSet(d.db, mux.Vars(r)["name"], containers)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return EmptySyncResponse
+}
+
+var clusterMemberContainersCmd = Command{name: "cluster/members/{name}/containers", put: clusterMemberContainersPut}
+
+// clusterContainersPublish tells the other cluster members which containers
+// are hosted here, letting them forward requests without looking around.
+func clusterContainersPublish(d *Daemon) {
+	local, peers, err := clusterMembers(d)
+	if err != nil || local == nil || len(peers) == 0 {
+		return
+	}
+
+	containers, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		shared.LogError("Failed to list containers", log.Ctx{"err": err})
+		return
+	}
+
+	clusterNotify(d, "PUT", fmt.Sprintf("/1.0/cluster/members/%s/containers", local.Name), containers)
+}
+
+func clusterMemberRender(d *Daemon, node dbNode, isLocal bool) api.ClusterMember {
+	member := api.ClusterMember{
+		ServerName: node.Name,
+		URL:        fmt.Sprintf("https://%s", node.Address),
+		Status:     "Online",
+	}
+
+	if !isLocal {
+		_, err := clusterQuery(d, node, "GET", "/1.0", nil)
+		if err != nil {
+			member.Status = "Offline"
+			member.Message = err.Error()
+		} else if msg := clusterJournalError(node.Name); msg != "" {
+			member.Status = "Out of sync"
+			member.Message = msg
+		}
+	}
+
+	return member
+}
+
+// clusterMembers returns the local cluster member and its peers. The local
+// member is nil if the server isn't clustered.
+func clusterMembers(d *Daemon) (*dbNode, []dbNode, error) {
+	nodes, err := dbNodesGet(d.db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	address := daemonConfig["core.https_address"].Get()
+	if address != "" {
+		address = clusterNormalizeAddress(address)
+	}
+
+	var local *dbNode
+	peers := []dbNode{}
+	for i, node := range nodes {
+		if address != "" && node.Address == address {
+			local = &nodes[i]
+			continue
+		}
+
+		peers = append(peers, node)
+	}
+
+	if local == nil {
+		return nil, nil, nil
+	}
+
+	return local, peers, nil
+}
+
+// clusterLocalName returns the name of the local cluster member, if any.
+func clusterLocalName(d *Daemon) string {
+	local, _, err := clusterMembers(d)
+	if err != nil || local == nil {
+		return ""
+	}
+
+	return local.Name
+}
+
+func clusterLocalCertificate(d *Daemon) string {
+	if d.tlsConfig == nil || len(d.tlsConfig.Certificates) == 0 {
+		return ""
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: d.tlsConfig.Certificates[0].Certificate[0]}))
+}
+
+// clusterLocalAddress returns the address other members should use to reach
+// this server.
+func clusterLocalAddress() (string, error) {
+	address := daemonConfig["core.https_address"].Get()
+	if address == "" {
+		return "", fmt.Errorf("core.https_address must be set before clustering")
+	}
+
+	address = clusterNormalizeAddress(address)
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+
+	if shared.StringInSlice(host, []string{"", "0.0.0.0", "::"}) {
+		return "", fmt.Errorf("core.https_address must be a specific address when clustering")
+	}
+
+	return address, nil
+}
+
+// clusterNormalizeAddress appends the default port to addresses lacking one.
+func clusterNormalizeAddress(address string) string {
+	_, _, err := net.SplitHostPort(address)
+	if err == nil {
+		return address
+	}
+
+	ip := net.ParseIP(address)
+	if ip != nil && ip.To4() == nil {
+		return fmt.Sprintf("[%s]:%s", address, shared.DefaultPort)
+	}
+
+	return fmt.Sprintf("%s:%s", address, shared.DefaultPort)
+}
+
+// clusterReplayKey marks the requests replayed by clusterReplay.
+type clusterReplayKey struct{}
+
+// clusterIsForwarded checks whether the request was sent by another cluster
+// member or replayed locally on behalf of one. The header alone is never
+// trusted, the client must have authenticated as a cluster member.
+func clusterIsForwarded(d *Daemon, r *http.Request) bool {
+	if r.Header.Get(clusterForwardedHeader) == "" {
+		return false
+	}
+
+	if r.Context().Value(clusterReplayKey{}) != nil {
+		return true
+	}
+
+	return clusterIsMemberRequest(d, r)
+}
+
+// clusterIsMemberRequest checks whether the client authenticated with the
+// certificate of a cluster member.
+func clusterIsMemberRequest(d *Daemon, r *http.Request) bool {
+	if r.TLS == nil {
+		return false
+	}
+
+	for _, cert := range r.TLS.PeerCertificates {
+		for i := range d.clusterCerts {
+			if bytes.Equal(cert.Raw, d.clusterCerts[i].Raw) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// clusterRequestMember returns the cluster member which sent the request, if any.
+func clusterRequestMember(d *Daemon, r *http.Request) *dbNode {
+	if r.TLS == nil {
+		return nil
+	}
+
+	_, peers, err := clusterMembers(d)
+	if err != nil {
+		return nil
+	}
+
+	for _, cert := range r.TLS.PeerCertificates {
+		fingerprint := shared.CertFingerprint(cert)
+		for i, node := range peers {
+			nodeFingerprint, err := shared.CertFingerprintStr(node.Certificate)
+			if err == nil && nodeFingerprint == fingerprint {
+				return &peers[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// clusterIsMemberURL checks whether the URL points to one of the cluster members.
+func clusterIsMemberURL(d *Daemon, url string) bool {
+	_, peers, err := clusterMembers(d)
+	if err != nil {
+		return false
+	}
+
+	for _, node := range peers {
+		if strings.TrimSuffix(url, "/") == fmt.Sprintf("https://%s", node.Address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readSavedClusterCertList(d *Daemon) {
+	d.clusterCerts = []x509.Certificate{}
+
+	_, peers, err := clusterMembers(d)
+	if err != nil {
+		shared.LogInfof("Error reading cluster members from database: %s", err)
+		return
+	}
+
+	for _, node := range peers {
+		certBlock, _ := pem.Decode([]byte(node.Certificate))
+		if certBlock == nil {
+			shared.LogInfof("Error decoding certificate for cluster member %s", node.Name)
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(certBlock.Bytes)
+		if err != nil {
+			shared.LogInfof("Error reading certificate for cluster member %s: %s", node.Name, err)
+			continue
+		}
+		d.clusterCerts = append(d.clusterCerts, *cert)
+	}
+}
+
+// clusterTLSConfig returns a TLS configuration authenticating with the
+// server certificate and only accepting the given peer certificate.
+func clusterTLSConfig(certificate string) (*tls.Config, error) {
+	certBlock, _ := pem.Decode([]byte(certificate))
+	if certBlock == nil {
+		return nil, fmt.Errorf("Invalid certificate")
+	}
+
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	certf, keyf, err := readMyCert()
+	if err != nil {
+		return nil, err
+	}
+
+	return shared.GetTLSConfig(certf, keyf, "", cert)
+}
+
+func clusterTransport(node dbNode) (*http.Transport, error) {
+	tlsConfig, err := clusterTLSConfig(node.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Transport{
+		TLSClientConfig:   tlsConfig,
+		Dial:              shared.RFC3493Dialer,
+		DisableKeepAlives: true,
+	}, nil
+}
+
+// clusterQuery sends a request to another cluster member.
+func clusterQuery(d *Daemon, node dbNode, method string, path string, data interface{}) (*api.Response, error) {
+	tr, err := clusterTransport(node)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if data != nil {
+		buf := bytes.Buffer{}
+		err := json.NewEncoder(&buf).Encode(data)
+		if err != nil {
+			return nil, err
+		}
+
+		body = &buf
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("https://%s%s", node.Address, path), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent)
+	req.Header.Set(clusterForwardedHeader, "1")
+
+	client := &http.Client{Transport: tr}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := api.Response{}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Type == api.ErrorResponse {
+		return nil, fmt.Errorf(response.Error)
+	}
+
+	return &response, nil
+}
+
+// clusterNotify records a request in the journal replayed on all other
+// cluster members. Entries are kept until every member received them, so
+// members which are offline catch up once they're back.
+func clusterNotify(d *Daemon, method string, path string, data interface{}) {
+	body := []byte{}
+	if data != nil {
+		var err error
+		body, err = json.Marshal(data)
+		if err != nil {
+			shared.LogError("Failed to encode cluster change", log.Ctx{"method": method, "url": path, "err": err})
+			return
+		}
+	}
+
+	err := dbClusterJournalAdd(d.db, method, path, string(body))
+	if err != nil {
+		shared.LogError("Failed to record cluster change", log.Ctx{"method": method, "url": path, "err": err})
+		return
+	}
+
+	clusterJournalTrigger(d)
+}
+
+// clusterJournalEntry is a change sent to the other cluster members.
+type clusterJournalEntry struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// Serializes the sending of the journal so entries are sent in order.
+var clusterJournalLock sync.Mutex
+
+// The reason the last journal entries sent to a member didn't go through, by
+// member name. Those members are reported as out of sync.
+var clusterJournalErrors = map[string]string{}
+var clusterJournalErrorsLock sync.Mutex
+
+func clusterJournalError(name string) string {
+	clusterJournalErrorsLock.Lock()
+	defer clusterJournalErrorsLock.Unlock()
+
+	return clusterJournalErrors[name]
+}
+
+func clusterJournalSetError(name string, err error) {
+	clusterJournalErrorsLock.Lock()
+	defer clusterJournalErrorsLock.Unlock()
+
+	if err == nil {
+		delete(clusterJournalErrors, name)
+		return
+	}
+
+	clusterJournalErrors[name] = err.Error()
+}
+
+// clusterJournalTrigger has the pending journal entries sent without waiting
+// for the next periodic run.
+func clusterJournalTrigger(d *Daemon) {
+	select {
+	case d.clusterJournalChan <- true:
+	default:
+	}
+}
+
+// clusterJournalPush sends the pending journal entries to the other cluster
+// members and drops the ones all of them received.
+func clusterJournalPush(d *Daemon) {
+	clusterJournalLock.Lock()
+	defer clusterJournalLock.Unlock()
+
+	_, peers, err := clusterMembers(d)
+	if err != nil {
+		shared.LogError("Failed to list cluster members", log.Ctx{"err": err})
+		return
+	}
+
+	prune, err := dbClusterJournalHead(d.db)
+	if err != nil {
+		shared.LogError("Failed to read the cluster journal", log.Ctx{"err": err})
+		return
+	}
+
+	for _, node := range peers {
+		sent, err := clusterJournalPushMember(d, node)
+		if err != nil {
+			shared.LogWarn("Failed to send changes to cluster member", log.Ctx{"name": node.Name, "err": err})
+		}
+		clusterJournalSetError(node.Name, err)
+
+		if sent < prune {
+			prune = sent
+		}
+	}
+
+	err = dbClusterJournalPrune(d.db, prune)
+	if err != nil {
+		shared.LogError("Failed to prune the cluster journal", log.Ctx{"err": err})
+	}
+}
+
+// clusterJournalPushMember sends the pending journal entries to a cluster
+// member, returning the ID of the last entry it received.
+func clusterJournalPushMember(d *Daemon, node dbNode) (int, error) {
+	sent, _, err := dbNodeJournalGet(d.db, node.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		entries, err := dbClusterJournalGet(d.db, sent, 100)
+		if err != nil {
+			return sent, err
+		}
+
+		if len(entries) == 0 {
+			return sent, nil
+		}
+
+		_, err = clusterQuery(d, node, "POST", "/1.0/cluster/journal", entries)
+		if err != nil {
+			return sent, err
+		}
+
+		sent = entries[len(entries)-1].ID
+		err = dbNodeJournalSent(d.db, node.Name, sent)
+		if err != nil {
+			return sent, err
+		}
+	}
+}
+
+// clusterJournalRejoin asks the other cluster members for the changes made
+// while this server was offline.
+func clusterJournalRejoin(d *Daemon) {
+	_, peers, err := clusterMembers(d)
+	if err != nil {
+		shared.LogError("Failed to list cluster members", log.Ctx{"err": err})
+		return
+	}
+
+	for _, node := range peers {
+		_, err := clusterQuery(d, node, "POST", "/1.0/cluster/journal", []clusterJournalEntry{})
+		if err != nil {
+			shared.LogWarn("Failed to reach cluster member", log.Ctx{"name": node.Name, "err": err})
+		}
+	}
+}
+
+func clusterJournalPost(d *Daemon, r *http.Request) Response {
+	node := clusterRequestMember(d, r)
+	if node == nil {
+		return Forbidden
+	}
+
+	entries := []clusterJournalEntry{}
+	if err := shared.ReadToJSON(r.Body, &entries); err != nil {
+		return BadRequest(err)
+	}
+
+	// Members coming back online send an empty journal to get the
+	// changes they missed
+	if len(entries) == 0 {
+		clusterJournalTrigger(d)
+		return EmptySyncResponse
+	}
+
+	_, received, err := dbNodeJournalGet(d.db, node.Name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	for _, entry := range entries {
+		// Entries are sent again when our reply got lost
+		if entry.ID <= received {
+			continue
+		}
+
+		var data interface{}
+		if len(entry.Body) > 0 {
+			data = entry.Body
+		}
+
+		// Stop at the first failure, the sender keeps the entry and
+		// the ones after it until they can be applied
+		err := clusterReplay(d, entry.Method, entry.Path, data)
+		if err != nil {
+			shared.LogWarn("Failed to apply change from cluster member", log.Ctx{"name": node.Name, "method": entry.Method, "url": entry.Path, "err": err})
+			return InternalError(fmt.Errorf("Failed to apply change %d (%s %s): %v", entry.ID, entry.Method, entry.Path, err))
+		}
+
+		received = entry.ID
+		err = dbNodeJournalReceived(d.db, node.Name, received)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return EmptySyncResponse
+}
+
+var clusterJournalCmd = Command{name: "cluster/journal", post: clusterJournalPost}
+
+// clusterOperation is an operation running on another cluster member.
+type clusterOperation struct {
+	node      string
+	createdAt time.Time
+}
+
+// The operations created through forwarded requests, by ID.
+var clusterOperations = map[string]clusterOperation{}
+var clusterOperationsLock sync.Mutex
+
+// clusterOperationAdd records the cluster member running an operation.
+func clusterOperationAdd(id string, node string) {
+	clusterOperationsLock.Lock()
+	defer clusterOperationsLock.Unlock()
+
+	// Operations are gone a few seconds after completing, keep the
+	// long-running ones around for a day
+	for k, op := range clusterOperations {
+		if time.Since(op.createdAt) > 24*time.Hour {
+			delete(clusterOperations, k)
+		}
+	}
+
+	clusterOperations[id] = clusterOperation{node: node, createdAt: time.Now()}
+}
+
+// clusterOperationOwner returns the cluster member running an operation.
+// Operations which weren't created through this server are looked up on the
+// other members once.
+func clusterOperationOwner(d *Daemon, peers []dbNode, id string) *dbNode {
+	clusterOperationsLock.Lock()
+	op, ok := clusterOperations[id]
+	clusterOperationsLock.Unlock()
+
+	if ok {
+		node, err := dbNodeGet(d.db, op.node)
+		if err != nil {
+			return nil
+		}
+
+		return node
+	}
+
+	for i, node := range peers {
+		_, err := clusterQuery(d, node, "GET", fmt.Sprintf("/1.0/operations/%s", id), nil)
+		if err == nil {
+			clusterOperationAdd(id, node.Name)
+			return &peers[i]
+		}
+	}
+
+	return nil
+}
+
+// clusterForwardedResponse returns a response proxying the request to the
+// cluster member owning the targeted container or operation. It returns nil
+// when the request should be handled locally.
+func clusterForwardedResponse(d *Daemon, c Command, r *http.Request) Response {
+	if clusterIsForwarded(d, r) {
+		return nil
+	}
+
+	local, peers, err := clusterMembers(d)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if local == nil || len(peers) == 0 {
+		return nil
+	}
+
+	var owner *dbNode
+	if c.name == "containers" && r.Method == "POST" {
+		target := r.FormValue("target")
+		if target == "" || target == local.Name {
+			return nil
+		}
+
+		owner, err = dbNodeGet(d.db, target)
+		if err != nil {
+			if err == NoSuchObjectError {
+				return BadRequest(fmt.Errorf("No cluster member called \"%s\"", target))
+			}
+
+			return SmartError(err)
+		}
+	} else if strings.HasPrefix(c.name, "containers/{name}") {
+		name := projectPrefix(projectParam(r), mux.Vars(r)["name"])
+		_, err := dbContainerId(d.db, name)
+		if err == nil {
+			return nil
+		}
+
+		owner, err = dbNodeContainerGet(d.db, name)
+		if err != nil && err != NoSuchObjectError {
+			return SmartError(err)
+		}
+	} else if strings.HasPrefix(c.name, "operations/{id}") {
+		id := mux.Vars(r)["id"]
+		_, err := operationGet(id)
+		if err == nil {
+			return nil
+		}
+
+		owner = clusterOperationOwner(d, peers, id)
+	}
+
+	if owner == nil {
+		return nil
+	}
+
+	resp := &forwardedResponse{node: *owner, req: r}
+
+	cert := certificateRequestInfo(d, r)
+	if cert != nil {
+		resp.certificate = cert.Fingerprint
+	}
+
+	return resp
+}
+
+// Forwarded response
+type forwardedResponse struct {
+	node        dbNode
+	req         *http.Request
+	certificate string
+}
+
+func (r *forwardedResponse) Render(w http.ResponseWriter) error {
+	tr, err := clusterTransport(r.node)
+	if err != nil {
+		return err
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "https"
+			req.URL.Host = r.node.Address
+			req.Host = r.node.Address
+			req.Header.Set(clusterForwardedHeader, "1")
+
+			req.Header.Del(clusterForwardedCertificateHeader)
+			if r.certificate != "" {
+				req.Header.Set(clusterForwardedCertificateHeader, r.certificate)
+			}
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			// Remember where the operations created by the member live
+			location := resp.Header.Get("Location")
+			if resp.StatusCode == http.StatusAccepted && strings.HasPrefix(location, fmt.Sprintf("/%s/operations/", version.APIVersion)) {
+				clusterOperationAdd(path.Base(location), r.node.Name)
+			}
+
+			return nil
+		},
+		Transport:     tr,
+		FlushInterval: -1,
+	}
+
+	proxy.ServeHTTP(w, r.req)
+
+	return nil
+}
+
+func (r *forwardedResponse) String() string {
+	return fmt.Sprintf("forwarded to %s", r.node.Name)
+}
+
+// clusterReplicationBody returns the request body to replay on the other
+// cluster members, or nil if the request doesn't need replicating.
+func clusterReplicationBody(d *Daemon, c Command, r *http.Request) ([]byte, error) {
+	if r.Method == "GET" || clusterIsForwarded(d, r) {
+		return nil, nil
+	}
+
+	if !shared.StringInSlice(c.name, clusterReplicatedEndpoints) {
+		return nil, nil
+	}
+
+	local, peers, err := clusterMembers(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if local == nil || len(peers) == 0 {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = shared.BytesReadCloser{Buf: bytes.NewBuffer(body)}
+
+	return body, nil
+}
+
+// clusterReplicate has a request replayed on the other cluster members once
+// it succeeded. Requests running an operation are only replicated once the
+// operation completed successfully.
+func clusterReplicate(d *Daemon, r *http.Request, resp Response, body []byte) {
+	method := r.Method
+	path := r.URL.RequestURI()
+
+	var data interface{}
+	if len(body) > 0 {
+		data = json.RawMessage(body)
+	}
+
+	switch resp := resp.(type) {
+	case *errorResponse:
+		return
+	case *syncResponse:
+		if !resp.success {
+			return
+		}
+	case *operationResponse:
+		go func(op *operation) {
+			op.WaitFinal(-1)
+
+			op.lock.Lock()
+			status := op.status
+			op.lock.Unlock()
+
+			if status == api.Success {
+				clusterNotify(d, method, path, data)
+			}
+		}(resp.op)
+
+		return
+	}
+
+	clusterNotify(d, method, path, data)
+}
+
+// clusterMergeContainers adds the containers of the other cluster members to
+// a local container list.
+func clusterMergeContainers(d *Daemon, project string, result interface{}, recursion bool) (interface{}, error) {
+	_, peers, err := clusterMembers(d)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/1.0/containers?project=%s", project)
+	if recursion {
+		path = fmt.Sprintf("%s&recursion=1", path)
+	}
+
+	for _, node := range peers {
+		resp, err := clusterQuery(d, node, "GET", path, nil)
+		if err != nil {
+			shared.LogWarn("Failed to list containers of cluster member", log.Ctx{"name": node.Name, "err": err})
+			continue
+		}
+
+		switch containers := result.(type) {
+		case []string:
+			peerContainers := []string{}
+			err = resp.MetadataAsStruct(&peerContainers)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(containers, peerContainers...)
+		case []*api.Container:
+			peerContainers := []*api.Container{}
+			err = resp.MetadataAsStruct(&peerContainers)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(containers, peerContainers...)
+		}
+	}
+
+	return result, nil
+}
+
+// clusterJoin adds this server to an existing cluster and pulls the
+// cluster-wide objects from it.
+func clusterJoin(d *Daemon, req api.ClusterPut, address string) error {
+	containers, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		return err
+	}
+
+	if len(containers) > 0 {
+		return fmt.Errorf("Only servers without containers can join a cluster")
+	}
+
+	if req.ClusterCertificate == "" {
+		return fmt.Errorf("The cluster certificate is required to join a cluster")
+	}
+
+	certf, keyf, err := readMyCert()
+	if err != nil {
+		return err
+	}
+
+	cert, err := ioutil.ReadFile(certf)
+	if err != nil {
+		return err
+	}
+
+	key, err := ioutil.ReadFile(keyf)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://%s", clusterNormalizeAddress(req.ClusterAddress))
+	remote, err := lxd.ConnectLXD(url, &lxd.ConnectionArgs{
+		TLSServerCert: req.ClusterCertificate,
+		TLSClientCert: string(cert),
+		TLSClientKey:  string(key),
+		UserAgent:     version.UserAgent,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Have the cluster record us
+	resp, _, err := remote.RawQuery("POST", "/1.0/cluster/members", api.ClusterMembersPost{
+		ServerName:    req.ServerName,
+		ServerAddress: address,
+		Password:      req.ClusterPassword,
+	}, "")
+	if err != nil {
+		return err
+	}
+
+	nodes := []dbNode{}
+	err = resp.MetadataAsStruct(&nodes)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		err = dbNodeAdd(d.db, node.Name, node.Address, node.Certificate)
+		if err != nil {
+			return err
+		}
+	}
+
+	readSavedClusterCertList(d)
+
+	return clusterSync(d, remote, url, req.ClusterCertificate)
+}
+
+// clusterSync copies the projects, network ACLs, networks, storage pools,
+// profiles and images of an existing cluster member.
+func clusterSync(d *Daemon, remote lxd.ContainerServer, url string, certificate string) error {
+	projects, err := remote.GetProjects()
+	if err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		_, _, err := dbProjectGet(d.db, project.Name)
+		if err == nil {
+			err = clusterReplay(d, "PUT", fmt.Sprintf("/1.0/projects/%s", project.Name), project.Writable())
+		} else {
+			err = clusterReplay(d, "POST", "/1.0/projects", api.ProjectsPost{ProjectPut: project.Writable(), Name: project.Name})
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to copy project \"%s\": %s", project.Name, err)
+		}
+	}
+
+	// Networks may refer to ACLs, so those go first
+	acls, err := remote.GetNetworkACLs()
+	if err != nil {
+		return err
+	}
+
+	for _, acl := range acls {
+		_, _, err := dbNetworkACLGet(d.db, acl.Name)
+		if err == nil {
+			err = clusterReplay(d, "PUT", fmt.Sprintf("/1.0/network-acls/%s", acl.Name), acl.Writable())
+		} else {
+			err = clusterReplay(d, "POST", "/1.0/network-acls", api.NetworkACLsPost{NetworkACLPut: acl.Writable(), Name: acl.Name})
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to copy network ACL \"%s\": %s", acl.Name, err)
+		}
+	}
+
+	networks, err := remote.GetNetworks()
+	if err != nil {
+		return err
+	}
+
+	for _, network := range networks {
+		if !network.Managed {
+			continue
+		}
+
+		_, _, err := dbNetworkGet(d.db, network.Name)
+		if err == nil {
+			err = clusterReplay(d, "PUT", fmt.Sprintf("/1.0/networks/%s", network.Name), network.Writable())
+		} else {
+			req := api.NetworksPost{Name: network.Name, Type: network.Type}
+			req.Config = network.Config
+			err = clusterReplay(d, "POST", "/1.0/networks", req)
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to copy network \"%s\": %s", network.Name, err)
+		}
+
+		err = clusterSyncNetwork(d, remote, network.Name)
+		if err != nil {
+			return fmt.Errorf("Failed to copy network \"%s\": %s", network.Name, err)
+		}
+	}
+
+	pools, err := remote.GetStoragePools()
+	if err != nil {
+		return err
+	}
+
+	for _, pool := range pools {
+		_, err := dbStoragePoolGetID(d.db, pool.Name)
+		if err == nil {
+			continue
+		}
+
+		req := api.StoragePoolsPost{Name: pool.Name, Driver: pool.Driver}
+		req.Config = map[string]string{}
+		for k, v := range pool.Config {
+			if shared.StringInSlice(k, clusterNodeSpecificPoolKeys) || strings.HasPrefix(k, "volatile.") {
+				continue
+			}
+
+			req.Config[k] = v
+		}
+
+		err = clusterReplay(d, "POST", "/1.0/storage-pools", req)
+		if err != nil {
+			return fmt.Errorf("Failed to copy storage pool \"%s\": %s", pool.Name, err)
+		}
+	}
+
+	for _, project := range projects {
+		err = clusterSyncProject(d, remote.UseProject(project.Name), project.Name, url, certificate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clusterSyncNetwork copies the DHCP reservations, DNS records and forwards
+// of a network.
+func clusterSyncNetwork(d *Daemon, remote lxd.ContainerServer, name string) error {
+	networkID, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return err
+	}
+
+	reservations, err := remote.GetNetworkReservations(name)
+	if err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		_, err := dbNetworkReservationGet(d.db, networkID, reservation.Hwaddr)
+		if err == nil {
+			err = clusterReplay(d, "PUT", fmt.Sprintf("/1.0/networks/%s/reservations/%s", name, reservation.Hwaddr), reservation.Writable())
+		} else {
+			err = clusterReplay(d, "POST", fmt.Sprintf("/1.0/networks/%s/reservations", name), api.NetworkReservationsPost{NetworkReservationPut: reservation.Writable(), Hwaddr: reservation.Hwaddr})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	records, err := remote.GetNetworkDNSRecords(name)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		_, err := dbNetworkDNSRecordGet(d.db, networkID, record.Name)
+		if err == nil {
+			err = clusterReplay(d, "PUT", fmt.Sprintf("/1.0/networks/%s/dns-records/%s", name, record.Name), record.Writable())
+		} else {
+			err = clusterReplay(d, "POST", fmt.Sprintf("/1.0/networks/%s/dns-records", name), api.NetworkDNSRecordsPost{NetworkDNSRecordPut: record.Writable(), Name: record.Name})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	forwards, err := remote.GetNetworkForwards(name)
+	if err != nil {
+		return err
+	}
+
+	for _, forward := range forwards {
+		_, _, err := dbNetworkForwardGet(d.db, networkID, forward.ListenAddress)
+		if err == nil {
+			err = clusterReplay(d, "PUT", fmt.Sprintf("/1.0/networks/%s/forwards/%s", name, forward.ListenAddress), forward.Writable())
+		} else {
+			err = clusterReplay(d, "POST", fmt.Sprintf("/1.0/networks/%s/forwards", name), api.NetworkForwardsPost{NetworkForwardPut: forward.Writable(), ListenAddress: forward.ListenAddress})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clusterSyncProject copies the profiles and images of a project, unless it
+// uses the ones of the default project.
+func clusterSyncProject(d *Daemon, remote lxd.ContainerServer, project string, url string, certificate string) error {
+	query := projectQuery(project)
+
+	profileProject, err := dbProjectEffective(d.db, project, "profiles")
+	if err != nil {
+		return err
+	}
+
+	if profileProject == project {
+		profiles, err := remote.GetProfiles()
+		if err != nil {
+			return err
+		}
+
+		for _, profile := range profiles {
+			_, _, err := dbProfileGet(d.db, project, profile.Name)
+			if err == nil {
+				err = clusterReplay(d, "PUT", fmt.Sprintf("/1.0/profiles/%s%s", profile.Name, query), profile.Writable())
+			} else {
+				err = clusterReplay(d, "POST", fmt.Sprintf("/1.0/profiles%s", query), api.ProfilesPost{ProfilePut: profile.Writable(), Name: profile.Name})
+			}
+			if err != nil {
+				return fmt.Errorf("Failed to copy profile \"%s\" of project \"%s\": %s", profile.Name, project, err)
+			}
+		}
+	}
+
+	imageProject, err := dbProjectEffective(d.db, project, "images")
+	if err != nil {
+		return err
+	}
+
+	if imageProject == project {
+		images, err := remote.GetImages()
+		if err != nil {
+			return err
+		}
+
+		for _, image := range images {
+			_, _, err := dbImageGet(d.db, project, image.Fingerprint, false, true)
+			if err == nil {
+				continue
+			}
+
+			// The download itself happens in the background
+			err = clusterReplay(d, "POST", fmt.Sprintf("/1.0/images%s", query), clusterImagePull(url, certificate, image))
+			if err != nil {
+				return fmt.Errorf("Failed to copy image \"%s\" of project \"%s\": %s", image.Fingerprint, project, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// clusterImagePull returns the request needed for another member to pull an
+// image from the given server.
+func clusterImagePull(url string, certificate string, image api.Image) api.ImagesPost {
+	req := api.ImagesPost{
+		Source: &api.ImagesPostSource{
+			Type:        "image",
+			Mode:        "pull",
+			Fingerprint: image.Fingerprint,
+		},
+		Aliases: image.Aliases,
+	}
+	req.Public = image.Public
+	req.Source.Server = url
+	req.Source.Protocol = "lxd"
+	req.Source.Certificate = certificate
+
+	return req
+}
+
+// clusterReplicateImage has all other cluster members pull a newly added image.
+func clusterReplicateImage(d *Daemon, project string, image api.Image) {
+	local, _, err := clusterMembers(d)
+	if err != nil || local == nil {
+		return
+	}
+
+	url := fmt.Sprintf("https://%s", local.Address)
+	clusterNotify(d, "POST", fmt.Sprintf("/1.0/images%s", projectQuery(project)), clusterImagePull(url, local.Certificate, image))
+}
+
+// clusterImageProject returns the project cluster members pull an image from.
+// Their pulls don't carry a project, so images outside of the given project
+// are looked up in any project holding them.
+func clusterImageProject(d *Daemon, project string, fingerprint string) string {
+	_, _, err := dbImageGet(d.db, project, fingerprint, false, false)
+	if err == nil {
+		return project
+	}
+
+	projects, err := dbImageGetProjects(d.db, fingerprint)
+	if err != nil || len(projects) == 0 {
+		return project
+	}
+
+	return projects[0]
+}
+
+// clusterReplay runs a request against the local API, bypassing cluster
+// forwarding and replication.
+func clusterReplay(d *Daemon, method string, path string, data interface{}) error {
+	buf := bytes.Buffer{}
+	if data != nil {
+		err := json.NewEncoder(&buf).Encode(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, path, &buf)
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(context.WithValue(req.Context(), clusterReplayKey{}, true))
+	req.RemoteAddr = "@"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(clusterForwardedHeader, "1")
+
+	rec := httptest.NewRecorder()
+	d.mux.ServeHTTP(rec, req)
+
+	resp := api.Response{}
+	err = json.NewDecoder(rec.Body).Decode(&resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.Type == api.ErrorResponse {
+		return fmt.Errorf(resp.Error)
+	}
+
+	return nil
+}
+
+// clusterGetCertificate retrieves the certificate of a cluster member so it
+// can be verified by the user before joining.
+func clusterGetCertificate(address string) (string, error) {
+	tlsConfig, err := shared.GetTLSConfig("", "", "", nil)
+	if err != nil {
+		return "", err
+	}
+
+	tlsConfig.InsecureSkipVerify = true
+	tr := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		Dial:            shared.RFC3493Dialer,
+	}
+
+	client := &http.Client{Transport: tr}
+	resp, err := client.Get(fmt.Sprintf("https://%s", clusterNormalizeAddress(address)))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return "", fmt.Errorf("Unable to read remote TLS certificate")
+	}
+
+	cert := resp.TLS.PeerCertificates[0]
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})), nil
+}
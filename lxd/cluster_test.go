@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_clusterIsForwarded(t *testing.T) {
+	member := x509.Certificate{Raw: []byte("member")}
+	client := x509.Certificate{Raw: []byte("client")}
+
+	d := &Daemon{}
+	d.clusterCerts = []x509.Certificate{member}
+
+	tests := []struct {
+		cert      *x509.Certificate
+		header    bool
+		forwarded bool
+	}{
+		{&member, true, true},
+		{&member, false, false},
+		{&client, true, false},
+		{nil, true, false},
+	}
+
+	for i, test := range tests {
+		r := httptest.NewRequest("DELETE", "/1.0/cluster/members/node2", nil)
+		if test.cert != nil {
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{test.cert}}
+		}
+
+		if test.header {
+			r.Header.Set(clusterForwardedHeader, "1")
+		}
+
+		if clusterIsForwarded(d, r) != test.forwarded {
+			t.Errorf("Wrong result for test %d", i)
+		}
+	}
+
+	// Local replays are trusted
+	r := httptest.NewRequest("POST", "/1.0/profiles", nil)
+	r = r.WithContext(context.WithValue(r.Context(), clusterReplayKey{}, true))
+	r.Header.Set(clusterForwardedHeader, "1")
+	if !clusterIsForwarded(d, r) {
+		t.Errorf("Local replays should be trusted")
+	}
+}
@@ -111,12 +111,20 @@ func containerValidDeviceConfigKey(t, k string) bool {
 			return true
 		case "vlan":
 			return true
+		case "vlan.tagged":
+			return true
 		case "ipv4.address":
 			return true
 		case "ipv6.address":
 			return true
+		case "ipv4.gateway":
+			return true
+		case "ipv6.gateway":
+			return true
 		case "security.mac_filtering":
 			return true
+		case "security.acls":
+			return true
 		default:
 			return false
 		}
@@ -181,6 +189,15 @@ func containerValidDeviceConfigKey(t, k string) bool {
 		default:
 			return false
 		}
+	case "proxy":
+		switch k {
+		case "listen":
+			return true
+		case "connect":
+			return true
+		default:
+			return false
+		}
 	case "none":
 		return false
 	default:
@@ -272,7 +289,7 @@ func containerValidDevices(d *Daemon, devices types.Devices, profile bool, expan
 			return fmt.Errorf("Missing device type for device '%s'", name)
 		}
 
-		if !shared.StringInSlice(m["type"], []string{"none", "nic", "disk", "unix-char", "unix-block", "usb", "gpu"}) {
+		if !shared.StringInSlice(m["type"], []string{"none", "nic", "disk", "unix-char", "unix-block", "usb", "gpu", "proxy"}) {
 			return fmt.Errorf("Invalid device type for device '%s'", name)
 		}
 
@@ -287,13 +304,60 @@ func containerValidDevices(d *Daemon, devices types.Devices, profile bool, expan
 				return fmt.Errorf("Missing nic type")
 			}
 
-			if !shared.StringInSlice(m["nictype"], []string{"bridged", "physical", "p2p", "macvlan"}) {
+			if !shared.StringInSlice(m["nictype"], []string{"bridged", "physical", "p2p", "macvlan", "routed", "ipvlan", "sriov"}) {
 				return fmt.Errorf("Bad nic type: %s", m["nictype"])
 			}
 
-			if shared.StringInSlice(m["nictype"], []string{"bridged", "physical", "macvlan"}) && m["parent"] == "" {
+			if shared.StringInSlice(m["nictype"], []string{"bridged", "physical", "macvlan", "ipvlan", "sriov"}) && m["parent"] == "" {
 				return fmt.Errorf("Missing parent for %s type nic.", m["nictype"])
 			}
+
+			if shared.StringInSlice(m["nictype"], []string{"routed", "ipvlan"}) {
+				err := networkRoutedValidate(m)
+				if err != nil {
+					return err
+				}
+			} else if m["ipv4.gateway"] != "" || m["ipv6.gateway"] != "" {
+				return fmt.Errorf("Gateways can only be set on routed and ipvlan nics")
+			}
+
+			if m["vlan"] != "" {
+				if !shared.StringInSlice(m["nictype"], []string{"bridged", "physical", "macvlan", "sriov"}) {
+					return fmt.Errorf("VLANs can only be set on bridged, physical, macvlan and sriov nics")
+				}
+
+				err := networkValidVLAN(m["vlan"])
+				if err != nil {
+					return err
+				}
+			}
+
+			if m["vlan.tagged"] != "" {
+				if m["nictype"] != "bridged" {
+					return fmt.Errorf("Tagged VLANs can only be set on bridged nics")
+				}
+
+				err := networkValidVLANList(m["vlan.tagged"])
+				if err != nil {
+					return err
+				}
+			}
+
+			if m["security.acls"] != "" {
+				if m["nictype"] != "bridged" {
+					return fmt.Errorf("Network ACLs can only be set on bridged nics")
+				}
+
+				err := networkACLValidNames(m["security.acls"])
+				if err != nil {
+					return err
+				}
+
+				err = networkACLsCheck(d, m["security.acls"])
+				if err != nil {
+					return err
+				}
+			}
 		} else if m["type"] == "disk" {
 			if !expanded && !shared.StringInSlice(m["path"], diskDevicePaths) {
 				diskDevicePaths = append(diskDevicePaths, m["path"])
@@ -366,6 +430,24 @@ func containerValidDevices(d *Daemon, devices types.Devices, profile bool, expan
 		} else if m["type"] == "gpu" {
 			// Probably no checks needed, since we allow users to
 			// pass in all GPUs.
+		} else if m["type"] == "proxy" {
+			if m["listen"] == "" || m["connect"] == "" {
+				return fmt.Errorf("Proxy device entry is missing the required \"listen\" or \"connect\" property.")
+			}
+
+			listenProto, _, err := proxyParseAddr(m["listen"])
+			if err != nil {
+				return err
+			}
+
+			connectProto, _, err := proxyParseAddr(m["connect"])
+			if err != nil {
+				return err
+			}
+
+			if (listenProto == "udp") != (connectProto == "udp") {
+				return fmt.Errorf("Proxy device can't forward between udp and %s", connectProto)
+			}
 		} else if m["type"] == "none" {
 			continue
 		} else {
@@ -394,6 +476,7 @@ type containerArgs struct {
 	Config       map[string]string
 	CreationDate time.Time
 	LastUsedDate time.Time
+	ExpiryDate   time.Time
 	Ctype        containerType
 	Devices      types.Devices
 	Ephemeral    bool
@@ -469,9 +552,11 @@ type container interface {
 	// Properties
 	Id() int
 	Name() string
+	Project() string
 	Architecture() int
 	CreationDate() time.Time
 	LastUsedDate() time.Time
+	ExpiryDate() time.Time
 	ExpandedConfig() map[string]string
 	ExpandedDevices() types.Devices
 	LocalConfig() map[string]string
@@ -542,8 +627,14 @@ func containerCreateEmptySnapshot(d *Daemon, args containerArgs) (container, err
 }
 
 func containerCreateFromImage(d *Daemon, args containerArgs, hash string) (container, error) {
+	project, _ := projectSplitName(args.Name)
+	imageProject, err := dbProjectEffective(d.db, project, "images")
+	if err != nil {
+		return nil, err
+	}
+
 	// Get the image properties
-	_, img, err := dbImageGet(d.db, hash, false, false)
+	_, img, err := dbImageGet(d.db, imageProject, hash, false, false)
 	if err != nil {
 		return nil, err
 	}
@@ -741,8 +832,9 @@ func containerCreateInternal(d *Daemon, args containerArgs) (container, error) {
 	}
 
 	// Validate container name
+	project, name := projectSplitName(args.Name)
 	if args.Ctype == cTypeRegular {
-		err := containerValidName(args.Name)
+		err := containerValidName(name)
 		if err != nil {
 			return nil, err
 		}
@@ -771,7 +863,12 @@ func containerCreateInternal(d *Daemon, args containerArgs) (container, error) {
 	}
 
 	// Validate profiles
-	profiles, err := dbProfiles(d.db)
+	profileProject, err := dbProjectEffective(d.db, project, "profiles")
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := dbProfiles(d.db, profileProject)
 	if err != nil {
 		return nil, err
 	}
@@ -807,6 +904,7 @@ func containerCreateInternal(d *Daemon, args containerArgs) (container, error) {
 	}
 	args.CreationDate = dbArgs.CreationDate
 	args.LastUsedDate = dbArgs.LastUsedDate
+	args.ExpiryDate = dbArgs.ExpiryDate
 
 	// Setup the container struct and finish creation (storage and idmap)
 	c, err := containerLXCCreate(d, args)
@@ -814,6 +912,11 @@ func containerCreateInternal(d *Daemon, args containerArgs) (container, error) {
 		return nil, err
 	}
 
+	// Let the other cluster members know where the container lives
+	if args.Ctype == cTypeRegular {
+		clusterContainersPublish(d)
+	}
+
 	return c, nil
 }
 
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+func containerBackupsGet(d *Daemon, r *http.Request) Response {
+	recursionStr := r.FormValue("recursion")
+	recursion, err := strconv.Atoi(recursionStr)
+	if err != nil {
+		recursion = 0
+	}
+
+	project := projectParam(r)
+	cname := mux.Vars(r)["name"]
+	c, err := containerLoadByName(d, projectPrefix(project, cname))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	names, err := dbContainerGetBackups(d.db, c.Id())
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []*api.ContainerBackup{}
+
+	for _, name := range names {
+		if recursion == 0 {
+			url := fmt.Sprintf("/%s/containers/%s/backups/%s%s", version.APIVersion, cname, name, projectQuery(project))
+			resultString = append(resultString, url)
+		} else {
+			b, err := backupLoadByName(d, c, name)
+			if err != nil {
+				continue
+			}
+
+			resultMap = append(resultMap, b.Render())
+		}
+	}
+
+	if recursion == 0 {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultMap)
+}
+
+func containerBackupsPost(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if c.IsSnapshot() {
+		return BadRequest(fmt.Errorf("Backups of snapshots aren't supported"))
+	}
+
+	req := api.ContainerBackupsPost{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	if req.Name == "" {
+		// come up with a name
+		names, err := dbContainerGetBackups(d.db, c.Id())
+		if err != nil {
+			return SmartError(err)
+		}
+
+		i := 0
+		for {
+			req.Name = fmt.Sprintf("backup%d", i)
+			if !shared.StringInSlice(req.Name, names) {
+				break
+			}
+			i++
+		}
+	}
+
+	if strings.Contains(req.Name, "/") {
+		return BadRequest(fmt.Errorf("Backup names may not contain slashes"))
+	}
+
+	backup := func(op *operation) error {
+		args := backupArgs{
+			ContainerID:   c.Id(),
+			Name:          req.Name,
+			CreationDate:  time.Now().UTC(),
+			ExpiryDate:    req.ExpiryDate,
+			ContainerOnly: req.ContainerOnly,
+		}
+
+		return backupCreate(d, args, c)
+	}
+
+	resources := map[string][]string{}
+	resources["containers"] = []string{name}
+
+	op, err := operationCreate(operationClassTask, resources, nil, backup, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func containerBackupHandler(d *Daemon, r *http.Request) Response {
+	containerName := mux.Vars(r)["name"]
+	backupName := mux.Vars(r)["backupName"]
+
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), containerName))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	b, err := backupLoadByName(d, c, backupName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	switch r.Method {
+	case "GET":
+		return containerBackupGet(b)
+	case "POST":
+		return containerBackupPost(d, r, b)
+	case "DELETE":
+		return containerBackupDelete(b)
+	default:
+		return NotFound
+	}
+}
+
+func containerBackupGet(b *backup) Response {
+	return SyncResponse(true, b.Render())
+}
+
+func containerBackupPost(d *Daemon, r *http.Request, b *backup) Response {
+	req := api.ContainerBackupPost{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	if req.Name == "" || strings.Contains(req.Name, "/") {
+		return BadRequest(fmt.Errorf("Invalid backup name"))
+	}
+
+	// Check that the name isn't already in use
+	_, err := dbContainerBackupGet(d.db, b.container.Id(), req.Name)
+	if err == nil {
+		return Conflict
+	}
+
+	rename := func(op *operation) error {
+		return b.Rename(req.Name)
+	}
+
+	resources := map[string][]string{}
+	resources["containers"] = []string{b.container.Name()}
+
+	op, err := operationCreate(operationClassTask, resources, nil, rename, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func containerBackupDelete(b *backup) Response {
+	remove := func(op *operation) error {
+		return b.Delete()
+	}
+
+	resources := map[string][]string{}
+	resources["containers"] = []string{b.container.Name()}
+
+	op, err := operationCreate(operationClassTask, resources, nil, remove, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func containerBackupExportGet(d *Daemon, r *http.Request) Response {
+	containerName := mux.Vars(r)["name"]
+	backupName := mux.Vars(r)["backupName"]
+
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), containerName))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	b, err := backupLoadByName(d, c, backupName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	ent := fileResponseEntry{
+		path:     b.Path(),
+		filename: fmt.Sprintf("%s_%s.tar", containerName, backupName),
+	}
+
+	_, ext, err := detectCompression(ent.path)
+	if err == nil {
+		ent.filename = fmt.Sprintf("%s_%s%s", containerName, backupName, ext)
+	}
+
+	return FileResponse(r, []fileResponseEntry{ent}, nil, false)
+}
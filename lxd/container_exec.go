@@ -325,7 +325,7 @@ func (s *execWs) Do(op *operation) error {
 
 func containerExecPost(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
 	if err != nil {
 		return SmartError(err)
 	}
@@ -462,8 +462,8 @@ func containerExecPost(d *Daemon, r *http.Request) Response {
 			// Update metadata with the right URLs
 			metadata["return"] = cmdResult
 			metadata["output"] = shared.Jmap{
-				"1": fmt.Sprintf("/%s/containers/%s/logs/%s", version.APIVersion, c.Name(), filepath.Base(stdout.Name())),
-				"2": fmt.Sprintf("/%s/containers/%s/logs/%s", version.APIVersion, c.Name(), filepath.Base(stderr.Name())),
+				"1": fmt.Sprintf("/%s/containers/%s/logs/%s%s", version.APIVersion, name, filepath.Base(stdout.Name()), projectQuery(c.Project())),
+				"2": fmt.Sprintf("/%s/containers/%s/logs/%s%s", version.APIVersion, name, filepath.Base(stderr.Name()), projectQuery(c.Project())),
 			}
 		} else {
 			_, cmdResult, _, cmdErr = c.Exec(post.Command, env, nil, nil, nil, true)
@@ -15,7 +15,7 @@ import (
 
 func containerFileHandler(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
 	if err != nil {
 		return SmartError(err)
 	}
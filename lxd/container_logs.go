@@ -29,7 +29,7 @@ func containerLogsGet(d *Daemon, r *http.Request) Response {
 
 	result := []string{}
 
-	dents, err := ioutil.ReadDir(shared.LogPath(name))
+	dents, err := ioutil.ReadDir(shared.LogPath(projectPrefix(projectParam(r), name)))
 	if err != nil {
 		return SmartError(err)
 	}
@@ -39,7 +39,7 @@ func containerLogsGet(d *Daemon, r *http.Request) Response {
 			continue
 		}
 
-		result = append(result, fmt.Sprintf("/%s/containers/%s/logs/%s", version.APIVersion, name, f.Name()))
+		result = append(result, fmt.Sprintf("/%s/containers/%s/logs/%s%s", version.APIVersion, name, f.Name(), projectQuery(projectParam(r))))
 	}
 
 	return SyncResponse(true, result)
@@ -74,7 +74,7 @@ func containerLogGet(d *Daemon, r *http.Request) Response {
 	}
 
 	ent := fileResponseEntry{
-		path:     shared.LogPath(name, file),
+		path:     shared.LogPath(projectPrefix(projectParam(r), name), file),
 		filename: file,
 	}
 
@@ -93,7 +93,7 @@ func containerLogDelete(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("log file name %s not valid", file))
 	}
 
-	return SmartError(os.Remove(shared.LogPath(name, file)))
+	return SmartError(os.Remove(shared.LogPath(projectPrefix(projectParam(r), name), file)))
 }
 
 var containerLogCmd = Command{
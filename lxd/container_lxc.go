@@ -192,6 +192,7 @@ func containerLXCCreate(d *Daemon, args containerArgs) (container, error) {
 		stateful:     args.Stateful,
 		creationDate: args.CreationDate,
 		lastUsedDate: args.LastUsedDate,
+		expiryDate:   args.ExpiryDate,
 		profiles:     args.Profiles,
 		localConfig:  args.Config,
 		localDevices: args.Devices,
@@ -352,6 +353,7 @@ func containerLXCLoad(d *Daemon, args containerArgs) (container, error) {
 		cType:        args.Ctype,
 		creationDate: args.CreationDate,
 		lastUsedDate: args.LastUsedDate,
+		expiryDate:   args.ExpiryDate,
 		profiles:     args.Profiles,
 		localConfig:  args.Config,
 		localDevices: args.Devices,
@@ -374,6 +376,7 @@ type containerLXC struct {
 	cType        containerType
 	creationDate time.Time
 	lastUsedDate time.Time
+	expiryDate   time.Time
 	ephemeral    bool
 	id           int
 	name         string
@@ -905,7 +908,8 @@ func (c *containerLXC) initLXC() error {
 	}
 
 	// Setup the hostname
-	err = lxcSetConfigItem(cc, "lxc.utsname", c.Name())
+	_, hostname := projectSplitName(c.Name())
+	err = lxcSetConfigItem(cc, "lxc.utsname", hostname)
 	if err != nil {
 		return err
 	}
@@ -1209,6 +1213,23 @@ func (c *containerLXC) initLXC() error {
 				return err
 			}
 
+			// SR-IOV nics are passed to LXC once a virtual function is
+			// allocated for them on start
+			if m["nictype"] == "sriov" {
+				continue
+			}
+
+			// Routed and ipvlan nics are plugged once the container is
+			// started, only make sure it gets its own network namespace
+			if shared.StringInSlice(m["nictype"], []string{"routed", "ipvlan"}) {
+				err = lxcSetConfigItem(cc, "lxc.network.type", "empty")
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
 			// Interface type specific configuration
 			if shared.StringInSlice(m["nictype"], []string{"bridged", "p2p"}) {
 				err = lxcSetConfigItem(cc, "lxc.network.type", "veth")
@@ -1253,8 +1274,8 @@ func (c *containerLXC) initLXC() error {
 			vethName := ""
 			if m["host_name"] != "" {
 				vethName = m["host_name"]
-			} else if shared.IsTrue(m["security.mac_filtering"]) {
-				// We need a known device name for MAC filtering
+			} else if shared.IsTrue(m["security.mac_filtering"]) || m["security.acls"] != "" {
+				// We need a known device name for MAC filtering and ACLs
 				vethName = deviceNextVeth()
 			}
 
@@ -1411,9 +1432,14 @@ func (c *containerLXC) initStorage() error {
 func (c *containerLXC) expandConfig() error {
 	config := map[string]string{}
 
+	profileProject, err := dbProjectEffective(c.daemon.db, c.Project(), "profiles")
+	if err != nil {
+		return err
+	}
+
 	// Apply all the profiles
 	for _, name := range c.profiles {
-		profileConfig, err := dbProfileConfig(c.daemon.db, name)
+		profileConfig, err := dbProfileConfig(c.daemon.db, profileProject, name)
 		if err != nil {
 			return err
 		}
@@ -1435,9 +1461,14 @@ func (c *containerLXC) expandConfig() error {
 func (c *containerLXC) expandDevices() error {
 	devices := types.Devices{}
 
+	profileProject, err := dbProjectEffective(c.daemon.db, c.Project(), "profiles")
+	if err != nil {
+		return err
+	}
+
 	// Apply all the profiles
 	for _, p := range c.profiles {
-		profileDevices, err := dbDevices(c.daemon.db, p, true)
+		profileDevices, err := dbDevices(c.daemon.db, profileProject, p, true)
 		if err != nil {
 			return err
 		}
@@ -1640,6 +1671,7 @@ func (c *containerLXC) startCommon() (string, error) {
 	c.removeUnixDevices()
 	c.removeDiskDevices()
 	c.removeNetworkFilters()
+	c.resetSRIOVDevices()
 
 	var usbs []usbDevice
 	var gpus []gpuDevice
@@ -1734,7 +1766,52 @@ func (c *containerLXC) startCommon() (string, error) {
 				diskDevices[k] = m
 			}
 		} else if m["type"] == "nic" {
-			if m["nictype"] == "bridged" && shared.IsTrue(m["security.mac_filtering"]) {
+			// VLANs on bridged nics are set up once the container is started
+			if m["nictype"] == "bridged" && (m["vlan"] != "" || m["vlan.tagged"] != "") && !networkIsOVSBridge(m["parent"]) {
+				return "", fmt.Errorf("VLANs on bridged nics require an Open vSwitch bridge: %s", m["parent"])
+			}
+
+			if shared.StringInSlice(m["nictype"], []string{"routed", "ipvlan"}) {
+				err = networkRoutedCheckHost(m)
+				if err != nil {
+					return "", err
+				}
+			}
+
+			// Pass a virtual function to LXC as a physical nic
+			if m["nictype"] == "sriov" {
+				m, err = c.fillNetworkDevice(k, m)
+				if err != nil {
+					return "", err
+				}
+
+				vfDev, err := c.setupSRIOVDevice(k, m)
+				if err != nil {
+					return "", err
+				}
+
+				items := [][]string{
+					{"lxc.network.type", "phys"},
+					{"lxc.network.flags", "up"},
+					{"lxc.network.link", vfDev},
+					{"lxc.network.hwaddr", m["hwaddr"]},
+					{"lxc.network.mtu", m["mtu"]},
+					{"lxc.network.name", m["name"]},
+				}
+
+				for _, item := range items {
+					if item[1] == "" {
+						continue
+					}
+
+					err = lxcSetConfigItem(c.c, item[0], item[1])
+					if err != nil {
+						return "", err
+					}
+				}
+			}
+
+			if m["nictype"] == "bridged" && (shared.IsTrue(m["security.mac_filtering"]) || m["security.acls"] != "") {
 				m, err = c.fillNetworkDevice(k, m)
 				if err != nil {
 					return "", err
@@ -1766,9 +1843,18 @@ func (c *containerLXC) startCommon() (string, error) {
 					return "", fmt.Errorf("Failed to find device name for mac_filtering")
 				}
 
-				err = c.createNetworkFilter(vethName, m["parent"], m["hwaddr"])
-				if err != nil {
-					return "", err
+				if shared.IsTrue(m["security.mac_filtering"]) {
+					err = c.daemon.firewall.NicSetupMACFilter(m["parent"], vethName, m["hwaddr"])
+					if err != nil {
+						return "", err
+					}
+				}
+
+				if m["security.acls"] != "" {
+					err = networkACLNicApply(c.daemon, c.Name(), k, m["parent"], vethName, m["security.acls"])
+					if err != nil {
+						return "", err
+					}
 				}
 			}
 
@@ -1957,6 +2043,13 @@ func (c *containerLXC) Start(stateful bool) error {
 			return err
 		}
 
+		// Start the proxy devices
+		err = c.insertProxyDevices()
+		if err != nil {
+			shared.LogError("Failed starting container", ctxMap)
+			return err
+		}
+
 		shared.LogInfo("Started container", ctxMap)
 
 		return err
@@ -2023,6 +2116,27 @@ func (c *containerLXC) Start(stateful bool) error {
 		return err
 	}
 
+	// Plug the routed and ipvlan nics
+	err = c.insertRoutedNetworkDevices()
+	if err != nil {
+		shared.LogError("Failed starting container", ctxMap)
+		return err
+	}
+
+	// Configure the VLANs of the bridged nics
+	err = c.setupBridgedVLANs()
+	if err != nil {
+		shared.LogError("Failed starting container", ctxMap)
+		return err
+	}
+
+	// Start the proxy devices
+	err = c.insertProxyDevices()
+	if err != nil {
+		shared.LogError("Failed starting container", ctxMap)
+		return err
+	}
+
 	shared.LogInfo("Started container", ctxMap)
 
 	return nil
@@ -2101,7 +2215,8 @@ func (c *containerLXC) OnStart() error {
 			continue
 		}
 
-		if m["limits.max"] == "" && m["limits.ingress"] == "" && m["limits.egress"] == "" {
+		ingress, egress := c.networkLimits(m)
+		if ingress == "" && egress == "" {
 			continue
 		}
 
@@ -2340,6 +2455,18 @@ func (c *containerLXC) OnStop(target string) error {
 			shared.LogError("Unable to remove network filters", log.Ctx{"container": c.Name(), "err": err})
 		}
 
+		// Release the SR-IOV virtual functions
+		err = c.resetSRIOVDevices()
+		if err != nil {
+			shared.LogError("Unable to reset SR-IOV devices", log.Ctx{"container": c.Name(), "err": err})
+		}
+
+		// Stop all the proxy devices
+		err = c.removeProxyDevices()
+		if err != nil {
+			shared.LogError("Unable to remove proxy devices", log.Ctx{"container": c.Name(), "err": err})
+		}
+
 		// Reboot the container
 		if target == "reboot" {
 			// Start the container again
@@ -2475,6 +2602,9 @@ func (c *containerLXC) Render() (interface{}, interface{}, error) {
 	// Prepare the ETag
 	etag := []interface{}{c.architecture, c.localConfig, c.localDevices, c.ephemeral, c.profiles}
 
+	// Hide the project prefix
+	_, name := projectSplitName(c.name)
+
 	if c.IsSnapshot() {
 		return &api.ContainerSnapshot{
 			Architecture:    architectureName,
@@ -2485,9 +2615,10 @@ func (c *containerLXC) Render() (interface{}, interface{}, error) {
 			ExpandedConfig:  c.expandedConfig,
 			ExpandedDevices: c.expandedDevices,
 			LastUsedDate:    c.lastUsedDate,
-			Name:            c.name,
+			Name:            name,
 			Profiles:        c.profiles,
 			Stateful:        c.stateful,
+			ExpiresAt:       c.expiryDate,
 		}, etag, nil
 	} else {
 		// FIXME: Render shouldn't directly access the go-lxc struct
@@ -2500,10 +2631,11 @@ func (c *containerLXC) Render() (interface{}, interface{}, error) {
 		ct := api.Container{
 			ExpandedConfig:  c.expandedConfig,
 			ExpandedDevices: c.expandedDevices,
-			Name:            c.name,
+			Name:            name,
 			Status:          statusCode.String(),
 			StatusCode:      statusCode,
 			Stateful:        c.stateful,
+			Location:        clusterLocalName(c.daemon),
 		}
 
 		ct.Architecture = architectureName
@@ -2744,6 +2876,12 @@ func (c *containerLXC) Delete() error {
 		// Clean things up
 		c.cleanup()
 
+		// Remove all backups
+		if err := os.RemoveAll(shared.VarPath("backups", c.Name())); err != nil {
+			shared.LogWarn("Failed to delete backups", log.Ctx{"name": c.Name(), "err": err})
+			return err
+		}
+
 		// Delete the container from disk
 		if shared.PathExists(c.Path()) && c.storage != nil {
 			if err := c.storage.ContainerDelete(c); err != nil {
@@ -2788,6 +2926,10 @@ func (c *containerLXC) Delete() error {
 		networkClearLease(c.daemon, m["parent"], m["hwaddr"])
 	}
 
+	if !c.IsSnapshot() {
+		clusterContainersPublish(c.daemon)
+	}
+
 	shared.LogInfo("Deleted container", ctxMap)
 
 	return nil
@@ -2810,7 +2952,8 @@ func (c *containerLXC) Rename(newName string) error {
 	}
 
 	// Sanity checks
-	if !c.IsSnapshot() && !shared.ValidHostname(newName) {
+	_, name := projectSplitName(newName)
+	if !c.IsSnapshot() && !shared.ValidHostname(name) {
 		return fmt.Errorf("Invalid container name")
 	}
 
@@ -2886,6 +3029,16 @@ func (c *containerLXC) Rename(newName string) error {
 				return err
 			}
 		}
+
+		// Rename the backups directory
+		backupsPath := shared.VarPath("backups", oldName)
+		if shared.PathExists(backupsPath) {
+			err := os.Rename(backupsPath, shared.VarPath("backups", newName))
+			if err != nil {
+				shared.LogError("Failed renaming container", ctxMap)
+				return err
+			}
+		}
 	}
 
 	// Set the new name in the struct
@@ -2898,6 +3051,10 @@ func (c *containerLXC) Rename(newName string) error {
 	// Invalidate the go-lxc cache
 	c.c = nil
 
+	if !c.IsSnapshot() {
+		clusterContainersPublish(c.daemon)
+	}
+
 	shared.LogInfo("Renamed container", ctxMap)
 
 	return nil
@@ -3074,7 +3231,12 @@ func (c *containerLXC) Update(args containerArgs, userRequested bool) error {
 	}
 
 	// Validate the new profiles
-	profiles, err := dbProfiles(c.daemon.db)
+	profileProject, err := dbProjectEffective(c.daemon.db, c.Project(), "profiles")
+	if err != nil {
+		return err
+	}
+
+	profiles, err := dbProfiles(c.daemon.db, profileProject)
 	if err != nil {
 		return err
 	}
@@ -3637,6 +3799,11 @@ func (c *containerLXC) Update(args containerArgs, userRequested bool) error {
 				if err != nil {
 					return err
 				}
+			} else if m["type"] == "proxy" {
+				err = c.removeProxyDevice(k, m)
+				if err != nil {
+					return err
+				}
 			} else if m["type"] == "usb" {
 				if usbs == nil {
 					usbs, err = deviceLoadUsb()
@@ -3729,6 +3896,11 @@ func (c *containerLXC) Update(args containerArgs, userRequested bool) error {
 				if err != nil {
 					return err
 				}
+			} else if m["type"] == "proxy" {
+				err = c.insertProxyDevice(k, m)
+				if err != nil {
+					return err
+				}
 			} else if m["type"] == "usb" {
 				if usbs == nil {
 					usbs, err = deviceLoadUsb()
@@ -3865,7 +4037,7 @@ func (c *containerLXC) Update(args containerArgs, userRequested bool) error {
 		return err
 	}
 
-	err = dbContainerProfilesInsert(tx, c.id, args.Profiles)
+	err = dbContainerProfilesInsert(tx, c.id, profileProject, args.Profiles)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -4985,9 +5157,16 @@ func (c *containerLXC) networkState() map[string]api.ContainerStateNetwork {
 		return result
 	}
 
-	// Add HostName field
+	// Add HostName field and the installed traffic shaping
 	for netName, net := range networks {
 		net.HostName = c.getHostInterface(netName)
+		if net.HostName != "" {
+			ingress, egress := networkTCLimits(net.HostName)
+			if ingress != "" || egress != "" {
+				net.Limits = &api.ContainerStateNetworkLimits{Ingress: ingress, Egress: egress}
+			}
+		}
+
 		result[netName] = net
 	}
 
@@ -5613,11 +5792,249 @@ func (c *containerLXC) removeUnixDevices() error {
 	return nil
 }
 
+// setupBridgedVLANs applies the VLAN settings of the bridged nics to their
+// ports on the Open vSwitch bridges. LXC attaches the host side interfaces, so
+// this can only be done after the container is started.
+func (c *containerLXC) setupBridgedVLANs() error {
+	for _, k := range c.expandedDevices.DeviceNames() {
+		m := c.expandedDevices[k]
+		if m["type"] != "nic" || m["nictype"] != "bridged" || (m["vlan"] == "" && m["vlan.tagged"] == "") {
+			continue
+		}
+
+		m, err := c.fillNetworkDevice(k, m)
+		if err != nil {
+			return err
+		}
+
+		hostName := c.getHostInterface(m["name"])
+		if hostName == "" {
+			return fmt.Errorf("Failed to find the host interface of %s", k)
+		}
+
+		err = networkOVSSetPortVLAN(m["parent"], hostName, m["vlan"], m["vlan.tagged"])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertRoutedNetworkDevices plugs the routed and ipvlan nics into the
+// container. LXC can't configure those, so this happens once it's started.
+func (c *containerLXC) insertRoutedNetworkDevices() error {
+	for _, k := range c.expandedDevices.DeviceNames() {
+		m := c.expandedDevices[k]
+		if m["type"] != "nic" || !shared.StringInSlice(m["nictype"], []string{"routed", "ipvlan"}) {
+			continue
+		}
+
+		err := c.insertNetworkDevice(k, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setupRoutedNetworkDevice configures the addresses and routes of a routed or
+// ipvlan nic from within the container network namespace.
+func (c *containerLXC) setupRoutedNetworkDevice(m types.Device) error {
+	pid := fmt.Sprintf("%d", c.InitPID())
+
+	for _, command := range networkRoutedContainerCommands(m) {
+		_, err := shared.RunCommand(execPath, append([]string{"forknet", pid}, command...)...)
+		if err != nil {
+			return fmt.Errorf("Failed to configure the interface %s: %s", m["name"], err)
+		}
+	}
+
+	return nil
+}
+
+// volatileSet stores volatile keys straight into the database, without going
+// through a full container update. Empty values remove the keys.
+func (c *containerLXC) volatileSet(changes map[string]string) error {
+	for key, value := range changes {
+		err := dbContainerConfigRemove(c.daemon.db, c.id, key)
+		if err != nil {
+			return err
+		}
+
+		delete(c.localConfig, key)
+		delete(c.expandedConfig, key)
+
+		if value == "" {
+			continue
+		}
+
+		tx, err := dbBegin(c.daemon.db)
+		if err != nil {
+			return err
+		}
+
+		err = dbContainerConfigInsert(tx, c.id, map[string]string{key: value})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = txCommit(tx)
+		if err != nil {
+			return err
+		}
+
+		c.localConfig[key] = value
+		c.expandedConfig[key] = value
+	}
+
+	return nil
+}
+
+// setupSRIOVDevice allocates a virtual function of the parent of a sriov nic
+// and configures it. Its previous state is kept in volatile keys so it can be
+// reset once the container is done with it.
+func (c *containerLXC) setupSRIOVDevice(name string, m types.Device) (string, error) {
+	networkSRIOVLock.Lock()
+	defer networkSRIOVLock.Unlock()
+
+	claimed, err := dbContainersConfigValues(c.daemon.db, "volatile.%.host_name")
+	if err != nil {
+		return "", err
+	}
+
+	vfID, vfDev, err := networkSRIOVFreeVF(m["parent"], claimed)
+	if err != nil {
+		return "", err
+	}
+
+	vf, err := networkSRIOVGetVF(m["parent"], vfID)
+	if err != nil {
+		return "", err
+	}
+
+	mtu, err := ioutil.ReadFile(filepath.Join(networkSysfsNet, vfDev, "mtu"))
+	if err != nil {
+		return "", err
+	}
+
+	prefix := fmt.Sprintf("volatile.%s.", name)
+	err = c.volatileSet(map[string]string{
+		prefix + "host_name":              vfDev,
+		prefix + "last_state.mtu":         strings.TrimSpace(string(mtu)),
+		prefix + "last_state.vf.id":       fmt.Sprintf("%d", vfID),
+		prefix + "last_state.vf.hwaddr":   vf.hwaddr,
+		prefix + "last_state.vf.vlan":     vf.vlan,
+		prefix + "last_state.vf.spoofchk": fmt.Sprintf("%t", vf.spoofchk),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	err = networkSRIOVSetVF(m["parent"], vfID, networkSRIOVVF{hwaddr: m["hwaddr"], vlan: m["vlan"], spoofchk: shared.IsTrue(m["security.mac_filtering"])})
+	if err != nil {
+		c.resetSRIOVDevice(name, m)
+		return "", fmt.Errorf("Failed to configure the virtual function: %s", err)
+	}
+
+	return vfDev, nil
+}
+
+// resetSRIOVDevice restores the virtual function of a sriov nic to the state
+// it was in before being allocated and releases it.
+func (c *containerLXC) resetSRIOVDevice(name string, m types.Device) error {
+	prefix := fmt.Sprintf("volatile.%s.", name)
+	if c.localConfig[prefix+"last_state.vf.id"] == "" {
+		return nil
+	}
+
+	vfID, err := strconv.Atoi(c.localConfig[prefix+"last_state.vf.id"])
+	if err != nil {
+		return err
+	}
+
+	// The virtual function may take a moment to come back from the
+	// container network namespace
+	vfDev := ""
+	for i := 0; i < 20; i++ {
+		vfDev = networkSRIOVVFDevice(m["parent"], vfID)
+		if vfDev != "" {
+			break
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	if vfDev == "" {
+		return fmt.Errorf("Virtual function %d of %s didn't come back to the host", vfID, m["parent"])
+	}
+
+	hostName := c.localConfig[prefix+"host_name"]
+	if hostName != "" && vfDev != hostName {
+		_, err = shared.RunCommand("ip", "link", "set", "dev", vfDev, "down")
+		if err != nil {
+			return err
+		}
+
+		_, err = shared.RunCommand("ip", "link", "set", "dev", vfDev, "name", hostName)
+		if err != nil {
+			return err
+		}
+
+		vfDev = hostName
+	}
+
+	if c.localConfig[prefix+"last_state.mtu"] != "" {
+		_, err = shared.RunCommand("ip", "link", "set", "dev", vfDev, "mtu", c.localConfig[prefix+"last_state.mtu"])
+		if err != nil {
+			return err
+		}
+	}
+
+	vf := networkSRIOVVF{
+		hwaddr:   c.localConfig[prefix+"last_state.vf.hwaddr"],
+		vlan:     c.localConfig[prefix+"last_state.vf.vlan"],
+		spoofchk: shared.IsTrue(c.localConfig[prefix+"last_state.vf.spoofchk"]),
+	}
+
+	err = networkSRIOVSetVF(m["parent"], vfID, vf)
+	if err != nil {
+		return err
+	}
+
+	return c.volatileSet(map[string]string{
+		prefix + "host_name":              "",
+		prefix + "last_state.mtu":         "",
+		prefix + "last_state.vf.id":       "",
+		prefix + "last_state.vf.hwaddr":   "",
+		prefix + "last_state.vf.vlan":     "",
+		prefix + "last_state.vf.spoofchk": "",
+	})
+}
+
+func (c *containerLXC) resetSRIOVDevices() error {
+	for _, k := range c.expandedDevices.DeviceNames() {
+		m := c.expandedDevices[k]
+		if m["type"] != "nic" || m["nictype"] != "sriov" {
+			continue
+		}
+
+		err := c.resetSRIOVDevice(k, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Network device handling
 func (c *containerLXC) createNetworkDevice(name string, m types.Device) (string, error) {
 	var dev, n1 string
 
-	if shared.StringInSlice(m["nictype"], []string{"bridged", "p2p", "macvlan"}) {
+	if shared.StringInSlice(m["nictype"], []string{"bridged", "p2p", "macvlan", "routed", "ipvlan"}) {
 		// Host Virtual NIC name
 		if m["host_name"] != "" {
 			n1 = m["host_name"]
@@ -5626,8 +6043,8 @@ func (c *containerLXC) createNetworkDevice(name string, m types.Device) (string,
 		}
 	}
 
-	// Handle bridged and p2p
-	if shared.StringInSlice(m["nictype"], []string{"bridged", "p2p"}) {
+	// Handle bridged, p2p and routed
+	if shared.StringInSlice(m["nictype"], []string{"bridged", "p2p", "routed"}) {
 		n2 := deviceNextVeth()
 
 		_, err := shared.RunCommand("ip", "link", "add", n1, "type", "veth", "peer", "name", n2)
@@ -5647,12 +6064,27 @@ func (c *containerLXC) createNetworkDevice(name string, m types.Device) (string,
 				return "", fmt.Errorf("Failed to add interface to bridge: %s", err)
 			}
 
+			err = networkOVSSetPortVLAN(m["parent"], n1, m["vlan"], m["vlan.tagged"])
+			if err != nil {
+				deviceRemoveInterface(n2)
+				return "", err
+			}
+
 			// Attempt to disable IPv6 on the host side interface
 			if shared.PathExists(fmt.Sprintf("/proc/sys/net/ipv6/conf/%s/disable_ipv6", n1)) {
 				ioutil.WriteFile(fmt.Sprintf("/proc/sys/net/ipv6/conf/%s/disable_ipv6", n1), []byte("1"), 0644)
 			}
 		}
 
+		if m["nictype"] == "routed" {
+			err = networkRoutedSetupHost(m, n1)
+			if err != nil {
+				deviceRemoveInterface(n2)
+				networkRoutedClearHost(m)
+				return "", fmt.Errorf("Failed to set up the routes: %s", err)
+			}
+		}
+
 		dev = n2
 	}
 
@@ -5683,6 +6115,34 @@ func (c *containerLXC) createNetworkDevice(name string, m types.Device) (string,
 		dev = n1
 	}
 
+	// Handle SR-IOV
+	if m["nictype"] == "sriov" {
+		vfDev, err := c.setupSRIOVDevice(name, m)
+		if err != nil {
+			return "", err
+		}
+
+		if m["mtu"] != "" {
+			_, err = shared.RunCommand("ip", "link", "set", "dev", vfDev, "mtu", m["mtu"])
+			if err != nil {
+				c.resetSRIOVDevice(name, m)
+				return "", fmt.Errorf("Failed to set the MTU: %s", err)
+			}
+		}
+
+		dev = vfDev
+	}
+
+	// Handle ipvlan
+	if m["nictype"] == "ipvlan" {
+		_, err := shared.RunCommand("ip", "link", "add", n1, "link", m["parent"], "type", "ipvlan", "mode", "l3s")
+		if err != nil {
+			return "", fmt.Errorf("Failed to create the new ipvlan interface: %s", err)
+		}
+
+		dev = n1
+	}
+
 	// Set the MAC address
 	if m["hwaddr"] != "" {
 		_, err := shared.RunCommand("ip", "link", "set", "dev", dev, "address", m["hwaddr"])
@@ -5701,7 +6161,15 @@ func (c *containerLXC) createNetworkDevice(name string, m types.Device) (string,
 
 	// Set the filter
 	if m["nictype"] == "bridged" && shared.IsTrue(m["security.mac_filtering"]) {
-		err = c.createNetworkFilter(dev, m["parent"], m["hwaddr"])
+		err = c.daemon.firewall.NicSetupMACFilter(m["parent"], dev, m["hwaddr"])
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Apply the network ACLs
+	if m["nictype"] == "bridged" && m["security.acls"] != "" {
+		err = networkACLNicApply(c.daemon, c.Name(), name, m["parent"], dev, m["security.acls"])
 		if err != nil {
 			return "", err
 		}
@@ -5795,7 +6263,7 @@ func (c *containerLXC) fillNetworkDevice(name string, m types.Device) (types.Dev
 	}
 
 	// Fill in the MAC address
-	if m["nictype"] != "physical" && m["hwaddr"] == "" {
+	if !shared.StringInSlice(m["nictype"], []string{"physical", "ipvlan"}) && m["hwaddr"] == "" {
 		configKey := fmt.Sprintf("volatile.%s.hwaddr", name)
 		volatileHwaddr := c.localConfig[configKey]
 		if volatileHwaddr == "" {
@@ -5857,62 +6325,33 @@ func (c *containerLXC) fillNetworkDevice(name string, m types.Device) (types.Dev
 	return newDevice, nil
 }
 
-func (c *containerLXC) createNetworkFilter(name string, bridge string, hwaddr string) error {
-	_, err := shared.RunCommand("ebtables", "-A", "FORWARD", "-s", "!", hwaddr, "-i", name, "-o", bridge, "-j", "DROP")
-	if err != nil {
-		return err
-	}
-
-	_, err = shared.RunCommand("ebtables", "-A", "INPUT", "-s", "!", hwaddr, "-i", name, "-j", "DROP")
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (c *containerLXC) removeNetworkFilter(hwaddr string, bridge string) error {
-	out, err := shared.RunCommand("ebtables", "-L", "--Lmac2", "--Lx")
-	for _, line := range strings.Split(out, "\n") {
-		line = strings.TrimSpace(line)
-		fields := strings.Fields(line)
+func (c *containerLXC) removeNetworkFilters() error {
+	for k, m := range c.expandedDevices {
+		if m["type"] != "nic" {
+			continue
+		}
 
-		if len(fields) == 12 {
-			match := []string{"ebtables", "-t", "filter", "-A", "INPUT", "-s", "!", hwaddr, "-i", fields[9], "-j", "DROP"}
-			if reflect.DeepEqual(fields, match) {
-				fields[3] = "-D"
-				_, err = shared.RunCommand(fields[0], fields[1:]...)
-				if err != nil {
-					return err
-				}
-			}
-		} else if len(fields) == 14 {
-			match := []string{"ebtables", "-t", "filter", "-A", "FORWARD", "-s", "!", hwaddr, "-i", fields[9], "-o", bridge, "-j", "DROP"}
-			if reflect.DeepEqual(fields, match) {
-				fields[3] = "-D"
-				_, err = shared.RunCommand(fields[0], fields[1:]...)
-				if err != nil {
-					return err
-				}
-			}
+		// Routed nics also have proxy entries on their parent
+		if m["nictype"] == "routed" {
+			networkRoutedClearHost(m)
+			continue
 		}
-	}
 
-	return nil
-}
+		if m["nictype"] != "bridged" {
+			continue
+		}
 
-func (c *containerLXC) removeNetworkFilters() error {
-	for k, m := range c.expandedDevices {
 		m, err := c.fillNetworkDevice(k, m)
 		if err != nil {
 			return err
 		}
 
-		if m["type"] != "nic" || m["nictype"] != "bridged" {
-			continue
+		err = c.daemon.firewall.NicClearMACFilter(m["parent"], m["hwaddr"])
+		if err != nil {
+			return err
 		}
 
-		err = c.removeNetworkFilter(m["hwaddr"], m["parent"])
+		err = c.daemon.firewall.NicClearACLs(c.Name(), k, m["parent"])
 		if err != nil {
 			return err
 		}
@@ -5955,6 +6394,14 @@ func (c *containerLXC) insertNetworkDevice(name string, m types.Device) error {
 		return fmt.Errorf("Failed to attach interface: %s: %s", devName, err)
 	}
 
+	// Configure the addresses, no DHCP is involved
+	if shared.StringInSlice(m["nictype"], []string{"routed", "ipvlan"}) {
+		err = c.setupRoutedNetworkDevice(m)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -5980,6 +6427,8 @@ func (c *containerLXC) removeNetworkDevice(name string, m types.Device) error {
 	var hostName string
 	if m["nictype"] == "physical" {
 		hostName = m["parent"]
+	} else if m["nictype"] == "sriov" {
+		hostName = c.localConfig[fmt.Sprintf("volatile.%s.host_name", name)]
 	} else {
 		hostName = deviceNextVeth()
 	}
@@ -5997,18 +6446,36 @@ func (c *containerLXC) removeNetworkDevice(name string, m types.Device) error {
 	}
 
 	// If a veth, destroy it
-	if m["nictype"] != "physical" {
+	if !shared.StringInSlice(m["nictype"], []string{"physical", "sriov"}) {
 		deviceRemoveInterface(hostName)
 	}
 
+	// Release the virtual function
+	if m["nictype"] == "sriov" {
+		err = c.resetSRIOVDevice(name, m)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Remove any filter
 	if m["nictype"] == "bridged" {
-		err = c.removeNetworkFilter(m["hwaddr"], m["parent"])
+		err = c.daemon.firewall.NicClearMACFilter(m["parent"], m["hwaddr"])
+		if err != nil {
+			return err
+		}
+
+		err = c.daemon.firewall.NicClearACLs(c.Name(), name, m["parent"])
 		if err != nil {
 			return err
 		}
 	}
 
+	// Remove the proxy entries
+	if m["nictype"] == "routed" {
+		networkRoutedClearHost(m)
+	}
+
 	return nil
 }
 
@@ -6269,6 +6736,167 @@ func (c *containerLXC) removeDiskDevices() error {
 	return nil
 }
 
+// Proxy device handling
+func (c *containerLXC) insertProxyDevice(name string, m types.Device) error {
+	pid := c.InitPID()
+	if pid < 1 {
+		return fmt.Errorf("Can't add proxy device to stopped container")
+	}
+
+	listenProto, listenAddr, err := proxyParseAddr(m["listen"])
+	if err != nil {
+		return err
+	}
+
+	// Setup the listener on the host, the proxy process inherits it
+	var file *os.File
+	switch listenProto {
+	case "udp":
+		conn, err := net.ListenPacket("udp", listenAddr)
+		if err != nil {
+			return err
+		}
+
+		file, err = conn.(*net.UDPConn).File()
+		conn.Close()
+		if err != nil {
+			return err
+		}
+	case "unix":
+		listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: listenAddr, Net: "unix"})
+		if err != nil {
+			return err
+		}
+
+		listener.SetUnlinkOnClose(false)
+		file, err = listener.File()
+		listener.Close()
+		if err != nil {
+			return err
+		}
+	default:
+		listener, err := net.Listen("tcp", listenAddr)
+		if err != nil {
+			return err
+		}
+
+		file, err = listener.(*net.TCPListener).File()
+		listener.Close()
+		if err != nil {
+			return err
+		}
+	}
+	defer file.Close()
+
+	err = os.MkdirAll(c.DevicesPath(), 0711)
+	if err != nil {
+		return err
+	}
+
+	logFile, err := os.Create(filepath.Join(c.LogPath(), fmt.Sprintf("proxy.%s.log", name)))
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	// Spawn the proxy in the container's namespaces
+	cmd := exec.Command(execPath, "forkproxy", fmt.Sprintf("%d", pid), m["listen"], m["connect"])
+	cmd.ExtraFiles = []*os.File{file}
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	go cmd.Wait()
+
+	pidPath := filepath.Join(c.DevicesPath(), fmt.Sprintf("proxy.%s", name))
+	err = ioutil.WriteFile(pidPath, []byte(fmt.Sprintf("%d", cmd.Process.Pid)), 0600)
+	if err != nil {
+		cmd.Process.Kill()
+		return err
+	}
+
+	return nil
+}
+
+func (c *containerLXC) insertProxyDevices() error {
+	for _, name := range c.expandedDevices.DeviceNames() {
+		m := c.expandedDevices[name]
+		if m["type"] != "proxy" {
+			continue
+		}
+
+		err := c.insertProxyDevice(name, m)
+		if err != nil {
+			c.removeProxyDevices()
+			return fmt.Errorf("Failed to start proxy device '%s': %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *containerLXC) removeProxyDevice(name string, m types.Device) error {
+	pidPath := filepath.Join(c.DevicesPath(), fmt.Sprintf("proxy.%s", name))
+	if !shared.PathExists(pidPath) {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(pidPath)
+	if err != nil {
+		return err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return err
+	}
+
+	// The proxy may already be gone along with the container
+	err = syscall.Kill(pid, syscall.SIGTERM)
+	if err != nil && err != syscall.ESRCH {
+		return err
+	}
+
+	// Unix sockets aren't removed when the proxy gets killed
+	listenProto, listenAddr, err := proxyParseAddr(m["listen"])
+	if err == nil && listenProto == "unix" {
+		os.Remove(listenAddr)
+	}
+
+	return os.Remove(pidPath)
+}
+
+func (c *containerLXC) removeProxyDevices() error {
+	// Check that we indeed have devices to remove
+	if !shared.PathExists(c.DevicesPath()) {
+		return nil
+	}
+
+	// Load the directory listing
+	dents, err := ioutil.ReadDir(c.DevicesPath())
+	if err != nil {
+		return err
+	}
+
+	// Go through all the proxy devices
+	for _, f := range dents {
+		if !strings.HasPrefix(f.Name(), "proxy.") {
+			continue
+		}
+
+		name := strings.TrimPrefix(f.Name(), "proxy.")
+		err := c.removeProxyDevice(name, c.expandedDevices[name])
+		if err != nil {
+			shared.LogError("Failed to remove proxy device", log.Ctx{"err": err, "device": name})
+		}
+	}
+
+	return nil
+}
+
 // Block I/O limits
 func (c *containerLXC) getDiskLimits() (map[string]deviceBlockLimit, error) {
 	result := map[string]deviceBlockLimit{}
@@ -6502,6 +7130,20 @@ func (c *containerLXC) getHostInterface(name string) string {
 	return ""
 }
 
+// networkLimits returns the effective limits of a nic, including the default
+// limits of its managed network.
+func (c *containerLXC) networkLimits(m types.Device) (string, string) {
+	var netConfig map[string]string
+	if m["nictype"] == "bridged" && m["parent"] != "" {
+		_, dbInfo, err := dbNetworkGet(c.daemon.db, m["parent"])
+		if err == nil {
+			netConfig = dbInfo.Config
+		}
+	}
+
+	return networkNicLimits(m, netConfig)
+}
+
 func (c *containerLXC) setNetworkLimits(name string, m types.Device) error {
 	// We can only do limits on some network type
 	if m["nictype"] != "bridged" && m["nictype"] != "p2p" {
@@ -6532,24 +7174,21 @@ func (c *containerLXC) setNetworkLimits(name string, m types.Device) error {
 		return fmt.Errorf("LXC doesn't now about this device and the host_name property isn't set, can't find host side veth name")
 	}
 
-	// Apply max limit
-	if m["limits.max"] != "" {
-		m["limits.ingress"] = m["limits.max"]
-		m["limits.egress"] = m["limits.max"]
-	}
+	// Get the effective limits
+	ingress, egress := c.networkLimits(m)
 
 	// Parse the values
 	var ingressInt int64
-	if m["limits.ingress"] != "" {
-		ingressInt, err = shared.ParseBitSizeString(m["limits.ingress"])
+	if ingress != "" {
+		ingressInt, err = shared.ParseBitSizeString(ingress)
 		if err != nil {
 			return err
 		}
 	}
 
 	var egressInt int64
-	if m["limits.egress"] != "" {
-		egressInt, err = shared.ParseBitSizeString(m["limits.egress"])
+	if egress != "" {
+		egressInt, err = shared.ParseBitSizeString(egress)
 		if err != nil {
 			return err
 		}
@@ -6560,7 +7199,7 @@ func (c *containerLXC) setNetworkLimits(name string, m types.Device) error {
 	shared.RunCommand("tc", "qdisc", "del", "dev", veth, "ingress")
 
 	// Apply new limits
-	if m["limits.ingress"] != "" {
+	if ingress != "" {
 		out, err := shared.RunCommand("tc", "qdisc", "add", "dev", veth, "root", "handle", "1:0", "htb", "default", "10")
 		if err != nil {
 			return fmt.Errorf("Failed to create root tc qdisc: %s", out)
@@ -6577,7 +7216,7 @@ func (c *containerLXC) setNetworkLimits(name string, m types.Device) error {
 		}
 	}
 
-	if m["limits.egress"] != "" {
+	if egress != "" {
 		out, err := shared.RunCommand("tc", "qdisc", "add", "dev", veth, "handle", "ffff:0", "ingress")
 		if err != nil {
 			return fmt.Errorf("Failed to create ingress tc qdisc: %s", out)
@@ -6633,6 +7272,9 @@ func (c *containerLXC) CreationDate() time.Time {
 func (c *containerLXC) LastUsedDate() time.Time {
 	return c.lastUsedDate
 }
+func (c *containerLXC) ExpiryDate() time.Time {
+	return c.expiryDate
+}
 func (c *containerLXC) ExpandedConfig() map[string]string {
 	return c.expandedConfig
 }
@@ -6727,6 +7369,11 @@ func (c *containerLXC) Name() string {
 	return c.name
 }
 
+func (c *containerLXC) Project() string {
+	project, _ := projectSplitName(c.name)
+	return project
+}
+
 func (c *containerLXC) Profiles() []string {
 	return c.profiles
 }
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// The metadata.yaml and templates of a container are what "publish" embeds
+// in the resulting image and what templateApplyNow renders.
+
+func containerMetadataRead(c container) (*api.ImageMetadata, error) {
+	metadata := api.ImageMetadata{}
+
+	content, err := ioutil.ReadFile(filepath.Join(c.Path(), "metadata.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &metadata, nil
+		}
+
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(content, &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse metadata.yaml: %v", err)
+	}
+
+	return &metadata, nil
+}
+
+// containerMetadataTemplatePath returns the path of a template of the
+// container, making sure it can't point outside of its templates directory.
+// Images may ship symlinks, so the templates directory has to be a real
+// directory and the template, if it exists, a regular file.
+func containerMetadataTemplatePath(c container, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return "", fmt.Errorf("Invalid template name: %s", name)
+	}
+
+	fi, err := os.Lstat(c.TemplatesPath())
+	if err == nil && !fi.IsDir() {
+		return "", fmt.Errorf("The templates directory isn't a directory")
+	}
+
+	path := filepath.Join(c.TemplatesPath(), name)
+	fi, err = os.Lstat(path)
+	if err == nil && !fi.Mode().IsRegular() {
+		return "", fmt.Errorf("The template %s isn't a regular file", name)
+	}
+
+	return path, nil
+}
+
+func containerMetadataGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	ourStart, err := c.StorageStart()
+	if err != nil {
+		return InternalError(err)
+	}
+	if ourStart {
+		defer c.StorageStop()
+	}
+
+	metadata, err := containerMetadataRead(c)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return SyncResponseETag(true, metadata, metadata)
+}
+
+func containerMetadataPut(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if c.IsSnapshot() {
+		return BadRequest(fmt.Errorf("The metadata of snapshots can't be modified"))
+	}
+
+	ourStart, err := c.StorageStart()
+	if err != nil {
+		return InternalError(err)
+	}
+	if ourStart {
+		defer c.StorageStop()
+	}
+
+	// Validate the ETag
+	metadata, err := containerMetadataRead(c)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	err = etagCheck(r, metadata)
+	if err != nil {
+		return PreconditionFailed(err)
+	}
+
+	req := api.ImageMetadata{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	for path, template := range req.Templates {
+		if template == nil {
+			return BadRequest(fmt.Errorf("Missing definition of the template for %s", path))
+		}
+
+		_, err := containerMetadataTemplatePath(c, template.Template)
+		if err != nil {
+			return BadRequest(err)
+		}
+	}
+
+	data, err := yaml.Marshal(&req)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(c.Path(), "metadata.yaml"), data, 0644)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return EmptySyncResponse
+}
+
+func containerMetadataTemplatesGet(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
+	name := mux.Vars(r)["name"]
+	c, err := containerLoadByName(d, projectPrefix(project, name))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	ourStart, err := c.StorageStart()
+	if err != nil {
+		return InternalError(err)
+	}
+	if ourStart {
+		defer c.StorageStop()
+	}
+
+	result := []string{}
+
+	dents, err := ioutil.ReadDir(c.TemplatesPath())
+	if err != nil && !os.IsNotExist(err) {
+		return InternalError(err)
+	}
+
+	names := []string{}
+	for _, dent := range dents {
+		if !dent.Mode().IsRegular() {
+			continue
+		}
+
+		names = append(names, dent.Name())
+	}
+	sort.Strings(names)
+
+	for _, template := range names {
+		result = append(result, fmt.Sprintf("/%s/containers/%s/metadata/templates/%s%s", version.APIVersion, name, template, projectQuery(project)))
+	}
+
+	return SyncResponse(true, result)
+}
+
+func containerMetadataTemplateHandler(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if r.Method != "GET" && c.IsSnapshot() {
+		return BadRequest(fmt.Errorf("The templates of snapshots can't be modified"))
+	}
+
+	ourStart, err := c.StorageStart()
+	if err != nil {
+		return InternalError(err)
+	}
+	if ourStart {
+		defer c.StorageStop()
+	}
+
+	path, err := containerMetadataTemplatePath(c, mux.Vars(r)["templateName"])
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	switch r.Method {
+	case "GET":
+		return containerMetadataTemplateGet(path, r)
+	case "POST":
+		if shared.PathExists(path) {
+			return Conflict
+		}
+
+		return containerMetadataTemplateWrite(c, path, r)
+	case "PUT":
+		if !shared.PathExists(path) {
+			return NotFound
+		}
+
+		return containerMetadataTemplateWrite(c, path, r)
+	case "DELETE":
+		err := os.Remove(path)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		return EmptySyncResponse
+	default:
+		return NotFound
+	}
+}
+
+func containerMetadataTemplateGet(path string, r *http.Request) Response {
+	// The storage of the container may be stopped by the time the file is
+	// served, so serve a copy of it.
+	temp, err := ioutil.TempFile("", "lxd_template_")
+	if err != nil {
+		return InternalError(err)
+	}
+	temp.Close()
+
+	err = shared.FileCopy(path, temp.Name())
+	if err != nil {
+		os.Remove(temp.Name())
+		if os.IsNotExist(err) {
+			return NotFound
+		}
+
+		return InternalError(err)
+	}
+
+	files := make([]fileResponseEntry, 1)
+	files[0].identifier = filepath.Base(path)
+	files[0].path = temp.Name()
+	files[0].filename = filepath.Base(path)
+
+	return FileResponse(r, files, nil, true)
+}
+
+func containerMetadataTemplateWrite(c container, path string, r *http.Request) Response {
+	err := os.MkdirAll(c.TemplatesPath(), 0755)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|syscall.O_NOFOLLOW, 0644)
+	if err != nil {
+		return InternalError(err)
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r.Body)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return EmptySyncResponse
+}
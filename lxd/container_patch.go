@@ -17,7 +17,7 @@ import (
 func containerPatch(d *Daemon, r *http.Request) Response {
 	// Get the container
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
 	if err != nil {
 		return NotFound
 	}
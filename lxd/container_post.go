@@ -13,8 +13,9 @@ import (
 )
 
 func containerPost(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByName(d, projectPrefix(project, name))
 	if err != nil {
 		return SmartError(err)
 	}
@@ -64,13 +65,13 @@ func containerPost(d *Daemon, r *http.Request) Response {
 	}
 
 	// Check that the name isn't already in use
-	id, _ := dbContainerId(d.db, req.Name)
+	id, _ := dbContainerId(d.db, projectPrefix(project, req.Name))
 	if id > 0 {
 		return Conflict
 	}
 
 	run := func(*operation) error {
-		return c.Rename(req.Name)
+		return c.Rename(projectPrefix(project, req.Name))
 	}
 
 	resources := map[string][]string{}
@@ -22,7 +22,7 @@ import (
 func containerPut(d *Daemon, r *http.Request) Response {
 	// Get the container
 	name := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
 	if err != nil {
 		return NotFound
 	}
@@ -66,8 +66,13 @@ func containerPut(d *Daemon, r *http.Request) Response {
 		}
 	} else {
 		// Snapshot Restore
+		snap := configRaw.Restore
+		if shared.IsSnapshot(snap) {
+			snap = projectPrefix(projectParam(r), snap)
+		}
+
 		do = func(op *operation) error {
-			return containerSnapRestore(d, name, configRaw.Restore)
+			return containerSnapRestore(d, c.Name(), snap)
 		}
 	}
 
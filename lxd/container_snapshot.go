@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	log "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -21,8 +23,9 @@ func containerSnapshotsGet(d *Daemon, r *http.Request) Response {
 		recursion = 0
 	}
 
+	project := projectParam(r)
 	cname := mux.Vars(r)["name"]
-	c, err := containerLoadByName(d, cname)
+	c, err := containerLoadByName(d, projectPrefix(project, cname))
 	if err != nil {
 		return SmartError(err)
 	}
@@ -38,7 +41,7 @@ func containerSnapshotsGet(d *Daemon, r *http.Request) Response {
 	for _, snap := range snaps {
 		snapName := strings.SplitN(snap.Name(), shared.SnapshotDelimiter, 2)[1]
 		if recursion == 0 {
-			url := fmt.Sprintf("/%s/containers/%s/snapshots/%s", version.APIVersion, cname, snapName)
+			url := fmt.Sprintf("/%s/containers/%s/snapshots/%s%s", version.APIVersion, cname, snapName, projectQuery(project))
 			resultString = append(resultString, url)
 		} else {
 			render, _, err := snap.Render()
@@ -60,9 +63,16 @@ func containerSnapshotsGet(d *Daemon, r *http.Request) Response {
 /*
  * Note, the code below doesn't deal with snapshots of snapshots.
  * To do that, we'll need to weed out based on # slashes in names
+ *
+ * The pattern is a snapshot name containing "%d", such as "snap%d".
  */
-func nextSnapshot(d *Daemon, name string) int {
-	base := name + shared.SnapshotDelimiter + "snap"
+func nextSnapshot(d *Daemon, name string, pattern string) int {
+	prefix, suffix, err := shared.SnapshotPatternSplit(pattern)
+	if err != nil {
+		return 0
+	}
+
+	base := name + shared.SnapshotDelimiter + prefix
 	length := len(base)
 	q := fmt.Sprintf("SELECT name FROM containers WHERE type=? AND SUBSTR(name,1,?)=?")
 	var numstr string
@@ -76,15 +86,21 @@ func nextSnapshot(d *Daemon, name string) int {
 
 	for _, r := range results {
 		numstr = r[0].(string)
-		if len(numstr) <= length {
+		if len(numstr) <= length+len(suffix) || !strings.HasSuffix(numstr, suffix) {
 			continue
 		}
-		substr := numstr[length:]
-		var num int
-		count, err := fmt.Sscanf(substr, "%d", &num)
-		if err != nil || count != 1 {
+
+		// The whole name has to match, so only digits may be left
+		substr := strings.TrimSuffix(numstr[length:], suffix)
+		if strings.Trim(substr, "0123456789") != "" {
 			continue
 		}
+
+		num, err := strconv.Atoi(substr)
+		if err != nil {
+			continue
+		}
+
 		if num >= max {
 			max = num + 1
 		}
@@ -102,7 +118,7 @@ func containerSnapshotsPost(d *Daemon, r *http.Request) Response {
 	 * 2. copy the database info over
 	 * 3. copy over the rootfs
 	 */
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
 	if err != nil {
 		return SmartError(err)
 	}
@@ -122,33 +138,24 @@ func containerSnapshotsPost(d *Daemon, r *http.Request) Response {
 
 	if req.Name == "" {
 		// come up with a name
-		i := nextSnapshot(d, name)
+		i := nextSnapshot(d, c.Name(), "snap%d")
 		req.Name = fmt.Sprintf("snap%d", i)
 	}
 
-	fullName := name +
-		shared.SnapshotDelimiter +
-		req.Name
-
-	snapshot := func(op *operation) error {
-		args := containerArgs{
-			Name:         fullName,
-			Ctype:        cTypeSnapshot,
-			Config:       c.LocalConfig(),
-			Profiles:     c.Profiles(),
-			Ephemeral:    c.IsEphemeral(),
-			BaseImage:    c.ExpandedConfig()["volatile.base_image"],
-			Architecture: c.Architecture(),
-			Devices:      c.LocalDevices(),
-			Stateful:     req.Stateful,
-		}
-
-		_, err := containerCreateAsSnapshot(d, args, c)
+	// Default to the expiry configured for the container
+	var expiry time.Time
+	if req.ExpiresAt != nil {
+		expiry = *req.ExpiresAt
+	} else {
+		expiry, err = shared.GetSnapshotExpiry(time.Now().UTC(), c.ExpandedConfig()["snapshots.expiry"])
 		if err != nil {
-			return err
+			return BadRequest(err)
 		}
+	}
 
-		return nil
+	snapshot := func(op *operation) error {
+		_, err := containerSnapshotCreate(d, c, req.Name, req.Stateful, expiry)
+		return err
 	}
 
 	resources := map[string][]string{}
@@ -162,13 +169,103 @@ func containerSnapshotsPost(d *Daemon, r *http.Request) Response {
 	return OperationResponse(op)
 }
 
+// containerSnapshotCreate takes a snapshot of the container with the given
+// name and expiry date (zero for no expiry).
+func containerSnapshotCreate(d *Daemon, c container, name string, stateful bool, expiry time.Time) (container, error) {
+	args := containerArgs{
+		Name:         c.Name() + shared.SnapshotDelimiter + name,
+		Ctype:        cTypeSnapshot,
+		Config:       c.LocalConfig(),
+		Profiles:     c.Profiles(),
+		Ephemeral:    c.IsEphemeral(),
+		BaseImage:    c.ExpandedConfig()["volatile.base_image"],
+		Architecture: c.Architecture(),
+		Devices:      c.LocalDevices(),
+		Stateful:     stateful,
+		ExpiryDate:   expiry,
+	}
+
+	return containerCreateAsSnapshot(d, args, c)
+}
+
+// autoCreateContainerSnapshots takes the snapshots scheduled for the given
+// minute, as set through snapshots.schedule.
+func autoCreateContainerSnapshots(d *Daemon, now time.Time) {
+	names, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		shared.LogError("Unable to retrieve the list of containers", log.Ctx{"err": err})
+		return
+	}
+
+	for _, name := range names {
+		c, err := containerLoadByName(d, name)
+		if err != nil {
+			continue
+		}
+
+		config := c.ExpandedConfig()
+		if config["snapshots.schedule"] == "" {
+			continue
+		}
+
+		schedule, err := shared.CronParse(config["snapshots.schedule"])
+		if err != nil || !schedule.Matches(now) {
+			continue
+		}
+
+		if !c.IsRunning() && !shared.IsTrue(config["snapshots.schedule.stopped"]) {
+			continue
+		}
+
+		pattern := config["snapshots.pattern"]
+		if pattern == "" {
+			pattern = "snap%d"
+		}
+
+		expiry, err := shared.GetSnapshotExpiry(now, config["snapshots.expiry"])
+		if err != nil {
+			shared.LogError("Invalid snapshot expiry", log.Ctx{"container": name, "err": err})
+			continue
+		}
+
+		snapName := fmt.Sprintf(pattern, nextSnapshot(d, name, pattern))
+
+		shared.LogInfo("Creating scheduled snapshot", log.Ctx{"container": name, "snapshot": snapName})
+		_, err = containerSnapshotCreate(d, c, snapName, false, expiry)
+		if err != nil {
+			shared.LogError("Error creating scheduled snapshot", log.Ctx{"container": name, "snapshot": snapName, "err": err})
+		}
+	}
+}
+
+func pruneExpiredContainerSnapshots(d *Daemon) {
+	names, err := dbContainerSnapshotsGetExpired(d.db, time.Now().UTC())
+	if err != nil {
+		shared.LogError("Unable to retrieve the list of expired container snapshots", log.Ctx{"err": err})
+		return
+	}
+
+	for _, name := range names {
+		c, err := containerLoadByName(d, name)
+		if err != nil {
+			continue
+		}
+
+		shared.LogInfo("Deleting expired snapshot", log.Ctx{"snapshot": name})
+		err = c.Delete()
+		if err != nil {
+			shared.LogError("Error deleting expired snapshot", log.Ctx{"snapshot": name, "err": err})
+		}
+	}
+}
+
 func snapshotHandler(d *Daemon, r *http.Request) Response {
 	containerName := mux.Vars(r)["name"]
 	snapshotName := mux.Vars(r)["snapshotName"]
 
 	sc, err := containerLoadByName(
 		d,
-		containerName+
+		projectPrefix(projectParam(r), containerName)+
 			shared.SnapshotDelimiter+
 			snapshotName)
 	if err != nil {
@@ -225,7 +322,8 @@ func snapshotPost(d *Daemon, r *http.Request, sc container, containerName string
 		return BadRequest(err)
 	}
 
-	fullName := containerName + shared.SnapshotDelimiter + newName
+	parentName := strings.SplitN(sc.Name(), shared.SnapshotDelimiter, 2)[0]
+	fullName := parentName + shared.SnapshotDelimiter + newName
 
 	// Check that the name isn't already in use
 	id, _ := dbContainerId(d.db, fullName)
@@ -35,6 +35,7 @@ func (suite *lxdTestSuite) TestContainer_ProfilesMulti() {
 	// Create an unprivileged profile
 	_, err := dbProfileCreate(
 		suite.d.db,
+		"default",
 		"unprivileged",
 		"unprivileged",
 		map[string]string{"security.privileged": "true"},
@@ -42,7 +43,7 @@ func (suite *lxdTestSuite) TestContainer_ProfilesMulti() {
 
 	suite.Req.Nil(err, "Failed to create the unprivileged profile.")
 	defer func() {
-		dbProfileDelete(suite.d.db, "unprivileged")
+		dbProfileDelete(suite.d.db, "default", "unprivileged")
 	}()
 
 	args := containerArgs{
@@ -383,3 +384,24 @@ func (suite *lxdTestSuite) TestContainer_findIdmap_maxed() {
 		}
 	}
 }
+
+func (suite *lxdTestSuite) TestContainer_ValidDevicesProxy() {
+	valid := types.Devices{
+		"web": types.Device{"type": "proxy", "listen": "tcp:0.0.0.0:80", "connect": "tcp:127.0.0.1:80"},
+		"dns": types.Device{"type": "proxy", "listen": "udp:[::]:53", "connect": "udp:127.0.0.1:53"},
+		"app": types.Device{"type": "proxy", "listen": "tcp:127.0.0.1:8080", "connect": "unix:/run/app.sock"},
+	}
+	suite.Req.Nil(containerValidDevices(suite.d, valid, false, false))
+
+	invalid := []types.Device{
+		{"type": "proxy", "listen": "tcp:0.0.0.0:80"},
+		{"type": "proxy", "listen": "sctp:0.0.0.0:80", "connect": "tcp:127.0.0.1:80"},
+		{"type": "proxy", "listen": "tcp:0.0.0.0", "connect": "tcp:127.0.0.1:80"},
+		{"type": "proxy", "listen": "udp:0.0.0.0:53", "connect": "tcp:127.0.0.1:53"},
+		{"type": "proxy", "listen": "tcp:0.0.0.0:80", "connect": "tcp:127.0.0.1:80", "bind": "host"},
+	}
+
+	for _, m := range invalid {
+		suite.Req.NotNil(containerValidDevices(suite.d, types.Devices{"proxy": m}, false, false), "%v should be refused", m)
+	}
+}
@@ -39,6 +39,25 @@ var containerFileCmd = Command{
 	delete: containerFileHandler,
 }
 
+var containerMetadataCmd = Command{
+	name: "containers/{name}/metadata",
+	get:  containerMetadataGet,
+	put:  containerMetadataPut,
+}
+
+var containerMetadataTemplatesCmd = Command{
+	name: "containers/{name}/metadata/templates",
+	get:  containerMetadataTemplatesGet,
+}
+
+var containerMetadataTemplateCmd = Command{
+	name:   "containers/{name}/metadata/templates/{templateName}",
+	get:    containerMetadataTemplateHandler,
+	post:   containerMetadataTemplateHandler,
+	put:    containerMetadataTemplateHandler,
+	delete: containerMetadataTemplateHandler,
+}
+
 var containerSnapshotsCmd = Command{
 	name: "containers/{name}/snapshots",
 	get:  containerSnapshotsGet,
@@ -52,6 +71,24 @@ var containerSnapshotCmd = Command{
 	delete: snapshotHandler,
 }
 
+var containerBackupsCmd = Command{
+	name: "containers/{name}/backups",
+	get:  containerBackupsGet,
+	post: containerBackupsPost,
+}
+
+var containerBackupCmd = Command{
+	name:   "containers/{name}/backups/{backupName}",
+	get:    containerBackupHandler,
+	post:   containerBackupHandler,
+	delete: containerBackupHandler,
+}
+
+var containerBackupExportCmd = Command{
+	name: "containers/{name}/backups/{backupName}/export",
+	get:  containerBackupExportGet,
+}
+
 var containerExecCmd = Command{
 	name: "containers/{name}/exec",
 	post: containerExecPost,
@@ -11,10 +11,15 @@ import (
 )
 
 func containersGet(d *Daemon, r *http.Request) Response {
+	project := projectParam(r)
+
 	for i := 0; i < 100; i++ {
-		result, err := doContainersGet(d, d.isRecursionRequest(r))
+		result, err := doContainersGet(d, project, d.isRecursionRequest(r))
+		if err == nil && !clusterIsForwarded(d, r) {
+			result, err = clusterMergeContainers(d, project, result, d.isRecursionRequest(r))
+		}
 		if err == nil {
-			return SyncResponse(true, result)
+			return SyncResponse(true, certificateFilterContainers(d, r, result))
 		}
 		if !isDbLockedError(err) {
 			shared.LogDebugf("DBERR: containersGet: error %q", err)
@@ -30,7 +35,7 @@ func containersGet(d *Daemon, r *http.Request) Response {
 	return InternalError(fmt.Errorf("DB is locked"))
 }
 
-func doContainersGet(d *Daemon, recursion bool) (interface{}, error) {
+func doContainersGet(d *Daemon, project string, recursion bool) (interface{}, error) {
 	result, err := dbContainersList(d.db, cTypeRegular)
 	if err != nil {
 		return nil, err
@@ -43,14 +48,20 @@ func doContainersGet(d *Daemon, recursion bool) (interface{}, error) {
 	}
 
 	for _, container := range result {
+		// Only list the containers of the requested project
+		ctProject, name := projectSplitName(container)
+		if ctProject != project {
+			continue
+		}
+
 		if !recursion {
-			url := fmt.Sprintf("/%s/containers/%s", version.APIVersion, container)
+			url := fmt.Sprintf("/%s/containers/%s%s", version.APIVersion, name, projectQuery(project))
 			resultString = append(resultString, url)
 		} else {
 			c, err := doContainerGet(d, container)
 			if err != nil {
 				c = &api.Container{
-					Name:       container,
+					Name:       name,
 					Status:     api.Error.String(),
 					StatusCode: api.Error}
 			}
@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/dustinkirkland/golang-petname"
@@ -19,17 +22,22 @@ import (
 	log "gopkg.in/inconshreveable/log15.v2"
 )
 
-func createFromImage(d *Daemon, req *api.ContainersPost) Response {
+func createFromImage(d *Daemon, project string, req *api.ContainersPost) Response {
 	var hash string
 	var err error
 
+	imageProject, err := dbProjectEffective(d.db, project, "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	if req.Source.Fingerprint != "" {
 		hash = req.Source.Fingerprint
 	} else if req.Source.Alias != "" {
 		if req.Source.Server != "" {
 			hash = req.Source.Alias
 		} else {
-			_, alias, err := dbImageAliasGet(d.db, req.Source.Alias, true)
+			_, alias, err := dbImageAliasGet(d.db, imageProject, req.Source.Alias, true)
 			if err != nil {
 				return InternalError(err)
 			}
@@ -41,7 +49,7 @@ func createFromImage(d *Daemon, req *api.ContainersPost) Response {
 			return BadRequest(fmt.Errorf("Property match is only supported for local images"))
 		}
 
-		hashes, err := dbImagesGet(d.db, false)
+		hashes, err := dbImagesGet(d.db, imageProject, false)
 		if err != nil {
 			return InternalError(err)
 		}
@@ -49,7 +57,7 @@ func createFromImage(d *Daemon, req *api.ContainersPost) Response {
 		var image *api.Image
 
 		for _, imageHash := range hashes {
-			_, img, err := dbImageGet(d.db, imageHash, false, true)
+			_, img, err := dbImageGet(d.db, imageProject, imageHash, false, true)
 			if err != nil {
 				continue
 			}
@@ -88,20 +96,20 @@ func createFromImage(d *Daemon, req *api.ContainersPost) Response {
 			Ctype:     cTypeRegular,
 			Devices:   req.Devices,
 			Ephemeral: req.Ephemeral,
-			Name:      req.Name,
+			Name:      projectPrefix(project, req.Name),
 			Profiles:  req.Profiles,
 		}
 
 		var info *api.Image
 		if req.Source.Server != "" {
 			info, err = d.ImageDownload(
-				op, req.Source.Server, req.Source.Protocol, req.Source.Certificate, req.Source.Secret,
+				op, imageProject, req.Source.Server, req.Source.Protocol, req.Source.Certificate, req.Source.SigningKeys, req.Source.Secret,
 				hash, true, daemonConfig["images.auto_update_cached"].GetBool(), "")
 			if err != nil {
 				return err
 			}
 		} else {
-			_, info, err = dbImageGet(d.db, hash, false, false)
+			_, info, err = dbImageGet(d.db, imageProject, hash, false, false)
 			if err != nil {
 				return err
 			}
@@ -127,13 +135,13 @@ func createFromImage(d *Daemon, req *api.ContainersPost) Response {
 	return OperationResponse(op)
 }
 
-func createFromNone(d *Daemon, req *api.ContainersPost) Response {
+func createFromNone(d *Daemon, project string, req *api.ContainersPost) Response {
 	args := containerArgs{
 		Config:    req.Config,
 		Ctype:     cTypeRegular,
 		Devices:   req.Devices,
 		Ephemeral: req.Ephemeral,
-		Name:      req.Name,
+		Name:      projectPrefix(project, req.Name),
 		Profiles:  req.Profiles,
 	}
 
@@ -161,7 +169,7 @@ func createFromNone(d *Daemon, req *api.ContainersPost) Response {
 	return OperationResponse(op)
 }
 
-func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
+func createFromMigration(d *Daemon, project string, req *api.ContainersPost) Response {
 	// Validate migration mode
 	if req.Source.Mode != "pull" && req.Source.Mode != "push" {
 		return NotImplemented
@@ -183,10 +191,20 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 		Ctype:        cTypeRegular,
 		Devices:      req.Devices,
 		Ephemeral:    req.Ephemeral,
-		Name:         req.Name,
+		Name:         projectPrefix(project, req.Name),
 		Profiles:     req.Profiles,
 	}
 
+	profileProject, err := dbProjectEffective(d.db, project, "profiles")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	imageProject, err := dbProjectEffective(d.db, project, "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// Grab the container's root device if one is specified
 	storagePool := ""
 	storagePoolProfile := ""
@@ -210,7 +228,7 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 	// If we don't have a valid pool yet, look through profiles
 	if storagePool == "" {
 		for _, pName := range req.Profiles {
-			_, p, err := dbProfileGet(d.db, pName)
+			_, p, err := dbProfileGet(d.db, profileProject, pName)
 			if err != nil {
 				return InternalError(err)
 			}
@@ -284,7 +302,7 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 	 * point and just negotiate it over the migration control
 	 * socket. Anyway, it'll happen later :)
 	 */
-	_, _, err = dbImageGet(d.db, req.Source.BaseImage, false, true)
+	_, _, err = dbImageGet(d.db, imageProject, req.Source.BaseImage, false, true)
 	if err != nil {
 		c, err = containerCreateAsEmpty(d, args)
 		if err != nil {
@@ -407,12 +425,12 @@ func createFromMigration(d *Daemon, req *api.ContainersPost) Response {
 	return OperationResponse(op)
 }
 
-func createFromCopy(d *Daemon, req *api.ContainersPost) Response {
+func createFromCopy(d *Daemon, project string, req *api.ContainersPost) Response {
 	if req.Source.Source == "" {
 		return BadRequest(fmt.Errorf("must specify a source container"))
 	}
 
-	source, err := containerLoadByName(d, req.Source.Source)
+	source, err := containerLoadByName(d, projectPrefix(project, req.Source.Source))
 	if err != nil {
 		return SmartError(err)
 	}
@@ -467,7 +485,7 @@ func createFromCopy(d *Daemon, req *api.ContainersPost) Response {
 		Ctype:        cTypeRegular,
 		Devices:      req.Devices,
 		Ephemeral:    req.Ephemeral,
-		Name:         req.Name,
+		Name:         projectPrefix(project, req.Name),
 		Profiles:     req.Profiles,
 	}
 
@@ -490,9 +508,50 @@ func createFromCopy(d *Daemon, req *api.ContainersPost) Response {
 	return OperationResponse(op)
 }
 
+func createFromBackup(d *Daemon, project string, data io.Reader) Response {
+	// Write the data to a temporary file
+	f, err := ioutil.TempFile(shared.VarPath("backups"), "lxd_backup_")
+	if err != nil {
+		return InternalError(err)
+	}
+
+	_, err = io.Copy(f, data)
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return InternalError(err)
+	}
+
+	run := func(op *operation) error {
+		defer os.Remove(f.Name())
+
+		_, err := containerCreateFromBackup(d, project, f.Name())
+		return err
+	}
+
+	op, err := operationCreate(operationClassTask, nil, nil, run, nil, nil)
+	if err != nil {
+		os.Remove(f.Name())
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
 func containersPost(d *Daemon, r *http.Request) Response {
 	shared.LogDebugf("Responding to container create")
 
+	project := projectParam(r)
+	_, _, err := dbProjectGet(d.db, project)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// If we're getting binary content, process separately
+	if r.Header.Get("Content-Type") == "application/octet-stream" {
+		return createFromBackup(d, project, r.Body)
+	}
+
 	req := api.ContainersPost{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return BadRequest(err)
@@ -514,7 +573,7 @@ func containersPost(d *Daemon, r *http.Request) Response {
 		for {
 			i++
 			req.Name = strings.ToLower(petname.Generate(2, "-"))
-			if !shared.StringInSlice(req.Name, cs) {
+			if !shared.StringInSlice(projectPrefix(project, req.Name), cs) {
 				break
 			}
 
@@ -539,13 +598,13 @@ func containersPost(d *Daemon, r *http.Request) Response {
 
 	switch req.Source.Type {
 	case "image":
-		return createFromImage(d, &req)
+		return createFromImage(d, project, &req)
 	case "none":
-		return createFromNone(d, &req)
+		return createFromNone(d, project, &req)
 	case "migration":
-		return createFromMigration(d, &req)
+		return createFromMigration(d, project, &req)
 	case "copy":
-		return createFromCopy(d, &req)
+		return createFromCopy(d, project, &req)
 	default:
 		return BadRequest(fmt.Errorf("unknown source type %s", req.Source.Type))
 	}
@@ -68,6 +68,8 @@ type Daemon struct {
 	architectures       []int
 	BackingFs           string
 	clientCerts         []x509.Certificate
+	clientCertsInfo     map[string]*dbCertInfo
+	clusterCerts        []x509.Certificate
 	db                  *sql.DB
 	group               string
 	IdmapSet            *shared.IdmapSet
@@ -78,6 +80,7 @@ type Daemon struct {
 	pruneChan           chan bool
 	shutdownChan        chan bool
 	resetAutoUpdateChan chan bool
+	clusterJournalChan  chan bool
 
 	TCPSocket  *Socket
 	UnixSocket *Socket
@@ -90,6 +93,8 @@ type Daemon struct {
 	tlsConfig *tls.Config
 
 	proxy func(req *http.Request) (*url.URL, error)
+
+	firewall firewall
 }
 
 // Command is the basic structure for every API call.
@@ -208,10 +213,25 @@ func (d *Daemon) createCmd(version string, c Command) {
 	d.mux.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
+		// Only cluster members may mark their requests as forwarded
+		if !clusterIsForwarded(d, r) {
+			r.Header.Del(clusterForwardedHeader)
+			r.Header.Del(clusterForwardedCertificateHeader)
+		}
+
 		if d.isTrustedClient(r) {
 			shared.LogDebug(
 				"handling",
 				log.Ctx{"method": r.Method, "url": r.URL.RequestURI(), "ip": r.RemoteAddr})
+
+			err := certificateAccessCheck(d, c, r)
+			if err != nil {
+				shared.LogWarn(
+					"rejecting request from restricted client",
+					log.Ctx{"method": r.Method, "url": r.URL.RequestURI(), "ip": r.RemoteAddr, "err": err})
+				Forbidden.Render(w)
+				return
+			}
 		} else if r.Method == "GET" && c.untrustedGet {
 			shared.LogDebug(
 				"allowing untrusted GET",
@@ -228,6 +248,8 @@ func (d *Daemon) createCmd(version string, c Command) {
 			return
 		}
 
+		start := time.Now()
+
 		if debug && r.Method != "GET" && isJSONRequest(r) {
 			newBody := &bytes.Buffer{}
 			captured := &bytes.Buffer{}
@@ -241,6 +263,30 @@ func (d *Daemon) createCmd(version string, c Command) {
 			shared.DebugJson(captured)
 		}
 
+		if version == "1.0" {
+			// Containers and operations living on another cluster
+			// member are handled by that member
+			resp := clusterForwardedResponse(d, c, r)
+			if resp != nil {
+				if err := resp.Render(w); err != nil {
+					InternalError(err).Render(w)
+				}
+				return
+			}
+		}
+
+		// Changes to cluster-wide objects get replicated to the other
+		// cluster members once applied locally
+		var replicate []byte
+		if version == "1.0" {
+			var err error
+			replicate, err = clusterReplicationBody(d, c, r)
+			if err != nil {
+				InternalError(err).Render(w)
+				return
+			}
+		}
+
 		var resp Response
 		resp = NotImplemented
 
@@ -274,8 +320,12 @@ func (d *Daemon) createCmd(version string, c Command) {
 			if err != nil {
 				shared.LogErrorf("Failed writing error for error, giving up")
 			}
+		} else if replicate != nil {
+			clusterReplicate(d, r, resp, replicate)
 		}
 
+		metricsObserveRequest(r.Method, time.Since(start))
+
 		/*
 		 * When we create a new lxc.Container, it adds a finalizer (via
 		 * SetFinalizer) that frees the struct. However, it sometimes
@@ -513,6 +563,7 @@ func (d *Daemon) Init() error {
 	/* Initialize some variables */
 	d.readyChan = make(chan bool)
 	d.shutdownChan = make(chan bool)
+	d.clusterJournalChan = make(chan bool, 1)
 
 	/* Set the executable path */
 	/* Set the LVM environment */
@@ -709,6 +760,9 @@ func (d *Daemon) Init() error {
 	if err := os.MkdirAll(shared.CachePath(), 0700); err != nil {
 		return err
 	}
+	if err := os.MkdirAll(shared.VarPath("backups"), 0700); err != nil {
+		return err
+	}
 	if err := os.MkdirAll(shared.VarPath("containers"), 0711); err != nil {
 		return err
 	}
@@ -803,6 +857,13 @@ func (d *Daemon) Init() error {
 		return err
 	}
 
+	/* Pick the firewall backend */
+	d.firewall, err = firewallLoad(daemonConfig["core.firewall"].Get())
+	if err != nil {
+		return err
+	}
+	shared.LogInfo("Firewall loaded", log.Ctx{"backend": d.firewall.String()})
+
 	if !d.MockMode {
 		/* Read the storage pools */
 		err = d.SetupStorageDriver(false)
@@ -920,6 +981,7 @@ func (d *Daemon) Init() error {
 		d.tlsConfig = tlsConfig
 
 		readSavedClientCAList(d)
+		readSavedClusterCertList(d)
 	}
 
 	/* Setup the web server */
@@ -939,6 +1001,19 @@ func (d *Daemon) Init() error {
 		d.createCmd("internal", c)
 	}
 
+	// Read-only simplestreams rendering of the public images
+	d.mux.HandleFunc("/"+imageStreamsIndexPath, func(w http.ResponseWriter, r *http.Request) {
+		imageStreamsIndexGet(d, w, r)
+	})
+
+	d.mux.HandleFunc("/"+imageStreamsManifestPath, func(w http.ResponseWriter, r *http.Request) {
+		imageStreamsManifestGet(d, w, r)
+	})
+
+	d.mux.HandleFunc("/streams/v1/images/{fingerprint}/{file}", func(w http.ResponseWriter, r *http.Request) {
+		imageStreamsFileGet(d, w, r)
+	})
+
 	d.mux.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		shared.LogInfo("Sending top level 404", log.Ctx{"url": r.URL})
 		w.Header().Set("Content-Type", "application/json")
@@ -1073,6 +1148,51 @@ func (d *Daemon) Ready() error {
 		}
 	}()
 
+	/* Prune expired container backups */
+	go func() {
+		for {
+			pruneExpiredContainerBackups(d)
+			time.Sleep(time.Hour)
+		}
+	}()
+
+	/* Take scheduled snapshots and prune expired ones */
+	go func() {
+		for {
+			// Wake up at the start of every minute
+			now := time.Now()
+			next := now.Truncate(time.Minute).Add(time.Minute)
+			time.Sleep(next.Sub(now))
+
+			autoCreateContainerSnapshots(d, next)
+			pruneExpiredContainerSnapshots(d)
+		}
+	}()
+
+	/* Follow the IPv6 prefixes delegated to the host */
+	go func() {
+		for {
+			time.Sleep(time.Minute)
+			networkUpdateDelegated(d)
+		}
+	}()
+
+	/* Send the changes to cluster-wide objects to the other members */
+	go func() {
+		clusterContainersPublish(d)
+		clusterJournalRejoin(d)
+		for {
+			clusterJournalPush(d)
+
+			timer := time.NewTimer(time.Minute)
+			select {
+			case <-timer.C:
+			case <-d.clusterJournalChan:
+				timer.Stop()
+			}
+		}
+	}()
+
 	/* Auto-update images */
 	d.resetAutoUpdateChan = make(chan bool)
 	go func() {
@@ -1124,6 +1244,13 @@ func (d *Daemon) CheckTrustState(cert x509.Certificate) bool {
 		}
 	}
 
+	for k, v := range d.clusterCerts {
+		if bytes.Compare(cert.Raw, v.Raw) == 0 {
+			shared.LogDebug("Found cluster member cert", log.Ctx{"k": k})
+			return true
+		}
+	}
+
 	return false
 }
 
@@ -15,6 +15,7 @@ import (
 	log "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/simplestreams"
 )
 
 var daemonConfigLock sync.Mutex
@@ -173,6 +174,9 @@ func (k *daemonConfigKey) GetInt64() int64 {
 func daemonConfigInit(db *sql.DB) error {
 	// Set all the keys
 	daemonConfig = map[string]*daemonConfigKey{
+		"backups.compression_algorithm": {valueType: "string", validator: daemonConfigValidateCompression, defaultValue: "gzip"},
+
+		"core.firewall":                  {valueType: "string", defaultValue: "auto", validValues: []string{"auto", "nftables", "xtables"}, setter: daemonConfigSetFirewall},
 		"core.https_address":             {valueType: "string", setter: daemonConfigSetAddress},
 		"core.https_allowed_headers":     {valueType: "string"},
 		"core.https_allowed_methods":     {valueType: "string"},
@@ -187,6 +191,8 @@ func daemonConfigInit(db *sql.DB) error {
 		"images.auto_update_interval":  {valueType: "int", defaultValue: "6"},
 		"images.compression_algorithm": {valueType: "string", validator: daemonConfigValidateCompression, defaultValue: "gzip"},
 		"images.remote_cache_expiry":   {valueType: "int", defaultValue: "10", trigger: daemonConfigTriggerExpiry},
+		"images.require_signature":     {valueType: "bool", defaultValue: "false"},
+		"images.trusted_keys":          {valueType: "string", validator: daemonConfigValidateKeys},
 
 		// Keys deprecated since the implementation of the storage api.
 		"storage.lvm_fstype":           {valueType: "string", defaultValue: "ext4", validValues: []string{"ext4", "xfs"}, validator: storageDeprecatedKeys},
@@ -271,6 +277,25 @@ func daemonConfigSetAddress(d *Daemon, key string, value string) (string, error)
 	return value, nil
 }
 
+func daemonConfigSetFirewall(d *Daemon, key string, value string) (string, error) {
+	fw, err := firewallLoad(value)
+	if err != nil {
+		return "", err
+	}
+
+	// Move the existing rules over to the new backend
+	if d.firewall != nil && fw.String() != d.firewall.String() {
+		err = firewallSwitch(d, fw)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	d.firewall = fw
+
+	return value, nil
+}
+
 func daemonConfigSetProxy(d *Daemon, key string, value string) (string, error) {
 	// Get the current config
 	config := map[string]string{}
@@ -308,10 +333,23 @@ func daemonConfigValidateCompression(d *Daemon, key string, value string) error
 		return nil
 	}
 
-	_, err := exec.LookPath(value)
+	algorithm, _, err := compressionParse(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = exec.LookPath(algorithm)
 	return err
 }
 
+func daemonConfigValidateKeys(d *Daemon, key string, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	return (&simplestreams.SimpleStreams{}).SetKeys(value)
+}
+
 func storageDeprecatedKeys(d *Daemon, key string, value string) error {
 	if value == "" || daemonConfig[key].defaultValue == value {
 		return nil
@@ -28,6 +28,7 @@ type imageStreamCacheEntry struct {
 	Aliases      []api.ImageAliasesEntry `yaml:"aliases"`
 	Certificate  string                  `yaml:"certificate"`
 	Fingerprints []string                `yaml:"fingerprints"`
+	SigningKeys  string                  `yaml:"signing_keys,omitempty"`
 
 	expiry time.Time
 	remote lxd.ImageServer
@@ -77,6 +78,7 @@ func imageLoadStreamCache(d *Daemon) error {
 				TLSServerCert: entry.Certificate,
 				UserAgent:     version.UserAgent,
 				Proxy:         d.proxy,
+				SigningKeys:   entry.SigningKeys,
 			})
 			if err != nil {
 				continue
@@ -90,13 +92,17 @@ func imageLoadStreamCache(d *Daemon) error {
 }
 
 // ImageDownload resolves the image fingerprint and if not in the database, downloads it
-func (d *Daemon) ImageDownload(op *operation, server string, protocol string, certificate string, secret string, alias string, forContainer bool, autoUpdate bool, storagePool string) (*api.Image, error) {
+func (d *Daemon) ImageDownload(op *operation, project string, server string, protocol string, certificate string, signingKeys string, secret string, alias string, forContainer bool, autoUpdate bool, storagePool string) (*api.Image, error) {
 	var err error
 	var ctxMap log.Ctx
 
 	var remote lxd.ImageServer
 	var info *api.Image
 
+	// Bytes actually fetched from the server, less than the image size
+	// when its rootfs got rebuilt from a delta
+	var transferred int64
+
 	// Default protocol is LXD
 	if protocol == "" {
 		protocol = "lxd"
@@ -105,11 +111,21 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 	// Default the fingerprint to the alias string we received
 	fp := alias
 
+	// When signatures are required, only the keys trusted by the server
+	// count, not the ones sent along with the request
+	sourceKeys := signingKeys
+	if protocol == "simplestreams" && daemonConfig["images.require_signature"].GetBool() {
+		signingKeys = daemonConfig["images.trusted_keys"].Get()
+		if signingKeys == "" {
+			return nil, fmt.Errorf("images.trusted_keys must be set when requiring image signatures")
+		}
+	}
+
 	// Attempt to resolve the alias
 	if protocol == "simplestreams" {
 		imageStreamCacheLock.Lock()
 		entry, _ := imageStreamCache[server]
-		if entry == nil || entry.expiry.Before(time.Now()) {
+		if entry == nil || entry.expiry.Before(time.Now()) || entry.SigningKeys != signingKeys {
 			// Add a new entry to the cache
 			refresh := func() (*imageStreamCacheEntry, error) {
 				// Setup simplestreams client
@@ -117,6 +133,7 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 					TLSServerCert: certificate,
 					UserAgent:     version.UserAgent,
 					Proxy:         d.proxy,
+					SigningKeys:   signingKeys,
 				})
 				if err != nil {
 					return nil, err
@@ -140,7 +157,7 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 				}
 
 				// Generate cache entry
-				entry = &imageStreamCacheEntry{remote: remote, Aliases: aliases, Certificate: certificate, Fingerprints: fingerprints, expiry: time.Now().Add(time.Hour)}
+				entry = &imageStreamCacheEntry{remote: remote, Aliases: aliases, Certificate: certificate, Fingerprints: fingerprints, SigningKeys: signingKeys, expiry: time.Now().Add(time.Hour)}
 				imageStreamCache[server] = entry
 				imageSaveStreamCache()
 
@@ -151,7 +168,7 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 			if err == nil {
 				// Cache refreshed
 				entry = newEntry
-			} else if entry != nil {
+			} else if entry != nil && entry.SigningKeys == signingKeys {
 				// Failed to fetch entry but existing cache
 				shared.LogWarn("Unable to refresh cache, using stale entry", log.Ctx{"server": server})
 				entry.expiry = time.Now().Add(time.Hour)
@@ -192,11 +209,35 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 		}
 	} else if protocol == "lxd" {
 		// Setup LXD client
-		remote, err = lxd.ConnectPublicLXD(server, &lxd.ConnectionArgs{
+		args := &lxd.ConnectionArgs{
 			TLSServerCert: certificate,
 			UserAgent:     version.UserAgent,
 			Proxy:         d.proxy,
-		})
+		}
+
+		// Cluster members trust each other, allowing private images
+		// to be replicated too
+		if clusterIsMemberURL(d, server) {
+			certf, keyf, err := readMyCert()
+			if err != nil {
+				return nil, err
+			}
+
+			cert, err := ioutil.ReadFile(certf)
+			if err != nil {
+				return nil, err
+			}
+
+			key, err := ioutil.ReadFile(keyf)
+			if err != nil {
+				return nil, err
+			}
+
+			args.TLSClientCert = string(cert)
+			args.TLSClientKey = string(key)
+		}
+
+		remote, err = lxd.ConnectPublicLXD(server, args)
 		if err != nil {
 			return nil, err
 		}
@@ -220,7 +261,7 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 	}
 
 	// Check if the image already exists (partial hash match)
-	_, imgInfo, err := dbImageGet(d.db, fp, false, true)
+	_, imgInfo, err := dbImageGet(d.db, project, fp, false, true)
 	if err == nil {
 		shared.LogDebug("Image already exists in the db", log.Ctx{"image": fp})
 		info = imgInfo
@@ -274,7 +315,7 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 		<-waitChannel
 
 		// Grab the database entry
-		_, imgInfo, err := dbImageGet(d.db, fp, false, true)
+		_, imgInfo, err := dbImageGet(d.db, project, fp, false, true)
 		if err != nil {
 			// Other download failed, lets try again
 			shared.LogError("Other image download didn't succeed", log.Ctx{"image": fp})
@@ -310,9 +351,15 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 	destDir := shared.VarPath("images")
 	destName := filepath.Join(destDir, fp)
 
+	// Files shared with other projects must be kept on failure
+	otherProjects, err := dbImageGetProjects(d.db, fp)
+	if err != nil {
+		return nil, err
+	}
+
 	failure := true
 	cleanup := func() {
-		if failure {
+		if failure && len(otherProjects) == 0 {
 			os.Remove(destName)
 			os.Remove(destName + ".rootfs")
 		}
@@ -362,12 +409,32 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 			}
 		}
 
+		// Only cluster members are trusted with the signature status of
+		// the images they serve
+		if protocol == "lxd" && !clusterIsMemberURL(d, server) {
+			info.Signature = api.ImageSignature{Status: "unverified"}
+		}
+
+		if daemonConfig["images.require_signature"].GetBool() && info.Signature.Status != "verified" {
+			return nil, fmt.Errorf("Image %s isn't signed by a trusted key", fp)
+		}
+
 		// Download the image
 		var resp *lxd.ImageFileResponse
 		request := lxd.ImageFileRequest{
 			MetaFile:        io.WriteSeeker(dest),
 			RootfsFile:      io.WriteSeeker(destRootfs),
 			ProgressHandler: progress,
+
+			// Cached images can be the base of rootfs deltas
+			DeltaSourceRetriever: func(fingerprint string, file string) string {
+				path := shared.VarPath("images", fmt.Sprintf("%s.%s", fingerprint, file))
+				if shared.PathExists(path) {
+					return path
+				}
+
+				return ""
+			},
 		}
 
 		if secret != "" {
@@ -379,6 +446,11 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 			return nil, err
 		}
 
+		transferred = resp.MetaSize + resp.RootfsSize
+		if resp.RootfsDeltaSize > 0 {
+			transferred = resp.MetaSize + resp.RootfsDeltaSize
+		}
+
 		// Deal with unified images
 		if resp.RootfsSize == 0 {
 			err := os.Remove(destName + ".rootfs")
@@ -386,7 +458,29 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 				return nil, err
 			}
 		}
+
+		// Verify the image as a whole, its rootfs may have been
+		// rebuilt from a delta
+		if protocol == "simplestreams" {
+			paths := []string{destName}
+			if resp.RootfsSize > 0 {
+				paths = append(paths, destName+".rootfs")
+			}
+
+			hash, err := imageHashFiles(paths...)
+			if err != nil {
+				return nil, err
+			}
+
+			if hash != info.Fingerprint {
+				return nil, fmt.Errorf("Image fingerprint mismatch: %s != %s", hash, info.Fingerprint)
+			}
+		}
 	} else if protocol == "direct" {
+		if daemonConfig["images.require_signature"].GetBool() {
+			return nil, fmt.Errorf("Images downloaded from a URL can't be verified")
+		}
+
 		// Setup HTTP client
 		httpClient, err := d.httpClient(certificate)
 		if err != nil {
@@ -449,32 +543,45 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 			return nil, err
 		}
 
+		info = &api.Image{}
+		info.Fingerprint = fp
 		info.Size = size
 		info.Architecture = imageMeta.Architecture
 		info.CreatedAt = time.Unix(imageMeta.CreationDate, 0)
 		info.ExpiresAt = time.Unix(imageMeta.ExpiryDate, 0)
 		info.Properties = imageMeta.Properties
+
+		transferred = size
 	}
 
+	metricsAddImageDownload(transferred)
+
 	// Override visiblity
 	info.Public = false
 
 	// Create the database entry
-	err = dbImageInsert(d.db, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
+	err = dbImageInsert(d.db, project, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
 	if err != nil {
 		return nil, fmt.Errorf("here: %v: %s", err, info.Fingerprint)
 	}
 
+	imageStreamsImport(info.Fingerprint)
+
+	err = dbImageSignatureSet(d.db, info.Fingerprint, info.Signature)
+	if err != nil {
+		return nil, err
+	}
+
 	// Image is in the DB now, don't wipe on-disk files on failure
 	failure = false
 
 	if alias != fp {
-		id, _, err := dbImageGet(d.db, fp, false, true)
+		id, _, err := dbImageGet(d.db, project, fp, false, true)
 		if err != nil {
 			return nil, err
 		}
 
-		err = dbImageSourceInsert(d.db, id, server, protocol, "", alias)
+		err = dbImageSourceInsert(d.db, id, server, protocol, "", sourceKeys, alias)
 		if err != nil {
 			return nil, err
 		}
@@ -482,12 +589,25 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 		info.AutoUpdate = autoUpdate
 	}
 
-	// Import into the requested storage pool
+	// Import into the requested storage pool, unless another project
+	// already did
 	if storagePool != "" {
-		err = imageCreateInPool(d, info, storagePool)
+		poolID, err := dbStoragePoolGetID(d.db, storagePool)
+		if err != nil {
+			return nil, err
+		}
+
+		poolIDs, err := dbImageGetPools(d.db, info.Fingerprint)
 		if err != nil {
 			return nil, err
 		}
+
+		if !shared.Int64InSlice(poolID, poolIDs) {
+			err = imageCreateInPool(d, info, storagePool)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Mark the image as "cached" if downloading for a container
@@ -501,3 +621,23 @@ func (d *Daemon) ImageDownload(op *operation, server string, protocol string, ce
 	shared.LogInfo("Image downloaded", ctxMap)
 	return info, nil
 }
+
+// imageHashFiles returns the fingerprint of an image made of the given files.
+func imageHashFiles(paths ...string) (string, error) {
+	sha256 := sha256.New()
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = io.Copy(sha256, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum(nil)), nil
+}
@@ -33,8 +33,32 @@ CREATE TABLE IF NOT EXISTS certificates (
     type INTEGER NOT NULL,
     name VARCHAR(255) NOT NULL,
     certificate TEXT NOT NULL,
+    restricted INTEGER NOT NULL DEFAULT 0,
+    permission VARCHAR(255) NOT NULL DEFAULT 'admin',
     UNIQUE (fingerprint)
 );
+CREATE TABLE IF NOT EXISTS certificates_containers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    certificate_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    UNIQUE (certificate_id, name),
+    FOREIGN KEY (certificate_id) REFERENCES certificates (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS certificates_projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    certificate_id INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    UNIQUE (certificate_id, project_id),
+    FOREIGN KEY (certificate_id) REFERENCES certificates (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS cluster_journal (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    method VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
 CREATE TABLE IF NOT EXISTS config (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     key VARCHAR(255) NOT NULL,
@@ -50,8 +74,19 @@ CREATE TABLE IF NOT EXISTS containers (
     stateful INTEGER NOT NULL DEFAULT 0,
     creation_date DATETIME,
     last_use_date DATETIME,
+    expiry_date DATETIME,
     UNIQUE (name)
 );
+CREATE TABLE IF NOT EXISTS containers_backups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    container_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    creation_date DATETIME,
+    expiry_date DATETIME,
+    container_only INTEGER NOT NULL default 0,
+    FOREIGN KEY (container_id) REFERENCES containers (id) ON DELETE CASCADE,
+    UNIQUE (container_id, name)
+);
 CREATE TABLE IF NOT EXISTS containers_config (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     container_id INTEGER NOT NULL,
@@ -87,6 +122,7 @@ CREATE TABLE IF NOT EXISTS containers_profiles (
 );
 CREATE TABLE IF NOT EXISTS images (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL DEFAULT 1,
     cached INTEGER NOT NULL DEFAULT 0,
     fingerprint VARCHAR(255) NOT NULL,
     filename VARCHAR(255) NOT NULL,
@@ -98,15 +134,20 @@ CREATE TABLE IF NOT EXISTS images (
     expiry_date DATETIME,
     upload_date DATETIME NOT NULL,
     last_use_date DATETIME,
-    UNIQUE (fingerprint)
+    signature_type VARCHAR(255) NOT NULL DEFAULT '',
+    signature_key VARCHAR(255) NOT NULL DEFAULT '',
+    UNIQUE (project_id, fingerprint),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
 );
 CREATE TABLE IF NOT EXISTS images_aliases (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL DEFAULT 1,
     name VARCHAR(255) NOT NULL,
     image_id INTEGER NOT NULL,
     description VARCHAR(255),
     FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE,
-    UNIQUE (name)
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    UNIQUE (project_id, name)
 );
 CREATE TABLE IF NOT EXISTS images_properties (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
@@ -123,6 +164,7 @@ CREATE TABLE IF NOT EXISTS images_source (
     protocol INTEGER NOT NULL,
     certificate TEXT NOT NULL,
     alias VARCHAR(255) NOT NULL,
+    signing_keys TEXT NOT NULL DEFAULT '',
     FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE
 );
 CREATE TABLE IF NOT EXISTS networks (
@@ -130,6 +172,27 @@ CREATE TABLE IF NOT EXISTS networks (
     name VARCHAR(255) NOT NULL,
     UNIQUE (name)
 );
+CREATE TABLE IF NOT EXISTS networks_acls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    UNIQUE (name)
+);
+CREATE TABLE IF NOT EXISTS networks_acls_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_acl_id INTEGER NOT NULL,
+    direction VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    action VARCHAR(255) NOT NULL,
+    source TEXT,
+    destination TEXT,
+    protocol VARCHAR(255),
+    source_port TEXT,
+    destination_port TEXT,
+    description TEXT,
+    UNIQUE (network_acl_id, direction, position),
+    FOREIGN KEY (network_acl_id) REFERENCES networks_acls (id) ON DELETE CASCADE
+);
 CREATE TABLE IF NOT EXISTS networks_config (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     network_id INTEGER NOT NULL,
@@ -138,6 +201,64 @@ CREATE TABLE IF NOT EXISTS networks_config (
     UNIQUE (network_id, key),
     FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
 );
+CREATE TABLE IF NOT EXISTS networks_dns_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    addresses TEXT NOT NULL,
+    UNIQUE (network_id, name),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS networks_forwards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    listen_address VARCHAR(255) NOT NULL,
+    description TEXT,
+    target_address VARCHAR(255),
+    UNIQUE (listen_address),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS networks_forwards_ports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_forward_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    protocol VARCHAR(255) NOT NULL,
+    listen_port TEXT NOT NULL,
+    target_port TEXT,
+    target_address VARCHAR(255) NOT NULL,
+    description TEXT,
+    UNIQUE (network_forward_id, position),
+    FOREIGN KEY (network_forward_id) REFERENCES networks_forwards (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS networks_reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    hwaddr VARCHAR(255) NOT NULL,
+    description TEXT,
+    ipv4_address VARCHAR(255),
+    ipv6_address VARCHAR(255),
+    hostname VARCHAR(255),
+    UNIQUE (network_id, hwaddr),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS nodes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    address VARCHAR(255) NOT NULL,
+    certificate TEXT NOT NULL,
+    journal_sent INTEGER NOT NULL DEFAULT 0,
+    journal_received INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (name),
+    UNIQUE (address)
+);
+CREATE TABLE IF NOT EXISTS nodes_containers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    node_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    UNIQUE (node_id, name),
+    FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE
+);
 CREATE TABLE IF NOT EXISTS patches (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     name VARCHAR(255) NOT NULL,
@@ -146,9 +267,11 @@ CREATE TABLE IF NOT EXISTS patches (
 );
 CREATE TABLE IF NOT EXISTS profiles (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL DEFAULT 1,
     name VARCHAR(255) NOT NULL,
     description TEXT,
-    UNIQUE (name)
+    UNIQUE (project_id, name),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
 );
 CREATE TABLE IF NOT EXISTS profiles_config (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
@@ -174,6 +297,20 @@ CREATE TABLE IF NOT EXISTS profiles_devices_config (
     UNIQUE (profile_device_id, key),
     FOREIGN KEY (profile_device_id) REFERENCES profiles_devices (id) ON DELETE CASCADE
 );
+CREATE TABLE IF NOT EXISTS projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    UNIQUE (name)
+);
+CREATE TABLE IF NOT EXISTS projects_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (project_id, key),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
 CREATE TABLE IF NOT EXISTS schema (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     version INTEGER NOT NULL,
@@ -245,7 +382,12 @@ func createDb(db *sql.DB) (err error) {
 		dbPatchesMarkApplied(db, p.name)
 	}
 
-	err = dbProfileCreateDefault(db)
+	err = dbProjectCreateDefault(db)
+	if err != nil {
+		return err
+	}
+
+	err = dbProfileCreateDefault(db, "default")
 	if err != nil {
 		return err
 	}
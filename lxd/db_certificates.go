@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -14,20 +15,22 @@ type dbCertInfo struct {
 	Type        int
 	Name        string
 	Certificate string
+	Restricted  bool
+	Permission  string
+	Containers  []string
+	Projects    []string
 }
 
 // dbCertsGet returns all certificates from the DB as CertBaseInfo objects.
 func dbCertsGet(db *sql.DB) (certs []*dbCertInfo, err error) {
 	rows, err := dbQuery(
 		db,
-		"SELECT id, fingerprint, type, name, certificate FROM certificates",
+		"SELECT id, fingerprint, type, name, certificate, restricted, permission FROM certificates",
 	)
 	if err != nil {
 		return certs, err
 	}
 
-	defer rows.Close()
-
 	for rows.Next() {
 		cert := new(dbCertInfo)
 		rows.Scan(
@@ -36,9 +39,19 @@ func dbCertsGet(db *sql.DB) (certs []*dbCertInfo, err error) {
 			&cert.Type,
 			&cert.Name,
 			&cert.Certificate,
+			&cert.Restricted,
+			&cert.Permission,
 		)
 		certs = append(certs, cert)
 	}
+	rows.Close()
+
+	for _, cert := range certs {
+		err = dbCertRestrictionsGet(db, cert)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return certs, nil
 }
@@ -58,11 +71,13 @@ func dbCertGet(db *sql.DB, fingerprint string) (cert *dbCertInfo, err error) {
 		&cert.Type,
 		&cert.Name,
 		&cert.Certificate,
+		&cert.Restricted,
+		&cert.Permission,
 	}
 
 	query := `
 		SELECT
-			id, fingerprint, type, name, certificate
+			id, fingerprint, type, name, certificate, restricted, permission
 		FROM
 			certificates
 		WHERE fingerprint LIKE ?`
@@ -71,9 +86,78 @@ func dbCertGet(db *sql.DB, fingerprint string) (cert *dbCertInfo, err error) {
 		return nil, err
 	}
 
+	err = dbCertRestrictionsGet(db, cert)
+	if err != nil {
+		return nil, err
+	}
+
 	return cert, err
 }
 
+// dbCertRestrictionsGet fills the containers and projects a certificate is
+// restricted to.
+func dbCertRestrictionsGet(db *sql.DB, cert *dbCertInfo) error {
+	var name string
+
+	cert.Containers = []string{}
+	query := "SELECT name FROM certificates_containers WHERE certificate_id=? ORDER BY name"
+	results, err := dbQueryScan(db, query, []interface{}{cert.ID}, []interface{}{name})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		cert.Containers = append(cert.Containers, r[0].(string))
+	}
+
+	cert.Projects = []string{}
+	query = `
+		SELECT projects.name FROM certificates_projects
+		JOIN projects ON certificates_projects.project_id = projects.id
+		WHERE certificate_id=? ORDER BY projects.name`
+	results, err = dbQueryScan(db, query, []interface{}{cert.ID}, []interface{}{name})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		cert.Projects = append(cert.Projects, r[0].(string))
+	}
+
+	return nil
+}
+
+// dbCertRestrictionsAdd records the containers and projects a certificate is
+// restricted to.
+func dbCertRestrictionsAdd(tx *sql.Tx, id int64, containers []string, projects []string) error {
+	for _, name := range containers {
+		_, err := tx.Exec("INSERT INTO certificates_containers (certificate_id, name) VALUES (?, ?)", id, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range projects {
+		result, err := tx.Exec(`
+			INSERT INTO certificates_projects (certificate_id, project_id)
+			SELECT ?, id FROM projects WHERE name=?`, id, name)
+		if err != nil {
+			return err
+		}
+
+		count, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if count != 1 {
+			return fmt.Errorf("Project '%s' doesn't exist", name)
+		}
+	}
+
+	return nil
+}
+
 // dbCertSave stores a CertBaseInfo object in the db,
 // it will ignore the ID field from the dbCertInfo.
 func dbCertSave(db *sql.DB, cert *dbCertInfo) error {
@@ -86,25 +170,41 @@ func dbCertSave(db *sql.DB, cert *dbCertInfo) error {
 				fingerprint,
 				type,
 				name,
-				certificate
-			) VALUES (?, ?, ?, ?)`,
+				certificate,
+				restricted,
+				permission
+			) VALUES (?, ?, ?, ?, ?, ?)`,
 	)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
-	_, err = stmt.Exec(
+	result, err := stmt.Exec(
 		cert.Fingerprint,
 		cert.Type,
 		cert.Name,
 		cert.Certificate,
+		cert.Restricted,
+		cert.Permission,
 	)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = dbCertRestrictionsAdd(tx, id, cert.Containers, cert.Projects)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	return txCommit(tx)
 }
 
@@ -118,13 +218,38 @@ func dbCertDelete(db *sql.DB, fingerprint string) error {
 	return nil
 }
 
-func dbCertUpdate(db *sql.DB, fingerprint string, certName string, certType int) error {
+func dbCertUpdate(db *sql.DB, fingerprint string, cert *dbCertInfo) error {
 	tx, err := dbBegin(db)
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec("UPDATE certificates SET name=?, type=? WHERE fingerprint=?", certName, certType, fingerprint)
+	_, err = tx.Exec("UPDATE certificates SET name=?, type=?, restricted=?, permission=? WHERE fingerprint=?", cert.Name, cert.Type, cert.Restricted, cert.Permission, fingerprint)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var id int64
+	err = tx.QueryRow("SELECT id FROM certificates WHERE fingerprint=?", fingerprint).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM certificates_containers WHERE certificate_id=?", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM certificates_projects WHERE certificate_id=?", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = dbCertRestrictionsAdd(tx, id, cert.Containers, cert.Projects)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -58,9 +58,10 @@ func dbContainerGet(db *sql.DB, name string) (containerArgs, error) {
 
 	ephemInt := -1
 	statefulInt := -1
-	q := "SELECT id, architecture, type, ephemeral, stateful, creation_date, last_use_date FROM containers WHERE name=?"
+	var expiry *time.Time
+	q := "SELECT id, architecture, type, ephemeral, stateful, creation_date, last_use_date, expiry_date FROM containers WHERE name=?"
 	arg1 := []interface{}{name}
-	arg2 := []interface{}{&args.Id, &args.Architecture, &args.Ctype, &ephemInt, &statefulInt, &args.CreationDate, &used}
+	arg2 := []interface{}{&args.Id, &args.Architecture, &args.Ctype, &ephemInt, &statefulInt, &args.CreationDate, &used, &expiry}
 	err := dbQueryRowScan(db, q, arg1, arg2)
 	if err != nil {
 		return args, err
@@ -84,6 +85,10 @@ func dbContainerGet(db *sql.DB, name string) (containerArgs, error) {
 		args.LastUsedDate = time.Unix(0, 0).UTC()
 	}
 
+	if expiry != nil {
+		args.ExpiryDate = *expiry
+	}
+
 	config, err := dbContainerConfig(db, args.Id)
 	if err != nil {
 		return args, err
@@ -98,7 +103,7 @@ func dbContainerGet(db *sql.DB, name string) (containerArgs, error) {
 
 	/* get container_devices */
 	args.Devices = types.Devices{}
-	newdevs, err := dbDevices(db, name, false)
+	newdevs, err := dbDevices(db, "", name, false)
 	if err != nil {
 		return args, err
 	}
@@ -116,6 +121,12 @@ func dbContainerCreate(db *sql.DB, args containerArgs) (int, error) {
 		return 0, DbErrAlreadyDefined
 	}
 
+	project, _ := projectSplitName(args.Name)
+	profileProject, err := dbProjectEffective(db, project, "profiles")
+	if err != nil {
+		return 0, err
+	}
+
 	tx, err := dbBegin(db)
 	if err != nil {
 		return 0, err
@@ -134,14 +145,19 @@ func dbContainerCreate(db *sql.DB, args containerArgs) (int, error) {
 	args.CreationDate = time.Now().UTC()
 	args.LastUsedDate = time.Unix(0, 0).UTC()
 
-	str := fmt.Sprintf("INSERT INTO containers (name, architecture, type, ephemeral, creation_date, last_use_date, stateful) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	var expiry interface{}
+	if !args.ExpiryDate.IsZero() {
+		expiry = args.ExpiryDate.Unix()
+	}
+
+	str := fmt.Sprintf("INSERT INTO containers (name, architecture, type, ephemeral, creation_date, last_use_date, stateful, expiry_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
 	stmt, err := tx.Prepare(str)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 	defer stmt.Close()
-	result, err := stmt.Exec(args.Name, args.Architecture, args.Ctype, ephemInt, args.CreationDate.Unix(), args.LastUsedDate.Unix(), statefulInt)
+	result, err := stmt.Exec(args.Name, args.Architecture, args.Ctype, ephemInt, args.CreationDate.Unix(), args.LastUsedDate.Unix(), statefulInt, expiry)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
@@ -159,7 +175,7 @@ func dbContainerCreate(db *sql.DB, args containerArgs) (int, error) {
 		return 0, err
 	}
 
-	if err := dbContainerProfilesInsert(tx, id, args.Profiles); err != nil {
+	if err := dbContainerProfilesInsert(tx, id, profileProject, args.Profiles); err != nil {
 		tx.Rollback()
 		return 0, err
 	}
@@ -237,17 +253,17 @@ func dbContainerSetStateful(db *sql.DB, id int, stateful bool) error {
 	return err
 }
 
-func dbContainerProfilesInsert(tx *sql.Tx, id int, profiles []string) error {
+func dbContainerProfilesInsert(tx *sql.Tx, id int, project string, profiles []string) error {
 	applyOrder := 1
 	str := `INSERT INTO containers_profiles (container_id, profile_id, apply_order) VALUES
-		(?, (SELECT id FROM profiles WHERE name=?), ?);`
+		(?, (SELECT id FROM profiles WHERE name=? AND project_id=(SELECT id FROM projects WHERE name=?)), ?);`
 	stmt, err := tx.Prepare(str)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 	for _, p := range profiles {
-		_, err = stmt.Exec(id, p, applyOrder)
+		_, err = stmt.Exec(id, p, project, applyOrder)
 		if err != nil {
 			shared.LogDebugf("Error adding profile %s to container: %s",
 				p, err)
@@ -286,6 +302,28 @@ func dbContainerProfiles(db *sql.DB, containerId int) ([]string, error) {
 	return profiles, nil
 }
 
+// dbContainersConfigValues returns the values of the config keys of all the
+// containers which match a LIKE pattern.
+func dbContainersConfigValues(db *sql.DB, pattern string) ([]string, error) {
+	var value string
+	q := `SELECT value FROM containers_config WHERE key LIKE ?`
+
+	inargs := []interface{}{pattern}
+	outfmt := []interface{}{value}
+
+	results, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	for _, r := range results {
+		values = append(values, r[0].(string))
+	}
+
+	return values, nil
+}
+
 // dbContainerConfig gets the container configuration map from the DB
 func dbContainerConfig(db *sql.DB, containerId int) (map[string]string, error) {
 	var key, value string
@@ -464,3 +502,125 @@ WHERE storage_volumes.name=? AND storage_volumes.type=?`
 
 	return poolName, nil
 }
+
+// Get the names of all the backups of a given container.
+func dbContainerGetBackups(db *sql.DB, containerID int) ([]string, error) {
+	var result []string
+
+	q := "SELECT name FROM containers_backups WHERE container_id=? ORDER BY creation_date"
+	inargs := []interface{}{containerID}
+	outfmt := []interface{}{""}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range dbResults {
+		result = append(result, r[0].(string))
+	}
+
+	return result, nil
+}
+
+// Get a backup of a given container.
+func dbContainerBackupGet(db *sql.DB, containerID int, name string) (backupArgs, error) {
+	args := backupArgs{}
+	args.ContainerID = containerID
+	args.Name = name
+
+	containerOnlyInt := -1
+	var expiry *time.Time
+	q := "SELECT id, creation_date, expiry_date, container_only FROM containers_backups WHERE container_id=? AND name=?"
+	arg1 := []interface{}{containerID, name}
+	arg2 := []interface{}{&args.ID, &args.CreationDate, &expiry, &containerOnlyInt}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return args, NoSuchObjectError
+		}
+
+		return args, err
+	}
+
+	if expiry != nil {
+		args.ExpiryDate = *expiry
+	}
+
+	if containerOnlyInt == 1 {
+		args.ContainerOnly = true
+	}
+
+	return args, nil
+}
+
+// Get the names of all backups which expired before the given date.
+func dbContainerBackupsGetExpired(db *sql.DB, date time.Time) (map[string][]string, error) {
+	result := map[string][]string{}
+
+	q := `SELECT containers.name, containers_backups.name FROM containers_backups
+JOIN containers ON containers.id=containers_backups.container_id
+WHERE containers_backups.expiry_date IS NOT NULL AND containers_backups.expiry_date > 0 AND containers_backups.expiry_date < ?`
+	inargs := []interface{}{date.Unix()}
+	outfmt := []interface{}{"", ""}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range dbResults {
+		cname := r[0].(string)
+		result[cname] = append(result[cname], r[1].(string))
+	}
+
+	return result, nil
+}
+
+// Get the names of all snapshots which expired before the given date.
+func dbContainerSnapshotsGetExpired(db *sql.DB, date time.Time) ([]string, error) {
+	result := []string{}
+
+	q := "SELECT name FROM containers WHERE type=? AND expiry_date IS NOT NULL AND expiry_date > 0 AND expiry_date < ?"
+	inargs := []interface{}{cTypeSnapshot, date.Unix()}
+	outfmt := []interface{}{""}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range dbResults {
+		result = append(result, r[0].(string))
+	}
+
+	return result, nil
+}
+
+func dbContainerBackupCreate(db *sql.DB, args backupArgs) error {
+	_, err := dbContainerBackupGet(db, args.ContainerID, args.Name)
+	if err == nil {
+		return DbErrAlreadyDefined
+	}
+
+	containerOnlyInt := 0
+	if args.ContainerOnly {
+		containerOnlyInt = 1
+	}
+
+	var expiry interface{}
+	if !args.ExpiryDate.IsZero() {
+		expiry = args.ExpiryDate.Unix()
+	}
+
+	str := "INSERT INTO containers_backups (container_id, name, creation_date, expiry_date, container_only) VALUES (?, ?, ?, ?, ?)"
+	_, err = dbExec(db, str, args.ContainerID, args.Name, args.CreationDate.Unix(), expiry, containerOnlyInt)
+	return err
+}
+
+func dbContainerBackupRemove(db *sql.DB, id int) error {
+	_, err := dbExec(db, "DELETE FROM containers_backups WHERE id=?", id)
+	return err
+}
+
+func dbContainerBackupRename(db *sql.DB, id int, newName string) error {
+	_, err := dbExec(db, "UPDATE containers_backups SET name=? WHERE id=?", newName, id)
+	return err
+}
@@ -17,14 +17,14 @@ var dbImageSourceProtocol = map[int]string{
 	2: "simplestreams",
 }
 
-func dbImagesGet(db *sql.DB, public bool) ([]string, error) {
-	q := "SELECT fingerprint FROM images"
+func dbImagesGet(db *sql.DB, project string, public bool) ([]string, error) {
+	q := "SELECT fingerprint FROM images WHERE project_id=(SELECT id FROM projects WHERE name=?)"
 	if public == true {
-		q = "SELECT fingerprint FROM images WHERE public=1"
+		q = "SELECT fingerprint FROM images WHERE project_id=(SELECT id FROM projects WHERE name=?) AND public=1"
 	}
 
 	var fp string
-	inargs := []interface{}{}
+	inargs := []interface{}{project}
 	outfmt := []interface{}{fp}
 	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
 	if err != nil {
@@ -39,8 +39,10 @@ func dbImagesGet(db *sql.DB, public bool) ([]string, error) {
 	return results, nil
 }
 
-func dbImagesGetExpired(db *sql.DB, expiry int64) ([]string, error) {
-	q := `SELECT fingerprint FROM images WHERE cached=1 AND creation_date<=strftime('%s', date('now', '-` + fmt.Sprintf("%d", expiry) + ` day'))`
+// dbImagesGetFingerprints returns the fingerprints of all the images, whatever
+// their project.
+func dbImagesGetFingerprints(db *sql.DB) ([]string, error) {
+	q := "SELECT DISTINCT fingerprint FROM images"
 
 	var fp string
 	inargs := []interface{}{}
@@ -58,8 +60,27 @@ func dbImagesGetExpired(db *sql.DB, expiry int64) ([]string, error) {
 	return results, nil
 }
 
-func dbImageSourceInsert(db *sql.DB, imageId int, server string, protocol string, certificate string, alias string) error {
-	stmt := `INSERT INTO images_source (image_id, server, protocol, certificate, alias) values (?, ?, ?, ?, ?)`
+func dbImagesGetExpired(db *sql.DB, project string, expiry int64) ([]string, error) {
+	q := `SELECT fingerprint FROM images WHERE project_id=(SELECT id FROM projects WHERE name=?) AND cached=1 AND creation_date<=strftime('%s', date('now', '-` + fmt.Sprintf("%d", expiry) + ` day'))`
+
+	var fp string
+	inargs := []interface{}{project}
+	outfmt := []interface{}{fp}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return []string{}, err
+	}
+
+	results := []string{}
+	for _, r := range dbResults {
+		results = append(results, r[0].(string))
+	}
+
+	return results, nil
+}
+
+func dbImageSourceInsert(db *sql.DB, imageId int, server string, protocol string, certificate string, signingKeys string, alias string) error {
+	stmt := `INSERT INTO images_source (image_id, server, protocol, certificate, signing_keys, alias) values (?, ?, ?, ?, ?, ?)`
 
 	protocolInt := -1
 	for protoInt, protoString := range dbImageSourceProtocol {
@@ -72,19 +93,19 @@ func dbImageSourceInsert(db *sql.DB, imageId int, server string, protocol string
 		return fmt.Errorf("Invalid protocol: %s", protocol)
 	}
 
-	_, err := dbExec(db, stmt, imageId, server, protocolInt, certificate, alias)
+	_, err := dbExec(db, stmt, imageId, server, protocolInt, certificate, signingKeys, alias)
 	return err
 }
 
 func dbImageSourceGet(db *sql.DB, imageId int) (int, api.ImageSource, error) {
-	q := `SELECT id, server, protocol, certificate, alias FROM images_source WHERE image_id=?`
+	q := `SELECT id, server, protocol, certificate, signing_keys, alias FROM images_source WHERE image_id=?`
 
 	id := 0
 	protocolInt := -1
 	result := api.ImageSource{}
 
 	arg1 := []interface{}{imageId}
-	arg2 := []interface{}{&id, &result.Server, &protocolInt, &result.Certificate, &result.Alias}
+	arg2 := []interface{}{&id, &result.Server, &protocolInt, &result.Certificate, &result.SigningKeys, &result.Alias}
 	err := dbQueryRowScan(db, q, arg1, arg2)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -108,9 +129,9 @@ func dbImageSourceGet(db *sql.DB, imageId int) (int, api.ImageSource, error) {
 // dbImageGet gets an ImageBaseInfo object from the database.
 // The argument fingerprint will be queried with a LIKE query, means you can
 // pass a shortform and will get the full fingerprint.
-// There can never be more than one image with a given fingerprint, as it is
-// enforced by a UNIQUE constraint in the schema.
-func dbImageGet(db *sql.DB, fingerprint string, public bool, strictMatching bool) (int, *api.Image, error) {
+// There can never be more than one image with a given fingerprint in a
+// project, as it is enforced by a UNIQUE constraint in the schema.
+func dbImageGet(db *sql.DB, project string, fingerprint string, public bool, strictMatching bool) (int, *api.Image, error) {
 	var err error
 	var create, expire, used, upload *time.Time // These hold the db-returned times
 
@@ -122,29 +143,29 @@ func dbImageGet(db *sql.DB, fingerprint string, public bool, strictMatching bool
 	// These two humongous things will be filled by the call to DbQueryRowScan
 	outfmt := []interface{}{&id, &image.Fingerprint, &image.Filename,
 		&image.Size, &image.Cached, &image.Public, &image.AutoUpdate, &arch,
-		&create, &expire, &used, &upload}
+		&create, &expire, &used, &upload, &image.Signature.Type, &image.Signature.Key}
 
 	var query string
 
 	var inargs []interface{}
 	if strictMatching {
-		inargs = []interface{}{fingerprint}
+		inargs = []interface{}{project, fingerprint}
 		query = `
         SELECT
             id, fingerprint, filename, size, cached, public, auto_update, architecture,
-            creation_date, expiry_date, last_use_date, upload_date
+            creation_date, expiry_date, last_use_date, upload_date, signature_type, signature_key
         FROM
             images
-        WHERE fingerprint = ?`
+        WHERE project_id = (SELECT id FROM projects WHERE name = ?) AND fingerprint = ?`
 	} else {
-		inargs = []interface{}{fingerprint + "%"}
+		inargs = []interface{}{project, fingerprint + "%"}
 		query = `
         SELECT
             id, fingerprint, filename, size, cached, public, auto_update, architecture,
-            creation_date, expiry_date, last_use_date, upload_date
+            creation_date, expiry_date, last_use_date, upload_date, signature_type, signature_key
         FROM
             images
-        WHERE fingerprint LIKE ?`
+        WHERE project_id = (SELECT id FROM projects WHERE name = ?) AND fingerprint LIKE ?`
 	}
 
 	if public {
@@ -158,7 +179,7 @@ func dbImageGet(db *sql.DB, fingerprint string, public bool, strictMatching bool
 
 	// Validate we only have a single match
 	if !strictMatching {
-		query = "SELECT COUNT(id) FROM images WHERE fingerprint LIKE ?"
+		query = "SELECT COUNT(id) FROM images WHERE project_id = (SELECT id FROM projects WHERE name = ?) AND fingerprint LIKE ?"
 		count := 0
 		outfmt := []interface{}{&count}
 
@@ -196,6 +217,11 @@ func dbImageGet(db *sql.DB, fingerprint string, public bool, strictMatching bool
 	// The upload date is enforced by NOT NULL in the schema, so it can never be nil.
 	image.UploadedAt = *upload
 
+	image.Signature.Status = "unverified"
+	if image.Signature.Type != "" {
+		image.Signature.Status = "verified"
+	}
+
 	// Get the properties
 	q := "SELECT key, value FROM images_properties where image_id=?"
 	var key, value, name, desc string
@@ -242,6 +268,29 @@ func dbImageGet(db *sql.DB, fingerprint string, public bool, strictMatching bool
 	return id, &image, nil
 }
 
+// dbImageGetProjects returns the projects which have an image with the given
+// fingerprint. They all share the same image files.
+func dbImageGetProjects(db *sql.DB, fingerprint string) ([]string, error) {
+	q := `SELECT projects.name FROM images
+		JOIN projects ON images.project_id=projects.id
+		WHERE images.fingerprint=?`
+
+	var name string
+	inargs := []interface{}{fingerprint}
+	outfmt := []interface{}{name}
+	dbResults, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return []string{}, err
+	}
+
+	results := []string{}
+	for _, r := range dbResults {
+		results = append(results, r[0].(string))
+	}
+
+	return results, nil
+}
+
 func dbImageDelete(db *sql.DB, id int) error {
 	_, err := dbExec(db, "DELETE FROM images WHERE id=?", id)
 	if err != nil {
@@ -251,12 +300,12 @@ func dbImageDelete(db *sql.DB, id int) error {
 	return nil
 }
 
-func dbImageAliasGet(db *sql.DB, name string, isTrustedClient bool) (int, api.ImageAliasesEntry, error) {
+func dbImageAliasGet(db *sql.DB, project string, name string, isTrustedClient bool) (int, api.ImageAliasesEntry, error) {
 	q := `SELECT images_aliases.id, images.fingerprint, images_aliases.description
 			 FROM images_aliases
 			 INNER JOIN images
 			 ON images_aliases.image_id=images.id
-			 WHERE images_aliases.name=?`
+			 WHERE images_aliases.project_id=(SELECT id FROM projects WHERE name=?) AND images_aliases.name=?`
 	if !isTrustedClient {
 		q = q + ` AND images.public=1`
 	}
@@ -265,7 +314,7 @@ func dbImageAliasGet(db *sql.DB, name string, isTrustedClient bool) (int, api.Im
 	id := -1
 	entry := api.ImageAliasesEntry{}
 
-	arg1 := []interface{}{name}
+	arg1 := []interface{}{project, name}
 	arg2 := []interface{}{&id, &fingerprint, &description}
 	err := dbQueryRowScan(db, q, arg1, arg2)
 	if err != nil {
@@ -288,8 +337,8 @@ func dbImageAliasRename(db *sql.DB, id int, name string) error {
 	return err
 }
 
-func dbImageAliasDelete(db *sql.DB, name string) error {
-	_, err := dbExec(db, "DELETE FROM images_aliases WHERE name=?", name)
+func dbImageAliasDelete(db *sql.DB, project string, name string) error {
+	_, err := dbExec(db, "DELETE FROM images_aliases WHERE project_id=(SELECT id FROM projects WHERE name=?) AND name=?", project, name)
 	return err
 }
 
@@ -299,9 +348,9 @@ func dbImageAliasesMove(db *sql.DB, source int, destination int) error {
 }
 
 // Insert an alias ento the database.
-func dbImageAliasAdd(db *sql.DB, name string, imageID int, desc string) error {
-	stmt := `INSERT INTO images_aliases (name, image_id, description) values (?, ?, ?)`
-	_, err := dbExec(db, stmt, name, imageID, desc)
+func dbImageAliasAdd(db *sql.DB, project string, name string, imageID int, desc string) error {
+	stmt := `INSERT INTO images_aliases (project_id, name, image_id, description) values ((SELECT id FROM projects WHERE name=?), ?, ?, ?)`
+	_, err := dbExec(db, stmt, project, name, imageID, desc)
 	return err
 }
 
@@ -323,6 +372,14 @@ func dbImageLastAccessInit(db *sql.DB, fingerprint string) error {
 	return err
 }
 
+// dbImageSignatureSet records the key the files of an image were verified
+// with, an empty signature marks the image unverified.
+func dbImageSignatureSet(db *sql.DB, fingerprint string, signature api.ImageSignature) error {
+	stmt := `UPDATE images SET signature_type=?, signature_key=? WHERE fingerprint=?`
+	_, err := dbExec(db, stmt, signature.Type, signature.Key, fingerprint)
+	return err
+}
+
 func dbImageUpdate(db *sql.DB, id int, fname string, sz int64, public bool, autoUpdate bool, architecture string, createdAt time.Time, expiresAt time.Time, properties map[string]string) error {
 	arch, err := osarch.ArchitectureId(architecture)
 	if err != nil {
@@ -380,7 +437,7 @@ func dbImageUpdate(db *sql.DB, id int, fname string, sz int64, public bool, auto
 	return nil
 }
 
-func dbImageInsert(db *sql.DB, fp string, fname string, sz int64, public bool, autoUpdate bool, architecture string, createdAt time.Time, expiresAt time.Time, properties map[string]string) error {
+func dbImageInsert(db *sql.DB, project string, fp string, fname string, sz int64, public bool, autoUpdate bool, architecture string, createdAt time.Time, expiresAt time.Time, properties map[string]string) error {
 	arch, err := osarch.ArchitectureId(architecture)
 	if err != nil {
 		arch = 0
@@ -401,14 +458,14 @@ func dbImageInsert(db *sql.DB, fp string, fname string, sz int64, public bool, a
 		autoUpdateInt = 1
 	}
 
-	stmt, err := tx.Prepare(`INSERT INTO images (fingerprint, filename, size, public, auto_update, architecture, creation_date, expiry_date, upload_date) VALUES (?, ?, ?, ?, ?, ?, ?, ?, strftime("%s"))`)
+	stmt, err := tx.Prepare(`INSERT INTO images (project_id, fingerprint, filename, size, public, auto_update, architecture, creation_date, expiry_date, upload_date) VALUES ((SELECT id FROM projects WHERE name=?), ?, ?, ?, ?, ?, ?, ?, ?, strftime("%s"))`)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(fp, fname, sz, publicInt, autoUpdateInt, arch, createdAt, expiresAt)
+	result, err := stmt.Exec(project, fp, fname, sz, publicInt, autoUpdateInt, arch, createdAt, expiresAt)
 	if err != nil {
 		tx.Rollback()
 		return err
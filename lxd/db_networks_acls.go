@@ -0,0 +1,201 @@
+package main
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func dbNetworkACLs(db *sql.DB) ([]string, error) {
+	q := "SELECT name FROM networks_acls ORDER BY name"
+	inargs := []interface{}{}
+	var name string
+	outfmt := []interface{}{name}
+	result, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return []string{}, err
+	}
+
+	response := []string{}
+	for _, r := range result {
+		response = append(response, r[0].(string))
+	}
+
+	return response, nil
+}
+
+func dbNetworkACLGet(db *sql.DB, name string) (int64, *api.NetworkACL, error) {
+	id := int64(-1)
+	description := sql.NullString{}
+
+	q := "SELECT id, description FROM networks_acls WHERE name=?"
+	arg1 := []interface{}{name}
+	arg2 := []interface{}{&id, &description}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, NoSuchObjectError
+		}
+
+		return -1, nil, err
+	}
+
+	acl := api.NetworkACL{
+		Name: name,
+	}
+	acl.Description = description.String
+
+	acl.Ingress, err = dbNetworkACLRulesGet(db, id, "ingress")
+	if err != nil {
+		return -1, nil, err
+	}
+
+	acl.Egress, err = dbNetworkACLRulesGet(db, id, "egress")
+	if err != nil {
+		return -1, nil, err
+	}
+
+	return id, &acl, nil
+}
+
+func dbNetworkACLRulesGet(db *sql.DB, id int64, direction string) ([]api.NetworkACLRule, error) {
+	var action, source, destination, protocol, sourcePort, destinationPort, description string
+	query := `
+        SELECT
+            action, source, destination, protocol, source_port, destination_port, description
+        FROM networks_acls_rules
+        WHERE network_acl_id=? AND direction=?
+        ORDER BY position`
+	inargs := []interface{}{id, direction}
+	outfmt := []interface{}{action, source, destination, protocol, sourcePort, destinationPort, description}
+	results, err := dbQueryScan(db, query, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []api.NetworkACLRule{}
+	for _, r := range results {
+		rules = append(rules, api.NetworkACLRule{
+			Action:          r[0].(string),
+			Source:          r[1].(string),
+			Destination:     r[2].(string),
+			Protocol:        r[3].(string),
+			SourcePort:      r[4].(string),
+			DestinationPort: r[5].(string),
+			Description:     r[6].(string),
+		})
+	}
+
+	return rules, nil
+}
+
+func dbNetworkACLCreate(db *sql.DB, name string, acl api.NetworkACLPut) (int64, error) {
+	tx, err := dbBegin(db)
+	if err != nil {
+		return -1, err
+	}
+
+	result, err := tx.Exec("INSERT INTO networks_acls (name, description) VALUES (?, ?)", name, acl.Description)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	err = dbNetworkACLRulesAdd(tx, id, acl)
+	if err != nil {
+		tx.Rollback()
+		return -1, err
+	}
+
+	err = txCommit(tx)
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+func dbNetworkACLUpdate(db *sql.DB, name string, acl api.NetworkACLPut) error {
+	id, _, err := dbNetworkACLGet(db, name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbBegin(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE networks_acls SET description=? WHERE id=?", acl.Description, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM networks_acls_rules WHERE network_acl_id=?", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = dbNetworkACLRulesAdd(tx, id, acl)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return txCommit(tx)
+}
+
+func dbNetworkACLRulesAdd(tx *sql.Tx, id int64, acl api.NetworkACLPut) error {
+	str := `
+INSERT INTO networks_acls_rules
+    (network_acl_id, direction, position, action, source, destination, protocol, source_port, destination_port, description)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	stmt, err := tx.Prepare(str)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	directions := map[string][]api.NetworkACLRule{"ingress": acl.Ingress, "egress": acl.Egress}
+	for direction, rules := range directions {
+		for i, rule := range rules {
+			_, err = stmt.Exec(id, direction, i, rule.Action, rule.Source, rule.Destination, rule.Protocol, rule.SourcePort, rule.DestinationPort, rule.Description)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func dbNetworkACLRename(db *sql.DB, oldName string, newName string) error {
+	id, _, err := dbNetworkACLGet(db, oldName)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbExec(db, "UPDATE networks_acls SET name=? WHERE id=?", newName, id)
+	return err
+}
+
+func dbNetworkACLDelete(db *sql.DB, name string) error {
+	id, _, err := dbNetworkACLGet(db, name)
+	if err != nil {
+		return err
+	}
+
+	// This also removes the rules of the ACL
+	_, err = dbExec(db, "DELETE FROM networks_acls WHERE id=?", id)
+	return err
+}
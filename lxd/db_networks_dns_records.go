@@ -0,0 +1,81 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func dbNetworkDNSRecords(db *sql.DB, networkID int64) ([]api.NetworkDNSRecord, error) {
+	var name, description, addresses string
+	q := "SELECT name, coalesce(description, ''), addresses FROM networks_dns_records WHERE network_id=? ORDER BY name"
+	inargs := []interface{}{networkID}
+	outfmt := []interface{}{name, description, addresses}
+	result, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []api.NetworkDNSRecord{}
+	for _, r := range result {
+		record := api.NetworkDNSRecord{Name: r[0].(string)}
+		record.Description = r[1].(string)
+		record.Addresses = dbNetworkDNSRecordAddresses(r[2].(string))
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func dbNetworkDNSRecordGet(db *sql.DB, networkID int64, name string) (*api.NetworkDNSRecord, error) {
+	description := sql.NullString{}
+	addresses := ""
+
+	q := "SELECT description, addresses FROM networks_dns_records WHERE network_id=? AND name=?"
+	arg1 := []interface{}{networkID, name}
+	arg2 := []interface{}{&description, &addresses}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NoSuchObjectError
+		}
+
+		return nil, err
+	}
+
+	record := api.NetworkDNSRecord{Name: name}
+	record.Description = description.String
+	record.Addresses = dbNetworkDNSRecordAddresses(addresses)
+
+	return &record, nil
+}
+
+// dbNetworkDNSRecordAddresses splits the comma separated addresses column.
+func dbNetworkDNSRecordAddresses(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+
+	return strings.Split(value, ",")
+}
+
+func dbNetworkDNSRecordCreate(db *sql.DB, networkID int64, name string, record api.NetworkDNSRecordPut) error {
+	_, err := dbExec(db, "INSERT INTO networks_dns_records (network_id, name, description, addresses) VALUES (?, ?, ?, ?)",
+		networkID, name, record.Description, strings.Join(record.Addresses, ","))
+	return err
+}
+
+func dbNetworkDNSRecordUpdate(db *sql.DB, networkID int64, name string, record api.NetworkDNSRecordPut) error {
+	_, err := dbExec(db, "UPDATE networks_dns_records SET description=?, addresses=? WHERE network_id=? AND name=?",
+		record.Description, strings.Join(record.Addresses, ","), networkID, name)
+	return err
+}
+
+func dbNetworkDNSRecordDelete(db *sql.DB, networkID int64, name string) error {
+	_, err := dbExec(db, "DELETE FROM networks_dns_records WHERE network_id=? AND name=?", networkID, name)
+	return err
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func dbNetworkForwards(db *sql.DB, networkID int64) ([]api.NetworkForward, error) {
+	var listenAddress string
+	q := "SELECT listen_address FROM networks_forwards WHERE network_id=? ORDER BY listen_address"
+	inargs := []interface{}{networkID}
+	outfmt := []interface{}{listenAddress}
+	result, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	forwards := []api.NetworkForward{}
+	for _, r := range result {
+		_, forward, err := dbNetworkForwardGet(db, networkID, r[0].(string))
+		if err != nil {
+			return nil, err
+		}
+
+		forwards = append(forwards, *forward)
+	}
+
+	return forwards, nil
+}
+
+func dbNetworkForwardGet(db *sql.DB, networkID int64, listenAddress string) (int64, *api.NetworkForward, error) {
+	id := int64(-1)
+	description := sql.NullString{}
+	targetAddress := sql.NullString{}
+
+	q := "SELECT id, description, target_address FROM networks_forwards WHERE network_id=? AND listen_address=?"
+	arg1 := []interface{}{networkID, listenAddress}
+	arg2 := []interface{}{&id, &description, &targetAddress}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, NoSuchObjectError
+		}
+
+		return -1, nil, err
+	}
+
+	forward := api.NetworkForward{ListenAddress: listenAddress}
+	forward.Description = description.String
+	forward.TargetAddress = targetAddress.String
+
+	forward.Ports, err = dbNetworkForwardPortsGet(db, id)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	return id, &forward, nil
+}
+
+// dbNetworkForwardNetwork returns the name of the network forwarding a listen
+// address, if any.
+func dbNetworkForwardNetwork(db *sql.DB, listenAddress string) (string, error) {
+	name := ""
+	q := "SELECT networks.name FROM networks_forwards JOIN networks ON networks.id=networks_forwards.network_id WHERE listen_address=?"
+	arg1 := []interface{}{listenAddress}
+	arg2 := []interface{}{&name}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", NoSuchObjectError
+		}
+
+		return "", err
+	}
+
+	return name, nil
+}
+
+func dbNetworkForwardPortsGet(db *sql.DB, id int64) ([]api.NetworkForwardPort, error) {
+	var protocol, listenPort, targetPort, targetAddress, description string
+	query := `
+        SELECT
+            protocol, listen_port, coalesce(target_port, ''), target_address, coalesce(description, '')
+        FROM networks_forwards_ports
+        WHERE network_forward_id=?
+        ORDER BY position`
+	inargs := []interface{}{id}
+	outfmt := []interface{}{protocol, listenPort, targetPort, targetAddress, description}
+	results, err := dbQueryScan(db, query, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := []api.NetworkForwardPort{}
+	for _, r := range results {
+		ports = append(ports, api.NetworkForwardPort{
+			Protocol:      r[0].(string),
+			ListenPort:    r[1].(string),
+			TargetPort:    r[2].(string),
+			TargetAddress: r[3].(string),
+			Description:   r[4].(string),
+		})
+	}
+
+	return ports, nil
+}
+
+func dbNetworkForwardCreate(db *sql.DB, networkID int64, listenAddress string, forward api.NetworkForwardPut) error {
+	tx, err := dbBegin(db)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("INSERT INTO networks_forwards (network_id, listen_address, description, target_address) VALUES (?, ?, ?, ?)",
+		networkID, listenAddress, forward.Description, forward.TargetAddress)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = dbNetworkForwardPortsAdd(tx, id, forward.Ports)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return txCommit(tx)
+}
+
+func dbNetworkForwardUpdate(db *sql.DB, networkID int64, listenAddress string, forward api.NetworkForwardPut) error {
+	id, _, err := dbNetworkForwardGet(db, networkID, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbBegin(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE networks_forwards SET description=?, target_address=? WHERE id=?", forward.Description, forward.TargetAddress, id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM networks_forwards_ports WHERE network_forward_id=?", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = dbNetworkForwardPortsAdd(tx, id, forward.Ports)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return txCommit(tx)
+}
+
+func dbNetworkForwardPortsAdd(tx *sql.Tx, id int64, ports []api.NetworkForwardPort) error {
+	str := `
+INSERT INTO networks_forwards_ports
+    (network_forward_id, position, protocol, listen_port, target_port, target_address, description)
+    VALUES (?, ?, ?, ?, ?, ?, ?)`
+	stmt, err := tx.Prepare(str)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, port := range ports {
+		_, err = stmt.Exec(id, i, port.Protocol, port.ListenPort, port.TargetPort, port.TargetAddress, port.Description)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func dbNetworkForwardDelete(db *sql.DB, networkID int64, listenAddress string) error {
+	// This also removes the ports of the forward
+	_, err := dbExec(db, "DELETE FROM networks_forwards WHERE network_id=? AND listen_address=?", networkID, listenAddress)
+	return err
+}
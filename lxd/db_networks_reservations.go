@@ -0,0 +1,82 @@
+package main
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func dbNetworkReservations(db *sql.DB, networkID int64) ([]api.NetworkReservation, error) {
+	var hwaddr, description, ipv4Address, ipv6Address, hostname string
+	q := `
+        SELECT
+            hwaddr, coalesce(description, ''), coalesce(ipv4_address, ''), coalesce(ipv6_address, ''), coalesce(hostname, '')
+        FROM networks_reservations
+        WHERE network_id=?
+        ORDER BY hwaddr`
+	inargs := []interface{}{networkID}
+	outfmt := []interface{}{hwaddr, description, ipv4Address, ipv6Address, hostname}
+	result, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := []api.NetworkReservation{}
+	for _, r := range result {
+		reservation := api.NetworkReservation{Hwaddr: r[0].(string)}
+		reservation.Description = r[1].(string)
+		reservation.IPv4Address = r[2].(string)
+		reservation.IPv6Address = r[3].(string)
+		reservation.Hostname = r[4].(string)
+
+		reservations = append(reservations, reservation)
+	}
+
+	return reservations, nil
+}
+
+func dbNetworkReservationGet(db *sql.DB, networkID int64, hwaddr string) (*api.NetworkReservation, error) {
+	description := sql.NullString{}
+	ipv4Address := sql.NullString{}
+	ipv6Address := sql.NullString{}
+	hostname := sql.NullString{}
+
+	q := "SELECT description, ipv4_address, ipv6_address, hostname FROM networks_reservations WHERE network_id=? AND hwaddr=?"
+	arg1 := []interface{}{networkID, hwaddr}
+	arg2 := []interface{}{&description, &ipv4Address, &ipv6Address, &hostname}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NoSuchObjectError
+		}
+
+		return nil, err
+	}
+
+	reservation := api.NetworkReservation{Hwaddr: hwaddr}
+	reservation.Description = description.String
+	reservation.IPv4Address = ipv4Address.String
+	reservation.IPv6Address = ipv6Address.String
+	reservation.Hostname = hostname.String
+
+	return &reservation, nil
+}
+
+func dbNetworkReservationCreate(db *sql.DB, networkID int64, hwaddr string, reservation api.NetworkReservationPut) error {
+	_, err := dbExec(db, "INSERT INTO networks_reservations (network_id, hwaddr, description, ipv4_address, ipv6_address, hostname) VALUES (?, ?, ?, ?, ?, ?)",
+		networkID, hwaddr, reservation.Description, reservation.IPv4Address, reservation.IPv6Address, reservation.Hostname)
+	return err
+}
+
+func dbNetworkReservationUpdate(db *sql.DB, networkID int64, hwaddr string, reservation api.NetworkReservationPut) error {
+	_, err := dbExec(db, "UPDATE networks_reservations SET description=?, ipv4_address=?, ipv6_address=?, hostname=? WHERE network_id=? AND hwaddr=?",
+		reservation.Description, reservation.IPv4Address, reservation.IPv6Address, reservation.Hostname, networkID, hwaddr)
+	return err
+}
+
+func dbNetworkReservationDelete(db *sql.DB, networkID int64, hwaddr string) error {
+	_, err := dbExec(db, "DELETE FROM networks_reservations WHERE network_id=? AND hwaddr=?", networkID, hwaddr)
+	return err
+}
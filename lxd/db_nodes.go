@@ -0,0 +1,244 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// dbNode is here to pass the cluster members from the database around
+type dbNode struct {
+	ID          int
+	Name        string
+	Address     string
+	Certificate string
+}
+
+// dbNodesGet returns all the cluster members, including the local one.
+func dbNodesGet(db *sql.DB) ([]dbNode, error) {
+	q := "SELECT id, name, address, certificate FROM nodes ORDER BY name"
+	id := -1
+	name := ""
+	address := ""
+	certificate := ""
+	inargs := []interface{}{}
+	outfmt := []interface{}{id, name, address, certificate}
+	result, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []dbNode{}
+	for _, r := range result {
+		nodes = append(nodes, dbNode{
+			ID:          r[0].(int),
+			Name:        r[1].(string),
+			Address:     r[2].(string),
+			Certificate: r[3].(string),
+		})
+	}
+
+	return nodes, nil
+}
+
+// dbNodeGet returns the cluster member with the given name.
+func dbNodeGet(db *sql.DB, name string) (*dbNode, error) {
+	node := dbNode{}
+
+	q := "SELECT id, name, address, certificate FROM nodes WHERE name=?"
+	arg1 := []interface{}{name}
+	arg2 := []interface{}{&node.ID, &node.Name, &node.Address, &node.Certificate}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NoSuchObjectError
+		}
+
+		return nil, err
+	}
+
+	return &node, nil
+}
+
+// dbNodeAdd records a new cluster member.
+func dbNodeAdd(db *sql.DB, name string, address string, certificate string) error {
+	nodes, err := dbNodesGet(db)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if node.Name == name || node.Address == address {
+			return DbErrAlreadyDefined
+		}
+	}
+
+	// Only the changes made from now on need to be sent to the new member
+	head, err := dbClusterJournalHead(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbExec(db, "INSERT INTO nodes (name, address, certificate, journal_sent) VALUES (?, ?, ?, ?)", name, address, certificate, head)
+	return err
+}
+
+// dbNodeRemove removes a cluster member.
+func dbNodeRemove(db *sql.DB, name string) error {
+	_, err := dbExec(db, "DELETE FROM nodes WHERE name=?", name)
+	return err
+}
+
+// dbNodesClear removes all cluster members and the pending changes, used
+// when leaving a cluster.
+func dbNodesClear(db *sql.DB) error {
+	_, err := dbExec(db, "DELETE FROM nodes")
+	if err != nil {
+		return err
+	}
+
+	_, err = dbExec(db, "DELETE FROM cluster_journal")
+	return err
+}
+
+// dbNodeContainersSet records the containers hosted by a cluster member.
+func dbNodeContainersSet(db *sql.DB, name string, containers []string) error {
+	node, err := dbNodeGet(db, name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbBegin(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM nodes_containers WHERE node_id=?", node.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, container := range containers {
+		_, err = tx.Exec("INSERT INTO nodes_containers (node_id, name) VALUES (?, ?)", node.ID, container)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return txCommit(tx)
+}
+
+// dbNodeContainerGet returns the cluster member hosting the container.
+func dbNodeContainerGet(db *sql.DB, container string) (*dbNode, error) {
+	node := dbNode{}
+
+	q := `SELECT nodes.id, nodes.name, nodes.address, nodes.certificate
+FROM nodes JOIN nodes_containers ON nodes.id = nodes_containers.node_id
+WHERE nodes_containers.name=? LIMIT 1`
+	arg1 := []interface{}{container}
+	arg2 := []interface{}{&node.ID, &node.Name, &node.Address, &node.Certificate}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NoSuchObjectError
+		}
+
+		return nil, err
+	}
+
+	return &node, nil
+}
+
+// dbNodeJournalGet returns the ID of the last journal entry sent to the
+// cluster member and the ID of the last entry received from it.
+func dbNodeJournalGet(db *sql.DB, name string) (int, int, error) {
+	sent := 0
+	received := 0
+
+	q := "SELECT journal_sent, journal_received FROM nodes WHERE name=?"
+	arg1 := []interface{}{name}
+	arg2 := []interface{}{&sent, &received}
+	err := dbQueryRowScan(db, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, -1, NoSuchObjectError
+		}
+
+		return -1, -1, err
+	}
+
+	return sent, received, nil
+}
+
+// dbNodeJournalSent records the last journal entry sent to the cluster member.
+func dbNodeJournalSent(db *sql.DB, name string, id int) error {
+	_, err := dbExec(db, "UPDATE nodes SET journal_sent=? WHERE name=?", id, name)
+	return err
+}
+
+// dbNodeJournalReceived records the last journal entry applied on behalf of
+// the cluster member.
+func dbNodeJournalReceived(db *sql.DB, name string, id int) error {
+	_, err := dbExec(db, "UPDATE nodes SET journal_received=? WHERE name=?", id, name)
+	return err
+}
+
+// dbClusterJournalAdd records a change to replicate to the other cluster members.
+func dbClusterJournalAdd(db *sql.DB, method string, path string, body string) error {
+	_, err := dbExec(db, "INSERT INTO cluster_journal (method, path, body, created_at) VALUES (?, ?, ?, ?)", method, path, body, time.Now().UTC())
+	return err
+}
+
+// dbClusterJournalGet returns up to limit journal entries following the given ID.
+func dbClusterJournalGet(db *sql.DB, after int, limit int) ([]clusterJournalEntry, error) {
+	q := "SELECT id, method, path, body FROM cluster_journal WHERE id > ? ORDER BY id LIMIT ?"
+	id := -1
+	method := ""
+	path := ""
+	body := ""
+	inargs := []interface{}{after, limit}
+	outfmt := []interface{}{id, method, path, body}
+	result, err := dbQueryScan(db, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []clusterJournalEntry{}
+	for _, r := range result {
+		entry := clusterJournalEntry{
+			ID:     r[0].(int),
+			Method: r[1].(string),
+			Path:   r[2].(string),
+		}
+
+		if r[3].(string) != "" {
+			entry.Body = []byte(r[3].(string))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// dbClusterJournalHead returns the ID of the latest journal entry.
+func dbClusterJournalHead(db *sql.DB) (int, error) {
+	head := 0
+
+	q := "SELECT IFNULL(MAX(id), 0) FROM cluster_journal"
+	err := dbQueryRowScan(db, q, []interface{}{}, []interface{}{&head})
+	if err != nil {
+		return -1, err
+	}
+
+	return head, nil
+}
+
+// dbClusterJournalPrune removes the journal entries up to the given ID.
+func dbClusterJournalPrune(db *sql.DB, id int) error {
+	_, err := dbExec(db, "DELETE FROM cluster_journal WHERE id <= ?", id)
+	return err
+}
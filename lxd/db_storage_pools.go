@@ -319,6 +319,29 @@ func dbStoragePoolVolumesGetType(db *sql.DB, volumeType int, poolID int64) ([]st
 	return response, nil
 }
 
+// Get the names of the snapshots of a storage volume attached to a given
+// storage pool.
+func dbStoragePoolVolumeSnapshotsGetType(db *sql.DB, volumeName string, volumeType int, poolID int64) ([]string, error) {
+	result := []string{}
+
+	regexp := volumeName + shared.SnapshotDelimiter
+	length := len(regexp)
+	query := "SELECT name FROM storage_volumes WHERE storage_pool_id=? AND type=? AND SUBSTR(name,1,?)=? ORDER BY id"
+	inargs := []interface{}{poolID, volumeType, length, regexp}
+	outargs := []interface{}{volumeName}
+
+	dbResults, err := dbQueryScan(db, query, inargs, outargs)
+	if err != nil {
+		return result, err
+	}
+
+	for _, r := range dbResults {
+		result = append(result, r[0].(string))
+	}
+
+	return result, nil
+}
+
 // Get a single storage volume attached to a given storage pool of a given type.
 func dbStoragePoolVolumeGetType(db *sql.DB, volumeName string, volumeType int, poolID int64) (int64, *api.StorageVolume, error) {
 	volumeID, err := dbStoragePoolVolumeGetTypeID(db, volumeName, volumeType, poolID)
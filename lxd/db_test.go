@@ -422,7 +422,7 @@ func Test_dbImageGet_finds_image_for_fingerprint(t *testing.T) {
 	db = createTestDb(t)
 	defer db.Close()
 
-	_, result, err = dbImageGet(db, "fingerprint", false, false)
+	_, result, err = dbImageGet(db, "default", "fingerprint", false, false)
 
 	if err != nil {
 		t.Fatal(err)
@@ -456,7 +456,7 @@ func Test_dbImageGet_for_missing_fingerprint(t *testing.T) {
 	db = createTestDb(t)
 	defer db.Close()
 
-	_, _, err = dbImageGet(db, "unknown", false, false)
+	_, _, err = dbImageGet(db, "default", "unknown", false, false)
 
 	if err != sql.ErrNoRows {
 		t.Fatal("Wrong err type returned")
@@ -471,7 +471,7 @@ func Test_dbImageAliasGet_alias_exists(t *testing.T) {
 	db = createTestDb(t)
 	defer db.Close()
 
-	_, alias, err := dbImageAliasGet(db, "somealias", true)
+	_, alias, err := dbImageAliasGet(db, "default", "somealias", true)
 	result = alias.Target
 
 	if err != nil {
@@ -491,7 +491,7 @@ func Test_dbImageAliasGet_alias_does_not_exists(t *testing.T) {
 	db = createTestDb(t)
 	defer db.Close()
 
-	_, _, err = dbImageAliasGet(db, "whatever", true)
+	_, _, err = dbImageAliasGet(db, "default", "whatever", true)
 
 	if err != NoSuchObjectError {
 		t.Fatal("Error should be NoSuchObjectError")
@@ -506,12 +506,12 @@ func Test_dbImageAliasAdd(t *testing.T) {
 	db = createTestDb(t)
 	defer db.Close()
 
-	err = dbImageAliasAdd(db, "Chaosphere", 1, "Someone will like the name")
+	err = dbImageAliasAdd(db, "default", "Chaosphere", 1, "Someone will like the name")
 	if err != nil {
 		t.Fatal("Error inserting Image alias.")
 	}
 
-	_, alias, err := dbImageAliasGet(db, "Chaosphere", true)
+	_, alias, err := dbImageAliasGet(db, "default", "Chaosphere", true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -547,6 +547,25 @@ func Test_dbContainerConfig(t *testing.T) {
 	}
 }
 
+func Test_dbContainersConfigValues(t *testing.T) {
+	db := createTestDb(t)
+	defer db.Close()
+
+	_, err := db.Exec("INSERT INTO containers_config (container_id, key, value) VALUES (1, 'volatile.eth0.host_name', 'enp1s0f0v0');")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := dbContainersConfigValues(db, "volatile.%.host_name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != 1 || values[0] != "enp1s0f0v0" {
+		t.Errorf("Unexpected values: %v", values)
+	}
+}
+
 func Test_dbProfileConfig(t *testing.T) {
 	var db *sql.DB
 	var err error
@@ -558,7 +577,7 @@ func Test_dbProfileConfig(t *testing.T) {
 
 	_, err = db.Exec("INSERT INTO profiles_config (profile_id, key, value) VALUES (3, 'something', 'something else');")
 
-	result, err = dbProfileConfig(db, "theprofile")
+	result, err = dbProfileConfig(db, "default", "theprofile")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -604,7 +623,7 @@ func Test_dbDevices_profiles(t *testing.T) {
 	db = createTestDb(t)
 	defer db.Close()
 
-	result, err = dbDevices(db, "theprofile", true)
+	result, err = dbDevices(db, "default", "theprofile", true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -630,7 +649,7 @@ func Test_dbDevices_containers(t *testing.T) {
 	db = createTestDb(t)
 	defer db.Close()
 
-	result, err = dbDevices(db, "thename", false)
+	result, err = dbDevices(db, "", "thename", false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -645,3 +664,795 @@ func Test_dbDevices_containers(t *testing.T) {
 	}
 
 }
+
+func Test_dbContainerBackups(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	args := backupArgs{
+		ContainerID:   1,
+		Name:          "backup0",
+		CreationDate:  time.Now().UTC(),
+		ContainerOnly: true,
+	}
+
+	err = dbContainerBackupCreate(db, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbContainerBackupCreate(db, args)
+	if err != DbErrAlreadyDefined {
+		t.Errorf("Creating a duplicate backup should have failed, got: %v", err)
+	}
+
+	result, err := dbContainerBackupGet(db, 1, "backup0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.ContainerOnly {
+		t.Errorf("Backup should have been container only")
+	}
+
+	if !result.ExpiryDate.IsZero() {
+		t.Errorf("Backup shouldn't have an expiry date: %s", result.ExpiryDate)
+	}
+
+	err = dbContainerBackupRename(db, result.ID, "backup1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := dbContainerGetBackups(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(names) != 1 || names[0] != "backup1" {
+		t.Errorf("Unexpected list of backups: %v", names)
+	}
+
+	err = dbContainerBackupRemove(db, result.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dbContainerBackupGet(db, 1, "backup1")
+	if err != NoSuchObjectError {
+		t.Errorf("Backup should have been removed, got: %v", err)
+	}
+}
+
+func Test_dbContainerBackupsGetExpired(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	now := time.Now().UTC()
+	backups := []backupArgs{
+		{ContainerID: 1, Name: "expired", CreationDate: now, ExpiryDate: now.Add(-time.Hour)},
+		{ContainerID: 1, Name: "current", CreationDate: now, ExpiryDate: now.Add(time.Hour)},
+		{ContainerID: 1, Name: "forever", CreationDate: now},
+	}
+
+	for _, args := range backups {
+		err = dbContainerBackupCreate(db, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired, err := dbContainerBackupsGetExpired(db, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(expired["thename"]) != 1 || expired["thename"][0] != "expired" {
+		t.Errorf("Unexpected list of expired backups: %v", expired)
+	}
+}
+
+func Test_dbContainerSnapshotsGetExpired(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	now := time.Now().UTC()
+	snapshots := []containerArgs{
+		{Name: "thename/expired", Ctype: cTypeSnapshot, ExpiryDate: now.Add(-time.Hour)},
+		{Name: "thename/current", Ctype: cTypeSnapshot, ExpiryDate: now.Add(time.Hour)},
+		{Name: "thename/forever", Ctype: cTypeSnapshot},
+	}
+
+	for _, args := range snapshots {
+		_, err = dbContainerCreate(db, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired, err := dbContainerSnapshotsGetExpired(db, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(expired) != 1 || expired[0] != "thename/expired" {
+		t.Errorf("Unexpected list of expired snapshots: %v", expired)
+	}
+
+	args, err := dbContainerGet(db, "thename/current")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args.ExpiryDate.Unix() != now.Add(time.Hour).Unix() {
+		t.Errorf("Unexpected expiry date: %s", args.ExpiryDate)
+	}
+}
+
+func Test_dbStoragePoolVolumeSnapshotsGetType(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	poolID, err := dbStoragePoolCreate(db, "pool1", "dir", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"vol1", "vol1/snap0", "vol1/snap1", "vol10", "vol10/snap0"} {
+		_, err = dbStoragePoolVolumeCreate(db, name, storagePoolVolumeTypeCustom, poolID, map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, err := dbStoragePoolVolumeSnapshotsGetType(db, "vol1", storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snapshots) != 2 || snapshots[0] != "vol1/snap0" || snapshots[1] != "vol1/snap1" {
+		t.Errorf("Unexpected list of snapshots: %v", snapshots)
+	}
+}
+
+func Test_dbNetworkACLs(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	acl := api.NetworkACLPut{
+		Description: "Web servers",
+		Ingress: []api.NetworkACLRule{
+			{Action: "allow", Protocol: "tcp", DestinationPort: "80,443"},
+			{Action: "drop"},
+		},
+	}
+
+	_, err = dbNetworkACLCreate(db, "web", acl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, result, err := dbNetworkACLGet(db, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Description != "Web servers" || len(result.Egress) != 0 || len(result.Ingress) != 2 {
+		t.Fatalf("Unexpected network ACL: %+v", result)
+	}
+
+	if result.Ingress[0].DestinationPort != "80,443" || result.Ingress[1].Action != "drop" {
+		t.Errorf("Unexpected ingress rules: %+v", result.Ingress)
+	}
+
+	// Updating replaces the rules
+	acl.Ingress = acl.Ingress[1:]
+	acl.Egress = []api.NetworkACLRule{{Action: "reject", Destination: "10.0.0.0/8"}}
+	err = dbNetworkACLUpdate(db, "web", acl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbNetworkACLRename(db, "web", "www")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, result, err = dbNetworkACLGet(db, "www")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Ingress) != 1 || len(result.Egress) != 1 || result.Egress[0].Destination != "10.0.0.0/8" {
+		t.Errorf("Unexpected network ACL after update: %+v", result)
+	}
+
+	err = dbNetworkACLDelete(db, "www")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := dbNetworkACLs(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(names) != 0 {
+		t.Errorf("Unexpected network ACLs: %v", names)
+	}
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM networks_acls_rules").Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 0 {
+		t.Errorf("The rules weren't removed with the ACL: %d left", count)
+	}
+}
+
+func Test_dbNetworkReservations(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	networkID, err := dbNetworkCreate(db, "lxdbr0", map[string]string{"ipv4.address": "10.0.0.1/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reservation := api.NetworkReservationPut{IPv4Address: "10.0.0.10", Hostname: "printer"}
+	err = dbNetworkReservationCreate(db, networkID, "00:16:3e:00:00:01", reservation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reservation.IPv4Address = "10.0.0.11"
+	reservation.Description = "Printer"
+	err = dbNetworkReservationUpdate(db, networkID, "00:16:3e:00:00:01", reservation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := dbNetworkReservationGet(db, networkID, "00:16:3e:00:00:01")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.IPv4Address != "10.0.0.11" || result.IPv6Address != "" || result.Hostname != "printer" || result.Description != "Printer" {
+		t.Errorf("Unexpected reservation: %+v", result)
+	}
+
+	_, err = dbNetworkReservationGet(db, networkID, "00:16:3e:00:00:02")
+	if err != NoSuchObjectError {
+		t.Errorf("Expected NoSuchObjectError, got: %v", err)
+	}
+
+	// Reservations go away along with their network
+	err = dbNetworkDelete(db, "lxdbr0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reservations, err := dbNetworkReservations(db, networkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reservations) != 0 {
+		t.Errorf("The reservations weren't removed with the network: %+v", reservations)
+	}
+}
+
+func Test_dbNetworkDNSRecords(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	networkID, err := dbNetworkCreate(db, "lxdbr0", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := api.NetworkDNSRecordPut{Addresses: []string{"10.0.0.5", "fd42::5"}}
+	err = dbNetworkDNSRecordCreate(db, networkID, "gateway", record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := dbNetworkDNSRecords(db, networkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 || records[0].Name != "gateway" || len(records[0].Addresses) != 2 || records[0].Addresses[1] != "fd42::5" {
+		t.Fatalf("Unexpected DNS records: %+v", records)
+	}
+
+	err = dbNetworkDNSRecordDelete(db, networkID, "gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dbNetworkDNSRecordGet(db, networkID, "gateway")
+	if err != NoSuchObjectError {
+		t.Errorf("Expected NoSuchObjectError, got: %v", err)
+	}
+}
+
+func Test_dbNetworkForwards(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	networkID, err := dbNetworkCreate(db, "lxdbr0", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forward := api.NetworkForwardPut{
+		TargetAddress: "10.0.0.5",
+		Ports: []api.NetworkForwardPort{
+			{Protocol: "tcp", ListenPort: "80,443", TargetAddress: "10.0.0.6"},
+			{Protocol: "udp", ListenPort: "53", TargetPort: "5353", TargetAddress: "10.0.0.7"},
+		},
+	}
+
+	err = dbNetworkForwardCreate(db, networkID, "192.0.2.1", forward)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forwards, err := dbNetworkForwards(db, networkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(forwards) != 1 || forwards[0].ListenAddress != "192.0.2.1" || len(forwards[0].Ports) != 2 || forwards[0].Ports[1].TargetPort != "5353" {
+		t.Fatalf("Unexpected forwards: %+v", forwards)
+	}
+
+	owner, err := dbNetworkForwardNetwork(db, "192.0.2.1")
+	if err != nil || owner != "lxdbr0" {
+		t.Fatalf("Unexpected network for the forward: %s (%v)", owner, err)
+	}
+
+	forward.Ports = forward.Ports[1:]
+	err = dbNetworkForwardUpdate(db, networkID, "192.0.2.1", forward)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, updated, err := dbNetworkForwardGet(db, networkID, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(updated.Ports) != 1 || updated.Ports[0].Protocol != "udp" {
+		t.Fatalf("Unexpected ports after the update: %+v", updated.Ports)
+	}
+
+	err = dbNetworkForwardDelete(db, networkID, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = dbNetworkForwardGet(db, networkID, "192.0.2.1")
+	if err != NoSuchObjectError {
+		t.Errorf("Expected NoSuchObjectError, got: %v", err)
+	}
+}
+
+func Test_dbNodes(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	err = dbNodeAdd(db, "node1", "10.0.0.1:8443", "cert1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbNodeAdd(db, "node2", "10.0.0.2:8443", "cert2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbNodeAdd(db, "node3", "10.0.0.1:8443", "cert3")
+	if err != DbErrAlreadyDefined {
+		t.Errorf("Adding a node with a duplicate address should have failed, got: %v", err)
+	}
+
+	nodes, err := dbNodesGet(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nodes) != 2 || nodes[0].Name != "node1" || nodes[1].Name != "node2" {
+		t.Errorf("Unexpected nodes: %v", nodes)
+	}
+
+	node, err := dbNodeGet(db, "node2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if node.Address != "10.0.0.2:8443" || node.Certificate != "cert2" {
+		t.Errorf("Unexpected node: %v", node)
+	}
+
+	err = dbNodeRemove(db, "node2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dbNodeGet(db, "node2")
+	if err != NoSuchObjectError {
+		t.Errorf("Removed node should be gone, got: %v", err)
+	}
+
+	err = dbNodesClear(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err = dbNodesGet(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nodes) != 0 {
+		t.Errorf("Nodes should have been cleared: %v", nodes)
+	}
+}
+
+func Test_dbNodeContainers(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	err = dbNodeAdd(db, "node1", "10.0.0.1:8443", "cert1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbNodeContainersSet(db, "node1", []string{"c1", "ci_c2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := dbNodeContainerGet(db, "ci_c2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if node.Name != "node1" || node.Address != "10.0.0.1:8443" {
+		t.Errorf("Unexpected node: %v", node)
+	}
+
+	// The list replaces the previous one
+	err = dbNodeContainersSet(db, "node1", []string{"c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dbNodeContainerGet(db, "ci_c2")
+	if err != NoSuchObjectError {
+		t.Errorf("Removed container should be gone, got: %v", err)
+	}
+
+	// Removing the node forgets its containers
+	err = dbNodeRemove(db, "node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dbNodeContainerGet(db, "c1")
+	if err != NoSuchObjectError {
+		t.Errorf("Containers of removed nodes should be gone, got: %v", err)
+	}
+
+	err = dbNodeContainersSet(db, "node1", []string{"c1"})
+	if err != NoSuchObjectError {
+		t.Errorf("Setting the containers of a missing node should fail, got: %v", err)
+	}
+}
+
+func Test_dbClusterJournal(t *testing.T) {
+	var db *sql.DB
+	var err error
+
+	db = createTestDb(t)
+	defer db.Close()
+
+	err = dbNodeAdd(db, "node1", "10.0.0.1:8443", "cert1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbClusterJournalAdd(db, "POST", "/1.0/profiles", `{"name": "p1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbClusterJournalAdd(db, "DELETE", "/1.0/profiles/p1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// New members only get the changes made after they joined
+	err = dbNodeAdd(db, "node2", "10.0.0.2:8443", "cert2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent, received, err := dbNodeJournalGet(db, "node2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent != 2 || received != 0 {
+		t.Errorf("Unexpected journal state for the new node: %d %d", sent, received)
+	}
+
+	entries, err := dbClusterJournalGet(db, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 || entries[0].Method != "POST" || string(entries[0].Body) != `{"name": "p1"}` || entries[1].Body != nil {
+		t.Errorf("Unexpected journal entries: %v", entries)
+	}
+
+	entries, err = dbClusterJournalGet(db, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].ID != 2 {
+		t.Errorf("Unexpected journal entries: %v", entries)
+	}
+
+	err = dbNodeJournalSent(db, "node1", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = dbClusterJournalPrune(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := dbClusterJournalHead(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err = dbClusterJournalGet(db, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if head != 2 || len(entries) != 1 {
+		t.Errorf("Unexpected journal after pruning: %d %v", head, entries)
+	}
+
+	err = dbNodesClear(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err = dbClusterJournalGet(db, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 0 {
+		t.Errorf("The journal should have been cleared: %v", entries)
+	}
+}
+
+func Test_dbProjects(t *testing.T) {
+	db := createTestDb(t)
+	defer db.Close()
+
+	config := map[string]string{"features.images": "false", "features.profiles": "true"}
+	_, err := dbProjectCreate(db, "foo", "Some project", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := dbProjects(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(projects) != 2 || projects[0] != "default" || projects[1] != "foo" {
+		t.Errorf("Unexpected projects: %v", projects)
+	}
+
+	_, project, err := dbProjectGet(db, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if project.Description != "Some project" || project.Config["features.profiles"] != "true" {
+		t.Errorf("Unexpected project: %v", project)
+	}
+
+	images, err := dbProjectEffective(db, "foo", "images")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if images != "default" {
+		t.Errorf("Images of the project should come from the default project, got: %s", images)
+	}
+
+	profiles, err := dbProjectEffective(db, "foo", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profiles != "foo" {
+		t.Errorf("Profiles of the project should be its own, got: %s", profiles)
+	}
+
+	// Profiles of different projects don't clash
+	err = dbProfileCreateDefault(db, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dbProfileCreate(db, "foo", "theprofile", "", map[string]string{"limits.cpu": "2"}, types.Devices{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := dbProfiles(db, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(names) != 2 {
+		t.Errorf("Unexpected profiles in the project: %v", names)
+	}
+
+	result, err := dbProfileConfig(db, "default", "theprofile")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result["limits.cpu"] != "" {
+		t.Errorf("The profile of the default project was changed: %v", result)
+	}
+
+	// Deleting the project removes its profiles
+	err = dbProjectDelete(db, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = dbProjectGet(db, "foo")
+	if err != NoSuchObjectError {
+		t.Errorf("Removed project should be gone, got: %v", err)
+	}
+
+	_, _, err = dbProfileGet(db, "foo", "theprofile")
+	if err == nil {
+		t.Errorf("Profiles of the removed project should be gone")
+	}
+}
+
+func Test_projectSplitName(t *testing.T) {
+	tests := map[string][2]string{
+		"c1":          {"default", "c1"},
+		"c1/snap0":    {"default", "c1/snap0"},
+		"foo_c1":      {"foo", "c1"},
+		"foo_c1/snap": {"foo", "c1/snap"},
+	}
+
+	for name, expected := range tests {
+		project, result := projectSplitName(name)
+		if project != expected[0] || result != expected[1] {
+			t.Errorf("Unexpected split of %s: %s, %s", name, project, result)
+		}
+
+		if projectPrefix(project, result) != name {
+			t.Errorf("Prefixing %s didn't give back %s", result, name)
+		}
+	}
+}
+
+func Test_dbCertRestrictions(t *testing.T) {
+	db := createTestDb(t)
+	defer db.Close()
+
+	_, err := dbProjectCreate(db, "ci", "", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := &dbCertInfo{
+		Fingerprint: "abcdef",
+		Type:        1,
+		Name:        "runner",
+		Certificate: "PEM certificate",
+		Restricted:  true,
+		Permission:  "operator",
+		Containers:  []string{"build2", "build1"},
+		Projects:    []string{"ci"},
+	}
+
+	err = dbCertSave(db, cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := dbCertGet(db, "abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Restricted || result.Permission != "operator" {
+		t.Errorf("Unexpected certificate permission: %v", result)
+	}
+
+	if len(result.Containers) != 2 || result.Containers[0] != "build1" {
+		t.Errorf("Unexpected certificate containers: %v", result.Containers)
+	}
+
+	if len(result.Projects) != 1 || result.Projects[0] != "ci" {
+		t.Errorf("Unexpected certificate projects: %v", result.Projects)
+	}
+
+	// Updating replaces the restrictions
+	cert.Containers = []string{}
+	cert.Projects = []string{"default"}
+	err = dbCertUpdate(db, "abcdef", cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certs, err := dbCertsGet(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(certs) != 1 || len(certs[0].Containers) != 0 || len(certs[0].Projects) != 1 || certs[0].Projects[0] != "default" {
+		t.Errorf("Unexpected certificates after update: %v", certs)
+	}
+
+	// Unknown projects are refused
+	cert.Projects = []string{"missing"}
+	err = dbCertUpdate(db, "abcdef", cert)
+	if err == nil {
+		t.Errorf("Restricting to a missing project should fail")
+	}
+}
@@ -68,6 +68,17 @@ var dbUpdates = []dbUpdate{
 	{version: 33, run: dbUpdateFromV32},
 	{version: 34, run: dbUpdateFromV33},
 	{version: 35, run: dbUpdateFromV34},
+	{version: 36, run: dbUpdateFromV35},
+	{version: 37, run: dbUpdateFromV36},
+	{version: 38, run: dbUpdateFromV37},
+	{version: 39, run: dbUpdateFromV38},
+	{version: 40, run: dbUpdateFromV39},
+	{version: 41, run: dbUpdateFromV40},
+	{version: 42, run: dbUpdateFromV41},
+	{version: 43, run: dbUpdateFromV42},
+	{version: 44, run: dbUpdateFromV43},
+	{version: 45, run: dbUpdateFromV44},
+	{version: 46, run: dbUpdateFromV45},
 }
 
 type dbUpdate struct {
@@ -124,6 +135,258 @@ func dbUpdatesApplyAll(d *Daemon) error {
 }
 
 // Schema updates begin here
+func dbUpdateFromV45(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+CREATE TABLE IF NOT EXISTS nodes_containers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    node_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    UNIQUE (node_id, name),
+    FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE
+);`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
+func dbUpdateFromV44(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+CREATE TABLE IF NOT EXISTS cluster_journal (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    method VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+ALTER TABLE nodes ADD COLUMN journal_sent INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE nodes ADD COLUMN journal_received INTEGER NOT NULL DEFAULT 0;`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
+func dbUpdateFromV43(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+ALTER TABLE images ADD COLUMN signature_type VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE images ADD COLUMN signature_key VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE images_source ADD COLUMN signing_keys TEXT NOT NULL DEFAULT '';`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
+func dbUpdateFromV42(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+CREATE TABLE IF NOT EXISTS networks_forwards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    listen_address VARCHAR(255) NOT NULL,
+    description TEXT,
+    target_address VARCHAR(255),
+    UNIQUE (listen_address),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS networks_forwards_ports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_forward_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    protocol VARCHAR(255) NOT NULL,
+    listen_port TEXT NOT NULL,
+    target_port TEXT,
+    target_address VARCHAR(255) NOT NULL,
+    description TEXT,
+    UNIQUE (network_forward_id, position),
+    FOREIGN KEY (network_forward_id) REFERENCES networks_forwards (id) ON DELETE CASCADE
+);`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
+func dbUpdateFromV41(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+CREATE TABLE IF NOT EXISTS networks_dns_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    addresses TEXT NOT NULL,
+    UNIQUE (network_id, name),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS networks_reservations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    hwaddr VARCHAR(255) NOT NULL,
+    description TEXT,
+    ipv4_address VARCHAR(255),
+    ipv6_address VARCHAR(255),
+    hostname VARCHAR(255),
+    UNIQUE (network_id, hwaddr),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
+func dbUpdateFromV40(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+CREATE TABLE IF NOT EXISTS networks_acls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    UNIQUE (name)
+);
+CREATE TABLE IF NOT EXISTS networks_acls_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_acl_id INTEGER NOT NULL,
+    direction VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    action VARCHAR(255) NOT NULL,
+    source TEXT,
+    destination TEXT,
+    protocol VARCHAR(255),
+    source_port TEXT,
+    destination_port TEXT,
+    description TEXT,
+    UNIQUE (network_acl_id, direction, position),
+    FOREIGN KEY (network_acl_id) REFERENCES networks_acls (id) ON DELETE CASCADE
+);`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
+func dbUpdateFromV39(currentVersion int, version int, d *Daemon) error {
+	_, err := d.db.Exec("ALTER TABLE containers ADD COLUMN expiry_date DATETIME;")
+	return err
+}
+
+func dbUpdateFromV38(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+ALTER TABLE certificates ADD COLUMN restricted INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE certificates ADD COLUMN permission VARCHAR(255) NOT NULL DEFAULT 'admin';
+CREATE TABLE IF NOT EXISTS certificates_containers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    certificate_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    UNIQUE (certificate_id, name),
+    FOREIGN KEY (certificate_id) REFERENCES certificates (id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS certificates_projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    certificate_id INTEGER NOT NULL,
+    project_id INTEGER NOT NULL,
+    UNIQUE (certificate_id, project_id),
+    FOREIGN KEY (certificate_id) REFERENCES certificates (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
+func dbUpdateFromV37(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+CREATE TABLE IF NOT EXISTS projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    UNIQUE (name)
+);
+CREATE TABLE IF NOT EXISTS projects_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (project_id, key),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+INSERT INTO projects (id, name, description) VALUES (1, "default", "Default LXD project");
+INSERT INTO projects_config (project_id, key, value) VALUES (1, "features.images", "true");
+INSERT INTO projects_config (project_id, key, value) VALUES (1, "features.profiles", "true");
+
+PRAGMA foreign_keys=OFF; -- So that integrity doesn't get in the way for now
+
+CREATE TABLE tmp (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL DEFAULT 1,
+    cached INTEGER NOT NULL DEFAULT 0,
+    fingerprint VARCHAR(255) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    size INTEGER NOT NULL,
+    public INTEGER NOT NULL DEFAULT 0,
+    auto_update INTEGER NOT NULL DEFAULT 0,
+    architecture INTEGER NOT NULL,
+    creation_date DATETIME,
+    expiry_date DATETIME,
+    upload_date DATETIME NOT NULL,
+    last_use_date DATETIME,
+    UNIQUE (project_id, fingerprint),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+INSERT INTO tmp (id, cached, fingerprint, filename, size, public, auto_update, architecture, creation_date, expiry_date, upload_date, last_use_date)
+    SELECT id, cached, fingerprint, filename, size, public, auto_update, architecture, creation_date, expiry_date, upload_date, last_use_date FROM images;
+DROP TABLE images;
+ALTER TABLE tmp RENAME TO images;
+
+CREATE TABLE tmp (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL DEFAULT 1,
+    name VARCHAR(255) NOT NULL,
+    image_id INTEGER NOT NULL,
+    description VARCHAR(255),
+    FOREIGN KEY (image_id) REFERENCES images (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE,
+    UNIQUE (project_id, name)
+);
+INSERT INTO tmp (id, name, image_id, description)
+    SELECT id, name, image_id, description FROM images_aliases;
+DROP TABLE images_aliases;
+ALTER TABLE tmp RENAME TO images_aliases;
+
+CREATE TABLE tmp (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL DEFAULT 1,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    UNIQUE (project_id, name),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+INSERT INTO tmp (id, name, description)
+    SELECT id, name, description FROM profiles;
+DROP TABLE profiles;
+ALTER TABLE tmp RENAME TO profiles;
+
+PRAGMA foreign_keys=ON; -- Make sure we turn integrity checks back on.`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
+func dbUpdateFromV36(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+CREATE TABLE IF NOT EXISTS nodes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    address VARCHAR(255) NOT NULL,
+    certificate TEXT NOT NULL,
+    UNIQUE (name),
+    UNIQUE (address)
+);`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
+func dbUpdateFromV35(currentVersion int, version int, d *Daemon) error {
+	stmt := `
+CREATE TABLE IF NOT EXISTS containers_backups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    container_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    creation_date DATETIME,
+    expiry_date DATETIME,
+    container_only INTEGER NOT NULL default 0,
+    FOREIGN KEY (container_id) REFERENCES containers (id) ON DELETE CASCADE,
+    UNIQUE (container_id, name)
+);`
+	_, err := d.db.Exec(stmt)
+	return err
+}
+
 func dbUpdateFromV34(currentVersion int, version int, d *Daemon) error {
 	stmt := `
 CREATE TABLE IF NOT EXISTS storage_pools (
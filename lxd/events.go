@@ -13,6 +13,7 @@ import (
 	log "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 )
 
 type eventsHandler struct {
@@ -51,22 +52,26 @@ type eventListener struct {
 	active       chan bool
 	id           string
 	msgLock      sync.Mutex
+
+	// Restricted certificate of the client, if any
+	cert *dbCertInfo
 }
 
 type eventsServe struct {
-	req *http.Request
+	req  *http.Request
+	cert *dbCertInfo
 }
 
 func (r *eventsServe) Render(w http.ResponseWriter) error {
-	return eventsSocket(r.req, w)
+	return eventsSocket(r.req, r.cert, w)
 }
 
 func (r *eventsServe) String() string {
 	return "event handler"
 }
 
-func eventsSocket(r *http.Request, w http.ResponseWriter) error {
-	listener := eventListener{}
+func eventsSocket(r *http.Request, cert *dbCertInfo, w http.ResponseWriter) error {
+	listener := eventListener{cert: cert}
 
 	typeStr := r.FormValue("type")
 	if typeStr == "" {
@@ -102,7 +107,7 @@ func eventsSocket(r *http.Request, w http.ResponseWriter) error {
 }
 
 func eventsGet(d *Daemon, r *http.Request) Response {
-	return &eventsServe{r}
+	return &eventsServe{r, certificateRequestInfo(d, r)}
 }
 
 var eventsCmd = Command{name: "events", get: eventsGet}
@@ -125,6 +130,10 @@ func eventSend(eventType string, eventMessage interface{}) error {
 			continue
 		}
 
+		if !eventAllowed(listener, eventType, eventMessage) {
+			continue
+		}
+
 		go func(listener *eventListener, body []byte) {
 			if listener == nil {
 				return
@@ -143,3 +152,18 @@ func eventSend(eventType string, eventMessage interface{}) error {
 
 	return nil
 }
+
+// eventAllowed checks whether the listener may get the event. Clients
+// restricted to some containers only get the events of their operations.
+func eventAllowed(listener *eventListener, eventType string, eventMessage interface{}) bool {
+	if listener.cert == nil || len(listener.cert.Containers) == 0 {
+		return true
+	}
+
+	op, ok := eventMessage.(*api.Operation)
+	if eventType != "operation" || !ok {
+		return false
+	}
+
+	return certificateAllowedOperation(listener.cert, op)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// firewall is implemented by the backends generating the filtering and NAT
+// rules of the managed networks and of the bridged container nics.
+type firewall interface {
+	String() string
+
+	// Managed networks
+	NetworkClear(netName string) error
+	NetworkSetupServices(family string, netName string, dhcp bool) error
+	NetworkSetupForward(family string, netName string, accept bool) error
+	NetworkSetupNAT(family string, netName string, subnet *net.IPNet) error
+	NetworkSetupACLs(netName string, acls []*api.NetworkACL) error
+	NetworkSetupForwards(netName string, forwards []api.NetworkForward) error
+
+	// Bridged container nics
+	NicSetupMACFilter(bridge string, hostName string, hwaddr string) error
+	NicClearMACFilter(bridge string, hwaddr string) error
+	NicSetupACLs(cName string, devName string, bridge string, hostName string, acls []*api.NetworkACL) error
+	NicClearACLs(cName string, devName string, bridge string) error
+}
+
+// firewallLoad returns the firewall backend for a "core.firewall" value.
+func firewallLoad(name string) (firewall, error) {
+	if name == "" || name == "auto" {
+		name = firewallDetect()
+	}
+
+	switch name {
+	case "xtables":
+		return &firewallXtables{}, nil
+	case "nftables":
+		return &firewallNftables{}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown firewall backend: %s", name)
+}
+
+// firewallDetect picks nftables on hosts where it's usable and where the
+// iptables tools are either missing or themselves backed by nftables, as
+// mixing legacy iptables rules with nftables ones doesn't work reliably.
+func firewallDetect() string {
+	_, err := exec.LookPath("nft")
+	if err != nil {
+		return "xtables"
+	}
+
+	_, err = shared.RunCommand("nft", "list", "tables")
+	if err != nil {
+		return "xtables"
+	}
+
+	_, err = exec.LookPath("iptables")
+	if err != nil {
+		return "nftables"
+	}
+
+	output, err := shared.RunCommand("iptables", "-V")
+	if err == nil && strings.Contains(output, "nf_tables") {
+		return "nftables"
+	}
+
+	return "xtables"
+}
+
+// firewallSwitch moves the rules of the running networks and containers from
+// the current firewall backend to a new one.
+func firewallSwitch(d *Daemon, fw firewall) error {
+	old := d.firewall
+
+	networks, err := dbNetworks(d.db)
+	if err != nil {
+		return err
+	}
+
+	running := []*network{}
+	for _, name := range networks {
+		n, err := networkLoadByName(d, name)
+		if err != nil {
+			return err
+		}
+
+		if !n.IsRunning() {
+			continue
+		}
+
+		err = old.NetworkClear(n.name)
+		if err != nil {
+			return err
+		}
+
+		running = append(running, n)
+	}
+
+	cts, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		return err
+	}
+
+	nics := map[*containerLXC][]string{}
+	for _, ct := range cts {
+		c, err := containerLoadByName(d, ct)
+		if err != nil {
+			return err
+		}
+
+		if !c.IsRunning() {
+			continue
+		}
+
+		cLXC, ok := c.(*containerLXC)
+		if !ok {
+			continue
+		}
+
+		for _, k := range c.ExpandedDevices().DeviceNames() {
+			m, err := cLXC.fillNetworkDevice(k, c.ExpandedDevices()[k])
+			if err != nil {
+				return err
+			}
+
+			if m["type"] != "nic" || m["nictype"] != "bridged" {
+				continue
+			}
+
+			err = old.NicClearMACFilter(m["parent"], m["hwaddr"])
+			if err != nil {
+				return err
+			}
+
+			err = old.NicClearACLs(c.Name(), k, m["parent"])
+			if err != nil {
+				return err
+			}
+
+			nics[cLXC] = append(nics[cLXC], k)
+		}
+	}
+
+	d.firewall = fw
+
+	for _, n := range running {
+		err = n.Start()
+		if err != nil {
+			return err
+		}
+	}
+
+	for c, devices := range nics {
+		for _, k := range devices {
+			m, err := c.fillNetworkDevice(k, c.ExpandedDevices()[k])
+			if err != nil {
+				return err
+			}
+
+			hostName := c.getHostInterface(m["name"])
+			if hostName == "" {
+				shared.LogWarn("Unable to find the host interface, skipping firewall rules", log.Ctx{"container": c.Name(), "device": k})
+				continue
+			}
+
+			if shared.IsTrue(m["security.mac_filtering"]) {
+				err = fw.NicSetupMACFilter(m["parent"], hostName, m["hwaddr"])
+				if err != nil {
+					return err
+				}
+			}
+
+			if m["security.acls"] != "" {
+				err = networkACLNicApply(d, c.Name(), k, m["parent"], hostName, m["security.acls"])
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
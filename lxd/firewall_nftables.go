@@ -0,0 +1,478 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// firewallNftables generates the rules through nft. Every managed network
+// gets its own "inet" table holding all of its rules, so clearing a network
+// is just a matter of removing its table. The rules of the container nics go
+// in a "bridge" table for each parent bridge.
+type firewallNftables struct{}
+
+func (f *firewallNftables) String() string {
+	return "nftables"
+}
+
+func (f *firewallNftables) run(args ...string) error {
+	_, err := shared.RunCommand("nft", args...)
+	return err
+}
+
+func (f *firewallNftables) tableName(name string) string {
+	return fmt.Sprintf("lxd_%s", name)
+}
+
+func (f *firewallNftables) tableExists(family string, table string) bool {
+	_, err := shared.RunCommand("nft", "list", "table", family, table)
+	return err == nil
+}
+
+func (f *firewallNftables) chainExists(family string, table string, chain string) bool {
+	_, err := shared.RunCommand("nft", "list", "chain", family, table, chain)
+	return err == nil
+}
+
+// setupTable creates a table along with its base chains, the chains are
+// given as {name, type, hook, priority}.
+func (f *firewallNftables) setupTable(family string, table string, chains [][]string) error {
+	err := f.run("add", "table", family, table)
+	if err != nil {
+		return err
+	}
+
+	for _, chain := range chains {
+		if f.chainExists(family, table, chain[0]) {
+			continue
+		}
+
+		err = f.run("add", "chain", family, table, chain[0], "{", "type", chain[1], "hook", chain[2], "priority", chain[3], ";", "policy", "accept", ";", "}")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listRules returns the chain and handle of the rules of a table for which
+// match returns true.
+func (f *firewallNftables) listRules(family string, table string, match func(rule string) bool) ([][]string, error) {
+	output, err := shared.RunCommand("nft", "-a", "list", "table", family, table)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := [][]string{}
+	chain := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "chain ") {
+			chain = strings.Fields(line)[1]
+			continue
+		}
+
+		idx := strings.LastIndex(line, "# handle ")
+		if chain == "" || idx < 0 {
+			continue
+		}
+
+		if !match(strings.TrimSpace(line[:idx])) {
+			continue
+		}
+
+		rules = append(rules, []string{chain, strings.TrimSpace(line[idx+len("# handle "):])})
+	}
+
+	return rules, nil
+}
+
+func (f *firewallNftables) quote(name string) string {
+	return fmt.Sprintf("\"%s\"", name)
+}
+
+// networkSetupTable creates the table of a managed network. The "acl" chain
+// is jumped to before any of the other forwarding rules.
+func (f *firewallNftables) networkSetupTable(netName string) error {
+	table := f.tableName(netName)
+
+	chains := [][]string{
+		{"input", "filter", "input", "0"},
+		{"output", "filter", "output", "0"},
+		{"forward", "filter", "forward", "0"},
+		{"postrouting", "nat", "postrouting", "100"},
+	}
+
+	err := f.setupTable("inet", table, chains)
+	if err != nil {
+		return err
+	}
+
+	if f.chainExists("inet", table, "acl") {
+		return nil
+	}
+
+	err = f.run("add", "chain", "inet", table, "acl")
+	if err != nil {
+		return err
+	}
+
+	for _, match := range []string{"iifname", "oifname"} {
+		err = f.run("insert", "rule", "inet", table, "forward", match, f.quote(netName), "jump", "acl")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallNftables) NetworkClear(netName string) error {
+	if f.hasIptables() {
+		err := networkIptablesClear("ipv4", netName, "mangle")
+		if err != nil {
+			shared.LogWarn("Unable to clear the DHCP checksum fix", log.Ctx{"network": netName, "err": err})
+		}
+	}
+
+	table := f.tableName(netName)
+	if !f.tableExists("inet", table) {
+		return nil
+	}
+
+	return f.run("delete", "table", "inet", table)
+}
+
+// NetworkSetupServices allows DHCP and DNS. nftables can't fill in checksums,
+// so the fix for broken DHCP clients still goes through iptables when the tool
+// is around.
+func (f *firewallNftables) NetworkSetupServices(family string, netName string, dhcp bool) error {
+	err := f.networkSetupTable(netName)
+	if err != nil {
+		return err
+	}
+
+	dhcpPort := "67"
+	if family == "ipv6" {
+		dhcpPort = "546"
+	}
+
+	rules := [][]string{
+		{"input", "iifname", "udp", "dport", "53"},
+		{"input", "iifname", "tcp", "dport", "53"},
+		{"output", "oifname", "udp", "sport", "53"},
+		{"output", "oifname", "tcp", "sport", "53"}}
+
+	if dhcp {
+		rules = append(rules, [][]string{
+			{"input", "iifname", "udp", "dport", dhcpPort},
+			{"output", "oifname", "udp", "sport", dhcpPort}}...)
+	}
+
+	table := f.tableName(netName)
+	for _, rule := range rules {
+		err = f.run("add", "rule", "inet", table, rule[0], "meta", "nfproto", family, rule[1], f.quote(netName), rule[2], rule[3], rule[4], "accept")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Workaround for broken DHCP clients
+	if family == "ipv4" && dhcp && f.hasIptables() {
+		err = networkIptablesPrepend("ipv4", netName, "mangle", "POSTROUTING", "-o", netName, "-p", "udp", "--dport", "68", "-j", "CHECKSUM", "--checksum-fill")
+		if err != nil {
+			shared.LogWarn("Unable to set up the DHCP checksum fix", log.Ctx{"network": netName, "err": err})
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallNftables) hasIptables() bool {
+	_, err := exec.LookPath("iptables")
+	return err == nil
+}
+
+func (f *firewallNftables) NetworkSetupForward(family string, netName string, accept bool) error {
+	err := f.networkSetupTable(netName)
+	if err != nil {
+		return err
+	}
+
+	verdict := "reject"
+	if accept {
+		verdict = "accept"
+	}
+
+	table := f.tableName(netName)
+	for _, match := range []string{"iifname", "oifname"} {
+		err = f.run("add", "rule", "inet", table, "forward", "meta", "nfproto", family, match, f.quote(netName), verdict)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallNftables) NetworkSetupNAT(family string, netName string, subnet *net.IPNet) error {
+	err := f.networkSetupTable(netName)
+	if err != nil {
+		return err
+	}
+
+	prefix := "ip"
+	if family == "ipv6" {
+		prefix = "ip6"
+	}
+
+	return f.run("add", "rule", "inet", f.tableName(netName), "postrouting", prefix, "saddr", subnet.String(), prefix, "daddr", "!=", subnet.String(), "masquerade")
+}
+
+func (f *firewallNftables) NetworkSetupACLs(netName string, acls []*api.NetworkACL) error {
+	err := f.networkSetupTable(netName)
+	if err != nil {
+		return err
+	}
+
+	table := f.tableName(netName)
+	err = f.run("flush", "chain", "inet", table, "acl")
+	if err != nil {
+		return err
+	}
+
+	// Ingress is the traffic routed out of the bridge, egress the traffic
+	// coming into it.
+	for _, acl := range acls {
+		directions := map[string][]api.NetworkACLRule{"oifname": acl.Ingress, "iifname": acl.Egress}
+		for _, match := range []string{"oifname", "iifname"} {
+			for _, rule := range directions[match] {
+				for _, family := range []string{"ipv4", "ipv6"} {
+					args := networkACLRuleNftablesArgs(family, rule)
+					if args == nil {
+						continue
+					}
+
+					args = append([]string{"add", "rule", "inet", table, "acl", "meta", "nfproto", family, match, f.quote(netName)}, args...)
+					err = f.run(args...)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// NetworkSetupForwards renders the forwards into nat chains hooked before the
+// routing decision, for the incoming traffic and for the traffic of the host.
+func (f *firewallNftables) NetworkSetupForwards(netName string, forwards []api.NetworkForward) error {
+	err := f.networkSetupTable(netName)
+	if err != nil {
+		return err
+	}
+
+	table := f.tableName(netName)
+	chains := [][]string{
+		{"fwd_prerouting", "nat", "prerouting", "-100"},
+		{"fwd_output", "nat", "output", "-100"},
+	}
+
+	err = f.setupTable("inet", table, chains)
+	if err != nil {
+		return err
+	}
+
+	for _, chain := range chains {
+		err = f.run("flush", "chain", "inet", table, chain[0])
+		if err != nil {
+			return err
+		}
+
+		for _, forward := range forwards {
+			for _, rule := range networkForwardRules(forward) {
+				args := append([]string{"add", "rule", "inet", table, chain[0]}, networkForwardNftablesArgs(forward.ListenAddress, rule)...)
+				err = f.run(args...)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallNftables) bridgeSetupTable(bridge string) error {
+	chains := [][]string{
+		{"input", "filter", "input", "0"},
+		{"output", "filter", "output", "0"},
+		{"forward", "filter", "forward", "0"},
+	}
+
+	return f.setupTable("bridge", f.tableName(bridge), chains)
+}
+
+func (f *firewallNftables) NicSetupMACFilter(bridge string, hostName string, hwaddr string) error {
+	err := f.bridgeSetupTable(bridge)
+	if err != nil {
+		return err
+	}
+
+	table := f.tableName(bridge)
+	for _, chain := range []string{"forward", "input"} {
+		err = f.run("add", "rule", "bridge", table, chain, "iifname", f.quote(hostName), "ether", "saddr", "!=", hwaddr, "drop")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallNftables) NicClearMACFilter(bridge string, hwaddr string) error {
+	table := f.tableName(bridge)
+	if !f.tableExists("bridge", table) {
+		return nil
+	}
+
+	rules, err := f.listRules("bridge", table, func(rule string) bool {
+		return strings.Contains(rule, fmt.Sprintf("ether saddr != %s ", strings.ToLower(hwaddr)))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		err = f.run("delete", "rule", "bridge", table, rule[0], "handle", rule[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NicSetupACLs renders the ACLs into chains matching on the host side veth, so
+// that the traffic between containers on the same bridge is filtered too.
+// Rejecting isn't possible at that level, so rejected frames are dropped.
+func (f *firewallNftables) NicSetupACLs(cName string, devName string, bridge string, hostName string, acls []*api.NetworkACL) error {
+	err := f.NicClearACLs(cName, devName, bridge)
+	if err != nil {
+		return err
+	}
+
+	if len(acls) == 0 {
+		return nil
+	}
+
+	err = f.bridgeSetupTable(bridge)
+	if err != nil {
+		return err
+	}
+
+	table := f.tableName(bridge)
+
+	// Ingress is the traffic going out of the veth into the container
+	chains := map[string][]string{"in": {"forward", "output"}, "out": {"forward", "input"}}
+	for direction, parents := range chains {
+		chain := networkACLNicChain(cName, devName, direction)
+
+		err = f.run("add", "chain", "bridge", table, chain)
+		if err != nil {
+			return err
+		}
+
+		for _, acl := range acls {
+			rules := acl.Ingress
+			if direction == "out" {
+				rules = acl.Egress
+			}
+
+			for _, rule := range rules {
+				for _, family := range []string{"ipv4", "ipv6"} {
+					args := networkACLRuleNftablesArgs(family, rule)
+					if args == nil {
+						continue
+					}
+
+					if args[len(args)-1] == "reject" {
+						args[len(args)-1] = "drop"
+					}
+
+					etherType := "ip"
+					if family == "ipv6" {
+						etherType = "ip6"
+					}
+
+					args = append([]string{"add", "rule", "bridge", table, chain, "ether", "type", etherType}, args...)
+					err = f.run(args...)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		match := "oifname"
+		if direction == "out" {
+			match = "iifname"
+		}
+
+		for _, parent := range parents {
+			err = f.run("add", "rule", "bridge", table, parent, match, f.quote(hostName), "jump", chain)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallNftables) NicClearACLs(cName string, devName string, bridge string) error {
+	table := f.tableName(bridge)
+	if !f.tableExists("bridge", table) {
+		return nil
+	}
+
+	for _, direction := range []string{"in", "out"} {
+		chain := networkACLNicChain(cName, devName, direction)
+		if !f.chainExists("bridge", table, chain) {
+			continue
+		}
+
+		// Remove the jumps to the chain
+		rules, err := f.listRules("bridge", table, func(rule string) bool {
+			return strings.HasSuffix(rule, fmt.Sprintf("jump %s", chain))
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, rule := range rules {
+			err = f.run("delete", "rule", "bridge", table, rule[0], "handle", rule[1])
+			if err != nil {
+				return err
+			}
+		}
+
+		err = f.run("delete", "chain", "bridge", table, chain)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
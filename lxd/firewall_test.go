@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_firewallLoad(t *testing.T) {
+	for _, name := range []string{"xtables", "nftables"} {
+		fw, err := firewallLoad(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fw.String() != name {
+			t.Errorf("Loaded %s instead of %s", fw.String(), name)
+		}
+	}
+
+	fw, err := firewallLoad("auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fw.String() != firewallDetect() {
+		t.Errorf("Auto-detection picked %s instead of %s", fw.String(), firewallDetect())
+	}
+
+	_, err = firewallLoad("pf")
+	if err == nil {
+		t.Errorf("Unknown backend should be refused")
+	}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// firewallXtables generates the rules through iptables, ip6tables and
+// ebtables. The network rules are tagged with a comment so they can be found
+// and removed again.
+type firewallXtables struct{}
+
+func (f *firewallXtables) String() string {
+	return "xtables"
+}
+
+func (f *firewallXtables) NetworkClear(netName string) error {
+	tables := [][]string{{"ipv4", ""}, {"ipv4", "mangle"}, {"ipv4", "nat"}, {"ipv6", ""}, {"ipv6", "nat"}}
+	for _, table := range tables {
+		err := networkIptablesClear(table[0], netName, table[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, family := range []string{"ipv4", "ipv6"} {
+		if !shared.PathExists("/proc/sys/net/ipv6") && family == "ipv6" {
+			continue
+		}
+
+		err := f.networkClearACLs(family, netName)
+		if err != nil {
+			return err
+		}
+
+		err = f.networkClearForwards(family, netName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallXtables) NetworkSetupServices(family string, netName string, dhcp bool) error {
+	dhcpPort := "67"
+	if family == "ipv6" {
+		dhcpPort = "546"
+	}
+
+	rules := [][]string{
+		{"INPUT", "-i", netName, "-p", "udp", "--dport", "53", "-j", "ACCEPT"},
+		{"INPUT", "-i", netName, "-p", "tcp", "--dport", "53", "-j", "ACCEPT"},
+		{"OUTPUT", "-o", netName, "-p", "udp", "--sport", "53", "-j", "ACCEPT"},
+		{"OUTPUT", "-o", netName, "-p", "tcp", "--sport", "53", "-j", "ACCEPT"}}
+
+	if dhcp {
+		rules = append(rules, [][]string{
+			{"INPUT", "-i", netName, "-p", "udp", "--dport", dhcpPort, "-j", "ACCEPT"},
+			{"OUTPUT", "-o", netName, "-p", "udp", "--sport", dhcpPort, "-j", "ACCEPT"}}...)
+	}
+
+	for _, rule := range rules {
+		err := networkIptablesPrepend(family, netName, "", rule[0], rule[1:]...)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Workaround for broken DHCP clients
+	if family == "ipv4" && dhcp {
+		err := networkIptablesPrepend("ipv4", netName, "mangle", "POSTROUTING", "-o", netName, "-p", "udp", "--dport", "68", "-j", "CHECKSUM", "--checksum-fill")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallXtables) NetworkSetupForward(family string, netName string, accept bool) error {
+	target := "REJECT"
+	if accept {
+		target = "ACCEPT"
+	}
+
+	err := networkIptablesPrepend(family, netName, "", "FORWARD", "-i", netName, "-j", target)
+	if err != nil {
+		return err
+	}
+
+	return networkIptablesPrepend(family, netName, "", "FORWARD", "-o", netName, "-j", target)
+}
+
+func (f *firewallXtables) NetworkSetupNAT(family string, netName string, subnet *net.IPNet) error {
+	return networkIptablesPrepend(family, netName, "nat", "POSTROUTING", "-s", subnet.String(), "!", "-d", subnet.String(), "-j", "MASQUERADE")
+}
+
+// NetworkSetupACLs renders the ACLs into a chain of their own, jumped to from
+// FORWARD for the traffic routed in and out of the bridge.
+func (f *firewallXtables) NetworkSetupACLs(netName string, acls []*api.NetworkACL) error {
+	chain := f.networkACLChain(netName)
+
+	for _, family := range []string{"ipv4", "ipv6"} {
+		// Detect kernels that lack IPv6 support
+		if !shared.PathExists("/proc/sys/net/ipv6") && family == "ipv6" {
+			continue
+		}
+
+		if len(acls) == 0 {
+			err := f.networkClearACLs(family, netName)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		cmd := "iptables"
+		if family == "ipv6" {
+			cmd = "ip6tables"
+		}
+
+		// Create the chain or flush the existing one
+		_, err := shared.RunCommand(cmd, "-w", "-L", chain, "-n")
+		if err != nil {
+			_, err = shared.RunCommand(cmd, "-w", "-N", chain)
+		} else {
+			_, err = shared.RunCommand(cmd, "-w", "-F", chain)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Ingress is the traffic routed out of the bridge, egress the
+		// traffic coming into it.
+		for _, acl := range acls {
+			directions := map[string][]api.NetworkACLRule{"-o": acl.Ingress, "-i": acl.Egress}
+			for _, flag := range []string{"-o", "-i"} {
+				for _, rule := range directions[flag] {
+					args := networkACLRuleIptablesArgs(family, rule)
+					if args == nil {
+						continue
+					}
+
+					args = append([]string{"-w", "-A", chain, flag, netName}, args...)
+					_, err = shared.RunCommand(cmd, args...)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		// The jumps are tagged as the other rules of the network so they
+		// get cleared along with them.
+		for _, flag := range []string{"-i", "-o"} {
+			err = networkIptablesPrepend(family, netName, "", "FORWARD", flag, netName, "-j", chain)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallXtables) networkACLChain(netName string) string {
+	return fmt.Sprintf("lxd_acl_%s", netName)
+}
+
+func (f *firewallXtables) networkClearACLs(family string, netName string) error {
+	cmd := "iptables"
+	if family == "ipv6" {
+		cmd = "ip6tables"
+	}
+
+	chain := f.networkACLChain(netName)
+
+	// Check whether the chain exists
+	_, err := shared.RunCommand(cmd, "-w", "-L", chain, "-n")
+	if err != nil {
+		return nil
+	}
+
+	// Remove the jumps to the chain, if still there
+	for _, flag := range []string{"-i", "-o"} {
+		shared.RunCommand(cmd, "-w", "-D", "FORWARD", flag, netName, "-j", chain, "-m", "comment", "--comment", fmt.Sprintf("generated for LXD network %s", netName))
+	}
+
+	_, err = shared.RunCommand(cmd, "-w", "-F", chain)
+	if err != nil {
+		return err
+	}
+
+	_, err = shared.RunCommand(cmd, "-w", "-X", chain)
+	return err
+}
+
+// NetworkSetupForwards renders the forwards into a nat chain of their own,
+// jumped to from PREROUTING and from OUTPUT for the traffic of the host.
+func (f *firewallXtables) NetworkSetupForwards(netName string, forwards []api.NetworkForward) error {
+	chain := f.networkForwardChain(netName)
+
+	for _, family := range []string{"ipv4", "ipv6"} {
+		// Detect kernels that lack IPv6 support
+		if !shared.PathExists("/proc/sys/net/ipv6") && family == "ipv6" {
+			continue
+		}
+
+		if len(forwards) == 0 {
+			err := f.networkClearForwards(family, netName)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		cmd := "iptables"
+		if family == "ipv6" {
+			cmd = "ip6tables"
+		}
+
+		// Create the chain or flush the existing one
+		_, err := shared.RunCommand(cmd, "-w", "-t", "nat", "-L", chain, "-n")
+		if err != nil {
+			_, err = shared.RunCommand(cmd, "-w", "-t", "nat", "-N", chain)
+		} else {
+			_, err = shared.RunCommand(cmd, "-w", "-t", "nat", "-F", chain)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, forward := range forwards {
+			if strings.Contains(forward.ListenAddress, ":") != (family == "ipv6") {
+				continue
+			}
+
+			for _, rule := range networkForwardRules(forward) {
+				args := append([]string{"-w", "-t", "nat", "-A", chain}, networkForwardIptablesArgs(forward.ListenAddress, rule)...)
+				_, err = shared.RunCommand(cmd, args...)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, parent := range []string{"PREROUTING", "OUTPUT"} {
+			err = networkIptablesPrepend(family, netName, "nat", parent, "-j", chain)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallXtables) networkForwardChain(netName string) string {
+	return fmt.Sprintf("lxd_fwd_%s", netName)
+}
+
+func (f *firewallXtables) networkClearForwards(family string, netName string) error {
+	cmd := "iptables"
+	if family == "ipv6" {
+		cmd = "ip6tables"
+	}
+
+	chain := f.networkForwardChain(netName)
+
+	// Check whether the chain exists
+	_, err := shared.RunCommand(cmd, "-w", "-t", "nat", "-L", chain, "-n")
+	if err != nil {
+		return nil
+	}
+
+	// Remove the jumps to the chain, if still there
+	for _, parent := range []string{"PREROUTING", "OUTPUT"} {
+		shared.RunCommand(cmd, "-w", "-t", "nat", "-D", parent, "-j", chain, "-m", "comment", "--comment", fmt.Sprintf("generated for LXD network %s", netName))
+	}
+
+	_, err = shared.RunCommand(cmd, "-w", "-t", "nat", "-F", chain)
+	if err != nil {
+		return err
+	}
+
+	_, err = shared.RunCommand(cmd, "-w", "-t", "nat", "-X", chain)
+	return err
+}
+
+func (f *firewallXtables) NicSetupMACFilter(bridge string, hostName string, hwaddr string) error {
+	_, err := shared.RunCommand("ebtables", "-A", "FORWARD", "-s", "!", hwaddr, "-i", hostName, "-o", bridge, "-j", "DROP")
+	if err != nil {
+		return err
+	}
+
+	_, err = shared.RunCommand("ebtables", "-A", "INPUT", "-s", "!", hwaddr, "-i", hostName, "-j", "DROP")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (f *firewallXtables) NicClearMACFilter(bridge string, hwaddr string) error {
+	out, err := shared.RunCommand("ebtables", "-L", "--Lmac2", "--Lx")
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+
+		if len(fields) == 12 {
+			match := []string{"ebtables", "-t", "filter", "-A", "INPUT", "-s", "!", hwaddr, "-i", fields[9], "-j", "DROP"}
+			if reflect.DeepEqual(fields, match) {
+				fields[3] = "-D"
+				_, err = shared.RunCommand(fields[0], fields[1:]...)
+				if err != nil {
+					return err
+				}
+			}
+		} else if len(fields) == 14 {
+			match := []string{"ebtables", "-t", "filter", "-A", "FORWARD", "-s", "!", hwaddr, "-i", fields[9], "-o", bridge, "-j", "DROP"}
+			if reflect.DeepEqual(fields, match) {
+				fields[3] = "-D"
+				_, err = shared.RunCommand(fields[0], fields[1:]...)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// NicSetupACLs renders the ACLs into ebtables chains matching on the host side
+// veth, so that the traffic between containers on the same bridge is filtered
+// too.
+func (f *firewallXtables) NicSetupACLs(cName string, devName string, bridge string, hostName string, acls []*api.NetworkACL) error {
+	err := f.NicClearACLs(cName, devName, bridge)
+	if err != nil {
+		return err
+	}
+
+	if len(acls) == 0 {
+		return nil
+	}
+
+	// Ingress is the traffic going out of the veth into the container
+	chains := map[string][]string{"in": {"FORWARD", "OUTPUT"}, "out": {"FORWARD", "INPUT"}}
+	for direction, parents := range chains {
+		chain := networkACLNicChain(cName, devName, direction)
+
+		_, err = shared.RunCommand("ebtables", "-N", chain, "-P", "RETURN")
+		if err != nil {
+			return err
+		}
+
+		for _, acl := range acls {
+			rules := acl.Ingress
+			if direction == "out" {
+				rules = acl.Egress
+			}
+
+			for _, rule := range rules {
+				for _, family := range []string{"ipv4", "ipv6"} {
+					for _, args := range networkACLRuleEbtablesArgs(family, rule) {
+						_, err = shared.RunCommand("ebtables", append([]string{"-A", chain}, args...)...)
+						if err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+
+		flag := "-o"
+		if direction == "out" {
+			flag = "-i"
+		}
+
+		for _, parent := range parents {
+			_, err = shared.RunCommand("ebtables", "-A", parent, flag, hostName, "-j", chain)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f *firewallXtables) NicClearACLs(cName string, devName string, bridge string) error {
+	output, err := shared.RunCommand("ebtables", "-L", "--Lx")
+	if err != nil {
+		return err
+	}
+
+	for _, direction := range []string{"in", "out"} {
+		chain := networkACLNicChain(cName, devName, direction)
+		if !strings.Contains(output, chain) {
+			continue
+		}
+
+		// Remove the jumps to the chain
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 5 || fields[len(fields)-2] != "-j" || fields[len(fields)-1] != chain {
+				continue
+			}
+
+			// ebtables -t filter -A <parent> ...
+			fields[3] = "-D"
+			_, err = shared.RunCommand(fields[0], fields[1:]...)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = shared.RunCommand("ebtables", "-X", chain)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
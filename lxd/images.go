@@ -52,6 +52,7 @@ func detectCompression(fname string) ([]string, string, error) {
 	// gz - 2 bytes, 0x1f 0x8b
 	// lzma - 6 bytes, { [0x000, 0xE0], '7', 'z', 'X', 'Z', 0x00 } -
 	// xy - 6 bytes,  header format { 0xFD, '7', 'z', 'X', 'Z', 0x00 }
+	// zstd - 4 bytes, 0x28 0xb5 0x2f 0xfd
 	// tar - 263 bytes, trying to get ustar from 257 - 262
 	header := make([]byte, 263)
 	_, err = f.Read(header)
@@ -70,6 +71,9 @@ func detectCompression(fname string) ([]string, string, error) {
 		return []string{"--lzma", "-xf"}, ".tar.lzma", nil
 	case bytes.Equal(header[0:3], []byte{0x5d, 0x00, 0x00}):
 		return []string{"--lzma", "-xf"}, ".tar.lzma", nil
+	case bytes.Equal(header[0:4], []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		// tar only knows about --zstd since 1.31
+		return []string{"--use-compress-program=zstd", "-xf"}, ".tar.zst", nil
 	case bytes.Equal(header[257:262], []byte{'u', 's', 't', 'a', 'r'}):
 		return []string{"-xf"}, ".tar", nil
 	case bytes.Equal(header[0:4], []byte{'h', 's', 'q', 's'}):
@@ -129,7 +133,7 @@ func unpack(d *Daemon, file string, path string, sType storageType) error {
 
 		// Check if we're running out of space
 		if int64(fs.Bfree) < int64(2*fs.Bsize) {
-			if sType == storageTypeLvm {
+			if sType == storageTypeLvm || sType == storageTypeCeph {
 				return fmt.Errorf("Unable to unpack image, run out of disk space (consider increasing your pool's volume.size).")
 			} else {
 				return fmt.Errorf("Unable to unpack image, run out of disk space.")
@@ -175,14 +179,64 @@ func unpackImage(d *Daemon, imagefname string, destpath string, sType storageTyp
 	return nil
 }
 
+// compressionLevels lists the range of levels supported by the compression
+// algorithms which can be given one.
+var compressionLevels = map[string][2]int{
+	"bzip2": {1, 9},
+	"gzip":  {1, 9},
+	"lzma":  {0, 9},
+	"xz":    {0, 9},
+	"zstd":  {1, 22},
+}
+
+// compressionParse splits a compression setting such as "zstd:19" into the
+// algorithm and its level, -1 meaning the default level.
+func compressionParse(compress string) (string, int, error) {
+	fields := strings.SplitN(compress, ":", 2)
+	if len(fields) == 1 {
+		return fields[0], -1, nil
+	}
+
+	algorithm := fields[0]
+	levels, ok := compressionLevels[algorithm]
+	if !ok {
+		return "", -1, fmt.Errorf("Compression levels aren't supported for \"%s\"", algorithm)
+	}
+
+	level, err := strconv.Atoi(fields[1])
+	if err != nil || level < levels[0] || level > levels[1] {
+		return "", -1, fmt.Errorf("Invalid %s compression level \"%s\", must be between %d and %d", algorithm, fields[1], levels[0], levels[1])
+	}
+
+	return algorithm, level, nil
+}
+
 func compressFile(path string, compress string) (string, error) {
 	reproducible := []string{"gzip"}
 
+	compress, level, err := compressionParse(compress)
+	if err != nil {
+		return "", err
+	}
+
 	args := []string{path, "-c"}
 	if shared.StringInSlice(compress, reproducible) {
 		args = append(args, "-n")
 	}
 
+	if compress == "zstd" {
+		args = append(args, "-q")
+
+		// Levels above 19 use a lot more memory and need to be requested
+		if level > 19 {
+			args = append(args, "--ultra")
+		}
+	}
+
+	if level >= 0 {
+		args = append(args, fmt.Sprintf("-%d", level))
+	}
+
 	cmd := exec.Command(compress, args...)
 
 	outfile, err := os.Create(path + ".compressed")
@@ -221,7 +275,7 @@ type imageMetadata struct {
  * This function takes a container or snapshot from the local image server and
  * exports it as an image.
  */
-func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir string) (*api.Image, error) {
+func imgPostContInfo(d *Daemon, r *http.Request, project string, req api.ImagesPost, builddir string) (*api.Image, error) {
 	info := api.Image{}
 	info.Properties = map[string]string{}
 	name := req.Source.Name
@@ -251,7 +305,7 @@ func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir st
 		info.Public = false
 	}
 
-	c, err := containerLoadByName(d, name)
+	c, err := containerLoadByName(d, projectPrefix(projectParam(r), name))
 	if err != nil {
 		return nil, err
 	}
@@ -274,6 +328,11 @@ func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir st
 
 	if req.CompressionAlgorithm != "" {
 		compress = req.CompressionAlgorithm
+
+		err = daemonConfigValidateCompression(d, "compression_algorithm", compress)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		compress = daemonConfig["images.compression_algorithm"].Get()
 	}
@@ -302,7 +361,7 @@ func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir st
 
 	info.Fingerprint = fmt.Sprintf("%x", sha256.Sum(nil))
 
-	_, _, err = dbImageGet(d.db, info.Fingerprint, false, true)
+	_, _, err = dbImageGet(d.db, project, info.Fingerprint, false, true)
 	if err == nil {
 		return nil, fmt.Errorf("The image already exists: %s", info.Fingerprint)
 	}
@@ -318,15 +377,17 @@ func imgPostContInfo(d *Daemon, r *http.Request, req api.ImagesPost, builddir st
 	info.Properties = req.Properties
 
 	// Create the database entry
-	err = dbImageInsert(d.db, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
+	err = dbImageInsert(d.db, project, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
 	if err != nil {
 		return nil, err
 	}
 
+	imageStreamsImport(info.Fingerprint)
+
 	return &info, nil
 }
 
-func imgPostRemoteInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image, error) {
+func imgPostRemoteInfo(d *Daemon, project string, req api.ImagesPost, op *operation) (*api.Image, error) {
 	var err error
 	var hash string
 
@@ -338,12 +399,12 @@ func imgPostRemoteInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image
 		return nil, fmt.Errorf("must specify one of alias or fingerprint for init from image")
 	}
 
-	info, err := d.ImageDownload(op, req.Source.Server, req.Source.Protocol, req.Source.Certificate, req.Source.Secret, hash, false, req.AutoUpdate, "")
+	info, err := d.ImageDownload(op, project, req.Source.Server, req.Source.Protocol, req.Source.Certificate, req.Source.SigningKeys, req.Source.Secret, hash, false, req.AutoUpdate, "")
 	if err != nil {
 		return nil, err
 	}
 
-	id, info, err := dbImageGet(d.db, info.Fingerprint, false, true)
+	id, info, err := dbImageGet(d.db, project, info.Fingerprint, false, true)
 	if err != nil {
 		return nil, err
 	}
@@ -364,7 +425,7 @@ func imgPostRemoteInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image
 	return info, nil
 }
 
-func imgPostURLInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image, error) {
+func imgPostURLInfo(d *Daemon, project string, req api.ImagesPost, op *operation) (*api.Image, error) {
 	var err error
 
 	if req.Source.URL == "" {
@@ -407,12 +468,12 @@ func imgPostURLInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image, e
 	}
 
 	// Import the image
-	info, err := d.ImageDownload(op, url, "direct", "", "", hash, false, req.AutoUpdate, "")
+	info, err := d.ImageDownload(op, project, url, "direct", "", "", "", hash, false, req.AutoUpdate, "")
 	if err != nil {
 		return nil, err
 	}
 
-	id, info, err := dbImageGet(d.db, info.Fingerprint, false, false)
+	id, info, err := dbImageGet(d.db, project, info.Fingerprint, false, false)
 	if err != nil {
 		return nil, err
 	}
@@ -432,7 +493,7 @@ func imgPostURLInfo(d *Daemon, req api.ImagesPost, op *operation) (*api.Image, e
 	return info, nil
 }
 
-func getImgPostInfo(d *Daemon, r *http.Request, builddir string, post *os.File) (*api.Image, error) {
+func getImgPostInfo(d *Daemon, r *http.Request, project string, builddir string, post *os.File) (*api.Image, error) {
 	info := api.Image{}
 	var imageMeta *imageMetadata
 	logger := logging.AddContext(shared.Log, log.Ctx{"function": "getImgPostInfo"})
@@ -620,11 +681,13 @@ func getImgPostInfo(d *Daemon, r *http.Request, builddir string, post *os.File)
 	}
 
 	// Create the database entry
-	err = dbImageInsert(d.db, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
+	err = dbImageInsert(d.db, project, info.Fingerprint, info.Filename, info.Size, info.Public, info.AutoUpdate, info.Architecture, info.CreatedAt, info.ExpiresAt, info.Properties)
 	if err != nil {
 		return nil, err
 	}
 
+	imageStreamsImport(info.Fingerprint)
+
 	return &info, nil
 }
 
@@ -656,6 +719,11 @@ func imageCreateInPool(d *Daemon, info *api.Image, storagePool string) error {
 func imagesPost(d *Daemon, r *http.Request) Response {
 	var err error
 
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// create a directory under which we keep everything while building
 	builddir, err := ioutil.TempDir(shared.VarPath("images"), "lxd_build_")
 	if err != nil {
@@ -714,20 +782,20 @@ func imagesPost(d *Daemon, r *http.Request) Response {
 		if !imageUpload {
 			if req.Source.Type == "image" {
 				/* Processing image copy from remote */
-				info, err = imgPostRemoteInfo(d, req, op)
+				info, err = imgPostRemoteInfo(d, project, req, op)
 				if err != nil {
 					return err
 				}
 			} else if req.Source.Type == "url" {
 				/* Processing image copy from URL */
-				info, err = imgPostURLInfo(d, req, op)
+				info, err = imgPostURLInfo(d, project, req, op)
 				if err != nil {
 					return err
 				}
 			} else {
 				/* Processing image creation from container */
 				imagePublishLock.Lock()
-				info, err = imgPostContInfo(d, r, req, builddir)
+				info, err = imgPostContInfo(d, r, project, req, builddir)
 				if err != nil {
 					imagePublishLock.Unlock()
 					return err
@@ -736,7 +804,7 @@ func imagesPost(d *Daemon, r *http.Request) Response {
 			}
 		} else {
 			/* Processing image upload */
-			info, err = getImgPostInfo(d, r, builddir, post)
+			info, err = getImgPostInfo(d, r, project, builddir, post)
 			if err != nil {
 				return err
 			}
@@ -744,22 +812,29 @@ func imagesPost(d *Daemon, r *http.Request) Response {
 
 		// Apply any provided alias
 		for _, alias := range req.Aliases {
-			_, _, err := dbImageAliasGet(d.db, alias.Name, true)
+			_, _, err := dbImageAliasGet(d.db, project, alias.Name, true)
 			if err == nil {
 				return fmt.Errorf("Alias already exists: %s", alias.Name)
 			}
 
-			id, _, err := dbImageGet(d.db, info.Fingerprint, false, false)
+			id, _, err := dbImageGet(d.db, project, info.Fingerprint, false, false)
 			if err != nil {
 				return err
 			}
 
-			err = dbImageAliasAdd(d.db, alias.Name, id, alias.Description)
+			err = dbImageAliasAdd(d.db, project, alias.Name, id, alias.Description)
 			if err != nil {
 				return err
 			}
 		}
 
+		// Have the other cluster members pull the new image
+		if !clusterIsForwarded(d, r) {
+			image := *info
+			image.Aliases = req.Aliases
+			clusterReplicateImage(d, project, image)
+		}
+
 		// Set the metadata
 		metadata := make(map[string]string)
 		metadata["fingerprint"] = info.Fingerprint
@@ -819,8 +894,8 @@ func getImageMetadata(fname string) (*imageMetadata, error) {
 	return &metadata, nil
 }
 
-func doImagesGet(d *Daemon, recursion bool, public bool) (interface{}, error) {
-	results, err := dbImagesGet(d.db, public)
+func doImagesGet(d *Daemon, project string, recursion bool, public bool) (interface{}, error) {
+	results, err := dbImagesGet(d.db, project, public)
 	if err != nil {
 		return []string{}, err
 	}
@@ -830,10 +905,10 @@ func doImagesGet(d *Daemon, recursion bool, public bool) (interface{}, error) {
 	i := 0
 	for _, name := range results {
 		if !recursion {
-			url := fmt.Sprintf("/%s/images/%s", version.APIVersion, name)
+			url := fmt.Sprintf("/%s/images/%s%s", version.APIVersion, name, projectQuery(project))
 			resultString[i] = url
 		} else {
-			image, response := doImageGet(d, name, public)
+			image, response := doImageGet(d, project, name, public)
 			if response != nil {
 				continue
 			}
@@ -853,7 +928,12 @@ func doImagesGet(d *Daemon, recursion bool, public bool) (interface{}, error) {
 func imagesGet(d *Daemon, r *http.Request) Response {
 	public := !d.isTrustedClient(r)
 
-	result, err := doImagesGet(d, d.isRecursionRequest(r), public)
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	result, err := doImagesGet(d, project, d.isRecursionRequest(r), public)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -865,92 +945,120 @@ var imagesCmd = Command{name: "images", post: imagesPost, untrustedGet: true, ge
 func autoUpdateImages(d *Daemon) {
 	shared.LogInfof("Updating images")
 
-	images, err := dbImagesGet(d.db, false)
+	projects, err := dbProjects(d.db)
+	if err != nil {
+		shared.LogError("Unable to retrieve the list of projects", log.Ctx{"err": err})
+		return
+	}
+
+	for _, project := range projects {
+		images, err := dbImagesGet(d.db, project, false)
+		if err != nil {
+			shared.LogError("Unable to retrieve the list of images", log.Ctx{"err": err, "project": project})
+			return
+		}
+
+		for _, fp := range images {
+			autoUpdateImage(d, project, fp)
+		}
+	}
+
+	shared.LogInfof("Done updating images")
+}
+
+func autoUpdateImage(d *Daemon, project string, fp string) {
+	id, info, err := dbImageGet(d.db, project, fp, false, true)
+	if err != nil {
+		shared.LogError("Error loading image", log.Ctx{"err": err, "fp": fp})
+		return
+	}
+
+	if !info.AutoUpdate {
+		return
+	}
+
+	_, source, err := dbImageSourceGet(d.db, id)
+	if err != nil {
+		return
+	}
+
+	// Get the IDs of all storage pools on which a storage volume
+	// for the requested image currently exists.
+	poolIDs, err := dbImageGetPools(d.db, fp)
+	if err != nil {
+		return
+	}
+
+	// Translate the IDs to poolNames.
+	poolNames, err := dbImageGetPoolNamesFromIDs(d.db, poolIDs)
 	if err != nil {
-		shared.LogError("Unable to retrieve the list of images", log.Ctx{"err": err})
 		return
 	}
 
-	for _, fp := range images {
-		id, info, err := dbImageGet(d.db, fp, false, true)
+	// If no optimized pools at least update the base store
+	if len(poolNames) == 0 {
+		poolNames = append(poolNames, "")
+	}
+
+	// Other projects may still use the old image files
+	projects, err := dbImageGetProjects(d.db, fp)
+	if err != nil {
+		return
+	}
+
+	inUse := len(projects) > 1
+
+	shared.LogDebug("Processing image", log.Ctx{"fp": fp, "server": source.Server, "protocol": source.Protocol, "alias": source.Alias})
+
+	// Update the image on each pool where it currently exists.
+	hash := fp
+	for _, poolName := range poolNames {
+		newInfo, err := d.ImageDownload(nil, project, source.Server, source.Protocol, "", source.SigningKeys, "", source.Alias, false, true, poolName)
 		if err != nil {
-			shared.LogError("Error loading image", log.Ctx{"err": err, "fp": fp})
+			shared.LogError("Failed to update the image", log.Ctx{"err": err, "fp": fp})
 			continue
 		}
 
-		if !info.AutoUpdate {
+		hash = newInfo.Fingerprint
+		if hash == fp {
+			shared.LogDebug("Already up to date", log.Ctx{"fp": fp})
 			continue
 		}
 
-		_, source, err := dbImageSourceGet(d.db, id)
+		newId, _, err := dbImageGet(d.db, project, hash, false, true)
 		if err != nil {
+			shared.LogError("Error loading image", log.Ctx{"err": err, "fp": hash})
 			continue
 		}
 
-		// Get the IDs of all storage pools on which a storage volume
-		// for the requested image currently exists.
-		poolIDs, err := dbImageGetPools(d.db, fp)
+		err = dbImageLastAccessUpdate(d.db, hash, info.LastUsedAt)
 		if err != nil {
+			shared.LogError("Error setting last use date", log.Ctx{"err": err, "fp": hash})
 			continue
 		}
 
-		// Translate the IDs to poolNames.
-		poolNames, err := dbImageGetPoolNamesFromIDs(d.db, poolIDs)
+		err = dbImageAliasesMove(d.db, id, newId)
 		if err != nil {
+			shared.LogError("Error moving aliases", log.Ctx{"err": err, "fp": hash})
 			continue
 		}
 
-		// If no optimized pools at least update the base store
-		if len(poolNames) == 0 {
-			poolNames = append(poolNames, "")
+		if inUse {
+			continue
 		}
 
-		shared.LogDebug("Processing image", log.Ctx{"fp": fp, "server": source.Server, "protocol": source.Protocol, "alias": source.Alias})
-
-		// Update the image on each pool where it currently exists.
-		hash := fp
-		for _, poolName := range poolNames {
-			newInfo, err := d.ImageDownload(nil, source.Server, source.Protocol, "", "", source.Alias, false, true, poolName)
-			if err != nil {
-				shared.LogError("Failed to update the image", log.Ctx{"err": err, "fp": fp})
-				continue
-			}
-
-			hash = newInfo.Fingerprint
-			if hash == fp {
-				shared.LogDebug("Already up to date", log.Ctx{"fp": fp})
-				continue
-			}
-
-			newId, _, err := dbImageGet(d.db, hash, false, true)
-			if err != nil {
-				shared.LogError("Error loading image", log.Ctx{"err": err, "fp": hash})
-				continue
-			}
-
-			err = dbImageLastAccessUpdate(d.db, hash, info.LastUsedAt)
-			if err != nil {
-				shared.LogError("Error setting last use date", log.Ctx{"err": err, "fp": hash})
-				continue
-			}
-
-			err = dbImageAliasesMove(d.db, id, newId)
-			if err != nil {
-				shared.LogError("Error moving aliases", log.Ctx{"err": err, "fp": hash})
-				continue
-			}
-
-			err = doDeleteImageFromPool(d, fp, poolName)
-			if err != nil {
-				shared.LogError("Error deleting image", log.Ctx{"err": err, "fp": fp})
-			}
+		err = doDeleteImageFromPool(d, fp, poolName)
+		if err != nil {
+			shared.LogError("Error deleting image", log.Ctx{"err": err, "fp": fp})
 		}
+	}
 
-		// Image didn't change, move on
-		if hash == fp {
-			continue
-		}
+	// Image didn't change, move on
+	if hash == fp {
+		return
+	}
 
+	if !inUse {
 		// Remove main image file.
 		fname := shared.VarPath("images", fp)
 		if shared.PathExists(fname) {
@@ -968,40 +1076,66 @@ func autoUpdateImages(d *Daemon) {
 				shared.LogDebugf("Error deleting image file %s: %s", fname, err)
 			}
 		}
-
-		// Remove the database entry for the image.
-		if err = dbImageDelete(d.db, id); err != nil {
-			shared.LogDebugf("Error deleting image from database %s: %s", fname, err)
-		}
 	}
 
-	shared.LogInfof("Done updating images")
+	// Remove the database entry for the image.
+	if err = dbImageDelete(d.db, id); err != nil {
+		shared.LogDebugf("Error deleting image %s from database: %s", fp, err)
+	}
 }
 
 func pruneExpiredImages(d *Daemon) {
 	shared.LogInfof("Pruning expired images")
 
+	projects, err := dbProjects(d.db)
+	if err != nil {
+		shared.LogError("Unable to retrieve the list of projects", log.Ctx{"err": err})
+		return
+	}
+
 	// Get the list of expired images.
 	expiry := daemonConfig["images.remote_cache_expiry"].GetInt64()
-	images, err := dbImagesGetExpired(d.db, expiry)
+	for _, project := range projects {
+		images, err := dbImagesGetExpired(d.db, project, expiry)
+		if err != nil {
+			shared.LogError("Unable to retrieve the list of expired images", log.Ctx{"err": err, "project": project})
+			return
+		}
+
+		// Delete them
+		for _, fp := range images {
+			pruneExpiredImage(d, project, fp)
+		}
+	}
+
+	shared.LogInfof("Done pruning expired images")
+}
+
+func pruneExpiredImage(d *Daemon, project string, fp string) {
+	imgID, _, err := dbImageGet(d.db, project, fp, false, false)
 	if err != nil {
-		shared.LogError("Unable to retrieve the list of expired images", log.Ctx{"err": err})
+		shared.LogDebugf("Error retrieving image info %s: %s", fp, err)
 		return
 	}
 
-	// Delete them
-	for _, fp := range images {
+	// Other projects may still use the image files
+	projects, err := dbImageGetProjects(d.db, fp)
+	if err != nil {
+		return
+	}
+
+	if len(projects) == 1 {
 		// Get the IDs of all storage pools on which a storage volume
 		// for the requested image currently exists.
 		poolIDs, err := dbImageGetPools(d.db, fp)
 		if err != nil {
-			continue
+			return
 		}
 
 		// Translate the IDs to poolNames.
 		poolNames, err := dbImageGetPoolNamesFromIDs(d.db, poolIDs)
 		if err != nil {
-			continue
+			return
 		}
 
 		for _, pool := range poolNames {
@@ -1029,19 +1163,12 @@ func pruneExpiredImages(d *Daemon) {
 				shared.LogDebugf("Error deleting image file %s: %s", fname, err)
 			}
 		}
-
-		imgID, _, err := dbImageGet(d.db, fp, false, false)
-		if err != nil {
-			shared.LogDebugf("Error retrieving image info %s: %s", fp, err)
-		}
-
-		// Remove the database entry for the image.
-		if err = dbImageDelete(d.db, imgID); err != nil {
-			shared.LogDebugf("Error deleting image %s from database: %s", fp, err)
-		}
 	}
 
-	shared.LogInfof("Done pruning expired images")
+	// Remove the database entry for the image.
+	if err = dbImageDelete(d.db, imgID); err != nil {
+		shared.LogDebugf("Error deleting image %s from database: %s", fp, err)
+	}
 }
 
 func doDeleteImageFromPool(d *Daemon, fingerprint string, storagePool string) error {
@@ -1063,14 +1190,29 @@ func doDeleteImageFromPool(d *Daemon, fingerprint string, storagePool string) er
 func imageDelete(d *Daemon, r *http.Request) Response {
 	fingerprint := mux.Vars(r)["fingerprint"]
 
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	deleteFromAllPools := func() error {
 		// Use the fingerprint we received in a LIKE query and use the full
 		// fingerprint we receive from the database in all further queries.
-		imgID, imgInfo, err := dbImageGet(d.db, fingerprint, false, false)
+		imgID, imgInfo, err := dbImageGet(d.db, project, fingerprint, false, false)
+		if err != nil {
+			return err
+		}
+
+		// Keep the image files around if other projects still use them
+		projects, err := dbImageGetProjects(d.db, imgInfo.Fingerprint)
 		if err != nil {
 			return err
 		}
 
+		if len(projects) > 1 {
+			return dbImageDelete(d.db, imgID)
+		}
+
 		poolIDs, err := dbImageGetPools(d.db, imgInfo.Fingerprint)
 		if err != nil {
 			return err
@@ -1125,8 +1267,8 @@ func imageDelete(d *Daemon, r *http.Request) Response {
 	return OperationResponse(op)
 }
 
-func doImageGet(d *Daemon, fingerprint string, public bool) (*api.Image, Response) {
-	_, imgInfo, err := dbImageGet(d.db, fingerprint, public, false)
+func doImageGet(d *Daemon, project string, fingerprint string, public bool) (*api.Image, Response) {
+	_, imgInfo, err := dbImageGet(d.db, project, fingerprint, public, false)
 	if err != nil {
 		return nil, SmartError(err)
 	}
@@ -1173,7 +1315,16 @@ func imageGet(d *Daemon, r *http.Request) Response {
 		public = false
 	}
 
-	info, response := doImageGet(d, fingerprint, public)
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if clusterIsMemberRequest(d, r) {
+		project = clusterImageProject(d, project, fingerprint)
+	}
+
+	info, response := doImageGet(d, project, fingerprint, public)
 	if response != nil {
 		return response
 	}
@@ -1183,9 +1334,14 @@ func imageGet(d *Daemon, r *http.Request) Response {
 }
 
 func imagePut(d *Daemon, r *http.Request) Response {
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// Get current value
 	fingerprint := mux.Vars(r)["fingerprint"]
-	id, info, err := dbImageGet(d.db, fingerprint, false, false)
+	id, info, err := dbImageGet(d.db, project, fingerprint, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1211,9 +1367,14 @@ func imagePut(d *Daemon, r *http.Request) Response {
 }
 
 func imagePatch(d *Daemon, r *http.Request) Response {
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// Get current value
 	fingerprint := mux.Vars(r)["fingerprint"]
-	id, info, err := dbImageGet(d.db, fingerprint, false, false)
+	id, info, err := dbImageGet(d.db, project, fingerprint, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1288,31 +1449,41 @@ func aliasesPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("name and target are required"))
 	}
 
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// This is just to see if the alias name already exists.
-	_, _, err := dbImageAliasGet(d.db, req.Name, true)
+	_, _, err = dbImageAliasGet(d.db, project, req.Name, true)
 	if err == nil {
 		return Conflict
 	}
 
-	id, _, err := dbImageGet(d.db, req.Target, false, false)
+	id, _, err := dbImageGet(d.db, project, req.Target, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
 
-	err = dbImageAliasAdd(d.db, req.Name, id, req.Description)
+	err = dbImageAliasAdd(d.db, project, req.Name, id, req.Description)
 	if err != nil {
 		return InternalError(err)
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/images/aliases/%s", version.APIVersion, req.Name))
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/images/aliases/%s%s", version.APIVersion, req.Name, projectQuery(project)))
 }
 
 func aliasesGet(d *Daemon, r *http.Request) Response {
 	recursion := d.isRecursionRequest(r)
 
-	q := "SELECT name FROM images_aliases"
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	q := "SELECT name FROM images_aliases WHERE project_id=(SELECT id FROM projects WHERE name=?)"
 	var name string
-	inargs := []interface{}{}
+	inargs := []interface{}{project}
 	outfmt := []interface{}{name}
 	results, err := dbQueryScan(d.db, q, inargs, outfmt)
 	if err != nil {
@@ -1323,11 +1494,11 @@ func aliasesGet(d *Daemon, r *http.Request) Response {
 	for _, res := range results {
 		name = res[0].(string)
 		if !recursion {
-			url := fmt.Sprintf("/%s/images/aliases/%s", version.APIVersion, name)
+			url := fmt.Sprintf("/%s/images/aliases/%s%s", version.APIVersion, name, projectQuery(project))
 			responseStr = append(responseStr, url)
 
 		} else {
-			_, alias, err := dbImageAliasGet(d.db, name, d.isTrustedClient(r))
+			_, alias, err := dbImageAliasGet(d.db, project, name, d.isTrustedClient(r))
 			if err != nil {
 				continue
 			}
@@ -1345,7 +1516,12 @@ func aliasesGet(d *Daemon, r *http.Request) Response {
 func aliasGet(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 
-	_, alias, err := dbImageAliasGet(d.db, name, d.isTrustedClient(r))
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, alias, err := dbImageAliasGet(d.db, project, name, d.isTrustedClient(r))
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1355,12 +1531,18 @@ func aliasGet(d *Daemon, r *http.Request) Response {
 
 func aliasDelete(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
-	_, _, err := dbImageAliasGet(d.db, name, true)
+
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
 	if err != nil {
 		return SmartError(err)
 	}
 
-	err = dbImageAliasDelete(d.db, name)
+	_, _, err = dbImageAliasGet(d.db, project, name, true)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = dbImageAliasDelete(d.db, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1369,9 +1551,14 @@ func aliasDelete(d *Daemon, r *http.Request) Response {
 }
 
 func aliasPut(d *Daemon, r *http.Request) Response {
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// Get current value
 	name := mux.Vars(r)["name"]
-	id, alias, err := dbImageAliasGet(d.db, name, true)
+	id, alias, err := dbImageAliasGet(d.db, project, name, true)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1391,7 +1578,7 @@ func aliasPut(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("The target field is required"))
 	}
 
-	imageId, _, err := dbImageGet(d.db, req.Target, false, false)
+	imageId, _, err := dbImageGet(d.db, project, req.Target, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1405,9 +1592,14 @@ func aliasPut(d *Daemon, r *http.Request) Response {
 }
 
 func aliasPatch(d *Daemon, r *http.Request) Response {
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// Get current value
 	name := mux.Vars(r)["name"]
-	id, alias, err := dbImageAliasGet(d.db, name, true)
+	id, alias, err := dbImageAliasGet(d.db, project, name, true)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1443,7 +1635,7 @@ func aliasPatch(d *Daemon, r *http.Request) Response {
 		alias.Description = description
 	}
 
-	imageId, _, err := dbImageGet(d.db, alias.Target, false, false)
+	imageId, _, err := dbImageGet(d.db, project, alias.Target, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1464,13 +1656,18 @@ func aliasPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	// Check that the name isn't already in use
-	id, _, _ := dbImageAliasGet(d.db, req.Name, true)
+	id, _, _ := dbImageAliasGet(d.db, project, req.Name, true)
 	if id > 0 {
 		return Conflict
 	}
 
-	id, _, err := dbImageAliasGet(d.db, name, true)
+	id, _, err = dbImageAliasGet(d.db, project, name, true)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1480,7 +1677,7 @@ func aliasPost(d *Daemon, r *http.Request) Response {
 		return SmartError(err)
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/images/aliases/%s", version.APIVersion, req.Name))
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/images/aliases/%s%s", version.APIVersion, req.Name, projectQuery(project)))
 }
 
 func imageExport(d *Daemon, r *http.Request) Response {
@@ -1493,7 +1690,16 @@ func imageExport(d *Daemon, r *http.Request) Response {
 		public = false
 	}
 
-	_, imgInfo, err := dbImageGet(d.db, fingerprint, public, false)
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if clusterIsMemberRequest(d, r) {
+		project = clusterImageProject(d, project, fingerprint)
+	}
+
+	_, imgInfo, err := dbImageGet(d.db, project, fingerprint, public, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -1539,7 +1745,13 @@ func imageExport(d *Daemon, r *http.Request) Response {
 
 func imageSecret(d *Daemon, r *http.Request) Response {
 	fingerprint := mux.Vars(r)["fingerprint"]
-	_, _, err := dbImageGet(d.db, fingerprint, false, false)
+
+	project, err := dbProjectEffective(d.db, projectParam(r), "images")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, _, err = dbImageGet(d.db, project, fingerprint, false, false)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/simplestreams"
+)
+
+// The public images of the default project are also served as a read-only
+// simplestreams tree, which LXD clients and regular mirroring tools can use.
+const imageStreamsIndexPath = "streams/v1/index.json"
+const imageStreamsManifestPath = "streams/v1/images.json"
+
+// imageStreamsFile is a file of an image as listed in the manifest.
+type imageStreamsFile struct {
+	name   string
+	path   string
+	ftype  string
+	sha256 string
+	size   int64
+}
+
+// The hashes of the individual files of split images are only computed once.
+type imageStreamsHashes struct {
+	done   chan struct{}
+	hashes []string
+	err    error
+}
+
+var imageStreamsFiles = map[string]*imageStreamsHashes{}
+var imageStreamsFilesLock sync.Mutex
+
+func imageStreamsHashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sha256 := sha256.New()
+	_, err = io.Copy(sha256, f)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum(nil)), nil
+}
+
+// imageStreamsFileType returns the simplestreams file type of a metadata
+// ("lxd") or rootfs ("root") file with the given extension.
+func imageStreamsFileType(kind string, ext string) string {
+	if ext == ".squashfs" {
+		return "squashfs"
+	}
+
+	return kind + ext
+}
+
+// imageStreamsImageFiles returns the metadata file of an image followed by its
+// rootfs, if it's a split image, without their hashes.
+func imageStreamsImageFiles(fingerprint string) ([]imageStreamsFile, error) {
+	files := []imageStreamsFile{}
+
+	metaPath := shared.VarPath("images", fingerprint)
+	rootfsPath := metaPath + ".rootfs"
+	for _, path := range []string{metaPath, rootfsPath} {
+		if path == rootfsPath && !shared.PathExists(rootfsPath) {
+			break
+		}
+
+		_, ext, err := detectCompression(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		file := imageStreamsFile{path: path, size: fi.Size()}
+		if path == metaPath {
+			file.name = fmt.Sprintf("meta%s", ext)
+			file.ftype = imageStreamsFileType("lxd", ext)
+		} else {
+			file.name = fmt.Sprintf("rootfs%s", ext)
+			file.ftype = imageStreamsFileType("root", ext)
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// imageStreamsImport hashes the files of a newly imported image in the
+// background, so they're ready by the time the image gets served.
+func imageStreamsImport(fingerprint string) {
+	go imageStreamsGetFiles(fingerprint)
+}
+
+// imageStreamsGetFiles returns the files of an image along with their hashes.
+// Unified images are hashed by their fingerprint, the files of split images
+// are hashed the first time they're listed.
+func imageStreamsGetFiles(fingerprint string) ([]imageStreamsFile, error) {
+	files, err := imageStreamsImageFiles(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 1 {
+		files[0].sha256 = fingerprint
+		return files, nil
+	}
+
+	imageStreamsFilesLock.Lock()
+	entry, ok := imageStreamsFiles[fingerprint]
+	if !ok {
+		entry = &imageStreamsHashes{done: make(chan struct{})}
+		imageStreamsFiles[fingerprint] = entry
+	}
+	imageStreamsFilesLock.Unlock()
+
+	// Concurrent requests wait for the same hashing
+	if !ok {
+		for _, file := range files {
+			hash, err := imageStreamsHashFile(file.path)
+			if err != nil {
+				entry.err = err
+				break
+			}
+
+			entry.hashes = append(entry.hashes, hash)
+		}
+
+		close(entry.done)
+
+		if entry.err != nil {
+			imageStreamsFilesLock.Lock()
+			delete(imageStreamsFiles, fingerprint)
+			imageStreamsFilesLock.Unlock()
+		}
+	}
+
+	<-entry.done
+	if entry.err != nil {
+		return nil, entry.err
+	}
+
+	for i := range files {
+		files[i].sha256 = entry.hashes[i]
+	}
+
+	return files, nil
+}
+
+type imageStreamsByUpload []api.Image
+
+func (a imageStreamsByUpload) Len() int {
+	return len(a)
+}
+
+func (a imageStreamsByUpload) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+func (a imageStreamsByUpload) Less(i, j int) bool {
+	return a[i].UploadedAt.After(a[j].UploadedAt)
+}
+
+// imageStreamsProduct returns the name of the product an image is a version
+// of. Images without an os and release each get their own product.
+func imageStreamsProduct(image api.Image) string {
+	if image.Properties["os"] == "" || image.Properties["release"] == "" {
+		return fmt.Sprintf("%s:%s", image.Fingerprint, image.Architecture)
+	}
+
+	variant := image.Properties["variant"]
+	if variant == "" {
+		variant = "default"
+	}
+
+	return strings.Join([]string{image.Properties["os"], image.Properties["release"], image.Architecture, variant}, ":")
+}
+
+// imageStreamsManifest renders a list of images as a simplestreams manifest.
+// Aliases only resolve to the latest version of a product on the client side,
+// so only those of the latest version are kept. Unified images are left out as
+// simplestreams clients only know about split images.
+func imageStreamsManifest(images []api.Image, getFiles func(fingerprint string) ([]imageStreamsFile, error)) (*simplestreams.SimpleStreamsManifest, error) {
+	manifest := simplestreams.SimpleStreamsManifest{
+		DataType: "image-downloads",
+		Format:   "products:1.0",
+		Products: map[string]simplestreams.SimpleStreamsManifestProduct{},
+	}
+
+	// Newest images first
+	sort.Sort(imageStreamsByUpload(images))
+
+	updated := time.Unix(0, 0).UTC()
+	for _, image := range images {
+		files, err := getFiles(image.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(files) != 2 {
+			continue
+		}
+
+		if image.UploadedAt.After(updated) {
+			updated = image.UploadedAt
+		}
+
+		name := imageStreamsProduct(image)
+		product, ok := manifest.Products[name]
+		if !ok {
+			product = simplestreams.SimpleStreamsManifestProduct{
+				Architecture:    image.Architecture,
+				OperatingSystem: image.Properties["os"],
+				Release:         image.Properties["release"],
+				ReleaseTitle:    image.Properties["release"],
+				Version:         image.Properties["version"],
+				Supported:       true,
+				Versions:        map[string]simplestreams.SimpleStreamsManifestProductVersion{},
+			}
+
+			aliases := []string{}
+			for _, alias := range image.Aliases {
+				aliases = append(aliases, alias.Name)
+			}
+			product.Aliases = strings.Join(aliases, ",")
+
+			if shared.TimeIsSet(image.ExpiresAt) {
+				product.SupportedEOL = image.ExpiresAt.UTC().Format("2006-01-02")
+			}
+		}
+
+		// Versions are named after their creation date
+		created := image.CreatedAt
+		if !shared.TimeIsSet(created) {
+			created = image.UploadedAt
+		}
+
+		serial := created.UTC().Format("20060102_1504")
+		_, ok = product.Versions[serial]
+		if ok {
+			serial = fmt.Sprintf("%s_%s", serial, image.Fingerprint[0:12])
+		}
+
+		version := simplestreams.SimpleStreamsManifestProductVersion{
+			Label: image.Properties["label"],
+			Items: map[string]simplestreams.SimpleStreamsManifestProductVersionItem{},
+		}
+
+		for i, file := range files {
+			item := simplestreams.SimpleStreamsManifestProductVersionItem{
+				Path:       fmt.Sprintf("streams/v1/images/%s/%s", image.Fingerprint, file.name),
+				FileType:   file.ftype,
+				HashSha256: file.sha256,
+				Size:       file.size,
+			}
+
+			// The metadata carries the fingerprint of the whole image
+			if i == 0 {
+				item.LXDHashSha256 = image.Fingerprint
+			}
+
+			version.Items[file.name] = item
+		}
+
+		product.Versions[serial] = version
+		manifest.Products[name] = product
+	}
+
+	manifest.Updated = updated.UTC().Format(time.RFC1123Z)
+
+	return &manifest, nil
+}
+
+// imageStreamsPublicImages returns the public images of the default project
+// and forgets the hashes of the images which went away.
+func imageStreamsPublicImages(d *Daemon) ([]api.Image, error) {
+	fingerprints, err := dbImagesGet(d.db, "default", true)
+	if err != nil {
+		return nil, err
+	}
+
+	images := []api.Image{}
+	for _, fingerprint := range fingerprints {
+		_, image, err := dbImageGet(d.db, "default", fingerprint, true, true)
+		if err != nil {
+			return nil, err
+		}
+
+		images = append(images, *image)
+	}
+
+	imageStreamsFilesLock.Lock()
+	for fingerprint := range imageStreamsFiles {
+		if !shared.PathExists(shared.VarPath("images", fingerprint)) {
+			delete(imageStreamsFiles, fingerprint)
+		}
+	}
+	imageStreamsFilesLock.Unlock()
+
+	return images, nil
+}
+
+// imageStreamsRender sends a JSON document along with an ETag, so HTTP caches
+// can revalidate it cheaply.
+func imageStreamsRender(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	content, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", fmt.Sprintf("\"%x\"", sha256.Sum256(content)))
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
+
+func imageStreamsCheckMethod(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Read-only simplestreams server", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	return true
+}
+
+func imageStreamsIndexGet(d *Daemon, w http.ResponseWriter, r *http.Request) {
+	if !imageStreamsCheckMethod(w, r) {
+		return
+	}
+
+	images, err := imageStreamsPublicImages(d)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	manifest, err := imageStreamsManifest(images, imageStreamsGetFiles)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	products := []string{}
+	for name := range manifest.Products {
+		products = append(products, name)
+	}
+	sort.Strings(products)
+
+	index := simplestreams.SimpleStreamsIndex{
+		Format: "index:1.0",
+		Index: map[string]simplestreams.SimpleStreamsIndexStream{
+			"images": {
+				DataType: "image-downloads",
+				Path:     imageStreamsManifestPath,
+				Products: products,
+				Updated:  manifest.Updated,
+			},
+		},
+		Updated: manifest.Updated,
+	}
+
+	imageStreamsRender(w, r, "index.json", index)
+}
+
+func imageStreamsManifestGet(d *Daemon, w http.ResponseWriter, r *http.Request) {
+	if !imageStreamsCheckMethod(w, r) {
+		return
+	}
+
+	images, err := imageStreamsPublicImages(d)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	manifest, err := imageStreamsManifest(images, imageStreamsGetFiles)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	imageStreamsRender(w, r, "images.json", manifest)
+}
+
+func imageStreamsFileGet(d *Daemon, w http.ResponseWriter, r *http.Request) {
+	if !imageStreamsCheckMethod(w, r) {
+		return
+	}
+
+	fingerprint := mux.Vars(r)["fingerprint"]
+	name := mux.Vars(r)["file"]
+
+	_, image, err := dbImageGet(d.db, "default", fingerprint, true, true)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	files, err := imageStreamsImageFiles(image.Fingerprint)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, file := range files {
+		if file.name != name {
+			continue
+		}
+
+		f, err := os.Open(file.path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, file.name, image.UploadedAt, f)
+		return
+	}
+
+	http.NotFound(w, r)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func Test_imageStreamsManifest(t *testing.T) {
+	old := api.Image{
+		Fingerprint:  "1111111111111111111111111111111111111111111111111111111111111111",
+		Architecture: "x86_64",
+		Aliases:      []api.ImageAlias{{Name: "old"}},
+		CreatedAt:    time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC),
+		UploadedAt:   time.Date(2017, 5, 2, 0, 0, 0, 0, time.UTC),
+	}
+	old.Properties = map[string]string{"os": "ubuntu", "release": "xenial"}
+
+	latest := old
+	latest.Fingerprint = "2222222222222222222222222222222222222222222222222222222222222222"
+	latest.Aliases = []api.ImageAlias{{Name: "xenial"}}
+	latest.CreatedAt = time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	latest.UploadedAt = time.Date(2017, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	unified := api.Image{
+		Fingerprint:  "3333333333333333333333333333333333333333333333333333333333333333",
+		Architecture: "x86_64",
+		UploadedAt:   time.Date(2017, 6, 3, 0, 0, 0, 0, time.UTC),
+	}
+
+	getFiles := func(fingerprint string) ([]imageStreamsFile, error) {
+		if fingerprint == unified.Fingerprint {
+			return []imageStreamsFile{{name: "meta.tar.gz", ftype: "lxd.tar.gz", sha256: fingerprint, size: 100}}, nil
+		}
+
+		return []imageStreamsFile{
+			{name: "meta.tar.xz", ftype: "lxd.tar.xz", sha256: "meta-" + fingerprint, size: 10},
+			{name: "rootfs.squashfs", ftype: "squashfs", sha256: "rootfs-" + fingerprint, size: 100},
+		}, nil
+	}
+
+	manifest, err := imageStreamsManifest([]api.Image{old, unified, latest}, getFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product, ok := manifest.Products["ubuntu:xenial:x86_64:default"]
+	if !ok || len(product.Versions) != 2 {
+		t.Fatalf("Wrong products: %+v", manifest.Products)
+	}
+
+	// Aliases only resolve to the latest version
+	if product.Aliases != "xenial" {
+		t.Errorf("Wrong aliases: %s", product.Aliases)
+	}
+
+	if manifest.Updated != "Fri, 02 Jun 2017 00:00:00 +0000" {
+		t.Errorf("Wrong update date: %s", manifest.Updated)
+	}
+
+	// The manifest must be understood by the simplestreams client
+	images, downloads := manifest.ToLXD()
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 images, got %d", len(images))
+	}
+
+	for _, image := range []api.Image{old, latest} {
+		files := downloads[image.Fingerprint]
+		if len(files) != 2 || files[0][2] != "meta" || files[1][2] != "root" {
+			t.Fatalf("Wrong files for %s: %v", image.Fingerprint, files)
+		}
+
+		if files[1][0] != "streams/v1/images/"+image.Fingerprint+"/rootfs.squashfs" || files[1][1] != "rootfs-"+image.Fingerprint {
+			t.Errorf("Wrong rootfs for %s: %v", image.Fingerprint, files[1])
+		}
+	}
+
+	// Unified images aren't published
+	_, ok = downloads[unified.Fingerprint]
+	if ok {
+		t.Errorf("Unified images shouldn't be in the manifest")
+	}
+}
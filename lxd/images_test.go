@@ -0,0 +1,116 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func Test_compressionParse(t *testing.T) {
+	tests := []struct {
+		value     string
+		algorithm string
+		level     int
+		valid     bool
+	}{
+		{"gzip", "gzip", -1, true},
+		{"none", "none", -1, true},
+		{"zstd:19", "zstd", 19, true},
+		{"zstd:22", "zstd", 22, true},
+		{"xz:0", "xz", 0, true},
+		{"zstd:23", "", -1, false},
+		{"gzip:0", "", -1, false},
+		{"xz:fast", "", -1, false},
+		{"pigz:9", "", -1, false},
+	}
+
+	for _, test := range tests {
+		algorithm, level, err := compressionParse(test.value)
+		if test.valid != (err == nil) {
+			t.Errorf("Unexpected result for %q: %v", test.value, err)
+			continue
+		}
+
+		if algorithm != test.algorithm || level != test.level {
+			t.Errorf("Wrong parsing of %q: %s %d", test.value, algorithm, level)
+		}
+	}
+}
+
+func Test_detectCompressionZstd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lxd_compression_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "image")
+	header := make([]byte, 512)
+	copy(header, []byte{0x28, 0xb5, 0x2f, 0xfd})
+
+	err = ioutil.WriteFile(path, header, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args, ext, err := detectCompression(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ext != ".tar.zst" || len(args) != 2 || args[0] != "--use-compress-program=zstd" {
+		t.Fatalf("Wrong detection: %v %s", args, ext)
+	}
+}
+
+func Test_unpackZstd(t *testing.T) {
+	_, err := exec.LookPath("zstd")
+	if err != nil {
+		t.Skip("zstd isn't available")
+	}
+
+	dir, err := ioutil.TempDir("", "lxd_compression_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source")
+	err = os.MkdirAll(filepath.Join(source, "rootfs"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(source, "rootfs", "hello"), []byte("world"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "image.tar.zst")
+	out, err := exec.Command("sh", "-c", "tar -C \"$1\" -cf - rootfs | zstd -q -o \"$2\"", "sh", source, path).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to create the tarball: %s", out)
+	}
+
+	target := filepath.Join(dir, "target")
+	err = os.MkdirAll(target, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = unpack(nil, path, target, storageTypeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(target, "rootfs", "hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "world" {
+		t.Fatalf("Wrong content after unpacking: %q", content)
+	}
+}
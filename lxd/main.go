@@ -139,8 +139,12 @@ func run() error {
 		fmt.Printf("        Get container network information\n")
 		fmt.Printf("    forkgetfile\n")
 		fmt.Printf("        Grab a file from a running container\n")
+		fmt.Printf("    forknet\n")
+		fmt.Printf("        Configure the network inside a container\n")
 		fmt.Printf("    forkmigrate\n")
 		fmt.Printf("        Restore a container after migration\n")
+		fmt.Printf("    forkproxy\n")
+		fmt.Printf("        Forward connections into a container\n")
 		fmt.Printf("    forkputfile\n")
 		fmt.Printf("        Push a file to a running container\n")
 		fmt.Printf("    forkstart\n")
@@ -195,7 +199,7 @@ func run() error {
 	// Process sub-commands
 	if len(os.Args) > 1 {
 		// "forkputfile", "forkgetfile", "forkmount" and "forkumount" are handled specially in nsexec.go
-		// "forkgetnet" is partially handled in nsexec.go (setns)
+		// "forkgetnet", "forknet" and "forkproxy" are partially handled in nsexec.go (setns)
 		switch os.Args[1] {
 		// Main commands
 		case "activateifneeded":
@@ -218,8 +222,12 @@ func run() error {
 		// Internal commands
 		case "forkgetnet":
 			return cmdForkGetNet()
+		case "forknet":
+			return cmdForkNet(os.Args[1:])
 		case "forkmigrate":
 			return cmdForkMigrate(os.Args[1:])
+		case "forkproxy":
+			return cmdForkProxy(os.Args[1:])
 		case "forkstart":
 			return cmdForkStart(os.Args[1:])
 		case "forkexec":
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// cmdForkNet runs "ip" inside the network namespace of the container, the
+// setns has already happened in nsexec.go.
+func cmdForkNet(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("Bad arguments: %q", args)
+	}
+
+	_, err := shared.RunCommand("ip", args[2:]...)
+	return err
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// proxyParseAddr splits a proxy device address such as "tcp:0.0.0.0:80" or
+// "unix:/run/app.sock" into its protocol and address.
+func proxyParseAddr(addr string) (string, string, error) {
+	fields := strings.SplitN(addr, ":", 2)
+	if len(fields) != 2 || fields[1] == "" {
+		return "", "", fmt.Errorf("Invalid proxy address: %s", addr)
+	}
+
+	if !shared.StringInSlice(fields[0], []string{"tcp", "udp", "unix"}) {
+		return "", "", fmt.Errorf("Invalid proxy protocol: %s", fields[0])
+	}
+
+	if fields[0] != "unix" {
+		_, _, err := net.SplitHostPort(fields[1])
+		if err != nil {
+			return "", "", fmt.Errorf("Invalid proxy address %s: %s", addr, err)
+		}
+	}
+
+	return fields[0], fields[1], nil
+}
+
+// ForkProxy is called with:
+//
+//	lxd forkproxy <pid> <listen address> <connect address>
+//
+// with the listening socket passed as fd 3. The network namespace (and mount
+// namespace for unix sockets) of <pid> is entered in nsexec.go, so all
+// connections are forwarded to the connect address inside the container.
+func cmdForkProxy(args []string) error {
+	if len(args) != 4 {
+		return fmt.Errorf("Bad arguments %q", args)
+	}
+
+	listenProto, _, err := proxyParseAddr(args[2])
+	if err != nil {
+		return err
+	}
+
+	connectProto, connectAddr, err := proxyParseAddr(args[3])
+	if err != nil {
+		return err
+	}
+
+	file := os.NewFile(3, "proxy")
+	defer file.Close()
+
+	if listenProto == "udp" {
+		conn, err := net.FilePacketConn(file)
+		if err != nil {
+			return err
+		}
+
+		return proxyPackets(conn, connectProto, connectAddr)
+	}
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return err
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go proxyStream(conn, connectProto, connectAddr)
+	}
+}
+
+func proxyStream(conn net.Conn, proto string, addr string) {
+	defer conn.Close()
+
+	target, err := net.Dial(proto, addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to %s:%s: %s\n", proto, addr, err)
+		return
+	}
+	defer target.Close()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		io.Copy(target, conn)
+		target.Close()
+		wg.Done()
+	}()
+
+	go func() {
+		io.Copy(conn, target)
+		conn.Close()
+		wg.Done()
+	}()
+
+	wg.Wait()
+}
+
+// proxyUDPTimeout is how long the socket used to reach the target on behalf of
+// a UDP client is kept around without any traffic in either direction.
+const proxyUDPTimeout = 2 * time.Minute
+
+func proxyPackets(conn net.PacketConn, proto string, addr string) error {
+	lock := sync.Mutex{}
+	targets := map[string]net.Conn{}
+
+	buf := make([]byte, 65536)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		lock.Lock()
+		target, ok := targets[peer.String()]
+		if !ok {
+			target, err = net.Dial(proto, addr)
+			if err != nil {
+				lock.Unlock()
+				fmt.Fprintf(os.Stderr, "Failed to connect to %s:%s: %s\n", proto, addr, err)
+				continue
+			}
+
+			targets[peer.String()] = target
+
+			// Send the replies back to the peer, until it's been idle
+			// for too long
+			target.SetReadDeadline(time.Now().Add(proxyUDPTimeout))
+			go func(target net.Conn, peer net.Addr) {
+				reply := make([]byte, 65536)
+				for {
+					n, err := target.Read(reply)
+					if err != nil {
+						break
+					}
+
+					target.SetReadDeadline(time.Now().Add(proxyUDPTimeout))
+					conn.WriteTo(reply[:n], peer)
+				}
+
+				lock.Lock()
+				if targets[peer.String()] == target {
+					delete(targets, peer.String())
+				}
+				lock.Unlock()
+				target.Close()
+			}(target, peer)
+		} else {
+			target.SetReadDeadline(time.Now().Add(proxyUDPTimeout))
+		}
+		lock.Unlock()
+
+		target.Write(buf[:n])
+	}
+}
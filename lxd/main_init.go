@@ -33,6 +33,10 @@ func cmdInit() error {
 	var bridgeIPv4Nat bool    // IPv4 address
 	var bridgeIPv6 string     // IPv6 address
 	var bridgeIPv6Nat bool    // IPv6 address
+	var clusterName string    // Name of this node in the cluster
+	var clusterAddress string // Address of an existing cluster member
+	var clusterCert string    // Certificate of an existing cluster member
+	var clusterPasswd string  // Trust password of the existing cluster
 
 	// Detect userns
 	defaultPrivileged = -1
@@ -47,6 +51,12 @@ func cmdInit() error {
 			continue
 		}
 
+		// CEPH pools live on a remote cluster which lxd init doesn't
+		// know how to set up.
+		if driver == "ceph" {
+			continue
+		}
+
 		// btrfs can work in user namespaces too. (If
 		// source=/some/path/on/btrfs is used.)
 		if runningInUserns && driver != "btrfs" {
@@ -239,7 +249,48 @@ func cmdInit() error {
 		return err
 	}
 
-	if *argAuto {
+	if !*argAuto && askBool("Would you like to use LXD clustering (yes/no) [default=no]? ", "no") {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+
+		clusterName = askString(fmt.Sprintf("What name should be used to identify this node in the cluster [default=%s]? ", hostname), hostname, nil)
+		networkAddress = askString("What IP address or DNS name should be used to reach this node? ", "", nil)
+		if ip := net.ParseIP(networkAddress); ip != nil && ip.To4() == nil {
+			networkAddress = fmt.Sprintf("[%s]", networkAddress)
+		}
+		networkPort = askInt("Port to bind LXD to [default=8443]: ", 1, 65535, "8443")
+
+		if askBool("Are you joining an existing cluster (yes/no) [default=no]? ", "no") {
+			clusterAddress = askString("IP address or FQDN of an existing cluster node: ", "", nil)
+			clusterCert, err = clusterGetCertificate(clusterAddress)
+			if err != nil {
+				return err
+			}
+
+			fingerprint, err := shared.CertFingerprintStr(clusterCert)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Cluster certificate fingerprint: %s\n", fingerprint)
+			if !askBool("Is this the correct fingerprint (yes/no) [default=no]? ", "no") {
+				return fmt.Errorf("Unable to verify the cluster certificate")
+			}
+
+			fmt.Printf("Cluster trust password: ")
+			pwd, _ := terminal.ReadPassword(0)
+			fmt.Printf("\n")
+			clusterPasswd = string(pwd)
+		} else {
+			trustPassword = askPassword("Trust password for new clients: ")
+		}
+	}
+
+	if clusterAddress != "" {
+		// Storage pools, networks and profiles come from the cluster
+	} else if *argAuto {
 		if *argStorageBackend == "" {
 			*argStorageBackend = "dir"
 		}
@@ -383,7 +434,7 @@ they otherwise would.
 			}
 		}
 
-		if askBool("Would you like LXD to be available over the network (yes/no) [default=no]? ", "no") {
+		if clusterName == "" && askBool("Would you like LXD to be available over the network (yes/no) [default=no]? ", "no") {
 			isIPAddress := func(s string) error {
 				if s != "all" && net.ParseIP(s) == nil {
 					return fmt.Errorf("'%s' is not an IP address", s)
@@ -594,6 +645,21 @@ they otherwise would.
 		}
 	}
 
+	if clusterName != "" {
+		cluster := api.ClusterPut{
+			ClusterAddress:     clusterAddress,
+			ClusterCertificate: clusterCert,
+			ClusterPassword:    clusterPasswd,
+		}
+		cluster.ServerName = clusterName
+		cluster.Enabled = true
+
+		err = c.UpdateCluster(cluster, "")
+		if err != nil {
+			return err
+		}
+	}
+
 	if bridgeName != "" {
 		bridgeConfig := map[string]string{}
 		bridgeConfig["ipv4.address"] = bridgeIPv4
@@ -651,6 +651,34 @@ void forkgetnet(char *buf, char *cur, ssize_t size) {
 	// The rest happens in Go
 }
 
+void forkproxy(char *buf, char *cur, ssize_t size) {
+	char *connect = NULL;
+	int pid;
+
+	ADVANCE_ARG_REQUIRED();
+	pid = atoi(cur);
+
+	ADVANCE_ARG_REQUIRED();
+
+	ADVANCE_ARG_REQUIRED();
+	connect = cur;
+
+	if (dosetns(pid, "net") < 0) {
+		fprintf(stderr, "Failed setns to container network namespace: %s\n", strerror(errno));
+		_exit(1);
+	}
+
+	// Unix sockets are looked up in the container filesystem
+	if (strncmp(connect, "unix:", 5) == 0) {
+		if (dosetns(pid, "mnt") < 0) {
+			fprintf(stderr, "Failed setns to container mount namespace: %s\n", strerror(errno));
+			_exit(1);
+		}
+	}
+
+	// The rest happens in Go
+}
+
 __attribute__((constructor)) void init(void) {
 	int cmdline;
 	char buf[CMDLINE_SIZE];
@@ -691,8 +719,10 @@ __attribute__((constructor)) void init(void) {
 		forkmount(buf, cur, size);
 	} else if (strcmp(cur, "forkumount") == 0) {
 		forkumount(buf, cur, size);
-	} else if (strcmp(cur, "forkgetnet") == 0) {
+	} else if (strcmp(cur, "forkgetnet") == 0 || strcmp(cur, "forknet") == 0) {
 		forkgetnet(buf, cur, size);
+	} else if (strcmp(cur, "forkproxy") == 0) {
+		forkproxy(buf, cur, size);
 	}
 }
 */
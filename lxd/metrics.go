@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Upper bounds (in seconds) of the API request latency histogram buckets
+var metricsRequestBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// API request latency, by request method
+type metricsHistogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+var metricsRequestsLock sync.Mutex
+var metricsRequests = map[string]*metricsHistogram{}
+
+// Total size of the images downloaded from remote servers
+var metricsImageDownloadBytes int64
+
+// metricsObserveRequest records the time taken to handle an API request.
+func metricsObserveRequest(method string, duration time.Duration) {
+	seconds := duration.Seconds()
+
+	metricsRequestsLock.Lock()
+	defer metricsRequestsLock.Unlock()
+
+	histogram, ok := metricsRequests[method]
+	if !ok {
+		histogram = &metricsHistogram{buckets: make([]uint64, len(metricsRequestBuckets))}
+		metricsRequests[method] = histogram
+	}
+
+	for i, bound := range metricsRequestBuckets {
+		if seconds <= bound {
+			histogram.buckets[i]++
+		}
+	}
+
+	histogram.count++
+	histogram.sum += seconds
+}
+
+// metricsAddImageDownload records the bytes transferred to download an image.
+func metricsAddImageDownload(size int64) {
+	atomic.AddInt64(&metricsImageDownloadBytes, size)
+}
+
+// metricsSet holds the samples of the metric families being rendered.
+type metricsSet struct {
+	families []string
+	types    map[string]string
+	help     map[string]string
+	samples  map[string][]string
+}
+
+func newMetricsSet() *metricsSet {
+	return &metricsSet{
+		types:   map[string]string{},
+		help:    map[string]string{},
+		samples: map[string][]string{},
+	}
+}
+
+// declare registers a metric family, samples are only rendered for declared
+// families.
+func (m *metricsSet) declare(family string, metricType string, help string) {
+	m.families = append(m.families, family)
+	m.types[family] = metricType
+	m.help[family] = help
+}
+
+// add records a sample. The suffix is appended to the family name, as
+// needed for counters ("_total") and histograms ("_bucket", "_sum", ...).
+func (m *metricsSet) add(family string, suffix string, labels map[string]string, value interface{}) {
+	keys := []string{}
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, k := range keys {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[k])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, value))
+	}
+
+	sample := family + suffix
+	if len(pairs) > 0 {
+		sample = fmt.Sprintf("%s{%s}", sample, strings.Join(pairs, ","))
+	}
+
+	m.samples[family] = append(m.samples[family], fmt.Sprintf("%s %v", sample, value))
+}
+
+// render produces the OpenMetrics text representation of the set.
+func (m *metricsSet) render() []byte {
+	buf := bytes.Buffer{}
+
+	for _, family := range m.families {
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", family, m.types[family])
+		fmt.Fprintf(&buf, "# HELP %s %s\n", family, m.help[family])
+		for _, sample := range m.samples[family] {
+			fmt.Fprintf(&buf, "%s\n", sample)
+		}
+	}
+
+	buf.WriteString("# EOF\n")
+
+	return buf.Bytes()
+}
+
+func metricsDeclare(metrics *metricsSet) {
+	metrics.declare("lxd_cpu_seconds", "counter", "CPU time used by the container.")
+	metrics.declare("lxd_memory_usage_bytes", "gauge", "Memory used by the container.")
+	metrics.declare("lxd_memory_usage_peak_bytes", "gauge", "Peak memory used by the container.")
+	metrics.declare("lxd_swap_usage_bytes", "gauge", "Swap used by the container.")
+	metrics.declare("lxd_network_receive_bytes", "counter", "Bytes received by the container network interface.")
+	metrics.declare("lxd_network_transmit_bytes", "counter", "Bytes sent by the container network interface.")
+	metrics.declare("lxd_network_receive_packets", "counter", "Packets received by the container network interface.")
+	metrics.declare("lxd_network_transmit_packets", "counter", "Packets sent by the container network interface.")
+	metrics.declare("lxd_disk_usage_bytes", "gauge", "Disk space used by the container disk device.")
+	metrics.declare("lxd_processes", "gauge", "Number of processes in the container.")
+	metrics.declare("lxd_operations", "gauge", "Number of operations by status.")
+	metrics.declare("lxd_api_request_duration_seconds", "histogram", "Time taken to handle API requests.")
+	metrics.declare("lxd_image_download_bytes", "counter", "Bytes of images downloaded from remote servers.")
+}
+
+func metricsAddContainer(metrics *metricsSet, project string, name string, state *api.ContainerState) {
+	labels := map[string]string{"project": project, "name": name}
+
+	metrics.add("lxd_cpu_seconds", "_total", labels, float64(state.CPU.Usage)/1e9)
+	metrics.add("lxd_memory_usage_bytes", "", labels, state.Memory.Usage)
+	metrics.add("lxd_memory_usage_peak_bytes", "", labels, state.Memory.UsagePeak)
+	metrics.add("lxd_swap_usage_bytes", "", labels, state.Memory.SwapUsage)
+	metrics.add("lxd_processes", "", labels, state.Processes)
+
+	nics := []string{}
+	for dev := range state.Network {
+		nics = append(nics, dev)
+	}
+	sort.Strings(nics)
+
+	for _, dev := range nics {
+		counters := state.Network[dev].Counters
+		devLabels := map[string]string{"project": project, "name": name, "device": dev}
+
+		metrics.add("lxd_network_receive_bytes", "_total", devLabels, counters.BytesReceived)
+		metrics.add("lxd_network_transmit_bytes", "_total", devLabels, counters.BytesSent)
+		metrics.add("lxd_network_receive_packets", "_total", devLabels, counters.PacketsReceived)
+		metrics.add("lxd_network_transmit_packets", "_total", devLabels, counters.PacketsSent)
+	}
+
+	disks := []string{}
+	for dev := range state.Disk {
+		disks = append(disks, dev)
+	}
+	sort.Strings(disks)
+
+	for _, dev := range disks {
+		devLabels := map[string]string{"project": project, "name": name, "device": dev}
+		metrics.add("lxd_disk_usage_bytes", "", devLabels, state.Disk[dev].Usage)
+	}
+}
+
+func metricsAddDaemon(metrics *metricsSet) {
+	// Operations by status
+	statuses := map[string]int{}
+	for _, status := range []api.StatusCode{api.Pending, api.Running, api.Cancelling, api.Success, api.Failure, api.Cancelled} {
+		statuses[status.String()] = 0
+	}
+
+	operationsLock.Lock()
+	ops := make([]*operation, 0, len(operations))
+	for _, op := range operations {
+		ops = append(ops, op)
+	}
+	operationsLock.Unlock()
+
+	// The status is only stable under the lock of each operation
+	for _, op := range ops {
+		op.lock.Lock()
+		statuses[op.status.String()]++
+		op.lock.Unlock()
+	}
+
+	names := []string{}
+	for status := range statuses {
+		names = append(names, status)
+	}
+	sort.Strings(names)
+
+	for _, status := range names {
+		metrics.add("lxd_operations", "", map[string]string{"status": status}, statuses[status])
+	}
+
+	// API request latency
+	metricsRequestsLock.Lock()
+	methods := []string{}
+	for method := range metricsRequests {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		histogram := metricsRequests[method]
+		for i, bound := range metricsRequestBuckets {
+			labels := map[string]string{"method": method, "le": fmt.Sprintf("%v", bound)}
+			metrics.add("lxd_api_request_duration_seconds", "_bucket", labels, histogram.buckets[i])
+		}
+
+		labels := map[string]string{"method": method}
+		metrics.add("lxd_api_request_duration_seconds", "_bucket", map[string]string{"method": method, "le": "+Inf"}, histogram.count)
+		metrics.add("lxd_api_request_duration_seconds", "_count", labels, histogram.count)
+		metrics.add("lxd_api_request_duration_seconds", "_sum", labels, histogram.sum)
+	}
+	metricsRequestsLock.Unlock()
+
+	// Image downloads
+	metrics.add("lxd_image_download_bytes", "_total", nil, atomic.LoadInt64(&metricsImageDownloadBytes))
+}
+
+// metricsResponse renders metrics in the OpenMetrics text format rather
+// than as a JSON document.
+type metricsResponse struct {
+	content []byte
+}
+
+func (r *metricsResponse) Render(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(r.content)
+	return err
+}
+
+func (r *metricsResponse) String() string {
+	return "success"
+}
+
+func metricsGet(d *Daemon, r *http.Request) Response {
+	metrics := newMetricsSet()
+	metricsDeclare(metrics)
+
+	names, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	for _, fullName := range names {
+		c, err := containerLoadByName(d, fullName)
+		if err != nil {
+			continue
+		}
+
+		if !c.IsRunning() {
+			continue
+		}
+
+		state, err := c.RenderState()
+		if err != nil {
+			continue
+		}
+
+		project, name := projectSplitName(fullName)
+		metricsAddContainer(metrics, project, name, state)
+	}
+
+	metricsAddDaemon(metrics)
+
+	return &metricsResponse{content: metrics.render()}
+}
+
+var metricsCmd = Command{name: "metrics", get: metricsGet}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func Test_metricsRender(t *testing.T) {
+	metrics := newMetricsSet()
+	metricsDeclare(metrics)
+
+	state := &api.ContainerState{
+		CPU:       api.ContainerStateCPU{Usage: 1500000000},
+		Memory:    api.ContainerStateMemory{Usage: 1024},
+		Processes: 3,
+		Network: map[string]api.ContainerStateNetwork{
+			"eth0": {Counters: api.ContainerStateNetworkCounters{BytesReceived: 42}},
+		},
+	}
+	metricsAddContainer(metrics, "default", "c1", state)
+
+	metricsObserveRequest("GET", 20*time.Millisecond)
+	metricsAddDaemon(metrics)
+
+	out := string(metrics.render())
+
+	expected := []string{
+		"# TYPE lxd_cpu_seconds counter\n",
+		`lxd_cpu_seconds_total{name="c1",project="default"} 1.5` + "\n",
+		`lxd_memory_usage_bytes{name="c1",project="default"} 1024` + "\n",
+		`lxd_processes{name="c1",project="default"} 3` + "\n",
+		`lxd_network_receive_bytes_total{device="eth0",name="c1",project="default"} 42` + "\n",
+		`lxd_operations{status="Running"} 0` + "\n",
+		`lxd_api_request_duration_seconds_bucket{le="0.01",method="GET"} 0` + "\n",
+		`lxd_api_request_duration_seconds_bucket{le="0.025",method="GET"} 1` + "\n",
+		`lxd_api_request_duration_seconds_count{method="GET"} 1` + "\n",
+		"# TYPE lxd_image_download_bytes counter\n",
+	}
+
+	for _, line := range expected {
+		if !strings.Contains(out, line) {
+			t.Errorf("Missing %q in:\n%s", line, out)
+		}
+	}
+
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Errorf("Metrics should end with an EOF marker")
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// migrationStorageSourceWs sends a custom storage volume, and its snapshots,
+// over the migration websockets. Custom volumes are always sent with rsync.
+type migrationStorageSourceWs struct {
+	migrationSourceWs
+
+	d          *Daemon
+	pool       string
+	volume     string
+	volumeOnly bool
+}
+
+func NewStorageMigrationSource(d *Daemon, pool string, volume string, volumeOnly bool) (*migrationStorageSourceWs, error) {
+	ret := migrationStorageSourceWs{
+		migrationSourceWs: migrationSourceWs{allConnected: make(chan bool, 1)},
+		d:                 d,
+		pool:              pool,
+		volume:            volume,
+		volumeOnly:        volumeOnly,
+	}
+
+	var err error
+	ret.controlSecret, err = shared.RandomCryptoString()
+	if err != nil {
+		return nil, err
+	}
+
+	ret.fsSecret, err = shared.RandomCryptoString()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *migrationStorageSourceWs) Do(migrateOp *operation) error {
+	<-s.allConnected
+
+	poolID, err := dbStoragePoolGetID(s.d.db, s.pool)
+	if err != nil {
+		s.sendControl(err)
+		return err
+	}
+
+	snapshots := []string{}
+	if !s.volumeOnly {
+		snapshots, err = dbStoragePoolVolumeSnapshotsGetType(s.d.db, s.volume, storagePoolVolumeTypeCustom, poolID)
+		if err != nil {
+			s.sendControl(err)
+			return err
+		}
+	}
+
+	snapshotNames := []string{}
+	for _, snapshot := range snapshots {
+		snapshotNames = append(snapshotNames, shared.ExtractSnapshotName(snapshot))
+	}
+
+	myType := MigrationFSType_RSYNC
+	header := MigrationHeader{
+		Fs:            &myType,
+		SnapshotNames: snapshotNames,
+	}
+
+	err = s.send(&header)
+	if err != nil {
+		s.sendControl(err)
+		return err
+	}
+
+	err = s.recv(&header)
+	if err != nil {
+		s.sendControl(err)
+		return err
+	}
+
+	for _, snapshot := range snapshots {
+		err := storagePoolVolumeRsyncSend(s.d, s.pool, snapshot, s.fsConn, migrateOp)
+		if err != nil {
+			s.sendControl(err)
+			return err
+		}
+	}
+
+	err = storagePoolVolumeRsyncSend(s.d, s.pool, s.volume, s.fsConn, migrateOp)
+	if err != nil {
+		s.sendControl(err)
+		return err
+	}
+
+	msg := MigrationControl{}
+	err = s.recv(&msg)
+	if err != nil {
+		s.disconnect()
+		return err
+	}
+
+	if !*msg.Success {
+		return fmt.Errorf(*msg.Message)
+	}
+
+	return nil
+}
+
+// migrationStorageSink receives a custom storage volume, and its snapshots,
+// from a migrationStorageSourceWs. The volume itself has to exist already.
+type migrationStorageSink struct {
+	*migrationSink
+
+	d            *Daemon
+	pool         string
+	volume       string
+	volumeConfig map[string]string
+}
+
+func NewStorageMigrationSink(d *Daemon, pool string, volume string, volumeConfig map[string]string, args *MigrationSinkArgs) (*migrationStorageSink, error) {
+	sink, err := NewMigrationSink(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrationStorageSink{
+		migrationSink: sink,
+		d:             d,
+		pool:          pool,
+		volume:        volume,
+		volumeConfig:  volumeConfig,
+	}, nil
+}
+
+func (c *migrationStorageSink) Do(migrateOp *operation) error {
+	var err error
+
+	fields := &c.src
+	if c.push {
+		<-c.allConnected
+		fields = &c.dest
+	} else {
+		c.src.controlConn, err = c.connectWithSecret(c.src.controlSecret)
+		if err != nil {
+			return err
+		}
+
+		c.src.fsConn, err = c.connectWithSecret(c.src.fsSecret)
+		if err != nil {
+			c.src.sendControl(err)
+			return err
+		}
+	}
+	defer fields.disconnect()
+
+	header := MigrationHeader{}
+	err = fields.recv(&header)
+	if err != nil {
+		fields.sendControl(err)
+		return err
+	}
+
+	myType := MigrationFSType_RSYNC
+	resp := MigrationHeader{Fs: &myType}
+	err = fields.send(&resp)
+	if err != nil {
+		fields.sendControl(err)
+		return err
+	}
+
+	for _, name := range header.SnapshotNames {
+		snapshot := fmt.Sprintf("%s%s%s", c.volume, shared.SnapshotDelimiter, name)
+		err = storagePoolVolumeCreateInternal(c.d, c.pool, snapshot, storagePoolVolumeTypeNameCustom, c.volumeConfig)
+		if err != nil {
+			fields.sendControl(err)
+			return err
+		}
+
+		err = storagePoolVolumeRsyncRecv(c.d, c.pool, snapshot, fields.fsConn, migrateOp)
+		if err != nil {
+			fields.sendControl(err)
+			return err
+		}
+	}
+
+	err = storagePoolVolumeRsyncRecv(c.d, c.pool, c.volume, fields.fsConn, migrateOp)
+	fields.sendControl(err)
+	return err
+}
+
+// storagePoolVolumeRsyncSend sends the content of a custom volume (or
+// snapshot) over a migration websocket.
+func storagePoolVolumeRsyncSend(d *Daemon, poolName string, volumeName string, conn *websocket.Conn, op *operation) error {
+	s, err := storagePoolVolumeInit(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	ourMount, err := s.StoragePoolVolumeMount()
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer s.StoragePoolVolumeUmount()
+	}
+
+	path := getStoragePoolVolumeMountPoint(poolName, volumeName)
+	wrapper := StorageProgressReader(op, "fs_progress", volumeName)
+	return RsyncSend(shared.AddSlash(path), conn, wrapper)
+}
+
+// storagePoolVolumeRsyncRecv receives the content of a custom volume (or
+// snapshot) from a migration websocket.
+func storagePoolVolumeRsyncRecv(d *Daemon, poolName string, volumeName string, conn *websocket.Conn, op *operation) error {
+	s, err := storagePoolVolumeInit(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	ourMount, err := s.StoragePoolVolumeMount()
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer s.StoragePoolVolumeUmount()
+	}
+
+	path := getStoragePoolVolumeMountPoint(poolName, volumeName)
+	wrapper := StorageProgressWriter(op, "fs_progress", volumeName)
+	return RsyncRecv(shared.AddSlash(path), conn, wrapper)
+}
@@ -94,6 +94,11 @@ func networksPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
+	err = networkACLsCheck(d, req.Config["security.acls"])
+	if err != nil {
+		return BadRequest(err)
+	}
+
 	// Set some default values where needed
 	if req.Config["bridge.mode"] == "fan" {
 		if req.Config["fan.underlay_subnet"] == "" {
@@ -124,6 +129,11 @@ func networksPost(d *Daemon, r *http.Request) Response {
 		return InternalError(err)
 	}
 
+	err = networkFillDelegated(d, req.Name, req.Config)
+	if err != nil {
+		return InternalError(err)
+	}
+
 	// Create the database entry
 	_, err = dbNetworkCreate(d.db, req.Name, req.Config)
 	if err != nil {
@@ -190,7 +200,8 @@ func doNetworkGet(d *Daemon, name string) (api.Network, error) {
 		}
 
 		if networkIsInUse(c, n.Name) {
-			n.UsedBy = append(n.UsedBy, fmt.Sprintf("/%s/containers/%s", version.APIVersion, ct))
+			project, name := projectSplitName(ct)
+			n.UsedBy = append(n.UsedBy, fmt.Sprintf("/%s/containers/%s%s", version.APIVersion, name, projectQuery(project)))
 		}
 	}
 
@@ -359,6 +370,11 @@ func doNetworkUpdate(d *Daemon, name string, oldConfig map[string]string, newCon
 		return BadRequest(err)
 	}
 
+	err = networkACLsCheck(d, newConfig["security.acls"])
+	if err != nil {
+		return BadRequest(err)
+	}
+
 	// When switching to a fan bridge, auto-detect the underlay
 	if newConfig["bridge.mode"] == "fan" {
 		if newConfig["fan.underlay_subnet"] == "" {
@@ -382,6 +398,140 @@ func doNetworkUpdate(d *Daemon, name string, oldConfig map[string]string, newCon
 
 var networkCmd = Command{name: "networks/{name}", get: networkGet, delete: networkDelete, post: networkPost, put: networkPut, patch: networkPatch}
 
+func networkLeasesGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	// Only managed networks have leases
+	networkID, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	leases := []api.NetworkLease{}
+
+	// Static entries, from the containers attached to the network
+	entries, err := networkGetStaticEntries(d, []string{name})
+	if err != nil {
+		return SmartError(err)
+	}
+
+	hwaddrs := map[string]string{}
+	for _, entry := range entries[name] {
+		hwaddr := entry[0]
+		cName := entry[1]
+		hwaddrs[cName] = hwaddr
+
+		for _, address := range entry[2:] {
+			if address == "" {
+				continue
+			}
+
+			leases = append(leases, api.NetworkLease{Hostname: cName, Hwaddr: hwaddr, Address: address, Type: "static"})
+		}
+	}
+
+	// Reservations for the hosts outside of LXD
+	reservations, err := dbNetworkReservations(d.db, networkID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	for _, reservation := range reservations {
+		if reservation.Hostname != "" {
+			hwaddrs[reservation.Hostname] = reservation.Hwaddr
+		}
+
+		for _, address := range []string{reservation.IPv4Address, reservation.IPv6Address} {
+			if address == "" {
+				continue
+			}
+
+			leases = append(leases, api.NetworkLease{Hostname: reservation.Hostname, Hwaddr: reservation.Hwaddr, Address: address, Type: "static"})
+		}
+	}
+
+	// Dynamic leases, from dnsmasq
+	f, err := os.Open(shared.VarPath("networks", name, "dnsmasq.leases"))
+	if err != nil && !os.IsNotExist(err) {
+		return SmartError(err)
+	}
+
+	if err == nil {
+		defer f.Close()
+
+		dynamic, err := networkParseLeases(f, hwaddrs)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		for _, lease := range dynamic {
+			// Skip the leases of the static entries
+			static := false
+			for _, entry := range leases {
+				if entry.Address == lease.Address {
+					static = true
+					break
+				}
+			}
+
+			if !static {
+				leases = append(leases, lease)
+			}
+		}
+	}
+
+	return SyncResponse(true, leases)
+}
+
+var networkLeasesCmd = Command{name: "networks/{name}/leases", get: networkLeasesGet}
+
+func networkStateGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	// Only managed networks have a state
+	_, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	interfaces, err := networkInterfacesState()
+	if err != nil {
+		return SmartError(err)
+	}
+
+	netIf, ok := interfaces[name]
+	if !ok {
+		return NotFound
+	}
+
+	state := api.NetworkState{
+		Addresses: []api.NetworkStateAddress{},
+		Counters: api.NetworkStateCounters{
+			BytesReceived:   netIf.Counters.BytesReceived,
+			BytesSent:       netIf.Counters.BytesSent,
+			PacketsReceived: netIf.Counters.PacketsReceived,
+			PacketsSent:     netIf.Counters.PacketsSent,
+		},
+		Hwaddr: netIf.Hwaddr,
+		Mtu:    netIf.Mtu,
+		State:  netIf.State,
+		Type:   netIf.Type,
+	}
+
+	for _, addr := range netIf.Addresses {
+		state.Addresses = append(state.Addresses, api.NetworkStateAddress{
+			Family:  addr.Family,
+			Address: addr.Address,
+			Netmask: addr.Netmask,
+			Scope:   addr.Scope,
+		})
+	}
+
+	return SyncResponse(true, state)
+}
+
+var networkStateCmd = Command{name: "networks/{name}/state", get: networkStateGet}
+
 // The network structs and functions
 func networkLoadByName(d *Daemon, name string) (*network, error) {
 	id, dbInfo, err := dbNetworkGet(d.db, name)
@@ -548,8 +698,22 @@ func (n *network) Start() error {
 	// Get a list of tunnels
 	tunnels := networkGetTunnels(n.config)
 
+	// Resolve the IPv6 address of bridges using a delegated prefix
+	ipv6Address := n.config["ipv6.address"]
+	if networkDelegatedUplink(ipv6Address) != "" {
+		var err error
+		ipv6Address, err = networkDelegatedAddress(n.config)
+		if err != nil {
+			return err
+		}
+
+		if ipv6Address == "" {
+			shared.LogWarn("No IPv6 prefix delegated yet, skipping IPv6", log.Ctx{"network": n.name, "uplink": networkDelegatedUplink(n.config["ipv6.address"])})
+		}
+	}
+
 	// IPv6 bridge configuration
-	if !shared.StringInSlice(n.config["ipv6.address"], []string{"", "none"}) {
+	if !shared.StringInSlice(ipv6Address, []string{"", "none"}) {
 		err := networkSysctl(fmt.Sprintf("ipv6/conf/%s/autoconf", n.name), "0")
 		if err != nil {
 			return err
@@ -577,6 +741,14 @@ func (n *network) Start() error {
 		}
 	}
 
+	// Open vSwitch tunnels don't have a network device of their own
+	if n.config["bridge.driver"] == "openvswitch" {
+		err = networkOVSClearTunnels(n.name)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Set the MTU
 	mtu := ""
 	if n.config["bridge.mtu"] != "" {
@@ -636,18 +808,8 @@ func (n *network) Start() error {
 		}
 	}
 
-	// Remove any existing IPv4 iptables rules
-	err = networkIptablesClear("ipv4", n.name, "")
-	if err != nil {
-		return err
-	}
-
-	err = networkIptablesClear("ipv4", n.name, "mangle")
-	if err != nil {
-		return err
-	}
-
-	err = networkIptablesClear("ipv4", n.name, "nat")
+	// Remove any existing firewall rules
+	err = n.daemon.firewall.NetworkClear(n.name)
 	if err != nil {
 		return err
 	}
@@ -665,24 +827,8 @@ func (n *network) Start() error {
 
 	// Configure IPv4 firewall (includes fan)
 	if n.config["bridge.mode"] == "fan" || !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"}) {
-		// Setup basic iptables overrides
-		rules := [][]string{
-			{"ipv4", n.name, "", "INPUT", "-i", n.name, "-p", "udp", "--dport", "67", "-j", "ACCEPT"},
-			{"ipv4", n.name, "", "INPUT", "-i", n.name, "-p", "udp", "--dport", "53", "-j", "ACCEPT"},
-			{"ipv4", n.name, "", "INPUT", "-i", n.name, "-p", "tcp", "--dport", "53", "-j", "ACCEPT"},
-			{"ipv4", n.name, "", "OUTPUT", "-o", n.name, "-p", "udp", "--sport", "67", "-j", "ACCEPT"},
-			{"ipv4", n.name, "", "OUTPUT", "-o", n.name, "-p", "udp", "--sport", "53", "-j", "ACCEPT"},
-			{"ipv4", n.name, "", "OUTPUT", "-o", n.name, "-p", "tcp", "--sport", "53", "-j", "ACCEPT"}}
-
-		for _, rule := range rules {
-			err = networkIptablesPrepend(rule[0], rule[1], rule[2], rule[3], rule[4:]...)
-			if err != nil {
-				return err
-			}
-		}
-
-		// Workaround for broken DHCP clients
-		err = networkIptablesPrepend("ipv4", n.name, "mangle", "POSTROUTING", "-o", n.name, "-p", "udp", "--dport", "68", "-j", "CHECKSUM", "--checksum-fill")
+		// Allow DHCP and DNS
+		err = n.daemon.firewall.NetworkSetupServices("ipv4", n.name, n.config["ipv4.dhcp"] == "" || shared.IsTrue(n.config["ipv4.dhcp"]))
 		if err != nil {
 			return err
 		}
@@ -695,24 +841,14 @@ func (n *network) Start() error {
 			}
 
 			if n.config["ipv4.firewall"] == "" || shared.IsTrue(n.config["ipv4.firewall"]) {
-				err = networkIptablesPrepend("ipv4", n.name, "", "FORWARD", "-i", n.name, "-j", "ACCEPT")
-				if err != nil {
-					return err
-				}
-
-				err = networkIptablesPrepend("ipv4", n.name, "", "FORWARD", "-o", n.name, "-j", "ACCEPT")
+				err = n.daemon.firewall.NetworkSetupForward("ipv4", n.name, true)
 				if err != nil {
 					return err
 				}
 			}
 		} else {
 			if n.config["ipv4.firewall"] == "" || shared.IsTrue(n.config["ipv4.firewall"]) {
-				err = networkIptablesPrepend("ipv4", n.name, "", "FORWARD", "-i", n.name, "-j", "REJECT")
-				if err != nil {
-					return err
-				}
-
-				err = networkIptablesPrepend("ipv4", n.name, "", "FORWARD", "-o", n.name, "-j", "REJECT")
+				err = n.daemon.firewall.NetworkSetupForward("ipv4", n.name, false)
 				if err != nil {
 					return err
 				}
@@ -764,7 +900,7 @@ func (n *network) Start() error {
 
 		// Configure NAT
 		if shared.IsTrue(n.config["ipv4.nat"]) {
-			err = networkIptablesPrepend("ipv4", n.name, "nat", "POSTROUTING", "-s", subnet.String(), "!", "-d", subnet.String(), "-j", "MASQUERADE")
+			err = n.daemon.firewall.NetworkSetupNAT("ipv4", n.name, subnet)
 			if err != nil {
 				return err
 			}
@@ -782,17 +918,6 @@ func (n *network) Start() error {
 		}
 	}
 
-	// Remove any existing IPv6 iptables rules
-	err = networkIptablesClear("ipv6", n.name, "")
-	if err != nil {
-		return err
-	}
-
-	err = networkIptablesClear("ipv6", n.name, "nat")
-	if err != nil {
-		return err
-	}
-
 	// Flush all IPv6 addresses and routes
 	_, err = shared.RunCommand("ip", "-6", "addr", "flush", "dev", n.name, "scope", "global")
 	if err != nil {
@@ -805,7 +930,7 @@ func (n *network) Start() error {
 	}
 
 	// Configure IPv6
-	if !shared.StringInSlice(n.config["ipv6.address"], []string{"", "none"}) {
+	if !shared.StringInSlice(ipv6Address, []string{"", "none"}) {
 		// Enable IPv6 for the subnet
 		err := networkSysctl(fmt.Sprintf("ipv6/conf/%s/disable_ipv6", n.name), "0")
 		if err != nil {
@@ -813,7 +938,7 @@ func (n *network) Start() error {
 		}
 
 		// Parse the subnet
-		ip, subnet, err := net.ParseCIDR(n.config["ipv6.address"])
+		ip, subnet, err := net.ParseCIDR(ipv6Address)
 		if err != nil {
 			return err
 		}
@@ -846,20 +971,10 @@ func (n *network) Start() error {
 			dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("::,constructor:%s,ra-only", n.name)}...)
 		}
 
-		// Setup basic iptables overrides
-		rules := [][]string{
-			{"ipv6", n.name, "", "INPUT", "-i", n.name, "-p", "udp", "--dport", "546", "-j", "ACCEPT"},
-			{"ipv6", n.name, "", "INPUT", "-i", n.name, "-p", "udp", "--dport", "53", "-j", "ACCEPT"},
-			{"ipv6", n.name, "", "INPUT", "-i", n.name, "-p", "tcp", "--dport", "53", "-j", "ACCEPT"},
-			{"ipv6", n.name, "", "OUTPUT", "-o", n.name, "-p", "udp", "--sport", "546", "-j", "ACCEPT"},
-			{"ipv6", n.name, "", "OUTPUT", "-o", n.name, "-p", "udp", "--sport", "53", "-j", "ACCEPT"},
-			{"ipv6", n.name, "", "OUTPUT", "-o", n.name, "-p", "tcp", "--sport", "53", "-j", "ACCEPT"}}
-
-		for _, rule := range rules {
-			err = networkIptablesPrepend(rule[0], rule[1], rule[2], rule[3], rule[4:]...)
-			if err != nil {
-				return err
-			}
+		// Allow DHCP and DNS, SLAAC only networks don't need DHCPv6
+		err = n.daemon.firewall.NetworkSetupServices("ipv6", n.name, n.config["ipv6.dhcp"] == "" || shared.IsTrue(n.config["ipv6.dhcp"]))
+		if err != nil {
+			return err
 		}
 
 		// Allow forwarding
@@ -892,24 +1007,14 @@ func (n *network) Start() error {
 			}
 
 			if n.config["ipv6.firewall"] == "" || shared.IsTrue(n.config["ipv6.firewall"]) {
-				err = networkIptablesPrepend("ipv6", n.name, "", "FORWARD", "-i", n.name, "-j", "ACCEPT")
-				if err != nil {
-					return err
-				}
-
-				err = networkIptablesPrepend("ipv6", n.name, "", "FORWARD", "-o", n.name, "-j", "ACCEPT")
+				err = n.daemon.firewall.NetworkSetupForward("ipv6", n.name, true)
 				if err != nil {
 					return err
 				}
 			}
 		} else {
 			if n.config["ipv6.firewall"] == "" || shared.IsTrue(n.config["ipv6.firewall"]) {
-				err = networkIptablesPrepend("ipv6", n.name, "", "FORWARD", "-i", n.name, "-j", "REJECT")
-				if err != nil {
-					return err
-				}
-
-				err = networkIptablesPrepend("ipv6", n.name, "", "FORWARD", "-o", n.name, "-j", "REJECT")
+				err = n.daemon.firewall.NetworkSetupForward("ipv6", n.name, false)
 				if err != nil {
 					return err
 				}
@@ -917,14 +1022,14 @@ func (n *network) Start() error {
 		}
 
 		// Add the address
-		_, err = shared.RunCommand("ip", "-6", "addr", "add", "dev", n.name, n.config["ipv6.address"])
+		_, err = shared.RunCommand("ip", "-6", "addr", "add", "dev", n.name, ipv6Address)
 		if err != nil {
 			return err
 		}
 
 		// Configure NAT
 		if shared.IsTrue(n.config["ipv6.nat"]) {
-			err = networkIptablesPrepend("ipv6", n.name, "nat", "POSTROUTING", "-s", subnet.String(), "!", "-d", subnet.String(), "-j", "MASQUERADE")
+			err = n.daemon.firewall.NetworkSetupNAT("ipv6", n.name, subnet)
 			if err != nil {
 				return err
 			}
@@ -942,6 +1047,18 @@ func (n *network) Start() error {
 		}
 	}
 
+	// Apply the network ACLs
+	err = networkACLNetworkApply(n.daemon, n.name, n.config["security.acls"])
+	if err != nil {
+		return err
+	}
+
+	// Publish the forwards
+	err = networkForwardsApply(n.daemon, n)
+	if err != nil {
+		return err
+	}
+
 	// Configure the fan
 	if n.config["bridge.mode"] == "fan" {
 		tunName := fmt.Sprintf("%s-fan", n.name)
@@ -1039,7 +1156,7 @@ func (n *network) Start() error {
 		}
 
 		// Configure NAT
-		err = networkIptablesPrepend("ipv4", n.name, "nat", "POSTROUTING", "-s", underlaySubnet.String(), "!", "-d", underlaySubnet.String(), "-j", "MASQUERADE")
+		err = n.daemon.firewall.NetworkSetupNAT("ipv4", n.name, underlaySubnet)
 		if err != nil {
 			return err
 		}
@@ -1056,6 +1173,22 @@ func (n *network) Start() error {
 		tunRemote := getConfig("remote")
 		tunName := fmt.Sprintf("%s-%s", n.name, tunnel)
 
+		// Use native tunnels on Open vSwitch bridges
+		if n.config["bridge.driver"] == "openvswitch" {
+			// Skip partial configs
+			if tunProtocol == "" || tunRemote == "" {
+				continue
+			}
+
+			args := networkOVSTunnelArgs(n.name, tunName, getConfig)
+			_, err = shared.RunCommand("ovs-vsctl", args...)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
 		// Configure the tunnel
 		cmd := []string{"ip", "link", "add", tunName}
 		if tunProtocol == "gre" {
@@ -1096,6 +1229,24 @@ func (n *network) Start() error {
 			}
 			cmd = append(cmd, []string{"dstport", tunPort}...)
 
+			tunId := getConfig("id")
+			if tunId == "" {
+				tunId = "1"
+			}
+			cmd = append(cmd, []string{"id", tunId}...)
+		} else if tunProtocol == "geneve" {
+			// Skip partial configs
+			if tunRemote == "" {
+				continue
+			}
+
+			cmd = append(cmd, []string{"type", "geneve", "remote", tunRemote}...)
+
+			tunPort := getConfig("port")
+			if tunPort != "" {
+				cmd = append(cmd, []string{"dstport", tunPort}...)
+			}
+
 			tunId := getConfig("id")
 			if tunId == "" {
 				tunId = "1"
@@ -1133,7 +1284,7 @@ func (n *network) Start() error {
 	}
 
 	// Configure dnsmasq
-	if n.config["bridge.mode"] == "fan" || !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"}) || !shared.StringInSlice(n.config["ipv6.address"], []string{"", "none"}) {
+	if n.config["bridge.mode"] == "fan" || !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"}) || !shared.StringInSlice(ipv6Address, []string{"", "none"}) {
 		// Setup the dnsmasq domain
 		dnsDomain := n.config["dns.domain"]
 		if dnsDomain == "" {
@@ -1161,6 +1312,24 @@ func (n *network) Start() error {
 			}
 		}
 
+		// Create the extra DNS records file (filled by networkUpdateStatic)
+		recordsPath := shared.VarPath("networks", n.name, "dnsmasq.records")
+		if n.config["dns.mode"] != "none" {
+			if !shared.PathExists(recordsPath) {
+				err = ioutil.WriteFile(recordsPath, []byte(""), 0644)
+				if err != nil {
+					return err
+				}
+			}
+
+			dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--addn-hosts=%s", recordsPath))
+		} else if shared.PathExists(recordsPath) {
+			err = os.Remove(recordsPath)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Attempt to drop privileges
 		for _, user := range []string{"lxd", "nobody"} {
 			_, err := shared.UserId(user)
@@ -1206,28 +1375,8 @@ func (n *network) Stop() error {
 		}
 	}
 
-	// Cleanup iptables
-	err := networkIptablesClear("ipv4", n.name, "")
-	if err != nil {
-		return err
-	}
-
-	err = networkIptablesClear("ipv4", n.name, "mangle")
-	if err != nil {
-		return err
-	}
-
-	err = networkIptablesClear("ipv4", n.name, "nat")
-	if err != nil {
-		return err
-	}
-
-	err = networkIptablesClear("ipv6", n.name, "")
-	if err != nil {
-		return err
-	}
-
-	err = networkIptablesClear("ipv6", n.name, "nat")
+	// Cleanup the firewall
+	err := n.daemon.firewall.NetworkClear(n.name)
 	if err != nil {
 		return err
 	}
@@ -1262,6 +1411,11 @@ func (n *network) Update(newNetwork api.NetworkPut) error {
 	if err != nil {
 		return err
 	}
+
+	err = networkFillDelegated(n.daemon, n.name, newNetwork.Config)
+	if err != nil {
+		return err
+	}
 	newConfig := newNetwork.Config
 
 	// Backup the current state
@@ -1361,6 +1515,14 @@ func (n *network) Update(newNetwork api.NetworkPut) error {
 		if err != nil {
 			return err
 		}
+
+		// Refresh the default limits of the attached nics
+		if shared.StringInSlice("limits.ingress", changedConfig) || shared.StringInSlice("limits.egress", changedConfig) {
+			err = networkUpdateLimits(n.daemon, n.name)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// Success, update the closure to mark that the changes should be kept.
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// API endpoints
+func networkACLsGet(d *Daemon, r *http.Request) Response {
+	recursionStr := r.FormValue("recursion")
+	recursion, err := strconv.Atoi(recursionStr)
+	if err != nil {
+		recursion = 0
+	}
+
+	names, err := dbNetworkACLs(d.db)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []api.NetworkACL{}
+	for _, name := range names {
+		if recursion == 0 {
+			resultString = append(resultString, fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, name))
+		} else {
+			acl, err := doNetworkACLGet(d, name)
+			if err != nil {
+				continue
+			}
+			resultMap = append(resultMap, *acl)
+		}
+	}
+
+	if recursion == 0 {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultMap)
+}
+
+func networkACLsPost(d *Daemon, r *http.Request) Response {
+	req := api.NetworkACLsPost{}
+
+	// Parse the request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Sanity checks
+	err = networkACLValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = networkACLValidate(req.NetworkACLPut)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	_, _, err = dbNetworkACLGet(d.db, req.Name)
+	if err == nil {
+		return BadRequest(fmt.Errorf("The network ACL already exists"))
+	}
+
+	if err != NoSuchObjectError {
+		return SmartError(err)
+	}
+
+	// Create the database entry
+	_, err = dbNetworkACLCreate(d.db, req.Name, req.NetworkACLPut)
+	if err != nil {
+		return InternalError(
+			fmt.Errorf("Error inserting %s into database: %s", req.Name, err))
+	}
+
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, req.Name))
+}
+
+var networkACLsCmd = Command{name: "network-acls", get: networkACLsGet, post: networkACLsPost}
+
+func networkACLGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	acl, err := doNetworkACLGet(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	etag := []interface{}{acl.Name, acl.Description, acl.Ingress, acl.Egress}
+
+	return SyncResponseETag(true, acl, etag)
+}
+
+func doNetworkACLGet(d *Daemon, name string) (*api.NetworkACL, error) {
+	_, acl, err := dbNetworkACLGet(d.db, name)
+	if err != nil {
+		return nil, err
+	}
+
+	networks, profiles, containers, err := networkACLUsedBy(d, name)
+	if err != nil {
+		return nil, err
+	}
+
+	acl.UsedBy = []string{}
+	for _, network := range networks {
+		acl.UsedBy = append(acl.UsedBy, fmt.Sprintf("/%s/networks/%s", version.APIVersion, network))
+	}
+
+	for _, profile := range profiles {
+		acl.UsedBy = append(acl.UsedBy, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, profile))
+	}
+
+	for _, ct := range containers {
+		project, cName := projectSplitName(ct.Name())
+		acl.UsedBy = append(acl.UsedBy, fmt.Sprintf("/%s/containers/%s%s", version.APIVersion, cName, projectQuery(project)))
+	}
+
+	return acl, nil
+}
+
+// networkACLUsedBy returns the managed networks, the profiles and the
+// containers referencing the given ACL in their "security.acls". Profiles of
+// other projects than the default one carry their project query.
+func networkACLUsedBy(d *Daemon, name string) ([]string, []string, []container, error) {
+	networks := []string{}
+	profiles := []string{}
+	containers := []container{}
+
+	names, err := dbNetworks(d.db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, network := range names {
+		_, info, err := dbNetworkGet(d.db, network)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if shared.StringInSlice(name, networkACLSplitNames(info.Config["security.acls"])) {
+			networks = append(networks, network)
+		}
+	}
+
+	projects, err := dbProjects(d.db)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, project := range projects {
+		pNames, err := dbProfiles(d.db, project)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		for _, pName := range pNames {
+			_, profile, err := dbProfileGet(d.db, project, pName)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			for _, m := range profile.Devices {
+				if m["type"] != "nic" || m["nictype"] != "bridged" {
+					continue
+				}
+
+				if shared.StringInSlice(name, networkACLSplitNames(m["security.acls"])) {
+					profiles = append(profiles, pName+projectQuery(project))
+					break
+				}
+			}
+		}
+	}
+
+	cts, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, ct := range cts {
+		c, err := containerLoadByName(d, ct)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		for _, k := range c.ExpandedDevices().DeviceNames() {
+			m := c.ExpandedDevices()[k]
+			if m["type"] != "nic" || m["nictype"] != "bridged" {
+				continue
+			}
+
+			if shared.StringInSlice(name, networkACLSplitNames(m["security.acls"])) {
+				containers = append(containers, c)
+				break
+			}
+		}
+	}
+
+	return networks, profiles, containers, nil
+}
+
+func networkACLPut(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	// Get the existing ACL
+	_, acl, err := dbNetworkACLGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Validate the ETag
+	etag := []interface{}{acl.Name, acl.Description, acl.Ingress, acl.Egress}
+
+	err = etagCheck(r, etag)
+	if err != nil {
+		return PreconditionFailed(err)
+	}
+
+	req := api.NetworkACLPut{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	err = networkACLValidate(req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	networks, _, containers, err := networkACLUsedBy(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = dbNetworkACLUpdate(d.db, name, req)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Refresh the firewall of everything using the ACL, putting the
+	// previous rules back if any of it fails
+	err = networkACLApplyUsers(d, networks, containers)
+	if err != nil {
+		dbNetworkACLUpdate(d.db, name, acl.NetworkACLPut)
+		networkACLApplyUsers(d, networks, containers)
+		return SmartError(err)
+	}
+
+	return EmptySyncResponse
+}
+
+// networkACLApplyUsers renders the ACLs of the given running networks and
+// containers again.
+func networkACLApplyUsers(d *Daemon, networks []string, containers []container) error {
+	for _, network := range networks {
+		n, err := networkLoadByName(d, network)
+		if err != nil {
+			return err
+		}
+
+		if !n.IsRunning() {
+			continue
+		}
+
+		err = networkACLNetworkApply(d, n.name, n.config["security.acls"])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range containers {
+		if !c.IsRunning() {
+			continue
+		}
+
+		for _, k := range c.ExpandedDevices().DeviceNames() {
+			m := c.ExpandedDevices()[k]
+			if m["type"] != "nic" || m["nictype"] != "bridged" || m["security.acls"] == "" {
+				continue
+			}
+
+			hostName := m["host_name"]
+			if hostName == "" {
+				hostName = c.(*containerLXC).getHostInterface(m["name"])
+			}
+
+			if hostName == "" {
+				shared.LogWarn("Unable to find the host interface, skipping network ACLs", log.Ctx{"container": c.Name(), "device": k})
+				continue
+			}
+
+			err := networkACLNicApply(d, c.Name(), k, m["parent"], hostName, m["security.acls"])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func networkACLPost(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	req := api.NetworkACLPost{}
+
+	// Parse the request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Get the existing ACL
+	_, _, err = dbNetworkACLGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Sanity checks
+	err = networkACLValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	_, _, err = dbNetworkACLGet(d.db, req.Name)
+	if err == nil {
+		return Conflict
+	}
+
+	// The references in the network and device configs would go stale
+	err = networkACLCheckUnused(d, name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = dbNetworkACLRename(d.db, name, req.Name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, req.Name))
+}
+
+func networkACLDelete(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	// Get the existing ACL
+	_, _, err := dbNetworkACLGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = networkACLCheckUnused(d, name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = dbNetworkACLDelete(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return EmptySyncResponse
+}
+
+func networkACLCheckUnused(d *Daemon, name string) error {
+	networks, profiles, containers, err := networkACLUsedBy(d, name)
+	if err != nil {
+		return err
+	}
+
+	if len(networks) > 0 || len(profiles) > 0 || len(containers) > 0 {
+		return fmt.Errorf("The network ACL is currently in use")
+	}
+
+	return nil
+}
+
+var networkACLCmd = Command{name: "network-acls/{name}", get: networkACLGet, put: networkACLPut, post: networkACLPost, delete: networkACLDelete}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+func networkACLValidName(value string) error {
+	if value == "" {
+		return fmt.Errorf("No name provided")
+	}
+
+	if len(value) > 63 {
+		return fmt.Errorf("ACL name is too long (maximum 63 characters)")
+	}
+
+	match, _ := regexp.MatchString("^[-_a-zA-Z0-9]*$", value)
+	if !match {
+		return fmt.Errorf("ACL name contains invalid characters")
+	}
+
+	return nil
+}
+
+// networkACLValidNames validates a "security.acls" value, a comma separated
+// list of ACL names.
+func networkACLValidNames(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	for _, name := range networkACLSplitNames(value) {
+		err := networkACLValidName(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func networkACLSplitNames(value string) []string {
+	names := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// networkACLsCheck makes sure that all the ACLs in a "security.acls" value
+// exist.
+func networkACLsCheck(d *Daemon, value string) error {
+	for _, name := range networkACLSplitNames(value) {
+		_, _, err := dbNetworkACLGet(d.db, name)
+		if err == NoSuchObjectError {
+			return fmt.Errorf("Network ACL '%s' doesn't exist", name)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func networkACLValidAddresses(value string) error {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if strings.Contains(entry, "/") {
+			_, _, err := net.ParseCIDR(entry)
+			if err != nil {
+				return fmt.Errorf("Invalid subnet '%s'", entry)
+			}
+
+			continue
+		}
+
+		if net.ParseIP(entry) == nil {
+			return fmt.Errorf("Invalid address '%s'", entry)
+		}
+	}
+
+	return nil
+}
+
+func networkACLValidPorts(value string) error {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+
+		bounds := strings.SplitN(entry, "-", 2)
+		for _, bound := range bounds {
+			port, err := strconv.Atoi(bound)
+			if err != nil || port < 1 || port > 65535 {
+				return fmt.Errorf("Invalid port '%s'", entry)
+			}
+		}
+
+		if len(bounds) == 2 {
+			start, _ := strconv.Atoi(bounds[0])
+			end, _ := strconv.Atoi(bounds[1])
+			if start > end {
+				return fmt.Errorf("Invalid port range '%s'", entry)
+			}
+		}
+	}
+
+	return nil
+}
+
+func networkACLValidate(acl api.NetworkACLPut) error {
+	rules := append([]api.NetworkACLRule{}, acl.Ingress...)
+	rules = append(rules, acl.Egress...)
+
+	for _, rule := range rules {
+		err := shared.IsOneOf(rule.Action, []string{"allow", "drop", "reject"})
+		if err != nil {
+			return fmt.Errorf("Invalid rule action: %s", err)
+		}
+
+		err = shared.IsOneOf(rule.Protocol, []string{"", "tcp", "udp", "icmp4", "icmp6"})
+		if err != nil {
+			return fmt.Errorf("Invalid rule protocol: %s", err)
+		}
+
+		if rule.Source != "" {
+			err = networkACLValidAddresses(rule.Source)
+			if err != nil {
+				return err
+			}
+		}
+
+		if rule.Destination != "" {
+			err = networkACLValidAddresses(rule.Destination)
+			if err != nil {
+				return err
+			}
+		}
+
+		if rule.SourcePort != "" || rule.DestinationPort != "" {
+			if !shared.StringInSlice(rule.Protocol, []string{"tcp", "udp"}) {
+				return fmt.Errorf("Ports can only be used with the tcp and udp protocols")
+			}
+		}
+
+		if rule.SourcePort != "" {
+			err = networkACLValidPorts(rule.SourcePort)
+			if err != nil {
+				return err
+			}
+		}
+
+		if rule.DestinationPort != "" {
+			err = networkACLValidPorts(rule.DestinationPort)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// networkACLFilterAddresses returns the addresses of a comma separated list
+// which belong to the given family ("ipv4" or "ipv6").
+func networkACLFilterAddresses(family string, value string) []string {
+	addresses := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+
+		ip := net.ParseIP(strings.SplitN(entry, "/", 2)[0])
+		if ip == nil {
+			continue
+		}
+
+		if (ip.To4() != nil) == (family == "ipv4") {
+			addresses = append(addresses, entry)
+		}
+	}
+
+	return addresses
+}
+
+// networkACLRuleApplies returns whether the rule has anything to match in the
+// given family, a rule only using IPv6 addresses is skipped for IPv4.
+func networkACLRuleApplies(family string, rule api.NetworkACLRule) bool {
+	if rule.Protocol == "icmp4" && family != "ipv4" {
+		return false
+	}
+
+	if rule.Protocol == "icmp6" && family != "ipv6" {
+		return false
+	}
+
+	if rule.Source != "" && len(networkACLFilterAddresses(family, rule.Source)) == 0 {
+		return false
+	}
+
+	if rule.Destination != "" && len(networkACLFilterAddresses(family, rule.Destination)) == 0 {
+		return false
+	}
+
+	return true
+}
+
+// networkACLRuleIptablesArgs renders an ACL rule as iptables (or ip6tables)
+// arguments, or returns nil if the rule doesn't apply to the family.
+func networkACLRuleIptablesArgs(family string, rule api.NetworkACLRule) []string {
+	if !networkACLRuleApplies(family, rule) {
+		return nil
+	}
+
+	args := []string{}
+
+	if rule.Source != "" {
+		args = append(args, "-s", strings.Join(networkACLFilterAddresses(family, rule.Source), ","))
+	}
+
+	if rule.Destination != "" {
+		args = append(args, "-d", strings.Join(networkACLFilterAddresses(family, rule.Destination), ","))
+	}
+
+	switch rule.Protocol {
+	case "tcp", "udp":
+		args = append(args, "-p", rule.Protocol)
+	case "icmp4":
+		args = append(args, "-p", "icmp")
+	case "icmp6":
+		args = append(args, "-p", "ipv6-icmp")
+	}
+
+	ports := func(value string) string {
+		return strings.Replace(strings.Replace(value, " ", "", -1), "-", ":", -1)
+	}
+
+	if rule.SourcePort != "" {
+		args = append(args, "-m", "multiport", "--sports", ports(rule.SourcePort))
+	}
+
+	if rule.DestinationPort != "" {
+		args = append(args, "-m", "multiport", "--dports", ports(rule.DestinationPort))
+	}
+
+	switch rule.Action {
+	case "allow":
+		args = append(args, "-j", "ACCEPT")
+	case "drop":
+		args = append(args, "-j", "DROP")
+	case "reject":
+		args = append(args, "-j", "REJECT")
+	}
+
+	return args
+}
+
+// networkACLRuleNftablesArgs renders an ACL rule as an nftables rule
+// statement, or returns nil if the rule doesn't apply to the family. The
+// caller is expected to restrict the rule to the family.
+func networkACLRuleNftablesArgs(family string, rule api.NetworkACLRule) []string {
+	if !networkACLRuleApplies(family, rule) {
+		return nil
+	}
+
+	args := []string{}
+
+	prefix := "ip"
+	if family == "ipv6" {
+		prefix = "ip6"
+	}
+
+	set := func(values []string) string {
+		return fmt.Sprintf("{ %s }", strings.Join(values, ", "))
+	}
+
+	if rule.Source != "" {
+		args = append(args, prefix, "saddr", set(networkACLFilterAddresses(family, rule.Source)))
+	}
+
+	if rule.Destination != "" {
+		args = append(args, prefix, "daddr", set(networkACLFilterAddresses(family, rule.Destination)))
+	}
+
+	switch rule.Protocol {
+	case "tcp", "udp":
+		args = append(args, "meta", "l4proto", rule.Protocol)
+	case "icmp4":
+		args = append(args, "meta", "l4proto", "icmp")
+	case "icmp6":
+		args = append(args, "meta", "l4proto", "ipv6-icmp")
+	}
+
+	ports := func(value string) string {
+		values := []string{}
+		for _, entry := range strings.Split(value, ",") {
+			values = append(values, strings.TrimSpace(entry))
+		}
+
+		return set(values)
+	}
+
+	if rule.SourcePort != "" {
+		args = append(args, rule.Protocol, "sport", ports(rule.SourcePort))
+	}
+
+	if rule.DestinationPort != "" {
+		args = append(args, rule.Protocol, "dport", ports(rule.DestinationPort))
+	}
+
+	switch rule.Action {
+	case "allow":
+		args = append(args, "accept")
+	case "drop":
+		args = append(args, "drop")
+	case "reject":
+		args = append(args, "reject")
+	}
+
+	return args
+}
+
+// networkACLRuleEbtablesArgs renders an ACL rule as ebtables arguments. As
+// ebtables only matches a single address or port range per rule, a rule may
+// expand to several ebtables rules. Rejecting isn't possible at layer 2, so
+// rejected frames are dropped instead.
+func networkACLRuleEbtablesArgs(family string, rule api.NetworkACLRule) [][]string {
+	if !networkACLRuleApplies(family, rule) {
+		return nil
+	}
+
+	prefix := "--ip"
+	base := []string{"-p", "IPv4"}
+	if family == "ipv6" {
+		prefix = "--ip6"
+		base = []string{"-p", "IPv6"}
+	}
+
+	switch rule.Protocol {
+	case "tcp", "udp":
+		base = append(base, prefix+"-proto", rule.Protocol)
+	case "icmp4":
+		base = append(base, prefix+"-proto", "icmp")
+	case "icmp6":
+		base = append(base, prefix+"-proto", "ipv6-icmp")
+	}
+
+	// Every combination of the listed addresses and ports
+	expand := func(rules [][]string, option string, values []string) [][]string {
+		if len(values) == 0 {
+			return rules
+		}
+
+		expanded := [][]string{}
+		for _, rule := range rules {
+			for _, value := range values {
+				entry := append([]string{}, rule...)
+				expanded = append(expanded, append(entry, option, value))
+			}
+		}
+
+		return expanded
+	}
+
+	split := func(value string) []string {
+		if value == "" {
+			return nil
+		}
+
+		values := []string{}
+		for _, entry := range strings.Split(value, ",") {
+			values = append(values, strings.Replace(strings.TrimSpace(entry), "-", ":", -1))
+		}
+
+		return values
+	}
+
+	rules := [][]string{base}
+	if rule.Source != "" {
+		rules = expand(rules, prefix+"-src", networkACLFilterAddresses(family, rule.Source))
+	}
+
+	if rule.Destination != "" {
+		rules = expand(rules, prefix+"-dst", networkACLFilterAddresses(family, rule.Destination))
+	}
+
+	rules = expand(rules, prefix+"-sport", split(rule.SourcePort))
+	rules = expand(rules, prefix+"-dport", split(rule.DestinationPort))
+
+	target := "DROP"
+	if rule.Action == "allow" {
+		target = "ACCEPT"
+	}
+
+	for i := range rules {
+		rules[i] = append(rules[i], "-j", target)
+	}
+
+	return rules
+}
+
+// networkACLLoad returns the ACLs of a "security.acls" value.
+func networkACLLoad(d *Daemon, value string) ([]*api.NetworkACL, error) {
+	acls := []*api.NetworkACL{}
+	for _, name := range networkACLSplitNames(value) {
+		_, acl, err := dbNetworkACLGet(d.db, name)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load network ACL '%s': %s", name, err)
+		}
+
+		acls = append(acls, acl)
+	}
+
+	return acls, nil
+}
+
+// networkACLNetworkApply applies the ACLs of a "security.acls" value to the
+// traffic routed through a managed network.
+func networkACLNetworkApply(d *Daemon, netName string, value string) error {
+	acls, err := networkACLLoad(d, value)
+	if err != nil {
+		return err
+	}
+
+	return d.firewall.NetworkSetupACLs(netName, acls)
+}
+
+// networkACLNicApply applies the ACLs of a "security.acls" value to the
+// traffic of a bridged container nic.
+func networkACLNicApply(d *Daemon, cName string, devName string, bridge string, hostName string, value string) error {
+	acls, err := networkACLLoad(d, value)
+	if err != nil {
+		return err
+	}
+
+	return d.firewall.NicSetupACLs(cName, devName, bridge, hostName, acls)
+}
+
+// networkACLNicChain returns the name of the chain holding the ACL rules of a
+// container nic for the given direction. The names are hashed to fit in the
+// 31 characters allowed by ebtables.
+func networkACLNicChain(cName string, devName string, direction string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s", cName, devName)))
+	return fmt.Sprintf("lxd-%x-%s", hash[:6], direction)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func Test_networkACLValidate(t *testing.T) {
+	valid := []api.NetworkACLRule{
+		{Action: "allow"},
+		{Action: "drop", Source: "10.0.0.1,fd00::/8"},
+		{Action: "reject", Protocol: "tcp", DestinationPort: "22, 8000-8080"},
+		{Action: "allow", Protocol: "icmp6"},
+	}
+
+	for _, rule := range valid {
+		err := networkACLValidate(api.NetworkACLPut{Ingress: []api.NetworkACLRule{rule}})
+		if err != nil {
+			t.Errorf("%+v should be accepted: %s", rule, err)
+		}
+	}
+
+	invalid := []api.NetworkACLRule{
+		{Action: "accept"},
+		{Action: "allow", Protocol: "sctp"},
+		{Action: "allow", Source: "10.0.0.256"},
+		{Action: "allow", Destination: "10.0.0.0/33"},
+		{Action: "allow", DestinationPort: "80"},
+		{Action: "allow", Protocol: "udp", SourcePort: "0"},
+		{Action: "allow", Protocol: "udp", SourcePort: "100-10"},
+	}
+
+	for _, rule := range invalid {
+		err := networkACLValidate(api.NetworkACLPut{Egress: []api.NetworkACLRule{rule}})
+		if err == nil {
+			t.Errorf("%+v should be refused", rule)
+		}
+	}
+}
+
+func Test_networkACLRuleIptablesArgs(t *testing.T) {
+	rule := api.NetworkACLRule{
+		Action:          "allow",
+		Source:          "10.0.0.0/8,fd00::/8",
+		Protocol:        "tcp",
+		DestinationPort: "80,8000-8080",
+	}
+
+	args := networkACLRuleIptablesArgs("ipv4", rule)
+	expected := []string{"-s", "10.0.0.0/8", "-p", "tcp", "-m", "multiport", "--dports", "80,8000:8080", "-j", "ACCEPT"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Unexpected IPv4 arguments: %v", args)
+	}
+
+	args = networkACLRuleIptablesArgs("ipv6", rule)
+	expected = []string{"-s", "fd00::/8", "-p", "tcp", "-m", "multiport", "--dports", "80,8000:8080", "-j", "ACCEPT"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Unexpected IPv6 arguments: %v", args)
+	}
+
+	// Rules which can't match the family are skipped
+	args = networkACLRuleIptablesArgs("ipv6", api.NetworkACLRule{Action: "drop", Destination: "10.0.0.1"})
+	if args != nil {
+		t.Errorf("IPv4 only rule rendered for IPv6: %v", args)
+	}
+
+	args = networkACLRuleIptablesArgs("ipv4", api.NetworkACLRule{Action: "reject", Protocol: "icmp6"})
+	if args != nil {
+		t.Errorf("ICMPv6 rule rendered for IPv4: %v", args)
+	}
+}
+
+func Test_networkACLRuleNftablesArgs(t *testing.T) {
+	rule := api.NetworkACLRule{
+		Action:          "reject",
+		Source:          "10.0.0.0/8,10.1.0.1,fd00::/8",
+		Protocol:        "tcp",
+		DestinationPort: "22, 8000-8080",
+	}
+
+	args := networkACLRuleNftablesArgs("ipv4", rule)
+	expected := []string{"ip", "saddr", "{ 10.0.0.0/8, 10.1.0.1 }", "meta", "l4proto", "tcp", "tcp", "dport", "{ 22, 8000-8080 }", "reject"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Unexpected IPv4 arguments: %v", args)
+	}
+
+	args = networkACLRuleNftablesArgs("ipv6", rule)
+	expected = []string{"ip6", "saddr", "{ fd00::/8 }", "meta", "l4proto", "tcp", "tcp", "dport", "{ 22, 8000-8080 }", "reject"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Unexpected IPv6 arguments: %v", args)
+	}
+
+	args = networkACLRuleNftablesArgs("ipv6", api.NetworkACLRule{Action: "allow", Protocol: "icmp6"})
+	expected = []string{"meta", "l4proto", "ipv6-icmp", "accept"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Unexpected ICMPv6 arguments: %v", args)
+	}
+
+	if networkACLRuleNftablesArgs("ipv4", api.NetworkACLRule{Action: "allow", Protocol: "icmp6"}) != nil {
+		t.Errorf("ICMPv6 rule rendered for IPv4")
+	}
+}
+
+func Test_networkACLRuleEbtablesArgs(t *testing.T) {
+	rule := api.NetworkACLRule{
+		Action:          "reject",
+		Destination:     "10.0.0.1,10.0.0.2",
+		Protocol:        "udp",
+		DestinationPort: "53,5353",
+	}
+
+	rules := networkACLRuleEbtablesArgs("ipv4", rule)
+	if len(rules) != 4 {
+		t.Fatalf("Unexpected number of rules: %d", len(rules))
+	}
+
+	expected := []string{"-p", "IPv4", "--ip-proto", "udp", "--ip-dst", "10.0.0.2", "--ip-dport", "5353", "-j", "DROP"}
+	if !reflect.DeepEqual(rules[3], expected) {
+		t.Errorf("Unexpected arguments: %v", rules[3])
+	}
+
+	if networkACLRuleEbtablesArgs("ipv6", rule) != nil {
+		t.Errorf("IPv4 only rule rendered for IPv6")
+	}
+}
+
+func Test_networkACLNicChain(t *testing.T) {
+	chain := networkACLNicChain("a-very-long-container-name", "eth0", "out")
+	if len(chain) > 31 {
+		t.Errorf("Chain name is too long: %s", chain)
+	}
+
+	if chain == networkACLNicChain("a-very-long-container-name", "eth1", "out") {
+		t.Errorf("Chain names of different devices collide")
+	}
+}
@@ -44,13 +44,14 @@ var networkConfigKeys = map[string]func(value string) error{
 	},
 
 	"tunnel.TARGET.protocol": func(value string) error {
-		return shared.IsOneOf(value, []string{"gre", "vxlan"})
+		return shared.IsOneOf(value, []string{"gre", "vxlan", "geneve"})
 	},
 	"tunnel.TARGET.local":  networkValidAddressV4,
 	"tunnel.TARGET.remote": networkValidAddressV4,
 	"tunnel.TARGET.port":   networkValidPort,
 	"tunnel.TARGET.group":  networkValidAddressV4,
 	"tunnel.TARGET.id":     shared.IsInt64,
+	"tunnel.TARGET.vlan":   networkValidVLAN,
 
 	"ipv4.address": func(value string) error {
 		if shared.IsOneOf(value, []string{"none", "auto"}) == nil {
@@ -72,8 +73,24 @@ var networkConfigKeys = map[string]func(value string) error{
 			return nil
 		}
 
+		if strings.HasPrefix(value, "delegated:") {
+			return networkValidName(networkDelegatedUplink(value))
+		}
+
 		return networkValidAddressCIDRV6(value)
 	},
+	"ipv6.delegated.subnet_id": func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 0 {
+			return fmt.Errorf("Invalid subnet ID: %s", value)
+		}
+
+		return nil
+	},
 	"ipv6.firewall":      shared.IsBool,
 	"ipv6.nat":           shared.IsBool,
 	"ipv6.dhcp":          shared.IsBool,
@@ -88,7 +105,12 @@ var networkConfigKeys = map[string]func(value string) error{
 		return shared.IsOneOf(value, []string{"dynamic", "managed", "none"})
 	},
 
+	"limits.ingress": networkValidBitRate,
+	"limits.egress":  networkValidBitRate,
+
 	"raw.dnsmasq": shared.IsAny,
+
+	"security.acls": networkACLValidNames,
 }
 
 func networkValidateConfig(name string, config map[string]string) error {
@@ -180,6 +202,41 @@ func networkValidateConfig(name string, config map[string]string) error {
 		}
 	}
 
+	// Tunnel checks
+	for _, tunnel := range networkGetTunnels(config) {
+		getConfig := func(key string) string {
+			return config[fmt.Sprintf("tunnel.%s.%s", tunnel, key)]
+		}
+
+		if config["bridge.driver"] == "openvswitch" {
+			if getConfig("protocol") != "" && getConfig("remote") == "" {
+				return fmt.Errorf("Open vSwitch tunnels require a remote address: %s", tunnel)
+			}
+
+			continue
+		}
+
+		if getConfig("vlan") != "" {
+			return fmt.Errorf("Tunnel VLANs require an Open vSwitch bridge: %s", tunnel)
+		}
+
+		if getConfig("protocol") == "geneve" && getConfig("remote") == "" {
+			return fmt.Errorf("Geneve tunnels require a remote address: %s", tunnel)
+		}
+	}
+
+	// Delegated prefix checks
+	if config["ipv6.delegated.subnet_id"] != "" && networkDelegatedUplink(config["ipv6.address"]) == "" {
+		return fmt.Errorf("ipv6.delegated.subnet_id requires a delegated ipv6.address")
+	}
+
+	// SLAAC only networks don't run DHCPv6
+	if config["ipv6.dhcp"] != "" && !shared.IsTrue(config["ipv6.dhcp"]) {
+		if shared.IsTrue(config["ipv6.dhcp.stateful"]) || config["ipv6.dhcp.ranges"] != "" {
+			return fmt.Errorf("Stateful DHCPv6 can't be configured when ipv6.dhcp is false")
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// networkDelegatedUplink returns the uplink of an "ipv6.address" of the form
+// "delegated:<uplink>", or an empty string.
+func networkDelegatedUplink(value string) string {
+	if !strings.HasPrefix(value, "delegated:") {
+		return ""
+	}
+
+	return strings.TrimPrefix(value, "delegated:")
+}
+
+// networkParseDelegatedPrefix looks for the prefix delegated to the host in
+// the routes installed by its DHCPv6 client. Those either go through the
+// uplink or are the unreachable placeholder most clients add for the prefix.
+// Routes naming the uplink as their device win, an unreachable route on
+// another device (usually "lo") is only used if it's the only one as it
+// can't be told apart from the prefix of another uplink.
+func networkParseDelegatedPrefix(output string, uplink string) *net.IPNet {
+	unreachables := []*net.IPNet{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		unreachable := false
+		if fields[0] == "unreachable" {
+			unreachable = true
+			fields = fields[1:]
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		_, prefix, err := net.ParseCIDR(fields[0])
+		if err != nil || prefix.IP.To4() != nil {
+			continue
+		}
+
+		size, _ := prefix.Mask.Size()
+		if size < 1 || size > 63 {
+			continue
+		}
+
+		dev := ""
+		for i := range fields[:len(fields)-1] {
+			if fields[i] == "dev" {
+				dev = fields[i+1]
+			}
+		}
+
+		if dev == uplink {
+			return prefix
+		}
+
+		if unreachable {
+			unreachables = append(unreachables, prefix)
+		}
+	}
+
+	if len(unreachables) == 1 {
+		return unreachables[0]
+	}
+
+	return nil
+}
+
+// networkDelegatedPrefix returns the prefix currently delegated through the
+// uplink, or nil if there is none.
+func networkDelegatedPrefix(uplink string) (*net.IPNet, error) {
+	output, err := shared.RunCommand("ip", "-6", "route", "show", "table", "all", "proto", "dhcp")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list the IPv6 routes: %s", strings.TrimSpace(output))
+	}
+
+	return networkParseDelegatedPrefix(output, uplink), nil
+}
+
+// networkDelegatedSubnet returns the bridge address (first host of the /64) of
+// a subnet of the delegated prefix.
+func networkDelegatedSubnet(prefix *net.IPNet, id int64) (string, error) {
+	size, _ := prefix.Mask.Size()
+	if id < 0 || big.NewInt(id).BitLen() > 64-size {
+		return "", fmt.Errorf("Subnet %d doesn't fit in the delegated prefix %s", id, prefix.String())
+	}
+
+	subnet := big.NewInt(0).SetBytes(prefix.IP.To16())
+	subnet.Or(subnet, big.NewInt(0).Lsh(big.NewInt(id), 64))
+	subnet.Add(subnet, big.NewInt(1))
+
+	ip := make(net.IP, net.IPv6len)
+	bytes := subnet.Bytes()
+	copy(ip[net.IPv6len-len(bytes):], bytes)
+
+	return fmt.Sprintf("%s/64", ip.String()), nil
+}
+
+// networkDelegatedAddress returns the IPv6 address of a bridge using a
+// delegated prefix, or an empty string while nothing is delegated.
+func networkDelegatedAddress(config map[string]string) (string, error) {
+	prefix, err := networkDelegatedPrefix(networkDelegatedUplink(config["ipv6.address"]))
+	if err != nil || prefix == nil {
+		return "", err
+	}
+
+	id, err := strconv.ParseInt(config["ipv6.delegated.subnet_id"], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("Invalid ipv6.delegated.subnet_id: %s", config["ipv6.delegated.subnet_id"])
+	}
+
+	return networkDelegatedSubnet(prefix, id)
+}
+
+// networkFillDelegated picks the first subnet of the delegated prefix not used
+// by another network of the same uplink. Subnet 0 is left to the host.
+func networkFillDelegated(d *Daemon, name string, config map[string]string) error {
+	uplink := networkDelegatedUplink(config["ipv6.address"])
+	if uplink == "" || config["ipv6.delegated.subnet_id"] != "" {
+		return nil
+	}
+
+	networks, err := dbNetworks(d.db)
+	if err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, other := range networks {
+		if other == name {
+			continue
+		}
+
+		_, dbInfo, err := dbNetworkGet(d.db, other)
+		if err != nil {
+			return err
+		}
+
+		if networkDelegatedUplink(dbInfo.Config["ipv6.address"]) == uplink {
+			used[dbInfo.Config["ipv6.delegated.subnet_id"]] = true
+		}
+	}
+
+	id := 1
+	for used[strconv.Itoa(id)] {
+		id++
+	}
+
+	config["ipv6.delegated.subnet_id"] = strconv.Itoa(id)
+
+	return nil
+}
+
+// networkUpdateDelegated restarts the running networks whose delegated prefix
+// changed, moving the bridge and dnsmasq to the new subnet.
+func networkUpdateDelegated(d *Daemon) {
+	networks, err := dbNetworks(d.db)
+	if err != nil {
+		return
+	}
+
+	for _, name := range networks {
+		n, err := networkLoadByName(d, name)
+		if err != nil || !n.IsRunning() || networkDelegatedUplink(n.config["ipv6.address"]) == "" {
+			continue
+		}
+
+		address, err := networkDelegatedAddress(n.config)
+		if err != nil {
+			shared.LogError("Failed to get the delegated prefix", log.Ctx{"network": name, "err": err})
+			continue
+		}
+
+		current, err := shared.RunCommand("ip", "-6", "addr", "show", "dev", name, "scope", "global")
+		if err != nil {
+			continue
+		}
+
+		if address != "" && strings.Contains(current, fmt.Sprintf("inet6 %s ", address)) {
+			continue
+		}
+
+		if address == "" && !strings.Contains(current, "inet6 ") {
+			continue
+		}
+
+		shared.LogInfo("Delegated prefix changed, restarting the network", log.Ctx{"network": name, "address": address})
+		err = n.Start()
+		if err != nil {
+			shared.LogError("Failed to restart the network", log.Ctx{"network": name, "err": err})
+		}
+	}
+}
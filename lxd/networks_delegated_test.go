@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func Test_networkParseDelegatedPrefix(t *testing.T) {
+	output := `default via fe80::1 dev eth0 proto dhcp metric 100 pref medium
+2001:db8:0:1::/64 dev eth0 proto dhcp metric 100 pref medium
+unreachable 2001:db8:1200::/56 dev lo proto dhcp metric 1024 pref medium
+`
+
+	prefix := networkParseDelegatedPrefix(output, "eth0")
+	if prefix == nil || prefix.String() != "2001:db8:1200::/56" {
+		t.Errorf("Unexpected delegated prefix: %v", prefix)
+	}
+
+	prefix = networkParseDelegatedPrefix("2001:db8:3400::/48 via fe80::1 dev eth1 proto dhcp metric 1024\n", "eth1")
+	if prefix == nil || prefix.String() != "2001:db8:3400::/48" {
+		t.Errorf("Unexpected delegated prefix: %v", prefix)
+	}
+
+	prefix = networkParseDelegatedPrefix("2001:db8:3400::/48 dev eth1 proto dhcp metric 1024\n", "eth0")
+	if prefix != nil {
+		t.Errorf("Prefix of another uplink picked: %v", prefix)
+	}
+
+	prefix = networkParseDelegatedPrefix("2001:db8:0:1::/64 dev eth0 proto dhcp\n", "eth0")
+	if prefix != nil {
+		t.Errorf("On-link /64 picked as delegated prefix: %v", prefix)
+	}
+	// Each uplink gets its own prefix
+	output = `unreachable 2001:db8:1200::/56 dev eth0 proto dhcp metric 1024 pref medium
+unreachable 2001:db8:3400::/56 dev eth1 proto dhcp metric 1024 pref medium
+`
+
+	prefix = networkParseDelegatedPrefix(output, "eth1")
+	if prefix == nil || prefix.String() != "2001:db8:3400::/56" {
+		t.Errorf("Unexpected delegated prefix for eth1: %v", prefix)
+	}
+
+	prefix = networkParseDelegatedPrefix(output, "eth0")
+	if prefix == nil || prefix.String() != "2001:db8:1200::/56" {
+		t.Errorf("Unexpected delegated prefix for eth0: %v", prefix)
+	}
+
+	// Placeholders on lo can't be told apart
+	output = `unreachable 2001:db8:1200::/56 dev lo proto dhcp metric 1024 pref medium
+unreachable 2001:db8:3400::/56 dev lo proto dhcp metric 1024 pref medium
+`
+
+	prefix = networkParseDelegatedPrefix(output, "eth0")
+	if prefix != nil {
+		t.Errorf("Ambiguous delegated prefix picked: %v", prefix)
+	}
+}
+
+func Test_networkDelegatedSubnet(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("2001:db8:1200::/56")
+
+	tests := map[int64]string{
+		0:   "2001:db8:1200::1/64",
+		1:   "2001:db8:1200:1::1/64",
+		255: "2001:db8:1200:ff::1/64",
+	}
+
+	for id, expected := range tests {
+		subnet, err := networkDelegatedSubnet(prefix, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if subnet != expected {
+			t.Errorf("Subnet %d: expected %s, got %s", id, expected, subnet)
+		}
+	}
+
+	_, err := networkDelegatedSubnet(prefix, 256)
+	if err == nil {
+		t.Errorf("Subnet outside of the delegated prefix should be refused")
+	}
+}
+
+func Test_networkValidateConfigDelegated(t *testing.T) {
+	valid := []map[string]string{
+		{"ipv6.address": "delegated:eth0"},
+		{"ipv6.address": "delegated:eth0", "ipv6.delegated.subnet_id": "3"},
+		{"ipv6.address": "fd42::1/64", "ipv6.dhcp": "false"},
+	}
+
+	for _, config := range valid {
+		err := networkValidateConfig("br0", config)
+		if err != nil {
+			t.Errorf("%v should be accepted: %s", config, err)
+		}
+	}
+
+	invalid := []map[string]string{
+		{"ipv6.address": "delegated:"},
+		{"ipv6.address": "delegated:eth0", "ipv6.delegated.subnet_id": "-1"},
+		{"ipv6.address": "fd42::1/64", "ipv6.delegated.subnet_id": "1"},
+		{"ipv6.address": "fd42::1/64", "ipv6.dhcp": "false", "ipv6.dhcp.stateful": "true"},
+	}
+
+	for _, config := range invalid {
+		err := networkValidateConfig("br0", config)
+		if err == nil {
+			t.Errorf("%v should be refused", config)
+		}
+	}
+}
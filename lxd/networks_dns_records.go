@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// API endpoints
+func networkDNSRecordsGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	recursionStr := r.FormValue("recursion")
+	recursion, err := strconv.Atoi(recursionStr)
+	if err != nil {
+		recursion = 0
+	}
+
+	// Only managed networks have DNS records
+	networkID, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	records, err := dbNetworkDNSRecords(d.db, networkID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if recursion == 0 {
+		resultString := []string{}
+		for _, record := range records {
+			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s/dns-records/%s", version.APIVersion, name, record.Name))
+		}
+
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, records)
+}
+
+func networkDNSRecordsPost(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	req := api.NetworkDNSRecordsPost{}
+
+	// Parse the request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	n, err := networkLoadByName(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Sanity checks
+	err = networkDNSRecordValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = networkDNSRecordValidate(req.NetworkDNSRecordPut)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	_, err = dbNetworkDNSRecordGet(d.db, n.id, req.Name)
+	if err == nil {
+		return BadRequest(fmt.Errorf("The DNS record already exists"))
+	}
+
+	if err != NoSuchObjectError {
+		return SmartError(err)
+	}
+
+	// Create the database entry
+	err = dbNetworkDNSRecordCreate(d.db, n.id, req.Name, req.NetworkDNSRecordPut)
+	if err != nil {
+		return InternalError(
+			fmt.Errorf("Error inserting %s into database: %s", req.Name, err))
+	}
+
+	// Reload dnsmasq
+	if n.IsRunning() {
+		err = networkUpdateStatic(d, n.name)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s/dns-records/%s", version.APIVersion, name, req.Name))
+}
+
+var networkDNSRecordsCmd = Command{name: "networks/{name}/dns-records", get: networkDNSRecordsGet, post: networkDNSRecordsPost}
+
+func networkDNSRecordGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	recordName := mux.Vars(r)["record"]
+
+	networkID, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	record, err := dbNetworkDNSRecordGet(d.db, networkID, recordName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	etag := []interface{}{record.Name, record.Description, record.Addresses}
+
+	return SyncResponseETag(true, record, etag)
+}
+
+func networkDNSRecordPut(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	recordName := mux.Vars(r)["record"]
+
+	n, err := networkLoadByName(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Get the existing record
+	record, err := dbNetworkDNSRecordGet(d.db, n.id, recordName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Validate the ETag
+	etag := []interface{}{record.Name, record.Description, record.Addresses}
+
+	err = etagCheck(r, etag)
+	if err != nil {
+		return PreconditionFailed(err)
+	}
+
+	req := api.NetworkDNSRecordPut{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	err = networkDNSRecordValidate(req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = dbNetworkDNSRecordUpdate(d.db, n.id, recordName, req)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Reload dnsmasq
+	if n.IsRunning() {
+		err = networkUpdateStatic(d, n.name)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return EmptySyncResponse
+}
+
+func networkDNSRecordDelete(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	recordName := mux.Vars(r)["record"]
+
+	n, err := networkLoadByName(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Get the existing record
+	_, err = dbNetworkDNSRecordGet(d.db, n.id, recordName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = dbNetworkDNSRecordDelete(d.db, n.id, recordName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Reload dnsmasq
+	if n.IsRunning() {
+		err = networkUpdateStatic(d, n.name)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return EmptySyncResponse
+}
+
+var networkDNSRecordCmd = Command{name: "networks/{name}/dns-records/{record}", get: networkDNSRecordGet, put: networkDNSRecordPut, delete: networkDNSRecordDelete}
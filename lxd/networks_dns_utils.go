@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// networkReservationValidHwaddr checks a MAC address and returns it in the
+// canonical form used as the key of the reservations.
+func networkReservationValidHwaddr(value string) (string, error) {
+	hwaddr, err := net.ParseMAC(value)
+	if err != nil || len(hwaddr) != 6 {
+		return "", fmt.Errorf("Invalid MAC address: %s", value)
+	}
+
+	return hwaddr.String(), nil
+}
+
+// networkValidReservedAddress checks that an address is a usable host address
+// of the subnet configured in the given network address key.
+func networkValidReservedAddress(config map[string]string, key string, value string) error {
+	family := "IPv4"
+	if key == "ipv6.address" {
+		family = "IPv6"
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil || (ip.To4() != nil) != (family == "IPv4") {
+		return fmt.Errorf("Not an %s address: %s", family, value)
+	}
+
+	if shared.StringInSlice(config[key], []string{"", "none"}) {
+		return fmt.Errorf("The network doesn't have %s enabled", family)
+	}
+
+	gateway, subnet, err := net.ParseCIDR(config[key])
+	if err != nil {
+		return err
+	}
+
+	if !subnet.Contains(ip) {
+		return fmt.Errorf("%s isn't part of the network subnet %s", value, subnet.String())
+	}
+
+	if ip.Equal(gateway) || ip.Equal(subnet.IP) {
+		return fmt.Errorf("%s can't be reserved", value)
+	}
+
+	return nil
+}
+
+// networkReservationValidate checks a DHCP reservation against the config of
+// its network.
+func networkReservationValidate(config map[string]string, reservation api.NetworkReservationPut) error {
+	if reservation.IPv4Address == "" && reservation.IPv6Address == "" {
+		return fmt.Errorf("A reservation needs an IPv4 or an IPv6 address")
+	}
+
+	if reservation.IPv4Address != "" {
+		err := networkValidReservedAddress(config, "ipv4.address", reservation.IPv4Address)
+		if err != nil {
+			return err
+		}
+	}
+
+	if reservation.IPv6Address != "" {
+		err := networkValidReservedAddress(config, "ipv6.address", reservation.IPv6Address)
+		if err != nil {
+			return err
+		}
+	}
+
+	if reservation.Hostname != "" && !shared.ValidHostname(reservation.Hostname) {
+		return fmt.Errorf("Invalid hostname: %s", reservation.Hostname)
+	}
+
+	return nil
+}
+
+// networkDNSRecordValidName checks that a record name is a valid DNS name,
+// either a single label or a dotted name.
+func networkDNSRecordValidName(name string) error {
+	if len(name) > 253 {
+		return fmt.Errorf("DNS name is too long (maximum 253 characters)")
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if !shared.ValidHostname(label) {
+			return fmt.Errorf("Invalid DNS name: %s", name)
+		}
+	}
+
+	return nil
+}
+
+func networkDNSRecordValidate(record api.NetworkDNSRecordPut) error {
+	if len(record.Addresses) == 0 {
+		return fmt.Errorf("A DNS record needs at least one address")
+	}
+
+	for _, address := range record.Addresses {
+		if net.ParseIP(address) == nil {
+			return fmt.Errorf("Invalid address: %s", address)
+		}
+	}
+
+	return nil
+}
+
+// networkReservationHostsLine renders a reservation in the dnsmasq
+// --dhcp-hostsfile format, the same way as the container static entries.
+func networkReservationHostsLine(config map[string]string, reservation api.NetworkReservation) string {
+	line := reservation.Hwaddr
+
+	if reservation.IPv4Address != "" {
+		line += fmt.Sprintf(",id:*,%s", reservation.IPv4Address)
+	}
+
+	if reservation.IPv6Address != "" {
+		line += fmt.Sprintf(",[%s]", reservation.IPv6Address)
+	}
+
+	if reservation.Hostname != "" && (config["dns.mode"] == "" || config["dns.mode"] == "managed") {
+		line += fmt.Sprintf(",%s", reservation.Hostname)
+	}
+
+	return line
+}
+
+// networkDNSRecordHostsLines renders a record in the dnsmasq --addn-hosts
+// format. Single label names also resolve within the network domain.
+func networkDNSRecordHostsLines(config map[string]string, record api.NetworkDNSRecord) []string {
+	names := record.Name
+	if !strings.Contains(record.Name, ".") {
+		dnsDomain := config["dns.domain"]
+		if dnsDomain == "" {
+			dnsDomain = "lxd"
+		}
+
+		names = fmt.Sprintf("%s.%s %s", record.Name, dnsDomain, record.Name)
+	}
+
+	lines := []string{}
+	for _, address := range record.Addresses {
+		lines = append(lines, fmt.Sprintf("%s %s", address, names))
+	}
+
+	return lines
+}
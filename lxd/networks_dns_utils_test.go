@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func Test_networkReservationValidate(t *testing.T) {
+	config := map[string]string{
+		"ipv4.address": "10.0.0.1/24",
+		"ipv6.address": "fd42::1/64",
+	}
+
+	valid := []api.NetworkReservationPut{
+		{IPv4Address: "10.0.0.10"},
+		{IPv6Address: "fd42::10", Hostname: "printer"},
+		{IPv4Address: "10.0.0.254", IPv6Address: "fd42::ff"},
+	}
+
+	for _, reservation := range valid {
+		err := networkReservationValidate(config, reservation)
+		if err != nil {
+			t.Errorf("%+v should be accepted: %s", reservation, err)
+		}
+	}
+
+	invalid := []api.NetworkReservationPut{
+		{},
+		{Hostname: "printer"},
+		{IPv4Address: "10.0.0.1"},
+		{IPv4Address: "10.0.1.10"},
+		{IPv4Address: "fd42::10"},
+		{IPv6Address: "10.0.0.10"},
+		{IPv4Address: "10.0.0.10", Hostname: "not_valid"},
+	}
+
+	for _, reservation := range invalid {
+		err := networkReservationValidate(config, reservation)
+		if err == nil {
+			t.Errorf("%+v should be refused", reservation)
+		}
+	}
+
+	err := networkReservationValidate(map[string]string{"ipv6.address": "none"}, api.NetworkReservationPut{IPv6Address: "fd42::10"})
+	if err == nil {
+		t.Errorf("IPv6 reservation accepted without IPv6")
+	}
+}
+
+func Test_networkReservationValidHwaddr(t *testing.T) {
+	hwaddr, err := networkReservationValidHwaddr("00:16:3E:AA:BB:CC")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hwaddr != "00:16:3e:aa:bb:cc" {
+		t.Errorf("MAC address not normalized: %s", hwaddr)
+	}
+
+	_, err = networkReservationValidHwaddr("00:16:3e:aa:bb")
+	if err == nil {
+		t.Errorf("Truncated MAC address accepted")
+	}
+}
+
+func Test_networkReservationHostsLine(t *testing.T) {
+	reservation := api.NetworkReservation{Hwaddr: "00:16:3e:aa:bb:cc"}
+	reservation.IPv4Address = "10.0.0.10"
+	reservation.IPv6Address = "fd42::10"
+	reservation.Hostname = "printer"
+
+	line := networkReservationHostsLine(map[string]string{}, reservation)
+	if line != "00:16:3e:aa:bb:cc,id:*,10.0.0.10,[fd42::10],printer" {
+		t.Errorf("Unexpected hosts line: %s", line)
+	}
+
+	line = networkReservationHostsLine(map[string]string{"dns.mode": "dynamic"}, reservation)
+	if line != "00:16:3e:aa:bb:cc,id:*,10.0.0.10,[fd42::10]" {
+		t.Errorf("Unexpected hosts line: %s", line)
+	}
+}
+
+func Test_networkDNSRecordHostsLines(t *testing.T) {
+	record := api.NetworkDNSRecord{Name: "gateway"}
+	record.Addresses = []string{"10.0.0.5", "fd42::5"}
+
+	lines := networkDNSRecordHostsLines(map[string]string{"dns.domain": "example.net"}, record)
+	expected := []string{"10.0.0.5 gateway.example.net gateway", "fd42::5 gateway.example.net gateway"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Unexpected hosts lines: %v", lines)
+	}
+
+	record.Name = "www.example.com"
+	lines = networkDNSRecordHostsLines(map[string]string{}, record)
+	expected = []string{"10.0.0.5 www.example.com", "fd42::5 www.example.com"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Unexpected hosts lines: %v", lines)
+	}
+
+	for _, name := range []string{"", "-foo", "foo..bar", "foo_bar"} {
+		if networkDNSRecordValidName(name) == nil {
+			t.Errorf("DNS name %q should be refused", name)
+		}
+	}
+}
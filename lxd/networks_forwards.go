@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// API endpoints
+func networkForwardsGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	recursionStr := r.FormValue("recursion")
+	recursion, err := strconv.Atoi(recursionStr)
+	if err != nil {
+		recursion = 0
+	}
+
+	// Only managed networks have forwards
+	networkID, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	forwards, err := dbNetworkForwards(d.db, networkID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if recursion == 0 {
+		resultString := []string{}
+		for _, forward := range forwards {
+			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s/forwards/%s", version.APIVersion, name, forward.ListenAddress))
+		}
+
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, forwards)
+}
+
+func networkForwardsPost(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	req := api.NetworkForwardsPost{}
+
+	// Parse the request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	n, err := networkLoadByName(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Sanity checks
+	if req.ListenAddress == "" {
+		return BadRequest(fmt.Errorf("No listen address provided"))
+	}
+
+	req.ListenAddress = networkForwardNormalizeAddress(req.ListenAddress)
+
+	err = networkForwardValidate(n.config, req.ListenAddress, req.NetworkForwardPut)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// A listen address can only be forwarded by one network
+	owner, err := dbNetworkForwardNetwork(d.db, req.ListenAddress)
+	if err == nil {
+		return BadRequest(fmt.Errorf("The address '%s' is already forwarded on network %s", req.ListenAddress, owner))
+	}
+
+	if err != NoSuchObjectError {
+		return SmartError(err)
+	}
+
+	// Create the database entry
+	err = dbNetworkForwardCreate(d.db, n.id, req.ListenAddress, req.NetworkForwardPut)
+	if err != nil {
+		return InternalError(
+			fmt.Errorf("Error inserting %s into database: %s", req.ListenAddress, err))
+	}
+
+	// Refresh the firewall
+	if n.IsRunning() {
+		err = networkForwardsApply(d, n)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s/forwards/%s", version.APIVersion, name, req.ListenAddress))
+}
+
+var networkForwardsCmd = Command{name: "networks/{name}/forwards", get: networkForwardsGet, post: networkForwardsPost}
+
+func networkForwardGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	listenAddress := networkForwardNormalizeAddress(mux.Vars(r)["address"])
+
+	networkID, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, forward, err := dbNetworkForwardGet(d.db, networkID, listenAddress)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	etag := []interface{}{forward.ListenAddress, forward.Description, forward.TargetAddress, forward.Ports}
+
+	return SyncResponseETag(true, forward, etag)
+}
+
+func networkForwardPut(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	listenAddress := networkForwardNormalizeAddress(mux.Vars(r)["address"])
+
+	n, err := networkLoadByName(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Get the existing forward
+	_, forward, err := dbNetworkForwardGet(d.db, n.id, listenAddress)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Validate the ETag
+	etag := []interface{}{forward.ListenAddress, forward.Description, forward.TargetAddress, forward.Ports}
+
+	err = etagCheck(r, etag)
+	if err != nil {
+		return PreconditionFailed(err)
+	}
+
+	req := api.NetworkForwardPut{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	err = networkForwardValidate(n.config, listenAddress, req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = dbNetworkForwardUpdate(d.db, n.id, listenAddress, req)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Refresh the firewall
+	if n.IsRunning() {
+		err = networkForwardsApply(d, n)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return EmptySyncResponse
+}
+
+func networkForwardDelete(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	listenAddress := networkForwardNormalizeAddress(mux.Vars(r)["address"])
+
+	n, err := networkLoadByName(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Get the existing forward
+	_, _, err = dbNetworkForwardGet(d.db, n.id, listenAddress)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = dbNetworkForwardDelete(d.db, n.id, listenAddress)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Refresh the firewall
+	if n.IsRunning() {
+		err = networkForwardsApply(d, n)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return EmptySyncResponse
+}
+
+var networkForwardCmd = Command{name: "networks/{name}/forwards/{address}", get: networkForwardGet, put: networkForwardPut, delete: networkForwardDelete}
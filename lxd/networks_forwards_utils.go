@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// networkForwardRule is a single DNAT rule of a network forward. An empty
+// protocol matches all the traffic, empty ports keep the original port.
+type networkForwardRule struct {
+	protocol      string
+	listenPort    string
+	targetAddress string
+	targetPort    string
+}
+
+// networkForwardExpandPorts turns a validated list of ports and port ranges
+// into single ports.
+func networkForwardExpandPorts(value string) []int {
+	ports := []int{}
+
+	for _, entry := range strings.Split(value, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(entry), "-", 2)
+		start, _ := strconv.Atoi(bounds[0])
+		end := start
+		if len(bounds) == 2 {
+			end, _ = strconv.Atoi(bounds[1])
+		}
+
+		for port := start; port <= end; port++ {
+			ports = append(ports, port)
+		}
+	}
+
+	return ports
+}
+
+// networkForwardNormalizeAddress returns the canonical form of a listen
+// address so that different spellings of an IPv6 address match.
+func networkForwardNormalizeAddress(address string) string {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return address
+	}
+
+	return ip.String()
+}
+
+// networkForwardValidate checks a forward, its target addresses have to be
+// in the subnet of the network of the same family as the listen address.
+func networkForwardValidate(config map[string]string, listenAddress string, forward api.NetworkForwardPut) error {
+	listenIP := net.ParseIP(listenAddress)
+	if listenIP == nil {
+		return fmt.Errorf("Invalid listen address '%s'", listenAddress)
+	}
+
+	checkTarget := func(address string) error {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return fmt.Errorf("Invalid target address '%s'", address)
+		}
+
+		key := "ipv4.address"
+		if ip.To4() == nil {
+			key = "ipv6.address"
+		}
+
+		if (ip.To4() == nil) != (listenIP.To4() == nil) {
+			return fmt.Errorf("Target address '%s' isn't of the family of the listen address", address)
+		}
+
+		// Bridges using a delegated prefix get their subnet from it
+		networkAddress := config[key]
+		if key == "ipv6.address" && networkDelegatedUplink(networkAddress) != "" {
+			delegated, err := networkDelegatedAddress(config)
+			if err != nil {
+				return err
+			}
+
+			if delegated == "" {
+				return fmt.Errorf("No IPv6 prefix is delegated to the network yet")
+			}
+
+			networkAddress = delegated
+		}
+
+		_, subnet, err := net.ParseCIDR(networkAddress)
+		if err != nil || !subnet.Contains(ip) {
+			return fmt.Errorf("Target address '%s' isn't on the network", address)
+		}
+
+		return nil
+	}
+
+	if forward.TargetAddress != "" {
+		err := checkTarget(forward.TargetAddress)
+		if err != nil {
+			return err
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, port := range forward.Ports {
+		err := shared.IsOneOf(port.Protocol, []string{"tcp", "udp"})
+		if err != nil {
+			return fmt.Errorf("Invalid port protocol: %s", err)
+		}
+
+		if port.ListenPort == "" {
+			return fmt.Errorf("Missing listen port")
+		}
+
+		err = networkACLValidPorts(port.ListenPort)
+		if err != nil {
+			return err
+		}
+
+		err = checkTarget(port.TargetAddress)
+		if err != nil {
+			return err
+		}
+
+		listenPorts := networkForwardExpandPorts(port.ListenPort)
+		if port.TargetPort != "" {
+			err = networkACLValidPorts(port.TargetPort)
+			if err != nil {
+				return err
+			}
+
+			targetPorts := networkForwardExpandPorts(port.TargetPort)
+			if len(targetPorts) != 1 && len(targetPorts) != len(listenPorts) {
+				return fmt.Errorf("Target port '%s' must be a single port or match the number of listen ports", port.TargetPort)
+			}
+		}
+
+		for _, listenPort := range listenPorts {
+			key := fmt.Sprintf("%d/%s", listenPort, port.Protocol)
+			if seen[key] {
+				return fmt.Errorf("Port %s is forwarded more than once", key)
+			}
+
+			seen[key] = true
+		}
+	}
+
+	return nil
+}
+
+// networkForwardRules returns the DNAT rules of a forward, in order. Port
+// ranges which are translated get a rule for each port.
+func networkForwardRules(forward api.NetworkForward) []networkForwardRule {
+	rules := []networkForwardRule{}
+
+	for _, port := range forward.Ports {
+		if port.TargetPort == "" {
+			rules = append(rules, networkForwardRule{
+				protocol:      port.Protocol,
+				listenPort:    strings.Replace(port.ListenPort, " ", "", -1),
+				targetAddress: port.TargetAddress,
+			})
+
+			continue
+		}
+
+		listenPorts := networkForwardExpandPorts(port.ListenPort)
+		targetPorts := networkForwardExpandPorts(port.TargetPort)
+		for i, listenPort := range listenPorts {
+			targetPort := targetPorts[0]
+			if len(targetPorts) > 1 {
+				targetPort = targetPorts[i]
+			}
+
+			rules = append(rules, networkForwardRule{
+				protocol:      port.Protocol,
+				listenPort:    strconv.Itoa(listenPort),
+				targetAddress: port.TargetAddress,
+				targetPort:    strconv.Itoa(targetPort),
+			})
+		}
+	}
+
+	// The rest of the traffic goes to the default target
+	if forward.TargetAddress != "" {
+		rules = append(rules, networkForwardRule{targetAddress: forward.TargetAddress})
+	}
+
+	return rules
+}
+
+// networkForwardTarget formats the destination of a DNAT rule.
+func networkForwardTarget(rule networkForwardRule) string {
+	if rule.targetPort == "" {
+		return rule.targetAddress
+	}
+
+	if strings.Contains(rule.targetAddress, ":") {
+		return fmt.Sprintf("[%s]:%s", rule.targetAddress, rule.targetPort)
+	}
+
+	return fmt.Sprintf("%s:%s", rule.targetAddress, rule.targetPort)
+}
+
+// networkForwardIptablesArgs returns the iptables arguments of a DNAT rule.
+func networkForwardIptablesArgs(listenAddress string, rule networkForwardRule) []string {
+	args := []string{"-d", listenAddress}
+
+	if rule.protocol != "" {
+		args = append(args, "-p", rule.protocol)
+
+		if strings.Contains(rule.listenPort, ",") {
+			args = append(args, "-m", "multiport", "--dports", strings.Replace(rule.listenPort, "-", ":", -1))
+		} else {
+			args = append(args, "--dport", strings.Replace(rule.listenPort, "-", ":", -1))
+		}
+	}
+
+	return append(args, "-j", "DNAT", "--to-destination", networkForwardTarget(rule))
+}
+
+// networkForwardNftablesArgs returns the nft statement of a DNAT rule.
+func networkForwardNftablesArgs(listenAddress string, rule networkForwardRule) []string {
+	family := "ip"
+	if strings.Contains(listenAddress, ":") {
+		family = "ip6"
+	}
+
+	args := []string{family, "daddr", listenAddress}
+
+	if rule.protocol != "" {
+		ports := strings.Split(rule.listenPort, ",")
+		args = append(args, "meta", "l4proto", rule.protocol, rule.protocol, "dport", fmt.Sprintf("{ %s }", strings.Join(ports, ", ")))
+	}
+
+	return append(args, "dnat", family, "to", networkForwardTarget(rule))
+}
+
+// networkForwardsApply sets up the DNAT rules of all the forwards of a
+// running network.
+func networkForwardsApply(d *Daemon, n *network) error {
+	forwards, err := dbNetworkForwards(d.db, n.id)
+	if err != nil {
+		return err
+	}
+
+	return d.firewall.NetworkSetupForwards(n.name, forwards)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func Test_networkForwardValidate(t *testing.T) {
+	config := map[string]string{"ipv4.address": "10.0.0.1/24", "ipv6.address": "fd42::1/64"}
+
+	valid := []api.NetworkForwardPut{
+		{TargetAddress: "10.0.0.5"},
+		{Ports: []api.NetworkForwardPort{{Protocol: "tcp", ListenPort: "80,443", TargetAddress: "10.0.0.5"}}},
+		{Ports: []api.NetworkForwardPort{
+			{Protocol: "tcp", ListenPort: "8000-8002", TargetPort: "80-82", TargetAddress: "10.0.0.5"},
+			{Protocol: "udp", ListenPort: "8000", TargetPort: "53", TargetAddress: "10.0.0.6"},
+		}},
+	}
+
+	for _, forward := range valid {
+		err := networkForwardValidate(config, "192.0.2.1", forward)
+		if err != nil {
+			t.Errorf("%+v should be accepted: %s", forward, err)
+		}
+	}
+
+	invalid := []api.NetworkForwardPut{
+		{TargetAddress: "10.1.0.5"},
+		{TargetAddress: "fd42::5"},
+		{Ports: []api.NetworkForwardPort{{Protocol: "icmp", ListenPort: "80", TargetAddress: "10.0.0.5"}}},
+		{Ports: []api.NetworkForwardPort{{Protocol: "tcp", TargetAddress: "10.0.0.5"}}},
+		{Ports: []api.NetworkForwardPort{{Protocol: "tcp", ListenPort: "80-82", TargetPort: "80-81", TargetAddress: "10.0.0.5"}}},
+		{Ports: []api.NetworkForwardPort{
+			{Protocol: "tcp", ListenPort: "80-82", TargetAddress: "10.0.0.5"},
+			{Protocol: "tcp", ListenPort: "81", TargetAddress: "10.0.0.6"},
+		}},
+	}
+
+	for _, forward := range invalid {
+		err := networkForwardValidate(config, "192.0.2.1", forward)
+		if err == nil {
+			t.Errorf("%+v should be refused", forward)
+		}
+	}
+
+	err := networkForwardValidate(config, "2001:db8::1", api.NetworkForwardPut{TargetAddress: "fd42::5"})
+	if err != nil {
+		t.Errorf("IPv6 forward should be accepted: %s", err)
+	}
+
+	err = networkForwardValidate(config, "not-an-ip", api.NetworkForwardPut{TargetAddress: "10.0.0.5"})
+	if err == nil {
+		t.Errorf("Invalid listen address should be refused")
+	}
+}
+
+func Test_networkForwardNormalizeAddress(t *testing.T) {
+	tests := map[string]string{
+		"2001:DB8:0::1":   "2001:db8::1",
+		"2001:db8::1":     "2001:db8::1",
+		"::ffff:10.0.0.1": "10.0.0.1",
+		"192.0.2.1":       "192.0.2.1",
+		"not-an-ip":       "not-an-ip",
+	}
+
+	for address, expected := range tests {
+		normalized := networkForwardNormalizeAddress(address)
+		if normalized != expected {
+			t.Errorf("%s: expected %s, got %s", address, expected, normalized)
+		}
+	}
+}
+
+func Test_networkForwardRules(t *testing.T) {
+	forward := api.NetworkForward{
+		ListenAddress: "192.0.2.1",
+		NetworkForwardPut: api.NetworkForwardPut{
+			TargetAddress: "10.0.0.5",
+			Ports: []api.NetworkForwardPort{
+				{Protocol: "tcp", ListenPort: "80, 443", TargetAddress: "10.0.0.6"},
+				{Protocol: "udp", ListenPort: "8000-8001", TargetPort: "53", TargetAddress: "10.0.0.7"},
+			},
+		},
+	}
+
+	expected := []networkForwardRule{
+		{protocol: "tcp", listenPort: "80,443", targetAddress: "10.0.0.6"},
+		{protocol: "udp", listenPort: "8000", targetAddress: "10.0.0.7", targetPort: "53"},
+		{protocol: "udp", listenPort: "8001", targetAddress: "10.0.0.7", targetPort: "53"},
+		{targetAddress: "10.0.0.5"},
+	}
+
+	rules := networkForwardRules(forward)
+	if !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("Unexpected rules: %+v", rules)
+	}
+
+	args := networkForwardIptablesArgs("192.0.2.1", rules[0])
+	expectedArgs := []string{"-d", "192.0.2.1", "-p", "tcp", "-m", "multiport", "--dports", "80,443", "-j", "DNAT", "--to-destination", "10.0.0.6"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Unexpected iptables arguments: %v", args)
+	}
+
+	args = networkForwardNftablesArgs("192.0.2.1", rules[1])
+	expectedArgs = []string{"ip", "daddr", "192.0.2.1", "meta", "l4proto", "udp", "udp", "dport", "{ 8000 }", "dnat", "ip", "to", "10.0.0.7:53"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Unexpected nft arguments: %v", args)
+	}
+
+	rule := networkForwardRule{protocol: "tcp", listenPort: "2000-2010", targetAddress: "fd42::5", targetPort: "22"}
+	args = networkForwardIptablesArgs("2001:db8::1", rule)
+	expectedArgs = []string{"-d", "2001:db8::1", "-p", "tcp", "--dport", "2000:2010", "-j", "DNAT", "--to-destination", "[fd42::5]:22"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Unexpected ip6tables arguments: %v", args)
+	}
+}
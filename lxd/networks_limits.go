@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/lxc/lxd/lxd/types"
+	"github.com/lxc/lxd/shared"
+)
+
+func networkValidBitRate(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	_, err := shared.ParseBitSizeString(value)
+	return err
+}
+
+// networkNicLimits returns the effective ingress and egress limits of a nic.
+// Bridged nics fall back to the limits of their managed network for the
+// directions they don't limit themselves.
+func networkNicLimits(m types.Device, netConfig map[string]string) (string, string) {
+	ingress := m["limits.ingress"]
+	egress := m["limits.egress"]
+
+	if m["limits.max"] != "" {
+		ingress = m["limits.max"]
+		egress = m["limits.max"]
+	}
+
+	if m["nictype"] != "bridged" || netConfig == nil {
+		return ingress, egress
+	}
+
+	if ingress == "" {
+		ingress = netConfig["limits.ingress"]
+	}
+
+	if egress == "" {
+		egress = netConfig["limits.egress"]
+	}
+
+	return ingress, egress
+}
+
+// networkParseTCRate extracts the rate following a keyword (the "htb" class
+// or the "police" action) in the output of "tc".
+func networkParseTCRate(output string, keyword string) string {
+	fields := strings.Fields(output)
+	for i, field := range fields {
+		if field != keyword {
+			continue
+		}
+
+		for j := i + 1; j < len(fields)-1; j++ {
+			if fields[j] == "rate" {
+				return fields[j+1]
+			}
+		}
+	}
+
+	return ""
+}
+
+// networkTCLimits returns the traffic shaping currently installed on a host
+// side interface, the ingress of the container being the egress of the host.
+func networkTCLimits(hostName string) (string, string) {
+	ingress := ""
+	out, err := shared.RunCommand("tc", "class", "show", "dev", hostName)
+	if err == nil {
+		ingress = networkParseTCRate(out, "htb")
+	}
+
+	egress := ""
+	out, err = shared.RunCommand("tc", "filter", "show", "dev", hostName, "parent", "ffff:")
+	if err == nil {
+		egress = networkParseTCRate(out, "police")
+	}
+
+	return ingress, egress
+}
+
+// networkUpdateLimits refreshes the traffic shaping of the running containers
+// attached to a managed network, following a change of its default limits.
+func networkUpdateLimits(d *Daemon, name string) error {
+	cts, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		return err
+	}
+
+	for _, ct := range cts {
+		c, err := containerLoadByName(d, ct)
+		if err != nil {
+			return err
+		}
+
+		if !c.IsRunning() {
+			continue
+		}
+
+		for _, k := range c.ExpandedDevices().DeviceNames() {
+			m := c.ExpandedDevices()[k]
+			if m["type"] != "nic" || m["nictype"] != "bridged" || m["parent"] != name {
+				continue
+			}
+
+			err = c.(*containerLXC).setNetworkLimits(k, m)
+			if err != nil {
+				shared.LogError("Failed to apply network limits", log.Ctx{"container": c.Name(), "device": k, "err": err})
+			}
+		}
+	}
+
+	return nil
+}
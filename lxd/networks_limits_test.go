@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lxc/lxd/lxd/types"
+)
+
+func Test_networkNicLimits(t *testing.T) {
+	netConfig := map[string]string{"limits.ingress": "10Mbit", "limits.egress": "5Mbit"}
+
+	tests := []struct {
+		m       types.Device
+		config  map[string]string
+		ingress string
+		egress  string
+	}{
+		{types.Device{"nictype": "bridged"}, netConfig, "10Mbit", "5Mbit"},
+		{types.Device{"nictype": "bridged", "limits.ingress": "1Mbit"}, netConfig, "1Mbit", "5Mbit"},
+		{types.Device{"nictype": "bridged", "limits.max": "2Mbit"}, netConfig, "2Mbit", "2Mbit"},
+		{types.Device{"nictype": "bridged"}, nil, "", ""},
+		{types.Device{"nictype": "p2p"}, netConfig, "", ""},
+		{types.Device{"nictype": "p2p", "limits.egress": "3Mbit"}, nil, "", "3Mbit"},
+	}
+
+	for _, test := range tests {
+		ingress, egress := networkNicLimits(test.m, test.config)
+		if ingress != test.ingress || egress != test.egress {
+			t.Errorf("%v: expected %q/%q, got %q/%q", test.m, test.ingress, test.egress, ingress, egress)
+		}
+	}
+}
+
+func Test_networkParseTCRate(t *testing.T) {
+	class := "class htb 1:10 root prio 0 rate 10Mbit ceil 10Mbit burst 1600b cburst 1600b \n"
+	if rate := networkParseTCRate(class, "htb"); rate != "10Mbit" {
+		t.Errorf("Unexpected htb rate: %q", rate)
+	}
+
+	filter := `filter parent ffff: protocol all pref 49152 u32 chain 0
+filter parent ffff: protocol all pref 49152 u32 chain 0 fh 800: ht divisor 1
+filter parent ffff: protocol all pref 49152 u32 chain 0 fh 800::800 order 2048 key ht 800 bkt 0 flowid :1 not_in_hw
+  match 00000000/00000000 at 0
+ police 0x1 rate 5Mbit burst 1Mb mtu 64Kb action drop overhead 0b
+	ref 1 bind 1
+`
+	if rate := networkParseTCRate(filter, "police"); rate != "5Mbit" {
+		t.Errorf("Unexpected police rate: %q", rate)
+	}
+
+	if rate := networkParseTCRate("", "htb"); rate != "" {
+		t.Errorf("Unexpected rate without any class: %q", rate)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// networkIsOVSBridge returns whether an interface is an Open vSwitch bridge.
+func networkIsOVSBridge(name string) bool {
+	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s/bridge", name)) {
+		return false
+	}
+
+	_, err := shared.RunCommand("ovs-vsctl", "br-exists", name)
+	return err == nil
+}
+
+func networkValidVLAN(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	vlan, err := strconv.Atoi(value)
+	if err != nil || vlan < 0 || vlan > 4094 {
+		return fmt.Errorf("Invalid VLAN ID: %s", value)
+	}
+
+	return nil
+}
+
+// networkValidVLANList checks a comma separated list of VLAN IDs.
+func networkValidVLANList(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		err := networkValidVLAN(strings.TrimSpace(entry))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// networkOVSPortVLANArgs returns the port settings for a VLAN access port
+// (vlan), a trunk port (tagged) or a trunk with an untagged native VLAN (both).
+func networkOVSPortVLANArgs(vlan string, tagged string) []string {
+	trunks := []string{}
+	for _, entry := range strings.Split(tagged, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			trunks = append(trunks, entry)
+		}
+	}
+
+	if vlan != "" && len(trunks) > 0 {
+		return []string{"vlan_mode=native-untagged", fmt.Sprintf("tag=%s", vlan), fmt.Sprintf("trunks=%s", strings.Join(trunks, ","))}
+	}
+
+	if vlan != "" {
+		return []string{"vlan_mode=access", fmt.Sprintf("tag=%s", vlan)}
+	}
+
+	if len(trunks) > 0 {
+		return []string{"vlan_mode=trunk", fmt.Sprintf("trunks=%s", strings.Join(trunks, ","))}
+	}
+
+	return nil
+}
+
+// networkOVSSetPortVLAN applies the VLAN settings of a nic to its port on an
+// Open vSwitch bridge.
+func networkOVSSetPortVLAN(bridge string, port string, vlan string, tagged string) error {
+	args := networkOVSPortVLANArgs(vlan, tagged)
+	if args == nil {
+		return nil
+	}
+
+	if !networkIsOVSBridge(bridge) {
+		return fmt.Errorf("VLANs on bridged nics require an Open vSwitch bridge: %s", bridge)
+	}
+
+	_, err := shared.RunCommand("ovs-vsctl", append([]string{"set", "port", port}, args...)...)
+	return err
+}
+
+// networkOVSTunnelArgs returns the ovs-vsctl arguments creating a native Open
+// vSwitch tunnel port on a bridge, getConfig returning the tunnel keys.
+func networkOVSTunnelArgs(bridge string, tunName string, getConfig func(key string) string) []string {
+	args := []string{"--may-exist", "add-port", bridge, tunName}
+	if getConfig("vlan") != "" {
+		args = append(args, fmt.Sprintf("tag=%s", getConfig("vlan")))
+	}
+
+	args = append(args, "--", "set", "interface", tunName, fmt.Sprintf("type=%s", getConfig("protocol")), fmt.Sprintf("options:remote_ip=%s", getConfig("remote")))
+
+	if getConfig("local") != "" {
+		args = append(args, fmt.Sprintf("options:local_ip=%s", getConfig("local")))
+	}
+
+	if getConfig("id") != "" {
+		args = append(args, fmt.Sprintf("options:key=%s", getConfig("id")))
+	}
+
+	if getConfig("port") != "" && getConfig("protocol") != "gre" {
+		args = append(args, fmt.Sprintf("options:dst_port=%s", getConfig("port")))
+	}
+
+	return args
+}
+
+// networkOVSClearTunnels removes the tunnel ports of a managed network from
+// its Open vSwitch bridge.
+func networkOVSClearTunnels(bridge string) error {
+	output, err := shared.RunCommand("ovs-vsctl", "list-ports", bridge)
+	if err != nil {
+		return err
+	}
+
+	for _, port := range strings.Split(output, "\n") {
+		port = strings.TrimSpace(port)
+		if !strings.HasPrefix(port, fmt.Sprintf("%s-", bridge)) {
+			continue
+		}
+
+		_, err = shared.RunCommand("ovs-vsctl", "del-port", bridge, port)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
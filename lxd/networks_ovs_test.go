@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_networkOVSPortVLANArgs(t *testing.T) {
+	tests := []struct {
+		vlan     string
+		tagged   string
+		expected []string
+	}{
+		{"", "", nil},
+		{"10", "", []string{"vlan_mode=access", "tag=10"}},
+		{"", "20, 30", []string{"vlan_mode=trunk", "trunks=20,30"}},
+		{"10", "20,30", []string{"vlan_mode=native-untagged", "tag=10", "trunks=20,30"}},
+	}
+
+	for _, test := range tests {
+		args := networkOVSPortVLANArgs(test.vlan, test.tagged)
+		if !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("Unexpected arguments for vlan=%q tagged=%q: %v", test.vlan, test.tagged, args)
+		}
+	}
+}
+
+func Test_networkOVSTunnelArgs(t *testing.T) {
+	config := map[string]string{
+		"protocol": "geneve",
+		"remote":   "192.0.2.2",
+		"id":       "42",
+		"port":     "6082",
+		"vlan":     "10",
+	}
+
+	args := networkOVSTunnelArgs("ovsbr0", "ovsbr0-site2", func(key string) string { return config[key] })
+	expected := []string{"--may-exist", "add-port", "ovsbr0", "ovsbr0-site2", "tag=10", "--", "set", "interface", "ovsbr0-site2", "type=geneve", "options:remote_ip=192.0.2.2", "options:key=42", "options:dst_port=6082"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Unexpected arguments: %v", args)
+	}
+}
+
+func Test_networkValidateConfigTunnels(t *testing.T) {
+	valid := []map[string]string{
+		{"tunnel.site2.protocol": "geneve", "tunnel.site2.remote": "192.0.2.2", "tunnel.site2.id": "42"},
+		{"bridge.driver": "openvswitch", "tunnel.site2.protocol": "vxlan", "tunnel.site2.remote": "192.0.2.2", "tunnel.site2.vlan": "10"},
+	}
+
+	for _, config := range valid {
+		err := networkValidateConfig("br0", config)
+		if err != nil {
+			t.Errorf("%v should be accepted: %s", config, err)
+		}
+	}
+
+	invalid := []map[string]string{
+		{"tunnel.site2.protocol": "geneve"},
+		{"tunnel.site2.protocol": "gre", "tunnel.site2.local": "192.0.2.1", "tunnel.site2.remote": "192.0.2.2", "tunnel.site2.vlan": "10"},
+		{"bridge.driver": "openvswitch", "tunnel.site2.protocol": "vxlan"},
+		{"bridge.driver": "openvswitch", "tunnel.site2.protocol": "vxlan", "tunnel.site2.remote": "192.0.2.2", "tunnel.site2.vlan": "4095"},
+	}
+
+	for _, config := range invalid {
+		err := networkValidateConfig("br0", config)
+		if err == nil {
+			t.Errorf("%v should be refused", config)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// API endpoints
+func networkReservationsGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	recursionStr := r.FormValue("recursion")
+	recursion, err := strconv.Atoi(recursionStr)
+	if err != nil {
+		recursion = 0
+	}
+
+	// Only managed networks have reservations
+	networkID, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	reservations, err := dbNetworkReservations(d.db, networkID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if recursion == 0 {
+		resultString := []string{}
+		for _, reservation := range reservations {
+			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s/reservations/%s", version.APIVersion, name, reservation.Hwaddr))
+		}
+
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, reservations)
+}
+
+func networkReservationsPost(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+	req := api.NetworkReservationsPost{}
+
+	// Parse the request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	n, err := networkLoadByName(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Sanity checks
+	hwaddr, err := networkReservationValidHwaddr(req.Hwaddr)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = networkReservationValidate(n.config, req.NetworkReservationPut)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	_, err = dbNetworkReservationGet(d.db, n.id, hwaddr)
+	if err == nil {
+		return BadRequest(fmt.Errorf("The reservation already exists"))
+	}
+
+	if err != NoSuchObjectError {
+		return SmartError(err)
+	}
+
+	err = networkReservationCheckConflicts(d, n, hwaddr, req.NetworkReservationPut)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Create the database entry
+	err = dbNetworkReservationCreate(d.db, n.id, hwaddr, req.NetworkReservationPut)
+	if err != nil {
+		return InternalError(
+			fmt.Errorf("Error inserting %s into database: %s", hwaddr, err))
+	}
+
+	// Reload dnsmasq
+	if n.IsRunning() {
+		err = networkUpdateStatic(d, n.name)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s/reservations/%s", version.APIVersion, name, hwaddr))
+}
+
+var networkReservationsCmd = Command{name: "networks/{name}/reservations", get: networkReservationsGet, post: networkReservationsPost}
+
+// networkReservationCheckConflicts makes sure a reservation doesn't clash with
+// the static addresses of the containers or with the other reservations.
+func networkReservationCheckConflicts(d *Daemon, n *network, hwaddr string, reservation api.NetworkReservationPut) error {
+	sameIP := func(a string, b string) bool {
+		return a != "" && b != "" && net.ParseIP(a).Equal(net.ParseIP(b))
+	}
+
+	entries, err := networkGetStaticEntries(d, []string{n.name})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries[n.name] {
+		entryHwaddr, _ := networkReservationValidHwaddr(entry[0])
+		if entryHwaddr == hwaddr {
+			return fmt.Errorf("The MAC address is used by container \"%s\"", entry[1])
+		}
+
+		if sameIP(entry[2], reservation.IPv4Address) || sameIP(entry[3], reservation.IPv6Address) {
+			return fmt.Errorf("The address is used by container \"%s\"", entry[1])
+		}
+	}
+
+	reservations, err := dbNetworkReservations(d.db, n.id)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range reservations {
+		if other.Hwaddr == hwaddr {
+			continue
+		}
+
+		if sameIP(other.IPv4Address, reservation.IPv4Address) || sameIP(other.IPv6Address, reservation.IPv6Address) {
+			return fmt.Errorf("The address is already reserved for %s", other.Hwaddr)
+		}
+	}
+
+	return nil
+}
+
+func networkReservationGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	networkID, _, err := dbNetworkGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	hwaddr, err := networkReservationValidHwaddr(mux.Vars(r)["hwaddr"])
+	if err != nil {
+		return NotFound
+	}
+
+	reservation, err := dbNetworkReservationGet(d.db, networkID, hwaddr)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	etag := []interface{}{reservation.Hwaddr, reservation.Description, reservation.IPv4Address, reservation.IPv6Address, reservation.Hostname}
+
+	return SyncResponseETag(true, reservation, etag)
+}
+
+func networkReservationPut(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	n, err := networkLoadByName(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	hwaddr, err := networkReservationValidHwaddr(mux.Vars(r)["hwaddr"])
+	if err != nil {
+		return NotFound
+	}
+
+	// Get the existing reservation
+	reservation, err := dbNetworkReservationGet(d.db, n.id, hwaddr)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Validate the ETag
+	etag := []interface{}{reservation.Hwaddr, reservation.Description, reservation.IPv4Address, reservation.IPv6Address, reservation.Hostname}
+
+	err = etagCheck(r, etag)
+	if err != nil {
+		return PreconditionFailed(err)
+	}
+
+	req := api.NetworkReservationPut{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	err = networkReservationValidate(n.config, req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = networkReservationCheckConflicts(d, n, hwaddr, req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = dbNetworkReservationUpdate(d.db, n.id, hwaddr, req)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Reload dnsmasq
+	if n.IsRunning() {
+		err = networkUpdateStatic(d, n.name)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return EmptySyncResponse
+}
+
+func networkReservationDelete(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	n, err := networkLoadByName(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	hwaddr, err := networkReservationValidHwaddr(mux.Vars(r)["hwaddr"])
+	if err != nil {
+		return NotFound
+	}
+
+	// Get the existing reservation
+	_, err = dbNetworkReservationGet(d.db, n.id, hwaddr)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	err = dbNetworkReservationDelete(d.db, n.id, hwaddr)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Reload dnsmasq
+	if n.IsRunning() {
+		err = networkUpdateStatic(d, n.name)
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return EmptySyncResponse
+}
+
+var networkReservationCmd = Command{name: "networks/{name}/reservations/{hwaddr}", get: networkReservationGet, put: networkReservationPut, delete: networkReservationDelete}
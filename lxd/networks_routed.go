@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/types"
+	"github.com/lxc/lxd/shared"
+)
+
+// The routed and ipvlan nics don't rely on a bridge. The host routes the
+// container addresses to the host side veth of routed nics and answers ARP and
+// NDP for them on the parent, while ipvlan nics share the MAC address of their
+// parent.
+const networkRoutedGatewayV4 = "169.254.0.1"
+const networkRoutedGatewayV6 = "fe80::1"
+
+func networkRoutedValidate(m types.Device) error {
+	if m["ipv4.address"] == "" && m["ipv6.address"] == "" {
+		return fmt.Errorf("Missing ipv4.address or ipv6.address for %s type nic.", m["nictype"])
+	}
+
+	if m["ipv4.address"] != "" {
+		ip := net.ParseIP(m["ipv4.address"])
+		if ip == nil || ip.To4() == nil {
+			return fmt.Errorf("Not an IPv4 address: %s", m["ipv4.address"])
+		}
+	}
+
+	if m["ipv6.address"] != "" {
+		ip := net.ParseIP(m["ipv6.address"])
+		if ip == nil || ip.To4() != nil {
+			return fmt.Errorf("Not an IPv6 address: %s", m["ipv6.address"])
+		}
+	}
+
+	for _, key := range []string{"ipv4.gateway", "ipv6.gateway"} {
+		if !shared.StringInSlice(m[key], []string{"", "auto", "none"}) {
+			return fmt.Errorf("Invalid value for %s: %s", key, m[key])
+		}
+	}
+
+	if m["nictype"] == "ipvlan" && m["hwaddr"] != "" {
+		return fmt.Errorf("MAC addresses can't be set on ipvlan nics, they use the one of their parent")
+	}
+
+	return nil
+}
+
+// networkRoutedCheckHost makes sure the host can route the traffic of the nic.
+func networkRoutedCheckHost(m types.Device) error {
+	if m["parent"] != "" && !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", m["parent"])) {
+		return fmt.Errorf("Parent device '%s' doesn't exist", m["parent"])
+	}
+
+	sysctls := map[string]string{
+		"ipv4.address": "/proc/sys/net/ipv4/ip_forward",
+		"ipv6.address": "/proc/sys/net/ipv6/conf/all/forwarding",
+	}
+
+	for key, path := range sysctls {
+		if m[key] == "" {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(string(content)) != "1" {
+			return fmt.Errorf("Forwarding must be enabled on the host for %s nics: %s", m["nictype"], path)
+		}
+	}
+
+	return nil
+}
+
+// networkRoutedHostCommands returns the "ip" commands configuring the host
+// side of a routed nic.
+func networkRoutedHostCommands(m types.Device, hostName string) [][]string {
+	commands := [][]string{}
+	if m["nictype"] != "routed" {
+		return commands
+	}
+
+	if m["ipv4.address"] != "" {
+		if m["ipv4.gateway"] != "none" {
+			commands = append(commands, []string{"-4", "addr", "add", fmt.Sprintf("%s/32", networkRoutedGatewayV4), "dev", hostName})
+		}
+
+		commands = append(commands, []string{"-4", "route", "add", fmt.Sprintf("%s/32", m["ipv4.address"]), "dev", hostName})
+
+		if m["parent"] != "" {
+			commands = append(commands, []string{"-4", "neigh", "add", "proxy", m["ipv4.address"], "dev", m["parent"]})
+		}
+	}
+
+	if m["ipv6.address"] != "" {
+		if m["ipv6.gateway"] != "none" {
+			commands = append(commands, []string{"-6", "addr", "add", fmt.Sprintf("%s/64", networkRoutedGatewayV6), "dev", hostName, "nodad"})
+		}
+
+		commands = append(commands, []string{"-6", "route", "add", fmt.Sprintf("%s/128", m["ipv6.address"]), "dev", hostName})
+
+		if m["parent"] != "" {
+			commands = append(commands, []string{"-6", "neigh", "add", "proxy", m["ipv6.address"], "dev", m["parent"]})
+		}
+	}
+
+	return commands
+}
+
+// networkRoutedContainerCommands returns the "ip" commands configuring the
+// nic inside the container, with the default routes going through the host.
+func networkRoutedContainerCommands(m types.Device) [][]string {
+	commands := [][]string{}
+
+	if m["mtu"] != "" {
+		commands = append(commands, []string{"link", "set", "dev", m["name"], "mtu", m["mtu"]})
+	}
+
+	commands = append(commands, []string{"link", "set", "dev", m["name"], "up"})
+
+	if m["ipv4.address"] != "" {
+		commands = append(commands, []string{"-4", "addr", "add", fmt.Sprintf("%s/32", m["ipv4.address"]), "dev", m["name"]})
+
+		if m["ipv4.gateway"] != "none" {
+			if m["nictype"] == "routed" {
+				commands = append(commands, []string{"-4", "route", "add", networkRoutedGatewayV4, "dev", m["name"]})
+				commands = append(commands, []string{"-4", "route", "add", "default", "via", networkRoutedGatewayV4, "dev", m["name"]})
+			} else {
+				commands = append(commands, []string{"-4", "route", "add", "default", "dev", m["name"]})
+			}
+		}
+	}
+
+	if m["ipv6.address"] != "" {
+		commands = append(commands, []string{"-6", "addr", "add", fmt.Sprintf("%s/128", m["ipv6.address"]), "dev", m["name"], "nodad"})
+
+		if m["ipv6.gateway"] != "none" {
+			if m["nictype"] == "routed" {
+				commands = append(commands, []string{"-6", "route", "add", "default", "via", networkRoutedGatewayV6, "dev", m["name"]})
+			} else {
+				commands = append(commands, []string{"-6", "route", "add", "default", "dev", m["name"]})
+			}
+		}
+	}
+
+	return commands
+}
+
+func networkRoutedSetupHost(m types.Device, hostName string) error {
+	// NDP proxying has to be enabled on the parent
+	if m["nictype"] == "routed" && m["parent"] != "" && m["ipv6.address"] != "" {
+		err := networkSysctl(fmt.Sprintf("ipv6/conf/%s/proxy_ndp", m["parent"]), "1")
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, command := range networkRoutedHostCommands(m, hostName) {
+		_, err := shared.RunCommand("ip", command...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// networkRoutedClearHost removes the proxy entries of a routed nic, the routes
+// go away along with the host side veth.
+func networkRoutedClearHost(m types.Device) {
+	if m["nictype"] != "routed" || m["parent"] == "" {
+		return
+	}
+
+	if m["ipv4.address"] != "" {
+		shared.RunCommand("ip", "-4", "neigh", "del", "proxy", m["ipv4.address"], "dev", m["parent"])
+	}
+
+	if m["ipv6.address"] != "" {
+		shared.RunCommand("ip", "-6", "neigh", "del", "proxy", m["ipv6.address"], "dev", m["parent"])
+	}
+}
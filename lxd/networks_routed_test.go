@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lxc/lxd/lxd/types"
+)
+
+func Test_networkRoutedValidate(t *testing.T) {
+	valid := []types.Device{
+		{"nictype": "routed", "ipv4.address": "192.0.2.2"},
+		{"nictype": "routed", "ipv6.address": "2001:db8::2", "ipv6.gateway": "none"},
+		{"nictype": "ipvlan", "parent": "eth0", "ipv4.address": "192.0.2.2", "ipv4.gateway": "auto"},
+	}
+
+	for _, m := range valid {
+		err := networkRoutedValidate(m)
+		if err != nil {
+			t.Errorf("%v should be accepted: %s", m, err)
+		}
+	}
+
+	invalid := []types.Device{
+		{"nictype": "routed"},
+		{"nictype": "routed", "ipv4.address": "2001:db8::2"},
+		{"nictype": "routed", "ipv6.address": "192.0.2.2"},
+		{"nictype": "routed", "ipv4.address": "192.0.2.0/24"},
+		{"nictype": "routed", "ipv4.address": "192.0.2.2", "ipv4.gateway": "192.0.2.1"},
+		{"nictype": "ipvlan", "parent": "eth0", "ipv4.address": "192.0.2.2", "hwaddr": "00:16:3e:00:00:01"},
+	}
+
+	for _, m := range invalid {
+		err := networkRoutedValidate(m)
+		if err == nil {
+			t.Errorf("%v should be refused", m)
+		}
+	}
+}
+
+func Test_networkRoutedHostCommands(t *testing.T) {
+	m := types.Device{"nictype": "routed", "parent": "eth0", "ipv4.address": "192.0.2.2", "ipv6.address": "2001:db8::2", "ipv6.gateway": "none"}
+
+	expected := [][]string{
+		{"-4", "addr", "add", "169.254.0.1/32", "dev", "veth0"},
+		{"-4", "route", "add", "192.0.2.2/32", "dev", "veth0"},
+		{"-4", "neigh", "add", "proxy", "192.0.2.2", "dev", "eth0"},
+		{"-6", "route", "add", "2001:db8::2/128", "dev", "veth0"},
+		{"-6", "neigh", "add", "proxy", "2001:db8::2", "dev", "eth0"},
+	}
+
+	commands := networkRoutedHostCommands(m, "veth0")
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Unexpected host commands: %v", commands)
+	}
+
+	// ipvlan nics don't need anything on the host
+	m["nictype"] = "ipvlan"
+	if len(networkRoutedHostCommands(m, "veth0")) != 0 {
+		t.Errorf("Host commands generated for an ipvlan nic")
+	}
+}
+
+func Test_networkRoutedContainerCommands(t *testing.T) {
+	m := types.Device{"nictype": "routed", "name": "eth0", "ipv4.address": "192.0.2.2"}
+
+	expected := [][]string{
+		{"link", "set", "dev", "eth0", "up"},
+		{"-4", "addr", "add", "192.0.2.2/32", "dev", "eth0"},
+		{"-4", "route", "add", "169.254.0.1", "dev", "eth0"},
+		{"-4", "route", "add", "default", "via", "169.254.0.1", "dev", "eth0"},
+	}
+
+	commands := networkRoutedContainerCommands(m)
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Unexpected routed commands: %v", commands)
+	}
+
+	m = types.Device{"nictype": "ipvlan", "name": "eth0", "mtu": "1400", "ipv6.address": "2001:db8::2"}
+
+	expected = [][]string{
+		{"link", "set", "dev", "eth0", "mtu", "1400"},
+		{"link", "set", "dev", "eth0", "up"},
+		{"-6", "addr", "add", "2001:db8::2/128", "dev", "eth0", "nodad"},
+		{"-6", "route", "add", "default", "dev", "eth0"},
+	}
+
+	commands = networkRoutedContainerCommands(m)
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Unexpected ipvlan commands: %v", commands)
+	}
+}
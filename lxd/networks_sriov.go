@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// networkSysfsNet is where the network devices are looked up in sysfs, the
+// tests point it to a fake tree.
+var networkSysfsNet = "/sys/class/net"
+
+// networkSRIOVLock prevents two nics from grabbing the same virtual function.
+var networkSRIOVLock sync.Mutex
+
+type networkSRIOVVF struct {
+	hwaddr   string
+	vlan     string
+	spoofchk bool
+}
+
+// networkSRIOVVFDevice returns the name of a virtual function on the host, or
+// an empty string if it was moved into a container.
+func networkSRIOVVFDevice(pf string, vfID int) string {
+	devices, err := ioutil.ReadDir(filepath.Join(networkSysfsNet, pf, "device", fmt.Sprintf("virtfn%d", vfID), "net"))
+	if err != nil || len(devices) == 0 {
+		return ""
+	}
+
+	return devices[0].Name()
+}
+
+// networkSRIOVFreeVF returns the ID and device name of the first virtual
+// function of a PF which is on the host, isn't up and isn't claimed by another
+// container.
+func networkSRIOVFreeVF(pf string, claimed []string) (int, string, error) {
+	paths, err := filepath.Glob(filepath.Join(networkSysfsNet, pf, "device", "virtfn*"))
+	if err != nil {
+		return -1, "", err
+	}
+
+	if len(paths) == 0 {
+		return -1, "", fmt.Errorf("%s doesn't have any SR-IOV virtual function", pf)
+	}
+
+	ids := []int{}
+	for _, path := range paths {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(path), "virtfn"))
+		if err != nil {
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		name := networkSRIOVVFDevice(pf, id)
+		if name == "" || shared.StringInSlice(name, claimed) {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(networkSysfsNet, pf, "device", fmt.Sprintf("virtfn%d", id), "net", name, "flags"))
+		if err != nil {
+			continue
+		}
+
+		flags, err := strconv.ParseInt(strings.TrimSpace(string(content)), 0, 64)
+		if err != nil {
+			continue
+		}
+
+		// Skip the virtual functions in use on the host (IFF_UP)
+		if flags&0x1 != 0 {
+			continue
+		}
+
+		return id, name, nil
+	}
+
+	return -1, "", fmt.Errorf("No free virtual function on %s", pf)
+}
+
+// networkSRIOVParseVFs parses the virtual functions listed by "ip link show"
+// for a PF.
+func networkSRIOVParseVFs(output string) map[int]networkSRIOVVF {
+	vfs := map[int]networkSRIOVVF{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(strings.Replace(line, ",", " , ", -1))
+		if len(fields) < 2 || fields[0] != "vf" {
+			continue
+		}
+
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+
+		vf := networkSRIOVVF{vlan: "0"}
+		for i, field := range fields[:len(fields)-1] {
+			switch field {
+			case "MAC", "link/ether":
+				vf.hwaddr = fields[i+1]
+			case "vlan":
+				vf.vlan = fields[i+1]
+			case "checking":
+				vf.spoofchk = fields[i+1] == "on"
+			}
+		}
+
+		vfs[id] = vf
+	}
+
+	return vfs
+}
+
+// networkSRIOVGetVF returns the current settings of a virtual function.
+func networkSRIOVGetVF(pf string, vfID int) (*networkSRIOVVF, error) {
+	output, err := shared.RunCommand("ip", "link", "show", "dev", pf)
+	if err != nil {
+		return nil, err
+	}
+
+	vf, ok := networkSRIOVParseVFs(output)[vfID]
+	if !ok {
+		return nil, fmt.Errorf("Virtual function %d of %s not found", vfID, pf)
+	}
+
+	return &vf, nil
+}
+
+func networkSRIOVVFArgs(pf string, vfID int, vf networkSRIOVVF) []string {
+	vlan := vf.vlan
+	if vlan == "" {
+		vlan = "0"
+	}
+
+	spoofchk := "off"
+	if vf.spoofchk {
+		spoofchk = "on"
+	}
+
+	return []string{"link", "set", "dev", pf, "vf", fmt.Sprintf("%d", vfID), "mac", vf.hwaddr, "vlan", vlan, "spoofchk", spoofchk}
+}
+
+// networkSRIOVSetVF applies the MAC address, VLAN and spoof checking of a
+// virtual function through its PF.
+func networkSRIOVSetVF(pf string, vfID int, vf networkSRIOVVF) error {
+	_, err := shared.RunCommand("ip", networkSRIOVVFArgs(pf, vfID, vf)...)
+	return err
+}
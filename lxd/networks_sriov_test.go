@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Output of "ip link show" for a netdevsim PF with three virtual functions
+var networkSRIOVTestOutput = `4: eni1np1: <BROADCAST,NOARP,UP,LOWER_UP> mtu 1500 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000
+    link/ether 5a:2c:1f:44:0e:bd brd ff:ff:ff:ff:ff:ff
+    vf 0     link/ether 00:00:00:00:00:00 brd ff:ff:ff:ff:ff:ff, spoof checking off, link-state auto, trust off
+    vf 1     link/ether 00:16:3e:12:34:56 brd ff:ff:ff:ff:ff:ff, vlan 10, spoof checking on, link-state auto, trust off
+    vf 2 MAC 00:16:3e:ab:cd:ef, vlan 20, spoof checking on, link-state auto`
+
+func Test_networkSRIOVParseVFs(t *testing.T) {
+	vfs := networkSRIOVParseVFs(networkSRIOVTestOutput)
+
+	expected := map[int]networkSRIOVVF{
+		0: {hwaddr: "00:00:00:00:00:00", vlan: "0", spoofchk: false},
+		1: {hwaddr: "00:16:3e:12:34:56", vlan: "10", spoofchk: true},
+		2: {hwaddr: "00:16:3e:ab:cd:ef", vlan: "20", spoofchk: true},
+	}
+
+	if !reflect.DeepEqual(vfs, expected) {
+		t.Errorf("Unexpected virtual functions: %+v", vfs)
+	}
+}
+
+func Test_networkSRIOVVFArgs(t *testing.T) {
+	args := networkSRIOVVFArgs("eni1np1", 1, networkSRIOVVF{hwaddr: "00:16:3e:12:34:56", spoofchk: true})
+	expected := []string{"link", "set", "dev", "eni1np1", "vf", "1", "mac", "00:16:3e:12:34:56", "vlan", "0", "spoofchk", "on"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Unexpected arguments: %v", args)
+	}
+}
+
+func Test_networkSRIOVFreeVF(t *testing.T) {
+	sysfs, err := ioutil.TempDir("", "lxd_sriov_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sysfs)
+
+	oldSysfs := networkSysfsNet
+	networkSysfsNet = sysfs
+	defer func() { networkSysfsNet = oldSysfs }()
+
+	// virtfn0 is up on the host, virtfn1 is in a container, virtfn2 and
+	// virtfn10 are free.
+	vfs := map[int]string{0: "0x1003", 2: "0x1002", 10: "0x1002"}
+	for _, id := range []int{0, 1, 2, 10} {
+		path := filepath.Join(sysfs, "eni1np1", "device", fmt.Sprintf("virtfn%d", id), "net")
+		err = os.MkdirAll(path, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		flags, ok := vfs[id]
+		if !ok {
+			continue
+		}
+
+		name := fmt.Sprintf("eni1np1v%d", id)
+		err = os.MkdirAll(filepath.Join(path, name), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(filepath.Join(path, name, "flags"), []byte(flags+"\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	id, name, err := networkSRIOVFreeVF("eni1np1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id != 2 || name != "eni1np1v2" {
+		t.Errorf("Unexpected virtual function: %d (%s)", id, name)
+	}
+
+	// Virtual functions claimed by other containers are skipped
+	id, name, err = networkSRIOVFreeVF("eni1np1", []string{"eni1np1v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id != 10 || name != "eni1np1v10" {
+		t.Errorf("Unexpected virtual function: %d (%s)", id, name)
+	}
+
+	_, _, err = networkSRIOVFreeVF("eni1np1", []string{"eni1np1v2", "eni1np1v10"})
+	if err == nil {
+		t.Errorf("No virtual function should be free")
+	}
+
+	_, _, err = networkSRIOVFreeVF("eth0", nil)
+	if err == nil {
+		t.Errorf("eth0 doesn't have any virtual function")
+	}
+}
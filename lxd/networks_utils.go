@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"math/big"
@@ -20,6 +21,7 @@ import (
 	"time"
 
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 )
 
 func networkAutoAttach(d *Daemon, devName string) error {
@@ -721,25 +723,16 @@ func networkKillDnsmasq(name string, reload bool) error {
 	return nil
 }
 
-func networkUpdateStatic(d *Daemon, name string) error {
+// networkGetStaticEntries returns, for each of the given networks, the
+// hwaddr, container name, IPv4 and IPv6 address of the bridged nics attached
+// to it.
+func networkGetStaticEntries(d *Daemon, networks []string) (map[string][][]string, error) {
 	// Get all the containers
 	containers, err := dbContainersList(d.db, cTypeRegular)
 	if err != nil {
-		return err
-	}
-
-	networks := []string{}
-	if name == "" {
-		// Get all the networks
-		networks, err = dbNetworks(d.db)
-		if err != nil {
-			return err
-		}
-	} else {
-		networks = []string{name}
+		return nil, err
 	}
 
-	// Build a list of dhcp host entries
 	entries := map[string][][]string{}
 	for _, cName := range containers {
 		// Load the container
@@ -771,6 +764,29 @@ func networkUpdateStatic(d *Daemon, name string) error {
 		}
 	}
 
+	return entries, nil
+}
+
+func networkUpdateStatic(d *Daemon, name string) error {
+	var err error
+
+	networks := []string{}
+	if name == "" {
+		// Get all the networks
+		networks, err = dbNetworks(d.db)
+		if err != nil {
+			return err
+		}
+	} else {
+		networks = []string{name}
+	}
+
+	// Build a list of dhcp host entries
+	entries, err := networkGetStaticEntries(d, networks)
+	if err != nil {
+		return err
+	}
+
 	// Update the host files
 	for _, network := range networks {
 		entries, _ := entries[network]
@@ -786,42 +802,73 @@ func networkUpdateStatic(d *Daemon, name string) error {
 		}
 		config := n.Config()
 
-		// Update the file
-		if entries == nil {
-			err := ioutil.WriteFile(shared.VarPath("networks", network, "dnsmasq.hosts"), []byte(""), 0)
-			if err != nil {
-				return err
+		lines := []string{}
+		for _, entry := range entries {
+			hwaddr := entry[0]
+			cName := entry[1]
+			ipv4Address := entry[2]
+			ipv6Address := entry[3]
+
+			line := hwaddr
+
+			if ipv4Address != "" {
+				line += fmt.Sprintf(",id:*,%s", ipv4Address)
 			}
-		} else {
-			lines := []string{}
-			for _, entry := range entries {
-				hwaddr := entry[0]
-				cName := entry[1]
-				ipv4Address := entry[2]
-				ipv6Address := entry[3]
 
-				line := hwaddr
+			if ipv6Address != "" {
+				line += fmt.Sprintf(",[%s]", ipv6Address)
+			}
 
-				if ipv4Address != "" {
-					line += fmt.Sprintf(",id:*,%s", ipv4Address)
-				}
+			if config["dns.mode"] == "" || config["dns.mode"] == "managed" {
+				line += fmt.Sprintf(",%s", cName)
+			}
 
-				if ipv6Address != "" {
-					line += fmt.Sprintf(",[%s]", ipv6Address)
-				}
+			if line == hwaddr {
+				continue
+			}
 
-				if config["dns.mode"] == "" || config["dns.mode"] == "managed" {
-					line += fmt.Sprintf(",%s", cName)
-				}
+			lines = append(lines, line)
+		}
 
-				if line == hwaddr {
-					continue
-				}
+		// Add the reservations of hosts outside of LXD
+		reservations, err := dbNetworkReservations(d.db, n.id)
+		if err != nil {
+			return err
+		}
+
+		for _, reservation := range reservations {
+			lines = append(lines, networkReservationHostsLine(config, reservation))
+		}
+
+		// Update the file
+		content := ""
+		if len(lines) > 0 {
+			content = strings.Join(lines, "\n") + "\n"
+		}
+
+		err = ioutil.WriteFile(shared.VarPath("networks", network, "dnsmasq.hosts"), []byte(content), 0)
+		if err != nil {
+			return err
+		}
+
+		// Update the extra DNS records
+		if shared.PathExists(shared.VarPath("networks", network, "dnsmasq.records")) {
+			records, err := dbNetworkDNSRecords(d.db, n.id)
+			if err != nil {
+				return err
+			}
 
-				lines = append(lines, line)
+			lines = []string{}
+			for _, record := range records {
+				lines = append(lines, networkDNSRecordHostsLines(config, record)...)
 			}
 
-			err := ioutil.WriteFile(shared.VarPath("networks", network, "dnsmasq.hosts"), []byte(strings.Join(lines, "\n")+"\n"), 0)
+			content = ""
+			if len(lines) > 0 {
+				content = strings.Join(lines, "\n") + "\n"
+			}
+
+			err = ioutil.WriteFile(shared.VarPath("networks", network, "dnsmasq.records"), []byte(content), 0)
 			if err != nil {
 				return err
 			}
@@ -905,3 +952,171 @@ func networkClearLease(d *Daemon, network string, hwaddr string) error {
 
 	return nil
 }
+
+// networkInterfacesState returns the addresses, counters and status of all
+// the network interfaces in the current network namespace.
+func networkInterfacesState() (map[string]api.ContainerStateNetwork, error) {
+	networks := map[string]api.ContainerStateNetwork{}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string][]int64{}
+
+	content, err := ioutil.ReadFile("/proc/net/dev")
+	if err == nil {
+		for _, line := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(line)
+
+			if len(fields) != 17 {
+				continue
+			}
+
+			rxBytes, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				continue
+			}
+
+			rxPackets, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				continue
+			}
+
+			txBytes, err := strconv.ParseInt(fields[9], 10, 64)
+			if err != nil {
+				continue
+			}
+
+			txPackets, err := strconv.ParseInt(fields[10], 10, 64)
+			if err != nil {
+				continue
+			}
+
+			intName := strings.TrimSuffix(fields[0], ":")
+			stats[intName] = []int64{rxBytes, rxPackets, txBytes, txPackets}
+		}
+	}
+
+	for _, netIf := range interfaces {
+		netState := "down"
+		netType := "unknown"
+
+		if netIf.Flags&net.FlagBroadcast > 0 {
+			netType = "broadcast"
+		}
+
+		if netIf.Flags&net.FlagPointToPoint > 0 {
+			netType = "point-to-point"
+		}
+
+		if netIf.Flags&net.FlagLoopback > 0 {
+			netType = "loopback"
+		}
+
+		if netIf.Flags&net.FlagUp > 0 {
+			netState = "up"
+		}
+
+		network := api.ContainerStateNetwork{
+			Addresses: []api.ContainerStateNetworkAddress{},
+			Counters:  api.ContainerStateNetworkCounters{},
+			Hwaddr:    netIf.HardwareAddr.String(),
+			Mtu:       netIf.MTU,
+			State:     netState,
+			Type:      netType,
+		}
+
+		addrs, err := netIf.Addrs()
+		if err == nil {
+			for _, addr := range addrs {
+				fields := strings.SplitN(addr.String(), "/", 2)
+				if len(fields) != 2 {
+					continue
+				}
+
+				family := "inet"
+				if strings.Contains(fields[0], ":") {
+					family = "inet6"
+				}
+
+				scope := "global"
+				if strings.HasPrefix(fields[0], "127") {
+					scope = "local"
+				}
+
+				if fields[0] == "::1" {
+					scope = "local"
+				}
+
+				if strings.HasPrefix(fields[0], "169.254") {
+					scope = "link"
+				}
+
+				if strings.HasPrefix(fields[0], "fe80:") {
+					scope = "link"
+				}
+
+				address := api.ContainerStateNetworkAddress{}
+				address.Family = family
+				address.Address = fields[0]
+				address.Netmask = fields[1]
+				address.Scope = scope
+
+				network.Addresses = append(network.Addresses, address)
+			}
+		}
+
+		counters, ok := stats[netIf.Name]
+		if ok {
+			network.Counters.BytesReceived = counters[0]
+			network.Counters.PacketsReceived = counters[1]
+			network.Counters.BytesSent = counters[2]
+			network.Counters.PacketsSent = counters[3]
+		}
+
+		networks[netIf.Name] = network
+	}
+
+	return networks, nil
+}
+
+// networkParseLeases parses a dnsmasq lease file. IPv6 leases are keyed by
+// DUID rather than by MAC address, so their hwaddr is looked up by hostname
+// in the provided map.
+func networkParseLeases(r io.Reader, hwaddrs map[string]string) ([]api.NetworkLease, error) {
+	leases := []api.NetworkLease{}
+
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		// <expiry> <hwaddr or IAID> <address> <hostname> <client id or DUID>
+		fields := strings.Fields(scan.Text())
+		if len(fields) < 4 || fields[0] == "duid" {
+			continue
+		}
+
+		lease := api.NetworkLease{
+			Hwaddr:  fields[1],
+			Address: fields[2],
+			Type:    "dynamic",
+		}
+
+		if fields[3] != "*" {
+			lease.Hostname = fields[3]
+		}
+
+		if strings.Contains(lease.Address, ":") {
+			lease.Hwaddr = hwaddrs[lease.Hostname]
+		}
+
+		leases = append(leases, lease)
+	}
+
+	err := scan.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_networkParseLeases(t *testing.T) {
+	content := `1514567890 00:16:3e:aa:bb:cc 10.0.3.5 c1 01:00:16:3e:aa:bb:cc
+1514567890 00:16:3e:dd:ee:ff 10.0.3.6 * *
+duid 00:01:00:01:21:b1:2f:32:00:16:3e:11:22:33
+1514567890 1234567 fd42::5 c1 00:01:00:01:21:b1:2f:32:00:16:3e:aa:bb:cc
+`
+
+	leases, err := networkParseLeases(strings.NewReader(content), map[string]string{"c1": "00:16:3e:aa:bb:cc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(leases) != 3 {
+		t.Fatalf("Unexpected number of leases: %d", len(leases))
+	}
+
+	if leases[0].Hostname != "c1" || leases[0].Hwaddr != "00:16:3e:aa:bb:cc" || leases[0].Address != "10.0.3.5" || leases[0].Type != "dynamic" {
+		t.Errorf("Unexpected IPv4 lease: %+v", leases[0])
+	}
+
+	if leases[1].Hostname != "" {
+		t.Errorf("Unexpected hostname for an anonymous lease: %s", leases[1].Hostname)
+	}
+
+	if leases[2].Hwaddr != "00:16:3e:aa:bb:cc" || leases[2].Address != "fd42::5" {
+		t.Errorf("Unexpected IPv6 lease: %+v", leases[2])
+	}
+}
@@ -455,6 +455,7 @@ func operationsAPIGet(d *Daemon, r *http.Request) Response {
 	var md shared.Jmap
 
 	recursion := d.isRecursionRequest(r)
+	cert := certificateRequestInfo(d, r)
 
 	md = shared.Jmap{}
 
@@ -463,6 +464,16 @@ func operationsAPIGet(d *Daemon, r *http.Request) Response {
 	operationsLock.Unlock()
 
 	for _, v := range ops {
+		_, body, err := v.Render()
+		if err != nil {
+			continue
+		}
+
+		// Restricted clients only see the operations of their containers
+		if !certificateAllowedOperation(cert, body) {
+			continue
+		}
+
 		status := strings.ToLower(v.status.String())
 		_, ok := md[status]
 		if !ok {
@@ -478,11 +489,6 @@ func operationsAPIGet(d *Daemon, r *http.Request) Response {
 			continue
 		}
 
-		_, body, err := v.Render()
-		if err != nil {
-			continue
-		}
-
 		md[status] = append(md[status].([]*api.Operation), body)
 	}
 
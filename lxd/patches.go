@@ -101,7 +101,7 @@ DELETE FROM profiles_devices_config WHERE profile_device_id NOT IN (SELECT id FR
 }
 
 func patchInvalidProfileNames(name string, d *Daemon) error {
-	profiles, err := dbProfiles(d.db)
+	profiles, err := dbProfiles(d.db, "default")
 	if err != nil {
 		return err
 	}
@@ -109,7 +109,7 @@ func patchInvalidProfileNames(name string, d *Daemon) error {
 	for _, profile := range profiles {
 		if strings.Contains(profile, "/") || shared.StringInSlice(profile, []string{".", ".."}) {
 			shared.LogInfo("Removing unreachable profile (invalid name)", log.Ctx{"name": profile})
-			err := dbProfileDelete(d.db, profile)
+			err := dbProfileDelete(d.db, "default", profile)
 			if err != nil {
 				return err
 			}
@@ -200,13 +200,13 @@ func patchStorageApi(name string, d *Daemon) error {
 	}
 
 	// Get list of existing public images.
-	imgPublic, err := dbImagesGet(d.db, true)
+	imgPublic, err := dbImagesGet(d.db, "default", true)
 	if err != nil {
 		return err
 	}
 
 	// Get list of existing private images.
-	imgPrivate, err := dbImagesGet(d.db, false)
+	imgPrivate, err := dbImagesGet(d.db, "default", false)
 	if err != nil {
 		return err
 	}
@@ -1677,10 +1677,10 @@ func updatePoolPropertyForAllObjects(d *Daemon, poolName string, allcontainers [
 	// appropriate device including a pool is added to the default profile
 	// or the user explicitly passes the pool the container's storage volume
 	// is supposed to be created on.
-	profiles, err := dbProfiles(d.db)
+	profiles, err := dbProfiles(d.db, "default")
 	if err == nil {
 		for _, pName := range profiles {
-			pID, p, err := dbProfileGet(d.db, pName)
+			pID, p, err := dbProfileGet(d.db, "default", pName)
 			if err != nil {
 				shared.LogErrorf("Could not query database: %s.", err)
 				return err
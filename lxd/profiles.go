@@ -20,7 +20,12 @@ import (
 
 /* This is used for both profiles post and profile put */
 func profilesGet(d *Daemon, r *http.Request) Response {
-	results, err := dbProfiles(d.db)
+	project, err := dbProjectEffective(d.db, projectParam(r), "profiles")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	results, err := dbProfiles(d.db, project)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -32,10 +37,10 @@ func profilesGet(d *Daemon, r *http.Request) Response {
 	i := 0
 	for _, name := range results {
 		if !recursion {
-			url := fmt.Sprintf("/%s/profiles/%s", version.APIVersion, name)
+			url := fmt.Sprintf("/%s/profiles/%s%s", version.APIVersion, name, projectQuery(project))
 			resultString[i] = url
 		} else {
-			profile, err := doProfileGet(d, name)
+			profile, err := doProfileGet(d, project, name)
 			if err != nil {
 				shared.LogError("Failed to get profile", log.Ctx{"profile": name})
 				continue
@@ -53,6 +58,11 @@ func profilesGet(d *Daemon, r *http.Request) Response {
 }
 
 func profilesPost(d *Daemon, r *http.Request) Response {
+	project, err := dbProjectEffective(d.db, projectParam(r), "profiles")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	req := api.ProfilesPost{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return BadRequest(err)
@@ -63,7 +73,7 @@ func profilesPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("No name provided"))
 	}
 
-	_, profile, _ := dbProfileGet(d.db, req.Name)
+	_, profile, _ := dbProfileGet(d.db, project, req.Name)
 	if profile != nil {
 		return BadRequest(fmt.Errorf("The profile already exists"))
 	}
@@ -76,7 +86,7 @@ func profilesPost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("Invalid profile name '%s'", req.Name))
 	}
 
-	err := containerValidConfig(d, req.Config, true, false)
+	err = containerValidConfig(d, req.Config, true, false)
 	if err != nil {
 		return BadRequest(err)
 	}
@@ -87,13 +97,13 @@ func profilesPost(d *Daemon, r *http.Request) Response {
 	}
 
 	// Update DB entry
-	_, err = dbProfileCreate(d.db, req.Name, req.Description, req.Config, req.Devices)
+	_, err = dbProfileCreate(d.db, project, req.Name, req.Description, req.Config, req.Devices)
 	if err != nil {
 		return InternalError(
 			fmt.Errorf("Error inserting %s into database: %s", req.Name, err))
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name))
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s%s", version.APIVersion, req.Name, projectQuery(project)))
 }
 
 var profilesCmd = Command{
@@ -101,20 +111,21 @@ var profilesCmd = Command{
 	get:  profilesGet,
 	post: profilesPost}
 
-func doProfileGet(d *Daemon, name string) (*api.Profile, error) {
-	_, profile, err := dbProfileGet(d.db, name)
+func doProfileGet(d *Daemon, project string, name string) (*api.Profile, error) {
+	_, profile, err := dbProfileGet(d.db, project, name)
 	if err != nil {
 		return nil, err
 	}
 
-	cts, err := dbProfileContainersGet(d.db, name)
+	cts, err := dbProfileContainersGet(d.db, project, name)
 	if err != nil {
 		return nil, err
 	}
 
 	usedBy := []string{}
 	for _, ct := range cts {
-		usedBy = append(usedBy, fmt.Sprintf("/%s/containers/%s", version.APIVersion, ct))
+		ctProject, ctName := projectSplitName(ct)
+		usedBy = append(usedBy, fmt.Sprintf("/%s/containers/%s%s", version.APIVersion, ctName, projectQuery(ctProject)))
 	}
 	profile.UsedBy = usedBy
 
@@ -124,7 +135,12 @@ func doProfileGet(d *Daemon, name string) (*api.Profile, error) {
 func profileGet(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 
-	resp, err := doProfileGet(d, name)
+	project, err := dbProjectEffective(d.db, projectParam(r), "profiles")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resp, err := doProfileGet(d, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -133,10 +149,10 @@ func profileGet(d *Daemon, r *http.Request) Response {
 	return SyncResponseETag(true, resp, etag)
 }
 
-func getContainersWithProfile(d *Daemon, profile string) []container {
+func getContainersWithProfile(d *Daemon, project string, profile string) []container {
 	results := []container{}
 
-	output, err := dbProfileContainersGet(d.db, profile)
+	output, err := dbProfileContainersGet(d.db, project, profile)
 	if err != nil {
 		return results
 	}
@@ -156,7 +172,13 @@ func getContainersWithProfile(d *Daemon, profile string) []container {
 func profilePut(d *Daemon, r *http.Request) Response {
 	// Get the profile
 	name := mux.Vars(r)["name"]
-	id, profile, err := dbProfileGet(d.db, name)
+
+	project, err := dbProjectEffective(d.db, projectParam(r), "profiles")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	id, profile, err := dbProfileGet(d.db, project, name)
 	if err != nil {
 		return InternalError(fmt.Errorf("Failed to retrieve profile='%s'", name))
 	}
@@ -173,13 +195,19 @@ func profilePut(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
-	return doProfileUpdate(d, name, id, profile, req)
+	return doProfileUpdate(d, project, name, id, profile, req)
 }
 
 func profilePatch(d *Daemon, r *http.Request) Response {
 	// Get the profile
 	name := mux.Vars(r)["name"]
-	id, profile, err := dbProfileGet(d.db, name)
+
+	project, err := dbProjectEffective(d.db, projectParam(r), "profiles")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	id, profile, err := dbProfileGet(d.db, project, name)
 	if err != nil {
 		return InternalError(fmt.Errorf("Failed to retrieve profile='%s'", name))
 	}
@@ -239,13 +267,18 @@ func profilePatch(d *Daemon, r *http.Request) Response {
 		}
 	}
 
-	return doProfileUpdate(d, name, id, profile, req)
+	return doProfileUpdate(d, project, name, id, profile, req)
 }
 
 // The handler for the post operation.
 func profilePost(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 
+	project, err := dbProjectEffective(d.db, projectParam(r), "profiles")
+	if err != nil {
+		return SmartError(err)
+	}
+
 	req := api.ProfilePost{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return BadRequest(err)
@@ -257,7 +290,7 @@ func profilePost(d *Daemon, r *http.Request) Response {
 	}
 
 	// Check that the name isn't already in use
-	id, _, _ := dbProfileGet(d.db, req.Name)
+	id, _, _ := dbProfileGet(d.db, project, req.Name)
 	if id > 0 {
 		return Conflict
 	}
@@ -270,29 +303,34 @@ func profilePost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("Invalid profile name '%s'", req.Name))
 	}
 
-	err := dbProfileUpdate(d.db, name, req.Name)
+	err = dbProfileUpdate(d.db, project, name, req.Name)
 	if err != nil {
 		return InternalError(err)
 	}
 
-	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s", version.APIVersion, req.Name))
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/profiles/%s%s", version.APIVersion, req.Name, projectQuery(project)))
 }
 
 // The handler for the delete operation.
 func profileDelete(d *Daemon, r *http.Request) Response {
 	name := mux.Vars(r)["name"]
 
-	_, err := doProfileGet(d, name)
+	project, err := dbProjectEffective(d.db, projectParam(r), "profiles")
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, err = doProfileGet(d, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
 
-	clist := getContainersWithProfile(d, name)
+	clist := getContainersWithProfile(d, project, name)
 	if len(clist) != 0 {
 		return BadRequest(fmt.Errorf("Profile is currently in use"))
 	}
 
-	err = dbProfileDelete(d.db, name)
+	err = dbProfileDelete(d.db, project, name)
 	if err != nil {
 		return SmartError(err)
 	}
@@ -7,7 +7,7 @@ import (
 	"github.com/lxc/lxd/shared/api"
 )
 
-func doProfileUpdate(d *Daemon, name string, id int64, profile *api.Profile, req api.ProfilePut) Response {
+func doProfileUpdate(d *Daemon, project string, name string, id int64, profile *api.Profile, req api.ProfilePut) Response {
 	// Sanity checks
 	err := containerValidConfig(d, req.Config, true, false)
 	if err != nil {
@@ -19,7 +19,7 @@ func doProfileUpdate(d *Daemon, name string, id int64, profile *api.Profile, req
 		return BadRequest(err)
 	}
 
-	containers := getContainersWithProfile(d, name)
+	containers := getContainersWithProfile(d, project, name)
 
 	// Check if the root device is supposed to be changed or removed.
 	oldProfileRootDiskDeviceKey, oldProfileRootDiskDevice, _ := containerGetRootDiskDevice(profile.Devices)
@@ -41,7 +41,7 @@ func doProfileUpdate(d *Daemon, name string, id int64, profile *api.Profile, req
 			// Check what profile the device comes from
 			profiles := container.Profiles()
 			for i := len(profiles) - 1; i >= 0; i-- {
-				_, profile, err := dbProfileGet(d.db, profiles[i])
+				_, profile, err := dbProfileGet(d.db, project, profiles[i])
 				if err != nil {
 					return InternalError(err)
 				}
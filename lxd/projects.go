@@ -0,0 +1,409 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var projectConfigKeys = map[string]func(value string) error{
+	"features.images":   shared.IsBool,
+	"features.profiles": shared.IsBool,
+}
+
+// API endpoints
+func projectsGet(d *Daemon, r *http.Request) Response {
+	recursion := d.isRecursionRequest(r)
+
+	projects, err := dbProjects(d.db)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []api.Project{}
+	for _, name := range projects {
+		if !recursion {
+			resultString = append(resultString, fmt.Sprintf("/%s/projects/%s", version.APIVersion, name))
+		} else {
+			project, err := doProjectGet(d, name)
+			if err != nil {
+				continue
+			}
+
+			resultMap = append(resultMap, *project)
+		}
+	}
+
+	if !recursion {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultMap)
+}
+
+func projectsPost(d *Daemon, r *http.Request) Response {
+	req := api.ProjectsPost{}
+
+	// Parse the request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Sanity checks
+	if req.Name == "" {
+		return BadRequest(fmt.Errorf("No name provided"))
+	}
+
+	err = projectValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	// New projects get their own images and profiles unless told otherwise
+	for key := range projectConfigKeys {
+		if req.Config[key] == "" {
+			req.Config[key] = "true"
+		}
+	}
+
+	err = projectValidateConfig(req.Config)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	_, project, _ := dbProjectGet(d.db, req.Name)
+	if project != nil {
+		return BadRequest(fmt.Errorf("The project already exists"))
+	}
+
+	// Create the database entry
+	_, err = dbProjectCreate(d.db, req.Name, req.Description, req.Config)
+	if err != nil {
+		return InternalError(
+			fmt.Errorf("Error inserting %s into database: %s", req.Name, err))
+	}
+
+	if shared.IsTrue(req.Config["features.profiles"]) {
+		err = dbProfileCreateDefault(d.db, req.Name)
+		if err != nil {
+			dbProjectDelete(d.db, req.Name)
+			return SmartError(err)
+		}
+	}
+
+	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/projects/%s", version.APIVersion, req.Name))
+}
+
+var projectsCmd = Command{name: "projects", get: projectsGet, post: projectsPost}
+
+func projectGet(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	project, err := doProjectGet(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	etag := []interface{}{project.Description, project.Config}
+
+	return SyncResponseETag(true, project, etag)
+}
+
+func doProjectGet(d *Daemon, name string) (*api.Project, error) {
+	_, project, err := dbProjectGet(d.db, name)
+	if err != nil {
+		return nil, err
+	}
+
+	project.UsedBy, err = projectUsedBy(d, name, project.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func projectPut(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	// Get the existing project
+	_, project, err := dbProjectGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Validate the ETag
+	etag := []interface{}{project.Description, project.Config}
+
+	err = etagCheck(r, etag)
+	if err != nil {
+		return PreconditionFailed(err)
+	}
+
+	req := api.ProjectPut{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	return doProjectUpdate(d, name, project, req)
+}
+
+func projectPatch(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	// Get the existing project
+	_, project, err := dbProjectGet(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Validate the ETag
+	etag := []interface{}{project.Description, project.Config}
+
+	err = etagCheck(r, etag)
+	if err != nil {
+		return PreconditionFailed(err)
+	}
+
+	req := api.ProjectPut{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return BadRequest(err)
+	}
+
+	// Config stacking
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	for k, v := range project.Config {
+		_, ok := req.Config[k]
+		if !ok {
+			req.Config[k] = v
+		}
+	}
+
+	if req.Description == "" {
+		req.Description = project.Description
+	}
+
+	return doProjectUpdate(d, name, project, req)
+}
+
+func doProjectUpdate(d *Daemon, name string, project *api.Project, req api.ProjectPut) Response {
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err := projectValidateConfig(req.Config)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Switching features on and off requires an empty project
+	changed := false
+	for key := range projectConfigKeys {
+		if shared.IsTrue(req.Config[key]) != shared.IsTrue(project.Config[key]) {
+			changed = true
+		}
+	}
+
+	if changed {
+		if name == "default" {
+			return BadRequest(fmt.Errorf("The features of the default project can't be changed"))
+		}
+
+		usedBy, err := projectUsedBy(d, name, project.Config)
+		if err != nil {
+			return SmartError(err)
+		}
+
+		if !projectIsEmpty(name, usedBy) {
+			return BadRequest(fmt.Errorf("Features can only be changed on empty projects"))
+		}
+	}
+
+	err = dbProjectUpdate(d.db, name, req.Description, req.Config)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	// Add or drop the project's own default profile
+	hadProfiles := shared.IsTrue(project.Config["features.profiles"])
+	hasProfiles := shared.IsTrue(req.Config["features.profiles"])
+	if hasProfiles && !hadProfiles {
+		err = dbProfileCreateDefault(d.db, name)
+		if err != nil {
+			return SmartError(err)
+		}
+	} else if hadProfiles && !hasProfiles {
+		err = dbProfileDelete(d.db, name, "default")
+		if err != nil {
+			return SmartError(err)
+		}
+	}
+
+	return EmptySyncResponse
+}
+
+func projectDelete(d *Daemon, r *http.Request) Response {
+	name := mux.Vars(r)["name"]
+
+	if name == "default" {
+		return BadRequest(fmt.Errorf("The 'default' project cannot be deleted"))
+	}
+
+	project, err := doProjectGet(d, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	if !projectIsEmpty(name, project.UsedBy) {
+		return BadRequest(fmt.Errorf("Only empty projects can be removed"))
+	}
+
+	err = dbProjectDelete(d.db, name)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	return EmptySyncResponse
+}
+
+var projectCmd = Command{name: "projects/{name}", get: projectGet, put: projectPut, patch: projectPatch, delete: projectDelete}
+
+// projectParam returns the project targeted by a request.
+func projectParam(r *http.Request) string {
+	project := r.FormValue("project")
+	if project == "" {
+		return "default"
+	}
+
+	return project
+}
+
+// projectQuery returns the query string to append to URLs of objects in the
+// given project.
+func projectQuery(project string) string {
+	if project == "" || project == "default" {
+		return ""
+	}
+
+	return fmt.Sprintf("?project=%s", project)
+}
+
+// projectPrefix returns the name a container of the given project is stored
+// under. Containers of other projects than the default one are prefixed with
+// their project name, keeping their storage, LXC and AppArmor names unique.
+func projectPrefix(project string, name string) string {
+	if project == "" || project == "default" {
+		return name
+	}
+
+	return fmt.Sprintf("%s_%s", project, name)
+}
+
+// projectSplitName returns the project and the user-visible name of a stored
+// container or snapshot name.
+func projectSplitName(name string) (string, string) {
+	fields := strings.SplitN(name, "_", 2)
+	if len(fields) == 2 && !strings.Contains(fields[0], shared.SnapshotDelimiter) {
+		return fields[0], fields[1]
+	}
+
+	return "default", name
+}
+
+func projectValidName(name string) error {
+	if strings.Contains(name, "_") {
+		return fmt.Errorf("Project names may not contain underscores")
+	}
+
+	if !shared.ValidHostname(name) {
+		return fmt.Errorf("Project name isn't a valid hostname")
+	}
+
+	return nil
+}
+
+func projectValidateConfig(config map[string]string) error {
+	for k, v := range config {
+		validator, ok := projectConfigKeys[k]
+		if !ok {
+			return fmt.Errorf("Invalid project configuration key: %s", k)
+		}
+
+		err := validator(v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// projectUsedBy lists the containers, images and profiles of a project.
+func projectUsedBy(d *Daemon, name string, config map[string]string) ([]string, error) {
+	usedBy := []string{}
+
+	cts, err := dbContainersList(d.db, cTypeRegular)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ct := range cts {
+		project, ctName := projectSplitName(ct)
+		if project != name {
+			continue
+		}
+
+		usedBy = append(usedBy, fmt.Sprintf("/%s/containers/%s%s", version.APIVersion, ctName, projectQuery(name)))
+	}
+
+	if shared.IsTrue(config["features.images"]) {
+		images, err := dbImagesGet(d.db, name, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fp := range images {
+			usedBy = append(usedBy, fmt.Sprintf("/%s/images/%s%s", version.APIVersion, fp, projectQuery(name)))
+		}
+	}
+
+	if shared.IsTrue(config["features.profiles"]) {
+		profiles, err := dbProfiles(d.db, name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, profile := range profiles {
+			usedBy = append(usedBy, fmt.Sprintf("/%s/profiles/%s%s", version.APIVersion, profile, projectQuery(name)))
+		}
+	}
+
+	return usedBy, nil
+}
+
+// projectIsEmpty checks whether a project has nothing but its default profile.
+func projectIsEmpty(name string, usedBy []string) bool {
+	for _, entry := range usedBy {
+		if entry != fmt.Sprintf("/%s/profiles/default%s", version.APIVersion, projectQuery(name)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// resourcesParseCPU builds the CPU resources from the content of
+// /proc/cpuinfo. Architectures which don't report the physical and core IDs
+// are reported as a single socket with one core per thread.
+func resourcesParseCPU(r io.Reader) (*api.ResourcesCPU, error) {
+	cpu := api.ResourcesCPU{Sockets: []api.ResourcesCPUSocket{}}
+
+	sockets := map[uint64]*api.ResourcesCPUSocket{}
+	cores := map[uint64]map[string]bool{}
+
+	// Fields of the processor being parsed
+	socketID := uint64(0)
+	coreID := ""
+	vendor := ""
+	name := ""
+	inProcessor := false
+
+	flush := func() {
+		if !inProcessor {
+			return
+		}
+
+		socket, ok := sockets[socketID]
+		if !ok {
+			socket = &api.ResourcesCPUSocket{Socket: socketID, Vendor: vendor, Name: name}
+			sockets[socketID] = socket
+			cores[socketID] = map[string]bool{}
+		}
+
+		socket.Threads++
+		if coreID == "" {
+			coreID = fmt.Sprintf("thread%d", socket.Threads)
+		}
+		cores[socketID][coreID] = true
+
+		cpu.Total++
+
+		socketID = 0
+		coreID = ""
+		inProcessor = false
+	}
+
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		fields := strings.SplitN(scan.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(fields[0])
+		value := strings.TrimSpace(fields[1])
+
+		switch key {
+		case "processor":
+			flush()
+			inProcessor = true
+		case "vendor_id":
+			vendor = value
+		case "model name":
+			name = value
+		case "physical id":
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid physical id: %s", value)
+			}
+			socketID = id
+		case "core id":
+			coreID = value
+		}
+	}
+
+	err := scan.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	flush()
+
+	ids := []int{}
+	for id := range sockets {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		socket := sockets[uint64(id)]
+		socket.Cores = uint64(len(cores[uint64(id)]))
+		cpu.Sockets = append(cpu.Sockets, *socket)
+	}
+
+	return &cpu, nil
+}
+
+// resourcesParseMemory builds the memory resources from the content of
+// /proc/meminfo.
+func resourcesParseMemory(r io.Reader) (*api.ResourcesMemory, error) {
+	values := map[string]uint64{}
+
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		fields := strings.Fields(scan.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// Values are in kB
+		if len(fields) == 3 && fields[2] == "kB" {
+			value *= 1024
+		}
+
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+
+	err := scan.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	total, ok := values["MemTotal"]
+	if !ok {
+		return nil, fmt.Errorf("Couldn't find MemTotal")
+	}
+
+	// Older kernels don't report MemAvailable
+	available, ok := values["MemAvailable"]
+	if !ok {
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+
+	if available > total {
+		available = total
+	}
+
+	return &api.ResourcesMemory{Used: total - available, Total: total}, nil
+}
+
+func resourcesGPU() (*api.ResourcesGPU, error) {
+	gpu := api.ResourcesGPU{Cards: []api.ResourcesGPUCard{}}
+
+	gpus, _, err := deviceLoadGpu()
+	if err != nil {
+		return nil, err
+	}
+
+	// deviceLoadGpu returns every DRM device, only keep the cards
+	for _, dev := range gpus {
+		if dev.id == "" {
+			continue
+		}
+
+		gpu.Cards = append(gpu.Cards, api.ResourcesGPUCard{
+			ID:         dev.id,
+			PCIAddress: dev.pci,
+			VendorID:   dev.vendorid,
+			ProductID:  dev.productid,
+			NVIDIA:     dev.isNvidiaGpu(),
+		})
+		gpu.Total++
+	}
+
+	return &gpu, nil
+}
+
+// /1.0/resources
+func resourcesGet(d *Daemon, r *http.Request) Response {
+	res := api.Resources{}
+
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return InternalError(err)
+	}
+	defer f.Close()
+
+	cpu, err := resourcesParseCPU(f)
+	if err != nil {
+		return InternalError(err)
+	}
+	res.CPU = *cpu
+
+	f, err = os.Open("/proc/meminfo")
+	if err != nil {
+		return InternalError(err)
+	}
+	defer f.Close()
+
+	memory, err := resourcesParseMemory(f)
+	if err != nil {
+		return InternalError(err)
+	}
+	res.Memory = *memory
+
+	gpu, err := resourcesGPU()
+	if err != nil {
+		return InternalError(err)
+	}
+	res.GPU = *gpu
+
+	return SyncResponse(true, res)
+}
+
+var resourcesCmd = Command{name: "resources", get: resourcesGet}
+
+// /1.0/storage-pools/{name}/resources
+func storagePoolResourcesGet(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["name"]
+
+	s, err := storagePoolInit(d, poolName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	ourMount, err := s.StoragePoolMount()
+	if err != nil {
+		return InternalError(err)
+	}
+	if ourMount {
+		defer s.StoragePoolUmount()
+	}
+
+	res, err := s.StoragePoolResources()
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return SyncResponse(true, res)
+}
+
+var storagePoolResourcesCmd = Command{name: "storage-pools/{name}/resources", get: storagePoolResourcesGet}
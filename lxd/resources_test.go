@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_resourcesParseCPU(t *testing.T) {
+	cpuinfo := `processor	: 0
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2620 v3 @ 2.40GHz
+physical id	: 0
+core id		: 0
+
+processor	: 1
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2620 v3 @ 2.40GHz
+physical id	: 0
+core id		: 0
+
+processor	: 2
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2620 v3 @ 2.40GHz
+physical id	: 0
+core id		: 1
+
+processor	: 3
+vendor_id	: GenuineIntel
+model name	: Intel(R) Xeon(R) CPU E5-2620 v3 @ 2.40GHz
+physical id	: 1
+core id		: 0
+`
+
+	cpu, err := resourcesParseCPU(strings.NewReader(cpuinfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cpu.Total != 4 {
+		t.Errorf("Unexpected number of threads: %d", cpu.Total)
+	}
+
+	if len(cpu.Sockets) != 2 {
+		t.Fatalf("Unexpected number of sockets: %d", len(cpu.Sockets))
+	}
+
+	socket := cpu.Sockets[0]
+	if socket.Socket != 0 || socket.Cores != 2 || socket.Threads != 3 || socket.Vendor != "GenuineIntel" {
+		t.Errorf("Unexpected first socket: %+v", socket)
+	}
+
+	socket = cpu.Sockets[1]
+	if socket.Socket != 1 || socket.Cores != 1 || socket.Threads != 1 {
+		t.Errorf("Unexpected second socket: %+v", socket)
+	}
+}
+
+func Test_resourcesParseCPUWithoutTopology(t *testing.T) {
+	cpuinfo := `processor	: 0
+BogoMIPS	: 100.00
+
+processor	: 1
+BogoMIPS	: 100.00
+`
+
+	cpu, err := resourcesParseCPU(strings.NewReader(cpuinfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cpu.Sockets) != 1 || cpu.Sockets[0].Cores != 2 || cpu.Sockets[0].Threads != 2 {
+		t.Errorf("Unexpected sockets: %+v", cpu.Sockets)
+	}
+}
+
+func Test_resourcesParseMemory(t *testing.T) {
+	meminfo := `MemTotal:        8000 kB
+MemFree:         1000 kB
+MemAvailable:    3000 kB
+Buffers:          500 kB
+Cached:          1500 kB
+`
+
+	memory, err := resourcesParseMemory(strings.NewReader(meminfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if memory.Total != 8000*1024 || memory.Used != 5000*1024 {
+		t.Errorf("Unexpected memory: %+v", memory)
+	}
+
+	// Without MemAvailable, free memory includes the buffers and cache
+	meminfo = strings.Replace(meminfo, "MemAvailable:    3000 kB\n", "", 1)
+	memory, err = resourcesParseMemory(strings.NewReader(meminfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if memory.Used != 5000*1024 {
+		t.Errorf("Unexpected memory: %+v", memory)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -147,14 +148,17 @@ const (
 	storageTypeLvm
 	storageTypeDir
 	storageTypeMock
+	storageTypeCeph
 )
 
-var supportedStoragePoolDrivers = []string{"btrfs", "dir", "lvm", "zfs"}
+var supportedStoragePoolDrivers = []string{"btrfs", "ceph", "dir", "lvm", "zfs"}
 
 func storageTypeToString(sType storageType) (string, error) {
 	switch sType {
 	case storageTypeBtrfs:
 		return "btrfs", nil
+	case storageTypeCeph:
+		return "ceph", nil
 	case storageTypeZfs:
 		return "zfs", nil
 	case storageTypeLvm:
@@ -172,6 +176,8 @@ func storageStringToType(sName string) (storageType, error) {
 	switch sName {
 	case "btrfs":
 		return storageTypeBtrfs, nil
+	case "ceph":
+		return storageTypeCeph, nil
 	case "zfs":
 		return storageTypeZfs, nil
 	case "lvm":
@@ -204,6 +210,7 @@ type storage interface {
 	StoragePoolUpdate(writable *api.StoragePoolPut, changedConfig []string) error
 	GetStoragePoolWritable() api.StoragePoolPut
 	SetStoragePoolWritable(writable *api.StoragePoolPut)
+	StoragePoolResources() (*api.ResourcesStoragePool, error)
 
 	// Functions dealing with custom storage volumes.
 	StoragePoolVolumeCreate() error
@@ -211,6 +218,12 @@ type storage interface {
 	StoragePoolVolumeMount() (bool, error)
 	StoragePoolVolumeUmount() (bool, error)
 	StoragePoolVolumeUpdate(changedConfig []string) error
+	// StoragePoolVolumeSnapshotCreate creates the snapshot of the custom
+	// volume with the given full "<volume>/<snapshot>" name.
+	StoragePoolVolumeSnapshotCreate(target string) error
+	// StoragePoolVolumeRename renames the custom volume or snapshot. The
+	// snapshots of a renamed volume are renamed separately.
+	StoragePoolVolumeRename(newName string) error
 	GetStoragePoolVolumeWritable() api.StorageVolumePut
 	SetStoragePoolVolumeWritable(writable *api.StorageVolumePut)
 
@@ -288,6 +301,13 @@ func storageCoreInit(driver string) (storage, error) {
 			return nil, err
 		}
 		return &btrfs, nil
+	case storageTypeCeph:
+		ceph := storageCeph{}
+		err = ceph.StorageCoreInit()
+		if err != nil {
+			return nil, err
+		}
+		return &ceph, nil
 	case storageTypeDir:
 		dir := storageDir{}
 		err = dir.StorageCoreInit()
@@ -361,6 +381,17 @@ func storageInit(d *Daemon, poolName string, volumeName string, volumeType int)
 			return nil, err
 		}
 		return &btrfs, nil
+	case storageTypeCeph:
+		ceph := storageCeph{}
+		ceph.poolID = poolID
+		ceph.pool = pool
+		ceph.volume = volume
+		ceph.d = d
+		err = ceph.StoragePoolInit()
+		if err != nil {
+			return nil, err
+		}
+		return &ceph, nil
 	case storageTypeDir:
 		dir := storageDir{}
 		dir.poolID = poolID
@@ -458,10 +489,30 @@ func getImageMountPoint(poolName string, fingerprint string) string {
 }
 
 // ${LXD_DIR}/storage-pools/<pool>/custom/<storage_volume>
+// ${LXD_DIR}/storage-pools/<pool>/custom-snapshots/<storage_volume>/<snapshot>
 func getStoragePoolVolumeMountPoint(poolName string, volumeName string) string {
+	if shared.IsSnapshot(volumeName) {
+		return shared.VarPath("storage-pools", poolName, "custom-snapshots", volumeName)
+	}
+
 	return shared.VarPath("storage-pools", poolName, "custom", volumeName)
 }
 
+// storagePoolVolumeSnapshotsDirCleanup removes the directory holding the
+// snapshots of a custom volume once the given snapshot was its last one.
+func storagePoolVolumeSnapshotsDirCleanup(poolName string, snapshotName string) {
+	if !shared.IsSnapshot(snapshotName) {
+		return
+	}
+
+	fields := strings.SplitN(snapshotName, shared.SnapshotDelimiter, 2)
+	snapshotsPath := shared.VarPath("storage-pools", poolName, "custom-snapshots", fields[0])
+	empty, err := shared.PathIsEmpty(snapshotsPath)
+	if err == nil && empty {
+		os.Remove(snapshotsPath)
+	}
+}
+
 func createContainerMountpoint(mountPoint string, mountPointSymlink string, privileged bool) error {
 	var mode os.FileMode
 	if privileged {
@@ -473,6 +473,10 @@ func (s *storageBtrfs) SetStoragePoolWritable(writable *api.StoragePoolPut) {
 	s.pool.StoragePoolPut = *writable
 }
 
+func (s *storageBtrfs) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	return storageResource(getStoragePoolMountPoint(s.pool.Name))
+}
+
 func (s *storageBtrfs) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
@@ -486,8 +490,10 @@ func (s *storageBtrfs) StoragePoolVolumeCreate() error {
 		return err
 	}
 
-	// Create subvolume path on the storage pool.
-	customSubvolumePath := s.getCustomSubvolumePath(s.pool.Name)
+	// Create subvolume path on the storage pool. Snapshots of custom
+	// volumes live in a per volume directory.
+	customSubvolumeName := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	customSubvolumePath := filepath.Dir(customSubvolumeName)
 	if !shared.PathExists(customSubvolumePath) {
 		err := os.MkdirAll(customSubvolumePath, 0700)
 		if err != nil {
@@ -496,7 +502,6 @@ func (s *storageBtrfs) StoragePoolVolumeCreate() error {
 	}
 
 	// Create subvolume.
-	customSubvolumeName := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
 	err = btrfsSubVolumeCreate(customSubvolumeName)
 	if err != nil {
 		return err
@@ -529,10 +534,62 @@ func (s *storageBtrfs) StoragePoolVolumeDelete() error {
 		}
 	}
 
+	storagePoolVolumeSnapshotsDirCleanup(s.pool.Name, s.volume.Name)
+
 	shared.LogInfof("Deleted BTRFS storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 	return nil
 }
 
+func (s *storageBtrfs) StoragePoolVolumeSnapshotCreate(target string) error {
+	shared.LogInfof("Creating BTRFS storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+
+	_, err := s.StoragePoolMount()
+	if err != nil {
+		return err
+	}
+
+	sourceSubvolumeName := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	targetSubvolumeName := getStoragePoolVolumeMountPoint(s.pool.Name, target)
+	err = os.MkdirAll(filepath.Dir(targetSubvolumeName), 0700)
+	if err != nil {
+		return err
+	}
+
+	err = s.btrfsPoolVolumeSnapshot(sourceSubvolumeName, targetSubvolumeName, false)
+	if err != nil {
+		return err
+	}
+
+	shared.LogInfof("Created BTRFS storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+	return nil
+}
+
+func (s *storageBtrfs) StoragePoolVolumeRename(newName string) error {
+	shared.LogInfof("Renaming BTRFS storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+
+	_, err := s.StoragePoolMount()
+	if err != nil {
+		return err
+	}
+
+	oldSubvolumeName := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	newSubvolumeName := getStoragePoolVolumeMountPoint(s.pool.Name, newName)
+	err = os.MkdirAll(filepath.Dir(newSubvolumeName), 0700)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(oldSubvolumeName, newSubvolumeName)
+	if err != nil {
+		return err
+	}
+
+	storagePoolVolumeSnapshotsDirCleanup(s.pool.Name, s.volume.Name)
+
+	shared.LogInfof("Renamed BTRFS storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
 func (s *storageBtrfs) StoragePoolVolumeMount() (bool, error) {
 	shared.LogDebugf("Mounting BTRFS storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 
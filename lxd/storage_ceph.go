@@ -0,0 +1,1578 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+type storageCeph struct {
+	clusterName string
+	osdPoolName string
+	userName    string
+	pgNum       string
+	storageShared
+}
+
+func (s *storageCeph) getRBDMountOptions() string {
+	if s.volume.Config["block.mount_options"] != "" {
+		return s.volume.Config["block.mount_options"]
+	}
+
+	if s.pool.Config["volume.block.mount_options"] != "" {
+		return s.pool.Config["volume.block.mount_options"]
+	}
+
+	return "discard"
+}
+
+func (s *storageCeph) getRBDFilesystem() string {
+	if s.volume.Config["block.filesystem"] != "" {
+		return s.volume.Config["block.filesystem"]
+	}
+
+	if s.pool.Config["volume.block.filesystem"] != "" {
+		return s.pool.Config["volume.block.filesystem"]
+	}
+
+	return "ext4"
+}
+
+func (s *storageCeph) getRBDSize() (string, error) {
+	sz, err := shared.ParseByteSizeString(s.volume.Config["size"])
+	if err != nil {
+		return "", err
+	}
+
+	// Safety net: Set to default value.
+	if sz == 0 {
+		sz, _ = shared.ParseByteSizeString("10GB")
+	}
+
+	return fmt.Sprintf("%d", sz), nil
+}
+
+// Only initialize the minimal information we need about a given storage type.
+func (s *storageCeph) StorageCoreInit() error {
+	s.sType = storageTypeCeph
+	typeName, err := storageTypeToString(s.sType)
+	if err != nil {
+		return err
+	}
+	s.sTypeName = typeName
+
+	output, err := shared.RunCommand("rbd", "--version")
+	if err != nil {
+		return fmt.Errorf("Error getting CEPH version: %s", output)
+	}
+
+	// ceph version 10.2.7 (50e863e0f4bc8f4b9e31156de690d765af245185)
+	fields := strings.Fields(output)
+	if len(fields) < 3 {
+		return fmt.Errorf("Could not parse CEPH version: %s", output)
+	}
+	s.sTypeVersion = fields[2]
+
+	shared.LogDebugf("Initializing a CEPH driver.")
+	return nil
+}
+
+func (s *storageCeph) StoragePoolInit() error {
+	err := s.StorageCoreInit()
+	if err != nil {
+		return err
+	}
+
+	// set cluster name
+	if s.pool.Config["ceph.cluster_name"] != "" {
+		s.clusterName = s.pool.Config["ceph.cluster_name"]
+	} else {
+		s.clusterName = "ceph"
+	}
+
+	// set osd pool name
+	if s.pool.Config["ceph.osd.pool_name"] != "" {
+		s.osdPoolName = s.pool.Config["ceph.osd.pool_name"]
+	} else {
+		s.osdPoolName = s.pool.Name
+	}
+
+	// set ceph user name
+	if s.pool.Config["ceph.user.name"] != "" {
+		s.userName = s.pool.Config["ceph.user.name"]
+	} else {
+		s.userName = "admin"
+	}
+
+	// set default placement group number
+	if s.pool.Config["ceph.osd.pg_num"] != "" {
+		s.pgNum = s.pool.Config["ceph.osd.pg_num"]
+	} else {
+		s.pgNum = "32"
+	}
+
+	return nil
+}
+
+func (s *storageCeph) StoragePoolCheck() error {
+	shared.LogDebugf("Checking CEPH storage pool \"%s\".", s.pool.Name)
+
+	if !cephOSDPoolExists(s.clusterName, s.osdPoolName, s.userName) {
+		return fmt.Errorf("The CEPH OSD storage pool \"%s\" does not exist.", s.osdPoolName)
+	}
+
+	shared.LogDebugf("Checked CEPH storage pool \"%s\".", s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolCreate() error {
+	shared.LogInfof("Creating CEPH storage pool \"%s\".", s.pool.Name)
+	tryUndo := true
+
+	// The source property is an alias for the OSD pool name.
+	source := s.pool.Config["source"]
+	if source != "" {
+		if s.pool.Config["ceph.osd.pool_name"] != "" && s.pool.Config["ceph.osd.pool_name"] != source {
+			return fmt.Errorf("Invalid combination of \"source\" and \"ceph.osd.pool_name\" property.")
+		}
+		s.osdPoolName = source
+	}
+	s.pool.Config["source"] = s.osdPoolName
+	s.pool.Config["ceph.osd.pool_name"] = s.osdPoolName
+	s.pool.Config["ceph.cluster_name"] = s.clusterName
+	s.pool.Config["ceph.user.name"] = s.userName
+	s.pool.Config["ceph.osd.pg_num"] = s.pgNum
+
+	// Only destroy OSD pools on deletion that we created ourselves.
+	if !cephOSDPoolExists(s.clusterName, s.osdPoolName, s.userName) {
+		err := cephOSDPoolCreate(s.clusterName, s.osdPoolName, s.pgNum, s.userName)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if tryUndo {
+				err := cephOSDPoolDestroy(s.clusterName, s.osdPoolName, s.userName)
+				if err != nil {
+					shared.LogWarnf("Failed to delete CEPH OSD storage pool \"%s\" in cluster \"%s\": %s.", s.osdPoolName, s.clusterName, err)
+				}
+			}
+		}()
+		s.pool.Config["volatile.pool.pristine"] = "true"
+	} else {
+		s.pool.Config["volatile.pool.pristine"] = "false"
+	}
+
+	// Create the mountpoint for the storage pool.
+	poolMntPoint := getStoragePoolMountPoint(s.pool.Name)
+	err := os.MkdirAll(poolMntPoint, 0711)
+	if err != nil {
+		return err
+	}
+
+	// Deregister cleanup.
+	tryUndo = false
+
+	shared.LogInfof("Created CEPH storage pool \"%s\".", s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolDelete() error {
+	shared.LogInfof("Deleting CEPH storage pool \"%s\".", s.pool.Name)
+
+	if shared.IsTrue(s.pool.Config["volatile.pool.pristine"]) {
+		err := cephOSDPoolDestroy(s.clusterName, s.osdPoolName, s.userName)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Delete the mountpoint for the storage pool.
+	poolMntPoint := getStoragePoolMountPoint(s.pool.Name)
+	err := os.RemoveAll(poolMntPoint)
+	if err != nil {
+		return err
+	}
+
+	shared.LogInfof("Deleted CEPH storage pool \"%s\".", s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolMount() (bool, error) {
+	// Nothing to do for network backed storage pools.
+	return true, nil
+}
+
+func (s *storageCeph) StoragePoolUmount() (bool, error) {
+	// Nothing to do for network backed storage pools.
+	return true, nil
+}
+
+// getCustomVolumeRBD returns the name and volume type prefix of the RBD
+// storage volume backing a custom volume. Snapshots of custom volumes use their
+// own prefix, the snapshot delimiter is escaped like for LVM.
+func (s *storageCeph) getCustomVolumeRBD() (string, string) {
+	if shared.IsSnapshot(s.volume.Name) {
+		return containerNameToLVName(s.volume.Name), "custom-snapshots"
+	}
+
+	return s.volume.Name, storagePoolVolumeTypeNameCustom
+}
+
+func (s *storageCeph) StoragePoolVolumeCreate() error {
+	shared.LogInfof("Creating CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	tryUndo := true
+
+	rbdSize, err := s.getRBDSize()
+	if err != nil {
+		return err
+	}
+
+	volumeName, volumeType := s.getCustomVolumeRBD()
+	err = s.createRBDVolume(volumeName, volumeType, rbdSize)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			s.StoragePoolVolumeDelete()
+		}
+	}()
+
+	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	err = os.MkdirAll(customPoolVolumeMntPoint, 0711)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.StoragePoolVolumeMount()
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	shared.LogInfof("Created CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeDelete() error {
+	shared.LogInfof("Deleting CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	_, err := s.StoragePoolVolumeUmount()
+	if err != nil {
+		return err
+	}
+
+	volumeName, volumeType := s.getCustomVolumeRBD()
+	if cephRBDVolumeExists(s.clusterName, s.osdPoolName, volumeName, volumeType, s.userName) {
+		err = cephRBDVolumeDelete(s.clusterName, s.osdPoolName, volumeName, volumeType, s.userName)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Snapshots are clones of a snapshot of their volume
+	if shared.IsSnapshot(s.volume.Name) {
+		fields := strings.SplitN(s.volume.Name, shared.SnapshotDelimiter, 2)
+		err = s.deleteCustomVolumeOrigin(fields[0], fmt.Sprintf("snapshot_%s", fields[1]))
+		if err != nil {
+			return err
+		}
+	}
+
+	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	if shared.PathExists(customPoolVolumeMntPoint) {
+		err := os.Remove(customPoolVolumeMntPoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	storagePoolVolumeSnapshotsDirCleanup(s.pool.Name, s.volume.Name)
+
+	shared.LogInfof("Deleted CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+// deleteCustomVolumeOrigin deletes the RBD snapshot a snapshot of a custom
+// volume was cloned from, if there's one.
+func (s *storageCeph) deleteCustomVolumeOrigin(volumeName string, snapshotName string) error {
+	snapshots, err := cephRBDVolumeListSnapshots(s.clusterName, s.osdPoolName, volumeName, storagePoolVolumeTypeNameCustom, s.userName)
+	if err != nil || !shared.StringInSlice(snapshotName, snapshots) {
+		return nil
+	}
+
+	err = cephRBDSnapshotUnprotect(s.clusterName, s.osdPoolName, volumeName, storagePoolVolumeTypeNameCustom, snapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	return cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, volumeName, storagePoolVolumeTypeNameCustom, snapshotName, s.userName)
+}
+
+func (s *storageCeph) StoragePoolVolumeSnapshotCreate(target string) error {
+	shared.LogInfof("Creating CEPH storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+
+	volumeName, volumeType := s.getCustomVolumeRBD()
+	fields := strings.SplitN(target, shared.SnapshotDelimiter, 2)
+	snapshotName := fmt.Sprintf("snapshot_%s", fields[1])
+	targetName := containerNameToLVName(target)
+
+	err := cephRBDSnapshotCreate(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	tryUndo := true
+	defer func() {
+		if tryUndo {
+			cephRBDVolumeDelete(s.clusterName, s.osdPoolName, targetName, "custom-snapshots", s.userName)
+			s.deleteCustomVolumeOrigin(volumeName, snapshotName)
+			cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+		}
+	}()
+
+	err = cephRBDSnapshotProtect(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDCloneCreate(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.osdPoolName, targetName, "custom-snapshots", s.userName)
+	if err != nil {
+		return err
+	}
+
+	if s.getRBDFilesystem() == "xfs" {
+		err = s.generateNewXFSUUID(targetName, "custom-snapshots")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.MkdirAll(getStoragePoolVolumeMountPoint(s.pool.Name, target), 0711)
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	shared.LogInfof("Created CEPH storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeRename(newName string) error {
+	shared.LogInfof("Renaming CEPH storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+
+	_, err := s.StoragePoolVolumeUmount()
+	if err != nil {
+		return err
+	}
+
+	volumeName, volumeType := s.getCustomVolumeRBD()
+	newVolumeName := newName
+	if shared.IsSnapshot(newName) {
+		newVolumeName = containerNameToLVName(newName)
+	}
+
+	err = cephRBDVolumeRename(s.clusterName, s.osdPoolName, volumeType, volumeName, newVolumeName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	// Keep the snapshot the clone comes from named after it, the volume
+	// holding it was renamed first
+	if shared.IsSnapshot(newName) {
+		oldFields := strings.SplitN(s.volume.Name, shared.SnapshotDelimiter, 2)
+		newFields := strings.SplitN(newName, shared.SnapshotDelimiter, 2)
+		oldSnapshotName := fmt.Sprintf("snapshot_%s", oldFields[1])
+		newSnapshotName := fmt.Sprintf("snapshot_%s", newFields[1])
+
+		snapshots, err := cephRBDVolumeListSnapshots(s.clusterName, s.osdPoolName, newFields[0], storagePoolVolumeTypeNameCustom, s.userName)
+		if err == nil && oldSnapshotName != newSnapshotName && shared.StringInSlice(oldSnapshotName, snapshots) {
+			err = cephRBDSnapshotRename(s.clusterName, s.osdPoolName, newFields[0], storagePoolVolumeTypeNameCustom, oldSnapshotName, newSnapshotName, s.userName)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	oldMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	newMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, newName)
+	err = os.MkdirAll(filepath.Dir(newMntPoint), 0711)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(oldMntPoint, newMntPoint)
+	if err != nil {
+		return err
+	}
+
+	storagePoolVolumeSnapshotsDirCleanup(s.pool.Name, s.volume.Name)
+
+	shared.LogInfof("Renamed CEPH storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeMount() (bool, error) {
+	shared.LogDebugf("Mounting CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+
+	customMountLockID := getCustomMountLockID(s.pool.Name, s.volume.Name)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[customMountLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			shared.LogWarnf("Received value over semaphore. This should not have happened.")
+		}
+		// Give the benefit of the doubt and assume that the other
+		// thread actually succeeded in mounting the storage volume.
+		return false, nil
+	}
+
+	lxdStorageOngoingOperationMap[customMountLockID] = make(chan bool)
+	lxdStorageMapLock.Unlock()
+
+	var customerr error
+	ourMount := false
+	if !shared.IsMountPoint(customPoolVolumeMntPoint) {
+		volumeName, volumeType := s.getCustomVolumeRBD()
+		customerr = s.mountRBDVolume(volumeName, volumeType, "", customPoolVolumeMntPoint)
+		ourMount = true
+	}
+
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[customMountLockID]; ok {
+		close(waitChannel)
+		delete(lxdStorageOngoingOperationMap, customMountLockID)
+	}
+	lxdStorageMapLock.Unlock()
+
+	if customerr != nil {
+		return false, customerr
+	}
+
+	shared.LogDebugf("Mounted CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourMount, nil
+}
+
+func (s *storageCeph) StoragePoolVolumeUmount() (bool, error) {
+	shared.LogDebugf("Unmounting CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+
+	customUmountLockID := getCustomUmountLockID(s.pool.Name, s.volume.Name)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[customUmountLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			shared.LogWarnf("Received value over semaphore. This should not have happened.")
+		}
+		// Give the benefit of the doubt and assume that the other
+		// thread actually succeeded in unmounting the storage volume.
+		return false, nil
+	}
+
+	lxdStorageOngoingOperationMap[customUmountLockID] = make(chan bool)
+	lxdStorageMapLock.Unlock()
+
+	var customerr error
+	ourUmount := false
+	if shared.IsMountPoint(customPoolVolumeMntPoint) {
+		volumeName, volumeType := s.getCustomVolumeRBD()
+		customerr = s.umountRBDVolume(volumeName, volumeType, "", customPoolVolumeMntPoint)
+		ourUmount = true
+	}
+
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[customUmountLockID]; ok {
+		close(waitChannel)
+		delete(lxdStorageOngoingOperationMap, customUmountLockID)
+	}
+	lxdStorageMapLock.Unlock()
+
+	if customerr != nil {
+		return false, customerr
+	}
+
+	shared.LogDebugf("Unmounted CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourUmount, nil
+}
+
+func (s *storageCeph) GetStoragePoolWritable() api.StoragePoolPut {
+	return s.pool.Writable()
+}
+
+func (s *storageCeph) GetStoragePoolVolumeWritable() api.StorageVolumePut {
+	return s.volume.Writable()
+}
+
+func (s *storageCeph) SetStoragePoolWritable(writable *api.StoragePoolPut) {
+	s.pool.StoragePoolPut = *writable
+}
+
+func (s *storageCeph) SetStoragePoolVolumeWritable(writable *api.StorageVolumePut) {
+	s.volume.StorageVolumePut = *writable
+}
+
+func (s *storageCeph) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	output, err := shared.RunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", s.userName),
+		"--cluster", s.clusterName,
+		"df",
+		"-f", "json")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the usage of OSD pool \"%s\": %s", s.osdPoolName, output)
+	}
+
+	df := struct {
+		Pools []struct {
+			Name  string `json:"name"`
+			Stats struct {
+				BytesUsed uint64 `json:"bytes_used"`
+				MaxAvail  uint64 `json:"max_avail"`
+			} `json:"stats"`
+		} `json:"pools"`
+	}{}
+
+	err = json.Unmarshal([]byte(output), &df)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pool := range df.Pools {
+		if pool.Name != s.osdPoolName {
+			continue
+		}
+
+		// Inodes are per RBD volume
+		res := api.ResourcesStoragePool{}
+		res.Space.Used = pool.Stats.BytesUsed
+		res.Space.Total = pool.Stats.BytesUsed + pool.Stats.MaxAvail
+
+		return &res, nil
+	}
+
+	return nil, fmt.Errorf("OSD pool \"%s\" not found", s.osdPoolName)
+}
+
+func (s *storageCeph) GetContainerPoolInfo() (int64, string) {
+	return s.poolID, s.pool.Name
+}
+
+func (s *storageCeph) StoragePoolUpdate(writable *api.StoragePoolPut, changedConfig []string) error {
+	shared.LogInfof("Updating CEPH storage pool \"%s\".", s.pool.Name)
+
+	changeable := []string{
+		"volume.block.filesystem",
+		"volume.block.mount_options",
+		"volume.size"}
+	unchangeable := []string{}
+	for _, change := range changedConfig {
+		if !shared.StringInSlice(change, changeable) {
+			unchangeable = append(unchangeable, change)
+		}
+	}
+
+	if len(unchangeable) > 0 {
+		return fmt.Errorf("The following properties cannot be changed for \"%s\" storage pools: %s", s.pool.Driver, strings.Join(unchangeable, ", "))
+	}
+
+	// "volume.block.filesystem" requires no on-disk modifications.
+	// "volume.block.mount_options" requires no on-disk modifications.
+	// "volume.size" requires no on-disk modifications.
+
+	shared.LogInfof("Updated CEPH storage pool \"%s\".", s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) StoragePoolVolumeUpdate(changedConfig []string) error {
+	shared.LogInfof("Updating CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	if shared.StringInSlice("block.mount_options", changedConfig) && len(changedConfig) == 1 {
+		// noop
+	} else {
+		return fmt.Errorf("The properties \"%v\" cannot be changed.", changedConfig)
+	}
+
+	shared.LogInfof("Updated CEPH storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerStorageReady(name string) bool {
+	return cephRBDVolumeExists(s.clusterName, s.osdPoolName, name, storagePoolVolumeTypeNameContainer, s.userName)
+}
+
+func (s *storageCeph) ContainerCreate(container container) error {
+	shared.LogDebugf("Creating empty CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	tryUndo := true
+
+	containerName := container.Name()
+	rbdSize, err := s.getRBDSize()
+	if err != nil {
+		return err
+	}
+
+	err = s.createRBDVolume(containerName, storagePoolVolumeTypeNameContainer, rbdSize)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			s.ContainerDelete(container)
+		}
+	}()
+
+	containerMntPoint := getContainerMountPoint(s.pool.Name, containerName)
+	err = createContainerMountpoint(containerMntPoint, container.Path(), container.IsPrivileged())
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	shared.LogDebugf("Created empty CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerCreateFromImage(container container, fingerprint string) error {
+	shared.LogDebugf("Creating CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	tryUndo := true
+
+	imageStoragePoolLockID := getImageCreateLockID(s.pool.Name, fingerprint)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[imageStoragePoolLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			shared.LogWarnf("Received value over semaphore. This should not have happened.")
+		}
+	} else {
+		lxdStorageOngoingOperationMap[imageStoragePoolLockID] = make(chan bool)
+		lxdStorageMapLock.Unlock()
+
+		var imgerr error
+		if !cephRBDVolumeExists(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeTypeNameImage, s.userName) {
+			imgerr = s.ImageCreate(fingerprint)
+		}
+
+		lxdStorageMapLock.Lock()
+		if waitChannel, ok := lxdStorageOngoingOperationMap[imageStoragePoolLockID]; ok {
+			close(waitChannel)
+			delete(lxdStorageOngoingOperationMap, imageStoragePoolLockID)
+		}
+		lxdStorageMapLock.Unlock()
+
+		if imgerr != nil {
+			return imgerr
+		}
+	}
+
+	containerName := container.Name()
+	err := cephRBDCloneCreate(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeTypeNameImage, "readonly", s.osdPoolName, containerName, storagePoolVolumeTypeNameContainer, s.userName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			s.ContainerDelete(container)
+		}
+	}()
+
+	containerMntPoint := getContainerMountPoint(s.pool.Name, containerName)
+	containerPath := container.Path()
+	err = createContainerMountpoint(containerMntPoint, containerPath, container.IsPrivileged())
+	if err != nil {
+		return err
+	}
+
+	// Generate a new xfs's UUID
+	if s.getRBDFilesystem() == "xfs" {
+		err := s.generateNewXFSUUID(containerName, storagePoolVolumeTypeNameContainer)
+		if err != nil {
+			return err
+		}
+	}
+
+	ourMount, err := s.ContainerMount(containerName, containerPath)
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer s.ContainerUmount(containerName, containerPath)
+	}
+
+	if container.IsPrivileged() {
+		err = os.Chmod(containerMntPoint, 0700)
+	} else {
+		err = os.Chmod(containerMntPoint, 0755)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !container.IsPrivileged() {
+		err := s.shiftRootfs(container)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = container.TemplateApply("create")
+	if err != nil {
+		shared.LogErrorf("Error in create template during ContainerCreateFromImage, continuing to unmount: %s.", err)
+		return err
+	}
+
+	tryUndo = false
+
+	shared.LogDebugf("Created CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerCanRestore(container container, sourceContainer container) error {
+	return nil
+}
+
+func (s *storageCeph) ContainerDelete(container container) error {
+	shared.LogDebugf("Deleting CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	containerName := container.Name()
+	containerMntPoint := getContainerMountPoint(s.pool.Name, containerName)
+
+	// Make sure that the container is really unmounted at this point.
+	// Otherwise we will fail.
+	if shared.IsMountPoint(containerMntPoint) {
+		err := s.umountRBDVolume(containerName, storagePoolVolumeTypeNameContainer, "", containerMntPoint)
+		if err != nil {
+			return fmt.Errorf("Failed to unmount container path '%s': %s", containerMntPoint, err)
+		}
+	}
+
+	if cephRBDVolumeExists(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeTypeNameContainer, s.userName) {
+		// RBD storage volumes cannot be removed while they still have
+		// snapshots.
+		snapshots, err := cephRBDVolumeListSnapshots(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeTypeNameContainer, s.userName)
+		if err != nil {
+			return err
+		}
+
+		for _, snap := range snapshots {
+			err := cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeTypeNameContainer, snap, s.userName)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = cephRBDVolumeDelete(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeTypeNameContainer, s.userName)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := deleteContainerMountpoint(containerMntPoint, container.Path(), s.GetStorageTypeName())
+	if err != nil {
+		return err
+	}
+
+	shared.LogDebugf("Deleted CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerCopy(target container, source container, containerOnly bool) error {
+	shared.LogDebugf("Copying CEPH container storage %s -> %s.", source.Name(), target.Name())
+
+	tryUndo := true
+
+	_, sourcePool := source.Storage().GetContainerPoolInfo()
+	_, targetPool := target.Storage().GetContainerPoolInfo()
+	if sourcePool != targetPool {
+		return fmt.Errorf("Copying containers between different storage pools is not implemented.")
+	}
+
+	sourceName := source.Name()
+	sourceSnapshot := ""
+	if source.IsSnapshot() {
+		fields := strings.SplitN(sourceName, shared.SnapshotDelimiter, 2)
+		sourceName = fields[0]
+		sourceSnapshot = getCephRBDSnapshotName(fields[1])
+		containerOnly = true
+	}
+
+	targetName := target.Name()
+	size, err := cephRBDVolumeGetSize(s.clusterName, s.osdPoolName, sourceName, storagePoolVolumeTypeNameContainer, s.userName)
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDVolumeCreate(s.clusterName, s.osdPoolName, targetName, storagePoolVolumeTypeNameContainer, fmt.Sprintf("%d", size), s.userName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			s.ContainerDelete(target)
+		}
+	}()
+
+	// Transfer the snapshots one by one. export-diff/import-diff recreates
+	// each snapshot on the target so that only the changes between
+	// consecutive snapshots need to be copied.
+	lastSnapshot := ""
+	if !containerOnly {
+		snapshots, err := source.Snapshots()
+		if err != nil {
+			return err
+		}
+
+		for _, snap := range snapshots {
+			fields := strings.SplitN(snap.Name(), shared.SnapshotDelimiter, 2)
+			rbdSnapshotName := getCephRBDSnapshotName(fields[1])
+
+			err := cephRBDVolumeSendDiff(s.clusterName, s.osdPoolName, sourceName, targetName, storagePoolVolumeTypeNameContainer, lastSnapshot, rbdSnapshotName, s.userName)
+			if err != nil {
+				return err
+			}
+			lastSnapshot = rbdSnapshotName
+
+			newSnapName := fmt.Sprintf("%s/%s", targetName, fields[1])
+			targetSnapshotMntPoint := getSnapshotMountPoint(s.pool.Name, newSnapName)
+			snapshotMntPointSymlinkTarget := shared.VarPath("storage-pools", s.pool.Name, "snapshots", targetName)
+			snapshotMntPointSymlink := shared.VarPath("snapshots", targetName)
+			err = createSnapshotMountpoint(targetSnapshotMntPoint, snapshotMntPointSymlinkTarget, snapshotMntPointSymlink)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = cephRBDVolumeSendDiff(s.clusterName, s.osdPoolName, sourceName, targetName, storagePoolVolumeTypeNameContainer, lastSnapshot, sourceSnapshot, s.userName)
+	if err != nil {
+		return err
+	}
+
+	// Copying from a snapshot recreates the snapshot on the target which
+	// isn't wanted for a plain copy.
+	if sourceSnapshot != "" {
+		err := cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, targetName, storagePoolVolumeTypeNameContainer, sourceSnapshot, s.userName)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Generate a new xfs's UUID
+	if s.getRBDFilesystem() == "xfs" {
+		err := s.generateNewXFSUUID(targetName, storagePoolVolumeTypeNameContainer)
+		if err != nil {
+			return err
+		}
+	}
+
+	targetContainerMntPoint := getContainerMountPoint(s.pool.Name, targetName)
+	err = createContainerMountpoint(targetContainerMntPoint, target.Path(), target.IsPrivileged())
+	if err != nil {
+		return err
+	}
+
+	ourMount, err := s.ContainerMount(targetName, target.Path())
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer s.ContainerUmount(targetName, target.Path())
+	}
+
+	err = s.setUnprivUserAcl(source, targetContainerMntPoint)
+	if err != nil {
+		return err
+	}
+
+	err = target.TemplateApply("copy")
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	shared.LogDebugf("Copied CEPH container storage %s -> %s.", source.Name(), target.Name())
+	return nil
+}
+
+func (s *storageCeph) ContainerMount(name string, path string) (bool, error) {
+	shared.LogDebugf("Mounting CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	containerMntPoint := getContainerMountPoint(s.pool.Name, name)
+	volumeName := name
+	snapshotName := ""
+	if shared.IsSnapshot(name) {
+		containerMntPoint = getSnapshotMountPoint(s.pool.Name, name)
+		fields := strings.SplitN(name, shared.SnapshotDelimiter, 2)
+		volumeName = fields[0]
+		snapshotName = getCephRBDSnapshotName(fields[1])
+	}
+
+	containerMountLockID := getContainerMountLockID(s.pool.Name, name)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[containerMountLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			shared.LogWarnf("Received value over semaphore. This should not have happened.")
+		}
+		// Give the benefit of the doubt and assume that the other
+		// thread actually succeeded in mounting the storage volume.
+		return false, nil
+	}
+
+	lxdStorageOngoingOperationMap[containerMountLockID] = make(chan bool)
+	lxdStorageMapLock.Unlock()
+
+	var mounterr error
+	ourMount := false
+	if !shared.IsMountPoint(containerMntPoint) {
+		mounterr = s.mountRBDVolume(volumeName, storagePoolVolumeTypeNameContainer, snapshotName, containerMntPoint)
+		ourMount = true
+	}
+
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[containerMountLockID]; ok {
+		close(waitChannel)
+		delete(lxdStorageOngoingOperationMap, containerMountLockID)
+	}
+	lxdStorageMapLock.Unlock()
+
+	if mounterr != nil {
+		return false, mounterr
+	}
+
+	shared.LogDebugf("Mounted CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourMount, nil
+}
+
+func (s *storageCeph) ContainerUmount(name string, path string) (bool, error) {
+	shared.LogDebugf("Unmounting CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	containerMntPoint := getContainerMountPoint(s.pool.Name, name)
+	volumeName := name
+	snapshotName := ""
+	if shared.IsSnapshot(name) {
+		containerMntPoint = getSnapshotMountPoint(s.pool.Name, name)
+		fields := strings.SplitN(name, shared.SnapshotDelimiter, 2)
+		volumeName = fields[0]
+		snapshotName = getCephRBDSnapshotName(fields[1])
+	}
+
+	containerUmountLockID := getContainerUmountLockID(s.pool.Name, name)
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[containerUmountLockID]; ok {
+		lxdStorageMapLock.Unlock()
+		if _, ok := <-waitChannel; ok {
+			shared.LogWarnf("Received value over semaphore. This should not have happened.")
+		}
+		// Give the benefit of the doubt and assume that the other
+		// thread actually succeeded in unmounting the storage volume.
+		return false, nil
+	}
+
+	lxdStorageOngoingOperationMap[containerUmountLockID] = make(chan bool)
+	lxdStorageMapLock.Unlock()
+
+	var imgerr error
+	ourUmount := false
+	if shared.IsMountPoint(containerMntPoint) {
+		imgerr = s.umountRBDVolume(volumeName, storagePoolVolumeTypeNameContainer, snapshotName, containerMntPoint)
+		ourUmount = true
+	}
+
+	lxdStorageMapLock.Lock()
+	if waitChannel, ok := lxdStorageOngoingOperationMap[containerUmountLockID]; ok {
+		close(waitChannel)
+		delete(lxdStorageOngoingOperationMap, containerUmountLockID)
+	}
+	lxdStorageMapLock.Unlock()
+
+	if imgerr != nil {
+		return false, imgerr
+	}
+
+	shared.LogDebugf("Unmounted CEPH storage volume for container \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourUmount, nil
+}
+
+func (s *storageCeph) ContainerRename(container container, newContainerName string) error {
+	shared.LogDebugf("Renaming CEPH storage volume for container \"%s\" from %s -> %s.", s.volume.Name, s.volume.Name, newContainerName)
+
+	tryUndo := true
+
+	oldName := container.Name()
+
+	_, err := s.ContainerUmount(oldName, container.Path())
+	if err != nil {
+		return err
+	}
+
+	// Snapshots are part of the RBD storage volume and are renamed along
+	// with it.
+	err = cephRBDVolumeRename(s.clusterName, s.osdPoolName, storagePoolVolumeTypeNameContainer, oldName, newContainerName, s.userName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			cephRBDVolumeRename(s.clusterName, s.osdPoolName, storagePoolVolumeTypeNameContainer, newContainerName, oldName, s.userName)
+		}
+	}()
+
+	oldContainerMntPoint := getContainerMountPoint(s.pool.Name, oldName)
+	oldContainerMntPointSymlink := container.Path()
+	newContainerMntPoint := getContainerMountPoint(s.pool.Name, newContainerName)
+	newContainerMntPointSymlink := shared.VarPath("containers", newContainerName)
+	err = renameContainerMountpoint(oldContainerMntPoint, oldContainerMntPointSymlink, newContainerMntPoint, newContainerMntPointSymlink)
+	if err != nil {
+		return err
+	}
+
+	oldSnapshotPath := getSnapshotMountPoint(s.pool.Name, oldName)
+	newSnapshotPath := getSnapshotMountPoint(s.pool.Name, newContainerName)
+	if shared.PathExists(oldSnapshotPath) {
+		err = os.Rename(oldSnapshotPath, newSnapshotPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	oldSnapshotSymlink := shared.VarPath("snapshots", oldName)
+	newSnapshotSymlink := shared.VarPath("snapshots", newContainerName)
+	if shared.PathExists(oldSnapshotSymlink) {
+		err := os.Remove(oldSnapshotSymlink)
+		if err != nil {
+			return err
+		}
+
+		err = os.Symlink(newSnapshotPath, newSnapshotSymlink)
+		if err != nil {
+			return err
+		}
+	}
+
+	tryUndo = false
+
+	shared.LogDebugf("Renamed CEPH storage volume for container \"%s\" from %s -> %s.", s.volume.Name, s.volume.Name, newContainerName)
+	return nil
+}
+
+func (s *storageCeph) ContainerRestore(container container, sourceContainer container) error {
+	shared.LogDebugf("Restoring CEPH storage volume for container \"%s\" from %s -> %s.", s.volume.Name, sourceContainer.Name(), container.Name())
+
+	_, sourcePool := sourceContainer.Storage().GetContainerPoolInfo()
+	if s.pool.Name != sourcePool {
+		return fmt.Errorf("Containers must be on the same pool to be restored.")
+	}
+
+	fields := strings.SplitN(sourceContainer.Name(), shared.SnapshotDelimiter, 2)
+	if len(fields) != 2 || fields[0] != container.Name() {
+		return fmt.Errorf("Containers can only be restored from their own snapshots.")
+	}
+
+	_, err := s.ContainerUmount(container.Name(), container.Path())
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDSnapshotRollback(s.clusterName, s.osdPoolName, container.Name(), storagePoolVolumeTypeNameContainer, getCephRBDSnapshotName(fields[1]), s.userName)
+	if err != nil {
+		return err
+	}
+
+	shared.LogDebugf("Restored CEPH storage volume for container \"%s\" from %s -> %s.", s.volume.Name, sourceContainer.Name(), container.Name())
+	return nil
+}
+
+func (s *storageCeph) ContainerSetQuota(container container, size int64) error {
+	shared.LogDebugf("Setting CEPH quota for container \"%s\".", container.Name())
+
+	// RBD storage volumes always have a fixed size so there is nothing to
+	// do when the quota is removed.
+	if size <= 0 {
+		return nil
+	}
+
+	containerName := container.Name()
+	oldSize, err := cephRBDVolumeGetSize(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeTypeNameContainer, s.userName)
+	if err != nil {
+		return err
+	}
+
+	if size == oldSize {
+		return nil
+	}
+
+	if size < oldSize {
+		return fmt.Errorf("Shrinking CEPH storage volumes is not supported.")
+	}
+
+	err = cephRBDVolumeResize(s.clusterName, s.osdPoolName, containerName, storagePoolVolumeTypeNameContainer, size, s.userName)
+	if err != nil {
+		return err
+	}
+
+	// Grow the filesystem while mounted which works for both ext4 and xfs.
+	ourMount, err := s.ContainerMount(containerName, container.Path())
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer s.ContainerUmount(containerName, container.Path())
+	}
+
+	containerMntPoint := getContainerMountPoint(s.pool.Name, containerName)
+	devPath, err := cephGetMountSource(containerMntPoint)
+	if err != nil {
+		return err
+	}
+
+	err = cephGrowFilesystem(devPath, containerMntPoint, s.getRBDFilesystem())
+	if err != nil {
+		return err
+	}
+
+	shared.LogDebugf("Set CEPH quota for container \"%s\".", container.Name())
+	return nil
+}
+
+func (s *storageCeph) ContainerGetUsage(container container) (int64, error) {
+	return cephRBDVolumeGetUsage(s.clusterName, s.osdPoolName, container.Name(), storagePoolVolumeTypeNameContainer, s.userName)
+}
+
+func (s *storageCeph) ContainerSnapshotCreate(snapshotContainer container, sourceContainer container) error {
+	shared.LogDebugf("Creating CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	err := s.createSnapshot(snapshotContainer)
+	if err != nil {
+		return err
+	}
+
+	shared.LogDebugf("Created CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) createSnapshot(snapshotContainer container) error {
+	tryUndo := true
+
+	fields := strings.SplitN(snapshotContainer.Name(), shared.SnapshotDelimiter, 2)
+	sourceName := fields[0]
+	rbdSnapshotName := getCephRBDSnapshotName(fields[1])
+
+	err := cephRBDSnapshotCreate(s.clusterName, s.osdPoolName, sourceName, storagePoolVolumeTypeNameContainer, rbdSnapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, sourceName, storagePoolVolumeTypeNameContainer, rbdSnapshotName, s.userName)
+		}
+	}()
+
+	snapshotMntPoint := getSnapshotMountPoint(s.pool.Name, snapshotContainer.Name())
+	snapshotMntPointSymlinkTarget := shared.VarPath("storage-pools", s.pool.Name, "snapshots", sourceName)
+	snapshotMntPointSymlink := shared.VarPath("snapshots", sourceName)
+	err = createSnapshotMountpoint(snapshotMntPoint, snapshotMntPointSymlinkTarget, snapshotMntPointSymlink)
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	return nil
+}
+
+func (s *storageCeph) ContainerSnapshotDelete(snapshotContainer container) error {
+	shared.LogDebugf("Deleting CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	snapshotName := snapshotContainer.Name()
+	fields := strings.SplitN(snapshotName, shared.SnapshotDelimiter, 2)
+	sourceName := fields[0]
+	rbdSnapshotName := getCephRBDSnapshotName(fields[1])
+
+	snapshotMntPoint := getSnapshotMountPoint(s.pool.Name, snapshotName)
+	if shared.IsMountPoint(snapshotMntPoint) {
+		err := s.umountRBDVolume(sourceName, storagePoolVolumeTypeNameContainer, rbdSnapshotName, snapshotMntPoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, sourceName, storagePoolVolumeTypeNameContainer, rbdSnapshotName, s.userName)
+	if err != nil {
+		return fmt.Errorf("Error deleting snapshot %s: %s", snapshotName, err)
+	}
+
+	snapshotMntPointSymlinkTarget := shared.VarPath("storage-pools", s.pool.Name, "snapshots", sourceName)
+	snapshotMntPointSymlink := shared.VarPath("snapshots", sourceName)
+	err = deleteSnapshotMountpoint(snapshotMntPoint, snapshotMntPointSymlinkTarget, snapshotMntPointSymlink)
+	if err != nil {
+		return err
+	}
+
+	shared.LogDebugf("Deleted CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ContainerSnapshotRename(snapshotContainer container, newContainerName string) error {
+	shared.LogDebugf("Renaming CEPH storage volume for snapshot \"%s\" from %s -> %s.", s.volume.Name, s.volume.Name, newContainerName)
+
+	tryUndo := true
+
+	oldName := snapshotContainer.Name()
+	oldFields := strings.SplitN(oldName, shared.SnapshotDelimiter, 2)
+	newFields := strings.SplitN(newContainerName, shared.SnapshotDelimiter, 2)
+	sourceName := oldFields[0]
+	oldRBDSnapshotName := getCephRBDSnapshotName(oldFields[1])
+	newRBDSnapshotName := getCephRBDSnapshotName(newFields[1])
+
+	err := cephRBDSnapshotRename(s.clusterName, s.osdPoolName, sourceName, storagePoolVolumeTypeNameContainer, oldRBDSnapshotName, newRBDSnapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tryUndo {
+			cephRBDSnapshotRename(s.clusterName, s.osdPoolName, sourceName, storagePoolVolumeTypeNameContainer, newRBDSnapshotName, oldRBDSnapshotName, s.userName)
+		}
+	}()
+
+	oldSnapshotMntPoint := getSnapshotMountPoint(s.pool.Name, oldName)
+	newSnapshotMntPoint := getSnapshotMountPoint(s.pool.Name, newContainerName)
+	err = os.Rename(oldSnapshotMntPoint, newSnapshotMntPoint)
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	shared.LogDebugf("Renamed CEPH storage volume for snapshot \"%s\" from %s -> %s.", s.volume.Name, s.volume.Name, newContainerName)
+	return nil
+}
+
+func (s *storageCeph) ContainerSnapshotStart(container container) (bool, error) {
+	shared.LogDebugf("Initializing CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	ourMount, err := s.ContainerMount(container.Name(), container.Path())
+	if err != nil {
+		return false, err
+	}
+
+	shared.LogDebugf("Initialized CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourMount, nil
+}
+
+func (s *storageCeph) ContainerSnapshotStop(container container) (bool, error) {
+	shared.LogDebugf("Stopping CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	ourUmount, err := s.ContainerUmount(container.Name(), container.Path())
+	if err != nil {
+		return false, err
+	}
+
+	shared.LogDebugf("Stopped CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return ourUmount, nil
+}
+
+// ContainerSnapshotCreateEmpty creates a snapshot of the current state of the
+// parent's RBD storage volume. RBD snapshots cannot be written to so callers
+// need to fill the parent before creating the snapshot.
+func (s *storageCeph) ContainerSnapshotCreateEmpty(snapshotContainer container) error {
+	shared.LogDebugf("Creating empty CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+
+	err := s.createSnapshot(snapshotContainer)
+	if err != nil {
+		return err
+	}
+
+	shared.LogDebugf("Created empty CEPH storage volume for snapshot \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ImageCreate(fingerprint string) error {
+	shared.LogDebugf("Creating CEPH storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+
+	tryUndo := true
+	trySubUndo := true
+
+	rbdSize, err := s.getRBDSize()
+	if err != nil {
+		return err
+	}
+
+	err = s.createImageDbPoolVolume(fingerprint)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if !trySubUndo {
+			return
+		}
+		err := s.deleteImageDbPoolVolume(fingerprint)
+		if err != nil {
+			shared.LogWarnf("Could not delete image \"%s\" from storage volume database. Manual intervention needed.", fingerprint)
+		}
+	}()
+
+	err = s.createRBDVolume(fingerprint, storagePoolVolumeTypeNameImage, rbdSize)
+	if err != nil {
+		return err
+	}
+	trySubUndo = false
+	defer func() {
+		if tryUndo {
+			s.ImageDelete(fingerprint)
+		}
+	}()
+
+	// Create image mountpoint.
+	imageMntPoint := getImageMountPoint(s.pool.Name, fingerprint)
+	if !shared.PathExists(imageMntPoint) {
+		err := os.MkdirAll(imageMntPoint, 0700)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.ImageMount(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	imagePath := shared.VarPath("images", fingerprint)
+	err = unpackImage(s.d, imagePath, imageMntPoint, storageTypeCeph)
+	if err != nil {
+		s.ImageUmount(fingerprint)
+		return err
+	}
+
+	_, err = s.ImageUmount(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	// Containers are created as clones of a protected snapshot of the
+	// image.
+	err = cephRBDSnapshotCreate(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeTypeNameImage, "readonly", s.userName)
+	if err != nil {
+		return err
+	}
+
+	err = cephRBDSnapshotProtect(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeTypeNameImage, "readonly", s.userName)
+	if err != nil {
+		return err
+	}
+
+	tryUndo = false
+
+	shared.LogDebugf("Created CEPH storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ImageDelete(fingerprint string) error {
+	shared.LogDebugf("Deleting CEPH storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+
+	_, err := s.ImageUmount(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := cephRBDVolumeListSnapshots(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeTypeNameImage, s.userName)
+	if err != nil {
+		return err
+	}
+
+	if shared.StringInSlice("readonly", snapshots) {
+		// Containers cloned from the image need to be made independent
+		// of it before the image can go away.
+		clones, err := cephRBDSnapshotListClones(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeTypeNameImage, "readonly", s.userName)
+		if err != nil {
+			return err
+		}
+
+		for _, clone := range clones {
+			err := cephRBDFlatten(s.clusterName, clone, s.userName)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = cephRBDSnapshotUnprotect(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeTypeNameImage, "readonly", s.userName)
+		if err != nil {
+			return err
+		}
+
+		err = cephRBDSnapshotDelete(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeTypeNameImage, "readonly", s.userName)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = cephRBDVolumeDelete(s.clusterName, s.osdPoolName, fingerprint, storagePoolVolumeTypeNameImage, s.userName)
+	if err != nil {
+		return err
+	}
+
+	err = s.deleteImageDbPoolVolume(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	imageMntPoint := getImageMountPoint(s.pool.Name, fingerprint)
+	if shared.PathExists(imageMntPoint) {
+		err := os.Remove(imageMntPoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	shared.LogDebugf("Deleted CEPH storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+	return nil
+}
+
+func (s *storageCeph) ImageMount(fingerprint string) (bool, error) {
+	shared.LogDebugf("Mounting CEPH storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+
+	imageMntPoint := getImageMountPoint(s.pool.Name, fingerprint)
+	if shared.IsMountPoint(imageMntPoint) {
+		return false, nil
+	}
+
+	err := s.mountRBDVolume(fingerprint, storagePoolVolumeTypeNameImage, "", imageMntPoint)
+	if err != nil {
+		shared.LogErrorf(fmt.Sprintf("Error mounting image RBD storage volume for unpacking: %s", err))
+		return false, fmt.Errorf("Error mounting image RBD storage volume: %v", err)
+	}
+
+	shared.LogDebugf("Mounted CEPH storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+	return true, nil
+}
+
+func (s *storageCeph) ImageUmount(fingerprint string) (bool, error) {
+	shared.LogDebugf("Unmounting CEPH storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+
+	imageMntPoint := getImageMountPoint(s.pool.Name, fingerprint)
+	if !shared.IsMountPoint(imageMntPoint) {
+		return false, nil
+	}
+
+	err := s.umountRBDVolume(fingerprint, storagePoolVolumeTypeNameImage, "", imageMntPoint)
+	if err != nil {
+		return false, err
+	}
+
+	shared.LogDebugf("Unmounted CEPH storage volume for image \"%s\" on storage pool \"%s\".", fingerprint, s.pool.Name)
+	return true, nil
+}
+
+// createRBDVolume creates a new RBD storage volume and formats it with the
+// configured filesystem.
+func (s *storageCeph) createRBDVolume(volumeName string, volumeType string, size string) error {
+	err := cephRBDVolumeCreate(s.clusterName, s.osdPoolName, volumeName, volumeType, size, s.userName)
+	if err != nil {
+		return err
+	}
+
+	devPath, err := cephRBDVolumeMap(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+	if err != nil {
+		cephRBDVolumeDelete(s.clusterName, s.osdPoolName, volumeName, volumeType, s.userName)
+		return err
+	}
+
+	fserr := cephMakeFilesystem(devPath, s.getRBDFilesystem())
+
+	err = cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+	if err != nil {
+		return err
+	}
+
+	if fserr != nil {
+		cephRBDVolumeDelete(s.clusterName, s.osdPoolName, volumeName, volumeType, s.userName)
+		return fserr
+	}
+
+	return nil
+}
+
+// mountRBDVolume maps an RBD storage volume or RBD snapshot and mounts it at
+// the given path. Snapshots are mounted read-only.
+func (s *storageCeph) mountRBDVolume(volumeName string, volumeType string, snapshotName string, mntPoint string) error {
+	devPath, err := cephRBDVolumeMap(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+	if err != nil {
+		return err
+	}
+
+	fsType := s.getRBDFilesystem()
+	mountOptions := s.getRBDMountOptions()
+	mountFlags := uintptr(0)
+	if snapshotName != "" {
+		mountFlags = syscall.MS_RDONLY
+		mountOptions = cephSnapshotMountOptions(fsType, mountOptions)
+	}
+
+	err = tryMount(devPath, mntPoint, fsType, mountFlags, mountOptions)
+	if err != nil {
+		cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+		return err
+	}
+
+	return nil
+}
+
+// umountRBDVolume unmounts an RBD storage volume or RBD snapshot and unmaps
+// it.
+func (s *storageCeph) umountRBDVolume(volumeName string, volumeType string, snapshotName string, mntPoint string) error {
+	err := tryUnmount(mntPoint, 0)
+	if err != nil {
+		return err
+	}
+
+	return cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, volumeName, volumeType, snapshotName, s.userName)
+}
+
+// generateNewXFSUUID gives the xfs filesystem on a cloned or copied RBD
+// storage volume its own UUID so it can be mounted next to its origin.
+func (s *storageCeph) generateNewXFSUUID(volumeName string, volumeType string) error {
+	devPath, err := cephRBDVolumeMap(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+	if err != nil {
+		return err
+	}
+
+	uuiderr := xfsGenerateNewUUID(devPath)
+
+	err = cephRBDVolumeUnmap(s.clusterName, s.osdPoolName, volumeName, volumeType, "", s.userName)
+	if err != nil {
+		return err
+	}
+
+	return uuiderr
+}
+
+func (s *storageCeph) MigrationType() MigrationFSType {
+	return MigrationFSType_RSYNC
+}
+
+func (s *storageCeph) PreservesInodes() bool {
+	return false
+}
+
+func (s *storageCeph) MigrationSource(container container, containerOnly bool) (MigrationStorageSourceDriver, error) {
+	return rsyncMigrationSource(container, containerOnly)
+}
+
+func (s *storageCeph) MigrationSink(live bool, container container, snapshots []*Snapshot, conn *websocket.Conn, srcIdmap *shared.IdmapSet, op *operation, containerOnly bool) error {
+	return rsyncMigrationSink(live, container, snapshots, conn, srcIdmap, op, containerOnly)
+}
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// cephOSDPoolExists checks whether a given OSD pool exists.
+func cephOSDPoolExists(clusterName string, poolName string, userName string) bool {
+	_, err := shared.RunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", userName),
+		"--cluster", clusterName,
+		"osd",
+		"pool",
+		"get",
+		poolName,
+		"size")
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+// cephOSDPoolCreate creates an OSD pool with the given number of placement
+// groups.
+func cephOSDPoolCreate(clusterName string, poolName string, pgNum string, userName string) error {
+	output, err := shared.RunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", userName),
+		"--cluster", clusterName,
+		"osd",
+		"pool",
+		"create",
+		poolName,
+		pgNum)
+	if err != nil {
+		return fmt.Errorf("Failed to create OSD pool \"%s\": %s", poolName, output)
+	}
+
+	return nil
+}
+
+// cephOSDPoolDestroy destroys an OSD pool including any storage volumes that
+// still exist in it. The command succeeds even if the pool doesn't exist so
+// callers that care need to check for its existence first.
+func cephOSDPoolDestroy(clusterName string, poolName string, userName string) error {
+	output, err := shared.RunCommand(
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", userName),
+		"--cluster", clusterName,
+		"osd",
+		"pool",
+		"delete",
+		poolName,
+		poolName,
+		"--yes-i-really-really-mean-it")
+	if err != nil {
+		return fmt.Errorf("Failed to destroy OSD pool \"%s\": %s", poolName, output)
+	}
+
+	return nil
+}
+
+// getCephRBDName returns the name of the RBD storage volume backing a LXD
+// storage volume of the given type.
+func getCephRBDName(volumeType string, volumeName string) string {
+	return fmt.Sprintf("%s_%s", volumeType, volumeName)
+}
+
+// getCephRBDSnapshotName returns the name of the RBD snapshot backing a LXD
+// snapshot.
+func getCephRBDSnapshotName(snapshotName string) string {
+	return fmt.Sprintf("snapshot_%s", snapshotName)
+}
+
+// cephRBDSpec returns an RBD image specification of the form
+// <pool>/<volume>[@<snapshot>].
+func cephRBDSpec(poolName string, volumeName string, volumeType string, snapshotName string) string {
+	spec := fmt.Sprintf("%s/%s", poolName, getCephRBDName(volumeType, volumeName))
+	if snapshotName != "" {
+		spec = fmt.Sprintf("%s@%s", spec, snapshotName)
+	}
+
+	return spec
+}
+
+// cephRBDCommand runs an rbd command against the given cluster as the given
+// user.
+func cephRBDCommand(clusterName string, userName string, args ...string) (string, error) {
+	cmd := append([]string{"--id", userName, "--cluster", clusterName}, args...)
+	return shared.RunCommand("rbd", cmd...)
+}
+
+// cephRBDVolumeCreate creates an RBD storage volume.
+// Note that the set of features is intentionally limited by passing
+// --image-feature explicitly. This is done to ensure that the chances of a
+// conflict between the features supported by the userspace library and the
+// kernel module are minimized. Otherwise random panics might occur.
+func cephRBDVolumeCreate(clusterName string, poolName string, volumeName string, volumeType string, size string, userName string) error {
+	sz, err := shared.ParseByteSizeString(size)
+	if err != nil {
+		return err
+	}
+
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"--image-feature", "layering",
+		"--pool", poolName,
+		"--size", fmt.Sprintf("%dB", sz),
+		"create",
+		getCephRBDName(volumeType, volumeName))
+	if err != nil {
+		return fmt.Errorf("Failed to create RBD storage volume \"%s\": %s", volumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeExists checks whether a given RBD storage volume exists.
+func cephRBDVolumeExists(clusterName string, poolName string, volumeName string, volumeType string, userName string) bool {
+	_, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"--pool", poolName,
+		"info",
+		getCephRBDName(volumeType, volumeName))
+	if err != nil {
+		return false
+	}
+
+	return true
+}
+
+// cephRBDVolumeDelete deletes an RBD storage volume.
+func cephRBDVolumeDelete(clusterName string, poolName string, volumeName string, volumeType string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"--pool", poolName,
+		"rm",
+		getCephRBDName(volumeType, volumeName))
+	if err != nil {
+		return fmt.Errorf("Failed to delete RBD storage volume \"%s\": %s", volumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeRename renames an RBD storage volume.
+func cephRBDVolumeRename(clusterName string, poolName string, volumeType string, oldVolumeName string, newVolumeName string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"mv",
+		cephRBDSpec(poolName, oldVolumeName, volumeType, ""),
+		cephRBDSpec(poolName, newVolumeName, volumeType, ""))
+	if err != nil {
+		return fmt.Errorf("Failed to rename RBD storage volume \"%s\": %s", oldVolumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeMap maps a given RBD storage volume or RBD snapshot and returns
+// the path to the block device. Snapshots are always mapped read-only.
+func cephRBDVolumeMap(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) (string, error) {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"map",
+		cephRBDSpec(poolName, volumeName, volumeType, snapshotName))
+	if err != nil {
+		return "", fmt.Errorf("Failed to map RBD storage volume \"%s\": %s", volumeName, output)
+	}
+
+	devPath := strings.TrimSpace(output)
+	if !strings.HasPrefix(devPath, "/dev/") {
+		return "", fmt.Errorf("Unexpected output when mapping RBD storage volume \"%s\": %s", volumeName, output)
+	}
+
+	return devPath, nil
+}
+
+// cephRBDVolumeUnmap unmaps a given RBD storage volume or RBD snapshot.
+func cephRBDVolumeUnmap(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"unmap",
+		cephRBDSpec(poolName, volumeName, volumeType, snapshotName))
+	if err != nil {
+		return fmt.Errorf("Failed to unmap RBD storage volume \"%s\": %s", volumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeResize changes the size of an RBD storage volume. Shrinking is
+// refused as the filesystem on top of it cannot follow.
+func cephRBDVolumeResize(clusterName string, poolName string, volumeName string, volumeType string, size int64, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"--pool", poolName,
+		"--size", fmt.Sprintf("%dB", size),
+		"resize",
+		getCephRBDName(volumeType, volumeName))
+	if err != nil {
+		return fmt.Errorf("Failed to resize RBD storage volume \"%s\": %s", volumeName, output)
+	}
+
+	return nil
+}
+
+type cephRBDDiskUsage struct {
+	Images []struct {
+		Name     string `json:"name"`
+		Snapshot string `json:"snapshot"`
+		Used     int64  `json:"used_size"`
+	} `json:"images"`
+}
+
+// cephRBDVolumeGetUsage returns the number of bytes used by an RBD storage
+// volume, excluding its snapshots.
+func cephRBDVolumeGetUsage(clusterName string, poolName string, volumeName string, volumeType string, userName string) (int64, error) {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"--pool", poolName,
+		"--format", "json",
+		"du",
+		getCephRBDName(volumeType, volumeName))
+	if err != nil {
+		return -1, fmt.Errorf("Failed to retrieve usage of RBD storage volume \"%s\": %s", volumeName, output)
+	}
+
+	usage := cephRBDDiskUsage{}
+	err = json.Unmarshal([]byte(output), &usage)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, image := range usage.Images {
+		if image.Name == getCephRBDName(volumeType, volumeName) && image.Snapshot == "" {
+			return image.Used, nil
+		}
+	}
+
+	return -1, fmt.Errorf("No usage information found for RBD storage volume \"%s\"", volumeName)
+}
+
+// cephRBDSnapshotCreate creates a snapshot of an RBD storage volume.
+func cephRBDSnapshotCreate(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"snap",
+		"create",
+		cephRBDSpec(poolName, volumeName, volumeType, snapshotName))
+	if err != nil {
+		return fmt.Errorf("Failed to create snapshot \"%s\" of RBD storage volume \"%s\": %s", snapshotName, volumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotDelete deletes a snapshot of an RBD storage volume.
+func cephRBDSnapshotDelete(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"snap",
+		"rm",
+		cephRBDSpec(poolName, volumeName, volumeType, snapshotName))
+	if err != nil {
+		return fmt.Errorf("Failed to delete snapshot \"%s\" of RBD storage volume \"%s\": %s", snapshotName, volumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotRename renames a snapshot of an RBD storage volume.
+func cephRBDSnapshotRename(clusterName string, poolName string, volumeName string, volumeType string, oldSnapshotName string, newSnapshotName string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"snap",
+		"rename",
+		cephRBDSpec(poolName, volumeName, volumeType, oldSnapshotName),
+		cephRBDSpec(poolName, volumeName, volumeType, newSnapshotName))
+	if err != nil {
+		return fmt.Errorf("Failed to rename snapshot \"%s\" of RBD storage volume \"%s\": %s", oldSnapshotName, volumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotRollback restores an RBD storage volume to the state of one
+// of its snapshots.
+func cephRBDSnapshotRollback(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"snap",
+		"rollback",
+		cephRBDSpec(poolName, volumeName, volumeType, snapshotName))
+	if err != nil {
+		return fmt.Errorf("Failed to restore RBD storage volume \"%s\" from snapshot \"%s\": %s", volumeName, snapshotName, output)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotProtect protects a snapshot of an RBD storage volume so that
+// it can be used as the parent of clones.
+func cephRBDSnapshotProtect(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"snap",
+		"protect",
+		cephRBDSpec(poolName, volumeName, volumeType, snapshotName))
+	if err != nil {
+		return fmt.Errorf("Failed to protect snapshot \"%s\" of RBD storage volume \"%s\": %s", snapshotName, volumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotUnprotect unprotects a snapshot of an RBD storage volume.
+// This will fail if the snapshot still has clones.
+func cephRBDSnapshotUnprotect(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"snap",
+		"unprotect",
+		cephRBDSpec(poolName, volumeName, volumeType, snapshotName))
+	if err != nil {
+		return fmt.Errorf("Failed to unprotect snapshot \"%s\" of RBD storage volume \"%s\": %s", snapshotName, volumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDSnapshotListClones returns the RBD specifications of all clones of a
+// given snapshot.
+func cephRBDSnapshotListClones(clusterName string, poolName string, volumeName string, volumeType string, snapshotName string, userName string) ([]string, error) {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"children",
+		cephRBDSpec(poolName, volumeName, volumeType, snapshotName))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list clones of snapshot \"%s\" of RBD storage volume \"%s\": %s", snapshotName, volumeName, output)
+	}
+
+	clones := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		clones = append(clones, line)
+	}
+
+	return clones, nil
+}
+
+// cephRBDCloneCreate creates a clone of a protected RBD snapshot.
+func cephRBDCloneCreate(clusterName string, sourcePoolName string, sourceVolumeName string, sourceVolumeType string, sourceSnapshotName string, targetPoolName string, targetVolumeName string, targetVolumeType string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"--image-feature", "layering",
+		"clone",
+		cephRBDSpec(sourcePoolName, sourceVolumeName, sourceVolumeType, sourceSnapshotName),
+		cephRBDSpec(targetPoolName, targetVolumeName, targetVolumeType, ""))
+	if err != nil {
+		return fmt.Errorf("Failed to clone RBD storage volume \"%s\": %s", sourceVolumeName, output)
+	}
+
+	return nil
+}
+
+// cephRBDFlatten copies all data shared with the parent snapshot into a clone
+// so that the clone becomes independent of its parent. It takes a full RBD
+// specification as returned by cephRBDSnapshotListClones.
+func cephRBDFlatten(clusterName string, spec string, userName string) error {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"flatten",
+		spec)
+	if err != nil {
+		return fmt.Errorf("Failed to flatten RBD storage volume \"%s\": %s", spec, output)
+	}
+
+	return nil
+}
+
+// cephRBDVolumeSendDiff transfers the changes of an RBD storage volume
+// between two points in time to another RBD storage volume. When toSnapshot
+// is set the snapshot is recreated on the target. An empty fromSnapshot
+// transfers everything up to toSnapshot (or the current state of the volume).
+func cephRBDVolumeSendDiff(clusterName string, poolName string, sourceVolumeName string, targetVolumeName string, volumeType string, fromSnapshot string, toSnapshot string, userName string) error {
+	exportArgs := []string{"--id", userName, "--cluster", clusterName, "export-diff"}
+	if fromSnapshot != "" {
+		exportArgs = append(exportArgs, "--from-snap", fromSnapshot)
+	}
+	exportArgs = append(exportArgs, cephRBDSpec(poolName, sourceVolumeName, volumeType, toSnapshot), "-")
+
+	importArgs := []string{"--id", userName, "--cluster", clusterName, "import-diff", "-", cephRBDSpec(poolName, targetVolumeName, volumeType, "")}
+
+	exportCmd := exec.Command("rbd", exportArgs...)
+	importCmd := exec.Command("rbd", importArgs...)
+
+	reader, writer := io.Pipe()
+	exportCmd.Stdout = writer
+	importCmd.Stdin = reader
+
+	exportStderr := &bytes.Buffer{}
+	importStderr := &bytes.Buffer{}
+	exportCmd.Stderr = exportStderr
+	importCmd.Stderr = importStderr
+
+	err := importCmd.Start()
+	if err != nil {
+		return err
+	}
+
+	exportErr := exportCmd.Run()
+	writer.CloseWithError(exportErr)
+	importErr := importCmd.Wait()
+
+	if exportErr != nil {
+		return fmt.Errorf("Failed to export RBD storage volume \"%s\": %s", sourceVolumeName, exportStderr.String())
+	}
+
+	if importErr != nil {
+		return fmt.Errorf("Failed to import RBD storage volume \"%s\": %s", targetVolumeName, importStderr.String())
+	}
+
+	return nil
+}
+
+type cephRBDSnapshotEntry struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// cephRBDVolumeListSnapshots returns the names of all snapshots of an RBD
+// storage volume ordered from oldest to newest.
+func cephRBDVolumeListSnapshots(clusterName string, poolName string, volumeName string, volumeType string, userName string) ([]string, error) {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"--format", "json",
+		"snap",
+		"ls",
+		cephRBDSpec(poolName, volumeName, volumeType, ""))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list snapshots of RBD storage volume \"%s\": %s", volumeName, output)
+	}
+
+	entries := []cephRBDSnapshotEntry{}
+	err = json.Unmarshal([]byte(output), &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []string{}
+	for _, entry := range entries {
+		snapshots = append(snapshots, entry.Name)
+	}
+
+	return snapshots, nil
+}
+
+// cephMakeFilesystem creates a filesystem on a mapped RBD storage volume.
+func cephMakeFilesystem(devPath string, fsType string) error {
+	var output string
+	var err error
+
+	switch fsType {
+	case "xfs":
+		output, err = shared.TryRunCommand("mkfs.xfs", devPath)
+	default:
+		// default = ext4
+		output, err = shared.TryRunCommand(
+			"mkfs.ext4",
+			"-E", "nodiscard,lazy_itable_init=0,lazy_journal_init=0",
+			devPath)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Error making filesystem on RBD storage volume: %s", output)
+	}
+
+	return nil
+}
+
+// cephGrowFilesystem grows the filesystem on a mapped RBD storage volume to
+// the size of the underlying device. xfs can only be grown while mounted.
+func cephGrowFilesystem(devPath string, mntPoint string, fsType string) error {
+	var output string
+	var err error
+
+	switch fsType {
+	case "xfs":
+		output, err = shared.TryRunCommand("xfs_growfs", mntPoint)
+	default:
+		// default = ext4
+		output, err = shared.TryRunCommand("resize2fs", devPath)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Could not grow filesystem on RBD storage volume: %s", output)
+	}
+
+	return nil
+}
+
+// cephSnapshotMountOptions returns the mount options needed to mount a
+// read-only mapped RBD snapshot. The filesystem on the snapshot was captured
+// while mounted so the journal must not be replayed.
+func cephSnapshotMountOptions(fsType string, mountOptions string) string {
+	extra := "noload"
+	if fsType == "xfs" {
+		extra = "nouuid,norecovery"
+	}
+
+	if mountOptions == "" {
+		return extra
+	}
+
+	return fmt.Sprintf("%s,%s", mountOptions, extra)
+}
+
+type cephRBDVolumeInfo struct {
+	Size int64 `json:"size"`
+}
+
+// cephRBDVolumeGetSize returns the size in bytes of an RBD storage volume.
+func cephRBDVolumeGetSize(clusterName string, poolName string, volumeName string, volumeType string, userName string) (int64, error) {
+	output, err := cephRBDCommand(
+		clusterName,
+		userName,
+		"--pool", poolName,
+		"--format", "json",
+		"info",
+		getCephRBDName(volumeType, volumeName))
+	if err != nil {
+		return -1, fmt.Errorf("Failed to retrieve size of RBD storage volume \"%s\": %s", volumeName, output)
+	}
+
+	info := cephRBDVolumeInfo{}
+	err = json.Unmarshal([]byte(output), &info)
+	if err != nil {
+		return -1, err
+	}
+
+	return info.Size, nil
+}
+
+// cephGetMountSource returns the block device mounted at the given path.
+func cephGetMountSource(mntPoint string) (string, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		rows := strings.Fields(scanner.Text())
+		if len(rows) < 10 || rows[4] != mntPoint {
+			continue
+		}
+
+		// Go backward to avoid problems with optional fields
+		return rows[len(rows)-2], nil
+	}
+
+	return "", fmt.Errorf("Couldn't find a match /proc/self/mountinfo entry for \"%s\"", mntPoint)
+}
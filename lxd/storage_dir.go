@@ -153,6 +153,10 @@ func (s *storageDir) SetStoragePoolVolumeWritable(writable *api.StorageVolumePut
 	s.volume.StorageVolumePut = *writable
 }
 
+func (s *storageDir) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	return storageResource(getStoragePoolMountPoint(s.pool.Name))
+}
+
 func (s *storageDir) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
@@ -198,6 +202,8 @@ func (s *storageDir) StoragePoolVolumeDelete() error {
 		return err
 	}
 
+	storagePoolVolumeSnapshotsDirCleanup(s.pool.Name, s.volume.Name)
+
 	shared.LogInfof("Deleted DIR storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 	return nil
 }
@@ -214,6 +220,44 @@ func (s *storageDir) StoragePoolVolumeUpdate(changedConfig []string) error {
 	return fmt.Errorf("Dir storage properties cannot be changed.")
 }
 
+// StoragePoolVolumeSnapshotCreate copies the volume, plain directories can't
+// be snapshotted.
+func (s *storageDir) StoragePoolVolumeSnapshotCreate(target string) error {
+	shared.LogInfof("Creating DIR storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+
+	sourcePath := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	targetPath := getStoragePoolVolumeMountPoint(s.pool.Name, target)
+	output, err := storageRsyncCopy(sourcePath, targetPath)
+	if err != nil {
+		os.RemoveAll(targetPath)
+		return fmt.Errorf("Failed to rsync: %s: %s", output, err)
+	}
+
+	shared.LogInfof("Created DIR storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+	return nil
+}
+
+func (s *storageDir) StoragePoolVolumeRename(newName string) error {
+	shared.LogInfof("Renaming DIR storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+
+	oldPath := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	newPath := getStoragePoolVolumeMountPoint(s.pool.Name, newName)
+	err := os.MkdirAll(filepath.Dir(newPath), 0711)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(oldPath, newPath)
+	if err != nil {
+		return err
+	}
+
+	storagePoolVolumeSnapshotsDirCleanup(s.pool.Name, s.volume.Name)
+
+	shared.LogInfof("Renamed DIR storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
 func (s *storageDir) ContainerStorageReady(name string) bool {
 	containerMntPoint := getContainerMountPoint(s.pool.Name, name)
 	ok, _ := shared.PathIsEmpty(containerMntPoint)
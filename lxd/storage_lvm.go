@@ -127,6 +127,28 @@ func storageLVMThinpoolExists(vgName string, poolName string) (bool, error) {
 	return false, fmt.Errorf("Pool named '%s' exists but is not a thin pool.", poolName)
 }
 
+// lvmParseThinpoolUsage parses the "lv_size,data_percent" output of lvs for a
+// thin pool into its size and the bytes in use.
+func lvmParseThinpoolUsage(output string) (uint64, uint64, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("Unexpected output from lvs: %s", output)
+	}
+
+	size, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The decimal separator follows the locale
+	percent, err := strconv.ParseFloat(strings.Replace(fields[1], ",", ".", 1), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return size, uint64(float64(size) * percent / 100), nil
+}
+
 func storageLVMGetThinPoolUsers(d *Daemon) ([]string, error) {
 	results := []string{}
 
@@ -148,7 +170,7 @@ func storageLVMGetThinPoolUsers(d *Daemon) ([]string, error) {
 		}
 	}
 
-	imageNames, err := dbImagesGet(d.db, false)
+	imageNames, err := dbImagesGetFingerprints(d.db)
 	if err != nil {
 		return results, err
 	}
@@ -226,6 +248,22 @@ func containerNameToLVName(containerName string) string {
 	return strings.Replace(lvName, shared.SnapshotDelimiter, "-", -1)
 }
 
+// getCustomVolumeLV returns the volume type prefix and name of the LV backing
+// a custom volume. Snapshots of custom volumes use their own prefix so that
+// their escaped names can't clash with the names of custom volumes.
+func (s *storageLvm) getCustomVolumeLV() (string, string, error) {
+	if shared.IsSnapshot(s.volume.Name) {
+		return "custom-snapshots", containerNameToLVName(s.volume.Name), nil
+	}
+
+	volumeType, err := storagePoolVolumeTypeNameToApiEndpoint(s.volume.Type)
+	if err != nil {
+		return "", "", err
+	}
+
+	return volumeType, s.volume.Name, nil
+}
+
 type storageLvm struct {
 	vgName       string
 	thinPoolName string
@@ -729,7 +767,7 @@ func (s *storageLvm) StoragePoolVolumeCreate() error {
 		return err
 	}
 
-	volumeType, err := storagePoolVolumeTypeNameToApiEndpoint(s.volume.Type)
+	volumeType, lvName, err := s.getCustomVolumeLV()
 	if err != nil {
 		return err
 	}
@@ -739,7 +777,7 @@ func (s *storageLvm) StoragePoolVolumeCreate() error {
 		return err
 	}
 
-	err = lvmCreateThinLV(poolName, thinPoolName, s.volume.Name, lvFsType, lvSize, volumeType)
+	err = lvmCreateThinLV(poolName, thinPoolName, lvName, lvFsType, lvSize, volumeType)
 	if err != nil {
 		shared.LogErrorf("LVMCreateThinLV: %s.", err)
 		return fmt.Errorf("Error Creating LVM LV for new image: %v", err)
@@ -776,13 +814,13 @@ func (s *storageLvm) StoragePoolVolumeDelete() error {
 		return err
 	}
 
-	volumeType, err := storagePoolVolumeTypeNameToApiEndpoint(s.volume.Type)
+	volumeType, lvName, err := s.getCustomVolumeLV()
 	if err != nil {
 		return err
 	}
 
 	poolName := s.getOnDiskPoolName()
-	err = s.removeLV(poolName, volumeType, s.volume.Name)
+	err = s.removeLV(poolName, volumeType, lvName)
 	if err != nil {
 		return err
 	}
@@ -794,10 +832,91 @@ func (s *storageLvm) StoragePoolVolumeDelete() error {
 		}
 	}
 
+	storagePoolVolumeSnapshotsDirCleanup(s.pool.Name, s.volume.Name)
+
 	shared.LogInfof("Deleted LVM storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 	return nil
 }
 
+func (s *storageLvm) StoragePoolVolumeSnapshotCreate(target string) error {
+	shared.LogInfof("Creating LVM storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+
+	volumeType, lvName, err := s.getCustomVolumeLV()
+	if err != nil {
+		return err
+	}
+
+	poolName := s.getOnDiskPoolName()
+	targetLvName := containerNameToLVName(target)
+	targetLvmVolumePath, err := s.createSnapshotLV(poolName, lvName, volumeType, targetLvName, "custom-snapshots", false)
+	if err != nil {
+		return fmt.Errorf("Error creating snapshot LV: %s", err)
+	}
+
+	// Generate a new xfs's UUID
+	if s.getLvmFilesystem() == "xfs" {
+		err := xfsGenerateNewUUID(targetLvmVolumePath)
+		if err != nil {
+			s.removeLV(poolName, "custom-snapshots", targetLvName)
+			return err
+		}
+	}
+
+	targetMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, target)
+	err = os.MkdirAll(targetMntPoint, 0711)
+	if err != nil {
+		s.removeLV(poolName, "custom-snapshots", targetLvName)
+		return err
+	}
+
+	shared.LogInfof("Created LVM storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+	return nil
+}
+
+func (s *storageLvm) StoragePoolVolumeRename(newName string) error {
+	shared.LogInfof("Renaming LVM storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+
+	_, err := s.StoragePoolVolumeUmount()
+	if err != nil {
+		return err
+	}
+
+	volumeType, lvName, err := s.getCustomVolumeLV()
+	if err != nil {
+		return err
+	}
+
+	newVolumeType := volumeType
+	newLvName := newName
+	if shared.IsSnapshot(newName) {
+		newVolumeType = "custom-snapshots"
+		newLvName = containerNameToLVName(newName)
+	}
+
+	poolName := s.getOnDiskPoolName()
+	err = lvmLVRename(poolName, getPrefixedLvName(volumeType, lvName), getPrefixedLvName(newVolumeType, newLvName))
+	if err != nil {
+		return err
+	}
+
+	oldMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	newMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, newName)
+	err = os.MkdirAll(filepath.Dir(newMntPoint), 0711)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(oldMntPoint, newMntPoint)
+	if err != nil {
+		return err
+	}
+
+	storagePoolVolumeSnapshotsDirCleanup(s.pool.Name, s.volume.Name)
+
+	shared.LogInfof("Renamed LVM storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
 func (s *storageLvm) StoragePoolVolumeMount() (bool, error) {
 	shared.LogDebugf("Mounting LVM storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 
@@ -805,11 +924,11 @@ func (s *storageLvm) StoragePoolVolumeMount() (bool, error) {
 	poolName := s.getOnDiskPoolName()
 	mountOptions := s.getLvmBlockMountOptions()
 	lvFsType := s.getLvmFilesystem()
-	volumeType, err := storagePoolVolumeTypeNameToApiEndpoint(s.volume.Type)
+	volumeType, lvName, err := s.getCustomVolumeLV()
 	if err != nil {
 		return false, err
 	}
-	lvmVolumePath := getLvmDevPath(poolName, volumeType, s.volume.Name)
+	lvmVolumePath := getLvmDevPath(poolName, volumeType, lvName)
 
 	customMountLockID := getCustomMountLockID(s.pool.Name, s.volume.Name)
 	lxdStorageMapLock.Lock()
@@ -906,6 +1025,59 @@ func (s *storageLvm) SetStoragePoolVolumeWritable(writable *api.StorageVolumePut
 	s.volume.StorageVolumePut = *writable
 }
 
+func (s *storageLvm) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	vgName := s.getOnDiskPoolName()
+
+	// Volumes live in the thin pool, only its space is available to them
+	thinPoolName := s.getLvmThinpoolName()
+	exists, _ := storageLVMThinpoolExists(vgName, thinPoolName)
+	if exists {
+		output, err := shared.RunCommand("lvs", "--noheadings", "--nosuffix", "--units", "b", "-o", "lv_size,data_percent", fmt.Sprintf("%s/%s", vgName, thinPoolName))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get the usage of thin pool \"%s\": %s", thinPoolName, output)
+		}
+
+		size, used, err := lvmParseThinpoolUsage(output)
+		if err != nil {
+			return nil, err
+		}
+
+		// Inodes are per logical volume
+		res := api.ResourcesStoragePool{}
+		res.Space.Used = used
+		res.Space.Total = size
+
+		return &res, nil
+	}
+
+	output, err := shared.RunCommand("vgs", "--noheadings", "--nosuffix", "--units", "b", "-o", "vg_size,vg_free", vgName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the usage of volume group \"%s\": %s", vgName, output)
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("Unexpected output from vgs: %s", output)
+	}
+
+	size, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	free, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	// Inodes are per logical volume
+	res := api.ResourcesStoragePool{}
+	res.Space.Used = size - free
+	res.Space.Total = size
+
+	return &res, nil
+}
+
 func (s *storageLvm) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
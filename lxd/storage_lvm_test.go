@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_lvmParseThinpoolUsage(t *testing.T) {
+	tests := []struct {
+		output string
+		size   uint64
+		used   uint64
+		valid  bool
+	}{
+		{"  10737418240 25.00\n", 10737418240, 2684354560, true},
+		{"  10737418240 0,00\n", 10737418240, 0, true},
+		{"  10737418240\n", 0, 0, false},
+		{"  10737418240 foo\n", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		size, used, err := lvmParseThinpoolUsage(test.output)
+		if test.valid != (err == nil) {
+			t.Errorf("Unexpected result for %q: %v", test.output, err)
+			continue
+		}
+
+		if size != test.size || used != test.used {
+			t.Errorf("Wrong parsing of %q: %d %d", test.output, size, used)
+		}
+	}
+}
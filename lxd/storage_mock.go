@@ -75,6 +75,10 @@ func (s *storageMock) SetStoragePoolVolumeWritable(writable *api.StorageVolumePu
 	s.volume.StorageVolumePut = *writable
 }
 
+func (s *storageMock) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	return &api.ResourcesStoragePool{}, nil
+}
+
 func (s *storageMock) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
@@ -99,6 +103,14 @@ func (s *storageMock) StoragePoolVolumeUpdate(changedConfig []string) error {
 	return nil
 }
 
+func (s *storageMock) StoragePoolVolumeSnapshotCreate(target string) error {
+	return nil
+}
+
+func (s *storageMock) StoragePoolVolumeRename(newName string) error {
+	return nil
+}
+
 func (s *storageMock) StoragePoolUpdate(writable *api.StoragePoolPut, changedConfig []string) error {
 	return nil
 }
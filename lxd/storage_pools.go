@@ -132,12 +132,25 @@ func storagePoolPut(d *Daemon, r *http.Request) Response {
 		return BadRequest(err)
 	}
 
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	volatile, err := storagePoolConfigSplitVolatile(dbInfo.Config, req.Config)
+	if err != nil {
+		return BadRequest(err)
+	}
+
 	// Validate the configuration
 	err = storagePoolValidateConfig(poolName, req.Driver, req.Config)
 	if err != nil {
 		return BadRequest(err)
 	}
 
+	for k, v := range volatile {
+		req.Config[k] = v
+	}
+
 	err = storagePoolUpdate(d, poolName, req.Config)
 	if err != nil {
 		return InternalError(err)
@@ -182,12 +195,21 @@ func storagePoolPatch(d *Daemon, r *http.Request) Response {
 		}
 	}
 
+	volatile, err := storagePoolConfigSplitVolatile(dbInfo.Config, req.Config)
+	if err != nil {
+		return BadRequest(err)
+	}
+
 	// Validate the configuration
 	err = storagePoolValidateConfig(poolName, req.Driver, req.Config)
 	if err != nil {
 		return BadRequest(err)
 	}
 
+	for k, v := range volatile {
+		req.Config[k] = v
+	}
+
 	err = storagePoolUpdate(d, poolName, req.Config)
 	if err != nil {
 		return InternalError(fmt.Errorf("Failed to update the storage pool configuration."))
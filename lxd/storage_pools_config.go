@@ -37,6 +37,17 @@ var storagePoolConfigKeys = map[string]func(value string) error{
 	"lvm.vg_name":                 shared.IsAny,
 	"zfs.pool_name":               shared.IsAny,
 	"zfs.clone_copy":              shared.IsBool,
+	"ceph.cluster_name":           shared.IsAny,
+	"ceph.osd.pool_name":          shared.IsAny,
+	"ceph.user.name":              shared.IsAny,
+	"ceph.osd.pg_num": func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		_, err := strconv.ParseUint(value, 10, 32)
+		return err
+	},
 }
 
 func storagePoolValidateConfig(name string, driver string, config map[string]string) error {
@@ -82,6 +93,30 @@ func storagePoolValidateConfig(name string, driver string, config map[string]str
 			}
 		}
 
+		if driver != "ceph" {
+			if config["ceph.cluster_name"] != "" {
+				return fmt.Errorf("The key ceph.cluster_name cannot be used with non ceph storage pools.")
+			}
+
+			if config["ceph.osd.pg_num"] != "" {
+				return fmt.Errorf("The key ceph.osd.pg_num cannot be used with non ceph storage pools.")
+			}
+
+			if config["ceph.osd.pool_name"] != "" {
+				return fmt.Errorf("The key ceph.osd.pool_name cannot be used with non ceph storage pools.")
+			}
+
+			if config["ceph.user.name"] != "" {
+				return fmt.Errorf("The key ceph.user.name cannot be used with non ceph storage pools.")
+			}
+		}
+
+		if driver == "ceph" {
+			if config["size"] != "" {
+				return fmt.Errorf("The key size cannot be used with ceph storage pools.")
+			}
+		}
+
 		if driver == "dir" {
 			if config["size"] != "" {
 				return fmt.Errorf("The key size cannot be used with dir storage pools.")
@@ -104,8 +139,34 @@ func storagePoolValidateConfig(name string, driver string, config map[string]str
 	return nil
 }
 
+// storagePoolConfigSplitVolatile removes the volatile keys, which are only set
+// by LXD itself, from an updated storage pool configuration and returns the
+// current ones so they can be carried over. Changing them is refused.
+func storagePoolConfigSplitVolatile(current map[string]string, config map[string]string) (map[string]string, error) {
+	for key, val := range config {
+		if !strings.HasPrefix(key, "volatile.") {
+			continue
+		}
+
+		if current[key] != val {
+			return nil, fmt.Errorf("Volatile storage pool configuration keys can't be modified: %s", key)
+		}
+
+		delete(config, key)
+	}
+
+	volatile := map[string]string{}
+	for key, val := range current {
+		if strings.HasPrefix(key, "volatile.") {
+			volatile[key] = val
+		}
+	}
+
+	return volatile, nil
+}
+
 func storagePoolFillDefault(name string, driver string, config map[string]string) error {
-	if driver != "dir" {
+	if driver != "dir" && driver != "ceph" {
 		if config["size"] == "" {
 			st := syscall.Statfs_t{}
 			err := syscall.Statfs(shared.VarPath(), &st)
@@ -130,6 +191,27 @@ func storagePoolFillDefault(name string, driver string, config map[string]string
 		}
 	}
 
+	if driver == "ceph" {
+		if config["ceph.cluster_name"] == "" {
+			config["ceph.cluster_name"] = "ceph"
+		}
+
+		if config["ceph.osd.pg_num"] == "" {
+			config["ceph.osd.pg_num"] = "32"
+		}
+
+		if config["ceph.user.name"] == "" {
+			config["ceph.user.name"] = "admin"
+		}
+
+		if config["volume.size"] != "" {
+			_, err := shared.ParseByteSizeString(config["volume.size"])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if driver == "lvm" {
 		if config["lvm.thinpool_name"] == "" {
 			// Unchangeable pool property: Set unconditionally.
@@ -101,11 +101,12 @@ func storagePoolUsedByGet(db *sql.DB, poolID int64, poolName string) ([]string,
 		apiEndpoint, _ := storagePoolVolumeTypeNameToApiEndpoint(volumes[i].Type)
 		switch apiEndpoint {
 		case storagePoolVolumeApiEndpointContainers:
-			if strings.Index(volumes[i].Name, shared.SnapshotDelimiter) > 0 {
-				fields := strings.SplitN(volumes[i].Name, shared.SnapshotDelimiter, 2)
-				poolUsedBy[i] = fmt.Sprintf("/%s/containers/%s/snapshots/%s", version.APIVersion, fields[0], fields[1])
+			project, name := projectSplitName(volumes[i].Name)
+			if strings.Index(name, shared.SnapshotDelimiter) > 0 {
+				fields := strings.SplitN(name, shared.SnapshotDelimiter, 2)
+				poolUsedBy[i] = fmt.Sprintf("/%s/containers/%s/snapshots/%s%s", version.APIVersion, fields[0], fields[1], projectQuery(project))
 			} else {
-				poolUsedBy[i] = fmt.Sprintf("/%s/containers/%s", version.APIVersion, volumes[i].Name)
+				poolUsedBy[i] = fmt.Sprintf("/%s/containers/%s%s", version.APIVersion, name, projectQuery(project))
 			}
 		case storagePoolVolumeApiEndpointImages:
 			poolUsedBy[i] = fmt.Sprintf("/%s/images/%s", version.APIVersion, volumes[i].Name)
@@ -125,27 +126,36 @@ func storagePoolUsedByGet(db *sql.DB, poolID int64, poolName string) ([]string,
 	return poolUsedBy, err
 }
 
+// profilesUsingPoolGetNames returns the profiles referencing a storage pool.
+// Profiles of other projects than the default one carry their project query.
 func profilesUsingPoolGetNames(db *sql.DB, poolName string) ([]string, error) {
 	usedBy := []string{}
 
-	profiles, err := dbProfiles(db)
+	projects, err := dbProjects(db)
 	if err != nil {
 		return usedBy, err
 	}
 
-	for _, pName := range profiles {
-		_, profile, err := dbProfileGet(db, pName)
+	for _, project := range projects {
+		profiles, err := dbProfiles(db, project)
 		if err != nil {
 			return usedBy, err
 		}
 
-		for _, v := range profile.Devices {
-			if v["type"] != "disk" {
-				continue
+		for _, pName := range profiles {
+			_, profile, err := dbProfileGet(db, project, pName)
+			if err != nil {
+				return usedBy, err
 			}
 
-			if v["pool"] == poolName {
-				usedBy = append(usedBy, pName)
+			for _, v := range profile.Devices {
+				if v["type"] != "disk" {
+					continue
+				}
+
+				if v["pool"] == poolName {
+					usedBy = append(usedBy, pName+projectQuery(project))
+				}
 			}
 		}
 	}
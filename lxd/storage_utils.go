@@ -1,12 +1,24 @@
 package main
 
 import (
+	"fmt"
 	"strings"
+	"syscall"
 
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 )
 
 func storageValidName(value string) error {
+	if value == "" {
+		return fmt.Errorf("Invalid empty name")
+	}
+
+	// The snapshot delimiter separates custom volumes from their snapshots.
+	if strings.Contains(value, shared.SnapshotDelimiter) {
+		return fmt.Errorf("Invalid name \"%s\": names cannot contain \"%s\"", value, shared.SnapshotDelimiter)
+	}
+
 	return nil
 }
 
@@ -44,3 +56,23 @@ func storageConfigDiff(oldConfig map[string]string, newConfig map[string]string)
 
 	return changedConfig, userOnly
 }
+
+// storageResource returns the space and inode usage of the filesystem the
+// given path is on.
+func storageResource(path string) (*api.ResourcesStoragePool, error) {
+	st := syscall.Statfs_t{}
+	err := syscall.Statfs(path, &st)
+	if err != nil {
+		return nil, err
+	}
+
+	res := api.ResourcesStoragePool{}
+	res.Space.Total = st.Blocks * uint64(st.Bsize)
+	res.Space.Used = (st.Blocks - st.Bfree) * uint64(st.Bsize)
+
+	// Some filesystems (btrfs) don't have a fixed number of inodes
+	res.Inodes.Total = st.Files
+	res.Inodes.Used = st.Files - st.Ffree
+
+	return &res, nil
+}
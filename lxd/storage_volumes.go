@@ -1,12 +1,17 @@
 package main
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	log "gopkg.in/inconshreveable/log15.v2"
+
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/version"
@@ -38,7 +43,13 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) Response {
 	}
 
 	resultString := []string{}
+	resultMap := []*api.StorageVolume{}
 	for _, volume := range volumes {
+		// Snapshots of custom volumes are listed below their volume.
+		if volume.Type == storagePoolVolumeTypeNameCustom && shared.IsSnapshot(volume.Name) {
+			continue
+		}
+
 		apiEndpoint, err := storagePoolVolumeTypeNameToApiEndpoint(volume.Type)
 		if err != nil {
 			return InternalError(err)
@@ -52,6 +63,8 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) Response {
 				return InternalError(err)
 			}
 			volume.UsedBy = volumeUsedBy
+
+			resultMap = append(resultMap, volume)
 		}
 	}
 
@@ -59,7 +72,7 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) Response {
 		return SyncResponse(true, resultString)
 	}
 
-	return SyncResponse(true, volumes)
+	return SyncResponse(true, resultMap)
 }
 
 var storagePoolVolumesCmd = Command{name: "storage-pools/{name}/volumes", get: storagePoolVolumesGet}
@@ -107,6 +120,11 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) Response {
 	resultString := []string{}
 	resultMap := []*api.StorageVolume{}
 	for _, volume := range volumes {
+		// Snapshots of custom volumes are listed below their volume.
+		if volumeType == storagePoolVolumeTypeCustom && shared.IsSnapshot(volume) {
+			continue
+		}
+
 		if recursion == 0 {
 			apiEndpoint, err := storagePoolVolumeTypeToApiEndpoint(volumeType)
 			if err != nil {
@@ -139,7 +157,7 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) Response {
 // /1.0/storage-pools/{name}/volumes/{type}
 // Create a storage volume of a given volume type in a given storage pool.
 func storagePoolVolumesTypePost(d *Daemon, r *http.Request) Response {
-	req := api.StorageVolume{}
+	req := api.StorageVolumesPost{}
 
 	// Parse the request.
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -152,6 +170,11 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("No name provided"))
 	}
 
+	err = storageValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
 	// Check that the user gave use a storage volume type for the storage
 	// volume we are about to create.
 	if req.Type == "" {
@@ -162,6 +185,17 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) Response {
 	// volume is supposed to be created.
 	poolName := mux.Vars(r)["name"]
 
+	switch req.Source.Type {
+	case "":
+		break
+	case "copy":
+		return createStoragePoolVolumeFromCopy(d, poolName, &req)
+	case "migration":
+		return createStoragePoolVolumeFromMigration(d, poolName, &req)
+	default:
+		return BadRequest(fmt.Errorf("Unknown source type %s", req.Source.Type))
+	}
+
 	err = storagePoolVolumeCreateInternal(d, poolName, req.Name, req.Type, req.Config)
 	if err != nil {
 		return InternalError(err)
@@ -175,6 +209,127 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) Response {
 	return SyncResponseLocation(true, nil, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s", version.APIVersion, poolName, apiEndpoint))
 }
 
+func createStoragePoolVolumeFromCopy(d *Daemon, poolName string, req *api.StorageVolumesPost) Response {
+	if req.Type != storagePoolVolumeTypeNameCustom {
+		return BadRequest(fmt.Errorf("Only custom storage volumes can be copied"))
+	}
+
+	if req.Source.Name == "" {
+		return BadRequest(fmt.Errorf("must specify a source storage volume"))
+	}
+
+	sourcePoolName := req.Source.Pool
+	if sourcePoolName == "" {
+		sourcePoolName = poolName
+	}
+
+	sourcePoolID, err := dbStoragePoolGetID(d.db, sourcePoolName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, err = dbStoragePoolVolumeGetTypeID(d.db, req.Source.Name, storagePoolVolumeTypeCustom, sourcePoolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	run := func(op *operation) error {
+		return storagePoolVolumeCopyInternal(d, sourcePoolName, req.Source.Name, poolName, req.Name, req.Config, req.Source.VolumeOnly)
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{req.Name}
+
+	op, err := operationCreate(operationClassTask, resources, nil, run, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+func createStoragePoolVolumeFromMigration(d *Daemon, poolName string, req *api.StorageVolumesPost) Response {
+	// Validate migration mode
+	if req.Source.Mode != "pull" && req.Source.Mode != "push" {
+		return NotImplemented
+	}
+
+	if req.Type != storagePoolVolumeTypeNameCustom {
+		return BadRequest(fmt.Errorf("Only custom storage volumes can be migrated"))
+	}
+
+	var cert *x509.Certificate
+	if req.Source.Certificate != "" {
+		certBlock, _ := pem.Decode([]byte(req.Source.Certificate))
+		if certBlock == nil {
+			return InternalError(fmt.Errorf("Invalid certificate"))
+		}
+
+		var err error
+		cert, err = x509.ParseCertificate(certBlock.Bytes)
+		if err != nil {
+			return InternalError(err)
+		}
+	}
+
+	config, err := shared.GetTLSConfig("", "", "", cert)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	push := false
+	if req.Source.Mode == "push" {
+		push = true
+	}
+
+	migrationArgs := MigrationSinkArgs{
+		Url: req.Source.Operation,
+		Dialer: websocket.Dialer{
+			TLSClientConfig: config,
+			NetDial:         shared.RFC3493Dialer},
+		Secrets: req.Source.Websockets,
+		Push:    push,
+	}
+
+	err = storagePoolVolumeCreateInternal(d, poolName, req.Name, req.Type, req.Config)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	sink, err := NewStorageMigrationSink(d, poolName, req.Name, req.Config, &migrationArgs)
+	if err != nil {
+		storagePoolVolumeDeleteInternal(d, poolName, req.Name, storagePoolVolumeTypeCustom)
+		return InternalError(err)
+	}
+
+	run := func(op *operation) error {
+		err := sink.Do(op)
+		if err != nil {
+			shared.LogError("Error during migration sink", log.Ctx{"err": err})
+			storagePoolVolumeDeleteInternal(d, poolName, req.Name, storagePoolVolumeTypeCustom)
+			return fmt.Errorf("Error transferring storage volume: %s", err)
+		}
+
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{req.Name}
+
+	var op *operation
+	if push {
+		op, err = operationCreate(operationClassWebsocket, resources, sink.Metadata(), run, nil, sink.Connect)
+	} else {
+		op, err = operationCreate(operationClassTask, resources, nil, run, nil, nil)
+	}
+	if err != nil {
+		storagePoolVolumeDeleteInternal(d, poolName, req.Name, storagePoolVolumeTypeCustom)
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
 var storagePoolVolumesTypeCmd = Command{name: "storage-pools/{name}/volumes/{type}", get: storagePoolVolumesTypeGet, post: storagePoolVolumesTypePost}
 
 // /1.0/storage-pools/{pool}/volumes/{type}/{name}
@@ -388,27 +543,127 @@ func storagePoolVolumeTypeDelete(d *Daemon, r *http.Request) Response {
 		return BadRequest(fmt.Errorf("The storage volume is still in use by containers or profiles."))
 	}
 
-	s, err := storagePoolVolumeInit(d, poolName, volumeName, volumeType)
+	_, err = storagePoolVolumeInit(d, poolName, volumeName, volumeType)
 	if err != nil {
 		return NotFound
 	}
 
-	err = s.StoragePoolVolumeDelete()
+	err = storagePoolVolumeDeleteInternal(d, poolName, volumeName, volumeType)
 	if err != nil {
 		return InternalError(err)
 	}
 
+	return EmptySyncResponse
+}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}
+// Rename a storage volume, move it to another storage pool or get a migration
+// source for it.
+func storagePoolVolumeTypePost(d *Daemon, r *http.Request) Response {
+	volumeName := mux.Vars(r)["name"]
+	poolName := mux.Vars(r)["pool"]
+	volumeTypeName := mux.Vars(r)["type"]
+
+	// Only custom volumes can be renamed, moved or migrated.
+	if volumeTypeName != storagePoolVolumeTypeNameCustom {
+		return BadRequest(fmt.Errorf("Invalid storage volume type %s.", volumeTypeName))
+	}
+
+	// Snapshots are renamed through their own endpoint.
+	if shared.IsSnapshot(volumeName) {
+		return BadRequest(fmt.Errorf("Invalid storage volume name %s.", volumeName))
+	}
+
 	poolID, err := dbStoragePoolGetID(d.db, poolName)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	_, err = dbStoragePoolVolumeGetTypeID(d.db, volumeName, storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	req := api.StorageVolumePost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+
+	if req.Migration {
+		ws, err := NewStorageMigrationSource(d, poolName, volumeName, req.VolumeOnly)
+		if err != nil {
+			return InternalError(err)
+		}
+
+		op, err := operationCreate(operationClassWebsocket, resources, ws.Metadata(), ws.Do, nil, ws.Connect)
+		if err != nil {
+			return InternalError(err)
+		}
+
+		return OperationResponse(op)
+	}
+
+	if req.Name == "" {
+		req.Name = volumeName
+	}
+
+	if req.Pool == "" {
+		req.Pool = poolName
+	}
+
+	if req.Name == volumeName && req.Pool == poolName {
+		return BadRequest(fmt.Errorf("The storage volume is already called %s on storage pool %s", volumeName, poolName))
+	}
+
+	err = storageValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Check that the target name isn't already in use
+	targetPoolID, err := dbStoragePoolGetID(d.db, req.Pool)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	id, _ := dbStoragePoolVolumeGetTypeID(d.db, req.Name, storagePoolVolumeTypeCustom, targetPoolID)
+	if id > 0 {
+		return Conflict
+	}
+
+	volumeUsedBy, err := storagePoolVolumeUsedByGet(d, volumeName, volumeTypeName)
 	if err != nil {
 		return InternalError(err)
 	}
 
-	err = dbStoragePoolVolumeDelete(d.db, volumeName, volumeType, poolID)
+	if len(volumeUsedBy) > 0 {
+		return BadRequest(fmt.Errorf("The storage volume is still in use by containers or profiles."))
+	}
+
+	run := func(op *operation) error {
+		// Volumes are renamed in place within a storage pool
+		if req.Pool == poolName {
+			return storagePoolVolumeRenameInternal(d, poolName, volumeName, req.Name)
+		}
+
+		err := storagePoolVolumeCopyInternal(d, poolName, volumeName, req.Pool, req.Name, nil, false)
+		if err != nil {
+			return err
+		}
+
+		return storagePoolVolumeDeleteInternal(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+	}
+
+	op, err := operationCreate(operationClassTask, resources, nil, run, nil, nil)
 	if err != nil {
 		return InternalError(err)
 	}
 
-	return EmptySyncResponse
+	return OperationResponse(op)
 }
 
-var storagePoolVolumeTypeCmd = Command{name: "storage-pools/{pool}/volumes/{type}/{name:.*}", get: storagePoolVolumeTypeGet, put: storagePoolVolumeTypePut, patch: storagePoolVolumeTypePatch, delete: storagePoolVolumeTypeDelete}
+var storagePoolVolumeTypeCmd = Command{name: "storage-pools/{pool}/volumes/{type}/{name:.*}", get: storagePoolVolumeTypeGet, put: storagePoolVolumeTypePut, post: storagePoolVolumeTypePost, patch: storagePoolVolumeTypePatch, delete: storagePoolVolumeTypeDelete}
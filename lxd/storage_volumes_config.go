@@ -74,7 +74,7 @@ func storageVolumeValidateConfig(name string, config map[string]string, parentPo
 func storageVolumeFillDefault(name string, config map[string]string, parentPool *api.StoragePool) error {
 	if parentPool.Driver == "dir" {
 		config["size"] = ""
-	} else if parentPool.Driver == "lvm" {
+	} else if parentPool.Driver == "lvm" || parentPool.Driver == "ceph" {
 		if config["block.filesystem"] == "" {
 			config["block.filesystem"] = parentPool.Config["volume.block.filesystem"]
 		}
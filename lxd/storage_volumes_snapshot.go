@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// storagePoolVolumeSnapshotParent returns the ID of the pool holding the custom
+// volume a snapshot request is about, checking that the volume exists.
+func storagePoolVolumeSnapshotParent(d *Daemon, r *http.Request) (int64, Response) {
+	volumeTypeName := mux.Vars(r)["type"]
+	if volumeTypeName != storagePoolVolumeTypeNameCustom {
+		return -1, BadRequest(fmt.Errorf("Invalid storage volume type %s.", volumeTypeName))
+	}
+
+	poolID, err := dbStoragePoolGetID(d.db, mux.Vars(r)["pool"])
+	if err != nil {
+		return -1, SmartError(err)
+	}
+
+	_, err = dbStoragePoolVolumeGetTypeID(d.db, mux.Vars(r)["name"], storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return -1, SmartError(err)
+	}
+
+	return poolID, nil
+}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots
+func storagePoolVolumeSnapshotsTypeGet(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["pool"]
+	volumeName := mux.Vars(r)["name"]
+
+	poolID, resp := storagePoolVolumeSnapshotParent(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	recursion, err := strconv.Atoi(r.FormValue("recursion"))
+	if err != nil {
+		recursion = 0
+	}
+
+	snapshots, err := dbStoragePoolVolumeSnapshotsGetType(d.db, volumeName, storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []*api.StorageVolumeSnapshot{}
+	for _, snapshot := range snapshots {
+		snapshotName := shared.ExtractSnapshotName(snapshot)
+
+		if recursion == 0 {
+			url := fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s/snapshots/%s", version.APIVersion, poolName, storagePoolVolumeTypeNameCustom, volumeName, snapshotName)
+			resultString = append(resultString, url)
+		} else {
+			_, volume, err := dbStoragePoolVolumeGetType(d.db, snapshot, storagePoolVolumeTypeCustom, poolID)
+			if err != nil {
+				continue
+			}
+
+			resultMap = append(resultMap, &api.StorageVolumeSnapshot{Name: snapshotName, Config: volume.Config})
+		}
+	}
+
+	if recursion == 0 {
+		return SyncResponse(true, resultString)
+	}
+
+	return SyncResponse(true, resultMap)
+}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots
+func storagePoolVolumeSnapshotsTypePost(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["pool"]
+	volumeName := mux.Vars(r)["name"]
+
+	poolID, resp := storagePoolVolumeSnapshotParent(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	req := api.StorageVolumeSnapshotsPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Pick the first free name if none was provided.
+	if req.Name == "" {
+		for i := 0; ; i++ {
+			name := fmt.Sprintf("snap%d", i)
+			id, _ := dbStoragePoolVolumeGetTypeID(d.db, volumeName+shared.SnapshotDelimiter+name, storagePoolVolumeTypeCustom, poolID)
+			if id < 0 {
+				req.Name = name
+				break
+			}
+		}
+	}
+
+	err = storageValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	snapshot := volumeName + shared.SnapshotDelimiter + req.Name
+	id, _ := dbStoragePoolVolumeGetTypeID(d.db, snapshot, storagePoolVolumeTypeCustom, poolID)
+	if id > 0 {
+		return Conflict
+	}
+
+	_, volume, err := dbStoragePoolVolumeGetType(d.db, volumeName, storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	run := func(op *operation) error {
+		return storagePoolVolumeSnapshotInternal(d, poolName, volumeName, snapshot, volume.Config)
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+
+	op, err := operationCreate(operationClassTask, resources, nil, run, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+var storagePoolVolumeSnapshotsTypeCmd = Command{name: "storage-pools/{pool}/volumes/{type}/{name}/snapshots", get: storagePoolVolumeSnapshotsTypeGet, post: storagePoolVolumeSnapshotsTypePost}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots/{snapshotName}
+func storagePoolVolumeSnapshotTypeGet(d *Daemon, r *http.Request) Response {
+	volumeName := mux.Vars(r)["name"]
+	snapshotName := mux.Vars(r)["snapshotName"]
+
+	poolID, resp := storagePoolVolumeSnapshotParent(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	_, volume, err := dbStoragePoolVolumeGetType(d.db, volumeName+shared.SnapshotDelimiter+snapshotName, storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	snapshot := api.StorageVolumeSnapshot{Name: snapshotName, Config: volume.Config}
+	etag := []interface{}{snapshot.Name, snapshot.Config}
+
+	return SyncResponseETag(true, &snapshot, etag)
+}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots/{snapshotName}
+func storagePoolVolumeSnapshotTypePost(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["pool"]
+	volumeName := mux.Vars(r)["name"]
+	snapshotName := mux.Vars(r)["snapshotName"]
+
+	poolID, resp := storagePoolVolumeSnapshotParent(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	snapshot := volumeName + shared.SnapshotDelimiter + snapshotName
+	_, err := dbStoragePoolVolumeGetTypeID(d.db, snapshot, storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	req := api.StorageVolumeSnapshotPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	err = storageValidName(req.Name)
+	if err != nil {
+		return BadRequest(err)
+	}
+
+	// Check that the name isn't already in use
+	newSnapshot := volumeName + shared.SnapshotDelimiter + req.Name
+	id, _ := dbStoragePoolVolumeGetTypeID(d.db, newSnapshot, storagePoolVolumeTypeCustom, poolID)
+	if id > 0 {
+		return Conflict
+	}
+
+	run := func(op *operation) error {
+		return storagePoolVolumeRenameInternal(d, poolName, snapshot, newSnapshot)
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+
+	op, err := operationCreate(operationClassTask, resources, nil, run, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+// /1.0/storage-pools/{pool}/volumes/{type}/{name}/snapshots/{snapshotName}
+func storagePoolVolumeSnapshotTypeDelete(d *Daemon, r *http.Request) Response {
+	poolName := mux.Vars(r)["pool"]
+	volumeName := mux.Vars(r)["name"]
+	snapshotName := mux.Vars(r)["snapshotName"]
+
+	poolID, resp := storagePoolVolumeSnapshotParent(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	snapshot := volumeName + shared.SnapshotDelimiter + snapshotName
+	_, err := dbStoragePoolVolumeGetTypeID(d.db, snapshot, storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return SmartError(err)
+	}
+
+	run := func(op *operation) error {
+		return storagePoolVolumeDeleteInternal(d, poolName, snapshot, storagePoolVolumeTypeCustom)
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+
+	op, err := operationCreate(operationClassTask, resources, nil, run, nil, nil)
+	if err != nil {
+		return InternalError(err)
+	}
+
+	return OperationResponse(op)
+}
+
+var storagePoolVolumeSnapshotTypeCmd = Command{name: "storage-pools/{pool}/volumes/{type}/{name}/snapshots/{snapshotName}", get: storagePoolVolumeSnapshotTypeGet, post: storagePoolVolumeSnapshotTypePost, delete: storagePoolVolumeSnapshotTypeDelete}
@@ -3,6 +3,7 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -201,7 +202,8 @@ func storagePoolVolumeUsedByGet(d *Daemon, volumeName string, volumeTypeName str
 			// "container////bla" but only against "container/bla".
 			cleanSource := filepath.Clean(d["source"])
 			if cleanSource == volumeName || cleanSource == volumeNameWithType {
-				volumeUsedBy = append(volumeUsedBy, fmt.Sprintf("/%s/containers/%s", version.APIVersion, ct))
+				project, name := projectSplitName(ct)
+				volumeUsedBy = append(volumeUsedBy, fmt.Sprintf("/%s/containers/%s%s", version.APIVersion, name, projectQuery(project)))
 			}
 		}
 	}
@@ -222,37 +224,47 @@ func storagePoolVolumeUsedByGet(d *Daemon, volumeName string, volumeTypeName str
 	return volumeUsedBy, nil
 }
 
+// profilesUsingPoolVolumeGetNames returns the profiles referencing a storage
+// volume. Profiles of other projects than the default one carry their project
+// query.
 func profilesUsingPoolVolumeGetNames(db *sql.DB, volumeName string, volumeType string) ([]string, error) {
 	usedBy := []string{}
 
-	profiles, err := dbProfiles(db)
+	projects, err := dbProjects(db)
 	if err != nil {
 		return usedBy, err
 	}
 
-	for _, pName := range profiles {
-		_, profile, err := dbProfileGet(db, pName)
+	volumeNameWithType := fmt.Sprintf("%s/%s", volumeType, volumeName)
+	for _, project := range projects {
+		profiles, err := dbProfiles(db, project)
 		if err != nil {
 			return usedBy, err
 		}
 
-		volumeNameWithType := fmt.Sprintf("%s/%s", volumeType, volumeName)
-		for _, v := range profile.Devices {
-			if v["type"] != "disk" {
-				continue
+		for _, pName := range profiles {
+			_, profile, err := dbProfileGet(db, project, pName)
+			if err != nil {
+				return usedBy, err
 			}
 
-			// Can't be a storage volume.
-			if filepath.IsAbs(v["source"]) {
-				continue
-			}
-
-			// Make sure that we don't compare against stuff
-			// like "container////bla" but only against
-			// "container/bla".
-			cleanSource := filepath.Clean(v["source"])
-			if cleanSource == volumeName || cleanSource == volumeNameWithType {
-				usedBy = append(usedBy, pName)
+			for _, v := range profile.Devices {
+				if v["type"] != "disk" {
+					continue
+				}
+
+				// Can't be a storage volume.
+				if filepath.IsAbs(v["source"]) {
+					continue
+				}
+
+				// Make sure that we don't compare against stuff
+				// like "container////bla" but only against
+				// "container/bla".
+				cleanSource := filepath.Clean(v["source"])
+				if cleanSource == volumeName || cleanSource == volumeNameWithType {
+					usedBy = append(usedBy, pName+projectQuery(project))
+				}
 			}
 		}
 	}
@@ -262,10 +274,13 @@ func profilesUsingPoolVolumeGetNames(db *sql.DB, volumeName string, volumeType s
 
 func storagePoolVolumeDBCreate(d *Daemon, poolName string, volumeName string, volumeTypeName string, volumeConfig map[string]string) error {
 	// Check that the name of the new storage volume is valid. (For example.
-	// zfs pools cannot contain "/" in their names.)
-	err := storageValidName(volumeName)
-	if err != nil {
-		return err
+	// zfs pools cannot contain "/" in their names.) Snapshots of custom
+	// volumes are named "<volume>/<snapshot>".
+	for _, name := range strings.SplitN(volumeName, shared.SnapshotDelimiter, 2) {
+		err := storageValidName(name)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Convert the volume type name to our internal integer representation.
@@ -347,3 +362,210 @@ func storagePoolVolumeCreateInternal(d *Daemon, poolName string, volumeName stri
 
 	return nil
 }
+
+// storagePoolVolumeDeleteInternal deletes a storage volume, snapshots of
+// custom volumes are deleted along with them.
+func storagePoolVolumeDeleteInternal(d *Daemon, poolName string, volumeName string, volumeType int) error {
+	poolID, err := dbStoragePoolGetID(d.db, poolName)
+	if err != nil {
+		return err
+	}
+
+	if volumeType == storagePoolVolumeTypeCustom && !shared.IsSnapshot(volumeName) {
+		snapshots, err := dbStoragePoolVolumeSnapshotsGetType(d.db, volumeName, volumeType, poolID)
+		if err != nil {
+			return err
+		}
+
+		for _, snapshot := range snapshots {
+			err := storagePoolVolumeDeleteInternal(d, poolName, snapshot, volumeType)
+			if err != nil {
+				return err
+			}
+		}
+
+		snapshotsPath := shared.VarPath("storage-pools", poolName, "custom-snapshots", volumeName)
+		if shared.PathExists(snapshotsPath) {
+			os.Remove(snapshotsPath)
+		}
+	}
+
+	s, err := storagePoolVolumeInit(d, poolName, volumeName, volumeType)
+	if err != nil {
+		return err
+	}
+
+	err = s.StoragePoolVolumeDelete()
+	if err != nil {
+		return err
+	}
+
+	return dbStoragePoolVolumeDelete(d.db, volumeName, volumeType, poolID)
+}
+
+// storagePoolVolumeRsync copies the content of a custom volume (or snapshot)
+// over the content of another one.
+func storagePoolVolumeRsync(d *Daemon, sourcePoolName string, sourceVolumeName string, poolName string, volumeName string) error {
+	source, err := storagePoolVolumeInit(d, sourcePoolName, sourceVolumeName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	ourMount, err := source.StoragePoolVolumeMount()
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer source.StoragePoolVolumeUmount()
+	}
+
+	target, err := storagePoolVolumeInit(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	ourMount, err = target.StoragePoolVolumeMount()
+	if err != nil {
+		return err
+	}
+	if ourMount {
+		defer target.StoragePoolVolumeUmount()
+	}
+
+	sourcePath := getStoragePoolVolumeMountPoint(sourcePoolName, sourceVolumeName)
+	targetPath := getStoragePoolVolumeMountPoint(poolName, volumeName)
+	output, err := storageRsyncCopy(sourcePath, targetPath)
+	if err != nil {
+		return fmt.Errorf("Failed to rsync: %s: %s", output, err)
+	}
+
+	return nil
+}
+
+// storagePoolVolumeSnapshotInternal creates a snapshot of a custom volume
+// using the native snapshots of the storage driver.
+func storagePoolVolumeSnapshotInternal(d *Daemon, poolName string, volumeName string, snapshot string, volumeConfig map[string]string) error {
+	err := storagePoolVolumeDBCreate(d, poolName, snapshot, storagePoolVolumeTypeNameCustom, volumeConfig)
+	if err != nil {
+		return err
+	}
+
+	s, err := storagePoolVolumeInit(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+	if err == nil {
+		err = s.StoragePoolVolumeSnapshotCreate(snapshot)
+	}
+
+	if err != nil {
+		poolID, _ := dbStoragePoolGetID(d.db, poolName)
+		dbStoragePoolVolumeDelete(d.db, snapshot, storagePoolVolumeTypeCustom, poolID)
+		return err
+	}
+
+	return nil
+}
+
+// storagePoolVolumeRenameInternal renames a custom volume, along with its
+// snapshots, or a snapshot within its storage pool.
+func storagePoolVolumeRenameInternal(d *Daemon, poolName string, volumeName string, newName string) error {
+	poolID, err := dbStoragePoolGetID(d.db, poolName)
+	if err != nil {
+		return err
+	}
+
+	snapshots := []string{}
+	if !shared.IsSnapshot(volumeName) {
+		snapshots, err = dbStoragePoolVolumeSnapshotsGetType(d.db, volumeName, storagePoolVolumeTypeCustom, poolID)
+		if err != nil {
+			return err
+		}
+	}
+
+	s, err := storagePoolVolumeInit(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+	if err != nil {
+		return err
+	}
+
+	err = s.StoragePoolVolumeRename(newName)
+	if err != nil {
+		return err
+	}
+
+	err = dbStoragePoolVolumeRename(d.db, volumeName, newName, storagePoolVolumeTypeCustom, poolID)
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range snapshots {
+		fields := strings.SplitN(snapshot, shared.SnapshotDelimiter, 2)
+		err := storagePoolVolumeRenameInternal(d, poolName, snapshot, newName+shared.SnapshotDelimiter+fields[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storagePoolVolumeCopyInternal copies a custom volume, along with its
+// snapshots unless volumeOnly is set, to a new custom volume which can be on
+// another storage pool. The content is copied with rsync, which works between
+// storage pools of any driver, and the snapshots of the copy are native
+// snapshots taken as the content of each source snapshot was copied over.
+func storagePoolVolumeCopyInternal(d *Daemon, sourcePoolName string, sourceVolumeName string, poolName string, volumeName string, volumeConfig map[string]string, volumeOnly bool) error {
+	sourcePoolID, sourcePool, err := dbStoragePoolGet(d.db, sourcePoolName)
+	if err != nil {
+		return err
+	}
+
+	_, pool, err := dbStoragePoolGet(d.db, poolName)
+	if err != nil {
+		return err
+	}
+
+	_, sourceVolume, err := dbStoragePoolVolumeGetType(d.db, sourceVolumeName, storagePoolVolumeTypeCustom, sourcePoolID)
+	if err != nil {
+		return err
+	}
+
+	// The configuration of the source volume only applies to pools using
+	// the same driver.
+	if len(volumeConfig) == 0 && sourcePool.Driver == pool.Driver {
+		volumeConfig = sourceVolume.Config
+	}
+
+	snapshots := []string{}
+	if !volumeOnly {
+		snapshots, err = dbStoragePoolVolumeSnapshotsGetType(d.db, sourceVolumeName, storagePoolVolumeTypeCustom, sourcePoolID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = storagePoolVolumeCreateInternal(d, poolName, volumeName, storagePoolVolumeTypeNameCustom, volumeConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range snapshots {
+		fields := strings.SplitN(snapshot, shared.SnapshotDelimiter, 2)
+		snapshotName := fmt.Sprintf("%s%s%s", volumeName, shared.SnapshotDelimiter, fields[1])
+
+		err := storagePoolVolumeRsync(d, sourcePoolName, snapshot, poolName, volumeName)
+		if err == nil {
+			err = storagePoolVolumeSnapshotInternal(d, poolName, volumeName, snapshotName, volumeConfig)
+		}
+
+		if err != nil {
+			storagePoolVolumeDeleteInternal(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+			return err
+		}
+	}
+
+	err = storagePoolVolumeRsync(d, sourcePoolName, sourceVolumeName, poolName, volumeName)
+	if err != nil {
+		storagePoolVolumeDeleteInternal(d, poolName, volumeName, storagePoolVolumeTypeCustom)
+		return err
+	}
+
+	return nil
+}
@@ -36,6 +36,16 @@ func (s *storageZfs) getOnDiskPoolName() string {
 	return s.pool.Name
 }
 
+// getCustomVolumeDataset returns the dataset of the custom volume. Snapshots
+// of custom volumes are datasets of their own below "custom-snapshots".
+func (s *storageZfs) getCustomVolumeDataset() string {
+	if shared.IsSnapshot(s.volume.Name) {
+		return fmt.Sprintf("custom-snapshots/%s", s.volume.Name)
+	}
+
+	return fmt.Sprintf("custom/%s", s.volume.Name)
+}
+
 func zfsIsEnabled() bool {
 	out, err := exec.LookPath("zfs")
 	if err != nil || len(out) == 0 {
@@ -186,7 +196,7 @@ func (s *storageZfs) StoragePoolUmount() (bool, error) {
 func (s *storageZfs) StoragePoolVolumeCreate() error {
 	shared.LogInfof("Creating ZFS storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 
-	fs := fmt.Sprintf("custom/%s", s.volume.Name)
+	fs := s.getCustomVolumeDataset()
 	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
 
 	err := s.zfsPoolVolumeCreate(fs)
@@ -219,14 +229,28 @@ func (s *storageZfs) StoragePoolVolumeCreate() error {
 func (s *storageZfs) StoragePoolVolumeDelete() error {
 	shared.LogInfof("Deleting ZFS storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 
-	fs := fmt.Sprintf("custom/%s", s.volume.Name)
+	fs := s.getCustomVolumeDataset()
 	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
 
+	// Snapshots are clones of a snapshot of their volume
+	origin := ""
+	if shared.IsSnapshot(s.volume.Name) {
+		origin, _ = s.zfsFilesystemEntityPropertyGet(fs, "origin", true)
+	}
+
 	err := s.zfsPoolVolumeDestroy(fs)
 	if err != nil {
 		return err
 	}
 
+	if strings.Contains(origin, "@") {
+		fields := strings.SplitN(strings.TrimPrefix(origin, s.getOnDiskPoolName()+"/"), "@", 2)
+		err := s.zfsPoolVolumeSnapshotDestroy(fields[0], fields[1])
+		if err != nil {
+			return err
+		}
+	}
+
 	if shared.PathExists(customPoolVolumeMntPoint) {
 		err := os.RemoveAll(customPoolVolumeMntPoint)
 		if err != nil {
@@ -234,14 +258,116 @@ func (s *storageZfs) StoragePoolVolumeDelete() error {
 		}
 	}
 
+	if shared.IsSnapshot(s.volume.Name) {
+		s.zfsCustomSnapshotsCleanup(s.volume.Name)
+	}
+
 	shared.LogInfof("Deleted ZFS storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 	return nil
 }
 
+func (s *storageZfs) StoragePoolVolumeSnapshotCreate(target string) error {
+	shared.LogInfof("Creating ZFS storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+
+	fs := s.getCustomVolumeDataset()
+	fields := strings.SplitN(target, shared.SnapshotDelimiter, 2)
+	snapshotName := fmt.Sprintf("snapshot-%s", fields[1])
+	targetFs := fmt.Sprintf("custom-snapshots/%s", target)
+	targetMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, target)
+
+	err := s.zfsPoolVolumeSnapshotCreate(fs, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	err = s.zfsPoolVolumeClone(fs, snapshotName, targetFs, targetMntPoint)
+	if err != nil {
+		s.zfsPoolVolumeSnapshotDestroy(fs, snapshotName)
+		return err
+	}
+
+	if !shared.IsMountPoint(targetMntPoint) {
+		s.zfsPoolVolumeMount(targetFs)
+	}
+
+	shared.LogInfof("Created ZFS storage volume snapshot \"%s\" on storage pool \"%s\".", target, s.pool.Name)
+	return nil
+}
+
+func (s *storageZfs) StoragePoolVolumeRename(newName string) error {
+	shared.LogInfof("Renaming ZFS storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+
+	oldFs := s.getCustomVolumeDataset()
+	newFs := fmt.Sprintf("custom/%s", newName)
+	if shared.IsSnapshot(newName) {
+		newFs = fmt.Sprintf("custom-snapshots/%s", newName)
+	}
+	oldMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
+	newMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, newName)
+
+	err := s.zfsPoolVolumeRename(oldFs, newFs)
+	if err != nil {
+		return err
+	}
+
+	err = s.zfsPoolVolumeSet(newFs, "mountpoint", newMntPoint)
+	if err != nil {
+		return err
+	}
+
+	if shared.PathExists(oldMntPoint) {
+		os.Remove(oldMntPoint)
+	}
+
+	if !shared.IsSnapshot(s.volume.Name) {
+		shared.LogInfof("Renamed ZFS storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+		return nil
+	}
+
+	// Keep the snapshot the clone comes from named after it
+	origin, err := s.zfsFilesystemEntityPropertyGet(newFs, "origin", true)
+	if err == nil && strings.Contains(origin, "@") {
+		fields := strings.SplitN(strings.TrimPrefix(origin, s.getOnDiskPoolName()+"/"), "@", 2)
+		newSnapshotName := fmt.Sprintf("snapshot-%s", strings.SplitN(newName, shared.SnapshotDelimiter, 2)[1])
+		if fields[1] != newSnapshotName {
+			err = s.zfsPoolVolumeSnapshotRename(fields[0], fields[1], newSnapshotName)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	s.zfsCustomSnapshotsCleanup(s.volume.Name)
+
+	shared.LogInfof("Renamed ZFS storage volume \"%s\" to \"%s\" on storage pool \"%s\".", s.volume.Name, newName, s.pool.Name)
+	return nil
+}
+
+// zfsCustomSnapshotsCleanup removes the dataset holding the snapshots of a
+// custom volume once the given snapshot was its last one.
+func (s *storageZfs) zfsCustomSnapshotsCleanup(snapshotName string) {
+	fields := strings.SplitN(snapshotName, shared.SnapshotDelimiter, 2)
+	fs := fmt.Sprintf("custom-snapshots/%s", fields[0])
+	if s.zfsFilesystemEntityExists(fs, true) {
+		children, err := s.zfsPoolListSubvolumes(fmt.Sprintf("%s/%s", s.getOnDiskPoolName(), fs))
+		if err != nil || len(children) > 0 {
+			return
+		}
+
+		err = s.zfsPoolVolumeDestroy(fs)
+		if err != nil {
+			shared.LogWarnf("Failed to remove ZFS dataset \"%s\": %s.", fs, err)
+			return
+		}
+	}
+
+	storagePoolVolumeSnapshotsDirCleanup(s.pool.Name, snapshotName)
+}
+
 func (s *storageZfs) StoragePoolVolumeMount() (bool, error) {
 	shared.LogDebugf("Mounting ZFS storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 
-	fs := fmt.Sprintf("custom/%s", s.volume.Name)
+	fs := s.getCustomVolumeDataset()
 	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
 
 	customMountLockID := getCustomMountLockID(s.pool.Name, s.volume.Name)
@@ -284,7 +410,7 @@ func (s *storageZfs) StoragePoolVolumeMount() (bool, error) {
 func (s *storageZfs) StoragePoolVolumeUmount() (bool, error) {
 	shared.LogDebugf("Unmounting ZFS storage volume \"%s\" on storage pool \"%s\".", s.volume.Name, s.pool.Name)
 
-	fs := fmt.Sprintf("custom/%s", s.volume.Name)
+	fs := s.getCustomVolumeDataset()
 	customPoolVolumeMntPoint := getStoragePoolVolumeMountPoint(s.pool.Name, s.volume.Name)
 
 	customUmountLockID := getCustomUmountLockID(s.pool.Name, s.volume.Name)
@@ -340,6 +466,37 @@ func (s *storageZfs) SetStoragePoolVolumeWritable(writable *api.StorageVolumePut
 	s.volume.StorageVolumePut = *writable
 }
 
+func (s *storageZfs) StoragePoolResources() (*api.ResourcesStoragePool, error) {
+	poolName := s.getOnDiskPoolName()
+
+	output, err := shared.RunCommand("zfs", "get", "-Hp", "-o", "value", "used,available", poolName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the usage of ZFS pool \"%s\": %s", poolName, output)
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("Unexpected output from zfs: %s", output)
+	}
+
+	used, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	// ZFS doesn't have a fixed number of inodes
+	res := api.ResourcesStoragePool{}
+	res.Space.Used = used
+	res.Space.Total = used + available
+
+	return &res, nil
+}
+
 func (s *storageZfs) GetContainerPoolInfo() (int64, string) {
 	return s.poolID, s.pool.Name
 }
@@ -80,7 +80,14 @@ func (old Devices) Update(newlist Devices) (map[string]Device, map[string]Device
 			continue
 		}
 
-		for _, k := range []string{"limits.max", "limits.read", "limits.write", "limits.egress", "limits.ingress", "ipv4.address", "ipv6.address"} {
+		keys := []string{"limits.max", "limits.read", "limits.write", "limits.egress", "limits.ingress"}
+
+		// The addresses of routed and ipvlan nics are set when plugging them
+		if !shared.StringInSlice(newDevice["nictype"], []string{"routed", "ipvlan"}) {
+			keys = append(keys, "ipv4.address", "ipv6.address")
+		}
+
+		for _, k := range keys {
 			delete(oldDevice, k)
 			delete(newDevice, k)
 		}
@@ -20,3 +20,25 @@ func TestSortableDevices(t *testing.T) {
 		t.Error("devices sorted incorrectly")
 	}
 }
+
+func TestDevicesUpdate(t *testing.T) {
+	old := Devices{
+		"eth0": Device{"type": "nic", "nictype": "bridged", "parent": "lxdbr0", "ipv4.address": "10.0.0.2"},
+		"eth1": Device{"type": "nic", "nictype": "routed", "ipv4.address": "192.0.2.2"},
+	}
+
+	newlist := Devices{
+		"eth0": Device{"type": "nic", "nictype": "bridged", "parent": "lxdbr0", "ipv4.address": "10.0.0.3"},
+		"eth1": Device{"type": "nic", "nictype": "routed", "ipv4.address": "192.0.2.3"},
+	}
+
+	rmlist, addlist, updatelist := old.Update(newlist)
+
+	if len(updatelist) != 1 || updatelist["eth0"] == nil {
+		t.Errorf("Bridged nic should be updated in place: %v", updatelist)
+	}
+
+	if rmlist["eth1"] == nil || addlist["eth1"] == nil {
+		t.Errorf("Routed nic should be re-added")
+	}
+}
@@ -14,6 +14,12 @@ type CertificatesPost struct {
 type CertificatePut struct {
 	Name string `json:"name" yaml:"name"`
 	Type string `json:"type" yaml:"type"`
+
+	// API extension: certificate_permissions
+	Restricted bool     `json:"restricted" yaml:"restricted"`
+	Permission string   `json:"permission" yaml:"permission"`
+	Containers []string `json:"containers" yaml:"containers"`
+	Projects   []string `json:"projects" yaml:"projects"`
 }
 
 // Certificate represents a LXD certificate
@@ -0,0 +1,40 @@
+package api
+
+// Cluster represents high-level information about a LXD cluster
+//
+// API extension: clustering
+type Cluster struct {
+	ServerName string `json:"server_name" yaml:"server_name"`
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+}
+
+// ClusterPut represents the fields required to bootstrap or join a LXD cluster
+//
+// API extension: clustering
+type ClusterPut struct {
+	Cluster `yaml:",inline"`
+
+	ClusterAddress     string `json:"cluster_address" yaml:"cluster_address"`
+	ClusterCertificate string `json:"cluster_certificate" yaml:"cluster_certificate"`
+	ClusterPassword    string `json:"cluster_password" yaml:"cluster_password"`
+}
+
+// ClusterMembersPost represents the fields required to add a new member to a LXD cluster
+//
+// API extension: clustering
+type ClusterMembersPost struct {
+	ServerName        string `json:"server_name" yaml:"server_name"`
+	ServerAddress     string `json:"server_address" yaml:"server_address"`
+	ServerCertificate string `json:"server_certificate" yaml:"server_certificate"`
+	Password          string `json:"password" yaml:"password"`
+}
+
+// ClusterMember represents a LXD node in the cluster
+//
+// API extension: clustering
+type ClusterMember struct {
+	ServerName string `json:"server_name" yaml:"server_name"`
+	URL        string `json:"url" yaml:"url"`
+	Status     string `json:"status" yaml:"status"`
+	Message    string `json:"message" yaml:"message"`
+}
@@ -51,6 +51,9 @@ type Container struct {
 
 	// API extension: container_last_used_at
 	LastUsedAt time.Time `json:"last_used_at" yaml:"last_used_at"`
+
+	// API extension: clustering
+	Location string `json:"location" yaml:"location"`
 }
 
 // Writable converts a full Container struct into a ContainerPut struct (filters read-only fields)
@@ -83,6 +86,9 @@ type ContainerSource struct {
 	Secret      string            `json:"secret,omitempty" yaml:"secret,omitempty"`
 	Protocol    string            `json:"protocol,omitempty" yaml:"protocol,omitempty"`
 
+	// API extension: image_signatures
+	SigningKeys string `json:"signing_keys,omitempty" yaml:"signing_keys,omitempty"`
+
 	// For "migration" and "copy" types
 	BaseImage string `json:"base-image,omitempty" yaml:"base-image,omitempty"`
 
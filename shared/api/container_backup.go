@@ -0,0 +1,26 @@
+package api
+
+import (
+	"time"
+)
+
+// ContainerBackupsPost represents the fields available for a new LXD container backup
+type ContainerBackupsPost struct {
+	Name          string    `json:"name" yaml:"name"`
+	ExpiryDate    time.Time `json:"expiry_date" yaml:"expiry_date"`
+	ContainerOnly bool      `json:"container_only" yaml:"container_only"`
+}
+
+// ContainerBackup represents a LXD container backup
+type ContainerBackup struct {
+	Name          string    `json:"name" yaml:"name"`
+	CreationDate  time.Time `json:"creation_date" yaml:"creation_date"`
+	ExpiryDate    time.Time `json:"expiry_date" yaml:"expiry_date"`
+	ContainerOnly bool      `json:"container_only" yaml:"container_only"`
+}
+
+// ContainerBackupPost represents the fields available for the renaming of a
+// container backup
+type ContainerBackupPost struct {
+	Name string `json:"name" yaml:"name"`
+}
@@ -8,6 +8,9 @@ import (
 type ContainerSnapshotsPost struct {
 	Name     string `json:"name" yaml:"name"`
 	Stateful bool   `json:"stateful" yaml:"stateful"`
+
+	// API extension: snapshot_scheduling
+	ExpiresAt *time.Time `json:"expires_at" yaml:"expires_at"`
 }
 
 // ContainerSnapshotPost represents the fields required to rename/move a LXD container snapshot
@@ -29,4 +32,7 @@ type ContainerSnapshot struct {
 	Name            string                       `json:"name" yaml:"name"`
 	Profiles        []string                     `json:"profiles" yaml:"profiles"`
 	Stateful        bool                         `json:"stateful" yaml:"stateful"`
+
+	// API extension: snapshot_scheduling
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
 }
@@ -51,6 +51,18 @@ type ContainerStateNetwork struct {
 	Mtu       int                            `json:"mtu" yaml:"mtu"`
 	State     string                         `json:"state" yaml:"state"`
 	Type      string                         `json:"type" yaml:"type"`
+
+	// API extension: network_limits
+	Limits *ContainerStateNetworkLimits `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// ContainerStateNetworkLimits represents the traffic shaping installed on the
+// host side of a LXD container's network interface
+//
+// API extension: network_limits
+type ContainerStateNetworkLimits struct {
+	Ingress string `json:"ingress" yaml:"ingress"`
+	Egress  string `json:"egress" yaml:"egress"`
 }
 
 // ContainerStateNetworkAddress represents a network address as part of the network section of a LXD container's state
@@ -59,6 +59,9 @@ type Image struct {
 	ExpiresAt  time.Time `json:"expires_at" yaml:"expires_at"`
 	LastUsedAt time.Time `json:"last_used_at" yaml:"last_used_at"`
 	UploadedAt time.Time `json:"uploaded_at" yaml:"uploaded_at"`
+
+	// API extension: image_signatures
+	Signature ImageSignature `json:"signature" yaml:"signature"`
 }
 
 // Writable converts a full Image struct into a ImagePut struct (filters read-only fields)
@@ -66,6 +69,40 @@ func (img *Image) Writable() ImagePut {
 	return img.ImagePut
 }
 
+// ImageSignature represents the signature status of a LXD image
+//
+// API extension: image_signatures
+type ImageSignature struct {
+	// Either "verified" or "unverified"
+	Status string `json:"status" yaml:"status"`
+
+	// Type ("gpg" or "minisign") and ID of the key the image was verified with
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	Key  string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+// ImageMetadata represents the metadata.yaml of a LXD image or container
+//
+// API extension: container_edit_metadata
+type ImageMetadata struct {
+	Architecture string                            `json:"architecture" yaml:"architecture"`
+	CreationDate int64                             `json:"creation_date" yaml:"creation_date"`
+	ExpiryDate   int64                             `json:"expiry_date" yaml:"expiry_date"`
+	Properties   map[string]string                 `json:"properties" yaml:"properties"`
+	Templates    map[string]*ImageMetadataTemplate `json:"templates" yaml:"templates"`
+}
+
+// ImageMetadataTemplate represents a template entry of the image metadata,
+// keyed by the path of the file it generates in the container
+//
+// API extension: container_edit_metadata
+type ImageMetadataTemplate struct {
+	When       []string          `json:"when" yaml:"when"`
+	CreateOnly bool              `json:"create_only" yaml:"create_only"`
+	Template   string            `json:"template" yaml:"template"`
+	Properties map[string]string `json:"properties" yaml:"properties"`
+}
+
 // ImageAlias represents an alias from the alias list of a LXD image
 type ImageAlias struct {
 	Name        string `json:"name" yaml:"name"`
@@ -78,6 +115,9 @@ type ImageSource struct {
 	Certificate string `json:"certificate" yaml:"certificate"`
 	Protocol    string `json:"protocol" yaml:"protocol"`
 	Server      string `json:"server" yaml:"server"`
+
+	// API extension: image_signatures
+	SigningKeys string `json:"signing_keys,omitempty" yaml:"signing_keys,omitempty"`
 }
 
 // ImageAliasesPost represents a new LXD image alias
@@ -0,0 +1,66 @@
+package api
+
+// NetworkACLsPost represents the fields of a new LXD network ACL
+//
+// API extension: network_acl
+type NetworkACLsPost struct {
+	NetworkACLPut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"`
+}
+
+// NetworkACLPost represents the fields required to rename a LXD network ACL
+//
+// API extension: network_acl
+type NetworkACLPost struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// NetworkACLPut represents the modifiable fields of a LXD network ACL
+//
+// API extension: network_acl
+type NetworkACLPut struct {
+	Description string `json:"description" yaml:"description"`
+
+	// Rules applied to the traffic going to the network or nic, in order
+	Ingress []NetworkACLRule `json:"ingress" yaml:"ingress"`
+
+	// Rules applied to the traffic coming from the network or nic, in order
+	Egress []NetworkACLRule `json:"egress" yaml:"egress"`
+}
+
+// NetworkACLRule represents a single rule of a LXD network ACL
+//
+// API extension: network_acl
+type NetworkACLRule struct {
+	// One of "allow", "drop" or "reject"
+	Action string `json:"action" yaml:"action"`
+
+	// Comma separated lists of addresses or subnets
+	Source      string `json:"source,omitempty" yaml:"source,omitempty"`
+	Destination string `json:"destination,omitempty" yaml:"destination,omitempty"`
+
+	// One of "tcp", "udp", "icmp4" or "icmp6", empty for any
+	Protocol string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+
+	// Comma separated lists of ports or port ranges (tcp and udp only)
+	SourcePort      string `json:"source_port,omitempty" yaml:"source_port,omitempty"`
+	DestinationPort string `json:"destination_port,omitempty" yaml:"destination_port,omitempty"`
+
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// NetworkACL represents a LXD network ACL
+//
+// API extension: network_acl
+type NetworkACL struct {
+	NetworkACLPut `yaml:",inline"`
+
+	Name   string   `json:"name" yaml:"name"`
+	UsedBy []string `json:"used_by" yaml:"used_by"`
+}
+
+// Writable converts a full NetworkACL struct into a NetworkACLPut struct (filters read-only fields)
+func (acl *NetworkACL) Writable() NetworkACLPut {
+	return acl.NetworkACLPut
+}
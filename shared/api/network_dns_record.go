@@ -0,0 +1,34 @@
+package api
+
+// NetworkDNSRecordsPost represents the fields of a new DNS record
+//
+// API extension: network_dns_records
+type NetworkDNSRecordsPost struct {
+	NetworkDNSRecordPut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"`
+}
+
+// NetworkDNSRecordPut represents the modifiable fields of a DNS record
+//
+// API extension: network_dns_records
+type NetworkDNSRecordPut struct {
+	Description string `json:"description" yaml:"description"`
+
+	// IPv4 and IPv6 addresses the name resolves to
+	Addresses []string `json:"addresses" yaml:"addresses"`
+}
+
+// NetworkDNSRecord represents a DNS record served on a LXD managed network
+//
+// API extension: network_dns_records
+type NetworkDNSRecord struct {
+	NetworkDNSRecordPut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"`
+}
+
+// Writable converts a full NetworkDNSRecord struct into a NetworkDNSRecordPut struct (filters read-only fields)
+func (record *NetworkDNSRecord) Writable() NetworkDNSRecordPut {
+	return record.NetworkDNSRecordPut
+}
@@ -0,0 +1,53 @@
+package api
+
+// NetworkForwardsPost represents the fields of a new network forward
+//
+// API extension: network_forwards
+type NetworkForwardsPost struct {
+	NetworkForwardPut `yaml:",inline"`
+
+	ListenAddress string `json:"listen_address" yaml:"listen_address"`
+}
+
+// NetworkForwardPut represents the modifiable fields of a network forward
+//
+// API extension: network_forwards
+type NetworkForwardPut struct {
+	Description string `json:"description" yaml:"description"`
+
+	// Address receiving the traffic which isn't matched by any of the ports
+	TargetAddress string `json:"target_address" yaml:"target_address"`
+
+	Ports []NetworkForwardPort `json:"ports" yaml:"ports"`
+}
+
+// NetworkForwardPort represents a port mapping of a network forward
+//
+// API extension: network_forwards
+type NetworkForwardPort struct {
+	// One of "tcp" or "udp"
+	Protocol string `json:"protocol" yaml:"protocol"`
+
+	// Comma separated lists of ports or port ranges
+	ListenPort string `json:"listen_port" yaml:"listen_port"`
+	TargetPort string `json:"target_port,omitempty" yaml:"target_port,omitempty"`
+
+	TargetAddress string `json:"target_address" yaml:"target_address"`
+
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// NetworkForward represents a network forward, publishing services running
+// on a LXD managed network on a host address
+//
+// API extension: network_forwards
+type NetworkForward struct {
+	NetworkForwardPut `yaml:",inline"`
+
+	ListenAddress string `json:"listen_address" yaml:"listen_address"`
+}
+
+// Writable converts a full NetworkForward struct into a NetworkForwardPut struct (filters read-only fields)
+func (forward *NetworkForward) Writable() NetworkForwardPut {
+	return forward.NetworkForwardPut
+}
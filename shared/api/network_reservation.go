@@ -0,0 +1,38 @@
+package api
+
+// NetworkReservationsPost represents the fields of a new DHCP reservation
+//
+// API extension: network_reservations
+type NetworkReservationsPost struct {
+	NetworkReservationPut `yaml:",inline"`
+
+	Hwaddr string `json:"hwaddr" yaml:"hwaddr"`
+}
+
+// NetworkReservationPut represents the modifiable fields of a DHCP reservation
+//
+// API extension: network_reservations
+type NetworkReservationPut struct {
+	Description string `json:"description" yaml:"description"`
+
+	// At least one of the two addresses must be set
+	IPv4Address string `json:"ipv4_address" yaml:"ipv4_address"`
+	IPv6Address string `json:"ipv6_address" yaml:"ipv6_address"`
+
+	// Name given to the host in DNS (only with the "managed" DNS mode)
+	Hostname string `json:"hostname" yaml:"hostname"`
+}
+
+// NetworkReservation represents a DHCP reservation on a LXD managed network
+//
+// API extension: network_reservations
+type NetworkReservation struct {
+	NetworkReservationPut `yaml:",inline"`
+
+	Hwaddr string `json:"hwaddr" yaml:"hwaddr"`
+}
+
+// Writable converts a full NetworkReservation struct into a NetworkReservationPut struct (filters read-only fields)
+func (reservation *NetworkReservation) Writable() NetworkReservationPut {
+	return reservation.NetworkReservationPut
+}
@@ -0,0 +1,80 @@
+package api
+
+// Resources represents the system resources available to LXD
+//
+// API extension: resources
+type Resources struct {
+	CPU    ResourcesCPU    `json:"cpu" yaml:"cpu"`
+	Memory ResourcesMemory `json:"memory" yaml:"memory"`
+	GPU    ResourcesGPU    `json:"gpu" yaml:"gpu"`
+}
+
+// ResourcesCPUSocket represents a CPU socket on the system
+//
+// API extension: resources
+type ResourcesCPUSocket struct {
+	Socket  uint64 `json:"socket" yaml:"socket"`
+	Vendor  string `json:"vendor" yaml:"vendor"`
+	Name    string `json:"name" yaml:"name"`
+	Cores   uint64 `json:"cores" yaml:"cores"`
+	Threads uint64 `json:"threads" yaml:"threads"`
+}
+
+// ResourcesCPU represents the cpu resources available on the system
+//
+// API extension: resources
+type ResourcesCPU struct {
+	Sockets []ResourcesCPUSocket `json:"sockets" yaml:"sockets"`
+	Total   uint64               `json:"total" yaml:"total"`
+}
+
+// ResourcesMemory represents the memory resources available on the system
+//
+// API extension: resources
+type ResourcesMemory struct {
+	Used  uint64 `json:"used" yaml:"used"`
+	Total uint64 `json:"total" yaml:"total"`
+}
+
+// ResourcesGPUCard represents a GPU card on the system
+//
+// API extension: resources
+type ResourcesGPUCard struct {
+	ID         string `json:"id" yaml:"id"`
+	PCIAddress string `json:"pci_address" yaml:"pci_address"`
+	VendorID   string `json:"vendor_id" yaml:"vendor_id"`
+	ProductID  string `json:"product_id" yaml:"product_id"`
+	NVIDIA     bool   `json:"nvidia" yaml:"nvidia"`
+}
+
+// ResourcesGPU represents the GPU resources available on the system
+//
+// API extension: resources
+type ResourcesGPU struct {
+	Cards []ResourcesGPUCard `json:"cards" yaml:"cards"`
+	Total uint64             `json:"total" yaml:"total"`
+}
+
+// ResourcesStoragePool represents the resources available to a given storage pool
+//
+// API extension: resources
+type ResourcesStoragePool struct {
+	Space  ResourcesStoragePoolSpace  `json:"space" yaml:"space"`
+	Inodes ResourcesStoragePoolInodes `json:"inodes" yaml:"inodes"`
+}
+
+// ResourcesStoragePoolSpace represents the space available to a given storage pool
+//
+// API extension: resources
+type ResourcesStoragePoolSpace struct {
+	Used  uint64 `json:"used" yaml:"used"`
+	Total uint64 `json:"total" yaml:"total"`
+}
+
+// ResourcesStoragePoolInodes represents the inodes available to a given storage pool
+//
+// API extension: resources
+type ResourcesStoragePoolInodes struct {
+	Used  uint64 `json:"used" yaml:"used"`
+	Total uint64 `json:"total" yaml:"total"`
+}
@@ -16,6 +16,9 @@ type ServerEnvironment struct {
 	ServerVersion          string   `json:"server_version" yaml:"server_version"`
 	Storage                string   `json:"storage" yaml:"storage"`
 	StorageVersion         string   `json:"storage_version" yaml:"storage_version"`
+
+	// API extension: network_firewall_nftables
+	Firewall string `json:"firewall" yaml:"firewall"`
 }
 
 // ServerPut represents the modifiable fields of a LXD server configuration
@@ -36,6 +36,42 @@ type StorageVolumesPost struct {
 
 	Name string `json:"name" yaml:"name"`
 	Type string `json:"type" yaml:"type"`
+
+	// API extension: storage_api_volume_handling
+	Source StorageVolumeSource `json:"source" yaml:"source"`
+}
+
+// StorageVolumePost represents the fields required to rename or move a LXD
+// storage volume
+//
+// API extension: storage_api_volume_handling
+type StorageVolumePost struct {
+	Name string `json:"name" yaml:"name"`
+
+	// Used to move the volume to another storage pool
+	Pool string `json:"pool,omitempty" yaml:"pool,omitempty"`
+
+	// Used for migration
+	Migration  bool `json:"migration" yaml:"migration"`
+	VolumeOnly bool `json:"volume_only" yaml:"volume_only"`
+}
+
+// StorageVolumeSource represents the creation source for a new storage volume
+//
+// API extension: storage_api_volume_handling
+type StorageVolumeSource struct {
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+	Pool string `json:"pool" yaml:"pool"`
+
+	// For "migration" type
+	Certificate string            `json:"certificate" yaml:"certificate"`
+	Mode        string            `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Operation   string            `json:"operation,omitempty" yaml:"operation,omitempty"`
+	Websockets  map[string]string `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+
+	// For "copy" and "migration" types
+	VolumeOnly bool `json:"volume_only,omitempty" yaml:"volume_only,omitempty"`
 }
 
 // StorageVolume represents the fields of a LXD storage volume.
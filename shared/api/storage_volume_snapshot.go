@@ -0,0 +1,25 @@
+package api
+
+// StorageVolumeSnapshotsPost represents the fields available for a new LXD
+// storage volume snapshot
+//
+// API extension: storage_api_volume_handling
+type StorageVolumeSnapshotsPost struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// StorageVolumeSnapshotPost represents the fields required to rename a LXD
+// storage volume snapshot
+//
+// API extension: storage_api_volume_handling
+type StorageVolumeSnapshotPost struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// StorageVolumeSnapshot represents a LXD storage volume snapshot
+//
+// API extension: storage_api_volume_handling
+type StorageVolumeSnapshot struct {
+	Name   string            `json:"name" yaml:"name"`
+	Config map[string]string `json:"config" yaml:"config"`
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ContainerAction string
@@ -167,6 +168,25 @@ var KnownContainerConfigKeys = map[string]func(value string) error{
 
 	"linux.kernel_modules": IsAny,
 
+	"snapshots.schedule":         IsCronSchedule,
+	"snapshots.schedule.stopped": IsBool,
+	"snapshots.pattern": func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		if strings.Contains(value, "/") {
+			return fmt.Errorf("Invalid snapshot pattern: %s (must not contain a slash)", value)
+		}
+
+		_, _, err := SnapshotPatternSplit(value)
+		return err
+	},
+	"snapshots.expiry": func(value string) error {
+		_, err := GetSnapshotExpiry(time.Now(), value)
+		return err
+	},
+
 	"security.nesting":    IsBool,
 	"security.privileged": IsBool,
 
@@ -211,6 +231,18 @@ func ConfigKeyChecker(key string) (func(value string) error, error) {
 		if strings.HasSuffix(key, ".name") {
 			return IsAny, nil
 		}
+
+		if strings.HasSuffix(key, ".host_name") {
+			return IsAny, nil
+		}
+
+		if strings.HasSuffix(key, ".last_state.mtu") {
+			return IsAny, nil
+		}
+
+		if strings.Contains(key, ".last_state.vf.") {
+			return IsAny, nil
+		}
 	}
 
 	if strings.HasPrefix(key, "environment.") {
@@ -0,0 +1,234 @@
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron(8) style schedule.
+type CronSchedule struct {
+	minutes  []bool
+	hours    []bool
+	days     []bool
+	months   []bool
+	weekdays []bool
+
+	// Whether the day of month and day of week fields were restricted
+	anyDay     bool
+	anyWeekday bool
+}
+
+var cronShortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// CronParse parses a five fields (minute, hour, day of month, month, day of
+// week) cron schedule. Fields support "*", lists, ranges and steps, the
+// "@hourly" style shortcuts are also accepted.
+func CronParse(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	shortcut, ok := cronShortcuts[spec]
+	if ok {
+		spec = shortcut
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("Invalid schedule '%s': expected 5 fields", spec)
+	}
+
+	var err error
+	schedule := CronSchedule{}
+
+	schedule.minutes, err = cronParseField(fields[0], 0, 59)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.hours, err = cronParseField(fields[1], 0, 23)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.days, err = cronParseField(fields[2], 1, 31)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.months, err = cronParseField(fields[3], 1, 12)
+	if err != nil {
+		return nil, err
+	}
+
+	// Both 0 and 7 are Sunday
+	schedule.weekdays, err = cronParseField(fields[4], 0, 7)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.weekdays[7] {
+		schedule.weekdays[0] = true
+	}
+
+	schedule.anyDay = fields[2] == "*"
+	schedule.anyWeekday = fields[4] == "*"
+
+	return &schedule, nil
+}
+
+func cronParseField(field string, min int, max int) ([]bool, error) {
+	values := make([]bool, max+1)
+
+	for _, entry := range strings.Split(field, ",") {
+		step := 1
+		fields := strings.SplitN(entry, "/", 2)
+		if len(fields) == 2 {
+			var err error
+			step, err = strconv.Atoi(fields[1])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("Invalid step in schedule: %s", entry)
+			}
+		}
+
+		start := min
+		end := max
+		if fields[0] != "*" {
+			bounds := strings.SplitN(fields[0], "-", 2)
+
+			var err error
+			start, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid value in schedule: %s", entry)
+			}
+
+			end = start
+			if len(bounds) == 2 {
+				end, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("Invalid value in schedule: %s", entry)
+				}
+			} else if len(fields) == 2 {
+				// "5/15" means every 15 starting at 5
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("Out of range value in schedule: %s", entry)
+		}
+
+		for i := start; i <= end; i += step {
+			values[i] = true
+		}
+	}
+
+	return values, nil
+}
+
+// Matches returns whether the schedule fires at the minute of the given time.
+func (s *CronSchedule) Matches(t time.Time) bool {
+	if !s.minutes[t.Minute()] || !s.hours[t.Hour()] || !s.months[int(t.Month())] {
+		return false
+	}
+
+	day := s.days[t.Day()]
+	weekday := s.weekdays[int(t.Weekday())]
+
+	// Like cron, a day matches either field when both are restricted
+	if !s.anyDay && !s.anyWeekday {
+		return day || weekday
+	}
+
+	return day && weekday
+}
+
+// IsCronSchedule validates a cron schedule config value.
+func IsCronSchedule(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	_, err := CronParse(value)
+	return err
+}
+
+// GetSnapshotExpiry returns the expiry date of a snapshot taken at refDate,
+// given an expiry such as "2w" or "1d 12H". Valid units are M (minutes),
+// H (hours), d (days), w (weeks), m (months) and y (years).
+func GetSnapshotExpiry(refDate time.Time, s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	expiry := refDate
+	for _, value := range strings.Fields(s) {
+		if len(value) < 2 {
+			return time.Time{}, fmt.Errorf("Invalid expiry: %s", s)
+		}
+
+		num, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || num < 0 {
+			return time.Time{}, fmt.Errorf("Invalid expiry: %s", s)
+		}
+
+		switch value[len(value)-1] {
+		case 'M':
+			expiry = expiry.Add(time.Duration(num) * time.Minute)
+		case 'H':
+			expiry = expiry.Add(time.Duration(num) * time.Hour)
+		case 'd':
+			expiry = expiry.AddDate(0, 0, num)
+		case 'w':
+			expiry = expiry.AddDate(0, 0, num*7)
+		case 'm':
+			expiry = expiry.AddDate(0, num, 0)
+		case 'y':
+			expiry = expiry.AddDate(num, 0, 0)
+		default:
+			return time.Time{}, fmt.Errorf("Invalid expiry unit in: %s", s)
+		}
+	}
+
+	return expiry, nil
+}
+
+// SnapshotPatternSplit returns the text before and after the "%d" of a
+// snapshot name pattern, as rendered by fmt. Besides that single "%d", only
+// "%%" may be used.
+func SnapshotPatternSplit(pattern string) (string, string, error) {
+	fields := []string{"", ""}
+	verbs := 0
+
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			fields[verbs] += string(pattern[i])
+			continue
+		}
+
+		i++
+		if i < len(pattern) && pattern[i] == '%' {
+			fields[verbs] += "%"
+			continue
+		}
+
+		if i >= len(pattern) || pattern[i] != 'd' || verbs == 1 {
+			return "", "", fmt.Errorf("Invalid snapshot pattern: %s (must contain %%d once, other percent signs must be doubled)", pattern)
+		}
+
+		verbs++
+	}
+
+	if verbs != 1 {
+		return "", "", fmt.Errorf("Invalid snapshot pattern: %s (must contain %%d once, other percent signs must be doubled)", pattern)
+	}
+
+	return fields[0], fields[1], nil
+}
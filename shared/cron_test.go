@@ -0,0 +1,90 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronParse(t *testing.T) {
+	monday := time.Date(2017, time.July, 3, 4, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec    string
+		matches bool
+	}{
+		{"* * * * *", true},
+		{"30 4 * * *", true},
+		{"*/15 * * * *", true},
+		{"*/7 * * * *", false},
+		{"0,30 2-5 * * 1-5", true},
+		{"30 4 * * 0,6", false},
+		{"30 4 3 * *", true},
+		{"30 4 1 * 1", true},
+		{"30 4 1 * 2", false},
+		{"30 4 * 7 *", true},
+		{"@hourly", false},
+	}
+
+	for _, test := range tests {
+		schedule, err := CronParse(test.spec)
+		if err != nil {
+			t.Errorf("Failed to parse %s: %s", test.spec, err)
+			continue
+		}
+
+		if schedule.Matches(monday) != test.matches {
+			t.Errorf("Unexpected match for %s: %v", test.spec, !test.matches)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := CronParse(spec)
+		if err == nil {
+			t.Errorf("Invalid schedule %q was accepted", spec)
+		}
+	}
+}
+
+func TestGetSnapshotExpiry(t *testing.T) {
+	ref := time.Date(2017, time.July, 3, 4, 30, 0, 0, time.UTC)
+
+	expiry, err := GetSnapshotExpiry(ref, "2w 1d 3H 5M")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := time.Date(2017, time.July, 18, 7, 35, 0, 0, time.UTC)
+	if !expiry.Equal(expected) {
+		t.Errorf("Unexpected expiry: %s", expiry)
+	}
+
+	expiry, err = GetSnapshotExpiry(ref, "")
+	if err != nil || !expiry.IsZero() {
+		t.Errorf("Empty expiry should never expire: %s", expiry)
+	}
+
+	for _, value := range []string{"2", "w", "2x", "-1d"} {
+		_, err := GetSnapshotExpiry(ref, value)
+		if err == nil {
+			t.Errorf("Invalid expiry %q was accepted", value)
+		}
+	}
+}
+
+func TestSnapshotPatternSplit(t *testing.T) {
+	prefix, suffix, err := SnapshotPatternSplit("%%daily-%d%%")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prefix != "%daily-" || suffix != "%" {
+		t.Errorf("Unexpected split: %q %q", prefix, suffix)
+	}
+
+	for _, pattern := range []string{"daily", "%d-%d", "%s-%d", "%5d", "%d%", "%%d", "%v"} {
+		_, _, err := SnapshotPatternSplit(pattern)
+		if err == nil {
+			t.Errorf("Invalid pattern %q was accepted", pattern)
+		}
+	}
+}
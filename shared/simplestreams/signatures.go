@@ -0,0 +1,222 @@
+package simplestreams
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+const ssPGPKeyStart = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+const ssPGPKeyEnd = "-----END PGP PUBLIC KEY BLOCK-----"
+
+type ssMinisignKey struct {
+	id  []byte
+	key ed25519.PublicKey
+}
+
+// ssKeys holds the keys a simplestreams server is trusted to sign its index
+// and manifests with.
+type ssKeys struct {
+	pgp      openpgp.EntityList
+	minisign []ssMinisignKey
+}
+
+// ssParseKeys parses a list of armored GPG public keys and minisign public
+// keys, the "untrusted comment" lines of the minisign keys are ignored.
+func ssParseKeys(content string) (*ssKeys, error) {
+	keys := ssKeys{}
+
+	rest := content
+	for {
+		start := strings.Index(rest, ssPGPKeyStart)
+		if start == -1 {
+			break
+		}
+
+		end := strings.Index(rest[start:], ssPGPKeyEnd)
+		if end == -1 {
+			return nil, fmt.Errorf("Unterminated GPG public key block")
+		}
+		end += start + len(ssPGPKeyEnd)
+
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(rest[start:end]))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid GPG public key: %s", err)
+		}
+
+		keys.pgp = append(keys.pgp, entities...)
+		rest = rest[:start] + rest[end:]
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(rest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != "Ed" {
+			return nil, fmt.Errorf("Invalid minisign public key: %s", line)
+		}
+
+		keys.minisign = append(keys.minisign, ssMinisignKey{id: data[2:10], key: ed25519.PublicKey(data[10:])})
+	}
+
+	if len(keys.pgp) == 0 && len(keys.minisign) == 0 {
+		return nil, fmt.Errorf("No public key found")
+	}
+
+	return &keys, nil
+}
+
+// verifyPGP checks a clearsigned file and returns its content along with the
+// fingerprint of the key which signed it.
+func (k *ssKeys) verifyPGP(content []byte) ([]byte, string, error) {
+	block, _ := clearsign.Decode(content)
+	if block == nil {
+		return nil, "", fmt.Errorf("Not a clearsigned file")
+	}
+
+	signer, err := openpgp.CheckDetachedSignature(k.pgp, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return block.Plaintext, fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint), nil
+}
+
+// minisignKeyID formats a minisign key ID the way minisign itself prints it.
+func minisignKeyID(id []byte) string {
+	reversed := make([]byte, len(id))
+	for i := range id {
+		reversed[len(id)-1-i] = id[i]
+	}
+
+	return fmt.Sprintf("%X", reversed)
+}
+
+// verifyMinisign checks a detached minisign signature of a file and returns
+// the ID of the key which signed it. Both the legacy and the pre-hashed
+// signatures are supported, the trusted comment has to be signed too.
+func (k *ssKeys) verifyMinisign(content []byte, signature []byte) (string, error) {
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", fmt.Errorf("Invalid minisign signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return "", fmt.Errorf("Invalid minisign signature")
+	}
+
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return "", fmt.Errorf("Invalid minisign signature")
+	}
+
+	message := content
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		hash := blake2b.Sum512(content)
+		message = hash[:]
+	default:
+		return "", fmt.Errorf("Unsupported minisign signature algorithm")
+	}
+
+	for _, key := range k.minisign {
+		if !bytes.Equal(key.id, sig[2:10]) {
+			continue
+		}
+
+		if !ed25519.Verify(key.key, message, sig[10:]) {
+			return "", fmt.Errorf("Bad minisign signature")
+		}
+
+		comment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+		signed := append([]byte{}, sig[10:]...)
+		if !ed25519.Verify(key.key, append(signed, []byte(comment)...), globalSig) {
+			return "", fmt.Errorf("Bad minisign signature of the trusted comment")
+		}
+
+		return minisignKeyID(key.id), nil
+	}
+
+	return "", fmt.Errorf("Signed by unknown minisign key %s", minisignKeyID(sig[2:10]))
+}
+
+// SetKeys restricts the client to an index and manifests signed by one of the
+// provided GPG or minisign public keys.
+func (s *SimpleStreams) SetKeys(keys string) error {
+	parsed, err := ssParseKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	s.keys = parsed
+
+	return nil
+}
+
+// fetchSigned downloads a simplestreams file and, when trusted keys are set,
+// only returns its content if it was signed by one of them. GPG signatures
+// are looked up in the clearsigned .sjson version of the file, minisign ones
+// in a detached .minisig file.
+func (s *SimpleStreams) fetchSigned(path string) ([]byte, *api.ImageSignature, error) {
+	if s.keys == nil {
+		body, err := s.fetch(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return body, &api.ImageSignature{Status: "unverified"}, nil
+	}
+
+	failures := []string{}
+
+	if len(s.keys.pgp) > 0 {
+		content, err := s.fetch(fmt.Sprintf("%s.sjson", strings.TrimSuffix(path, ".json")))
+		if err == nil {
+			var body []byte
+			var key string
+
+			body, key, err = s.keys.verifyPGP(content)
+			if err == nil {
+				return body, &api.ImageSignature{Status: "verified", Type: "gpg", Key: key}, nil
+			}
+		}
+
+		failures = append(failures, err.Error())
+	}
+
+	if len(s.keys.minisign) > 0 {
+		body, err := s.fetch(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		signature, err := s.fetch(fmt.Sprintf("%s.minisig", path))
+		if err == nil {
+			var key string
+
+			key, err = s.keys.verifyMinisign(body, signature)
+			if err == nil {
+				return body, &api.ImageSignature{Status: "verified", Type: "minisign", Key: key}, nil
+			}
+		}
+
+		failures = append(failures, err.Error())
+	}
+
+	return nil, nil, fmt.Errorf("Unable to verify the signature of %s: %s", path, strings.Join(failures, ", "))
+}
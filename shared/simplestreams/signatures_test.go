@@ -0,0 +1,192 @@
+package simplestreams
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
+)
+
+const testIndex = `{"format": "index:1.0", "index": {}}`
+
+func testServer(files map[string]string) (*httptest.Server, *SimpleStreams) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte(content))
+	}))
+
+	return server, NewClient(server.URL, http.Client{}, "")
+}
+
+func testPGPKey(t *testing.T) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = entity.Serialize(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return entity, buf.String()
+}
+
+func testClearsign(t *testing.T, entity *openpgp.Entity, content string) string {
+	buf := bytes.Buffer{}
+	w, err := clearsign.Encode(&buf, entity.PrivateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Write([]byte(content))
+	w.Close()
+
+	return buf.String()
+}
+
+func testMinisign(key ed25519.PrivateKey, id []byte, content string) string {
+	hash := blake2b.Sum512([]byte(content))
+	sig := append([]byte("ED"), id...)
+	sig = append(sig, ed25519.Sign(key, hash[:])...)
+
+	comment := "timestamp:1500000000"
+	global := ed25519.Sign(key, append(append([]byte{}, sig[10:]...), []byte(comment)...))
+
+	return fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(sig), comment, base64.StdEncoding.EncodeToString(global))
+}
+
+func TestSignaturesUnsigned(t *testing.T) {
+	server, client := testServer(map[string]string{"/streams/v1/index.json": testIndex})
+	defer server.Close()
+
+	body, signature, err := client.fetchSigned("streams/v1/index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != testIndex || signature.Status != "unverified" {
+		t.Fatalf("Unexpected result: %s %v", body, signature)
+	}
+}
+
+func TestSignaturesPGP(t *testing.T) {
+	entity, public := testPGPKey(t)
+	other, _ := testPGPKey(t)
+
+	server, client := testServer(map[string]string{
+		"/streams/v1/index.json":   testIndex,
+		"/streams/v1/index.sjson":  testClearsign(t, entity, testIndex),
+		"/streams/v1/images.json":  testIndex,
+		"/streams/v1/images.sjson": testClearsign(t, other, testIndex),
+	})
+	defer server.Close()
+
+	err := client.SetKeys(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, signature, err := client.fetchSigned("streams/v1/index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.TrimSpace(string(body)) != testIndex || signature.Status != "verified" || signature.Type != "gpg" {
+		t.Fatalf("Unexpected result: %s %v", body, signature)
+	}
+
+	if signature.Key != fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint) {
+		t.Fatalf("Unexpected key: %s", signature.Key)
+	}
+
+	// Signed by an unknown key
+	_, _, err = client.fetchSigned("streams/v1/images.json")
+	if err == nil {
+		t.Fatal("Metadata signed by an unknown key was accepted")
+	}
+
+	// Not signed at all
+	_, _, err = client.fetchSigned("streams/v1/other.json")
+	if err == nil {
+		t.Fatal("Unsigned metadata was accepted")
+	}
+}
+
+func TestSignaturesMinisign(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	key := append(append([]byte("Ed"), id...), public...)
+
+	server, client := testServer(map[string]string{
+		"/streams/v1/index.json":          testIndex,
+		"/streams/v1/index.json.minisig":  testMinisign(private, id, testIndex),
+		"/streams/v1/images.json":         testIndex,
+		"/streams/v1/images.json.minisig": testMinisign(private, id, `{"format": "products:1.0"}`),
+	})
+	defer server.Close()
+
+	err = client.SetKeys(fmt.Sprintf("untrusted comment: minisign public key\n%s\n", base64.StdEncoding.EncodeToString(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, signature, err := client.fetchSigned("streams/v1/index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signature.Status != "verified" || signature.Type != "minisign" || signature.Key != "0807060504030201" {
+		t.Fatalf("Unexpected signature: %v", signature)
+	}
+
+	// Signature of another content
+	_, _, err = client.fetchSigned("streams/v1/images.json")
+	if err == nil {
+		t.Fatal("Tampered metadata was accepted")
+	}
+}
+
+func TestSignaturesParseKeys(t *testing.T) {
+	_, err := ssParseKeys("")
+	if err == nil {
+		t.Fatal("Empty key list was accepted")
+	}
+
+	_, err = ssParseKeys("RWQf6LRCGA9i53mlYecO4IzT51TGPpvWucNSCh1CBM0QTaLn73Y7GFO3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ssParseKeys("not a key")
+	if err == nil {
+		t.Fatal("Invalid key was accepted")
+	}
+}
@@ -82,6 +82,18 @@ type SimpleStreamsManifest struct {
 	Products map[string]SimpleStreamsManifestProduct `json:"products"`
 }
 
+// isTarball returns whether the file type is a tarball of the given kind
+// ("lxd" or "root"), using any of the compression formats LXD can unpack.
+func isTarball(ftype string, kind string) bool {
+	for _, ext := range []string{".tar.xz", ".tar.gz", ".tar.bz2", ".tar.lzma", ".tar.zst", ".tar"} {
+		if ftype == kind+ext {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (s *SimpleStreamsManifest) ToLXD() ([]api.Image, map[string][][]string) {
 	downloads := map[string][][]string{}
 
@@ -115,23 +127,25 @@ func (s *SimpleStreamsManifest) ToLXD() ([]api.Image, map[string][][]string) {
 			var meta SimpleStreamsManifestProductVersionItem
 			var rootTar SimpleStreamsManifestProductVersionItem
 			var rootSquash SimpleStreamsManifestProductVersionItem
+			deltas := []SimpleStreamsManifestProductVersionItem{}
 
 			for _, item := range version.Items {
-				// Skip the files we don't care about
-				if !shared.StringInSlice(item.FileType, []string{"root.tar.xz", "lxd.tar.xz", "squashfs"}) {
-					continue
-				}
-
-				if item.FileType == "lxd.tar.xz" {
+				if item.FileType == "squashfs.vcdiff" {
+					deltas = append(deltas, item)
+				} else if isTarball(item.FileType, "lxd") {
 					meta = item
 				} else if item.FileType == "squashfs" {
 					rootSquash = item
-				} else if item.FileType == "root.tar.xz" {
+				} else if isTarball(item.FileType, "root") {
 					rootTar = item
 				}
 			}
 
-			if meta.FileType == "" || (rootTar.FileType == "" && rootSquash.FileType == "") {
+			// Unified images only have a metadata file, which is the
+			// whole image
+			unified := rootTar.FileType == "" && rootSquash.FileType == "" && meta.LXDHashSha256 != "" && meta.LXDHashSha256 == meta.HashSha256
+
+			if meta.FileType == "" || (rootTar.FileType == "" && rootSquash.FileType == "" && !unified) {
 				// Invalid image
 				continue
 			}
@@ -147,7 +161,9 @@ func (s *SimpleStreamsManifest) ToLXD() ([]api.Image, map[string][][]string) {
 			size := meta.Size
 			fingerprint := ""
 
-			if rootSquash.FileType != "" {
+			if unified {
+				fingerprint = meta.LXDHashSha256
+			} else if rootSquash.FileType != "" {
 				if meta.LXDHashSha256SquashFs != "" {
 					fingerprint = meta.LXDHashSha256SquashFs
 				} else {
@@ -224,8 +240,40 @@ func (s *SimpleStreamsManifest) ToLXD() ([]api.Image, map[string][][]string) {
 			}
 
 			downloads[fingerprint] = [][]string{
-				{metaPath, metaHash, "meta", fmt.Sprintf("%d", metaSize)},
-				{rootfsPath, rootfsHash, "root", fmt.Sprintf("%d", rootfsSize)}}
+				{metaPath, metaHash, "meta", fmt.Sprintf("%d", metaSize)}}
+
+			if !unified {
+				downloads[fingerprint] = append(downloads[fingerprint], []string{rootfsPath, rootfsHash, "root", fmt.Sprintf("%d", rootfsSize)})
+			}
+
+			// Add the deltas against previous versions of the squashfs
+			if rootSquash.FileType != "" {
+				for _, delta := range deltas {
+					base, ok := product.Versions[delta.DeltaBase]
+					if !ok {
+						continue
+					}
+
+					baseFingerprint := ""
+					for _, item := range base.Items {
+						if !isTarball(item.FileType, "lxd") {
+							continue
+						}
+
+						baseFingerprint = item.LXDHashSha256SquashFs
+						if baseFingerprint == "" {
+							baseFingerprint = item.LXDHashSha256
+						}
+					}
+
+					if baseFingerprint == "" {
+						continue
+					}
+
+					downloads[fingerprint] = append(downloads[fingerprint], []string{delta.Path, delta.HashSha256, fmt.Sprintf("root.delta-%s", baseFingerprint), fmt.Sprintf("%d", delta.Size)})
+				}
+			}
+
 			images = append(images, image)
 		}
 	}
@@ -261,6 +309,7 @@ type SimpleStreamsManifestProductVersionItem struct {
 	LXDHashSha256RootXz   string `json:"combined_rootxz_sha256"`
 	LXDHashSha256SquashFs string `json:"combined_squashfs_sha256"`
 	Size                  int64  `json:"size"`
+	DeltaBase             string `json:"delta_base,omitempty"`
 }
 
 type SimpleStreamsIndex struct {
@@ -284,10 +333,11 @@ type SimpleStreamsFile struct {
 
 func NewClient(url string, httpClient http.Client, useragent string) *SimpleStreams {
 	return &SimpleStreams{
-		http:           &httpClient,
-		url:            url,
-		cachedManifest: map[string]*SimpleStreamsManifest{},
-		useragent:      useragent,
+		http:             &httpClient,
+		url:              url,
+		cachedManifest:   map[string]*SimpleStreamsManifest{},
+		cachedSignatures: map[string]*api.ImageSignature{},
+		useragent:        useragent,
 	}
 }
 
@@ -295,19 +345,17 @@ type SimpleStreams struct {
 	http      *http.Client
 	url       string
 	useragent string
+	keys      *ssKeys
 
-	cachedIndex    *SimpleStreamsIndex
-	cachedManifest map[string]*SimpleStreamsManifest
-	cachedImages   []api.Image
-	cachedAliases  map[string]*api.ImageAliasesEntry
+	cachedIndex      *SimpleStreamsIndex
+	cachedManifest   map[string]*SimpleStreamsManifest
+	cachedSignatures map[string]*api.ImageSignature
+	cachedImages     []api.Image
+	cachedAliases    map[string]*api.ImageAliasesEntry
 }
 
-func (s *SimpleStreams) parseIndex() (*SimpleStreamsIndex, error) {
-	if s.cachedIndex != nil {
-		return s.cachedIndex, nil
-	}
-
-	url := fmt.Sprintf("%s/streams/v1/index.json", s.url)
+func (s *SimpleStreams) fetch(path string) ([]byte, error) {
+	url := fmt.Sprintf("%s/%s", s.url, path)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -327,7 +375,15 @@ func (s *SimpleStreams) parseIndex() (*SimpleStreamsIndex, error) {
 		return nil, fmt.Errorf("Unable to fetch %s: %s", url, r.Status)
 	}
 
-	body, err := ioutil.ReadAll(r.Body)
+	return ioutil.ReadAll(r.Body)
+}
+
+func (s *SimpleStreams) parseIndex() (*SimpleStreamsIndex, error) {
+	if s.cachedIndex != nil {
+		return s.cachedIndex, nil
+	}
+
+	body, _, err := s.fetchSigned("streams/v1/index.json")
 	if err != nil {
 		return nil, err
 	}
@@ -349,27 +405,7 @@ func (s *SimpleStreams) parseManifest(path string) (*SimpleStreamsManifest, erro
 		return s.cachedManifest[path], nil
 	}
 
-	url := fmt.Sprintf("%s/%s", s.url, path)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	if s.useragent != "" {
-		req.Header.Set("User-Agent", s.useragent)
-	}
-
-	r, err := s.http.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer r.Body.Close()
-
-	if r.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Unable to fetch %s: %s", url, r.Status)
-	}
-
-	body, err := ioutil.ReadAll(r.Body)
+	body, signature, err := s.fetchSigned(path)
 	if err != nil {
 		return nil, err
 	}
@@ -382,6 +418,7 @@ func (s *SimpleStreams) parseManifest(path string) (*SimpleStreamsManifest, erro
 	}
 
 	s.cachedManifest[path] = &ssManifest
+	s.cachedSignatures[path] = signature
 
 	return &ssManifest, nil
 }
@@ -481,6 +518,7 @@ func (s *SimpleStreams) getImages() ([]api.Image, map[string]*api.ImageAliasesEn
 		manifestImages, _ := manifest.ToLXD()
 
 		for _, image := range manifestImages {
+			image.Signature = *s.cachedSignatures[entry.Path]
 			images = append(images, image)
 		}
 	}
@@ -0,0 +1,107 @@
+package simplestreams
+
+import (
+	"testing"
+)
+
+func TestToLXDDeltas(t *testing.T) {
+	item := func(ftype string, path string, extra ...string) SimpleStreamsManifestProductVersionItem {
+		i := SimpleStreamsManifestProductVersionItem{FileType: ftype, Path: path, HashSha256: path + "-hash", Size: 10}
+		if len(extra) > 0 {
+			i.LXDHashSha256SquashFs = extra[0]
+		}
+
+		return i
+	}
+
+	delta := item("squashfs.vcdiff", "images/ubuntu/20170602/20170601.vcdiff")
+	delta.DeltaBase = "20170601"
+
+	missing := item("squashfs.vcdiff", "images/ubuntu/20170602/20170501.vcdiff")
+	missing.DeltaBase = "20170501"
+
+	manifest := SimpleStreamsManifest{
+		Products: map[string]SimpleStreamsManifestProduct{
+			"ubuntu:xenial:amd64:default": {
+				Architecture:    "amd64",
+				OperatingSystem: "ubuntu",
+				Release:         "xenial",
+				Versions: map[string]SimpleStreamsManifestProductVersion{
+					"20170601": {
+						Items: map[string]SimpleStreamsManifestProductVersionItem{
+							"lxd.tar.xz":    item("lxd.tar.xz", "images/ubuntu/20170601/lxd.tar.xz", "old-fingerprint"),
+							"root.squashfs": item("squashfs", "images/ubuntu/20170601/root.squashfs"),
+						},
+					},
+					"20170602": {
+						Items: map[string]SimpleStreamsManifestProductVersionItem{
+							"lxd.tar.xz":     item("lxd.tar.xz", "images/ubuntu/20170602/lxd.tar.xz", "new-fingerprint"),
+							"root.squashfs":  item("squashfs", "images/ubuntu/20170602/root.squashfs"),
+							"delta-20170601": delta,
+							"delta-20170501": missing,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	images, downloads := manifest.ToLXD()
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 images, got %d", len(images))
+	}
+
+	files := downloads["new-fingerprint"]
+	if len(files) != 3 {
+		t.Fatalf("Expected the meta, root and a single delta, got: %v", files)
+	}
+
+	if files[2][0] != delta.Path || files[2][2] != "root.delta-old-fingerprint" {
+		t.Errorf("Unexpected delta entry: %v", files[2])
+	}
+
+	if len(downloads["old-fingerprint"]) != 2 {
+		t.Errorf("Unexpected downloads for the base image: %v", downloads["old-fingerprint"])
+	}
+}
+
+func TestToLXDCompression(t *testing.T) {
+	item := func(ftype string, path string, fingerprint string) SimpleStreamsManifestProductVersionItem {
+		return SimpleStreamsManifestProductVersionItem{FileType: ftype, Path: path, HashSha256: path + "-hash", Size: 10, LXDHashSha256: fingerprint}
+	}
+
+	manifest := SimpleStreamsManifest{
+		Products: map[string]SimpleStreamsManifestProduct{
+			"alpine:edge:amd64:default": {
+				Architecture:    "amd64",
+				OperatingSystem: "alpine",
+				Release:         "edge",
+				Versions: map[string]SimpleStreamsManifestProductVersion{
+					"20170601": {
+						Items: map[string]SimpleStreamsManifestProductVersionItem{
+							"meta.tar.gz":    item("lxd.tar.gz", "images/alpine/20170601/meta.tar.gz", "split-fingerprint"),
+							"rootfs.tar.zst": item("root.tar.zst", "images/alpine/20170601/rootfs.tar.zst", ""),
+						},
+					},
+					"20170602": {
+						Items: map[string]SimpleStreamsManifestProductVersionItem{
+							"meta.tar.bz2": item("lxd.tar.bz2", "images/alpine/20170602/meta.tar.bz2", "images/alpine/20170602/meta.tar.bz2-hash"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_, downloads := manifest.ToLXD()
+
+	files := downloads["split-fingerprint"]
+	if len(files) != 2 || files[1][0] != "images/alpine/20170601/rootfs.tar.zst" {
+		t.Errorf("Unexpected downloads for the split image: %v", files)
+	}
+
+	files = downloads["images/alpine/20170602/meta.tar.bz2-hash"]
+	if len(files) != 1 || files[0][0] != "images/alpine/20170602/meta.tar.bz2" {
+		t.Errorf("Unexpected downloads for the unified image: %v", files)
+	}
+}
@@ -0,0 +1,65 @@
+#!/bin/sh
+
+ceph_setup() {
+  # shellcheck disable=2039
+  local LXD_DIR
+
+  LXD_DIR=$1
+
+  echo "==> Setting up CEPH backend in ${LXD_DIR}"
+
+  # Without a cluster to talk to, use the stand-in tools
+  if [ -n "${LXD_CEPH_MOCK:-}" ] || ! which rbd >/dev/null 2>&1; then
+    ceph_mock_setup
+  fi
+
+  if ! which rbd >/dev/null 2>&1; then
+    echo "Couldn't find the rbd binary"; false
+  fi
+}
+
+ceph_mock_setup() {
+  if [ -n "${LXD_CEPH_MOCK_DIR:-}" ]; then
+    return
+  fi
+
+  if ! which losetup >/dev/null 2>&1; then
+    echo "Couldn't find the losetup binary"; false
+  fi
+
+  echo "==> Using the mock ceph and rbd tools"
+
+  LXD_CEPH_MOCK_DIR="${TEST_DIR}/ceph-mock"
+  mkdir -p "${LXD_CEPH_MOCK_DIR}/bin"
+  ln -s "$(pwd)/deps/ceph-mock" "${LXD_CEPH_MOCK_DIR}/bin/ceph"
+  ln -s "$(pwd)/deps/ceph-mock" "${LXD_CEPH_MOCK_DIR}/bin/rbd"
+
+  export LXD_CEPH_MOCK_DIR
+  export PATH="${LXD_CEPH_MOCK_DIR}/bin:${PATH}"
+}
+
+ceph_configure() {
+  # shellcheck disable=2039
+  local LXD_DIR
+
+  LXD_DIR=$1
+
+  echo "==> Configuring CEPH backend in ${LXD_DIR}"
+
+  lxc storage create "lxdtest-$(basename "${LXD_DIR}")" ceph ceph.cluster_name="${LXD_CEPH_CLUSTER:-ceph}" volume.size=25MB
+  lxc profile device add default root disk path="/" pool="lxdtest-$(basename "${LXD_DIR}")"
+}
+
+ceph_teardown() {
+  # shellcheck disable=2039
+  local LXD_DIR
+
+  LXD_DIR=$1
+
+  echo "==> Tearing down CEPH backend in ${LXD_DIR}"
+
+  # Remove the OSD pools of the daemon's storage pools
+  for pool in $(ceph --cluster "${LXD_CEPH_CLUSTER:-ceph}" osd pool ls | grep "^lxdtest-$(basename "${LXD_DIR}")"); do
+    ceph --cluster "${LXD_CEPH_CLUSTER:-ceph}" osd pool delete "${pool}" "${pool}" --yes-i-really-really-mean-it
+  done
+}
@@ -324,6 +324,10 @@ kill_lxd() {
     check_empty_table "${daemon_dir}/lxd.db" "containers_profiles"
     check_empty_table "${daemon_dir}/lxd.db" "networks"
     check_empty_table "${daemon_dir}/lxd.db" "networks_config"
+    check_empty_table "${daemon_dir}/lxd.db" "networks_dns_records"
+    check_empty_table "${daemon_dir}/lxd.db" "networks_forwards"
+    check_empty_table "${daemon_dir}/lxd.db" "networks_forwards_ports"
+    check_empty_table "${daemon_dir}/lxd.db" "networks_reservations"
     check_empty_table "${daemon_dir}/lxd.db" "images"
     check_empty_table "${daemon_dir}/lxd.db" "images_aliases"
     check_empty_table "${daemon_dir}/lxd.db" "images_properties"
@@ -561,10 +565,12 @@ run_test test_concurrent_exec "concurrent exec"
 run_test test_concurrent "concurrent startup"
 run_test test_snapshots "container snapshots"
 run_test test_snap_restore "snapshot restores"
+run_test test_snap_schedule "scheduled snapshots"
 run_test test_config_profiles "profiles and configuration"
 run_test test_server_config "server configuration"
 run_test test_filemanip "file manipulations"
 run_test test_network "network management"
+run_test test_network_acl "network ACLs"
 run_test test_idmap "id mapping"
 run_test test_template "file templating"
 run_test test_pki "PKI mode"
@@ -577,6 +583,12 @@ run_test test_mem_profiling "memory profiling"
 run_test test_storage "storage"
 run_test test_lxd_autoinit "lxd init auto"
 run_test test_storage_profiles "storage profiles"
+run_test test_storage_volume_handling "storage volume handling"
 run_test test_container_import "container import"
+run_test test_backup_import "backup import"
+run_test test_clustering "clustering"
+run_test test_projects "projects"
+run_test test_proxy_device "proxy device"
+run_test test_resources "resources"
 
 TEST_RESULT=success
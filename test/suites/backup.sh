@@ -93,3 +93,45 @@ test_container_import() {
   # shellcheck disable=SC2031
   kill_lxd "${LXD_IMPORT_DIR}"
 }
+
+test_backup_import() {
+  ensure_import_testimage
+
+  lxc launch testimage b1
+  lxc snapshot b1
+
+  # container only
+  lxc export b1 "${LXD_DIR}/c1.tar.gz" --container-only
+  tar -tzf "${LXD_DIR}/c1.tar.gz" | grep -q "^backup/index.yaml"
+  ! tar -tzf "${LXD_DIR}/c1.tar.gz" | grep -q "^backup/snapshots/"
+
+  # with snapshots
+  lxc export b1 "${LXD_DIR}/c2.tar.gz"
+  tar -tzf "${LXD_DIR}/c2.tar.gz" | grep -q "^backup/snapshots/snap0/"
+
+  # the temporary backups should have been removed
+  [ -z "$(ls "${LXD_DIR}/backups/b1")" ]
+
+  # the expiry date is accepted and reported under the same name
+  op=$(my_curl -X POST "https://${LXD_ADDR}/1.0/containers/b1/backups" -d '{"name": "manual", "expiry_date": "2030-01-01T00:00:00Z"}' | jq -r .operation)
+  my_curl "https://${LXD_ADDR}${op}/wait"
+  [ "$(my_curl "https://${LXD_ADDR}/1.0/containers/b1/backups/manual" | jq -r .metadata.expiry_date)" = "2030-01-01T00:00:00Z" ]
+  op=$(my_curl -X DELETE "https://${LXD_ADDR}/1.0/containers/b1/backups/manual" | jq -r .operation)
+  my_curl "https://${LXD_ADDR}${op}/wait"
+
+  # importing over an existing container fails
+  ! lxc import "${LXD_DIR}/c2.tar.gz"
+
+  lxc delete --force b1
+  lxc import "${LXD_DIR}/c2.tar.gz"
+  lxc info b1 | grep snap0
+  lxc start b1
+  lxc list | grep b1 | grep RUNNING
+  lxc delete --force b1
+
+  lxc import "${LXD_DIR}/c1.tar.gz"
+  ! lxc info b1 | grep snap0
+  lxc delete --force b1
+
+  rm -f "${LXD_DIR}/c1.tar.gz" "${LXD_DIR}/c2.tar.gz"
+}
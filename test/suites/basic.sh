@@ -38,6 +38,9 @@ test_basic_usage() {
   my_curl -f -X GET "https://${LXD_ADDR}/1.0"
   my_curl -f -X GET "https://${LXD_ADDR}/1.0/containers"
 
+  # test the metrics endpoint
+  my_curl -f -X GET "https://${LXD_ADDR}/1.0/metrics" | grep -q "^# EOF$"
+
   # Re-import the image
   mv "${LXD_DIR}/${sum}.tar.xz" "${LXD_DIR}/testimage.tar.xz"
   lxc image import "${LXD_DIR}/testimage.tar.xz" --alias testimage
@@ -116,6 +119,17 @@ test_basic_usage() {
   curl -k -s --cert "${LXD_CONF}/client3.crt" --key "${LXD_CONF}/client3.key" -X GET "https://${LXD_ADDR}/1.0/images" | grep "/1.0/images/" && false
   lxc image delete foo-image-compressed
 
+  # Test zstd and compression levels on publish
+  if which zstd >/dev/null 2>&1; then
+    lxc publish bar --alias=foo-image-zstd --compression=zstd:19
+    lxc image export foo-image-zstd "${LXD_DIR}/zstd" | grep -q "tar.zst"
+    rm -f "${LXD_DIR}"/zstd*
+    lxc init foo-image-zstd zstd
+    lxc delete zstd
+    lxc image delete foo-image-zstd
+  fi
+  ! lxc publish bar --alias=foo-image-compressed --compression=gzip:10
+
 
   # Test privileged container publish
   lxc profile create priv
@@ -0,0 +1,80 @@
+#!/bin/sh
+
+lxd_socket_curl() {
+  curl -s --unix-socket "${LXD_DIR}/unix.socket" "$@"
+}
+
+# Changes reach the other members in the background, retry for a while
+cluster_wait() {
+  for _ in $(seq 20); do
+    if "$@"; then
+      return 0
+    fi
+    sleep 0.5
+  done
+
+  return 1
+}
+
+test_clustering() {
+  LXD_ONE_DIR=$(mktemp -d -p "${TEST_DIR}" XXX)
+  chmod +x "${LXD_ONE_DIR}"
+  spawn_lxd "${LXD_ONE_DIR}" true
+  LXD_ONE_ADDR=$(cat "${LXD_ONE_DIR}/lxd.addr")
+
+  LXD_TWO_DIR=$(mktemp -d -p "${TEST_DIR}" XXX)
+  chmod +x "${LXD_TWO_DIR}"
+  spawn_lxd "${LXD_TWO_DIR}" true
+
+  # Bootstrap the cluster
+  LXD_DIR="${LXD_ONE_DIR}" lxd_socket_curl -X PUT lxd/1.0/cluster -d '{"server_name": "node1", "enabled": true}'
+  LXD_DIR="${LXD_ONE_DIR}" lxd_socket_curl lxd/1.0/cluster | jq -r .metadata.server_name | grep -q "^node1$"
+  LXD_DIR="${LXD_ONE_DIR}" lxd_socket_curl lxd/1.0/cluster | jq -r .metadata.enabled | grep -q "^true$"
+
+  # Objects existing before the join are copied
+  LXD_DIR="${LXD_ONE_DIR}" lxc project create early features.profiles=true
+  LXD_DIR="${LXD_ONE_DIR}" lxd_socket_curl -X POST "lxd/1.0/profiles?project=early" -d '{"name": "early-profile"}'
+  LXD_DIR="${LXD_ONE_DIR}" lxc network acl create early-acl
+
+  # Join it through the first member
+  req=$(jq -n --arg addr "${LXD_ONE_ADDR}" --arg cert "$(cat "${LXD_ONE_DIR}/server.crt")" \
+    '{"server_name": "node2", "enabled": true, "cluster_address": $addr, "cluster_certificate": $cert, "cluster_password": "foo"}')
+  LXD_DIR="${LXD_TWO_DIR}" lxd_socket_curl -X PUT lxd/1.0/cluster -d "${req}"
+  LXD_DIR="${LXD_TWO_DIR}" lxd_socket_curl lxd/1.0/cluster/members | jq -r '.metadata[]' | grep -q "/1.0/cluster/members/node1$"
+  LXD_DIR="${LXD_TWO_DIR}" lxd_socket_curl lxd/1.0/cluster/members | jq -r '.metadata[]' | grep -q "/1.0/cluster/members/node2$"
+
+  LXD_DIR="${LXD_TWO_DIR}" lxc project list | grep -q early
+  LXD_DIR="${LXD_TWO_DIR}" lxd_socket_curl "lxd/1.0/profiles?project=early" | jq -r '.metadata[]' | grep -q "/1.0/profiles/early-profile"
+  LXD_DIR="${LXD_TWO_DIR}" lxc network acl list | grep -q early-acl
+
+  # Profiles, projects and network ACLs are replicated
+  LXD_DIR="${LXD_ONE_DIR}" lxc profile create clustered
+  cluster_wait sh -c "LXD_DIR=${LXD_TWO_DIR} lxc profile list | grep -q clustered"
+  LXD_DIR="${LXD_ONE_DIR}" lxc project create clustered
+  cluster_wait sh -c "LXD_DIR=${LXD_TWO_DIR} lxc project list | grep -q clustered"
+  LXD_DIR="${LXD_ONE_DIR}" lxc network acl create clustered
+  cluster_wait sh -c "LXD_DIR=${LXD_TWO_DIR} lxc network acl list | grep -q clustered"
+  LXD_DIR="${LXD_TWO_DIR}" lxc network acl delete clustered
+  cluster_wait sh -c "! LXD_DIR=${LXD_ONE_DIR} lxc network acl list | grep -q clustered"
+
+  # Containers are visible and managed from any member
+  LXD_DIR="${LXD_ONE_DIR}" ensure_import_testimage
+  LXD_DIR="${LXD_ONE_DIR}" lxc init testimage c1
+  LXD_DIR="${LXD_TWO_DIR}" lxc list -c nL | grep c1 | grep -q node1
+  LXD_DIR="${LXD_TWO_DIR}" lxc config set c1 user.foo bar
+  LXD_DIR="${LXD_ONE_DIR}" lxc config get c1 user.foo | grep -q bar
+
+  # Members hosting containers can't be removed
+  ! LXD_DIR="${LXD_TWO_DIR}" lxd_socket_curl -X DELETE lxd/1.0/cluster/members/node1 | jq -r .type | grep -q "^sync$"
+  LXD_DIR="${LXD_TWO_DIR}" lxc delete c1
+  ! LXD_DIR="${LXD_ONE_DIR}" lxc list | grep -q c1
+
+  # Leave the cluster
+  LXD_DIR="${LXD_ONE_DIR}" lxd_socket_curl -X DELETE lxd/1.0/cluster/members/node2
+  LXD_DIR="${LXD_TWO_DIR}" lxd_socket_curl lxd/1.0/cluster | jq -r .metadata.enabled | grep -q "^false$"
+  LXD_DIR="${LXD_ONE_DIR}" lxd_socket_curl lxd/1.0/cluster/members | jq -r '.metadata[]' | grep -q "/1.0/cluster/members/node1$"
+  ! LXD_DIR="${LXD_ONE_DIR}" lxd_socket_curl lxd/1.0/cluster/members | jq -r '.metadata[]' | grep -q "node2"
+
+  kill_lxd "${LXD_ONE_DIR}"
+  kill_lxd "${LXD_TWO_DIR}"
+}
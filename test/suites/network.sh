@@ -39,6 +39,149 @@ test_network() {
 
   [ "${SUCCESS}" = "0" ] && (echo "Container static IP wasn't applied" && false)
 
+  # Static assignments show up as leases
+  lxc network list-leases lxdt$$ | grep -q "${v4_addr}"
+  lxc network list-leases lxdt$$ | grep -q "STATIC"
+
+  # DHCP reservations for hosts outside of LXD
+  res_addr="$(lxc network get lxdt$$ ipv4.address | cut -d/ -f1)1"
+  lxc network reservation create lxdt$$ 00:16:3E:00:00:01 ipv4_address="${res_addr}" hostname=printer
+  grep -q "^00:16:3e:00:00:01,id:\*,${res_addr},printer$" "${LXD_DIR}/networks/lxdt$$/dnsmasq.hosts"
+  grep -q "${v4_addr}.*nettest" "${LXD_DIR}/networks/lxdt$$/dnsmasq.hosts"
+  lxc network reservation list lxdt$$ | grep -q printer
+  lxc network list-leases lxdt$$ | grep -q "${res_addr}"
+  ! lxc network reservation create lxdt$$ 00:16:3e:00:00:01 ipv4_address="${res_addr}" || false
+  ! lxc network reservation create lxdt$$ 00:16:3e:00:00:02 ipv4_address="${v4_addr}" || false
+  ! lxc network reservation create lxdt$$ 00:16:3e:00:00:02 ipv4_address=192.0.2.1 || false
+  ! lxc network reservation create lxdt$$ 00:16:3e:00:00:02 hostname=scanner || false
+  lxc network reservation delete lxdt$$ 00:16:3e:00:00:01
+  ! grep -q printer "${LXD_DIR}/networks/lxdt$$/dnsmasq.hosts" || false
+
+  # Extra DNS records
+  lxc network dns-record create lxdt$$ gateway 192.0.2.1 2001:db8::1
+  grep -q "^192.0.2.1 gateway.test gateway$" "${LXD_DIR}/networks/lxdt$$/dnsmasq.records"
+  grep -q "^2001:db8::1 gateway.test gateway$" "${LXD_DIR}/networks/lxdt$$/dnsmasq.records"
+  if which dig >/dev/null 2>&1; then
+    dig +short @"$(lxc network get lxdt$$ ipv4.address | cut -d/ -f1)" gateway.test | grep -q 192.0.2.1
+  fi
+  ! lxc network dns-record create lxdt$$ bad_name 192.0.2.1 || false
+  ! lxc network dns-record create lxdt$$ other 192.0.2.300 || false
+  lxc network dns-record delete lxdt$$ gateway
+  ! grep -q gateway "${LXD_DIR}/networks/lxdt$$/dnsmasq.records" || false
+
+  # Network forwards
+  lxc network forward create lxdt$$ 192.0.2.10 description=web
+  lxc network forward port add lxdt$$ 192.0.2.10 tcp 80,443 "${v4_addr}"
+  lxc network forward port add lxdt$$ 192.0.2.10 tcp 2222 "${v4_addr}" 22
+  lxc network forward list lxdt$$ | grep -q "2222/tcp"
+  ! lxc network forward create lxdt$$ 192.0.2.10 || false
+  ! lxc network forward create lxdt$$ 192.0.2.11 target_address=192.0.2.300 || false
+  ! lxc network forward port add lxdt$$ 192.0.2.10 tcp 80 "${v4_addr}" || false
+  ! lxc network forward port add lxdt$$ 192.0.2.10 sctp 8080 "${v4_addr}" || false
+  ! lxc network forward port add lxdt$$ 192.0.2.10 tcp 8080-8082 "${v4_addr}" 80-81 || false
+
+  # VLANs need an Open vSwitch bridge
+  ! lxc config device add nettest eth1 nic nictype=bridged parent=lxdt$$ vlan=10 || false
+  if which ovs-vsctl >/dev/null 2>&1; then
+    lxc network create lxdo$$ bridge.driver=openvswitch ipv4.address=none ipv6.address=none \
+      tunnel.t2.protocol=geneve tunnel.t2.remote=192.0.2.2 tunnel.t2.id=42 tunnel.t2.vlan=10
+    [ "$(ovs-vsctl get interface lxdo$$-t2 type)" = "geneve" ]
+    [ "$(ovs-vsctl get port lxdo$$-t2 tag)" = "10" ]
+
+    lxc config device add nettest eth1 nic nictype=bridged parent=lxdo$$ vlan=10 vlan.tagged=20,30 host_name=vlt$$
+    [ "$(ovs-vsctl get port vlt$$ tag)" = "10" ]
+    [ "$(ovs-vsctl get port vlt$$ trunks)" = "[20, 30]" ]
+    lxc config device remove nettest eth1
+    lxc network delete lxdo$$
+  fi
+
+  # Routed and ipvlan nics need static addresses
+  ! lxc config device add nettest eth1 nic nictype=routed || false
+  ! lxc config device add nettest eth1 nic nictype=routed ipv4.address=2001:db8::2 || false
+  ! lxc config device add nettest eth1 nic nictype=ipvlan ipv4.address=192.0.2.2 || false
+  ! lxc config device add nettest eth1 nic nictype=bridged parent=lxdt$$ ipv4.gateway=none || false
+  lxc config device add nettest eth1 nic nictype=ipvlan parent=lxdt$$ ipv4.address=192.0.2.2 ipv6.gateway=none
+  lxc config device remove nettest eth1
+  if [ "$(cat /proc/sys/net/ipv4/ip_forward)" = "1" ]; then
+    lxc init testimage nettest-routed
+    lxc config device add nettest-routed eth0 nic nictype=routed ipv4.address=192.0.2.10 host_name=rtd$$
+    lxc start nettest-routed
+    ip -4 route show dev rtd$$ | grep -q "192.0.2.10"
+    lxc exec nettest-routed -- ip -4 addr show eth0 | grep -q "192.0.2.10/32"
+    lxc exec nettest-routed -- ip -4 route | grep -q "default via 169.254.0.1"
+    lxc delete -f nettest-routed
+    ! ip link show rtd$$ || false
+  fi
+
+  # SR-IOV nics need a parent with free virtual functions
+  ! lxc config device add nettest eth1 nic nictype=sriov || false
+  ! lxc config device add nettest eth1 nic nictype=sriov parent=eth0 ipv4.gateway=none || false
+  if modprobe netdevsim >/dev/null 2>&1 && echo "$$ 1" > /sys/bus/netdevsim/new_device; then
+    pf="$(ls "/sys/bus/netdevsim/devices/netdevsim$$/net/")"
+    echo 2 > "/sys/bus/netdevsim/devices/netdevsim$$/sriov_numvfs"
+    lxc init testimage nettest-sriov
+    lxc config device add nettest-sriov eth0 nic nictype=sriov parent="${pf}" vlan=10
+
+    # netdevsim doesn't create netdevs for its virtual functions
+    ! lxc start nettest-sriov || false
+    ! lxc config show nettest-sriov | grep -q "last_state.vf" || false
+    lxc delete -f nettest-sriov
+    echo "$$" > /sys/bus/netdevsim/del_device
+  fi
+
+  # Default bandwidth limits of the network
+  ! lxc network set lxdt$$ limits.ingress fast || false
+  lxc network set lxdt$$ limits.ingress 10Mbit
+  lxc network set lxdt$$ limits.egress 5Mbit
+  lxc info nettest | grep -q "Ingress limit: 10Mbit"
+  lxc info nettest | grep -q "Egress limit: 5Mbit"
+  lxc config device set nettest eth0 limits.ingress 2Mbit
+  lxc info nettest | grep -q "Ingress limit: 2Mbit"
+  lxc network unset lxdt$$ limits.ingress
+  lxc network unset lxdt$$ limits.egress
+  lxc config device unset nettest eth0 limits.ingress
+  ! lxc info nettest | grep -q "limit:" || false
+
+  # SLAAC only networks don't open DHCPv6
+  ! lxc network create lxds$$ ipv4.address=none ipv6.address=fd42:4242:4242:1010::1/64 ipv6.dhcp=false ipv6.dhcp.stateful=true || false
+  lxc network create lxds$$ ipv4.address=none ipv6.address=fd42:4242:4242:1010::1/64 ipv6.dhcp=false
+  ! ip6tables -w -S INPUT | grep "generated for LXD network lxds$$" | grep -q "dport 546" || false
+  lxc network delete lxds$$
+
+  # Delegated IPv6 prefixes
+  ! lxc network create lxdd$$ ipv6.address=fd42::1/64 ipv6.delegated.subnet_id=1 || false
+  ip link add dummy$$ type dummy
+  ip link set dummy$$ up
+  ip -6 route add unreachable 2001:db8:1200::/56 proto dhcp
+  lxc network create lxdd$$ ipv4.address=none ipv6.address=delegated:dummy$$
+  [ "$(lxc network get lxdd$$ ipv6.delegated.subnet_id)" = "1" ]
+  ip -6 addr show dev lxdd$$ | grep -q "2001:db8:1200:1::1/64"
+  lxc network set lxdd$$ ipv6.delegated.subnet_id 5
+  ip -6 addr show dev lxdd$$ | grep -q "2001:db8:1200:5::1/64"
+  lxc network delete lxdd$$
+  ip -6 route del unreachable 2001:db8:1200::/56 proto dhcp
+  ip link del dummy$$
+
+  # Runtime state of the bridge
+  lxc network info lxdt$$ | grep -q "State: up"
+  ! lxc network info lxdbr-missing$$ || false
+
+  # Switching firewall backends moves the rules over
+  ! lxc config set core.firewall pf || false
+  lxc config set core.firewall xtables
+  lxc info | grep -q "firewall: xtables"
+  iptables -w -t nat -S | grep -q "generated for LXD network lxdt$$"
+  iptables -w -t nat -S "lxd_fwd_lxdt$$" | grep -q "to-destination ${v4_addr}:22"
+  if which nft >/dev/null 2>&1; then
+    lxc config set core.firewall nftables
+    nft list table inet "lxd_lxdt$$" | grep -q masquerade
+    nft list chain inet "lxd_lxdt$$" fwd_prerouting | grep -q "dnat ip to ${v4_addr}:22"
+    ! iptables -w -t nat -S | grep -q "generated for LXD network lxdt$$" || false
+  fi
+  lxc config unset core.firewall
+  lxc network forward delete lxdt$$ 192.0.2.10
+  ! iptables -w -t nat -S "lxd_fwd_lxdt$$" || false
+
   lxc delete nettest -f
   lxc network delete lxdt$$
 }
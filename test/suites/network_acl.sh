@@ -0,0 +1,87 @@
+#!/bin/sh
+
+network_acl_rules() {
+  if [ "$(lxc info | awk '/firewall:/ {print $2}')" = "nftables" ]; then
+    nft list table inet "lxd_$1"
+  else
+    iptables -w -S "lxd_acl_$1"
+  fi
+}
+
+network_acl_nic_rules() {
+  if [ "$(lxc info | awk '/firewall:/ {print $2}')" = "nftables" ]; then
+    nft list table bridge "lxd_$1" || true
+  else
+    ebtables -L --Lx
+  fi
+}
+
+test_network_acl() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  # Creation and validation
+  lxc network acl create lxdt$$ "Test ACL"
+  lxc network acl list | grep -q "lxdt$$"
+  ! lxc network acl create lxdt$$ || false
+  ! lxc network acl create "bad/name$$" || false
+
+  cat <<EOF2 | lxc network acl edit lxdt$$
+description: Test ACL
+ingress:
+- action: allow
+  protocol: tcp
+  destination_port: 22,8000-8080
+- action: drop
+egress:
+- action: reject
+  destination: 192.0.2.0/24,2001:db8::/32
+EOF2
+  lxc network acl show lxdt$$ | grep -q "8000-8080"
+
+  ! echo "ingress: [{action: accept}]" | lxc network acl edit lxdt$$ || false
+  ! echo "ingress: [{action: allow, destination_port: 80}]" | lxc network acl edit lxdt$$ || false
+
+  # Attaching to a network renders it into its own chain
+  ! lxc network create lxdt$$ security.acls=missing$$ || false
+  lxc network create lxdt$$ ipv6.address=none security.acls=lxdt$$
+  network_acl_rules lxdt$$ | grep -q "8000"
+  lxc network acl show lxdt$$ | grep -q "/1.0/networks/lxdt$$"
+
+  # ACLs in use can't be renamed or removed
+  ! lxc network acl rename lxdt$$ lxdt$$-new || false
+  ! lxc network acl delete lxdt$$ || false
+
+  # Updates are applied to running networks
+  echo "ingress: [{action: drop, protocol: udp, destination_port: 5353}]" | lxc network acl edit lxdt$$
+  network_acl_rules lxdt$$ | grep -q "5353"
+  ! network_acl_rules lxdt$$ | grep -q "8000" || false
+
+  # Attaching to a bridged nic
+  lxc init testimage nettest
+  lxc config device add nettest eth0 nic nictype=bridged parent=lxdt$$ security.acls=lxdt$$
+  ! lxc config device set nettest eth0 security.acls=missing$$ || false
+  lxc network acl show lxdt$$ | grep -q "/1.0/containers/nettest"
+  lxc start nettest
+  network_acl_nic_rules lxdt$$ | grep -q "dport 5353"
+  lxc stop nettest --force
+  ! network_acl_nic_rules lxdt$$ | grep -q "dport 5353" || false
+  lxc delete nettest
+
+  # Profiles referencing the ACL keep it in use
+  lxc profile create lxdt$$
+  lxc profile device add lxdt$$ eth0 nic nictype=bridged parent=lxdt$$ security.acls=lxdt$$
+  lxc network acl show lxdt$$ | grep -q "/1.0/profiles/lxdt$$"
+  lxc network unset lxdt$$ security.acls
+  ! lxc network acl rename lxdt$$ lxdt$$-new || false
+  ! lxc network acl delete lxdt$$ || false
+  lxc profile delete lxdt$$
+
+  # Detaching removes the chain
+  ! network_acl_rules lxdt$$ | grep -q "5353" || false
+  lxc network delete lxdt$$
+
+  lxc network acl rename lxdt$$ lxdt$$-new
+  lxc network acl delete lxdt$$-new
+  ! lxc network acl list | grep -q "lxdt$$" || false
+}
@@ -0,0 +1,69 @@
+#!/bin/sh
+
+test_projects() {
+  ensure_import_testimage
+
+  # Create a project using its own profiles but the default images
+  lxc project create foo features.images=false
+  lxc project list | grep foo
+  lxc project show foo | grep "/1.0/profiles/default?project=foo"
+
+  # Project names can't contain underscores
+  ! lxc project create foo_bar
+
+  # Switch to the new project
+  lxc project switch foo
+  lxc project list | grep "foo (current)"
+
+  # The project has its own default profile and sees the default images
+  lxc profile list | grep default
+  ! lxc profile show docker
+  lxc profile create extra
+  lxc image list | grep testimage
+
+  # Containers of different projects don't clash
+  lxc profile device add default root disk path="/" pool="lxdtest-$(basename "${LXD_DIR}")"
+  lxc init testimage c1 -p default -p extra
+  lxc list | grep c1
+  lxc project show foo | grep "/1.0/containers/c1?project=foo"
+
+  # Snapshots are restored within the project
+  lxc config set c1 user.state before
+  lxc snapshot c1 snap0
+  lxc config set c1 user.state after
+
+  lxc project switch default
+  ! lxc list | grep c1
+  ! lxc profile show extra
+  lxc init testimage c1
+  lxc config set c1 user.state default
+  lxc snapshot c1 snap0
+
+  lxc project switch foo
+  lxc restore c1 snap0
+  lxc config get c1 user.state | grep before
+  lxc config set c1 user.state after
+  lxc restore c1 c1/snap0
+  lxc config get c1 user.state | grep before
+  lxc project switch default
+
+  # The container of the default project is untouched
+  lxc config get c1 user.state | grep default
+  lxc delete c1
+
+  # Features and non-empty projects can't be changed or removed
+  ! lxc project set foo features.images true
+  ! lxc project delete foo
+  ! lxc project delete default
+
+  lxc project switch foo
+  lxc delete c1
+  lxc profile delete extra
+  lxc project switch default
+
+  # Empty projects can switch features and be removed
+  lxc project set foo features.images true
+  lxc project get foo features.images | grep true
+  lxc project delete foo
+  ! lxc project list | grep foo
+}
@@ -0,0 +1,28 @@
+#!/bin/sh
+
+test_proxy_device() {
+  ensure_import_testimage
+
+  HOST_TCP_PORT=$(local_tcp_port)
+  lxc launch testimage proxyTester
+  lxc config device add proxyTester proxyDev proxy "listen=tcp:127.0.0.1:${HOST_TCP_PORT}" connect=tcp:127.0.0.1:4321
+
+  # Check that connections are forwarded into the container
+  lxc exec proxyTester -- nc -l -p 4321 > "${TEST_DIR}/proxy-output" &
+  NC_PID=$!
+  sleep 1
+
+  echo "hello from the host" | nc -w 1 127.0.0.1 "${HOST_TCP_PORT}"
+  wait "${NC_PID}" || true
+  grep -q "hello from the host" "${TEST_DIR}/proxy-output"
+
+  # Check that invalid devices are refused
+  ! lxc config device add proxyTester badDev proxy listen=udp:127.0.0.1:4322 connect=tcp:127.0.0.1:4321 || false
+
+  # Check that the listener goes away with the device
+  lxc config device remove proxyTester proxyDev
+  ! nc -z 127.0.0.1 "${HOST_TCP_PORT}" || false
+
+  rm -f "${TEST_DIR}/proxy-output"
+  lxc delete -f proxyTester
+}
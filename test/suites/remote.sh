@@ -81,6 +81,15 @@ test_remote_usage() {
   lxc_remote image import "${LXD_DIR}/${img}" localhost: --public
   lxc_remote image alias create localhost:testimage "${sum}"
 
+  # public split images are also served over simplestreams
+  deps/import-busybox --split --public --alias splitimage
+  split_sum=$(lxc image info splitimage | grep ^Fingerprint | cut -d' ' -f2)
+  my_curl -f "https://${LXD_ADDR}/streams/v1/index.json" | grep -q "streams/v1/images.json"
+  my_curl -f "https://${LXD_ADDR}/streams/v1/images.json" | grep -q "${split_sum}"
+  ! my_curl -f "https://${LXD_ADDR}/streams/v1/images.json" | grep -q "${sum}" || false
+  ! my_curl -f -X POST "https://${LXD_ADDR}/streams/v1/images.json"
+  lxc image delete splitimage
+
   lxc_remote image delete "lxd2:${sum}" || true
 
   lxc_remote image copy localhost:testimage lxd2: --copy-aliases --public
@@ -93,8 +102,18 @@ test_remote_usage() {
   lxc_remote image delete "lxd2:${sum}"
 
   lxc_remote image copy "localhost:$(echo "${sum}" | colrm 3)" lxd2:
+  lxc_remote image info "lxd2:${sum}" | grep -q "Signature: unverified"
   lxc_remote image delete "lxd2:${sum}"
 
+  # unverified images are refused when signatures are required
+  lxc_remote config set lxd2: images.require_signature true
+  ! lxc_remote image copy "localhost:${sum}" lxd2:
+  lxc_remote config unset lxd2: images.require_signature
+
+  # only valid keys can be trusted
+  ! lxc_remote config set lxd2: images.trusted_keys "not a key"
+  ! lxc_remote config get lxd2: images.trusted_keys | grep -q "not a key"
+
   # test a private image
   lxc_remote image copy "localhost:${sum}" lxd2:
   lxc_remote image delete "localhost:${sum}"
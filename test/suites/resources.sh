@@ -0,0 +1,12 @@
+#!/bin/sh
+
+test_resources() {
+  RES=$(lxc info --resources)
+  echo "${RES}" | grep -q "CPUs:"
+  echo "${RES}" | grep -q "Threads:"
+  echo "${RES}" | grep -q "Memory:"
+
+  # Every storage pool is listed with its usage
+  echo "${RES}" | grep -q "Storage pools:"
+  echo "${RES}" | grep -q "Space total:"
+}
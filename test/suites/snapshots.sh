@@ -180,3 +180,42 @@ restore_and_compare_fs() {
     diff -r "${LXD_DIR}/containers/bar/rootfs" "${LXD_DIR}/snapshots/bar/${snap}/rootfs"
   fi
 }
+
+test_snap_schedule() {
+  ensure_import_testimage
+
+  lxc init testimage foo
+
+  # Invalid schedules, patterns and expiries are refused
+  ! lxc config set foo snapshots.schedule "* * *" || false
+  ! lxc config set foo snapshots.pattern "daily" || false
+  ! lxc config set foo snapshots.pattern "%s-%d" || false
+  ! lxc config set foo snapshots.pattern "%d-%d" || false
+  ! lxc config set foo snapshots.expiry "2x" || false
+
+  lxc config set foo snapshots.schedule "@daily"
+  lxc config set foo snapshots.pattern "daily-%d"
+  lxc config set foo snapshots.expiry "1w"
+
+  # Manual snapshots get the configured expiry too
+  lxc snapshot foo
+  lxc info foo | grep "snap0" | grep "expires at"
+
+  # Expired snapshots get pruned
+  lxc config set foo snapshots.schedule "* * * * *"
+  lxc config set foo snapshots.schedule.stopped true
+  lxc config set foo snapshots.expiry "1M"
+  for _ in $(seq 150); do
+    lxc info foo | grep -q "daily-0" && break
+    sleep 1
+  done
+  lxc info foo | grep -q "daily-0"
+
+  for _ in $(seq 150); do
+    lxc info foo | grep -q "daily-0" || break
+    sleep 1
+  done
+  ! lxc info foo | grep -q "daily-0" || false
+
+  lxc delete -f foo
+}
@@ -63,6 +63,14 @@ test_storage() {
 
     lxc storage delete "lxdtest-$(basename "${LXD_DIR}")-pool5_under_lxd_dir"
 
+    # Check that ceph specific keys are rejected for other drivers.
+    ! lxc storage create "lxdtest-$(basename "${LXD_DIR}")-pool5_ceph_keys" dir ceph.osd.pool_name=foo
+    ! lxc storage create "lxdtest-$(basename "${LXD_DIR}")-pool5_ceph_keys" dir ceph.cluster_name=foo
+    ! lxc storage create "lxdtest-$(basename "${LXD_DIR}")-pool5_ceph_keys" dir ceph.user.name=foo
+
+    # Check that volatile keys can't be set by users.
+    ! lxc storage create "lxdtest-$(basename "${LXD_DIR}")-pool5_volatile_keys" dir volatile.pool.pristine=true
+
     if which lvdisplay >/dev/null 2>&1; then
       # Create lvm pool.
       configure_loop_device loop_file_3 loop_device_3
@@ -0,0 +1,82 @@
+#!/bin/sh
+
+test_storage_volume_handling() {
+  # shellcheck disable=2039
+
+  LXD_STORAGE_DIR=$(mktemp -d -p "${TEST_DIR}" XXXXXXXXX)
+  chmod +x "${LXD_STORAGE_DIR}"
+  spawn_lxd "${LXD_STORAGE_DIR}" false
+  (
+    set -e
+    # shellcheck disable=2030
+    LXD_DIR="${LXD_STORAGE_DIR}"
+
+    pool1="lxdtest-$(basename "${LXD_DIR}")-pool1"
+    pool2="lxdtest-$(basename "${LXD_DIR}")-pool2"
+    lxc storage create "${pool1}" dir
+    lxc storage create "${pool2}" dir
+
+    lxc storage volume create "${pool1}" vol1
+    echo "hello" > "${LXD_DIR}/storage-pools/${pool1}/custom/vol1/data"
+
+    # Snapshots
+    lxc storage volume snapshot "${pool1}" vol1
+    lxc storage volume snapshot "${pool1}" vol1 snap1
+    [ -f "${LXD_DIR}/storage-pools/${pool1}/custom-snapshots/vol1/snap0/data" ]
+    [ -f "${LXD_DIR}/storage-pools/${pool1}/custom-snapshots/vol1/snap1/data" ]
+    ! lxc storage volume snapshot "${pool1}" vol1 snap1 || false
+
+    # Snapshots aren't listed as volumes
+    ! lxc storage volume list "${pool1}" | grep -q snap0 || false
+
+    # Names containing the snapshot delimiter are refused
+    ! lxc storage volume create "${pool1}" "vol1/snap2" || false
+
+    # Copy on the same pool, with and without snapshots
+    lxc storage volume copy "${pool1}/vol1" "${pool1}/vol2"
+    [ -f "${LXD_DIR}/storage-pools/${pool1}/custom/vol2/data" ]
+    [ -d "${LXD_DIR}/storage-pools/${pool1}/custom-snapshots/vol2/snap1" ]
+    lxc storage volume copy "${pool1}/vol1" "${pool1}/vol3" --volume-only
+    [ -f "${LXD_DIR}/storage-pools/${pool1}/custom/vol3/data" ]
+    [ ! -d "${LXD_DIR}/storage-pools/${pool1}/custom-snapshots/vol3" ]
+    ! lxc storage volume copy "${pool1}/vol1" "${pool1}/vol2" || false
+
+    # Copy to another pool
+    lxc storage volume copy "${pool1}/vol1" "${pool2}/vol1"
+    [ -f "${LXD_DIR}/storage-pools/${pool2}/custom/vol1/data" ]
+    [ -d "${LXD_DIR}/storage-pools/${pool2}/custom-snapshots/vol1/snap0" ]
+
+    # Rename
+    lxc storage volume move "${pool1}/vol2" "${pool1}/vol4"
+    [ -f "${LXD_DIR}/storage-pools/${pool1}/custom/vol4/data" ]
+    [ -d "${LXD_DIR}/storage-pools/${pool1}/custom-snapshots/vol4/snap1" ]
+    [ ! -d "${LXD_DIR}/storage-pools/${pool1}/custom/vol2" ]
+    [ ! -d "${LXD_DIR}/storage-pools/${pool1}/custom-snapshots/vol2" ]
+
+    # Move to another pool
+    lxc storage volume move "${pool1}/vol4" "${pool2}/vol4"
+    [ -f "${LXD_DIR}/storage-pools/${pool2}/custom/vol4/data" ]
+    [ ! -d "${LXD_DIR}/storage-pools/${pool1}/custom/vol4" ]
+
+    # Attached volumes can't be moved
+    ensure_import_testimage
+    lxc init testimage c1
+    lxc storage volume attach "${pool1}" vol1 c1 /mnt
+    ! lxc storage volume move "${pool1}/vol1" "${pool1}/vol5" || false
+    lxc delete -f c1
+
+    # Deleting a volume deletes its snapshots
+    lxc storage volume delete "${pool1}" vol1
+    [ ! -d "${LXD_DIR}/storage-pools/${pool1}/custom-snapshots/vol1" ]
+
+    lxc storage volume delete "${pool1}" vol3
+    lxc storage volume delete "${pool2}" vol1
+    lxc storage volume delete "${pool2}" vol4
+    lxc storage delete "${pool1}"
+    lxc storage delete "${pool2}"
+  )
+
+  # shellcheck disable=SC2031
+  LXD_DIR="${LXD_DIR}"
+  kill_lxd "${LXD_STORAGE_DIR}"
+}
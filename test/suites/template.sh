@@ -86,4 +86,50 @@ test_template() {
   # Cleanup
   lxc image delete template-test
   lxc delete template template1 --force
+
+
+  # Add a template to an existing container through its metadata
+  ensure_import_testimage
+  lxc init testimage template
+  echo "name: {{ container.name }}" | lxc config template create template template.tpl
+  lxc config template list template | grep -q "^template.tpl$"
+  lxc config template show template template.tpl | grep -q "container.name"
+  ! echo "" | lxc config template create template template.tpl
+
+  lxc config metadata show template | sed "/^templates:/d" > "${LXD_DIR}/metadata.yaml"
+  cat >> "${LXD_DIR}/metadata.yaml" << EOF
+templates:
+  /template:
+    when:
+    - start
+    template: template.tpl
+EOF
+  lxc config metadata edit template < "${LXD_DIR}/metadata.yaml"
+  rm "${LXD_DIR}/metadata.yaml"
+  lxc config metadata show template | grep -q "template: template.tpl"
+
+  # Validate that the template is applied
+  lxc start template
+  lxc file pull template/template - | grep "^name: template$"
+
+  # Symlinks in the templates directory aren't followed
+  ln -s /etc/hostname "${LXD_DIR}/containers/template/templates/link.tpl"
+  ! lxc config template show template link.tpl || false
+  ! echo "" | lxc config template edit template link.tpl || false
+  rm "${LXD_DIR}/containers/template/templates/link.tpl"
+  lxc stop template --force
+
+  # And that it's carried over by publish
+  lxc publish template --alias template-test
+  lxc launch template-test template1
+  lxc file pull template1/template - | grep "^name: template1$"
+
+  echo "hostname: {{ container.name }}" | lxc config template edit template template.tpl
+  lxc config template show template template.tpl | grep -q "^hostname:"
+  lxc config template delete template template.tpl
+  ! lxc config template show template template.tpl
+
+  # Cleanup
+  lxc image delete template-test
+  lxc delete template template1 --force
 }